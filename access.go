@@ -0,0 +1,36 @@
+package smd
+
+import "sort"
+
+// AccessWindows returns the visibility windows, from the acquisition (AOS) to the loss (LOS) of signal, of the
+// spacecraft of the provided states by each station, sorted by AOS. Unlike the passes of a ContactScheduler, the
+// windows of all stations are returned regardless of their antennas. A window still open at the last state ends
+// there.
+func AccessWindows(states []State, stations ...Station) []TrackingPass {
+	var windows []TrackingPass
+	for _, st := range stations {
+		var window *TrackingPass
+		for _, state := range states {
+			visible, el := st.inView(state)
+			if !visible {
+				if window != nil {
+					windows = append(windows, *window)
+					window = nil
+				}
+				continue
+			}
+			if window == nil {
+				window = &TrackingPass{Station: st.Name, Spacecraft: state.SC.Name, Start: state.DT, MaxElevation: el}
+			}
+			window.End = state.DT
+			if el > window.MaxElevation {
+				window.MaxElevation = el
+			}
+		}
+		if window != nil {
+			windows = append(windows, *window)
+		}
+	}
+	sort.Stable(passesByStart(windows))
+	return windows
+}
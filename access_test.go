@@ -0,0 +1,46 @@
+package smd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccessWindows(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMultiMission(start, start.Add(24*time.Hour), time.Minute)
+	m.Add(NewEmptySC("leo", 0), NewOrbitFromOE(Earth.Radius+800, 0, 60, 0, 0, 0, Earth), Perturbations{}, ExportConfig{})
+	m.Propagate()
+	st1 := NewStation("st1", 0, 10, 40, -105, 0, 0)
+	st2 := NewStation("st2", 0, 10, 45, 10, 0, 0)
+	windows := AccessWindows(m.History[0], st1, st2)
+	if len(windows) < 4 {
+		t.Fatalf("only %d windows in a day", len(windows))
+	}
+	perStation := make(map[string]int)
+	for i, w := range windows {
+		perStation[w.Station]++
+		if i > 0 && w.Start.Before(windows[i-1].Start) {
+			t.Fatal("windows are not sorted")
+		}
+		if w.Spacecraft != "leo" || w.End.Before(w.Start) || w.Duration() > 20*time.Minute {
+			t.Fatalf("invalid window %s", w)
+		}
+		if w.MaxElevation < 10 || w.MaxElevation > 90 {
+			t.Fatalf("max elevation of %s", w)
+		}
+	}
+	if perStation["st1"] == 0 || perStation["st2"] == 0 {
+		t.Fatalf("windows per station: %+v", perStation)
+	}
+	// With a single spacecraft, the scheduler allocates all the windows.
+	passes := NewContactScheduler(st1).Schedule(m.History, []string{"leo"})
+	st1Windows := AccessWindows(m.History[0], st1)
+	if len(passes) != len(st1Windows) {
+		t.Fatalf("%d passes but %d windows", len(passes), len(st1Windows))
+	}
+	for i := range passes {
+		if !passes[i].Start.Equal(st1Windows[i].Start) || passes[i].MaxElevation != st1Windows[i].MaxElevation {
+			t.Fatalf("pass %s differs from window %s", passes[i], st1Windows[i])
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	aeroPassSteps        = 2000 // Number of integration steps through the atmosphere.
+	aerobrakingMaxPasses = 10000
+	corridorBisections   = 60
+)
+
+// AeroPass stores the effect of one atmospheric pass, modeled as an impulsive drag Δv at periapsis computed
+// by integrating the drag along the unperturbed conic within the atmosphere.
+type AeroPass struct {
+	Periapsis           float64       // Periapsis altitude (km)
+	ApoapsisBefore      float64       // Apoapsis altitude (km) before the pass, +Inf if hyperbolic
+	ApoapsisAfter       float64       // Apoapsis altitude (km) after the pass, +Inf if hyperbolic
+	ΔV                  float64       // Velocity lost to drag (km/s)
+	PeakDynamicPressure float64       // Peak dynamic pressure (N/m²)
+	PeakHeatRate        float64       // Peak free molecular heat rate proxy ½ρv³ (W/m²)
+	HeatLoad            float64       // Integrated heat rate proxy (J/m²)
+	Duration            time.Duration // Time spent within the atmosphere model
+	After               *Orbit        // Orbit after the pass, at periapsis
+}
+
+// Captured returns whether the orbit is closed after the pass.
+func (p AeroPass) Captured() bool {
+	return !math.IsInf(p.ApoapsisAfter, 1)
+}
+
+// ApoapsisReduction returns the apoapsis reduction of this pass (km).
+func (p AeroPass) ApoapsisReduction() float64 {
+	return p.ApoapsisBefore - p.ApoapsisAfter
+}
+
+func (p AeroPass) String() string {
+	return fmt.Sprintf("hp=%.3f km ha=%.3f->%.3f km Δv=%.3f m/s q=%.4f N/m² heat=%.1f W/m²", p.Periapsis, p.ApoapsisBefore, p.ApoapsisAfter, p.ΔV*1e3, p.PeakDynamicPressure, p.PeakHeatRate)
+}
+
+// AerobrakingPass returns the effect of the next atmospheric pass of the orbit about the Earth, Mars or Venus.
+// The orbit may be hyperbolic, in which case this is an aerocapture pass. The spacecraft must have a drag
+// coefficient and area.
+func AerobrakingPass(o Orbit, sc Spacecraft, sw SpaceWeather) AeroPass {
+	if sc.Cd <= 0 || sc.Area <= 0 {
+		panic("spacecraft Cd and Area must be strictly positive")
+	}
+	body := o.Origin
+	μ := body.μ
+	R, V := periapsisRV(o)
+	rP := Norm(R)
+	vP := Norm(V)
+	pass := AeroPass{Periapsis: rP - body.Radius, ApoapsisBefore: apoapsisAltitude(body, rP, vP)}
+	if rTop := body.Radius + atmosphereTop(body); rP < rTop {
+		// Ballistic factor in km²/kg, the density will be converted to kg/km³.
+		B := sc.Cd * sc.Area * 1e-6 / sc.Mass(time.Time{})
+		h := rP * vP
+		p := h * h / μ
+		e := p/rP - 1
+		νMax := math.Pi
+		if e > 0 {
+			νMax = math.Acos(math.Max(-1, (p/rTop-1)/e))
+		}
+		dν := 2 * νMax / aeroPassSteps
+		var duration float64
+		for k := 0; k <= aeroPassSteps; k++ {
+			ν := -νMax + float64(k)*dν
+			r := p / (1 + e*math.Cos(ν))
+			v := math.Sqrt(vP*vP - 2*μ*(1/rP-1/r))
+			ρ := AtmosphereDensity(body, r-body.Radius, sw)
+			dt := r * r / h * dν
+			if k == 0 || k == aeroPassSteps {
+				dt /= 2
+			}
+			heatRate := 0.5 * ρ * math.Pow(v*1e3, 3)
+			pass.ΔV += 0.5 * ρ * 1e9 * B * v * v * dt
+			pass.HeatLoad += heatRate * dt
+			pass.PeakHeatRate = math.Max(pass.PeakHeatRate, heatRate)
+			pass.PeakDynamicPressure = math.Max(pass.PeakDynamicPressure, 0.5*ρ*math.Pow(v*1e3, 2))
+			duration += dt
+		}
+		pass.Duration = time.Duration(duration * 1e9)
+	}
+	vAfter := math.Max(vP-pass.ΔV, 0)
+	pass.ApoapsisAfter = apoapsisAltitude(body, rP, vAfter)
+	for i := 0; i < 3; i++ {
+		V[i] *= vAfter / vP
+	}
+	pass.After = NewOrbitFromRV(R, V, body)
+	return pass
+}
+
+// periapsisRV returns the position and velocity at the periapsis of the orbit.
+func periapsisRV(o Orbit) (R, V []float64) {
+	μ := o.Origin.μ
+	r := o.R()
+	v := o.V()
+	rNorm := Norm(r)
+	vNorm := Norm(v)
+	eVec := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		eVec[i] = ((vNorm*vNorm-μ/rNorm)*r[i] - Dot(r, v)*v[i]) / μ
+	}
+	eNorm := Norm(eVec)
+	pHat := Unit(r)
+	if eNorm > eccentricityε {
+		pHat = Unit(eVec)
+	}
+	qHat := Cross(Unit(o.H()), pHat)
+	h := o.HNorm()
+	rP := h * h / μ / (1 + eNorm)
+	vP := h / rP
+	R = make([]float64, 3)
+	V = make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		R[i] = rP * pHat[i]
+		V[i] = vP * qHat[i]
+	}
+	return
+}
+
+// apoapsisAltitude returns the apoapsis altitude (km) from the periapsis radius and velocity.
+func apoapsisAltitude(body CelestialObject, rP, vP float64) float64 {
+	ξ := vP*vP/2 - body.μ/rP
+	if ξ >= 0 {
+		return math.Inf(1)
+	}
+	return -body.μ/ξ - rP - body.Radius
+}
+
+// AerobrakingCorridor returns the periapsis altitude corridor (km) of the orbit: the lower bound is limited by the
+// maximum heat rate proxy (W/m²) and the upper bound by the minimum useful apoapsis reduction per pass (km). The
+// apoapsis of the orbit is kept constant. An error is returned if the corridor is closed.
+func AerobrakingCorridor(o Orbit, sc Spacecraft, sw SpaceWeather, maxHeatRate, minΔrA float64) (minAlt, maxAlt float64, err error) {
+	R, V := periapsisRV(o)
+	rA := apoapsisAltitude(o.Origin, Norm(R), Norm(V)) + o.Origin.Radius
+	if math.IsInf(rA, 1) {
+		return 0, 0, errors.New("aerobraking corridor requires a closed orbit")
+	}
+	passAt := func(alt float64) AeroPass {
+		return AerobrakingPass(*periapsisOrbit(o, o.Origin.Radius+alt, rA), sc, sw)
+	}
+	minAlt = bisectAltitude(0, atmosphereTop(o.Origin), func(alt float64) bool {
+		return passAt(alt).PeakHeatRate <= maxHeatRate
+	})
+	maxAlt = bisectAltitude(0, atmosphereTop(o.Origin), func(alt float64) bool {
+		return passAt(alt).ApoapsisReduction() < minΔrA
+	})
+	if minAlt >= maxAlt {
+		err = fmt.Errorf("aerobraking corridor is closed: heat rate limited to %.3f km and apoapsis reduction to %.3f km", minAlt, maxAlt)
+	}
+	return
+}
+
+// bisectAltitude returns the lowest altitude between lo and hi at which the monotonic condition is verified.
+func bisectAltitude(lo, hi float64, ok func(float64) bool) float64 {
+	for k := 0; k < corridorBisections; k++ {
+		mid := (lo + hi) / 2
+		if ok(mid) {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi
+}
+
+// periapsisOrbit returns the orbit at periapsis with the same orientation as the provided orbit, and the
+// provided periapsis and apoapsis radii.
+func periapsisOrbit(o Orbit, rP, rA float64) *Orbit {
+	R, V := periapsisRV(o)
+	pHat := Unit(R)
+	qHat := Unit(V)
+	vP := math.Sqrt(o.Origin.μ * (2/rP - 2/(rP+rA)))
+	for i := 0; i < 3; i++ {
+		R[i] = rP * pHat[i]
+		V[i] = vP * qHat[i]
+	}
+	return NewOrbitFromRV(R, V, o.Origin)
+}
+
+// AerobrakingCampaign defines an aerobraking campaign which keeps the periapsis within a corridor until the
+// target apoapsis is reached.
+type AerobrakingCampaign struct {
+	MinPeriapsis, MaxPeriapsis float64 // Periapsis altitude corridor (km)
+	TargetApoapsis             float64 // Apoapsis altitude (km) which ends the campaign
+	SpaceWeather               SpaceWeather
+}
+
+// AerobrakingResult stores the outcome of an aerobraking campaign.
+type AerobrakingResult struct {
+	Passes       []AeroPass
+	Duration     time.Duration // Time from the first to the last pass
+	CorridorΔv   float64       // Total Δv of the corridor control maneuvers at apoapsis (km/s)
+	Maneuvers    int           // Number of corridor control maneuvers
+	PeakHeatRate float64       // Peak heat rate proxy of the campaign (W/m²)
+	Completed    bool          // Set to true if the target apoapsis was reached
+	Final        *Orbit        // Orbit at the last periapsis
+}
+
+func (r AerobrakingResult) String() string {
+	return fmt.Sprintf("%d passes in %s (completed: %v), corridor control: %d maneuvers for %.3f m/s, peak heat rate %.1f W/m²", len(r.Passes), r.Duration, r.Completed, r.Maneuvers, r.CorridorΔv*1e3, r.PeakHeatRate)
+}
+
+// Run runs the aerobraking campaign from the provided closed orbit. Before each pass, if the periapsis is outside
+// the corridor, a maneuver at apoapsis brings it back to the middle of the corridor.
+func (c AerobrakingCampaign) Run(o Orbit, sc Spacecraft) AerobrakingResult {
+	if c.MinPeriapsis >= c.MaxPeriapsis {
+		panic("aerobraking corridor minimum periapsis must be lower than its maximum")
+	}
+	body := o.Origin
+	res := AerobrakingResult{}
+	cur := &o
+	var elapsed float64
+	for len(res.Passes) < aerobrakingMaxPasses {
+		R, V := periapsisRV(*cur)
+		rP := Norm(R)
+		hA := apoapsisAltitude(body, rP, Norm(V))
+		if math.IsInf(hA, 1) {
+			panic("aerobraking campaign requires a closed orbit")
+		}
+		if hA <= c.TargetApoapsis {
+			res.Completed = true
+			break
+		}
+		rA := hA + body.Radius
+		if hP := rP - body.Radius; hP < c.MinPeriapsis || hP > c.MaxPeriapsis {
+			rPNew := body.Radius + (c.MinPeriapsis+c.MaxPeriapsis)/2
+			vA := math.Sqrt(body.μ * (2/rA - 2/(rA+rP)))
+			vANew := math.Sqrt(body.μ * (2/rA - 2/(rA+rPNew)))
+			res.CorridorΔv += math.Abs(vANew - vA)
+			res.Maneuvers++
+			cur = periapsisOrbit(*cur, rPNew, rA)
+		}
+		if len(res.Passes) > 0 {
+			elapsed += cur.Period().Seconds()
+		}
+		pass := AerobrakingPass(*cur, sc, c.SpaceWeather)
+		res.Passes = append(res.Passes, pass)
+		res.PeakHeatRate = math.Max(res.PeakHeatRate, pass.PeakHeatRate)
+		cur = pass.After
+	}
+	res.Duration = time.Duration(elapsed * 1e9)
+	res.Final = cur
+	return res
+}
@@ -0,0 +1,123 @@
+package smd
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func aerobrakingSC() Spacecraft {
+	sc := NewEmptySC("aerobraker", 700)
+	sc.Cd = 2.2
+	sc.Area = 17
+	return *sc
+}
+
+func TestAtmosphereDensity(t *testing.T) {
+	if AtmosphereDensity(Earth, 400, SpaceWeather{}) != EarthAtmosphereDensity(400, SpaceWeather{}) {
+		t.Fatal("Earth density differs from the Earth model")
+	}
+	if AtmosphereDensity(Mars, 0, SpaceWeather{}) != 1.5e-2 || AtmosphereDensity(Venus, 0, SpaceWeather{}) != 65 {
+		t.Fatal("invalid surface densities")
+	}
+	for _, body := range []CelestialObject{Mars, Venus} {
+		for alt := 1.; alt < 300; alt++ {
+			below := AtmosphereDensity(body, alt-1e-9, SpaceWeather{})
+			above := AtmosphereDensity(body, alt+1e-9, SpaceWeather{})
+			if !floats.EqualWithinRel(above, below, 1e-3) || AtmosphereDensity(body, alt+0.5, SpaceWeather{}) > below {
+				t.Fatalf("%s density not continuous and decreasing at %f km: %e %e", body.Name, alt, below, above)
+			}
+		}
+	}
+	assertPanic(t, func() {
+		AtmosphereDensity(Jupiter, 100, SpaceWeather{})
+	})
+}
+
+func TestAerobrakingPass(t *testing.T) {
+	sc := aerobrakingSC()
+	rP := Mars.Radius + 110
+	rA := Mars.Radius + 45000
+	a, e := Radii2ae(rA, rP)
+	o := *NewOrbitFromOE(a, e, 93, 10, 20, 180, Mars)
+	pass := AerobrakingPass(o, sc, SpaceWeather{})
+	if !floats.EqualWithinAbs(pass.Periapsis, 110, 1e-6) || !floats.EqualWithinAbs(pass.ApoapsisBefore, 45000, 1e-6) {
+		t.Fatalf("invalid pass geometry: %s", pass)
+	}
+	if !pass.Captured() || pass.ApoapsisReduction() <= 0 {
+		t.Fatalf("apoapsis not reduced: %s", pass)
+	}
+	// Approximation for a thin exponential atmosphere: Δv = ½ B ρp vP √(2π rP H (1+e)/e).
+	vP := math.Sqrt(Mars.μ * (2/rP - 1/a))
+	B := sc.Cd * sc.Area * 1e-6 / 700
+	ρ := AtmosphereDensity(Mars, 110, SpaceWeather{}) * 1e9
+	H := 7.109
+	if exp := 0.5 * B * ρ * vP * math.Sqrt(2*math.Pi*rP*H*(1+e)/e); !floats.EqualWithinRel(pass.ΔV, exp, 0.1) {
+		t.Fatalf("Δv=%e km/s expected about %e km/s", pass.ΔV, exp)
+	}
+	if !floats.EqualWithinAbs(pass.PeakDynamicPressure, 0.5*ρ*1e-9*math.Pow(vP*1e3, 2), 1e-9) {
+		t.Fatalf("invalid peak dynamic pressure %f", pass.PeakDynamicPressure)
+	}
+	if pass.HeatLoad <= 0 || pass.Duration <= 0 {
+		t.Fatal("no heat load or duration")
+	}
+	if !floats.EqualWithinAbs(pass.After.RNorm(), rP, 1e-6) || !floats.EqualWithinAbs(pass.After.VNorm(), vP-pass.ΔV, 1e-9) {
+		t.Fatal("invalid orbit after the pass")
+	}
+	// No drag above the atmosphere.
+	a, e = Radii2ae(rA, Mars.Radius+300)
+	high := AerobrakingPass(*NewOrbitFromOE(a, e, 93, 10, 20, 0, Mars), sc, SpaceWeather{})
+	if high.ΔV != 0 || high.ApoapsisReduction() != 0 || high.PeakHeatRate != 0 {
+		t.Fatalf("drag above the atmosphere: %s", high)
+	}
+}
+
+func TestAerocapture(t *testing.T) {
+	sc := aerobrakingSC()
+	for _, hP := range []float64{40, 140} {
+		c := NewCaptureFromApoapsis(Mars, 2.5, Mars.Radius+hP, Mars.Radius+hP)
+		pass := AerobrakingPass(*c.ApproachOrbit(0, 0, 0), sc, SpaceWeather{})
+		if !math.IsInf(pass.ApoapsisBefore, 1) {
+			t.Fatal("approach orbit is not hyperbolic")
+		}
+		if captured := hP < 100; pass.Captured() != captured {
+			t.Fatalf("aerocapture at %f km: captured=%v expected %v (%s)", hP, pass.Captured(), captured, pass)
+		}
+	}
+}
+
+func TestAerobrakingCampaign(t *testing.T) {
+	sc := aerobrakingSC()
+	a, e := Radii2ae(Mars.Radius+45000, Mars.Radius+160)
+	o := *NewOrbitFromOE(a, e, 93, 10, 20, 0, Mars)
+	maxHeat := 2500.
+	minAlt, maxAlt, err := AerobrakingCorridor(o, sc, SpaceWeather{}, maxHeat, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minAlt >= maxAlt || minAlt < 80 || maxAlt > 150 {
+		t.Fatalf("invalid corridor [%f, %f] km", minAlt, maxAlt)
+	}
+	if _, _, err = AerobrakingCorridor(o, sc, SpaceWeather{}, 1e-3, 20); err == nil {
+		t.Fatal("corridor should be closed")
+	}
+	campaign := AerobrakingCampaign{MinPeriapsis: minAlt, MaxPeriapsis: maxAlt, TargetApoapsis: 2000}
+	res := campaign.Run(o, sc)
+	if !res.Completed {
+		t.Fatalf("campaign not completed: %s", res)
+	}
+	hA := apoapsisAltitude(Mars, res.Final.RNorm(), res.Final.VNorm())
+	if hA > 2000 {
+		t.Fatalf("final apoapsis %f km", hA)
+	}
+	if res.PeakHeatRate > maxHeat*1.01 {
+		t.Fatalf("heat rate limit exceeded: %s", res)
+	}
+	if res.Duration.Hours() < 24*30 || res.Maneuvers == 0 {
+		t.Fatalf("unexpected campaign: %s", res)
+	}
+	assertPanic(t, func() {
+		AerobrakingCampaign{MinPeriapsis: 120, MaxPeriapsis: 100}.Run(o, sc)
+	})
+}
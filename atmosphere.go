@@ -0,0 +1,211 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// expAtmosphere is the exponential atmosphere model from Vallado (4th ed., table 8-4):
+// base altitude (km), nominal density (kg/m³) and scale height (km).
+var expAtmosphere = [][3]float64{
+	{0, 1.225, 7.249},
+	{25, 3.899e-2, 6.349},
+	{30, 1.774e-2, 6.682},
+	{40, 3.972e-3, 7.554},
+	{50, 1.057e-3, 8.382},
+	{60, 3.206e-4, 7.714},
+	{70, 8.770e-5, 6.549},
+	{80, 1.905e-5, 5.799},
+	{90, 3.396e-6, 5.382},
+	{100, 5.297e-7, 5.877},
+	{110, 9.661e-8, 7.263},
+	{120, 2.438e-8, 9.473},
+	{130, 8.484e-9, 12.636},
+	{140, 3.845e-9, 16.149},
+	{150, 2.070e-9, 22.523},
+	{180, 5.464e-10, 29.740},
+	{200, 2.789e-10, 37.105},
+	{250, 7.248e-11, 45.546},
+	{300, 2.418e-11, 53.628},
+	{350, 9.518e-12, 53.298},
+	{400, 3.725e-12, 58.515},
+	{450, 1.585e-12, 60.828},
+	{500, 6.967e-13, 63.822},
+	{600, 1.454e-13, 71.835},
+	{700, 3.614e-14, 88.667},
+	{800, 1.170e-14, 124.64},
+	{900, 5.245e-15, 181.05},
+	{1000, 3.019e-15, 268.00},
+}
+
+// marsExpAtmosphere is an approximate exponential model of the mean Martian atmosphere, with the same layout
+// as expAtmosphere.
+var marsExpAtmosphere = [][3]float64{
+	{0, 1.500e-2, 13.743},
+	{20, 3.500e-3, 11.341},
+	{40, 6.000e-4, 9.926},
+	{60, 8.000e-5, 6.091},
+	{80, 3.000e-6, 7.385},
+	{100, 2.000e-7, 7.109},
+	{120, 1.200e-8, 8.049},
+	{140, 1.000e-9, 10.542},
+	{160, 1.500e-10, 14.771},
+	{200, 1.000e-11, 21.715},
+	{250, 1.000e-12, 21.715},
+}
+
+// venusExpAtmosphere is an approximate exponential model of the mean Venusian atmosphere, with the same layout
+// as expAtmosphere.
+var venusExpAtmosphere = [][3]float64{
+	{0, 65, 13.498},
+	{50, 1.600, 6.676},
+	{70, 8.000e-2, 5.771},
+	{90, 2.500e-3, 3.909},
+	{110, 1.500e-5, 4.885},
+	{130, 2.500e-7, 4.837},
+	{150, 4.000e-9, 8.345},
+	{200, 1.000e-11, 16.690},
+	{250, 5.000e-13, 16.690},
+}
+
+// SpaceWeather defines the solar and geomagnetic activity used by the atmospheric density model.
+type SpaceWeather struct {
+	F107    float64 // Daily 10.7 cm solar flux (sfu)
+	F107Avg float64 // 81 day average of the 10.7 cm solar flux (sfu)
+	Ap      float64 // Daily geomagnetic planetary index
+}
+
+// ModerateSpaceWeather is a moderate solar activity, used as the reference of the exponential model.
+var ModerateSpaceWeather = SpaceWeather{150, 150, 15}
+
+// exosphericTemperature returns the exospheric temperature (K) as per Wertz (SMAD).
+func (sw SpaceWeather) exosphericTemperature() float64 {
+	return 900 + 2.5*(sw.F107Avg-70) + 1.5*sw.Ap
+}
+
+// EarthAtmosphereDensity returns the atmospheric density (kg/m³) at the provided altitude (km) above the Earth.
+// The exponential model is scaled above 180 km by the space weather via the SMAD thermospheric model. An empty
+// space weather is considered moderate.
+func EarthAtmosphereDensity(altitude float64, sw SpaceWeather) float64 {
+	if sw == (SpaceWeather{}) {
+		sw = ModerateSpaceWeather
+	}
+	ρ := expDensity(expAtmosphere, altitude)
+	if altitude > 180 && sw != ModerateSpaceWeather {
+		ρ *= smadDensity(altitude, sw) / smadDensity(altitude, ModerateSpaceWeather)
+	}
+	return ρ
+}
+
+// smadDensity returns the thermospheric density from Wertz (SMAD), valid between 180 and 500 km.
+func smadDensity(altitude float64, sw SpaceWeather) float64 {
+	h := math.Min(altitude, 500)
+	H := sw.exosphericTemperature() / (27 - 0.012*(h-200))
+	return 6e-10 * math.Exp(-(h-175)/H)
+}
+
+// AtmosphereDensity returns the atmospheric density (kg/m³) at the provided altitude (km) above the Earth, Mars
+// or Venus. The space weather is only used for the Earth.
+func AtmosphereDensity(body CelestialObject, altitude float64, sw SpaceWeather) float64 {
+	switch {
+	case body.Equals(Earth):
+		return EarthAtmosphereDensity(altitude, sw)
+	case body.Equals(Mars):
+		return expDensity(marsExpAtmosphere, altitude)
+	case body.Equals(Venus):
+		return expDensity(venusExpAtmosphere, altitude)
+	default:
+		panic(fmt.Errorf("no atmosphere model for %s", body.Name))
+	}
+}
+
+// atmosphereTop returns the highest altitude (km) of the atmosphere model of the provided body.
+func atmosphereTop(body CelestialObject) float64 {
+	switch {
+	case body.Equals(Earth):
+		return expAtmosphere[len(expAtmosphere)-1][0]
+	case body.Equals(Mars):
+		return marsExpAtmosphere[len(marsExpAtmosphere)-1][0]
+	case body.Equals(Venus):
+		return venusExpAtmosphere[len(venusExpAtmosphere)-1][0]
+	default:
+		panic(fmt.Errorf("no atmosphere model for %s", body.Name))
+	}
+}
+
+// expDensity returns the density of the exponential model at the provided altitude.
+func expDensity(table [][3]float64, altitude float64) float64 {
+	if altitude < 0 {
+		altitude = 0
+	}
+	base := table[0]
+	for _, layer := range table {
+		if altitude < layer[0] {
+			break
+		}
+		base = layer
+	}
+	return base[1] * math.Exp(-(altitude-base[0])/base[2])
+}
+
+// AtmosphericDrag returns an arbitrary perturbation (see Perturbations) of the atmospheric drag on the spacecraft,
+// which must have a drag coefficient and area. The rotation of the atmosphere is neglected.
+func AtmosphericDrag(sc Spacecraft, sw SpaceWeather) func(o Orbit) []float64 {
+	if sc.Cd <= 0 || sc.Area <= 0 {
+		panic("spacecraft Cd and Area must be strictly positive")
+	}
+	// Ballistic factor in km²/kg, the density will be converted to kg/km³.
+	B := sc.Cd * sc.Area * 1e-6 / sc.Mass(time.Time{})
+	return func(o Orbit) []float64 {
+		pert := make([]float64, 7)
+		altitude := o.RNorm() - o.Origin.Radius
+		if altitude > atmosphereTop(o.Origin) {
+			return pert
+		}
+		ρ := AtmosphereDensity(o.Origin, altitude, sw) * 1e9
+		V := o.V()
+		v := Norm(V)
+		for i := 0; i < 3; i++ {
+			pert[i+3] = -0.5 * ρ * B * v * V[i]
+		}
+		return pert
+	}
+}
+
+// AtmosphericDragPartials returns the partials (3x6) of the AtmosphericDrag acceleration with respect to the position
+// and velocity, e.g. as the ArbitraryPartials of the Perturbations. The density gradient is radial.
+func AtmosphericDragPartials(sc Spacecraft, sw SpaceWeather) func(o Orbit) *mat64.Dense {
+	if sc.Cd <= 0 || sc.Area <= 0 {
+		panic("spacecraft Cd and Area must be strictly positive")
+	}
+	B := sc.Cd * sc.Area * 1e-6 / sc.Mass(time.Time{})
+	return func(o Orbit) *mat64.Dense {
+		partials := mat64.NewDense(3, 6, nil)
+		altitude := o.RNorm() - o.Origin.Radius
+		if altitude > atmosphereTop(o.Origin) {
+			return partials
+		}
+		// The density is differentiated numerically as the models are piecewise.
+		h := 1e-3 // km
+		ρ := AtmosphereDensity(o.Origin, altitude, sw) * 1e9
+		dρdh := (AtmosphereDensity(o.Origin, altitude+h, sw) - AtmosphereDensity(o.Origin, math.Max(0, altitude-h), sw)) * 1e9 / (altitude + h - math.Max(0, altitude-h))
+		R, V := o.RV()
+		v := Norm(V)
+		rHat := Unit(R)
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				// a = -½ρBvV, so ∂a/∂R = -½B v V (dρ/dh) r̂ᵀ and ∂a/∂V = -½ρB (v I + V Vᵀ/v).
+				partials.Set(i, j, -0.5*B*v*V[i]*dρdh*rHat[j])
+				dadv := V[i] * V[j] / v
+				if i == j {
+					dadv += v
+				}
+				partials.Set(i, j+3, -0.5*ρ*B*dadv)
+			}
+		}
+		return partials
+	}
+}
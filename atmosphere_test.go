@@ -0,0 +1,57 @@
+package smd
+
+import (
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestEarthAtmosphereDensity(t *testing.T) {
+	// Values at the base altitudes of Vallado's table.
+	for _, exp := range []struct{ h, ρ float64 }{{0, 1.225}, {100, 5.297e-7}, {150, 2.070e-9}} {
+		if ρ := EarthAtmosphereDensity(exp.h, ModerateSpaceWeather); !floats.EqualWithinRel(ρ, exp.ρ, 1e-12) {
+			t.Fatalf("invalid density at %f km: %g", exp.h, ρ)
+		}
+	}
+	if EarthAtmosphereDensity(400, SpaceWeather{}) != EarthAtmosphereDensity(400, ModerateSpaceWeather) {
+		t.Fatal("empty space weather should be moderate")
+	}
+	prev := EarthAtmosphereDensity(0, ModerateSpaceWeather)
+	for h := 10.; h < 1200; h += 10 {
+		ρ := EarthAtmosphereDensity(h, ModerateSpaceWeather)
+		if ρ >= prev {
+			t.Fatalf("density does not decrease at %f km", h)
+		}
+		prev = ρ
+	}
+	high := SpaceWeather{250, 250, 50}
+	low := SpaceWeather{70, 70, 4}
+	if EarthAtmosphereDensity(400, high) <= EarthAtmosphereDensity(400, ModerateSpaceWeather) || EarthAtmosphereDensity(400, low) >= EarthAtmosphereDensity(400, ModerateSpaceWeather) {
+		t.Fatal("solar activity does not affect the density")
+	}
+	if EarthAtmosphereDensity(100, high) != EarthAtmosphereDensity(100, low) {
+		t.Fatal("space weather should not affect the density below 180 km")
+	}
+}
+
+func TestAtmosphericDrag(t *testing.T) {
+	sc := NewEmptySC("drag", 100)
+	sc.Cd = 2.2
+	sc.Area = 1
+	drag := AtmosphericDrag(*sc, ModerateSpaceWeather)
+	o := NewOrbitFromOE(Earth.Radius+400, 0, 51.6, 0, 0, 0, Earth)
+	pert := drag(*o)
+	acc := pert[3:6]
+	// ½ρv²CdA/m, opposite to the velocity.
+	v := o.VNorm()
+	exp := 0.5 * EarthAtmosphereDensity(400, ModerateSpaceWeather) * 1e9 * v * v * 2.2e-6 / 100
+	if !floats.EqualWithinRel(Norm(acc), exp, 1e-12) || !floats.EqualApprox(Unit(acc), Unit([]float64{-o.V()[0], -o.V()[1], -o.V()[2]}), 1e-12) {
+		t.Fatalf("invalid drag acceleration %+v", acc)
+	}
+	if pert = drag(*NewOrbitFromOE(Earth.Radius+2000, 0, 51.6, 0, 0, 0, Earth)); Norm(pert) != 0 {
+		t.Fatalf("drag above the atmosphere: %+v", pert)
+	}
+	assertPanic(t, func() {
+		AtmosphericDrag(*NewEmptySC("nodrag", 100), ModerateSpaceWeather)
+	})
+}
@@ -0,0 +1,168 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// AttitudeFunc returns the DCM from the body frame of the spacecraft to the inertial frame of its orbit at the
+// provided state.
+type AttitudeFunc func(State) *mat64.Dense
+
+// NadirPointing is the attitude whose body Z axis points to the center of the body, the Y axis along the negative
+// orbit normal and the X axis completes the triad (along the velocity for circular orbits).
+func NadirPointing(state State) *mat64.Dense {
+	r := Unit(state.Orbit.R())
+	c := Unit(state.Orbit.H())
+	i := Cross(c, r)
+	return mat64.NewDense(3, 3, []float64{
+		i[0], -c[0], -r[0],
+		i[1], -c[1], -r[1],
+		i[2], -c[2], -r[2]})
+}
+
+// SunPointing is the attitude whose body Z axis points to the Sun, e.g. the normal of fixed solar arrays, with the
+// body Y axis as close as possible to the negative orbit normal.
+func SunPointing(state State) *mat64.Dense {
+	R := state.Orbit.R()
+	rSun := sunPosition(state.Orbit, state.DT)
+	toSun := []float64{rSun[0] - R[0], rSun[1] - R[1], rSun[2] - R[2]}
+	c := state.Orbit.H()
+	return alignedConstrained([]float64{0, 0, 1}, toSun, []float64{0, 1, 0}, []float64{-c[0], -c[1], -c[2]})
+}
+
+// VelocityAligned is the attitude whose body X axis is along the inertial velocity, e.g. to thrust along the body X
+// axis, with the body Y axis as close as possible to the negative orbit normal. It differs from
+// NadirPointing by the flight path angle.
+func VelocityAligned(state State) *mat64.Dense {
+	c := state.Orbit.H()
+	return alignedConstrained([]float64{1, 0, 0}, state.Orbit.V(), []float64{0, 1, 0}, []float64{-c[0], -c[1], -c[2]})
+}
+
+// AlignedConstrained returns the attitude which aligns the primary body axis with the primary inertial direction,
+// and the secondary body axis as close as possible to the secondary inertial direction. The secondary direction is
+// ignored when collinear with the primary one.
+func AlignedConstrained(primaryBody, secondaryBody []float64, primary, secondary func(State) []float64) AttitudeFunc {
+	if Norm(Cross(primaryBody, secondaryBody)) == 0 {
+		panic("the primary and secondary body axes must not be collinear")
+	}
+	return func(state State) *mat64.Dense {
+		return alignedConstrained(primaryBody, primary(state), secondaryBody, secondary(state))
+	}
+}
+
+// alignedConstrained returns the body to inertial DCM from the triads of the primary and secondary vectors.
+func alignedConstrained(primaryBody, primary, secondaryBody, secondary []float64) *mat64.Dense {
+	triad := func(p, s []float64) *mat64.Dense {
+		t1 := Unit(p)
+		t2 := Unit(Cross(t1, s))
+		if Norm(t2) == 0 {
+			// Any direction orthogonal to the primary one.
+			t2 = Unit(Cross(t1, []float64{t1[1], t1[2], t1[0]}))
+			if Norm(t2) == 0 {
+				t2 = Unit(Cross(t1, []float64{1, 0, 0}))
+			}
+		}
+		t3 := Cross(t1, t2)
+		return mat64.NewDense(3, 3, []float64{
+			t1[0], t2[0], t3[0],
+			t1[1], t2[1], t3[1],
+			t1[2], t2[2], t3[2]})
+	}
+	var dcm mat64.Dense
+	dcm.Mul(triad(primary, secondary), triad(primaryBody, secondaryBody).T())
+	return &dcm
+}
+
+// InertialPointing returns the attitude fixed in the inertial frame by the provided body to inertial DCM.
+func InertialPointing(body2inertial *mat64.Dense) AttitudeFunc {
+	dcm := mat64.DenseCopyOf(body2inertial)
+	return func(State) *mat64.Dense {
+		return dcm
+	}
+}
+
+// BodyToInertial returns the DCM from the body frame to the inertial frame of the spacecraft at the provided state,
+// from its attitude, which defaults to NadirPointing.
+func (sc *Spacecraft) BodyToInertial(state State) *mat64.Dense {
+	if sc.Attitude == nil {
+		return NadirPointing(state)
+	}
+	return sc.Attitude(state)
+}
+
+// AttitudeState stores the attitude of a spacecraft at a given epoch.
+type AttitudeState struct {
+	DT   time.Time
+	Q    Quaternion // Body to inertial
+	Rate []float64  // Angular velocity of the body frame relative to the inertial frame, in the body frame (rad/s)
+}
+
+func (a AttitudeState) String() string {
+	return fmt.Sprintf("%s q=%s ω=%v rad/s", a.DT.Format(time.RFC3339), a.Q, a.Rate)
+}
+
+// AttitudeHistory returns the quaternion history of the attitude along the states. The sign of the quaternions is
+// continuous, and the angular velocity is computed by finite differences of the quaternions.
+func AttitudeHistory(states []State, attitude AttitudeFunc) []AttitudeState {
+	history := make([]AttitudeState, len(states))
+	for k, state := range states {
+		q := DCM2Quaternion(attitude(state))
+		if k > 0 && q.Dot(history[k-1].Q) < 0 {
+			q = q.Scale(-1)
+		}
+		history[k] = AttitudeState{DT: state.DT, Q: q}
+	}
+	for k := range history {
+		prev, next := k-1, k+1
+		if prev < 0 {
+			prev = k
+		}
+		if next == len(history) {
+			next = k
+		}
+		history[k].Rate = bodyRate(history[prev], history[next])
+	}
+	return history
+}
+
+// AttitudeHistory returns the quaternion history of the attitude of the spacecraft along the states.
+func (sc *Spacecraft) AttitudeHistory(states []State) []AttitudeState {
+	return AttitudeHistory(states, sc.BodyToInertial)
+}
+
+// bodyRate returns the mean angular velocity in the body frame between both attitudes, from q̇ = ½ q ⊗ ω.
+func bodyRate(a, b AttitudeState) []float64 {
+	Δt := b.DT.Sub(a.DT).Seconds()
+	if Δt == 0 {
+		return []float64{0, 0, 0}
+	}
+	δq := a.Q.Conjugate().Mul(b.Q)
+	if δq.W < 0 {
+		δq = δq.Scale(-1)
+	}
+	axis := []float64{δq.X, δq.Y, δq.Z}
+	sinHalf := Norm(axis)
+	if sinHalf == 0 {
+		return []float64{0, 0, 0}
+	}
+	θ := 2 * math.Atan2(sinHalf, δq.W)
+	return []float64{axis[0] / sinHalf * θ / Δt, axis[1] / sinHalf * θ / Δt, axis[2] / sinHalf * θ / Δt}
+}
+
+// WriteAttitudeHistory writes the quaternion history and angular velocity as a CSV table.
+func WriteAttitudeHistory(w io.Writer, history []AttitudeState) error {
+	if _, err := fmt.Fprint(w, "epoch,qw,qx,qy,qz,wx,wy,wz\n"); err != nil {
+		return err
+	}
+	for _, a := range history {
+		if _, err := fmt.Fprintf(w, "%s,%.12f,%.12f,%.12f,%.12f,%.12e,%.12e,%.12e\n", a.DT.UTC().Format(time.RFC3339Nano), a.Q.W, a.Q.X, a.Q.Y, a.Q.Z, a.Rate[0], a.Rate[1], a.Rate[2]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
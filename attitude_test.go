@@ -0,0 +1,116 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestQuaternion(t *testing.T) {
+	q := NewQuaternion([]float64{1, -2, 0.5}, Deg2rad(123))
+	p := NewQuaternion([]float64{0, 0, 1}, Deg2rad(-40))
+	if math.Abs(q.Dot(q)-1) > 1e-12 {
+		t.Fatalf("%s is not a unit quaternion", q)
+	}
+	// Round trip through the DCM, for all the branches of Shepperd's method.
+	for _, r := range []Quaternion{q, p, NewQuaternion([]float64{1, 0, 0}, 3), NewQuaternion([]float64{0, 1, 0}, 3), NewQuaternion([]float64{0, 0, 1}, 3)} {
+		if back := DCM2Quaternion(r.DCM()); r.Angle(back) > 1e-9 {
+			t.Fatalf("%s != %s", r, back)
+		}
+	}
+	v := []float64{0.3, -1, 2}
+	if !floats.EqualApprox(q.Rotate(v), MxV33(q.DCM(), v), 1e-12) {
+		t.Fatalf("rotation %v != %v", q.Rotate(v), MxV33(q.DCM(), v))
+	}
+	var qp mat64.Dense
+	qp.Mul(q.DCM(), p.DCM())
+	if !mat64.EqualApprox(q.Mul(p).DCM(), &qp, 1e-12) {
+		t.Fatal("the product of the quaternions does not match that of the DCMs")
+	}
+	if !floats.EqualApprox(q.Conjugate().Rotate(q.Rotate(v)), v, 1e-12) {
+		t.Fatal("the conjugate is not the inverse rotation")
+	}
+	if math.Abs(p.Angle(Quaternion{1, 0, 0, 0})-Deg2rad(40)) > 1e-12 {
+		t.Fatalf("invalid angle %f", Rad2deg(p.Angle(Quaternion{1, 0, 0, 0})))
+	}
+}
+
+func TestAttitudeProfiles(t *testing.T) {
+	start := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	o := NewOrbitFromOE(Earth.Radius+700, 0.1, 45, 10, 20, 30, Earth)
+	state := State{DT: start, Orbit: *o}
+	axis := func(dcm *mat64.Dense, j int) []float64 {
+		return []float64{dcm.At(0, j), dcm.At(1, j), dcm.At(2, j)}
+	}
+	for name, dcm := range map[string]*mat64.Dense{"nadir": NadirPointing(state), "sun": SunPointing(state), "velocity": VelocityAligned(state)} {
+		var I mat64.Dense
+		I.Mul(dcm, dcm.T())
+		if !mat64.EqualApprox(&I, DenseIdentity(3), 1e-12) || math.Abs(mat64.Det(dcm)-1) > 1e-12 {
+			t.Fatalf("%s attitude is not a rotation", name)
+		}
+	}
+	R := o.R()
+	rSun := sunPosition(*o, start)
+	if toSun := Unit([]float64{rSun[0] - R[0], rSun[1] - R[1], rSun[2] - R[2]}); !floats.EqualApprox(axis(SunPointing(state), 2), toSun, 1e-12) {
+		t.Fatal("body Z axis not pointed to the Sun")
+	}
+	if !floats.EqualApprox(axis(VelocityAligned(state), 0), Unit(o.V()), 1e-12) {
+		t.Fatal("body X axis not along the velocity")
+	}
+	// Both are constrained by the orbit normal: the Y axis of the velocity aligned attitude is that of nadir pointing.
+	if !floats.EqualApprox(axis(VelocityAligned(state), 1), axis(NadirPointing(state), 1), 1e-12) {
+		t.Fatal("velocity aligned attitude not constrained by the orbit normal")
+	}
+	custom := AlignedConstrained([]float64{0, 0, 1}, []float64{1, 0, 0}, func(s State) []float64 {
+		return s.Orbit.H()
+	}, func(s State) []float64 {
+		return s.Orbit.H()
+	})
+	if !floats.EqualApprox(axis(custom(state), 2), Unit(o.H()), 1e-12) {
+		t.Fatal("collinear secondary direction not ignored")
+	}
+	assertPanic(t, func() {
+		AlignedConstrained([]float64{0, 0, 1}, []float64{0, 0, -2}, nil, nil)
+	})
+}
+
+func TestAttitudeHistory(t *testing.T) {
+	start := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	o := NewOrbitFromOE(Earth.Radius+700, 0, 45, 10, 20, 30, Earth)
+	n := 2 * math.Pi / o.Period().Seconds()
+	var states []State
+	for dt := time.Duration(0); dt <= 2*time.Hour; dt += time.Minute {
+		states = append(states, State{DT: start.Add(dt), Orbit: *keplerPropagate(*o, dt)})
+	}
+	sc := NewEmptySC("nadir", 0)
+	history := sc.AttitudeHistory(states)
+	if len(history) != len(states) {
+		t.Fatalf("%d attitudes for %d states", len(history), len(states))
+	}
+	for k, a := range history {
+		// The nadir frame rotates at the mean motion about the orbit normal, i.e. the negative body Y axis.
+		if !floats.EqualApprox(a.Rate, []float64{0, -n, 0}, 1e-9) {
+			t.Fatalf("invalid body rate %v (n=%g)", a.Rate, n)
+		}
+		if k > 0 && a.Q.Dot(history[k-1].Q) < 0 {
+			t.Fatal("discontinuous quaternions")
+		}
+	}
+	sun := AttitudeHistory(states, SunPointing)
+	// The Sun and the orbit normal are nearly fixed in the inertial frame over two hours.
+	if rate := Norm(sun[len(sun)/2].Rate); rate > n/100 {
+		t.Fatalf("Sun pointing rotating at %g rad/s", rate)
+	}
+	var buf bytes.Buffer
+	if err := WriteAttitudeHistory(&buf, history); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != len(history)+1 || !strings.HasPrefix(lines[1], "2018-03-01T00:00:00Z,") {
+		t.Fatalf("unexpected table:\n%s", lines[:2])
+	}
+}
@@ -0,0 +1,94 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// SEPAngle returns the Sun-Earth-probe angle in degrees, from the heliocentric positions of the Earth and
+// of the probe, in the same frame.
+func SEPAngle(rEarth, rProbe []float64) float64 {
+	earthToSun := []float64{-rEarth[0], -rEarth[1], -rEarth[2]}
+	earthToProbe := []float64{rProbe[0] - rEarth[0], rProbe[1] - rEarth[1], rProbe[2] - rEarth[2]}
+	return math.Atan2(Norm(Cross(earthToSun, earthToProbe)), Dot(earthToSun, earthToProbe)) / deg2rad
+}
+
+// SunEarthProbeAngle returns the Sun-Earth-probe angle in degrees of the orbit at the provided epoch.
+// Note that this requires the ephemerides of the Earth and of the origin of the orbit.
+func SunEarthProbeAngle(o Orbit, dt time.Time) float64 {
+	return SEPAngle(Earth.HelioOrbit(dt).R(), heliocentricR(o, dt))
+}
+
+// heliocentricR returns the heliocentric position of the orbit in the ecliptic frame.
+func heliocentricR(o Orbit, dt time.Time) []float64 {
+	R, _ := o.HelioRV(dt)
+	return R
+}
+
+// BlackoutEvent stores a solar conjunction communications blackout.
+type BlackoutEvent struct {
+	Start, End time.Time
+	MinSEP     float64 // Minimum Sun-Earth-probe angle in degrees
+}
+
+// Duration returns the duration of this blackout.
+func (e BlackoutEvent) Duration() time.Duration {
+	return e.End.Sub(e.Start)
+}
+
+func (e BlackoutEvent) String() string {
+	return fmt.Sprintf("solar conjunction blackout from %s to %s (%s, min SEP %.3f deg)", e.Start, e.End, e.Duration(), e.MinSEP)
+}
+
+// BlackoutEvents returns the periods during which the Sun-Earth-probe angle is below the threshold (in degrees).
+// Note that this requires the ephemerides of the Earth and of the origin of the states.
+func BlackoutEvents(states []State, threshold float64) []BlackoutEvent {
+	dts := make([]time.Time, len(states))
+	angles := make([]float64, len(states))
+	for k, state := range states {
+		dts[k] = state.DT
+		angles[k] = SunEarthProbeAngle(state.Orbit, state.DT)
+	}
+	return blackoutEvents(dts, angles, threshold)
+}
+
+func blackoutEvents(dts []time.Time, angles []float64, threshold float64) []BlackoutEvent {
+	var events []BlackoutEvent
+	var cur *BlackoutEvent
+	for k, angle := range angles {
+		if angle < threshold {
+			if cur == nil {
+				cur = &BlackoutEvent{Start: dts[k], MinSEP: angle}
+			}
+			cur.End = dts[k]
+			cur.MinSEP = math.Min(cur.MinSEP, angle)
+		} else if cur != nil {
+			events = append(events, *cur)
+			cur = nil
+		}
+	}
+	if cur != nil {
+		events = append(events, *cur)
+	}
+	return events
+}
+
+// SEPAngleColumn is the Sun-Earth-probe angle column, for use in ExportConfig.Columns.
+var SEPAngleColumn = CSVColumn{Name: "sep", Unit: "deg", Extract: func(st State) float64 {
+	return SunEarthProbeAngle(st.Orbit, st.DT)
+}}
+
+// WriteBlackoutTable writes the blackout events as a CSV table.
+func WriteBlackoutTable(w io.Writer, events []BlackoutEvent) error {
+	if _, err := fmt.Fprint(w, "start,end,durationInDays,minSEP\n"); err != nil {
+		return err
+	}
+	for _, e := range events {
+		if _, err := fmt.Fprintf(w, "%s,%s,%.3f,%.6f\n", e.Start.UTC().Format(time.RFC3339), e.End.UTC().Format(time.RFC3339), e.Duration().Hours()/24, e.MinSEP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
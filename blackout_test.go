@@ -0,0 +1,60 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestSEPAngle(t *testing.T) {
+	rEarth := []float64{AU, 0, 0}
+	if sep := SEPAngle(rEarth, []float64{2 * AU, 0, 0}); !floats.EqualWithinAbs(sep, 180, 1e-9) {
+		t.Fatalf("probe at opposition should have a SEP of 180 deg, got %f", sep)
+	}
+	if sep := SEPAngle(rEarth, []float64{-1.5 * AU, 0, 0}); !floats.EqualWithinAbs(sep, 0, 1e-9) {
+		t.Fatalf("probe at superior conjunction should have a SEP of 0 deg, got %f", sep)
+	}
+	if sep := SEPAngle(rEarth, []float64{AU, AU, 0}); !floats.EqualWithinAbs(sep, 90, 1e-9) {
+		t.Fatalf("expected 90 deg, got %f", sep)
+	}
+}
+
+func TestBlackoutEvents(t *testing.T) {
+	// Probe fixed on the far side of the Sun, Earth on a circular orbit.
+	rProbe := []float64{-1.5 * AU, 0, 0}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var dts []time.Time
+	var angles []float64
+	for day := -180; day <= 180; day++ {
+		θ := 2 * math.Pi * float64(day) / 365.25
+		dts = append(dts, start.Add(time.Duration(day+180)*24*time.Hour))
+		angles = append(angles, SEPAngle([]float64{AU * math.Cos(θ), AU * math.Sin(θ), 0}, rProbe))
+	}
+	events := blackoutEvents(dts, angles, 3)
+	if len(events) != 1 {
+		t.Fatalf("expected one blackout, got %d", len(events))
+	}
+	blackout := events[0]
+	if !floats.EqualWithinAbs(blackout.MinSEP, 0, 1e-9) {
+		t.Fatalf("minimum SEP should be zero: %s", blackout)
+	}
+	conjunction := start.Add(180 * 24 * time.Hour)
+	if conjunction.Sub(blackout.Start) != blackout.End.Sub(conjunction) {
+		t.Fatalf("blackout should be centered on the conjunction: %s", blackout)
+	}
+	// The SEP is about 1.5/2.5 of the angle travelled by the Earth, i.e. five days on each side.
+	if d := blackout.Duration().Hours() / 24; d < 9 || d > 11 {
+		t.Fatalf("unexpected blackout duration of %f days", d)
+	}
+	var buf bytes.Buffer
+	if err := WriteBlackoutTable(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 {
+		t.Fatalf("invalid table:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,156 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/soniakeys/meeus/julian"
+)
+
+// RotationalElements stores the IAU rotational elements of a body with respect to the ICRF: the right ascension
+// and declination of its north pole, and the angle W of its prime meridian measured from the ascending node of
+// its equator on the ICRF equator. The periodic terms of the IAU models are not included.
+type RotationalElements struct {
+	PoleRA, PoleRARate   float64 // degrees and degrees per Julian century since J2000
+	PoleDec, PoleDecRate float64 // degrees and degrees per Julian century since J2000
+	W0, WRate            float64 // degrees and degrees per day since J2000
+}
+
+// iauRotationalElements are from the report of the IAU WGCCRE (2009).
+var iauRotationalElements = map[string]RotationalElements{
+	"Sun":     {286.13, 0, 63.87, 0, 84.176, 14.1844},
+	"Venus":   {272.76, 0, 67.16, 0, 160.20, -1.4813688},
+	"Earth":   {0, -0.641, 90, -0.557, 190.147, 360.9856235},
+	"Moon":    {269.9949, 0.0031, 66.5392, 0.0130, 38.3213, 13.17635815},
+	"Mars":    {317.68143, -0.1061, 52.88650, -0.0609, 176.630, 350.89198226},
+	"Jupiter": {268.056595, -0.006499, 64.495303, 0.002413, 284.95, 870.5360000},
+	"Saturn":  {40.589, -0.036, 83.537, -0.004, 38.90, 810.7939024},
+	"Uranus":  {257.311, 0, -15.175, 0, 203.81, -501.1600928},
+	"Neptune": {299.36, 0, 43.46, 0, 253.18, 536.3128492},
+	"Pluto":   {132.993, 0, -6.163, 0, 302.695, 56.3625225},
+}
+
+// At returns the right ascension and declination of the pole and the prime meridian angle, all in radians, at
+// the provided epoch.
+func (r RotationalElements) At(dt time.Time) (α, δ, W float64) {
+	d := julian.TimeToJD(dt.UTC()) - 2451545.0
+	T := d / 36525
+	α = Deg2rad(r.PoleRA + r.PoleRARate*T)
+	δ = Deg2rad(r.PoleDec + r.PoleDecRate*T)
+	W = Deg2rad(math.Mod(r.W0+r.WRate*d, 360))
+	return
+}
+
+// RotationalElements returns the IAU rotational elements of this body.
+func (c CelestialObject) RotationalElements() (RotationalElements, error) {
+	if r, ok := iauRotationalElements[c.Name]; ok {
+		return r, nil
+	}
+	return RotationalElements{}, fmt.Errorf("no rotational elements for %s", c.Name)
+}
+
+// ICRF2BodyFixed returns the DCM from the ICRF to the body fixed frame of this body at the provided epoch.
+func (c CelestialObject) ICRF2BodyFixed(dt time.Time) *mat64.Dense {
+	r, err := c.RotationalElements()
+	if err != nil {
+		panic(err)
+	}
+	α, δ, W := r.At(dt)
+	return R3R1R3(math.Pi/2+α, math.Pi/2-δ, W)
+}
+
+// BodyFixed2ICRF returns the DCM from the body fixed frame of this body to the ICRF at the provided epoch.
+func (c CelestialObject) BodyFixed2ICRF(dt time.Time) *mat64.Dense {
+	var dcm mat64.Dense
+	dcm.Clone(c.ICRF2BodyFixed(dt).T())
+	return &dcm
+}
+
+// Equatorial2Ecliptic returns the DCM from the inertial equatorial frame of this body, in which the orbits about it
+// are expressed, to the ecliptic frame of the heliocentric orbits at the provided epoch. The equatorial frame of the
+// Earth is the ICRF, which is rotated by the obliquity of the ecliptic, i.e. the axial tilt of the Earth. The
+// equatorial frame of the other bodies has its Z axis along their IAU pole and its X axis along the ascending node of
+// their equator on the ICRF equator, from which the prime meridian angle W is measured (cf. RotationAngle).
+// Panics if the body has no rotational elements.
+func (c CelestialObject) Equatorial2Ecliptic(dt time.Time) *mat64.Dense {
+	icrf2Ecliptic := R1(Deg2rad(Earth.tilt))
+	if c.Name == "Sun" {
+		return mat64.NewDense(3, 3, []float64{1, 0, 0, 0, 1, 0, 0, 0, 1})
+	}
+	if c.Name == "Earth" {
+		return icrf2Ecliptic
+	}
+	r, err := c.RotationalElements()
+	if err != nil {
+		panic(err)
+	}
+	α, δ, _ := r.At(dt)
+	var dcm mat64.Dense
+	dcm.Mul(icrf2Ecliptic, R3R1R3(math.Pi/2+α, math.Pi/2-δ, 0).T())
+	return &dcm
+}
+
+// Ecliptic2Equatorial returns the DCM from the ecliptic frame to the inertial equatorial frame of this body at the
+// provided epoch (cf. Equatorial2Ecliptic).
+func (c CelestialObject) Ecliptic2Equatorial(dt time.Time) *mat64.Dense {
+	var dcm mat64.Dense
+	dcm.Clone(c.Equatorial2Ecliptic(dt).T())
+	return &dcm
+}
+
+// rotation returns the rotation angle of this body at the provided epoch and the DCM from its inertial frame to the
+// frame from which that angle is measured: the J2000 to true of date DCM for the Earth, and nil (i.e. the identity)
+// for the other bodies.
+func (c CelestialObject) rotation(dt time.Time) (θ float64, NP *mat64.Dense) {
+	if c.Name == "Earth" {
+		return GAST(dt), J20002TOD(dt)
+	}
+	return c.RotationAngle(dt), nil
+}
+
+// Inertial2BodyFixed returns the DCM from the inertial frame of this body, in which the orbits about it are
+// expressed, to its rotating body fixed frame at the provided epoch. The J2000 vectors of the Earth are rotated to
+// the true equator and equinox of date before the rotation by the Greenwich apparent sidereal time.
+func (c CelestialObject) Inertial2BodyFixed(dt time.Time) *mat64.Dense {
+	θ, NP := c.rotation(dt)
+	if NP == nil {
+		return R3(θ)
+	}
+	var dcm mat64.Dense
+	dcm.Mul(R3(θ), NP)
+	return &dcm
+}
+
+// BodyFixed2Inertial returns the DCM from the body fixed frame of this body to its inertial frame at the provided
+// epoch (cf. Inertial2BodyFixed).
+func (c CelestialObject) BodyFixed2Inertial(dt time.Time) *mat64.Dense {
+	var dcm mat64.Dense
+	dcm.Clone(c.Inertial2BodyFixed(dt).T())
+	return &dcm
+}
+
+// LatLongAlt returns the latitude and longitude (in degrees) and the altitude (in km) above the spherical surface
+// of this body of the provided position, expressed in the inertial frame of this body (cf. Inertial2BodyFixed).
+func (c CelestialObject) LatLongAlt(R []float64, dt time.Time) (latΦ, longθ, alt float64) {
+	rBF := MxV33(c.Inertial2BodyFixed(dt), R)
+	r := Norm(rBF)
+	latΦ = Rad2deg180(math.Asin(rBF[2] / r))
+	longθ = Rad2deg180(math.Atan2(rBF[1], rBF[0]))
+	alt = r - c.Radius
+	return
+}
+
+// GroundTrack returns the latitude and longitude (in degrees) of each state, which must orbit this body.
+func (c CelestialObject) GroundTrack(states []State) (latΦ, longθ []float64) {
+	latΦ = make([]float64, len(states))
+	longθ = make([]float64, len(states))
+	for k, state := range states {
+		if !state.Orbit.Origin.Equals(c) {
+			panic(fmt.Errorf("state orbits %s, not %s", state.Orbit.Origin.Name, c.Name))
+		}
+		latΦ[k], longθ[k], _ = c.LatLongAlt(state.Orbit.R(), state.DT)
+	}
+	return
+}
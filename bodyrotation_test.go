@@ -0,0 +1,115 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestRotationalElements(t *testing.T) {
+	j2000 := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	r, err := Mars.RotationalElements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	α, δ, W := r.At(j2000)
+	if !floats.EqualWithinAbs(Rad2deg(α), 317.68143, 1e-9) || !floats.EqualWithinAbs(Rad2deg(δ), 52.8865, 1e-9) || !floats.EqualWithinAbs(Rad2deg(W), 176.63, 1e-9) {
+		t.Fatalf("invalid Mars elements at J2000: %f %f %f", Rad2deg(α), Rad2deg(δ), Rad2deg(W))
+	}
+	// One sol later, the prime meridian is back to the same place.
+	solInSeconds := 360 / 350.89198226 * 86400
+	sol := time.Duration(solInSeconds * 1e9)
+	if _, _, W1 := r.At(j2000.Add(sol)); !floats.EqualWithinAbs(Rad2deg180(W1-W), 0, 1e-6) {
+		t.Fatalf("W changed by %f degrees after one sol", Rad2deg180(W1-W))
+	}
+	if _, err := (CelestialObject{Name: "Vesta"}).RotationalElements(); err == nil {
+		t.Fatal("expected an error for an unknown body")
+	}
+	assertPanic(t, func() {
+		CelestialObject{Name: "Vesta"}.ICRF2BodyFixed(j2000)
+	})
+}
+
+func TestICRF2BodyFixed(t *testing.T) {
+	dt := time.Date(2020, 2, 11, 7, 0, 0, 0, time.UTC)
+	for _, body := range []CelestialObject{Sun, Venus, Earth, Moon, Mars, Jupiter, Saturn, Uranus, Neptune, Pluto} {
+		r, _ := body.RotationalElements()
+		α, δ, W := r.At(dt)
+		dcm := body.ICRF2BodyFixed(dt)
+		// The pole of the body must be the Z axis of its body fixed frame.
+		pole := MxV33(dcm, []float64{math.Cos(δ) * math.Cos(α), math.Cos(δ) * math.Sin(α), math.Sin(δ)})
+		if !floats.EqualApprox(pole, []float64{0, 0, 1}, 1e-12) {
+			t.Fatalf("invalid pole for %s: %+v", body.Name, pole)
+		}
+		// The ascending node of the equator is at -W in the body fixed frame.
+		node := MxV33(dcm, []float64{-math.Sin(α), math.Cos(α), 0})
+		if !floats.EqualApprox(node, []float64{math.Cos(W), -math.Sin(W), 0}, 1e-12) {
+			t.Fatalf("invalid node for %s: %+v", body.Name, node)
+		}
+		var I mat64.Dense
+		I.Mul(dcm, body.BodyFixed2ICRF(dt))
+		if !mat64.EqualApprox(&I, DenseIdentity(3), 1e-12) {
+			t.Fatalf("invalid inverse DCM for %s", body.Name)
+		}
+	}
+}
+
+func TestEquatorial2Ecliptic(t *testing.T) {
+	j2000 := time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+	// The north pole of Mars is at an ecliptic longitude of 352.88 degrees and a latitude of 63.28 degrees.
+	dcm := Mars.Equatorial2Ecliptic(j2000)
+	if pole := MxV33(dcm, []float64{0, 0, 1}); !floats.EqualApprox(pole, []float64{0.4461587269353554, -0.055511462513014365, 0.89323057936296}, 1e-12) {
+		t.Fatalf("invalid pole of Mars %+v", pole)
+	}
+	if node := MxV33(dcm, []float64{1, 0, 0}); !floats.EqualApprox(node, []float64{0.6732521982472341, 0.6783980519379643, -0.29412167666127875}, 1e-12) {
+		t.Fatalf("invalid node of Mars %+v", node)
+	}
+	// The pole of the Earth is tilted by the obliquity of the ecliptic.
+	if pole := MxV33(Earth.Equatorial2Ecliptic(j2000), []float64{0, 0, 1}); !floats.EqualApprox(pole, []float64{0, 0.3977772982704228, 0.9174820003578724}, 1e-12) {
+		t.Fatalf("invalid pole of the Earth %+v", pole)
+	}
+	if !mat64.Equal(Sun.Equatorial2Ecliptic(j2000), DenseIdentity(3)) {
+		t.Fatal("the frame of the Sun is the ecliptic")
+	}
+	for _, body := range []CelestialObject{Venus, Earth, Moon, Mars, Jupiter, Saturn, Uranus, Neptune, Pluto} {
+		var I mat64.Dense
+		I.Mul(body.Ecliptic2Equatorial(j2000), body.Equatorial2Ecliptic(j2000))
+		if !mat64.EqualApprox(&I, DenseIdentity(3), 1e-12) {
+			t.Fatalf("invalid inverse DCM for %s", body.Name)
+		}
+	}
+	assertPanic(t, func() {
+		CelestialObject{Name: "Vesta"}.Equatorial2Ecliptic(j2000)
+	})
+}
+
+func TestGroundTrack(t *testing.T) {
+	dt := time.Date(2020, 2, 11, 7, 0, 0, 0, time.UTC)
+	for _, body := range []CelestialObject{Earth, Mars, Moon} {
+		R := MxV33(body.BodyFixed2Inertial(dt), GEO2BodyFixed(250, Deg2rad(-20), Deg2rad(135), body))
+		lat, long, alt := body.LatLongAlt(R, dt)
+		if !floats.EqualWithinAbs(lat, -20, 1e-9) || !floats.EqualWithinAbs(long, 135, 1e-9) || !floats.EqualWithinAbs(alt, 250, 1e-9) {
+			t.Fatalf("invalid %s lat/long/alt: %f %f %f", body.Name, lat, long, alt)
+		}
+	}
+	// A polar orbit passes over both poles.
+	o := NewOrbitFromOE(Mars.Radius+400, 0, 90, 0, 0, 0, Mars)
+	m := NewPreciseMission(NewEmptySC("mro", 0), o, dt, dt.Add(2*time.Hour), Perturbations{}, time.Minute, false, ExportConfig{})
+	stateChan := make(chan State, 10)
+	m.RegisterStateChan(stateChan)
+	go m.Propagate()
+	var states []State
+	for state := range stateChan {
+		states = append(states, state)
+	}
+	lat, _ := Mars.GroundTrack(states)
+	if max, min := floats.Max(lat), floats.Min(lat); max < 85 || min > -85 {
+		t.Fatalf("polar ground track between %f and %f", min, max)
+	}
+	assertPanic(t, func() {
+		Earth.GroundTrack(states)
+	})
+}
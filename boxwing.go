@@ -0,0 +1,226 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// solarPressure is the solar radiation pressure at 1 AU (N/m²), from a solar flux of 1357 W/m².
+const solarPressure = 1357. / 299792458.
+
+// Plate is a flat surface of a box-wing model, lit and hit by the flow on its outward side only.
+type Plate struct {
+	Area              float64   // m²
+	Normal            []float64 // Outward normal in the body frame
+	CoP               []float64 // Center of pressure in the body frame (m)
+	Specular, Diffuse float64   // Reflectivity coefficients, the remainder being absorbed
+}
+
+// srpForce returns the SRP force (N) on the plate, where sun is the unit vector to the Sun in the body frame and P the
+// solar radiation pressure (N/m²) (Montenbruck & Gill, 2000, eq. 3.75).
+func (p Plate) srpForce(sun []float64, P float64) []float64 {
+	n := Unit(p.Normal)
+	cosθ := Dot(n, sun)
+	if cosθ <= 0 {
+		return []float64{0, 0, 0}
+	}
+	k := -P * p.Area * cosθ
+	kn := 2 * (p.Specular*cosθ + p.Diffuse/3)
+	return []float64{
+		k * ((1-p.Specular)*sun[0] + kn*n[0]),
+		k * ((1-p.Specular)*sun[1] + kn*n[1]),
+		k * ((1-p.Specular)*sun[2] + kn*n[2])}
+}
+
+// dragForce returns the drag force (N) on the plate, where V is the velocity relative to the atmosphere in the body
+// frame (m/s) and ρ the density (kg/m³).
+func (p Plate) dragForce(V []float64, ρ, Cd float64) []float64 {
+	v := Norm(V)
+	if v == 0 {
+		return []float64{0, 0, 0}
+	}
+	cosθ := Dot(Unit(p.Normal), V) / v
+	if cosθ <= 0 {
+		return []float64{0, 0, 0}
+	}
+	k := -0.5 * ρ * Cd * p.Area * cosθ * v
+	return []float64{k * V[0], k * V[1], k * V[2]}
+}
+
+// BoxWing is the box-wing model of a spacecraft: a set of plates, e.g. the six faces of the bus and both sides of the
+// solar arrays. The offset between their centers of pressure and the center of mass creates the SRP and drag torques.
+type BoxWing struct {
+	Plates []Plate
+	Cd     float64 // Drag coefficient of the plates
+}
+
+// NewBox returns the six plates of a box of the provided dimensions (m) along the body axes, centered at the
+// provided point (m).
+func NewBox(dimensions, center []float64, specular, diffuse float64) []Plate {
+	plates := make([]Plate, 0, 6)
+	for axis := 0; axis < 3; axis++ {
+		area := dimensions[(axis+1)%3] * dimensions[(axis+2)%3]
+		for _, sign := range []float64{1, -1} {
+			n := []float64{0, 0, 0}
+			n[axis] = sign
+			cop := []float64{center[0], center[1], center[2]}
+			cop[axis] += sign * dimensions[axis] / 2
+			plates = append(plates, Plate{area, n, cop, specular, diffuse})
+		}
+	}
+	return plates
+}
+
+// NewWing returns both sides of a flat wing (e.g. a solar array) of the provided area (m²), normal and center (m).
+func NewWing(area float64, normal, center []float64, specular, diffuse float64) []Plate {
+	n := Unit(normal)
+	return []Plate{
+		{area, n, []float64{center[0], center[1], center[2]}, specular, diffuse},
+		{area, []float64{-n[0], -n[1], -n[2]}, []float64{center[0], center[1], center[2]}, specular, diffuse}}
+}
+
+// EnvironmentalTorque stores the environmental torques on the spacecraft, in its body frame (N m).
+type EnvironmentalTorque struct {
+	DT                         time.Time
+	SRP, Drag, GravityGradient []float64
+}
+
+// Total returns the sum of the environmental torques (N m).
+func (t EnvironmentalTorque) Total() []float64 {
+	τ := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		τ[i] = t.SRP[i] + t.Drag[i] + t.GravityGradient[i]
+	}
+	return τ
+}
+
+func (t EnvironmentalTorque) String() string {
+	return fmt.Sprintf("%s SRP=%v drag=%v GG=%v N m", t.DT.Format(time.RFC3339), t.SRP, t.Drag, t.GravityGradient)
+}
+
+// EnvironmentalTorque returns the SRP, drag and gravity gradient torques on the spacecraft at the provided state,
+// from its box-wing model, mass properties and attitude. As for AtmosphericDrag, the rotation of the atmosphere is
+// neglected. Without a box-wing model, only the gravity gradient torque is computed.
+func (sc *Spacecraft) EnvironmentalTorque(state State, sw SpaceWeather) EnvironmentalTorque {
+	t := EnvironmentalTorque{state.DT, []float64{0, 0, 0}, []float64{0, 0, 0}, sc.GravityGradientTorque(state)}
+	if sc.BoxWing == nil {
+		return t
+	}
+	dcm := sc.BodyToInertial(state)
+	com := sc.MassProperties(state.DT).CoM
+	R, V := state.Orbit.RV()
+	// Solar radiation pressure, scaled with the distance to the Sun and the illumination.
+	toSun := []float64{-R[0], -R[1], -R[2]}
+	P := solarPressure
+	if !state.Orbit.Origin.Equals(Sun) {
+		rSun := sunPosition(state.Orbit, state.DT)
+		toSun = []float64{rSun[0] - R[0], rSun[1] - R[1], rSun[2] - R[2]}
+		P *= Eclipsed(state.Orbit, state.DT, state.Orbit.Origin)
+	}
+	P *= math.Pow(AU/Norm(toSun), 2)
+	sun := MxV33(dcm.T(), Unit(toSun))
+	// Drag, in m/s and kg/m³, only about the bodies with an atmosphere model.
+	ρ := 0.
+	if body := state.Orbit.Origin; body.Equals(Earth) || body.Equals(Mars) || body.Equals(Venus) {
+		if altitude := Norm(R) - body.Radius; altitude <= atmosphereTop(body) {
+			ρ = AtmosphereDensity(body, altitude, sw)
+		}
+	}
+	vBody := MxV33(dcm.T(), []float64{V[0] * 1e3, V[1] * 1e3, V[2] * 1e3})
+	for _, plate := range sc.BoxWing.Plates {
+		arm := []float64{plate.CoP[0] - com[0], plate.CoP[1] - com[1], plate.CoP[2] - com[2]}
+		if P > 0 {
+			τ := Cross(arm, plate.srpForce(sun, P))
+			for i := 0; i < 3; i++ {
+				t.SRP[i] += τ[i]
+			}
+		}
+		if ρ > 0 {
+			τ := Cross(arm, plate.dragForce(vBody, ρ, sc.BoxWing.Cd))
+			for i := 0; i < 3; i++ {
+				t.Drag[i] += τ[i]
+			}
+		}
+	}
+	return t
+}
+
+// MomentumBudget stores the angular momentum accumulated from the environmental torques along a trajectory, which
+// the reaction wheels of a three axis stabilized spacecraft must absorb.
+type MomentumBudget struct {
+	Torques  []EnvironmentalTorque
+	Inertial [][]float64 // Accumulated angular momentum in the inertial frame (N m s)
+	Body     [][]float64 // Accumulated angular momentum in the body frame (N m s), i.e. that of the wheels
+}
+
+// MomentumAccumulation integrates the environmental torques on the spacecraft along the states, with its attitude
+// profile (e.g. the attitude history of SunPointing or NadirPointing). The angular momentum of the body itself, due
+// to its commanded rotation, is not included.
+func (sc *Spacecraft) MomentumAccumulation(states []State, sw SpaceWeather) MomentumBudget {
+	b := MomentumBudget{make([]EnvironmentalTorque, len(states)), make([][]float64, len(states)), make([][]float64, len(states))}
+	var prevτ []float64
+	H := []float64{0, 0, 0}
+	for k, state := range states {
+		b.Torques[k] = sc.EnvironmentalTorque(state, sw)
+		dcm := sc.BodyToInertial(state)
+		τ := MxV33(dcm, b.Torques[k].Total())
+		if k > 0 {
+			// Trapezoidal integration in the inertial frame.
+			Δt := state.DT.Sub(states[k-1].DT).Seconds()
+			for i := 0; i < 3; i++ {
+				H[i] += (τ[i] + prevτ[i]) * Δt / 2
+			}
+		}
+		prevτ = τ
+		b.Inertial[k] = []float64{H[0], H[1], H[2]}
+		b.Body[k] = MxV33(dcm.T(), H)
+	}
+	return b
+}
+
+// Peak returns the largest norm of the accumulated momentum in the body frame (N m s), to size the reaction wheels.
+func (b MomentumBudget) Peak() (peak float64) {
+	for _, h := range b.Body {
+		peak = math.Max(peak, Norm(h))
+	}
+	return
+}
+
+// SecularRate returns the mean rate of the momentum accumulated in the inertial frame (N m), i.e. the secular part of
+// the environmental torques, which must be dumped by desaturation maneuvers.
+func (b MomentumBudget) SecularRate() float64 {
+	n := len(b.Inertial)
+	if n < 2 {
+		return 0
+	}
+	return Norm(b.Inertial[n-1]) / b.Torques[n-1].DT.Sub(b.Torques[0].DT).Seconds()
+}
+
+// DesaturationInterval returns the mean interval between two desaturations of wheels of the provided momentum
+// capacity (N m s), from the secular rate, or zero if the momentum does not build up.
+func (b MomentumBudget) DesaturationInterval(capacity float64) time.Duration {
+	rate := b.SecularRate()
+	if rate == 0 {
+		return 0
+	}
+	return time.Duration(capacity / rate * float64(time.Second))
+}
+
+// WriteMomentumBudget writes the environmental torques and the accumulated momentum as a CSV table.
+func WriteMomentumBudget(w io.Writer, b MomentumBudget) error {
+	if _, err := fmt.Fprint(w, "epoch,srpX,srpY,srpZ,dragX,dragY,dragZ,ggX,ggY,ggZ,hX,hY,hZ,hBodyX,hBodyY,hBodyZ\n"); err != nil {
+		return err
+	}
+	for k, t := range b.Torques {
+		line := t.DT.UTC().Format(time.RFC3339)
+		for _, v := range [][]float64{t.SRP, t.Drag, t.GravityGradient, b.Inertial[k], b.Body[k]} {
+			line += fmt.Sprintf(",%.6e,%.6e,%.6e", v[0], v[1], v[2])
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestBoxWingPlates(t *testing.T) {
+	box := NewBox([]float64{1, 2, 3}, []float64{0, 0, 0.5}, 0.2, 0.3)
+	if len(box) != 6 {
+		t.Fatalf("%d plates in a box", len(box))
+	}
+	for k, exp := range []float64{6, 6, 3, 3, 2, 2} {
+		if box[k].Area != exp {
+			t.Fatalf("plate %d: area of %f m² instead of %f", k, box[k].Area, exp)
+		}
+	}
+	if !floats.Equal(box[5].Normal, []float64{0, 0, -1}) || !floats.Equal(box[5].CoP, []float64{0, 0, -1}) {
+		t.Fatalf("invalid bottom plate %+v", box[5])
+	}
+	wing := NewWing(4, []float64{0, 0, 2}, []float64{0, 3, 0}, 0, 0)
+	if len(wing) != 2 || !floats.Equal(wing[0].Normal, []float64{0, 0, 1}) || !floats.Equal(wing[1].Normal, []float64{0, 0, -1}) {
+		t.Fatalf("invalid wing %+v", wing)
+	}
+	// An absorbing plate facing the Sun is pushed away from it, and a specular one twice as much.
+	sun := []float64{0, 0, 1}
+	if F := wing[0].srpForce(sun, 1); !floats.EqualApprox(F, []float64{0, 0, -4}, 1e-12) {
+		t.Fatalf("invalid SRP force %v", F)
+	}
+	if F := wing[1].srpForce(sun, 1); Norm(F) != 0 {
+		t.Fatalf("SRP force %v on the unlit side", F)
+	}
+	mirror := Plate{4, []float64{0, 0, 1}, []float64{0, 0, 0}, 1, 0}
+	if F := mirror.srpForce(sun, 1); !floats.EqualApprox(F, []float64{0, 0, -8}, 1e-12) {
+		t.Fatalf("invalid SRP force %v on a mirror", F)
+	}
+}
+
+func TestEnvironmentalTorque(t *testing.T) {
+	start := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	o := NewOrbitFromOE(Earth.Radius+400, 0, 51.6, 10, 20, 30, Earth)
+	state := State{DT: start, Orbit: *o}
+	sc := NewEmptySC("boxwing", 100)
+	// A box centered on the center of mass has no torque.
+	sc.BoxWing = &BoxWing{NewBox([]float64{1, 1, 1}, []float64{0, 0, 0}, 0.1, 0.2), 2.2}
+	for _, attitude := range []AttitudeFunc{NadirPointing, SunPointing, VelocityAligned} {
+		sc.Attitude = attitude
+		if τ := sc.EnvironmentalTorque(state, ModerateSpaceWeather).Total(); Norm(τ) > 1e-15 {
+			t.Fatalf("torque of %v N m on a symmetric box", τ)
+		}
+	}
+	// Drag on a plate facing the flow, two meters above the center of mass.
+	sc.BoxWing = &BoxWing{[]Plate{{2, []float64{1, 0, 0}, []float64{0, 0, 2}, 0, 0}}, 2.2}
+	sc.Attitude = VelocityAligned
+	ρ := AtmosphereDensity(Earth, o.RNorm()-Earth.Radius, ModerateSpaceWeather)
+	v := o.VNorm() * 1e3
+	drag := -0.5 * ρ * 2.2 * 2 * v * v
+	if τ := sc.EnvironmentalTorque(state, ModerateSpaceWeather); !floats.EqualApprox(τ.Drag, []float64{0, 2 * drag, 0}, 1e-6*math.Abs(drag)) {
+		t.Fatalf("invalid drag torque %v N m (expected %g about Y)", τ.Drag, 2*drag)
+	}
+	// Without an atmosphere, e.g. about the Sun, there is no drag torque.
+	helio := State{DT: start, Orbit: *NewOrbitFromOE(AU, 0, 0, 0, 0, 0, Sun)}
+	if τ := sc.EnvironmentalTorque(helio, ModerateSpaceWeather); Norm(τ.Drag) != 0 {
+		t.Fatalf("drag torque of %v N m about the Sun", τ.Drag)
+	}
+}
+
+func TestMomentumAccumulation(t *testing.T) {
+	start := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	// Dawn-dusk polar orbit, always lit: the orbit normal is close to the direction of the Sun.
+	o := NewOrbitFromOE(Earth.Radius+700, 0, 90, 72, 0, 0, Earth)
+	var states []State
+	for dt := time.Duration(0); dt <= 2*time.Hour; dt += time.Minute {
+		state := State{DT: start.Add(dt), Orbit: *keplerPropagate(*o, dt)}
+		if Eclipsed(state.Orbit, state.DT, Earth) < 1 {
+			t.Fatalf("orbit eclipsed at %s", state.DT)
+		}
+		states = append(states, state)
+	}
+	sc := NewEmptySC("wing", 100)
+	sc.Attitude = SunPointing
+	// Absorbing solar array facing the Sun, one meter away from the center of mass along the body X axis, without drag.
+	sc.BoxWing = &BoxWing{NewWing(2, []float64{0, 0, 1}, []float64{1, 0, 0}, 0, 0), 0}
+	P := solarPressure * math.Pow(AU/Norm(sunPosition(*o, start)), 2)
+	τ := sc.EnvironmentalTorque(states[0], ModerateSpaceWeather)
+	if !floats.EqualApprox(τ.SRP, []float64{0, 2 * P, 0}, 1e-3*P) {
+		t.Fatalf("invalid SRP torque %v N m (expected %g about Y)", τ.SRP, 2*P)
+	}
+	budget := sc.MomentumAccumulation(states, ModerateSpaceWeather)
+	// The Sun pointing attitude is nearly inertial, so the torque builds up linearly.
+	H := 2 * P * (2 * time.Hour).Seconds()
+	if last := budget.Body[len(budget.Body)-1]; !floats.EqualApprox(last, []float64{0, H, 0}, 1e-2*H) {
+		t.Fatalf("accumulated %v N m s instead of %g about Y", last, H)
+	}
+	if math.Abs(budget.Peak()-H) > 1e-2*H {
+		t.Fatalf("peak of %g N m s instead of %g", budget.Peak(), H)
+	}
+	if interval := budget.DesaturationInterval(1); math.Abs(interval.Seconds()-1/(2*P)) > 1e-2/(2*P) {
+		t.Fatalf("desaturation every %s", interval)
+	}
+	var buf bytes.Buffer
+	if err := WriteMomentumBudget(&buf, budget); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != len(states)+1 || !strings.HasPrefix(lines[1], "2018-03-01T00:00:00Z,") {
+		t.Fatalf("unexpected table:\n%s", lines[:2])
+	}
+}
@@ -0,0 +1,137 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+)
+
+// CanonicalUnits are the distance (DU, in km) and time (TU, in seconds) units of a central body, chosen such that
+// its gravitational parameter is one DU³/TU². The distance unit is the radius of the body, which keeps the state
+// of order one from asteroids to the Sun.
+type CanonicalUnits struct {
+	DU, TU float64
+}
+
+// NewCanonicalUnits returns the canonical units of the provided body.
+func NewCanonicalUnits(body CelestialObject) CanonicalUnits {
+	if body.Radius <= 0 || body.μ <= 0 {
+		panic(fmt.Errorf("no canonical units for %s", body.Name))
+	}
+	return CanonicalUnits{body.Radius, math.Sqrt(math.Pow(body.Radius, 3) / body.μ)}
+}
+
+// VU returns the velocity unit, in km/s.
+func (u CanonicalUnits) VU() float64 {
+	return u.DU / u.TU
+}
+
+// ToCanonical returns the position and velocity in canonical units from those in km and km/s.
+func (u CanonicalUnits) ToCanonical(R, V []float64) (Rc, Vc []float64) {
+	Rc, Vc = make([]float64, 3), make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		Rc[i] = R[i] / u.DU
+		Vc[i] = V[i] / u.VU()
+	}
+	return
+}
+
+// FromCanonical returns the position and velocity in km and km/s from those in canonical units.
+func (u CanonicalUnits) FromCanonical(Rc, Vc []float64) (R, V []float64) {
+	R, V = make([]float64, 3), make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		R[i] = Rc[i] * u.DU
+		V[i] = Vc[i] * u.VU()
+	}
+	return
+}
+
+func (u CanonicalUnits) String() string {
+	return fmt.Sprintf("DU=%f km TU=%f s", u.DU, u.TU)
+}
+
+// canonicalMission integrates a mission in the canonical units of the central body at the start of the
+// propagation, which improves the conditioning of the integration for very large and very small systems.
+// The state is converted at the boundary with the mission, so the orbit, the spacecraft, the perturbations and the
+// states published are all in km and seconds. The units are not changed on SOI transitions.
+type canonicalMission struct {
+	*Mission
+	units CanonicalUnits
+	scale []float64 // Conversion factor of each component of the state into canonical units
+}
+
+// newCanonicalMission returns the canonical integration of the provided mission.
+func newCanonicalMission(a *Mission) canonicalMission {
+	u := NewCanonicalUnits(a.Orbit.Origin)
+	// Scale of the position, velocity and the remaining parameters (fuel and Cr) of the STM.
+	dimScale := func(i int) float64 {
+		switch {
+		case i < 3:
+			return 1 / u.DU
+		case i < 6:
+			return 1 / u.VU()
+		default:
+			return 1
+		}
+	}
+	scale := make([]float64, a.stateSize())
+	for i := range scale {
+		scale[i] = dimScale(i)
+	}
+	if a.computeSTM {
+		// Φ maps the initial deviations onto the current ones.
+		rSTM, cSTM := a.perts.STMSize()
+		sIdx := rSTM + 1
+		for i := 0; i < rSTM; i++ {
+			for j := 0; j < cSTM; j++ {
+				scale[sIdx] = dimScale(i) / dimScale(j)
+				sIdx++
+			}
+		}
+	} else if a.perts.DMC != nil {
+		for i := a.dmcIndex(); i < a.dmcIndex()+3; i++ {
+			scale[i] = u.TU * u.TU / u.DU
+		}
+	}
+	return canonicalMission{a, u, scale}
+}
+
+// GetState returns the state in canonical units.
+func (c canonicalMission) GetState() []float64 {
+	s := c.Mission.GetState()
+	for i := range s {
+		s[i] *= c.scale[i]
+	}
+	return s
+}
+
+// SetState sets the state from canonical units. The state is updated in place on SOI transitions.
+func (c canonicalMission) SetState(t float64, s []float64) {
+	phys := c.physical(s)
+	c.Mission.SetState(t*c.units.TU, phys)
+	for i := range s {
+		s[i] = phys[i] * c.scale[i]
+	}
+}
+
+// Func returns the derivative of the state with respect to the canonical time.
+func (c canonicalMission) Func(t float64, f []float64) []float64 {
+	fDot := c.Mission.Func(t*c.units.TU, c.physical(f))
+	for i := range fDot {
+		fDot[i] *= c.scale[i] * c.units.TU
+	}
+	return fDot
+}
+
+// Stop implements the stop call of the integrator.
+func (c canonicalMission) Stop(t float64) bool {
+	return c.Mission.Stop(t * c.units.TU)
+}
+
+// physical returns a copy of the provided state in km and seconds.
+func (c canonicalMission) physical(s []float64) []float64 {
+	phys := make([]float64, len(s))
+	for i := range s {
+		phys[i] = s[i] / c.scale[i]
+	}
+	return phys
+}
@@ -0,0 +1,76 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestCanonicalUnits(t *testing.T) {
+	u := NewCanonicalUnits(Earth)
+	if !floats.EqualWithinAbs(u.TU, 806.8, 0.1) {
+		t.Fatalf("Earth TU=%f s", u.TU)
+	}
+	R, V := []float64{7000, -1000, 300}, []float64{1, 7.5, -0.2}
+	Rc, Vc := u.ToCanonical(R, V)
+	if !floats.EqualWithinAbs(Norm(Vc)*Norm(Vc)*Norm(Rc), Norm(V)*Norm(V)*Norm(R)/Earth.μ, 1e-12) {
+		t.Fatal("μ is not unity in canonical units")
+	}
+	R1, V1 := u.FromCanonical(Rc, Vc)
+	if !vectorsEqual(R, R1) || !vectorsEqual(V, V1) {
+		t.Fatalf("round trip failed: %+v %+v", R1, V1)
+	}
+	assertPanic(t, func() {
+		NewCanonicalUnits(CelestialObject{Name: "point"})
+	})
+}
+
+func TestCanonicalMission(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	propagate := func(o *Orbit, perts Perturbations, duration time.Duration, computeSTM, canonical bool) *Mission {
+		m := NewPreciseMission(NewEmptySC("canonical", 0), o, start, start.Add(duration), perts, time.Minute, computeSTM, ExportConfig{})
+		m.Canonical = canonical
+		m.Propagate()
+		return m
+	}
+	for _, tc := range []struct {
+		o        func() *Orbit
+		perts    Perturbations
+		duration time.Duration
+		stm      bool
+		tol      float64
+	}{
+		{func() *Orbit { return NewOrbitFromOE(7000, 0.01, 28.5, 10, 20, 30, Earth) }, Perturbations{Jn: 2}, 12 * time.Hour, true, 1e-6},
+		{func() *Orbit { return NewOrbitFromOE(1.5*AU, 0.1, 2, 10, 20, 30, Sun) }, Perturbations{}, 100 * 24 * time.Hour, false, 1e-3},
+	} {
+		phys := propagate(tc.o(), tc.perts, tc.duration, tc.stm, false)
+		canon := propagate(tc.o(), tc.perts, tc.duration, tc.stm, true)
+		if !canon.CurrentDT.Equal(phys.CurrentDT) {
+			t.Fatalf("canonical propagation ended at %s instead of %s", canon.CurrentDT, phys.CurrentDT)
+		}
+		ΔR := make([]float64, 3)
+		for i := 0; i < 3; i++ {
+			ΔR[i] = canon.Orbit.R()[i] - phys.Orbit.R()[i]
+		}
+		if Norm(ΔR)/phys.Orbit.RNorm() > tc.tol*1e-3 {
+			t.Fatalf("canonical propagation around %s differs by %e km", phys.Orbit.Origin.Name, Norm(ΔR))
+		}
+		if tc.stm {
+			r, c := canon.Φ.Dims()
+			for i := 0; i < r; i++ {
+				for j := 0; j < c; j++ {
+					if Φc, Φp := canon.Φ.At(i, j), phys.Φ.At(i, j); math.Abs(Φc-Φp) > tc.tol*math.Max(1, math.Abs(Φp)) {
+						t.Fatalf("Φ[%d,%d] = %e instead of %e", i, j, Φc, Φp)
+					}
+				}
+			}
+		}
+	}
+	assertPanic(t, func() {
+		m := NewPreciseMission(NewEmptySC("ks", 0), NewOrbitFromOE(7000, 0, 0, 0, 0, 0, Earth), start, start.Add(time.Hour), Perturbations{}, time.Minute, false, ExportConfig{})
+		m.Integrator, m.Canonical = KSIntegrator, true
+		m.Propagate()
+	})
+}
@@ -0,0 +1,83 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Capture stores an orbit insertion from a hyperbolic approach into an elliptical capture orbit, with the burn
+// performed at the common periapsis.
+type Capture struct {
+	Body          CelestialObject
+	VInf          float64 // Arrival hyperbolic excess velocity (km/s)
+	RP, RA        float64 // Periapsis and apoapsis radii of the capture orbit (km)
+	ΔV            float64 // Insertion Δv (km/s)
+	VPHyperbola   float64 // Velocity at periapsis on the approach hyperbola (km/s)
+	VPCapture     float64 // Velocity at periapsis on the capture orbit (km/s)
+	EHyperbola    float64 // Eccentricity of the approach hyperbola
+	TurnAngle     float64 // Turn angle of the approach hyperbola (radians)
+	B             float64 // B-plane magnitude, i.e. impact parameter (km)
+	νInf          float64 // True anomaly of the incoming asymptote (radians)
+	captureOrbitA float64
+}
+
+// NewCaptureFromApoapsis returns the capture into the orbit of the provided periapsis and apoapsis radii (km).
+func NewCaptureFromApoapsis(body CelestialObject, vInf, rP, rA float64) Capture {
+	if rP < body.Radius {
+		panic(fmt.Errorf("periapsis radius %f km is below the surface of %s", rP, body.Name))
+	}
+	if rA < rP {
+		panic("apoapsis radius is lower than the periapsis radius")
+	}
+	c := Capture{Body: body, VInf: vInf, RP: rP, RA: rA, captureOrbitA: (rP + rA) / 2}
+	c.VPHyperbola = math.Sqrt(vInf*vInf + 2*body.μ/rP)
+	c.VPCapture = math.Sqrt(body.μ * (2/rP - 1/c.captureOrbitA))
+	c.ΔV = c.VPHyperbola - c.VPCapture
+	c.EHyperbola = 1 + rP*vInf*vInf/body.μ
+	c.TurnAngle = GATurnAngle(vInf, rP, body)
+	c.B = rP * math.Sqrt(1+2*body.μ/(rP*vInf*vInf))
+	c.νInf = math.Acos(-1 / c.EHyperbola)
+	return c
+}
+
+// NewCaptureFromPeriod returns the capture into the orbit of the provided periapsis radius (km) and period.
+func NewCaptureFromPeriod(body CelestialObject, vInf, rP float64, period time.Duration) Capture {
+	a := math.Cbrt(body.μ * math.Pow(period.Seconds()/(2*math.Pi), 2))
+	return NewCaptureFromApoapsis(body, vInf, rP, 2*a-rP)
+}
+
+// AsymptoteTrueAnomaly returns the true anomaly of the incoming asymptote (radians, negative).
+func (c Capture) AsymptoteTrueAnomaly() float64 {
+	return -c.νInf
+}
+
+// CaptureOrbit returns the capture orbit with the provided orientation (angles in degrees), at periapsis.
+func (c Capture) CaptureOrbit(i, Ω, ω float64) *Orbit {
+	return NewOrbitFromOE(c.captureOrbitA, (c.RA-c.RP)/(c.RA+c.RP), i, Ω, ω, 0, c.Body)
+}
+
+// ApproachOrbit returns the approach hyperbola with the provided orientation (angles in degrees), at periapsis.
+func (c Capture) ApproachOrbit(i, Ω, ω float64) *Orbit {
+	R := Rot313Vec(-ω*deg2rad, -i*deg2rad, -Ω*deg2rad, []float64{c.RP, 0, 0})
+	V := Rot313Vec(-ω*deg2rad, -i*deg2rad, -Ω*deg2rad, []float64{0, c.VPHyperbola, 0})
+	return NewOrbitFromRV(R, V, c.Body)
+}
+
+// BurnDuration returns the duration and the fuel mass (kg) of the insertion burn performed by a spacecraft of
+// the provided initial mass (kg) with the provided thrust (N) and specific impulse (s).
+func (c Capture) BurnDuration(mass, thrust, isp float64) (time.Duration, float64) {
+	ve := isp * 9.807 // m/s
+	fuel := mass * (1 - math.Exp(-c.ΔV*1e3/ve))
+	return time.Duration(fuel*ve/thrust*1e9) * time.Nanosecond, fuel
+}
+
+// FiniteBurn returns the anti-tangential finite burn waypoint of the provided duration, centered on the periapsis
+// passage. The thrust is provided by the spacecraft thrusters, as for any waypoint.
+func (c Capture) FiniteBurn(periapsisDT time.Time, duration time.Duration, action *WaypointAction) *FiniteBurn {
+	return NewFiniteBurn(periapsisDT.Add(-duration/2), duration, AntiTangential{}, action)
+}
+
+func (c Capture) String() string {
+	return fmt.Sprintf("capture at %s with v∞=%.3f km/s into rP=%.1f km rA=%.1f km: Δv=%.3f km/s (B=%.1f km, e=%.4f)", c.Body.Name, c.VInf, c.RP, c.RA, c.ΔV, c.B, c.EHyperbola)
+}
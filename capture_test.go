@@ -0,0 +1,98 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestCaptureΔv(t *testing.T) {
+	rP := Mars.Radius + 300
+	rA := Mars.Radius + 33000
+	c := NewCaptureFromApoapsis(Mars, 2.5, rP, rA)
+	if !floats.EqualWithinAbs(c.ΔV, 0.837717, 1e-6) {
+		t.Fatalf("Δv=%f", c.ΔV)
+	}
+	if !floats.EqualWithinAbs(c.VPHyperbola-c.VPCapture, c.ΔV, 1e-12) {
+		t.Fatal("Δv is not the difference of the periapsis velocities")
+	}
+	if !floats.EqualWithinAbs(c.TurnAngle, GATurnAngle(2.5, rP, Mars), 1e-12) {
+		t.Fatal("invalid turn angle")
+	}
+	// The capture into a parabolic orbit is free.
+	if free := NewCaptureFromApoapsis(Mars, 1e-12, rP, 1e15); !floats.EqualWithinAbs(free.ΔV, 0, 1e-6) {
+		t.Fatalf("parabolic capture Δv=%f", free.ΔV)
+	}
+	// Same orbit defined from its period.
+	period := 2 * math.Pi * math.Sqrt(math.Pow((rP+rA)/2, 3)/Mars.μ)
+	cP := NewCaptureFromPeriod(Mars, 2.5, rP, time.Duration(period*1e9))
+	if !floats.EqualWithinAbs(cP.RA, rA, 1e-3) || !floats.EqualWithinAbs(cP.ΔV, c.ΔV, 1e-9) {
+		t.Fatalf("period capture differs: %s", cP)
+	}
+	if !floats.EqualWithinAbs(c.CaptureOrbit(30, 10, 20).Period().Seconds(), period, 1e-3) {
+		t.Fatal("invalid capture orbit period")
+	}
+	if len(c.String()) == 0 {
+		t.Fatal("capture string is empty")
+	}
+	assertPanic(t, func() {
+		NewCaptureFromApoapsis(Mars, 2.5, Mars.Radius-1, rA)
+	})
+	assertPanic(t, func() {
+		NewCaptureFromApoapsis(Mars, 2.5, rA, rP)
+	})
+}
+
+func TestCaptureGeometry(t *testing.T) {
+	c := NewCaptureFromApoapsis(Mars, 2.5, Mars.Radius+300, Mars.Radius+33000)
+	approach := c.ApproachOrbit(30, 10, 20)
+	capture := c.CaptureOrbit(30, 10, 20)
+	if !floats.EqualWithinAbs(approach.VNorm(), c.VPHyperbola, 1e-9) || !floats.EqualWithinAbs(capture.VNorm(), c.VPCapture, 1e-9) {
+		t.Fatal("invalid periapsis velocities")
+	}
+	if !floats.EqualApprox(approach.R(), capture.R(), 1e-9) {
+		t.Fatal("the approach and capture periapses differ")
+	}
+	if !floats.EqualApprox(Unit(approach.V()), Unit(capture.V()), 1e-9) {
+		t.Fatal("the insertion burn is not tangential")
+	}
+	_, e, _, _, _, _, _, _, _ := approach.Elements()
+	if !floats.EqualWithinAbs(e, c.EHyperbola, 1e-9) {
+		t.Fatalf("approach eccentricity %f != %f", e, c.EHyperbola)
+	}
+	// The B-plane magnitude is the semi-minor axis of the hyperbola.
+	a := Mars.μ / (c.VInf * c.VInf)
+	if !floats.EqualWithinAbs(c.B, a*math.Sqrt(e*e-1), 1e-6) {
+		t.Fatalf("B=%f", c.B)
+	}
+	if !floats.EqualWithinAbs(math.Cos(c.AsymptoteTrueAnomaly()), -1/e, 1e-12) || c.AsymptoteTrueAnomaly() > 0 {
+		t.Fatal("invalid asymptote true anomaly")
+	}
+}
+
+func TestCaptureFiniteBurn(t *testing.T) {
+	c := NewCaptureFromApoapsis(Mars, 2.5, Mars.Radius+300, Mars.Radius+33000)
+	mass, thrust, isp := 1000., 400., 320.
+	duration, fuel := c.BurnDuration(mass, thrust, isp)
+	// Rocket equation
+	if !floats.EqualWithinAbs(isp*9.807*math.Log(mass/(mass-fuel)), c.ΔV*1e3, 1e-6) {
+		t.Fatalf("fuel=%f kg does not match the rocket equation", fuel)
+	}
+	if !floats.EqualWithinAbs(duration.Seconds(), fuel*isp*9.807/thrust, 1e-6) {
+		t.Fatalf("burn duration=%s", duration)
+	}
+	periapsisDT := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	wp := c.FiniteBurn(periapsisDT, duration, nil)
+	o := *c.ApproachOrbit(0, 0, 0)
+	if ctrl, _ := wp.ThrustDirection(o, periapsisDT.Add(-duration/2-time.Second)); ctrl.Type() != coast {
+		t.Fatal("burn started too early")
+	}
+	if ctrl, _ := wp.ThrustDirection(o, periapsisDT); ctrl.Type() != antiTangential {
+		t.Fatal("burn not anti-tangential at periapsis")
+	}
+	if _, reached := wp.ThrustDirection(o, periapsisDT.Add(duration/2)); !reached {
+		t.Fatal("burn not completed")
+	}
+}
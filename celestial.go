@@ -2,16 +2,16 @@ package smd
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
+	"github.com/soniakeys/meeus/julian"
 	"github.com/soniakeys/meeus/planetposition"
 )
 
-const (
-	// AU is one astronomical unit in kilometers.
-	AU = 1.49597870700e8
-)
+// AU is one astronomical unit in kilometers. It is set with the constants set (cf. UseConstants).
+var AU = 1.49597870700e8
 
 // CelestialObject defines a celestial object.
 // Note: globe and elements may be nil; does not support satellites yet.
@@ -73,6 +73,23 @@ func (c *CelestialObject) HelioOrbit(dt time.Time) Orbit {
 	return *NewOrbitFromRV(R, V, Sun)
 }
 
+// RotationAngle returns the angle (in radians) of the prime meridian of this body from the X axis of the frame
+// about which it rotates. The Greenwich apparent sidereal time is used for the Earth, which is measured from the true
+// equinox of date and not from the J2000 X axis (cf. Inertial2BodyFixed), the IAU prime meridian angle W for the
+// other bodies with rotational elements (where the X axis is the ascending node of the equator on the ICRF equator),
+// and a uniform rotation since J2000 otherwise.
+func (c CelestialObject) RotationAngle(dt time.Time) float64 {
+	if c.Name == "Earth" {
+		return GAST(dt)
+	}
+	if r, err := c.RotationalElements(); err == nil {
+		_, _, W := r.At(dt)
+		return W
+	}
+	d := julian.TimeToJD(dt.UTC()) - 2451545.0
+	return math.Mod(c.RotRate*d*86400, 2*math.Pi)
+}
+
 // CelestialObjectFromString returns the object from its name
 func CelestialObjectFromString(name string) (CelestialObject, error) {
 	switch strings.ToLower(name) {
@@ -94,6 +111,8 @@ func CelestialObjectFromString(name string) (CelestialObject, error) {
 		return Neptune, nil
 	case "pluto":
 		return Pluto, nil
+	case "moon":
+		return Moon, nil
 	default:
 		return CelestialObject{}, fmt.Errorf("undefined planet '%s'", name)
 	}
@@ -104,6 +123,9 @@ func CelestialObjectFromString(name string) (CelestialObject, error) {
 // Sun is our closest star.
 var Sun = CelestialObject{"Sun", 695700, -1, 1.32712440017987e11, 0.0, 0.0, -1, 0, 0, 0, 0, nil}
 
+// Moon is our only natural satellite. Its semi-major axis and SOI are with respect to the Earth.
+var Moon = CelestialObject{"Moon", 1737.4, 384400, 4.9028e3, 6.68, 5.145, 66100, 202.7e-6, 0, 0, 2.6617e-6, nil}
+
 // Venus is poisonous.
 var Venus = CelestialObject{"Venus", 6051.8, 108208601, 3.24858599e5, 117.36, 3.39458, 0.616e6, 0.000027, 0, 0, 0, nil}
 
@@ -111,7 +133,7 @@ var Venus = CelestialObject{"Venus", 6051.8, 108208601, 3.24858599e5, 117.36, 3.
 var Earth = CelestialObject{"Earth", 6378.1363, 149598023, 3.98600433e5, 23.4393, 0.00005, 924645.0, 1082.6269e-6, -2.5324e-6, -1.6204e-6, 7.292115900231276e-5, nil}
 
 // Mars is the vacation place.
-var Mars = CelestialObject{"Mars", 3396.19, 227939282.5616, 4.28283100e4, 25.19, 1.85, 576000, 1964e-6, 36e-6, -18e-6, 3.878785053314509e-05, nil}
+var Mars = CelestialObject{"Mars", 3396.19, 227939282.5616, 4.28283100e4, 25.19, 1.85, 576000, 1964e-6, 36e-6, -18e-6, 7.088218066303858e-05, nil}
 
 // Jupiter is big.
 var Jupiter = CelestialObject{"Jupiter", 71492.0, 778298361, 1.266865361e8, 3.13, 1.30326966, 48.2e6, 0.01475, 0, -0.00058, 0, nil}
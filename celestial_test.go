@@ -31,6 +31,39 @@ func TestCelestialObject(t *testing.T) {
 	}
 }
 
+func TestCelestialObjectEquals(t *testing.T) {
+	earth := Earth
+	if !earth.Equals(Earth) {
+		t.Fatal("a copy of the Earth is not the Earth")
+	}
+	earth.J2 *= 2
+	if earth.Equals(Earth) || Earth.Equals(earth) {
+		t.Fatal("a body with the same name but a different J2 is the Earth")
+	}
+	earth = Earth
+	earth.μ *= 1.001
+	if earth.Equals(Earth) {
+		t.Fatal("a body with the same name but a different GM is the Earth")
+	}
+}
+
+func TestRotRate(t *testing.T) {
+	// Sidereal days of 23 h 56 min 4.0905 s for the Earth and 24 h 37 min 22.663 s for Mars.
+	for _, body := range []struct {
+		object   CelestialObject
+		sidereal float64
+	}{{Earth, 86164.0905}, {Mars, 88642.663}} {
+		if !floats.EqualWithinRel(body.object.RotRate, 2*math.Pi/body.sidereal, 1e-8) {
+			t.Fatalf("%s rotates at %e rad/s instead of once per %f s", body.object.Name, body.object.RotRate, body.sidereal)
+		}
+	}
+	// And consistent with the IAU prime meridian rate of Mars.
+	r, _ := Mars.RotationalElements()
+	if !floats.EqualWithinRel(Mars.RotRate, Deg2rad(r.WRate)/86400, 1e-12) {
+		t.Fatalf("Mars rotates at %e rad/s instead of %f deg/day", Mars.RotRate, r.WRate)
+	}
+}
+
 func TestPanics(t *testing.T) {
 	assertPanic(t, func() {
 		fake := CelestialObject{"Fake", -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, nil}
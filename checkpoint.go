@@ -0,0 +1,212 @@
+package smd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// FilterSnapshot stores one estimate of an orbit determination filter, as needed to smooth the arc afterwards.
+type FilterSnapshot struct {
+	DT         time.Time
+	Estimate   *mat64.Vector   // State deviation from the reference trajectory
+	Covariance *mat64.SymDense // Covariance of the state deviation
+	Φ          *mat64.Dense    // State transition matrix from the previous snapshot
+}
+
+// FilterCheckpoint stores the state of an orbit determination filter at a pass boundary, so that it can be
+// restarted later with new measurements, e.g. for daily orbit updates instead of a single run over all the tracking.
+type FilterCheckpoint struct {
+	Epoch      time.Time
+	Orbit      Orbit           // Reference trajectory at epoch
+	Estimate   *mat64.Vector   // State deviation from the reference trajectory
+	Covariance *mat64.SymDense // Covariance of the state deviation
+	Smoother   []FilterSnapshot
+}
+
+// NewFilterCheckpoint returns a checkpoint of the filter estimate and covariance on the provided reference state.
+// The covariance is copied, so the filter may continue afterwards.
+func NewFilterCheckpoint(state State, estimate *mat64.Vector, P mat64.Symmetric) FilterCheckpoint {
+	R, V := state.Orbit.RV()
+	return FilterCheckpoint{
+		Epoch:      state.DT,
+		Orbit:      *NewOrbitFromRV(R, V, state.Orbit.Origin),
+		Estimate:   copyVector(estimate),
+		Covariance: copySym(P),
+	}
+}
+
+// Snapshot appends the provided estimate to the smoother buffer of this checkpoint.
+func (c *FilterCheckpoint) Snapshot(dt time.Time, estimate *mat64.Vector, P mat64.Symmetric, Φ mat64.Matrix) {
+	var ΦCopy *mat64.Dense
+	if Φ != nil {
+		ΦCopy = mat64.DenseCopyOf(Φ)
+	}
+	c.Smoother = append(c.Smoother, FilterSnapshot{dt, copyVector(estimate), copySym(P), ΦCopy})
+}
+
+// WarmStart returns the orbit and covariance from which to restart the filter: the estimated deviation is applied
+// to the reference trajectory, so the restarted filter starts with a null deviation.
+func (c FilterCheckpoint) WarmStart() (*Orbit, *mat64.SymDense) {
+	R, V := c.Orbit.RV()
+	R = []float64{R[0], R[1], R[2]}
+	V = []float64{V[0], V[1], V[2]}
+	if c.Estimate != nil {
+		for i := 0; i < 3; i++ {
+			R[i] += c.Estimate.At(i, 0)
+			V[i] += c.Estimate.At(i+3, 0)
+		}
+	}
+	var P *mat64.SymDense
+	if c.Covariance != nil {
+		P = copySym(c.Covariance)
+	}
+	return NewOrbitFromRV(R, V, c.Orbit.Origin), P
+}
+
+func (c FilterCheckpoint) String() string {
+	return fmt.Sprintf("checkpoint @ %s: %s (%d smoother snapshots)", c.Epoch, c.Orbit, len(c.Smoother))
+}
+
+// checkpointJSON is the serialized form of FilterCheckpoint.
+type checkpointJSON struct {
+	Epoch      time.Time
+	Body       string
+	R, V       []float64
+	Estimate   []float64
+	Covariance []float64
+	Smoother   []snapshotJSON `json:",omitempty"`
+}
+
+type snapshotJSON struct {
+	DT         time.Time
+	Estimate   []float64
+	Covariance []float64
+	Φ          []float64 `json:",omitempty"`
+}
+
+// WriteFilterCheckpoint writes the checkpoint as JSON.
+func WriteFilterCheckpoint(w io.Writer, c FilterCheckpoint) error {
+	R, V := c.Orbit.RV()
+	out := checkpointJSON{c.Epoch, c.Orbit.Origin.Name, R, V, vectorData(c.Estimate), symData(c.Covariance), nil}
+	for _, s := range c.Smoother {
+		snap := snapshotJSON{DT: s.DT, Estimate: vectorData(s.Estimate), Covariance: symData(s.Covariance)}
+		if s.Φ != nil {
+			snap.Φ = matrixData(s.Φ)
+		}
+		out.Smoother = append(out.Smoother, snap)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// ReadFilterCheckpoint reads a checkpoint written by WriteFilterCheckpoint.
+func ReadFilterCheckpoint(r io.Reader) (FilterCheckpoint, error) {
+	var in checkpointJSON
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return FilterCheckpoint{}, err
+	}
+	body, err := CelestialObjectFromString(in.Body)
+	if err != nil {
+		return FilterCheckpoint{}, err
+	}
+	if len(in.R) != 3 || len(in.V) != 3 {
+		return FilterCheckpoint{}, fmt.Errorf("invalid reference state R=%v V=%v", in.R, in.V)
+	}
+	c := FilterCheckpoint{Epoch: in.Epoch, Orbit: *NewOrbitFromRV(in.R, in.V, body)}
+	c.Estimate = dataVector(in.Estimate)
+	if c.Covariance, err = dataSym(in.Covariance); err != nil {
+		return FilterCheckpoint{}, err
+	}
+	for _, s := range in.Smoother {
+		snap := FilterSnapshot{DT: s.DT, Estimate: dataVector(s.Estimate)}
+		if snap.Covariance, err = dataSym(s.Covariance); err != nil {
+			return FilterCheckpoint{}, err
+		}
+		if len(s.Φ) > 0 {
+			n := len(s.Estimate)
+			if len(s.Φ) != n*n {
+				return FilterCheckpoint{}, fmt.Errorf("snapshot %s: Φ has %d elements, expected %d", s.DT, len(s.Φ), n*n)
+			}
+			snap.Φ = mat64.NewDense(n, n, s.Φ)
+		}
+		c.Smoother = append(c.Smoother, snap)
+	}
+	return c, nil
+}
+
+func copyVector(v *mat64.Vector) *mat64.Vector {
+	if v == nil {
+		return nil
+	}
+	return mat64.NewVector(v.Len(), vectorData(v))
+}
+
+func copySym(P mat64.Symmetric) *mat64.SymDense {
+	if P == nil {
+		return nil
+	}
+	n := P.Symmetric()
+	S := mat64.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			S.SetSym(i, j, P.At(i, j))
+		}
+	}
+	return S
+}
+
+func vectorData(v *mat64.Vector) []float64 {
+	if v == nil {
+		return nil
+	}
+	data := make([]float64, v.Len())
+	for i := range data {
+		data[i] = v.At(i, 0)
+	}
+	return data
+}
+
+func symData(P *mat64.SymDense) []float64 {
+	if P == nil {
+		return nil
+	}
+	return matrixData(P)
+}
+
+// matrixData returns the elements of the matrix in row major order.
+func matrixData(m mat64.Matrix) []float64 {
+	r, c := m.Dims()
+	data := make([]float64, 0, r*c)
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			data = append(data, m.At(i, j))
+		}
+	}
+	return data
+}
+
+func dataVector(data []float64) *mat64.Vector {
+	if len(data) == 0 {
+		return nil
+	}
+	return mat64.NewVector(len(data), data)
+}
+
+func dataSym(data []float64) (*mat64.SymDense, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	n := 0
+	for n*n < len(data) {
+		n++
+	}
+	if n*n != len(data) {
+		return nil, fmt.Errorf("covariance of %d elements is not square", len(data))
+	}
+	return mat64.NewSymDense(n, data), nil
+}
@@ -0,0 +1,84 @@
+package smd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestFilterCheckpoint(t *testing.T) {
+	dt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	o := NewOrbitFromOE(7000, 0.001, 30, 80, 40, 0, Earth)
+	estimate := mat64.NewVector(6, []float64{1, 2, 3, 1e-3, 2e-3, 3e-3})
+	P := mat64.NewSymDense(6, nil)
+	for i := 0; i < 6; i++ {
+		P.SetSym(i, i, float64(i+1))
+	}
+	P.SetSym(0, 1, 0.5)
+	c := NewFilterCheckpoint(State{DT: dt, Orbit: *o}, estimate, P)
+	// The checkpoint must not change with the filter.
+	P.SetSym(0, 0, 100)
+	estimate.SetVec(0, 100)
+	if c.Covariance.At(0, 0) != 1 || c.Estimate.At(0, 0) != 1 {
+		t.Fatal("checkpoint shares memory with the filter")
+	}
+	c.Snapshot(dt.Add(-time.Minute), estimate, P, DenseIdentity(6))
+	c.Snapshot(dt, estimate, P, nil)
+
+	var buf bytes.Buffer
+	if err := WriteFilterCheckpoint(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadFilterCheckpoint(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !read.Epoch.Equal(dt) || !read.Orbit.Origin.Equals(Earth) || !floats.Equal(read.Orbit.R(), o.R()) || !floats.Equal(read.Orbit.V(), o.V()) {
+		t.Fatalf("invalid reference: %s", read)
+	}
+	if !mat64.Equal(read.Estimate, c.Estimate) || !mat64.Equal(read.Covariance, c.Covariance) {
+		t.Fatal("invalid estimate or covariance")
+	}
+	if len(read.Smoother) != 2 || !mat64.Equal(read.Smoother[0].Φ, DenseIdentity(6)) || read.Smoother[1].Φ != nil {
+		t.Fatalf("invalid smoother buffer: %+v", read.Smoother)
+	}
+	if !read.Smoother[0].DT.Equal(dt.Add(-time.Minute)) || read.Smoother[1].Estimate.At(0, 0) != 100 {
+		t.Fatal("invalid smoother snapshot")
+	}
+	// Warm start applies the deviation to the reference.
+	warm, warmP := read.WarmStart()
+	R, V := o.RV()
+	if !floats.EqualApprox(warm.R(), []float64{R[0] + 1, R[1] + 2, R[2] + 3}, 1e-9) || !floats.EqualApprox(warm.V(), []float64{V[0] + 1e-3, V[1] + 2e-3, V[2] + 3e-3}, 1e-12) {
+		t.Fatalf("invalid warm start %s", warm)
+	}
+	if warmP.At(0, 1) != 0.5 || !floats.Equal(read.Orbit.R(), R) {
+		t.Fatal("warm start changed the checkpoint")
+	}
+	if _, err := ReadFilterCheckpoint(bytes.NewBufferString(`{"Body":"Vulcan"}`)); err == nil {
+		t.Fatal("unknown body should fail")
+	}
+	if _, err := ReadFilterCheckpoint(bytes.NewBufferString(`{"Body":"Earth","R":[1,2,3],"V":[1,2,3],"Covariance":[1,2]}`)); err == nil {
+		t.Fatal("non square covariance should fail")
+	}
+}
+
+func TestFilterCheckpointEmpty(t *testing.T) {
+	c := NewFilterCheckpoint(State{DT: time.Now(), Orbit: *NewOrbitFromOE(7000, 0, 30, 0, 0, 0, Earth)}, nil, nil)
+	var buf bytes.Buffer
+	if err := WriteFilterCheckpoint(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadFilterCheckpoint(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Estimate != nil || read.Covariance != nil {
+		t.Fatal("expected no estimate nor covariance")
+	}
+	if warm, _ := read.WarmStart(); !floats.Equal(warm.R(), c.Orbit.R()) {
+		t.Fatal("warm start without estimate should not change the orbit")
+	}
+}
@@ -182,14 +182,12 @@ func main() {
 				if !more {
 					break
 				}
-				Δt := state.DT.Sub(startDT).Seconds()
-				θgst := Δt * scOrbit.Origin.RotRate
 				for _, st := range stations {
 					if state.DT.Sub(stationSampling[st.Name]).Seconds() >= measurementSampling.Seconds() {
 						stationSampling[st.Name] = state.DT
-						measurement := st.PerformMeasurement(θgst, state)
+						measurement := st.Measure(state)
 						if measurement.Visible {
-							f.WriteString(fmt.Sprintf("\"%s\",\"%s\",%f,%f,%s\n", st.Name, state.DT.Format(dateFormat), julian.TimeToJD(state.DT), θgst, measurement.ShortCSV()))
+							f.WriteString(fmt.Sprintf("\"%s\",\"%s\",%f,%f,%s\n", st.Name, state.DT.Format(dateFormat), julian.TimeToJD(state.DT), measurement.Timeθgst, measurement.ShortCSV()))
 							numVis++
 						}
 					}
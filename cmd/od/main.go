@@ -143,8 +143,32 @@ func main() {
 	filterStartDT := confReadJDEorTime("filter.start")
 	filterEndDT := confReadJDEorTime("filter.end")
 
+	// Warm start from the checkpoint of a previous run.
+	var restart *smd.FilterCheckpoint
+	if restartFile := viper.GetString("filter.restart"); len(restartFile) > 0 {
+		restart = readCheckpoint(restartFile)
+		log.Printf("[info] restarting from %s", restart)
+	}
+
 	// Check overlap between measurement file and the dates of the mission.
-	if viper.GetBool("mission.autodate") {
+	if restart != nil {
+		// Only process the measurements after the checkpoint.
+		startDT = restart.Epoch
+		if viper.GetBool("mission.autodate") {
+			endDT = measEndDT
+		}
+		newMeas := []time.Time{}
+		for _, dt := range measurementTimes {
+			if dt.After(startDT) {
+				newMeas = append(newMeas, dt)
+			}
+		}
+		if len(newMeas) == 0 {
+			log.Fatalf("[error] no measurement after the checkpoint at %s", startDT)
+		}
+		measurementTimes = newMeas
+		scOrbit, _ = restart.WarmStart()
+	} else if viper.GetBool("mission.autodate") {
 		startDT = measStartDT
 		endDT = measEndDT
 	} else if startDT.After(measEndDT) {
@@ -291,6 +315,23 @@ func main() {
 		prevP.SetSym(i, i, covarDistance)
 		prevP.SetSym(i+3, i+3, covarVelocity)
 	}
+	if restart != nil && restart.Covariance != nil {
+		_, prevP = restart.WarmStart()
+	}
+
+	// Checkpoints are written at the end of each pass, i.e. at the last measurement before a tracking gap.
+	checkpointFile := viper.GetString("filter.checkpoint")
+	passGap := viper.GetDuration("filter.passGap")
+	if passGap == 0 {
+		passGap = 30 * time.Minute
+	}
+	passEnds := make(map[time.Time]bool)
+	for i, dt := range measurementTimes {
+		if i == len(measurementTimes)-1 || measurementTimes[i+1].Sub(dt) > passGap {
+			passEnds[dt.Truncate(time.Second)] = true
+		}
+	}
+	var arc smd.FilterCheckpoint // Smoother buffer of the current pass
 
 	visibilityErrors := 0
 
@@ -335,7 +376,7 @@ func main() {
 		roundedDT := state.DT.Truncate(time.Second)
 		measurements, exists := measurements[roundedDT]
 		if !exists {
-			if measNo == 0 {
+			if measNo == 0 && restart == nil {
 				panic(fmt.Errorf("should start KF at first measurement: \n%s (got)\n%s (exp)", roundedDT, measurementTimes[0]))
 			}
 			// There is no truth measurement here, let's only predict the KF covariance.
@@ -351,10 +392,11 @@ func main() {
 			}
 			if smoothing {
 				// Save to history in order to perform smoothing.
-				estHistory[stateNo-1] = timedEstimate{state.DT, estI}
+				estHistory[stateNo-1] = timedEstimate{state, estI}
+				arc.Snapshot(state.DT, est.State(), est.Covariance(), state.Φ)
 			} else {
 				// Stream to CSV file
-				estChan <- timedEstimate{state.DT, est}
+				estChan <- timedEstimate{state, est}
 			}
 			continue
 		}
@@ -388,7 +430,7 @@ func main() {
 				continue
 			}
 			// Compute "real" measurement
-			computedObservation := measurement.Station.PerformMeasurement(measurement.Timeθgst, state)
+			computedObservation := measurement.Station.Measure(state)
 			if !computedObservation.Visible {
 				fmt.Printf("[WARN] #%05d station %s should see the SC but does not\n", measNo, measurement.Station.Name)
 				visibilityErrors++
@@ -419,27 +461,8 @@ func main() {
 		if sncEnabled {
 			if Δt < sncDisableTime {
 				if sncRIC {
-					// Build the RIC DCM
-					rUnit := smd.Unit(state.Orbit.R())
-					cUnit := smd.Unit(state.Orbit.H())
-					iUnit := smd.Unit(smd.Cross(rUnit, cUnit))
-					dcmVals := make([]float64, 9)
-					for i := 0; i < 3; i++ {
-						dcmVals[i] = rUnit[i]
-						dcmVals[i+3] = cUnit[i]
-						dcmVals[i+6] = iUnit[i]
-					}
-					// Update the Q matrix in the PQW
-					dcm := mat64.NewDense(3, 3, dcmVals)
-					var QECI, QECI0 mat64.Dense
-					QECI0.Mul(noiseQ, dcm.T())
-					QECI.Mul(dcm, &QECI0)
-					QECISym, err := gokalman.AsSymDense(&QECI)
-					if err != nil {
-						fmt.Printf("[ERR!] QECI is not symmertric!")
-						panic(err)
-					}
-					kf.SetNoise(gokalman.NewNoiseless(QECISym, noiseR))
+					// Rotate the Q matrix from the RIC frame
+					kf.SetNoise(gokalman.NewNoiseless(smd.RIC2ECICovariance(state.Orbit, noiseQ), noiseR))
 				}
 				// Only enable SNC for small time differences between measurements.
 				Γtop := gokalman.ScaledDenseIdentity(3, math.Pow(Δt, 2)/2)
@@ -470,10 +493,18 @@ func main() {
 		// Stream to CSV file
 		if smoothing {
 			// Save to history in order to perform smoothing.
-			estHistory[stateNo-1] = timedEstimate{state.DT, est}
+			estHistory[stateNo-1] = timedEstimate{state, est}
+			arc.Snapshot(state.DT, est.State(), est.Covariance(), state.Φ)
 		} else {
 			// Stream to CSV file
-			estChan <- timedEstimate{state.DT, est}
+			estChan <- timedEstimate{state, est}
+		}
+		if len(checkpointFile) > 0 && passEnds[roundedDT] {
+			// Must be done prior to updating the reference trajectory in EKF mode.
+			checkpoint := smd.NewFilterCheckpoint(state, est.State(), est.Covariance())
+			checkpoint.Smoother = arc.Smoother
+			writeCheckpoint(checkpointFile, checkpoint)
+			arc.Smoother = nil
 		}
 		// If in EKF, update the reference trajectory.
 		if kf.EKFEnabled() {
@@ -564,19 +595,22 @@ func processEst(fn string, timeEstChan chan (timedEstimate), startDT time.Time)
 	numMeasurements := 0
 	rmsPosition := 0.0
 	rmsVelocity := 0.0
+	var ricErrors []smd.RICError
 	ce, _ := gokalman.NewCustomCSVExporter([]string{"_epoch", "_seconds", "_minutes", "_hours", "_days", "x", "y", "z", "xDot", "yDot", "zDot"}, ".", fn+".csv", 3)
 	for {
 		timedEst, more := <-timeEstChan
 		if !more {
 			ce.Close()
+			writeRICErrors(fn, ricErrors)
 			wg.Done()
 			break
 		}
 		// Compute time delta
-		deltaT := timedEst.dt.Sub(startDT)
-		ce.WriteRaw(fmt.Sprintf("\"%s\",%f,%f,%f,%f,", timedEst.dt.Format(dateFormat), deltaT.Seconds(), deltaT.Minutes(), deltaT.Hours(), deltaT.Hours()/24))
+		deltaT := timedEst.state.DT.Sub(startDT)
+		ce.WriteRaw(fmt.Sprintf("\"%s\",%f,%f,%f,%f,", timedEst.state.DT.Format(dateFormat), deltaT.Seconds(), deltaT.Minutes(), deltaT.Hours(), deltaT.Hours()/24))
 		est := timedEst.est
 		ce.Write(est)
+		ricErrors = append(ricErrors, smd.NewRICError(timedEst.state, est.State(), est.Covariance()))
 		for i := 0; i < 3; i++ {
 			rmsPosition += math.Pow(est.State().At(i, 0), 2)
 			rmsVelocity += math.Pow(est.State().At(i+3, 0), 2)
@@ -591,7 +625,46 @@ func processEst(fn string, timeEstChan chan (timedEstimate), startDT time.Time)
 	fmt.Printf("=== RMS ===\nPosition = %f\tVelocity = %f\n", rmsPosition, rmsVelocity)
 }
 
+// writeRICErrors writes the errors of the estimates in the RIC frame of the reference trajectory.
+func writeRICErrors(fn string, ricErrors []smd.RICError) {
+	f, err := os.Create(fmt.Sprintf("%s-ric.csv", fn))
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	if err := smd.WriteRICErrors(f, ricErrors); err != nil {
+		panic(err)
+	}
+}
+
+// readCheckpoint reads the filter checkpoint from the provided file.
+func readCheckpoint(fn string) *smd.FilterCheckpoint {
+	f, err := os.Open(fn)
+	if err != nil {
+		log.Fatalf("[error] %s", err)
+	}
+	defer f.Close()
+	checkpoint, err := smd.ReadFilterCheckpoint(f)
+	if err != nil {
+		log.Fatalf("[error] could not read checkpoint %s: %s", fn, err)
+	}
+	return &checkpoint
+}
+
+// writeCheckpoint writes the filter checkpoint to the provided file, overwriting the one of the previous pass.
+func writeCheckpoint(fn string, checkpoint smd.FilterCheckpoint) {
+	f, err := os.Create(fn)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	if err := smd.WriteFilterCheckpoint(f, checkpoint); err != nil {
+		panic(err)
+	}
+	log.Printf("[info] wrote %s", checkpoint)
+}
+
 type timedEstimate struct {
-	dt  time.Time
-	est gokalman.Estimate
+	state smd.State
+	est   gokalman.Estimate
 }
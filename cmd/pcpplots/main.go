@@ -2,11 +2,14 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/ChristopherRabotin/smd"
+	"github.com/gonum/matrix/mat64"
 	"github.com/soniakeys/meeus/julian"
 	"github.com/spf13/viper"
 )
@@ -97,5 +100,22 @@ func main() {
 		log.Fatal(plerr)
 	}
 	smd.PCPGenerator(initPlanet, arrivalPlanet, initLaunch, maxLaunch, initArrival, maxArrival, resoInit, resoArr, ttype, c3plot, verbose, true)
+	if viper.GetBool("General.png") {
+		// Render the contour plots directly.
+		pcp := smd.NewPorkchop(initPlanet, arrivalPlanet, initLaunch, maxLaunch, initArrival, maxArrival, ttype)
+		pcp.LaunchStep = time.Duration(24/resoInit) * time.Hour
+		pcp.ArrivalStep = time.Duration(24/resoArr) * time.Hour
+		grids := pcp.Grids()
+		for name, grid := range map[string]*mat64.Dense{"c3": grids.C3, "vinf": grids.VInfArrival, "tof": grids.TOF} {
+			f, err := os.Create(fmt.Sprintf("./contour-%s-to-%s-%s.png", initPlanet.Name, arrivalPlanet.Name, name))
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := smd.WritePorkchopPNG(f, grid, smd.PorkchopLevels(grid, 10)); err != nil {
+				log.Fatal(err)
+			}
+			f.Close()
+		}
+	}
 	return
 }
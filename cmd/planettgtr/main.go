@@ -165,7 +165,7 @@ func sc2Mars(fuel float64) *smd.Spacecraft {
 				smd.NewReachDistance(distance, true, ref2Mars),
 				smd.NewLoiter(time.Hour, nil),
 				smd.NewToElliptical(nil),
-				smd.NewOrbitTarget(*hyper, nil, smd.Naasz, smd.OptiΔaCL, smd.OptiΔiCL),
+				smd.NewOrbitTarget(*hyper, nil, smd.Naasz, smd.TimeOptimal, smd.OptiΔaCL, smd.OptiΔiCL),
 				smd.NewLoiter(7*24*time.Hour, nil),
 			})
 	}
@@ -196,7 +196,7 @@ func sc2Earth(fuel float64) *smd.Spacecraft {
 				smd.NewReachDistance(distance+smd.Earth.SOI, false, ref2Earth),
 				smd.NewLoiter(time.Hour, nil),
 				smd.NewToElliptical(nil),
-				smd.NewOrbitTarget(*hyper, nil, smd.Naasz, smd.OptiΔaCL, smd.OptiΔiCL),
+				smd.NewOrbitTarget(*hyper, nil, smd.Naasz, smd.TimeOptimal, smd.OptiΔaCL, smd.OptiΔiCL),
 				smd.NewLoiter(7*24*time.Hour, nil),
 			})
 	}
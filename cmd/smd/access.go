@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ChristopherRabotin/smd"
+)
+
+// access propagates the mission of a scenario and reports the visibility windows of the stations.
+func access(args []string) {
+	flags := flag.NewFlagSet("access", flag.ExitOnError)
+	scenarioPath := flags.String("scenario", "", "scenario file (TOML, YAML or JSON)")
+	stationNames := flags.String("stations", "builtin.DSS13,builtin.DSS34,builtin.DSS65", "comma separated stations, builtin or defined in the scenario")
+	output := flags.String("output", "", "CSV file of the contact schedule, printed if empty")
+	flags.Parse(args)
+	if *scenarioPath == "" {
+		log.Fatal("no scenario provided")
+	}
+	sc, err := loadScenario(*scenarioPath)
+	if err != nil {
+		log.Fatalf("%s: %s", *scenarioPath, err)
+	}
+	var stations []smd.Station
+	for _, name := range strings.Split(*stationNames, ",") {
+		st, err := loadStation(strings.TrimSpace(name))
+		if err != nil {
+			log.Fatal(err)
+		}
+		stations = append(stations, st)
+	}
+
+	stateChan := make(chan (smd.State), 100)
+	sc.mission.RegisterStateChan(stateChan)
+	done := make(chan ([]smd.State))
+	go func() {
+		var states []smd.State
+		for state := range stateChan {
+			states = append(states, state)
+		}
+		done <- states
+	}()
+	sc.mission.PropagateUntil(sc.end, true)
+	windows := smd.AccessWindows(<-done, stations...)
+
+	if *output == "" {
+		for _, w := range windows {
+			fmt.Printf("%s\tAOS %s\tLOS %s\t%s\tmax el. %.1f°\n", w.Station, w.Start.Format(dateFormat), w.End.Format(dateFormat), w.Duration(), w.MaxElevation)
+		}
+		return
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = smd.WriteTrackingSchedule(f, windows); err != nil {
+		log.Fatal(err)
+	}
+	f.Close()
+	log.Printf("[info] %d visibility windows written to %s", len(windows), *output)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ChristopherRabotin/smd"
+)
+
+// lambert solves the Lambert problem between two bodies or two positions, and prints the transfer.
+func lambert(args []string) {
+	flags := flag.NewFlagSet("lambert", flag.ExitOnError)
+	from := flags.String("from", "", "departure body (heliocentric transfer)")
+	to := flags.String("to", "", "arrival body (heliocentric transfer)")
+	launch := flags.String("launch", "", "departure date time or JDE, with -from and -to")
+	arrival := flags.String("arrival", "", "arrival date time or JDE, with -from and -to")
+	ri := flags.String("ri", "", "initial position x,y,z (km), instead of -from")
+	vi := flags.String("vi", "", "velocity x,y,z (km/s) before the departure, optional with -ri")
+	rf := flags.String("rf", "", "final position x,y,z (km), instead of -to")
+	vf := flags.String("vf", "", "velocity x,y,z (km/s) after the arrival, optional with -rf")
+	tof := flags.Duration("tof", 0, "time of flight, with -ri and -rf")
+	center := flags.String("body", "Sun", "central body of the transfer, with -ri and -rf")
+	ttype := flags.Int("type", 0, "transfer type (3 or 4 for one revolution, otherwise determined by the Lambert solver)")
+	flags.Parse(args)
+
+	var Ri, Vi, Rf, Vf []float64
+	var body smd.CelestialObject
+	var err error
+	if *from != "" || *to != "" {
+		// Heliocentric transfer between two bodies.
+		body = smd.Sun
+		fromBody, err := smd.CelestialObjectFromString(*from)
+		if err != nil {
+			log.Fatal(err)
+		}
+		toBody, err := smd.CelestialObjectFromString(*to)
+		if err != nil {
+			log.Fatal(err)
+		}
+		launchDT, err := parseJDEorTime(*launch)
+		if err != nil {
+			log.Fatal(err)
+		}
+		arrivalDT, err := parseJDEorTime(*arrival)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*tof = arrivalDT.Sub(launchDT)
+		Ri, Vi = smd.HelioEphemeris(fromBody, launchDT)
+		Rf, Vf = smd.HelioEphemeris(toBody, arrivalDT)
+		fmt.Printf("%s (%s) -> %s (%s)\n", fromBody.Name, launchDT.Format(dateFormat), toBody.Name, arrivalDT.Format(dateFormat))
+	} else {
+		if body, err = smd.CelestialObjectFromString(*center); err != nil {
+			log.Fatal(err)
+		}
+		for _, vec := range []struct {
+			str string
+			dst *[]float64
+		}{{*ri, &Ri}, {*vi, &Vi}, {*rf, &Rf}, {*vf, &Vf}} {
+			if vec.str == "" {
+				continue
+			}
+			if *vec.dst, err = parseVector(vec.str); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if Ri == nil || Rf == nil {
+			log.Fatal("either -from and -to, or -ri and -rf are required")
+		}
+	}
+
+	sol, err := smd.LambertTransfer(Ri, Rf, *tof, smd.TransferTypeFromInt(*ttype), body)
+	if err != nil {
+		log.Fatalf("no Lambert solution: %s", err)
+	}
+	fmt.Printf("TOF = %s (%.3f days), %s\n", sol.TOF, sol.TOF.Hours()/24, sol.Type)
+	fmt.Printf("Vi = %s km/s\nVf = %s km/s\n", formatVector(sol.Vi), formatVector(sol.Vf))
+	if Vi != nil {
+		vInf := smd.Norm([]float64{sol.Vi[0] - Vi[0], sol.Vi[1] - Vi[1], sol.Vi[2] - Vi[2]})
+		fmt.Printf("departure v∞ = %f km/s\tC3 = %f km²/s²\n", vInf, math.Pow(vInf, 2))
+	}
+	if Vf != nil {
+		vInf := smd.Norm([]float64{sol.Vf[0] - Vf[0], sol.Vf[1] - Vf[1], sol.Vf[2] - Vf[2]})
+		fmt.Printf("arrival v∞ = %f km/s\n", vInf)
+	}
+	a, e, i, Ω, ω, ν, _, _, _ := sol.Orbit.Elements()
+	fmt.Printf("transfer orbit: a = %f km\te = %f\ti = %f°\tΩ = %f°\tω = %f°\tν = %f°\n", a, e, smd.Rad2deg(i), smd.Rad2deg(Ω), smd.Rad2deg(ω), smd.Rad2deg(ν))
+}
+
+// parseVector parses a comma separated vector of three components.
+func parseVector(str string) ([]float64, error) {
+	parts := strings.Split(str, ",")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("`%s` is not a vector of three components", str)
+	}
+	vec := make([]float64, 3)
+	for k, part := range parts {
+		val, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		vec[k] = val
+	}
+	return vec, nil
+}
+
+// formatVector returns the vector as comma separated values.
+func formatVector(vec []float64) string {
+	return fmt.Sprintf("%f,%f,%f", vec[0], vec[1], vec[2])
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+const dateFormat = "2006-01-02 15:04:05"
+
+// smd is the command line interface of the smd package, which runs the missions defined in scenario files.
+
+const usage = `usage: smd <command> [arguments]
+
+commands:
+	propagate	propagate the mission of a scenario file (TOML, YAML or JSON) and write its exports
+	pcp		generate the porkchop plot of the direct transfers between two bodies
+	lambert		solve the Lambert problem between two bodies or two positions
+	access		report the visibility windows of stations over the mission of a scenario file
+
+Run "smd <command> -h" for the arguments of a command.
+`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "propagate":
+		propagate(os.Args[2:])
+	case "pcp":
+		pcp(os.Args[2:])
+	case "lambert":
+		lambert(os.Args[2:])
+	case "access":
+		access(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command `%s`\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
+
+// propagate loads the scenario and propagates its mission until its end date.
+func propagate(args []string) {
+	flags := flag.NewFlagSet("propagate", flag.ExitOnError)
+	scenarioPath := flags.String("scenario", "", "scenario file (TOML, YAML or JSON)")
+	verbose := flags.Bool("verbose", false, "print the scenario before propagating")
+	flags.Parse(args)
+	if *scenarioPath == "" {
+		if flags.NArg() != 1 {
+			log.Fatal("no scenario provided")
+		}
+		*scenarioPath = flags.Arg(0)
+	}
+	sc, err := loadScenario(*scenarioPath)
+	if err != nil {
+		log.Fatalf("%s: %s", *scenarioPath, err)
+	}
+	if *verbose {
+		log.Printf("[info] %s", sc)
+	}
+	sc.mission.PropagateUntil(sc.end, true)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChristopherRabotin/smd"
+	"github.com/gonum/matrix/mat64"
+	"github.com/soniakeys/meeus/julian"
+)
+
+// pcp generates the porkchop plot of the direct transfers between two bodies.
+func pcp(args []string) {
+	flags := flag.NewFlagSet("pcp", flag.ExitOnError)
+	from := flags.String("from", "Earth", "departure body")
+	to := flags.String("to", "Mars", "arrival body")
+	launch := flags.String("launch", "", "start of the launch window (date time or JDE)")
+	launchEnd := flags.String("launch-end", "", "end of the launch window (date time or JDE)")
+	arrival := flags.String("arrival", "", "start of the arrival window (date time or JDE)")
+	arrivalEnd := flags.String("arrival-end", "", "end of the arrival window (date time or JDE)")
+	launchStep := flags.Duration("launch-step", 24*time.Hour, "resolution of the launch window")
+	arrivalStep := flags.Duration("arrival-step", 24*time.Hour, "resolution of the arrival window")
+	ttype := flags.Int("type", 0, "transfer type (3 or 4 for one revolution, otherwise determined by the Lambert solver)")
+	output := flags.String("output", "pcp", "prefix of the output files")
+	formats := flags.String("format", "csv,png", "comma separated output formats (csv and png)")
+	levels := flags.Int("levels", 10, "number of C3 contour levels of the PNG")
+	cachePath := flags.String("cache", "ephemeris-cache.csv", "file of the cached planetary states, empty to disable")
+	flags.Parse(args)
+
+	fromBody, err := smd.CelestialObjectFromString(*from)
+	if err != nil {
+		log.Fatal(err)
+	}
+	toBody, err := smd.CelestialObjectFromString(*to)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var window [4]time.Time
+	for k, str := range []*string{launch, launchEnd, arrival, arrivalEnd} {
+		if window[k], err = parseJDEorTime(*str); err != nil {
+			log.Fatal(err)
+		}
+	}
+	p := smd.NewPorkchop(fromBody, toBody, window[0], window[1], window[2], window[3], smd.TransferTypeFromInt(*ttype))
+	p.LaunchStep, p.ArrivalStep = *launchStep, *arrivalStep
+
+	var cache *smd.EphemerisCache
+	if *cachePath != "" {
+		if cache, err = smd.NewEphemerisCache(smd.HelioEphemeris, *cachePath); err != nil {
+			log.Fatal(err)
+		}
+		p.Ephemeris = cache.Ephemeris
+	}
+	grids := p.Grids()
+	if cache != nil {
+		if err = cache.Save(); err != nil {
+			log.Fatalf("could not save the ephemeris cache: %s", err)
+		}
+		states, added := cache.Len()
+		log.Printf("[info] %d cached states (%d new) in %s", states, added, cache.Path)
+	}
+
+	for _, format := range strings.Split(*formats, ",") {
+		switch strings.TrimSpace(format) {
+		case "csv":
+			for name, grid := range map[string]*mat64.Dense{"c3": grids.C3, "vinf": grids.VInfArrival, "tof": grids.TOF} {
+				if err = writeGridCSV(fmt.Sprintf("%s-%s.csv", *output, name), grids, grid); err != nil {
+					log.Fatal(err)
+				}
+			}
+		case "png":
+			f, err := os.Create(*output + "-c3.png")
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err = smd.WritePorkchopPNG(f, grids.C3, smd.PorkchopLevels(grids.C3, *levels)); err != nil {
+				log.Fatal(err)
+			}
+			f.Close()
+		default:
+			log.Fatalf("unknown output format `%s`", format)
+		}
+	}
+}
+
+// writeGridCSV writes the grid with one row per arrival date and one column per launch date, in Julian days.
+func writeGridCSV(path string, grids smd.PorkchopGrids, grid mat64.Matrix) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	header := []string{"arrival\\launch"}
+	for _, dt := range grids.Launches {
+		header = append(header, strconv.FormatFloat(julian.TimeToJD(dt), 'f', 6, 64))
+	}
+	fmt.Fprintln(f, strings.Join(header, ","))
+	for i, dt := range grids.Arrivals {
+		row := []string{strconv.FormatFloat(julian.TimeToJD(dt), 'f', 6, 64)}
+		for j := range grids.Launches {
+			row = append(row, strconv.FormatFloat(grid.At(i, j), 'f', -1, 64))
+		}
+		fmt.Fprintln(f, strings.Join(row, ","))
+	}
+	return f.Close()
+}
+
+// parseJDEorTime parses a date time either as a Julian date or as a UTC date time string.
+func parseJDEorTime(str string) (time.Time, error) {
+	if jde, err := strconv.ParseFloat(str, 64); err == nil {
+		return julian.JDToTime(jde), nil
+	}
+	for _, layout := range []string{dateFormat, "2006-01-02"} {
+		if dt, err := time.Parse(layout, str); err == nil {
+			return dt, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not parse date time `%s`", str)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ChristopherRabotin/smd"
+	"github.com/soniakeys/meeus/julian"
+	"github.com/spf13/viper"
+)
+
+// scenario is a mission loaded from a scenario file.
+type scenario struct {
+	name       string
+	mission    *smd.Mission
+	start, end time.Time
+	export     smd.ExportConfig
+}
+
+func (s scenario) String() string {
+	return fmt.Sprintf("%s: %s from %s to %s (%s, CSV: %v, Cosmographia: %v)", s.name, s.mission.Orbit, s.start, s.end, s.mission.Integrator, s.export.AsCSV, s.export.Cosmo)
+}
+
+// exportColumns are the custom CSV columns which can be requested in the export section of a scenario.
+var exportColumns = map[string]smd.CSVColumns{
+	"guidance":       smd.GuidanceColumns,
+	"progress":       smd.ProgressColumns,
+	"thermal":        smd.ThermalColumns,
+	"massproperties": smd.MassPropertiesColumns,
+	"sungeometry":    smd.SunGeometryColumns,
+}
+
+// loadScenario reads the scenario file, whose format is that of its extension (TOML, YAML or JSON).
+func loadScenario(path string) (s scenario, err error) {
+	viper.SetConfigFile(path)
+	if err = viper.ReadInConfig(); err != nil {
+		return
+	}
+
+	// Mission parameters
+	if s.start, err = readJDEorTime("mission.start"); err != nil {
+		return
+	}
+	if s.end, err = readJDEorTime("mission.end"); err != nil {
+		return
+	}
+	step := smd.StepSize
+	if viper.IsSet("mission.step") {
+		step = viper.GetDuration("mission.step")
+	}
+
+	// Spacecraft
+	s.name = viper.GetString("spacecraft.name")
+	sc := smd.NewSpacecraft(s.name, viper.GetFloat64("spacecraft.dry"), viper.GetFloat64("spacecraft.fuel"), smd.NewUnlimitedEPS(), []smd.EPThruster{}, true, []*smd.Cargo{}, []smd.Waypoint{})
+
+	// Orbit
+	body, err := smd.CelestialObjectFromString(viper.GetString("orbit.body"))
+	if err != nil {
+		return
+	}
+	orbit := smd.NewOrbitFromOE(viper.GetFloat64("orbit.sma"), viper.GetFloat64("orbit.ecc"), viper.GetFloat64("orbit.inc"), viper.GetFloat64("orbit.RAAN"), viper.GetFloat64("orbit.argPeri"), viper.GetFloat64("orbit.tAnomaly"), body)
+
+	// Perturbations
+	var perts smd.Perturbations
+	for _, n := range []uint8{2, 3, 4} {
+		if viper.GetBool(fmt.Sprintf("perturbations.J%d", n)) {
+			perts.Jn = n
+		}
+	}
+	for _, name := range viper.GetStringSlice("perturbations.bodies") {
+		pertBody, bodyErr := smd.CelestialObjectFromString(name)
+		if bodyErr != nil {
+			return s, bodyErr
+		}
+		if !pertBody.Equals(smd.Sun) {
+			log.Printf("[warning] body `%s` not yet supported, skipping it in perturbations", name)
+			continue
+		}
+		perts.PerturbingBody = &pertBody
+	}
+
+	// Maneuvers
+	for burnNo := 0; viper.IsSet(fmt.Sprintf("burns.%d", burnNo)); burnNo++ {
+		key := fmt.Sprintf("burns.%d.", burnNo)
+		burnDT, dtErr := readJDEorTime(key + "date")
+		if dtErr != nil {
+			return s, dtErr
+		}
+		if burnDT.After(s.end) || burnDT.Before(s.start) {
+			log.Printf("[warning] burn #%d scheduled out of propagation time", burnNo)
+		}
+		sc.Maneuvers[burnDT] = smd.NewManeuver(viper.GetFloat64(key+"R"), viper.GetFloat64(key+"N"), viper.GetFloat64(key+"C"))
+	}
+
+	// Exports
+	s.export = smd.ExportConfig{Filename: viper.GetString("export.filename"), AsCSV: viper.GetBool("export.csv"), Cosmo: viper.GetBool("export.cosmo"), Timestamp: viper.GetBool("export.timestamp")}
+	if s.export.Filename == "" {
+		s.export.Filename = s.name
+	}
+	for _, name := range viper.GetStringSlice("export.columns") {
+		columns, ok := exportColumns[strings.ToLower(name)]
+		if !ok {
+			return s, fmt.Errorf("unknown export columns `%s`", name)
+		}
+		s.export.Columns = append(s.export.Columns, columns)
+	}
+
+	s.mission = smd.NewPreciseMission(sc, orbit, s.start, s.end, perts, step, false, s.export)
+	if name := viper.GetString("mission.integrator"); name != "" {
+		if s.mission.Integrator, err = integratorFromString(name); err != nil {
+			return
+		}
+	}
+	s.mission.Canonical = viper.GetBool("mission.canonical")
+	return
+}
+
+// integratorFromString returns the integrator of the provided name, e.g. "RK4".
+func integratorFromString(name string) (smd.Integrator, error) {
+	for _, integrator := range []smd.Integrator{smd.RK4Integrator, smd.Yoshida4Integrator, smd.KSIntegrator} {
+		if strings.EqualFold(integrator.String(), name) {
+			return integrator, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown integrator `%s`", name)
+}
+
+// readJDEorTime reads a date time either as a Julian date or as a date time string.
+func readJDEorTime(key string) (dt time.Time, err error) {
+	if jde := viper.GetFloat64(key); jde != 0 {
+		return julian.JDToTime(jde), nil
+	}
+	if dt = viper.GetTime(key); dt.IsZero() {
+		err = fmt.Errorf("could not parse date time in `%s`", key)
+	}
+	return
+}
+
+// loadStation returns the builtin station (e.g. "builtin.DSS34" or "builtin.DSS34Canberra") or the station defined in the station section of
+// the scenario, with angles in degrees.
+func loadStation(name string) (smd.Station, error) {
+	if strings.HasPrefix(name, "builtin.") {
+		for _, st := range smd.DSNStations {
+			if strings.EqualFold(st.Name, name[8:]) || strings.EqualFold(fmt.Sprintf("DSS%d", st.DSS), name[8:]) {
+				return st, nil
+			}
+		}
+		return smd.Station{}, fmt.Errorf("unknown builtin station `%s`", name)
+	}
+	key := fmt.Sprintf("station.%s.", name)
+	if !viper.IsSet(key + "name") {
+		return smd.Station{}, fmt.Errorf("station `%s` is not defined in the scenario", name)
+	}
+	planet := smd.Earth
+	if planetName := viper.GetString(key + "planet"); planetName != "" {
+		var err error
+		if planet, err = smd.CelestialObjectFromString(planetName); err != nil {
+			return smd.Station{}, err
+		}
+	}
+	return smd.NewBodyStation(viper.GetString(key+"name"), planet, viper.GetFloat64(key+"altitude"), viper.GetFloat64(key+"elevation"), viper.GetFloat64(key+"latitude"), viper.GetFloat64(key+"longitude"), viper.GetFloat64(key+"range_sigma"), viper.GetFloat64(key+"rate_sigma")), nil
+}
@@ -0,0 +1,207 @@
+package smd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EphemerisDifference is the difference between a trajectory and its reference at an epoch, in the RIC frame of
+// the reference.
+type EphemerisDifference struct {
+	DT       time.Time
+	ΔR, ΔV   []float64 // Position (km) and velocity (km/s) differences in the RIC frame
+	Position float64   // Norm of the position difference (km)
+	Velocity float64   // Norm of the velocity difference (km/s)
+}
+
+// EphemerisComparison stores the differences of a trajectory with respect to a reference, e.g. to validate the
+// propagator against GMAT or STK.
+type EphemerisComparison struct {
+	Differences              []EphemerisDifference
+	MaxRIC, RMSRIC           []float64 // Maximum absolute and RMS position difference per RIC component (km)
+	MaxPosition, RMSPosition float64   // Maximum and RMS of the norm of the position difference (km)
+	MaxVelocity, RMSVelocity float64   // Maximum and RMS of the norm of the velocity difference (km/s)
+	MaxPositionDT            time.Time // Epoch of the maximum position difference
+}
+
+func (c EphemerisComparison) String() string {
+	return fmt.Sprintf("%d epochs: position max %.6f km (at %s) RMS %.6f km; RIC max %v km RMS %v km; velocity max %.9f km/s RMS %.9f km/s", len(c.Differences), c.MaxPosition, c.MaxPositionDT.Format(time.RFC3339), c.RMSPosition, c.MaxRIC, c.RMSRIC, c.MaxVelocity, c.RMSVelocity)
+}
+
+// CompareEphemerides differences the trajectory with the reference at each epoch of the reference within the span
+// of the trajectory. The trajectory is interpolated with cubic Hermite polynomials between its states, so both
+// may have different steps. Both must be sorted by epoch and about the same body.
+func CompareEphemerides(trajectory, reference []State) (EphemerisComparison, error) {
+	if len(trajectory) == 0 || len(reference) == 0 {
+		return EphemerisComparison{}, errors.New("empty trajectory")
+	}
+	c := EphemerisComparison{MaxRIC: make([]float64, 3), RMSRIC: make([]float64, 3)}
+	for _, ref := range reference {
+		if !ref.Orbit.Origin.Equals(trajectory[0].Orbit.Origin) {
+			return c, fmt.Errorf("reference about %s but trajectory about %s", ref.Orbit.Origin.Name, trajectory[0].Orbit.Origin.Name)
+		}
+		R, V, ok := hermiteState(trajectory, ref.DT)
+		if !ok {
+			continue
+		}
+		Rref, Vref := ref.Orbit.RV()
+		dcm := ref.Orbit.RICDCM()
+		d := EphemerisDifference{DT: ref.DT}
+		d.ΔR = MxV33(dcm, []float64{R[0] - Rref[0], R[1] - Rref[1], R[2] - Rref[2]})
+		d.ΔV = MxV33(dcm, []float64{V[0] - Vref[0], V[1] - Vref[1], V[2] - Vref[2]})
+		d.Position, d.Velocity = Norm(d.ΔR), Norm(d.ΔV)
+		for i := 0; i < 3; i++ {
+			c.MaxRIC[i] = math.Max(c.MaxRIC[i], math.Abs(d.ΔR[i]))
+			c.RMSRIC[i] += d.ΔR[i] * d.ΔR[i]
+		}
+		if d.Position > c.MaxPosition || len(c.Differences) == 0 {
+			c.MaxPosition, c.MaxPositionDT = d.Position, d.DT
+		}
+		c.MaxVelocity = math.Max(c.MaxVelocity, d.Velocity)
+		c.RMSPosition += d.Position * d.Position
+		c.RMSVelocity += d.Velocity * d.Velocity
+		c.Differences = append(c.Differences, d)
+	}
+	n := float64(len(c.Differences))
+	if n == 0 {
+		return c, errors.New("the reference does not overlap the trajectory")
+	}
+	for i := 0; i < 3; i++ {
+		c.RMSRIC[i] = math.Sqrt(c.RMSRIC[i] / n)
+	}
+	c.RMSPosition, c.RMSVelocity = math.Sqrt(c.RMSPosition/n), math.Sqrt(c.RMSVelocity/n)
+	return c, nil
+}
+
+// hermiteState returns the position and velocity of the sorted states at the provided epoch, interpolated with
+// a cubic Hermite polynomial between the surrounding states, and false if outside of the states.
+func hermiteState(states []State, dt time.Time) (R, V []float64, ok bool) {
+	k := sort.Search(len(states), func(i int) bool { return !states[i].DT.Before(dt) })
+	if k == len(states) {
+		return nil, nil, false
+	}
+	if states[k].DT.Equal(dt) {
+		R, V = states[k].Orbit.RV()
+		return R, V, true
+	}
+	if k == 0 {
+		return nil, nil, false
+	}
+	R0, V0 := states[k-1].Orbit.RV()
+	R1, V1 := states[k].Orbit.RV()
+	h := states[k].DT.Sub(states[k-1].DT).Seconds()
+	s := dt.Sub(states[k-1].DT).Seconds() / h
+	s2, s3 := s*s, s*s*s
+	h00, h10, h01, h11 := 2*s3-3*s2+1, s3-2*s2+s, -2*s3+3*s2, s3-s2
+	d00, d10, d01, d11 := 6*s2-6*s, 3*s2-4*s+1, -6*s2+6*s, 3*s2-2*s
+	R, V = make([]float64, 3), make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		R[i] = h00*R0[i] + h10*h*V0[i] + h01*R1[i] + h11*h*V1[i]
+		V[i] = (d00*R0[i]+d01*R1[i])/h + d10*V0[i] + d11*V1[i]
+	}
+	return R, V, true
+}
+
+// WriteEphemerisComparison writes the differences of the comparison as a CSV table.
+func WriteEphemerisComparison(w io.Writer, c EphemerisComparison) error {
+	if _, err := fmt.Fprint(w, "epoch,dR,dI,dC,dVR,dVI,dVC,position,velocity\n"); err != nil {
+		return err
+	}
+	for _, d := range c.Differences {
+		if _, err := fmt.Fprintf(w, "%s,%e,%e,%e,%e,%e,%e,%e,%e\n", d.DT.UTC().Format(time.RFC3339Nano), d.ΔR[0], d.ΔR[1], d.ΔR[2], d.ΔV[0], d.ΔV[1], d.ΔV[2], d.Position, d.Velocity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadOEM reads the states of a CCSDS Orbit Ephemeris Message in KVN format, e.g. exported by GMAT or STK, as a
+// reference for CompareEphemerides. Only the UTC time system and the EME2000 (or ICRF) frame are supported. The
+// covariance and the accelerations, if any, are ignored.
+func ReadOEM(r io.Reader) ([]State, error) {
+	var states []State
+	var body CelestialObject
+	inMeta, inCovariance := false, false
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "COMMENT") {
+			continue
+		}
+		switch line {
+		case "META_START":
+			inMeta = true
+			continue
+		case "META_STOP":
+			inMeta = false
+			continue
+		case "COVARIANCE_START":
+			inCovariance = true
+			continue
+		case "COVARIANCE_STOP":
+			inCovariance = false
+			continue
+		}
+		if inCovariance {
+			continue
+		}
+		if kv := strings.SplitN(line, "=", 2); len(kv) == 2 {
+			key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+			if !inMeta {
+				continue // Header
+			}
+			var err error
+			switch key {
+			case "CENTER_NAME":
+				if body, err = CelestialObjectFromString(strings.ToLower(value)); err != nil {
+					return nil, fmt.Errorf("OEM line %d: %s", lineNo, err)
+				}
+			case "REF_FRAME":
+				if value != "EME2000" && value != "ICRF" {
+					return nil, fmt.Errorf("OEM line %d: unsupported frame %s", lineNo, value)
+				}
+			case "TIME_SYSTEM":
+				if value != "UTC" {
+					return nil, fmt.Errorf("OEM line %d: unsupported time system %s", lineNo, value)
+				}
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 7 && len(fields) != 10 {
+			return nil, fmt.Errorf("OEM line %d: unexpected data line `%s`", lineNo, line)
+		}
+		if body.Name == "" {
+			return nil, fmt.Errorf("OEM line %d: data before the CENTER_NAME", lineNo)
+		}
+		dt, err := parseOEMEpoch(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("OEM line %d: %s", lineNo, err)
+		}
+		state := make([]float64, 6)
+		for i := range state {
+			if state[i], err = strconv.ParseFloat(fields[i+1], 64); err != nil {
+				return nil, fmt.Errorf("OEM line %d: %s", lineNo, err)
+			}
+		}
+		states = append(states, State{DT: dt, Orbit: *NewOrbitFromRV(state[:3], state[3:], body)})
+	}
+	return states, scanner.Err()
+}
+
+// parseOEMEpoch parses a CCSDS epoch, either in calendar or day of year format.
+func parseOEMEpoch(str string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", "2006-002T15:04:05.999999999"} {
+		if dt, err := time.Parse(layout, str); err == nil {
+			return dt, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid epoch `%s`", str)
+}
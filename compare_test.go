@@ -0,0 +1,67 @@
+package smd
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareEphemerides(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMultiMission(start, start.Add(2*time.Hour), 10*time.Second)
+	m.Add(NewEmptySC("leo", 0), NewOrbitFromOE(7000, 0.01, 28.5, 10, 20, 30, Earth), Perturbations{}, ExportConfig{})
+	m.Propagate()
+	// Analytical reference every 45 seconds, which requires interpolating the trajectory, with a 100 m along
+	// track offset.
+	var oem bytes.Buffer
+	oem.WriteString("CCSDS_OEM_VERS = 2.0\nCREATION_DATE = 2018-01-01T00:00:00\nORIGINATOR = test\n\nMETA_START\nOBJECT_NAME = leo\nCENTER_NAME = EARTH\nREF_FRAME = EME2000\nTIME_SYSTEM = UTC\nMETA_STOP\nCOMMENT reference\n")
+	for dt := 45 * time.Second; dt < 2*time.Hour; dt += 45 * time.Second {
+		o := keplerPropagate(*NewOrbitFromOE(7000, 0.01, 28.5, 10, 20, 30, Earth), dt)
+		R, V := o.RV()
+		I := MxV33(o.RICDCM().T(), []float64{0, 0.1, 0})
+		fmt.Fprintf(&oem, "%s %.12f %.12f %.12f %.12f %.12f %.12f\n", start.Add(dt).Format("2006-01-02T15:04:05.000"), R[0]+I[0], R[1]+I[1], R[2]+I[2], V[0], V[1], V[2])
+	}
+	reference, err := ReadOEM(&oem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reference) != 159 {
+		t.Fatalf("read %d states", len(reference))
+	}
+	c, err := CompareEphemerides(m.History[0], reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Differences) != len(reference) {
+		t.Fatalf("%d differences for %d reference states", len(c.Differences), len(reference))
+	}
+	if math.Abs(c.MaxRIC[1]-0.1) > 1e-4 || c.MaxRIC[0] > 1e-4 || c.MaxRIC[2] > 1e-4 {
+		t.Fatalf("RIC differences %+v instead of the 100 m along track offset", c.MaxRIC)
+	}
+	if math.Abs(c.RMSPosition-0.1) > 1e-4 || c.MaxVelocity > 1e-6 {
+		t.Fatalf("unexpected differences: %s", c)
+	}
+	var csv bytes.Buffer
+	if err = WriteEphemerisComparison(&csv, c); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(csv.String(), "\n"); lines != len(c.Differences)+1 {
+		t.Fatalf("%d CSV lines", lines)
+	}
+	if _, err = CompareEphemerides(m.History[0], []State{{DT: start.Add(24 * time.Hour), Orbit: reference[0].Orbit}}); err == nil {
+		t.Fatal("no error without overlap")
+	}
+	for _, invalid := range []string{
+		"META_START\nCENTER_NAME = EARTH\nREF_FRAME = TOD\nMETA_STOP\n",
+		"META_START\nCENTER_NAME = EARTH\nTIME_SYSTEM = TDB\nMETA_STOP\n",
+		"2018-01-01T00:00:00 7000 0 0 0 7.5 0\n",
+		"META_START\nCENTER_NAME = EARTH\nMETA_STOP\n2018-01-01T00:00:00 7000 0 0\n",
+	} {
+		if _, err = ReadOEM(strings.NewReader(invalid)); err == nil {
+			t.Fatalf("no error reading\n%s", invalid)
+		}
+	}
+}
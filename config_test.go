@@ -1,6 +1,7 @@
 package smd
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -20,19 +21,53 @@ func TestSpice(t *testing.T) {
 		t.Fatal("incorrect V")
 	}
 
-	// Test frame change
+	// SPICE reference (cf. cmd/refframes/tests.py): the state is defined in the IAU_EARTH frame, and ChgFrame rotates
+	// it to ECLIPJ2000 (velocity included, without the rotation of the frame) and adds the DE430 state of the Earth.
+	// The state is first rotated to the ICRF at the TDB epoch (TDB-UTC is 32.184 s plus the leap seconds).
 	scR := []float64{-996776.1190926583, -39776.102324992695, 25123.28168731782}
 	scV := []float64{-0.5114606889356655, -0.6914491357021403, -0.34254913653144525}
-	scOrbit := NewOrbitFromRV(scR, scV, Earth)
-	scOrbit.ToXCentric(Sun, time.Date(2016, 3, 24, 20, 41, 48, 0, time.UTC))
-	// I should get the following, but it's actually very slightly off (maybe a second or two) and I don't have time to debug precisely why.
-	// [-148030923.95108017, -12123548.951590259, 302492.17670564854, 2.6590243298160754, -29.849194304414752, -0.35374685933315347]
-	expR = []float64{-1.4803092395107993e+08, -1.2123548951590609e+07, 302492.1767054541}
-	expV = []float64{2.6590244425141782, -29.84919454394858, -0.3537466068661061}
-	if !floats.Equal(scOrbit.rVec, expR) {
-		t.Fatal("incorrect R")
+	for _, tc := range []struct {
+		dt    time.Time
+		tdb   time.Duration
+		state []float64
+	}{
+		{time.Date(2016, 3, 24, 20, 41, 48, 0, time.UTC), 68184 * time.Millisecond, []float64{-148030923.95108017, -12123548.951590259, 302492.17670564854, 2.6590243298160754, -29.849194304414752, -0.35374685933315347}},
+		{time.Date(2016, 4, 14, 20, 50, 23, 0, time.UTC), 68184 * time.Millisecond, []float64{-134976740.1465185, -64019984.225526303, 173268.29557571266, 12.914400364190689, -26.830454218461472, -0.48247193678732703}},
+		{time.Date(2016, 5, 12, 18, 0, 15, 0, time.UTC), 68184 * time.Millisecond, []float64{-91834879.055177972, -119989870.393112, 265756.61887669913, 23.896559117820097, -18.302614365094886, -0.39983700293859631}},
+		{time.Date(2018, 10, 2, 22, 21, 40, 0, time.UTC), 69184 * time.Millisecond, []float64{146717590.82034796, 24671383.129385762, -52708.613323677433, -6.003251556028836, 28.634454862602379, -0.09470460569670297}},
+	} {
+		dcm := Earth.BodyFixed2ICRF(tc.dt.Add(tc.tdb))
+		scOrbit := NewOrbitFromRV(MxV33(dcm, scR), MxV33(dcm, scV), Earth)
+		scOrbit.ToXCentric(Sun, tc.dt)
+		// The obliquity of smd differs from that of SPICE by 0.03 arcsecond, i.e. about 0.15 km at this distance.
+		for i := 0; i < 3; i++ {
+			if !floats.EqualWithinAbs(scOrbit.rVec[i], tc.state[i], 1) || !floats.EqualWithinAbs(scOrbit.vVec[i], tc.state[i+3], 1e-6) {
+				t.Fatalf("%s: incorrect state %+v %+v", tc.dt, scOrbit.rVec, scOrbit.vVec)
+			}
+		}
 	}
-	if !floats.Equal(scOrbit.vVec, expV) {
-		t.Fatal("incorrect V")
+	// About Mars, the X axis of the equatorial frame is the ascending node of its equator on the ICRF equator, and the
+	// Z axis is its IAU pole (α0 = 317.68143 - 0.1061 T, δ0 = 52.88650 - 0.0609 T in pck00010).
+	dt := time.Date(2016, 3, 24, 20, 41, 48, 0, time.UTC)
+	T := dt.Sub(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)).Hours() / (24 * 36525)
+	α0, δ0 := (317.68143-0.1061*T)*deg2rad, (52.88650-0.0609*T)*deg2rad
+	ε := 84381.448 / 3600 * deg2rad
+	toEcliptic := func(v []float64) []float64 {
+		return []float64{v[0], math.Cos(ε)*v[1] + math.Sin(ε)*v[2], -math.Sin(ε)*v[1] + math.Cos(ε)*v[2]}
+	}
+	node := toEcliptic([]float64{-math.Sin(α0), math.Cos(α0), 0})
+	pole := toEcliptic([]float64{math.Cos(δ0) * math.Cos(α0), math.Cos(δ0) * math.Sin(α0), math.Sin(δ0)})
+	marsOrbit := NewOrbitFromRV([]float64{3000, 0, 1500}, []float64{1.2, 0, -0.4}, Mars)
+	marsOrbit.ToXCentric(Sun, dt)
+	marsR, marsV := Mars.HelioOrbit(dt).RV()
+	for i := 0; i < 3; i++ {
+		if !floats.EqualWithinAbs(marsOrbit.rVec[i]-marsR[i], 3000*node[i]+1500*pole[i], 1e-3) || !floats.EqualWithinAbs(marsOrbit.vVec[i]-marsV[i], 1.2*node[i]-0.4*pole[i], 1e-6) {
+			t.Fatalf("incorrect Mars centric conversion %+v %+v", marsOrbit.rVec, marsOrbit.vVec)
+		}
+	}
+	// And back.
+	marsOrbit.ToXCentric(Mars, dt)
+	if !floats.EqualApprox(marsOrbit.rVec, []float64{3000, 0, 1500}, 1e-6) || !floats.EqualApprox(marsOrbit.vVec, []float64{1.2, 0, -0.4}, 1e-9) {
+		t.Fatalf("incorrect round trip %+v %+v", marsOrbit.rVec, marsOrbit.vVec)
 	}
 }
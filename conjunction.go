@@ -0,0 +1,165 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	pcRadialSteps  = 64  // Number of radial steps (must be even) in the numerical integration of the 2D Pc.
+	pcAngularSteps = 128 // Number of angular steps in the numerical integration of the 2D Pc.
+)
+
+// ConjunctionState is a state of a trajectory used for conjunction assessment.
+type ConjunctionState struct {
+	DT         time.Time
+	Orbit      Orbit
+	Covariance mat64.Symmetric // Inertial position (3x3) or position and velocity (6x6) covariance in km² (may be nil).
+}
+
+// CloseApproach stores a close approach between two trajectories.
+type CloseApproach struct {
+	TCA                 time.Time // Time of closest approach
+	Miss                []float64 // Inertial miss vector (secondary minus primary) at TCA, in km
+	RelativeVelocity    []float64 // Inertial relative velocity (secondary minus primary) at TCA, in km/s
+	Primary, Secondary  ConjunctionState
+	MissDistance, Speed float64
+	combinedCovariance  *mat64.SymDense
+}
+
+func (ca CloseApproach) String() string {
+	return fmt.Sprintf("TCA %s: miss distance %.3f km at %.3f km/s", ca.TCA, ca.MissDistance, ca.Speed)
+}
+
+// FindCloseApproaches screens both trajectories for close approaches below the threshold distance (in km).
+// The trajectories must be sampled at the same epochs and about the same body. Only the approaches which are
+// bracketed by the samples are returned. The TCA is refined assuming a linear relative motion.
+func FindCloseApproaches(primary, secondary []ConjunctionState, threshold float64) []CloseApproach {
+	if len(primary) != len(secondary) {
+		panic("trajectories must have the same number of states")
+	}
+	ranges := make([]float64, len(primary))
+	for k := range primary {
+		if !primary[k].DT.Equal(secondary[k].DT) {
+			panic(fmt.Errorf("trajectories are not sampled at the same epochs: %s != %s", primary[k].DT, secondary[k].DT))
+		}
+		if !primary[k].Orbit.Origin.Equals(secondary[k].Orbit.Origin) {
+			panic("trajectories must be about the same body")
+		}
+		ρ, _ := relativeRV(primary[k], secondary[k])
+		ranges[k] = Norm(ρ)
+	}
+	var approaches []CloseApproach
+	for k := 1; k < len(ranges)-1; k++ {
+		if ranges[k-1] < ranges[k] || ranges[k+1] < ranges[k] {
+			// Not a local minimum.
+			continue
+		}
+		ca := newCloseApproach(primary[k], secondary[k])
+		if ca.MissDistance <= threshold {
+			approaches = append(approaches, ca)
+		}
+	}
+	return approaches
+}
+
+func relativeRV(primary, secondary ConjunctionState) (ρ, ρDot []float64) {
+	r1, v1 := primary.Orbit.RV()
+	r2, v2 := secondary.Orbit.RV()
+	ρ = make([]float64, 3)
+	ρDot = make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		ρ[i] = r2[i] - r1[i]
+		ρDot[i] = v2[i] - v1[i]
+	}
+	return
+}
+
+// newCloseApproach refines the TCA from the provided sample assuming a linear relative motion.
+func newCloseApproach(primary, secondary ConjunctionState) CloseApproach {
+	ρ, ρDot := relativeRV(primary, secondary)
+	τ := -Dot(ρ, ρDot) / Dot(ρDot, ρDot)
+	miss := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		miss[i] = ρ[i] + ρDot[i]*τ
+	}
+	ca := CloseApproach{TCA: primary.DT.Add(time.Duration(τ * 1e9)), Miss: miss, RelativeVelocity: ρDot, Primary: primary, Secondary: secondary, MissDistance: Norm(miss), Speed: Norm(ρDot)}
+	if primary.Covariance != nil && secondary.Covariance != nil {
+		C := mat64.NewSymDense(3, nil)
+		for i := 0; i < 3; i++ {
+			for j := i; j < 3; j++ {
+				C.SetSym(i, j, primary.Covariance.At(i, j)+secondary.Covariance.At(i, j))
+			}
+		}
+		ca.combinedCovariance = C
+	}
+	return ca
+}
+
+// EncounterPlane returns the miss distance and the 2x2 combined covariance projected in the encounter plane
+// (normal to the relative velocity), where the first axis is along the miss vector.
+func (ca CloseApproach) EncounterPlane() (miss float64, C *mat64.Dense) {
+	if ca.combinedCovariance == nil {
+		panic("both states must have a covariance")
+	}
+	zUnit := Unit(ca.RelativeVelocity)
+	xUnit := Unit(ca.Miss)
+	if Norm(ca.Miss) == 0 {
+		// Direct hit: any axis normal to the relative velocity will do.
+		xUnit = Unit(Cross(zUnit, []float64{0, 0, 1}))
+	}
+	yUnit := Cross(zUnit, xUnit)
+	proj := mat64.NewDense(2, 3, []float64{xUnit[0], xUnit[1], xUnit[2], yUnit[0], yUnit[1], yUnit[2]})
+	var tmp mat64.Dense
+	tmp.Mul(proj, ca.combinedCovariance)
+	C = mat64.NewDense(2, 2, nil)
+	C.Mul(&tmp, proj.T())
+	return ca.MissDistance, C
+}
+
+// CollisionProbability returns the 2D probability of collision (Foster) for the combined hard body radius (in km),
+// computed by numerical integration of the encounter plane probability density over the hard body disk.
+func (ca CloseApproach) CollisionProbability(hardBodyRadius float64) float64 {
+	miss, C := ca.EncounterPlane()
+	σx2, σy2, σxy := C.At(0, 0), C.At(1, 1), C.At(0, 1)
+	det := σx2*σy2 - σxy*σxy
+	if det <= 0 {
+		panic("encounter plane covariance is not positive definite")
+	}
+	pdf := func(x, y float64) float64 {
+		dx := x - miss
+		// Inverse of the 2x2 covariance.
+		q := (σy2*dx*dx - 2*σxy*dx*y + σx2*y*y) / det
+		return math.Exp(-q/2) / (2 * math.Pi * math.Sqrt(det))
+	}
+	// Simpson's rule on the radius and trapezoidal rule on the angle, in polar coordinates centered on the hard body.
+	Δr := hardBodyRadius / pcRadialSteps
+	Δθ := 2 * math.Pi / pcAngularSteps
+	pc := 0.0
+	for i := 0; i <= pcRadialSteps; i++ {
+		r := float64(i) * Δr
+		w := 2.
+		if i == 0 || i == pcRadialSteps {
+			w = 1
+		} else if i%2 == 1 {
+			w = 4
+		}
+		for j := 0; j < pcAngularSteps; j++ {
+			s, c := math.Sincos(float64(j) * Δθ)
+			pc += w / 3 * pdf(r*c, r*s) * r * Δr * Δθ
+		}
+	}
+	return pc
+}
+
+// MaxCollisionProbability returns the maximum 2D probability of collision when the shape of the combined covariance
+// is unknown, as per Alfano's worst case dilution (spherical covariance of σ² = miss²/2).
+func (ca CloseApproach) MaxCollisionProbability(hardBodyRadius float64) float64 {
+	if ca.MissDistance <= hardBodyRadius {
+		return 1
+	}
+	return math.Pow(hardBodyRadius/ca.MissDistance, 2) / math.E
+}
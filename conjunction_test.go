@@ -0,0 +1,71 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func conjunctionTrajectory(o *Orbit, start, end time.Time, P mat64.Symmetric) []ConjunctionState {
+	stateChan := make(chan State, 10)
+	states := []ConjunctionState{}
+	done := make(chan bool)
+	go func() {
+		for state := range stateChan {
+			states = append(states, ConjunctionState{state.DT, state.Orbit, P})
+		}
+		done <- true
+	}()
+	m := NewMission(NewEmptySC("conj", 0), o, start, end, Perturbations{}, false, ExportConfig{})
+	m.RegisterStateChan(stateChan)
+	m.Propagate()
+	<-done
+	return states
+}
+
+func TestConjunction(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(20 * time.Minute)
+	// Both orbits reach the X axis at the same time, with a slight offset in the node of the secondary.
+	P := mat64.NewSymDense(3, []float64{0.1, 0, 0, 0, 0.1, 0, 0, 0, 0.1})
+	primary := conjunctionTrajectory(NewOrbitFromOE(7000, 0, 0, 0, 0, 330, Earth), start, end, P)
+	secondary := conjunctionTrajectory(NewOrbitFromOE(7000, 0, 90, 0.001, 0, 330, Earth), start, end, P)
+	approaches := FindCloseApproaches(primary, secondary, 1)
+	if len(approaches) != 1 {
+		t.Fatalf("expected one close approach, got %d", len(approaches))
+	}
+	ca := approaches[0]
+	// The offset is along the velocity of the primary, hence at 45 degrees of the relative velocity.
+	expMiss := 7000 * Deg2rad(0.001) / math.Sqrt2
+	if !floats.EqualWithinAbs(ca.MissDistance, expMiss, 1e-2) {
+		t.Fatalf("invalid miss distance: %f km instead of %f km", ca.MissDistance, expMiss)
+	}
+	expTCA := start.Add(time.Duration(NewOrbitFromOE(7000, 0, 0, 0, 0, 0, Earth).Period().Seconds()/12) * time.Second)
+	if Δt := ca.TCA.Sub(expTCA); math.Abs(Δt.Seconds()) > 1 {
+		t.Fatalf("invalid TCA %s (expected %s)", ca.TCA, expTCA)
+	}
+	if !floats.EqualWithinAbs(ca.Speed, math.Sqrt2*primary[0].Orbit.VNorm(), 1e-3) {
+		t.Fatalf("invalid relative speed %f", ca.Speed)
+	}
+	// For a small hard body and a spherical combined covariance (σ² = 0.2 km²), Pc ≈ πR² × pdf(miss).
+	R := 0.02
+	expPc := math.Pi * R * R * math.Exp(-math.Pow(ca.MissDistance, 2)/(2*0.2)) / (2 * math.Pi * 0.2)
+	if pc := ca.CollisionProbability(R); !floats.EqualWithinRel(pc, expPc, 1e-3) {
+		t.Fatalf("invalid Pc %g (expected %g)", pc, expPc)
+	}
+	if pc := ca.CollisionProbability(3); !floats.EqualWithinAbs(pc, 1, 1e-4) {
+		t.Fatalf("Pc should be one with a huge hard body, got %f", pc)
+	}
+	if ca.MaxCollisionProbability(R) < ca.CollisionProbability(R) {
+		t.Fatal("maximum Pc is lower than Pc")
+	}
+	if len(FindCloseApproaches(primary, secondary, 0.01)) != 0 {
+		t.Fatal("threshold not respected")
+	}
+	assertPanic(t, func() {
+		FindCloseApproaches(primary, secondary[1:], 1)
+	})
+}
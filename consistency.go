@@ -0,0 +1,187 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// NEES returns the normalized estimation error squared δ'*P^-1*δ of the estimation error δ (truth minus estimate)
+// given the covariance of the estimate. Its expected value is the dimension of the state if the filter is
+// consistent.
+func NEES(δ *mat64.Vector, P mat64.Symmetric) (float64, error) {
+	return normalizedSquare(δ, P)
+}
+
+// NIS returns the normalized innovation squared y'*S^-1*y of the innovation (prefit residual) y given its
+// covariance S = H*P*H' + R. Its expected value is the dimension of the measurement if the filter is consistent.
+func NIS(y *mat64.Vector, S mat64.Symmetric) (float64, error) {
+	return normalizedSquare(y, S)
+}
+
+// InnovationCovariance returns the covariance S = H*P*H' + R of the innovation from the H tilde matrix, the
+// predicted covariance of the state and the covariance of the measurement noise.
+func InnovationCovariance(H mat64.Matrix, P, R mat64.Symmetric) *mat64.SymDense {
+	S := rotateCovariance(H, P)
+	S.AddSym(S, R)
+	return S
+}
+
+// normalizedSquare returns x'*P^-1*x.
+func normalizedSquare(x *mat64.Vector, P mat64.Symmetric) (float64, error) {
+	if n := P.Symmetric(); n != x.Len() {
+		return 0, fmt.Errorf("covariance dimension %d does not match the vector dimension %d", n, x.Len())
+	}
+	var Pinv mat64.Dense
+	if err := Pinv.Inverse(P); err != nil {
+		return 0, err
+	}
+	Px := mat64.NewVector(x.Len(), nil)
+	Px.MulVec(&Pinv, x)
+	return mat64.Dot(x, Px), nil
+}
+
+// ConsistencyRun stores the NEES and NIS of each estimate of one filter run, e.g. one Monte Carlo sample of
+// a simulated tracking arc where the true state is known.
+type ConsistencyRun struct {
+	Epochs            []time.Time
+	NEES, NIS         []float64
+	StateDim, MeasDim int
+}
+
+// Add appends the NEES of the estimation error δ given the covariance P of the estimate, and the NIS of the
+// innovation y given its covariance S. The dimensions must not change during the run.
+func (r *ConsistencyRun) Add(dt time.Time, δ *mat64.Vector, P mat64.Symmetric, y *mat64.Vector, S mat64.Symmetric) error {
+	if len(r.Epochs) > 0 && (δ.Len() != r.StateDim || y.Len() != r.MeasDim) {
+		return fmt.Errorf("dimensions changed from %d and %d to %d and %d", r.StateDim, r.MeasDim, δ.Len(), y.Len())
+	}
+	nees, err := NEES(δ, P)
+	if err != nil {
+		return err
+	}
+	nis, err := NIS(y, S)
+	if err != nil {
+		return err
+	}
+	r.StateDim, r.MeasDim = δ.Len(), y.Len()
+	r.Epochs = append(r.Epochs, dt)
+	r.NEES = append(r.NEES, nees)
+	r.NIS = append(r.NIS, nis)
+	return nil
+}
+
+// ConsistencyStats stores the χ² consistency test of a normalized squared statistic (NEES or NIS) averaged over
+// the Monte Carlo runs at each epoch.
+type ConsistencyStats struct {
+	Name         string
+	Dimension    int // Degrees of freedom of the statistic of a single run
+	Runs         int
+	Epochs       []time.Time
+	Average      []float64 // Average of the statistic over the runs at each epoch
+	Mean         float64   // Mean of the averages, equal to the dimension if the filter is consistent
+	Lower, Upper float64   // Two-sided acceptance bounds of the average at the confidence level
+	Confidence   float64
+	Within       float64 // Fraction of the epochs where the average is within the bounds, close to the confidence if consistent
+}
+
+func (c ConsistencyStats) String() string {
+	return fmt.Sprintf("%s over %d runs: mean=%.3f (expected %d), bounds [%.3f, %.3f] at %.1f%%, within: %.1f%%", c.Name, c.Runs, c.Mean, c.Dimension, c.Lower, c.Upper, 100*c.Confidence, 100*c.Within)
+}
+
+// newConsistencyStats returns the consistency test of the statistic of each run, which must all be at the same
+// epochs. The average of N runs of a χ² statistic of n degrees of freedom is χ² distributed with N*n degrees of
+// freedom once multiplied by N, whence the bounds.
+func newConsistencyStats(name string, dimension int, epochs []time.Time, values [][]float64, confidence float64) ConsistencyStats {
+	N := len(values)
+	c := ConsistencyStats{Name: name, Dimension: dimension, Runs: N, Epochs: epochs, Average: make([]float64, len(epochs)), Confidence: confidence}
+	α := 1 - confidence
+	c.Lower = chiSquareQuantile(α/2, N*dimension) / float64(N)
+	c.Upper = chiSquareQuantile(1-α/2, N*dimension) / float64(N)
+	for k := range epochs {
+		for _, run := range values {
+			c.Average[k] += run[k]
+		}
+		c.Average[k] /= float64(N)
+		c.Mean += c.Average[k]
+		if c.Average[k] >= c.Lower && c.Average[k] <= c.Upper {
+			c.Within++
+		}
+	}
+	c.Mean /= float64(len(epochs))
+	c.Within /= float64(len(epochs))
+	return c
+}
+
+// FilterConsistency stores the NEES and NIS consistency tests of a Monte Carlo analysis of a filter.
+type FilterConsistency struct {
+	NEES, NIS ConsistencyStats
+}
+
+// NewFilterConsistency returns the NEES and NIS consistency tests of the runs at the provided confidence level
+// (e.g. 0.95). All runs must be at the same epochs and of the same dimensions.
+func NewFilterConsistency(runs []ConsistencyRun, confidence float64) (FilterConsistency, error) {
+	if len(runs) == 0 || len(runs[0].Epochs) == 0 {
+		return FilterConsistency{}, errors.New("no filter runs")
+	}
+	if confidence <= 0 || confidence >= 1 {
+		return FilterConsistency{}, fmt.Errorf("invalid confidence level %f", confidence)
+	}
+	ref := runs[0]
+	nees := make([][]float64, len(runs))
+	nis := make([][]float64, len(runs))
+	for r, run := range runs {
+		if len(run.Epochs) != len(ref.Epochs) || run.StateDim != ref.StateDim || run.MeasDim != ref.MeasDim {
+			return FilterConsistency{}, fmt.Errorf("run %d does not match the first run", r)
+		}
+		for k, dt := range run.Epochs {
+			if !dt.Equal(ref.Epochs[k]) {
+				return FilterConsistency{}, fmt.Errorf("run %d is at %s instead of %s", r, dt, ref.Epochs[k])
+			}
+		}
+		nees[r] = run.NEES
+		nis[r] = run.NIS
+	}
+	return FilterConsistency{
+		NEES: newConsistencyStats("NEES", ref.StateDim, ref.Epochs, nees, confidence),
+		NIS:  newConsistencyStats("NIS", ref.MeasDim, ref.Epochs, nis, confidence),
+	}, nil
+}
+
+func (f FilterConsistency) String() string {
+	return fmt.Sprintf("%s\n%s", f.NEES, f.NIS)
+}
+
+// WriteFilterConsistency writes the average NEES and NIS at each epoch along with their bounds as a CSV table
+// for plotting.
+func WriteFilterConsistency(w io.Writer, f FilterConsistency) error {
+	if _, err := fmt.Fprint(w, "time,NEES,NEESLower,NEESUpper,NIS,NISLower,NISUpper\n"); err != nil {
+		return err
+	}
+	for k, dt := range f.NEES.Epochs {
+		if _, err := fmt.Fprintf(w, "%s,%.6f,%.6f,%.6f,%.6f,%.6f,%.6f\n", dt.UTC().Format(time.RFC3339), f.NEES.Average[k], f.NEES.Lower, f.NEES.Upper, f.NIS.Average[k], f.NIS.Lower, f.NIS.Upper); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chiSquareQuantile returns the value of the χ² distribution with k degrees of freedom whose cumulative
+// distribution is p, by bisection of chiSquareCDF.
+func chiSquareQuantile(p float64, k int) float64 {
+	lo, hi := 0., float64(k)
+	for chiSquareCDF(hi, k) < p {
+		lo, hi = hi, 2*hi
+	}
+	for i := 0; i < 100 && hi-lo > 1e-10*hi; i++ {
+		mid := (lo + hi) / 2
+		if chiSquareCDF(mid, k) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
@@ -0,0 +1,106 @@
+package smd
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestChiSquareQuantile(t *testing.T) {
+	for _, tc := range []struct {
+		p   float64
+		k   int
+		exp float64
+	}{{0.95, 1, 3.841458821}, {0.025, 6, 1.237344246}, {0.975, 6, 14.449375335}, {0.5, 2, 1.386294361}} {
+		if got := chiSquareQuantile(tc.p, tc.k); !floats.EqualWithinAbs(got, tc.exp, 1e-6) {
+			t.Fatalf("χ²⁻¹(%f, %d)=%f expected %f", tc.p, tc.k, got, tc.exp)
+		}
+	}
+}
+
+func TestNEESNIS(t *testing.T) {
+	P := mat64.NewSymDense(2, []float64{4, 0, 0, 9})
+	if nees, err := NEES(mat64.NewVector(2, []float64{2, 3}), P); err != nil || !floats.EqualWithinAbs(nees, 2, 1e-12) {
+		t.Fatalf("NEES=%f (%v)", nees, err)
+	}
+	if _, err := NIS(mat64.NewVector(3, nil), P); err == nil {
+		t.Fatal("dimension mismatch accepted")
+	}
+	H := mat64.NewDense(1, 2, []float64{1, 1})
+	S := InnovationCovariance(H, P, mat64.NewSymDense(1, []float64{1}))
+	if S.Symmetric() != 1 || S.At(0, 0) != 14 {
+		t.Fatalf("S=%v", mat64.Formatted(S))
+	}
+}
+
+func TestFilterConsistency(t *testing.T) {
+	epoch := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	σ := []float64{1, 2, 3, 0.1, 0.2, 0.3}
+	σy := []float64{0.01, 0.001}
+	simulate := func(scale float64) []ConsistencyRun {
+		rng := rand.New(rand.NewSource(1))
+		P := mat64.NewSymDense(6, nil)
+		for i, s := range σ {
+			P.SetSym(i, i, s*s)
+		}
+		S := mat64.NewSymDense(2, nil)
+		for i, s := range σy {
+			S.SetSym(i, i, s*s)
+		}
+		runs := make([]ConsistencyRun, 50)
+		for r := range runs {
+			for k := 0; k < 100; k++ {
+				δ := mat64.NewVector(6, nil)
+				for i, s := range σ {
+					δ.SetVec(i, scale*s*rng.NormFloat64())
+				}
+				y := mat64.NewVector(2, nil)
+				for i, s := range σy {
+					y.SetVec(i, scale*s*rng.NormFloat64())
+				}
+				if err := runs[r].Add(epoch.Add(time.Duration(k)*time.Minute), δ, P, y, S); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		return runs
+	}
+	consistent, err := NewFilterConsistency(simulate(1), 0.95)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if consistent.NEES.Within < 0.85 || consistent.NIS.Within < 0.85 || !floats.EqualWithinAbs(consistent.NEES.Mean, 6, 0.2) || !floats.EqualWithinAbs(consistent.NIS.Mean, 2, 0.1) {
+		t.Fatalf("consistent filter failed:\n%s", consistent)
+	}
+	if consistent.NEES.Lower >= 6 || consistent.NEES.Upper <= 6 {
+		t.Fatalf("invalid NEES bounds: %s", consistent.NEES)
+	}
+	// An overconfident filter has errors larger than its covariance.
+	overconfident, err := NewFilterConsistency(simulate(2), 0.95)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overconfident.NEES.Within > 0.5 || overconfident.NIS.Within > 0.5 {
+		t.Fatalf("overconfident filter passed:\n%s", overconfident)
+	}
+	var buf bytes.Buffer
+	if err := WriteFilterConsistency(&buf, consistent); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 101 {
+		t.Fatalf("%d lines written", lines)
+	}
+	runs := simulate(1)
+	runs[1].Epochs = runs[1].Epochs[1:]
+	if _, err := NewFilterConsistency(runs, 0.95); err == nil {
+		t.Fatal("mismatched runs accepted")
+	}
+	if _, err := NewFilterConsistency(nil, 0.95); err == nil {
+		t.Fatal("no runs accepted")
+	}
+}
@@ -0,0 +1,110 @@
+package smd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BodyConstants are the physical constants of a celestial body which differ between the sets of constants.
+type BodyConstants struct {
+	Radius     float64 // Equatorial radius (km)
+	GM         float64 // Gravitational parameter (km^3/s^2)
+	J2, J3, J4 float64 // Zonal harmonics, unchanged when all three are zero
+}
+
+// ConstantsSet is a set of astrodynamical constants, e.g. those of a textbook or of a JPL ephemeris, so that the
+// results can be matched to those of external tools when validating.
+type ConstantsSet struct {
+	Name   string
+	AU     float64                  // Astronomical unit (km), unchanged if zero
+	Bodies map[string]BodyConstants // Per body name, e.g. "Earth"; the other bodies are unchanged
+}
+
+func (s ConstantsSet) String() string {
+	names := make([]string, 0, len(s.Bodies))
+	for name := range s.Bodies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("%s constants (%s)", s.Name, strings.Join(names, ", "))
+}
+
+// apply sets the non zero constants onto the provided body.
+func (bc BodyConstants) apply(body *CelestialObject) {
+	if bc.Radius > 0 {
+		body.Radius = bc.Radius
+	}
+	if bc.GM > 0 {
+		body.μ = bc.GM
+	}
+	if bc.J2 != 0 || bc.J3 != 0 || bc.J4 != 0 {
+		body.J2, body.J3, body.J4 = bc.J2, bc.J3, bc.J4
+	}
+}
+
+// celestialBodies are the bodies whose constants are set by UseConstants.
+var celestialBodies = map[string]*CelestialObject{
+	"Sun": &Sun, "Moon": &Moon, "Venus": &Venus, "Earth": &Earth, "Mars": &Mars, "Jupiter": &Jupiter,
+	"Saturn": &Saturn, "Uranus": &Uranus, "Neptune": &Neptune, "Pluto": &Pluto,
+}
+
+// DefaultConstants are the constants smd uses by default.
+var DefaultConstants = currentConstants("smd")
+
+// currentConstants returns the constants currently in use.
+func currentConstants(name string) ConstantsSet {
+	set := ConstantsSet{Name: name, AU: AU, Bodies: make(map[string]BodyConstants)}
+	for bodyName, body := range celestialBodies {
+		set.Bodies[bodyName] = BodyConstants{body.Radius, body.μ, body.J2, body.J3, body.J4}
+	}
+	return set
+}
+
+// DE430Constants are the gravitational parameters of the JPL DE430 ephemeris (gm_de431.tpc) and the radii of the
+// IAU 2009 report (pck00010.tpc). The GM of the planets with moons other than the Earth are those of their systems.
+var DE430Constants = ConstantsSet{
+	Name: "DE430",
+	AU:   1.49597870700e8,
+	Bodies: map[string]BodyConstants{
+		"Sun":     {Radius: 696000, GM: 1.3271244004193938e11},
+		"Moon":    {Radius: 1737.4, GM: 4.9028000661637961e3},
+		"Venus":   {Radius: 6051.8, GM: 3.2485859200000006e5},
+		"Earth":   {Radius: 6378.1366, GM: 3.9860043543609598e5},
+		"Mars":    {Radius: 3396.19, GM: 4.2828375214000022e4},
+		"Jupiter": {Radius: 71492, GM: 1.2671276480000021e8},
+		"Saturn":  {Radius: 60268, GM: 3.7940585200000003e7},
+		"Uranus":  {Radius: 25559, GM: 5.7945486000000080e6},
+		"Neptune": {Radius: 24764, GM: 6.8365271005800236e6},
+		"Pluto":   {Radius: 1195, GM: 9.7700000000000068e2},
+	},
+}
+
+// ValladoConstants are the constants of the Sun, the Earth and the Moon of Vallado, Fundamentals of Astrodynamics
+// and Applications, 4th edition (appendix D), which derive from EGM-96.
+var ValladoConstants = ConstantsSet{
+	Name: "Vallado",
+	AU:   1.49597870700e8,
+	Bodies: map[string]BodyConstants{
+		"Sun":   {Radius: 696000, GM: 1.32712428e11},
+		"Moon":  {Radius: 1738, GM: 4902.799},
+		"Earth": {Radius: 6378.1363, GM: 398600.4418, J2: 0.0010826267, J3: -0.0000025327, J4: -0.0000016196},
+	},
+}
+
+// UseConstants sets the constants of the celestial bodies and the AU from the provided set, and updates the planet
+// of the builtin stations. It must be called before creating any orbit or station, since they keep a copy of their
+// central body with its constants. Use DefaultConstants to revert to the constants of smd. Panics if a body is unknown.
+func UseConstants(set ConstantsSet) {
+	for name, constants := range set.Bodies {
+		body, ok := celestialBodies[name]
+		if !ok {
+			panic(fmt.Errorf("unknown body '%s' in %s", name, set.Name))
+		}
+		constants.apply(body)
+	}
+	if set.AU > 0 {
+		AU = set.AU
+	}
+	refreshDSNStations()
+}
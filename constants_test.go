@@ -0,0 +1,81 @@
+package smd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUseConstants(t *testing.T) {
+	defer UseConstants(DefaultConstants)
+	μ, J2 := Earth.GM(), Earth.J2
+	UseConstants(DE430Constants)
+	if Earth.GM() != 3.9860043543609598e5 || Earth.Radius != 6378.1366 {
+		t.Fatalf("DE430 Earth: μ=%f R=%f", Earth.GM(), Earth.Radius)
+	}
+	if Earth.J2 != J2 {
+		t.Fatal("J2 changed without a value in the set")
+	}
+	if body, _ := CelestialObjectFromString("mars"); body.GM() != 4.2828375214000022e4 {
+		t.Fatalf("DE430 Mars μ=%f", body.GM())
+	}
+	o := NewOrbitFromOE(7000, 0, 0, 0, 0, 0, Earth)
+	if !o.Origin.Equals(Earth) {
+		t.Fatal("orbit origin is not the Earth")
+	}
+	UseConstants(ValladoConstants)
+	if Earth.GM() != 398600.4418 || Earth.J3 != -0.0000025327 {
+		t.Fatalf("Vallado Earth: μ=%f J3=%e", Earth.GM(), Earth.J3)
+	}
+	if Mars.GM() != 4.2828375214000022e4 {
+		t.Fatal("Mars is not in the Vallado set and should be unchanged")
+	}
+	UseConstants(DefaultConstants)
+	if Earth.GM() != μ || Earth.J2 != J2 || Mars.GM() != 4.28283100e4 {
+		t.Fatal("default constants not restored")
+	}
+	assertPanic(t, func() {
+		UseConstants(ConstantsSet{Name: "bogus", Bodies: map[string]BodyConstants{"Vulcan": {GM: 1}}})
+	})
+}
+
+func TestUseConstantsDSN(t *testing.T) {
+	defer UseConstants(DefaultConstants)
+	dt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	UseConstants(DE430Constants)
+	rSC := ECEF2ECI([]float64{-9000, -18000, 14000}, Earth.RotationAngle(dt))
+	state := State{DT: dt, Orbit: *NewOrbitFromRV(rSC, []float64{0, 1, 3}, Earth)}
+	for _, st := range []Station{DSS14Goldstone, BuiltinStationFromName("dss43"), DSNStations[len(DSNStations)-1]} {
+		if m := st.Measure(state); m.TrueRange <= 0 {
+			t.Fatalf("%s: invalid measurement %+v", st.Name, m)
+		}
+	}
+	if DSS14Goldstone.Planet.Radius != 6378.1366 || DSNStations[0].Planet.GM() != Earth.GM() {
+		t.Fatal("the DSN stations were not updated")
+	}
+}
+
+func TestMissionGravityOverride(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	R, V := NewOrbitFromOE(7000, 0.01, 28.5, 10, 20, 30, Earth).RV()
+	earth := BodyConstants{GM: 1.001 * Earth.GM(), J2: 2 * Earth.J2, J3: Earth.J3, J4: Earth.J4}
+	propagate := func(gravity map[string]BodyConstants) (*Mission, []float64) {
+		m := NewPreciseMission(NewEmptySC("gravity", 0), NewOrbitFromRV(R, V, Earth), start, start.Add(6*time.Hour), Perturbations{Jn: 2}, time.Minute, true, ExportConfig{})
+		m.Gravity = gravity
+		m.Propagate()
+		return m, m.Orbit.R()
+	}
+	_, nominal := propagate(nil)
+	overridden, withOverride := propagate(map[string]BodyConstants{"Earth": earth})
+	if Earth.GM() != 3.98600433e5 || !overridden.Orbit.Origin.Equals(Earth) {
+		t.Fatal("the override changed the Earth")
+	}
+	UseConstants(ConstantsSet{Name: "test", Bodies: map[string]BodyConstants{"Earth": earth}})
+	_, global := propagate(nil)
+	UseConstants(DefaultConstants)
+	if !vectorsEqual(withOverride, global) {
+		t.Fatalf("override %+v differs from the constants %+v", withOverride, global)
+	}
+	if vectorsEqual(withOverride, nominal) {
+		t.Fatal("the override had no effect")
+	}
+}
@@ -0,0 +1,246 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ConstellationMember is a satellite of a constellation.
+type ConstellationMember struct {
+	Name        string
+	Plane, Slot int
+	Orbit       *Orbit
+}
+
+func (m ConstellationMember) String() string {
+	return fmt.Sprintf("%s (plane %d, slot %d): %s", m.Name, m.Plane, m.Slot, m.Orbit)
+}
+
+// WalkerDelta returns the members of the Walker delta constellation i:t/p/f, where t is the total number of
+// satellites, p the number of equally spaced planes over 360 degrees of RAAN and f the relative phasing between
+// adjacent planes. The semi-major axis is in km and the inclination in degrees.
+func WalkerDelta(name string, t, p, f int, a, i float64, body CelestialObject) []ConstellationMember {
+	return walker(name, t, p, f, a, i, 360, body)
+}
+
+// WalkerStar returns the members of the Walker star constellation i:t/p/f, where the planes are equally spaced
+// over 180 degrees of RAAN, as for polar constellations.
+func WalkerStar(name string, t, p, f int, a, i float64, body CelestialObject) []ConstellationMember {
+	return walker(name, t, p, f, a, i, 180, body)
+}
+
+func walker(name string, t, p, f int, a, i, raanSpread float64, body CelestialObject) []ConstellationMember {
+	if t <= 0 || p <= 0 || t%p != 0 {
+		panic(fmt.Errorf("invalid Walker constellation %d/%d: the number of satellites must be a multiple of the number of planes", t, p))
+	}
+	if f < 0 || f >= p {
+		panic(fmt.Errorf("invalid Walker phasing %d: must be in [0, %d)", f, p))
+	}
+	s := t / p
+	members := make([]ConstellationMember, 0, t)
+	for plane := 0; plane < p; plane++ {
+		Ω := raanSpread * float64(plane) / float64(p)
+		for slot := 0; slot < s; slot++ {
+			ν := 360*float64(slot)/float64(s) + 360*float64(f*plane)/float64(t)
+			members = append(members, ConstellationMember{
+				Name:  fmt.Sprintf("%s-P%dS%d", name, plane, slot),
+				Plane: plane,
+				Slot:  slot,
+				Orbit: NewOrbitFromOE(a, 0, i, Ω, 0, math.Mod(ν, 360), body),
+			})
+		}
+	}
+	return members
+}
+
+// ConstellationMission propagates all the members of a constellation with the same perturbations and time step.
+type ConstellationMission struct {
+	Members  []ConstellationMember
+	Missions []*Mission
+	History  [][]State // States of each member, filled during the propagation
+}
+
+// NewConstellationMission returns a new constellation mission. If the export configuration is used, each member
+// is exported in its own file, suffixed with the name of the member. The orbits of the members are not modified.
+func NewConstellationMission(members []ConstellationMember, start, end time.Time, perts Perturbations, step time.Duration, conf ExportConfig) *ConstellationMission {
+	c := &ConstellationMission{Members: members, Missions: make([]*Mission, len(members)), History: make([][]State, len(members))}
+	for k, member := range members {
+		memberConf := conf
+		if !conf.IsUseless() {
+			memberConf.Filename = fmt.Sprintf("%s-%s", conf.Filename, member.Name)
+		}
+		o := *member.Orbit
+		c.Missions[k] = NewPreciseMission(NewEmptySC(member.Name, 0), &o, start, end, perts, step, false, memberConf)
+	}
+	return c
+}
+
+// Propagate propagates all members concurrently and records their states.
+func (c *ConstellationMission) Propagate() {
+	propagateAll(c.Missions, c.History)
+}
+
+// WriteMembers writes the final osculating elements of each member as a CSV table.
+func (c *ConstellationMission) WriteMembers(w io.Writer) error {
+	if _, err := fmt.Fprint(w, "name,plane,slot,epoch,a,e,i,raan,argp,nu\n"); err != nil {
+		return err
+	}
+	for k, member := range c.Members {
+		if len(c.History[k]) == 0 {
+			return fmt.Errorf("%s was not propagated", member.Name)
+		}
+		final := c.History[k][len(c.History[k])-1]
+		a, e, i, Ω, ω, ν, _, _, _ := final.Orbit.Elements()
+		if _, err := fmt.Fprintf(w, "%s,%d,%d,%s,%.6f,%.9f,%.6f,%.6f,%.6f,%.6f\n", member.Name, member.Plane, member.Slot, final.DT.UTC().Format(time.RFC3339), a, e, Rad2deg(i), Rad2deg(Ω), Rad2deg(ω), Rad2deg(ν)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlaneSummary stores the mean evolution of the members of a plane.
+type PlaneSummary struct {
+	Plane           int
+	Members         int
+	InitialRAAN     float64 // degrees
+	RAANDrift       float64 // Mean RAAN drift over the propagation (degrees)
+	MeanSMA         float64 // Mean final semi-major axis (km)
+	MeanInclination float64 // Mean final inclination (degrees)
+}
+
+// Planes returns the summary of each plane of the constellation.
+func (c *ConstellationMission) Planes() []PlaneSummary {
+	var summaries []PlaneSummary
+	index := make(map[int]int)
+	for k, member := range c.Members {
+		if len(c.History[k]) == 0 {
+			continue
+		}
+		idx, ok := index[member.Plane]
+		if !ok {
+			_, _, _, Ω, _, _, _, _, _ := member.Orbit.Elements()
+			idx = len(summaries)
+			index[member.Plane] = idx
+			summaries = append(summaries, PlaneSummary{Plane: member.Plane, InitialRAAN: Rad2deg(Ω)})
+		}
+		_, _, _, Ω0, _, _, _, _, _ := member.Orbit.Elements()
+		a, _, i, Ω, _, _, _, _, _ := c.History[k][len(c.History[k])-1].Orbit.Elements()
+		s := &summaries[idx]
+		s.Members++
+		s.RAANDrift += Rad2deg180(Ω - Ω0)
+		s.MeanSMA += a
+		s.MeanInclination += Rad2deg(i)
+	}
+	for k := range summaries {
+		n := float64(summaries[k].Members)
+		summaries[k].RAANDrift /= n
+		summaries[k].MeanSMA /= n
+		summaries[k].MeanInclination /= n
+	}
+	return summaries
+}
+
+// CoverageStats stores the coverage of a ground point by the constellation.
+type CoverageStats struct {
+	LatΦ, Longθ float64       // degrees
+	Coverage    float64       // Fraction of the time with at least one satellite in view
+	MaxGap      time.Duration // Longest period without any satellite in view
+	MeanGap     time.Duration // Mean period without any satellite in view (zero if always covered)
+	MeanInView  float64       // Mean number of satellites in view
+	MaxInView   int
+}
+
+func (s CoverageStats) String() string {
+	return fmt.Sprintf("(%.2f, %.2f): coverage=%.2f%% max gap=%s mean in view=%.2f", s.LatΦ, s.Longθ, 100*s.Coverage, s.MaxGap, s.MeanInView)
+}
+
+// Coverage returns the coverage statistics of the ground point at the provided latitude and longitude (degrees),
+// where a satellite is in view above the minimum elevation (degrees). The constellation must orbit the Earth.
+func (c *ConstellationMission) Coverage(latΦ, longθ, minElevation float64) CoverageStats {
+	steps := -1
+	for k, member := range c.Members {
+		if !member.Orbit.Origin.Equals(Earth) {
+			panic("coverage is only supported about the Earth")
+		}
+		if steps < 0 || len(c.History[k]) < steps {
+			steps = len(c.History[k])
+		}
+	}
+	if steps < 2 {
+		panic("constellation must be propagated before computing its coverage")
+	}
+	site := Station{R: GEO2ECEF(0, latΦ*deg2rad, longθ*deg2rad), LatΦ: latΦ * deg2rad, Longθ: longθ * deg2rad}
+	stats := CoverageStats{LatΦ: latΦ, Longθ: longθ}
+	var covered, gaps int
+	var gapStart time.Time
+	inGap := false
+	for step := 0; step < steps; step++ {
+		dt := c.History[0][step].DT
+		dcm := Earth.Inertial2BodyFixed(dt)
+		inView := 0
+		for k := range c.Members {
+			if _, _, el, _ := site.RangeElAz(MxV33(dcm, c.History[k][step].Orbit.R())); el >= minElevation {
+				inView++
+			}
+		}
+		stats.MeanInView += float64(inView)
+		if inView > stats.MaxInView {
+			stats.MaxInView = inView
+		}
+		if inView > 0 {
+			covered++
+			if inGap {
+				inGap = false
+				gaps++
+				stats.MeanGap += dt.Sub(gapStart)
+				if gap := dt.Sub(gapStart); gap > stats.MaxGap {
+					stats.MaxGap = gap
+				}
+			}
+		} else if !inGap {
+			inGap = true
+			gapStart = dt
+		}
+	}
+	if inGap {
+		// Account for the gap at the end of the propagation.
+		gaps++
+		gap := c.History[0][steps-1].DT.Sub(gapStart)
+		stats.MeanGap += gap
+		if gap > stats.MaxGap {
+			stats.MaxGap = gap
+		}
+	}
+	if gaps > 0 {
+		stats.MeanGap /= time.Duration(gaps)
+	}
+	stats.Coverage = float64(covered) / float64(steps)
+	stats.MeanInView /= float64(steps)
+	return stats
+}
+
+// CoverageGrid returns the coverage statistics on a latitude and longitude grid of the provided steps (degrees).
+func (c *ConstellationMission) CoverageGrid(latStep, longStep, minElevation float64) []CoverageStats {
+	var grid []CoverageStats
+	for lat := -90.; lat <= 90; lat += latStep {
+		for long := -180.; long < 180; long += longStep {
+			grid = append(grid, c.Coverage(lat, long, minElevation))
+		}
+	}
+	return grid
+}
+
+// WriteCoverage writes the coverage statistics as a CSV table.
+func WriteCoverage(w io.Writer, stats []CoverageStats) error {
+	if _, err := fmt.Fprint(w, "lat,long,coverage,maxGapInMinutes,meanGapInMinutes,meanInView,maxInView\n"); err != nil {
+		return err
+	}
+	for _, s := range stats {
+		if _, err := fmt.Fprintf(w, "%.3f,%.3f,%.6f,%.3f,%.3f,%.3f,%d\n", s.LatΦ, s.Longθ, s.Coverage, s.MaxGap.Minutes(), s.MeanGap.Minutes(), s.MeanInView, s.MaxInView); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestWalkerDelta(t *testing.T) {
+	// GPS like 55:24/6/1
+	members := WalkerDelta("gps", 24, 6, 1, Earth.Radius+20200, 55, Earth)
+	if len(members) != 24 {
+		t.Fatalf("%d members", len(members))
+	}
+	for _, m := range members {
+		_, _, i, Ω, _, _, _, _, _ := m.Orbit.Elements()
+		if !floats.EqualWithinAbs(Rad2deg(i), 55, 1e-9) || !floats.EqualWithinAbs(Rad2deg(Ω), 60*float64(m.Plane), 1e-9) {
+			t.Fatalf("invalid plane for %s", m)
+		}
+		// The argument of latitude includes the phasing of 360f/t per plane.
+		exp := Deg2rad(90*float64(m.Slot) + 15*float64(m.Plane))
+		node := []float64{math.Cos(Ω), math.Sin(Ω), 0}
+		R := m.Orbit.R()
+		u := math.Atan2(Dot(R, Cross(Unit(m.Orbit.H()), node)), Dot(R, node))
+		if !floats.EqualWithinAbs(Rad2deg180(u-exp), 0, 1e-6) {
+			t.Fatalf("invalid phasing for %s: u=%f expected %f", m, Rad2deg(u), Rad2deg(exp))
+		}
+	}
+	star := WalkerStar("polar", 6, 3, 0, Earth.Radius+780, 86.4, Earth)
+	if _, _, _, Ω, _, _, _, _, _ := star[5].Orbit.Elements(); !floats.EqualWithinAbs(Rad2deg(Ω), 120, 1e-9) {
+		t.Fatalf("invalid Walker star RAAN %f", Rad2deg(Ω))
+	}
+	assertPanic(t, func() {
+		WalkerDelta("invalid", 10, 3, 1, Earth.Radius+500, 50, Earth)
+	})
+	assertPanic(t, func() {
+		WalkerDelta("invalid", 12, 3, 3, Earth.Radius+500, 50, Earth)
+	})
+}
+
+func TestConstellationMission(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	members := WalkerDelta("leo", 6, 3, 1, Earth.Radius+1000, 60, Earth)
+	R0 := append([]float64{}, members[0].Orbit.R()...)
+	c := NewConstellationMission(members, start, start.Add(2*time.Hour), Perturbations{Jn: 2}, time.Minute, ExportConfig{})
+	c.Propagate()
+	if !floats.Equal(members[0].Orbit.R(), R0) {
+		t.Fatal("member orbit was modified by the propagation")
+	}
+	for k, history := range c.History {
+		if len(history) < 100 {
+			t.Fatalf("%s has %d states", members[k].Name, len(history))
+		}
+		if !history[len(history)-1].DT.Equal(c.History[0][len(c.History[0])-1].DT) {
+			t.Fatal("members not propagated to the same epoch")
+		}
+	}
+	planes := c.Planes()
+	if len(planes) != 3 {
+		t.Fatalf("%d planes", len(planes))
+	}
+	for _, p := range planes {
+		// J2 regresses the nodes of prograde orbits.
+		if p.Members != 2 || p.RAANDrift >= 0 || !floats.EqualWithinAbs(p.MeanInclination, 60, 0.1) {
+			t.Fatalf("invalid plane summary %+v", p)
+		}
+	}
+	var buf bytes.Buffer
+	if err := c.WriteMembers(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 7 {
+		t.Fatalf("wrote %d lines", lines)
+	}
+	grid := c.CoverageGrid(45, 90, 10)
+	if len(grid) != 5*4 {
+		t.Fatalf("grid of %d points", len(grid))
+	}
+	for _, s := range grid {
+		if s.Coverage < 0 || s.Coverage > 1 || s.MaxInView > 6 {
+			t.Fatalf("invalid coverage %s", s)
+		}
+		if s.LatΦ == 90 && s.Coverage != 0 {
+			t.Fatalf("60 degree constellation covers the pole: %s", s)
+		}
+	}
+	buf.Reset()
+	if err := WriteCoverage(&buf, grid); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConstellationCoverageGEO(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	// GEO satellite above longitude 10 degrees.
+	rλ := MxV33(Earth.BodyFixed2Inertial(start), GEO2ECEF(0, 0, Deg2rad(10)))
+	λ := Rad2deg(math.Atan2(rλ[1], rλ[0]))
+	members := []ConstellationMember{{"geo", 0, 0, NewOrbitFromOE(42164.17, 0, 0, 0, 0, λ, Earth)}}
+	c := NewConstellationMission(members, start, start.Add(6*time.Hour), Perturbations{}, time.Minute, ExportConfig{})
+	c.Propagate()
+	below := c.Coverage(0, 10, 80)
+	if below.Coverage != 1 || below.MaxGap != 0 || below.MeanInView != 1 {
+		t.Fatalf("sub-satellite point not always covered: %s", below)
+	}
+	opposite := c.Coverage(0, -170, 0)
+	if opposite.Coverage != 0 || opposite.MaxInView != 0 {
+		t.Fatalf("antipode covered: %s", opposite)
+	}
+	if dur := c.History[0][len(c.History[0])-1].DT.Sub(c.History[0][0].DT); opposite.MaxGap != dur {
+		t.Fatalf("max gap %s != %s", opposite.MaxGap, dur)
+	}
+}
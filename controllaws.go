@@ -0,0 +1,172 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TimedThrustControl is a ThrustControl whose control also depends on the epoch, e.g. to switch laws at given
+// times. The spacecraft uses ControlAt instead of Control when available.
+type TimedThrustControl interface {
+	ThrustControl
+	ControlAt(o Orbit, dt time.Time) []float64
+}
+
+// controlAt returns the control of the provided law at the provided epoch.
+func controlAt(ctrl ThrustControl, o Orbit, dt time.Time) []float64 {
+	if timed, ok := ctrl.(TimedThrustControl); ok {
+		return timed.ControlAt(o, dt)
+	}
+	return ctrl.Control(o)
+}
+
+// WeightedSum combines control laws by the weighted sum of their thrust directions, normalized to a unit vector.
+type WeightedSum struct {
+	Laws    []ThrustControl
+	Weights []float64
+	reason  string
+}
+
+// NewWeightedSum returns a new weighted sum of the provided control laws.
+func NewWeightedSum(reason string, laws []ThrustControl, weights []float64) WeightedSum {
+	if len(laws) == 0 || len(laws) != len(weights) {
+		panic(fmt.Errorf("%d control laws but %d weights", len(laws), len(weights)))
+	}
+	return WeightedSum{laws, weights, reason}
+}
+
+// Reason implements the ThrustControl interface.
+func (cl WeightedSum) Reason() string {
+	return cl.reason
+}
+
+// Type implements the ThrustControl interface.
+func (cl WeightedSum) Type() ControlLaw {
+	return multiOpti
+}
+
+// Control implements the ThrustControl interface.
+func (cl WeightedSum) Control(o Orbit) []float64 {
+	return cl.ControlAt(o, time.Time{})
+}
+
+// ControlAt implements the TimedThrustControl interface. The spacecraft coasts if the directions cancel out.
+func (cl WeightedSum) ControlAt(o Orbit, dt time.Time) []float64 {
+	thrust := []float64{0, 0, 0}
+	for i, law := range cl.Laws {
+		ctrl := controlAt(law, o, dt)
+		for j := 0; j < 3; j++ {
+			thrust[j] += cl.Weights[i] * ctrl[j]
+		}
+	}
+	if Norm(thrust) < 1e-12 {
+		return []float64{0, 0, 0}
+	}
+	return Unit(thrust)
+}
+
+// ScheduledLaw is a control law active from the provided epoch.
+type ScheduledLaw struct {
+	From time.Time
+	Law  ThrustControl
+}
+
+// TimeSwitch switches between control laws at given epochs. The spacecraft coasts before the first one.
+type TimeSwitch struct {
+	Schedule []ScheduledLaw
+	reason   string
+}
+
+// NewTimeSwitch returns a new time switch between the provided laws, which must be in chronological order.
+func NewTimeSwitch(reason string, schedule ...ScheduledLaw) TimeSwitch {
+	for i := 1; i < len(schedule); i++ {
+		if schedule[i].From.Before(schedule[i-1].From) {
+			panic(fmt.Errorf("control law #%d starts before the previous one", i))
+		}
+	}
+	return TimeSwitch{schedule, reason}
+}
+
+// Active returns the control law active at the provided epoch, or nil if none is.
+func (cl TimeSwitch) Active(dt time.Time) ThrustControl {
+	var active ThrustControl
+	for _, s := range cl.Schedule {
+		if s.From.After(dt) {
+			break
+		}
+		active = s.Law
+	}
+	return active
+}
+
+// Reason implements the ThrustControl interface.
+func (cl TimeSwitch) Reason() string {
+	return cl.reason
+}
+
+// Type implements the ThrustControl interface.
+func (cl TimeSwitch) Type() ControlLaw {
+	return multiOpti
+}
+
+// Control implements the ThrustControl interface. Without an epoch, only the laws scheduled from the zero time apply.
+func (cl TimeSwitch) Control(o Orbit) []float64 {
+	return cl.ControlAt(o, time.Time{})
+}
+
+// ControlAt implements the TimedThrustControl interface.
+func (cl TimeSwitch) ControlAt(o Orbit, dt time.Time) []float64 {
+	active := cl.Active(dt)
+	if active == nil {
+		return []float64{0, 0, 0}
+	}
+	return controlAt(active, o, dt)
+}
+
+// OrbitPredicate returns whether a condition on the orbit holds at the provided epoch.
+type OrbitPredicate func(o Orbit, dt time.Time) bool
+
+// TrueAnomalyWithin returns a predicate which holds when the true anomaly is within the provided half width of
+// the provided center (both in degrees), e.g. TrueAnomalyWithin(0, 30) to only thrust around periapsis.
+func TrueAnomalyWithin(center, halfWidth float64) OrbitPredicate {
+	return func(o Orbit, dt time.Time) bool {
+		_, _, _, _, _, ν, _, _, _ := o.Elements()
+		return math.Abs(Rad2deg180(ν-Deg2rad(center))) <= halfWidth
+	}
+}
+
+// GatedControl only applies its control law when the predicate holds, and coasts otherwise.
+type GatedControl struct {
+	Law       ThrustControl
+	Predicate OrbitPredicate
+	reason    string
+}
+
+// NewGatedControl returns a new control law gated by the provided predicate.
+func NewGatedControl(reason string, law ThrustControl, predicate OrbitPredicate) GatedControl {
+	return GatedControl{law, predicate, reason}
+}
+
+// Reason implements the ThrustControl interface.
+func (cl GatedControl) Reason() string {
+	return cl.reason
+}
+
+// Type implements the ThrustControl interface.
+func (cl GatedControl) Type() ControlLaw {
+	return cl.Law.Type()
+}
+
+// Control implements the ThrustControl interface.
+func (cl GatedControl) Control(o Orbit) []float64 {
+	return cl.ControlAt(o, time.Time{})
+}
+
+// ControlAt implements the TimedThrustControl interface.
+func (cl GatedControl) ControlAt(o Orbit, dt time.Time) []float64 {
+	if !cl.Predicate(o, dt) {
+		return []float64{0, 0, 0}
+	}
+	return controlAt(cl.Law, o, dt)
+}
@@ -0,0 +1,82 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestWeightedSum(t *testing.T) {
+	o := *NewOrbitFromOE(7000, 0.1, 30, 0, 0, 10, Earth)
+	sum := NewWeightedSum("tan+inc", []ThrustControl{Tangential{}, NewOptimalThrust(OptiΔiCL, "inc")}, []float64{1, 1})
+	ctrl := sum.Control(o)
+	s := 1 / math.Sqrt2
+	if !floats.EqualApprox(ctrl, []float64{0, s, s}, 1e-12) && !floats.EqualApprox(ctrl, []float64{0, s, -s}, 1e-12) {
+		t.Fatalf("invalid weighted sum %+v", ctrl)
+	}
+	if sum.Type() != multiOpti || sum.Reason() != "tan+inc" {
+		t.Fatal("invalid type or reason")
+	}
+	canceled := NewWeightedSum("", []ThrustControl{Tangential{}, AntiTangential{}}, []float64{1, 1})
+	if Norm(canceled.Control(o)) != 0 {
+		t.Fatal("opposite laws should cancel out")
+	}
+	assertPanic(t, func() {
+		NewWeightedSum("", []ThrustControl{Tangential{}}, []float64{1, 2})
+	})
+}
+
+func TestTimeSwitch(t *testing.T) {
+	o := *NewOrbitFromOE(7000, 0.1, 30, 0, 0, 10, Earth)
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	sw := NewTimeSwitch("raise then lower", ScheduledLaw{start, Tangential{}}, ScheduledLaw{start.Add(time.Hour), AntiTangential{}})
+	if ctrl := sw.ControlAt(o, start.Add(-time.Second)); Norm(ctrl) != 0 {
+		t.Fatalf("should coast before the first law: %+v", ctrl)
+	}
+	if ctrl := sw.ControlAt(o, start); !floats.Equal(ctrl, []float64{0, 1, 0}) {
+		t.Fatalf("should be tangential: %+v", ctrl)
+	}
+	if ctrl := sw.ControlAt(o, start.Add(2*time.Hour)); !floats.Equal(ctrl, []float64{0, -1, 0}) {
+		t.Fatalf("should be anti-tangential: %+v", ctrl)
+	}
+	if Norm(sw.Control(o)) != 0 {
+		t.Fatal("without an epoch, no law is scheduled")
+	}
+	assertPanic(t, func() {
+		NewTimeSwitch("", ScheduledLaw{start, Tangential{}}, ScheduledLaw{start.Add(-time.Hour), Coast{}})
+	})
+}
+
+func TestGatedControl(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	gate := NewGatedControl("perigee", Tangential{}, TrueAnomalyWithin(0, 30))
+	if gate.Type() != tangential {
+		t.Fatal("the gate should have the type of its law")
+	}
+	for ν, thrusting := range map[float64]bool{10: true, -29: true, 90: false, 180: false} {
+		o := *NewOrbitFromOE(7000, 0.1, 30, 0, 0, ν, Earth)
+		if ctrl := gate.Control(o); (Norm(ctrl) > 0) != thrusting {
+			t.Fatalf("ν=%f: thrusting=%v expected %v", ν, !thrusting, thrusting)
+		}
+	}
+	// Wrapping around ±180 degrees.
+	if !TrueAnomalyWithin(180, 20)(*NewOrbitFromOE(7000, 0.1, 30, 0, 0, -170, Earth), start) {
+		t.Fatal("ν=-170 is within 20 degrees of apoapsis")
+	}
+	// Gated laws can be nested in a time switch, and the spacecraft provides the epoch.
+	sw := NewTimeSwitch("later", ScheduledLaw{start.Add(time.Hour), gate})
+	sc := NewSpacecraft("gated", 500, 100, NewUnlimitedEPS(), []EPThruster{NewGenericEP(1, 2000)}, false, []*Cargo{}, []Waypoint{NewFiniteBurn(start, 24*time.Hour, sw, nil)})
+	perigee := NewOrbitFromOE(7000, 0.1, 30, 0, 0, 10, Earth)
+	apogee := NewOrbitFromOE(7000, 0.1, 30, 0, 0, 180, Earth)
+	if Δv, _ := sc.Accelerate(start.Add(time.Minute), perigee); Norm(Δv) != 0 {
+		t.Fatal("should coast before the switch")
+	}
+	if Δv, _ := sc.Accelerate(start.Add(2*time.Hour), apogee); Norm(Δv) != 0 {
+		t.Fatal("should coast away from perigee")
+	}
+	if Δv, _ := sc.Accelerate(start.Add(2*time.Hour), perigee); Δv[1] <= 0 || Δv[0] != 0 || Δv[2] != 0 {
+		t.Fatalf("should thrust tangentially at perigee: %+v", Δv)
+	}
+}
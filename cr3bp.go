@@ -0,0 +1,440 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ChristopherRabotin/ode"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	cr3bpStep = 1e-3  // Normalized integration step
+	cr3bpε    = 1e-11 // Convergence tolerance on the crossing conditions
+)
+
+// CR3BP defines a circular restricted three body problem between two primaries.
+// All the states are normalized: the length unit (LU) is the distance between both
+// primaries and the time unit (TU) is such that the mean motion is one. The synodic
+// frame is centered on the barycenter with the x-axis pointing to the secondary.
+type CR3BP struct {
+	Primary, Secondary CelestialObject
+	μ                  float64 // Mass ratio
+	LU, TU             float64 // Length unit (km) and time unit (s)
+}
+
+// NewCR3BP returns a new CR3BP system between the primary and the secondary.
+func NewCR3BP(primary, secondary CelestialObject) CR3BP {
+	μ := secondary.μ / (primary.μ + secondary.μ)
+	LU := secondary.a
+	TU := math.Sqrt(math.Pow(LU, 3) / (primary.μ + secondary.μ))
+	return CR3BP{primary, secondary, μ, LU, TU}
+}
+
+// MassRatio returns the μ of this system.
+func (s CR3BP) MassRatio() float64 {
+	return s.μ
+}
+
+// distances returns the distances from the primary and from the secondary.
+func (s CR3BP) distances(x, y, z float64) (r1, r2 float64) {
+	r1 = math.Sqrt(math.Pow(x+s.μ, 2) + y*y + z*z)
+	r2 = math.Sqrt(math.Pow(x-1+s.μ, 2) + y*y + z*z)
+	return
+}
+
+// JacobiConstant returns the Jacobi constant of the provided normalized state.
+func (s CR3BP) JacobiConstant(state []float64) float64 {
+	r1, r2 := s.distances(state[0], state[1], state[2])
+	U := (state[0]*state[0]+state[1]*state[1])/2 + (1-s.μ)/r1 + s.μ/r2
+	return 2*U - (state[3]*state[3] + state[4]*state[4] + state[5]*state[5])
+}
+
+// LagrangePoint returns the normalized position of the requested Lagrange point (1 through 5).
+func (s CR3BP) LagrangePoint(n int) []float64 {
+	switch n {
+	case 4:
+		return []float64{0.5 - s.μ, math.Sqrt(3) / 2, 0}
+	case 5:
+		return []float64{0.5 - s.μ, -math.Sqrt(3) / 2, 0}
+	}
+	var x float64
+	switch n {
+	case 1:
+		x = 1 - s.μ - math.Cbrt(s.μ/3)
+	case 2:
+		x = 1 - s.μ + math.Cbrt(s.μ/3)
+	case 3:
+		x = -1 - 5*s.μ/12
+	default:
+		panic(fmt.Errorf("there is no L%d Lagrange point", n))
+	}
+	// Collinear points are found via Newton iterations.
+	for iter := 0; iter < 100; iter++ {
+		d1 := math.Abs(x + s.μ)
+		d2 := math.Abs(x - 1 + s.μ)
+		f := x - (1-s.μ)*(x+s.μ)/math.Pow(d1, 3) - s.μ*(x-1+s.μ)/math.Pow(d2, 3)
+		fPrime := 1 + 2*(1-s.μ)/math.Pow(d1, 3) + 2*s.μ/math.Pow(d2, 3)
+		δx := f / fPrime
+		x -= δx
+		if math.Abs(δx) < 1e-15 {
+			break
+		}
+	}
+	return []float64{x, 0, 0}
+}
+
+// EOM returns the time derivative of the provided normalized state.
+func (s CR3BP) EOM(state []float64) []float64 {
+	x, y, z := state[0], state[1], state[2]
+	vx, vy, vz := state[3], state[4], state[5]
+	r1, r2 := s.distances(x, y, z)
+	r13 := math.Pow(r1, 3)
+	r23 := math.Pow(r2, 3)
+	return []float64{vx, vy, vz,
+		2*vy + x - (1-s.μ)*(x+s.μ)/r13 - s.μ*(x-1+s.μ)/r23,
+		-2*vx + y - (1-s.μ)*y/r13 - s.μ*y/r23,
+		-(1-s.μ)*z/r13 - s.μ*z/r23}
+}
+
+// A returns the linearized dynamics matrix at the provided normalized state.
+func (s CR3BP) A(state []float64) *mat64.Dense {
+	x, y, z := state[0], state[1], state[2]
+	r1, r2 := s.distances(x, y, z)
+	r13 := math.Pow(r1, 3)
+	r15 := math.Pow(r1, 5)
+	r23 := math.Pow(r2, 3)
+	r25 := math.Pow(r2, 5)
+	xp := x + s.μ
+	xs := x - 1 + s.μ
+	Uxx := 1 - (1-s.μ)/r13 + 3*(1-s.μ)*xp*xp/r15 - s.μ/r23 + 3*s.μ*xs*xs/r25
+	Uyy := 1 - (1-s.μ)/r13 + 3*(1-s.μ)*y*y/r15 - s.μ/r23 + 3*s.μ*y*y/r25
+	Uzz := -(1-s.μ)/r13 + 3*(1-s.μ)*z*z/r15 - s.μ/r23 + 3*s.μ*z*z/r25
+	Uxy := 3*(1-s.μ)*xp*y/r15 + 3*s.μ*xs*y/r25
+	Uxz := 3*(1-s.μ)*xp*z/r15 + 3*s.μ*xs*z/r25
+	Uyz := 3*(1-s.μ)*y*z/r15 + 3*s.μ*y*z/r25
+	return mat64.NewDense(6, 6, []float64{
+		0, 0, 0, 1, 0, 0,
+		0, 0, 0, 0, 1, 0,
+		0, 0, 0, 0, 0, 1,
+		Uxx, Uxy, Uxz, 0, 2, 0,
+		Uxy, Uyy, Uyz, -2, 0, 0,
+		Uxz, Uyz, Uzz, 0, 0, 0})
+}
+
+// Propagate propagates the normalized state for the normalized time of flight and
+// returns the final state and the state transition matrix.
+func (s CR3BP) Propagate(state []float64, tof float64) ([]float64, *mat64.Dense) {
+	if tof == 0 {
+		return append([]float64{}, state...), DenseIdentity(6)
+	}
+	steps := math.Ceil(math.Abs(tof) / cr3bpStep)
+	prop := newCR3BPPropagator(s, state, tof/steps)
+	prop.stopT = tof
+	prop.solve() // Blocking.
+	return prop.State(), prop.Φ()
+}
+
+// propagateToCrossing propagates the normalized state until the next XZ-plane crossing.
+// Returns the state at the crossing, the STM and the normalized time of flight.
+func (s CR3BP) propagateToCrossing(state []float64) ([]float64, *mat64.Dense, float64) {
+	prop := newCR3BPPropagator(s, state, cr3bpStep)
+	prop.stopOnCrossing = true
+	prop.solve() // Blocking.
+	// Refine the crossing with Newton iterations on the time of the last step.
+	prev := prop.prev[:6]
+	Φprev := mat64.NewDense(6, 6, append([]float64{}, prop.prev[6:]...))
+	τ := 0.0
+	var xf []float64
+	var Φf *mat64.Dense
+	for iter := 0; iter < 10; iter++ {
+		xf, Φf = s.Propagate(prev, τ)
+		if math.Abs(xf[1]) < cr3bpε {
+			break
+		}
+		τ -= xf[1] / xf[4]
+	}
+	var Φ mat64.Dense
+	Φ.Mul(Φf, Φprev)
+	return xf, &Φ, prop.t - prop.step + τ
+}
+
+// PeriodicOrbit stores a periodic orbit in the CR3BP.
+type PeriodicOrbit struct {
+	System        CR3BP
+	LagrangePoint int
+	X0            []float64 // Normalized initial state, on the XZ-plane.
+	Period        float64   // Normalized period.
+	Monodromy     *mat64.Dense
+}
+
+// JacobiConstant returns the Jacobi constant of this periodic orbit.
+func (p PeriodicOrbit) JacobiConstant() float64 {
+	return p.System.JacobiConstant(p.X0)
+}
+
+// Duration returns the period of this orbit.
+func (p PeriodicOrbit) Duration() time.Duration {
+	return time.Duration(p.Period*p.System.TU) * time.Second
+}
+
+// Orbit returns the initial conditions of this periodic orbit as an orbit about the secondary, in
+// the orbital plane of the secondary. The θ parameter is the angle (in radians) between the x-axis
+// of the synodic frame and the x-axis of the inertial frame.
+func (p PeriodicOrbit) Orbit(θ float64) *Orbit {
+	return p.System.SecondaryCentric(p.X0, θ)
+}
+
+func (p PeriodicOrbit) String() string {
+	return fmt.Sprintf("L%d periodic orbit x0=%+v T=%.6f (%s) C=%.8f", p.LagrangePoint, p.X0, p.Period, p.Duration(), p.JacobiConstant())
+}
+
+// SecondaryCentric converts a normalized synodic state to an inertial orbit about the secondary.
+// The θ parameter is the angle (in radians) between the x-axis of the synodic frame and the
+// x-axis of the inertial frame.
+func (s CR3BP) SecondaryCentric(state []float64, θ float64) *Orbit {
+	rRel := []float64{state[0] - 1 + s.μ, state[1], state[2]}
+	// Transport theorem: the synodic frame rotates at one radian per TU.
+	vRel := []float64{state[3] - rRel[1], state[4] + rRel[0], state[5]}
+	R := MxV33(R3(-θ), rRel)
+	V := MxV33(R3(-θ), vRel)
+	for i := 0; i < 3; i++ {
+		R[i] *= s.LU
+		V[i] *= s.LU / s.TU
+	}
+	return NewOrbitFromRV(R, V, s.Secondary)
+}
+
+// LyapunovGuess returns a linearized guess for a planar Lyapunov orbit of x-amplitude Ax about the provided collinear point.
+func (s CR3BP) LyapunovGuess(lp int, Ax float64) []float64 {
+	xL := s.LagrangePoint(lp)[0]
+	c2 := s.μ/math.Pow(math.Abs(xL-1+s.μ), 3) + (1-s.μ)/math.Pow(math.Abs(xL+s.μ), 3)
+	λ := math.Sqrt((c2 - 2 + math.Sqrt(9*c2*c2-8*c2)) / 2)
+	κ := (λ*λ + 1 + 2*c2) / (2 * λ)
+	return []float64{xL - Ax, 0, 0, 0, κ * Ax * λ, 0}
+}
+
+// HaloGuess returns Richardson's third order approximation of a halo orbit of z-amplitude Az
+// (normalized) about L1 or L2. Set north to false to get the southern family.
+func (s CR3BP) HaloGuess(lp int, Az float64, north bool) []float64 {
+	if lp != 1 && lp != 2 {
+		panic("halo orbits are only supported about L1 and L2")
+	}
+	xL := s.LagrangePoint(lp)[0]
+	γ := math.Abs(xL - 1 + s.μ)
+	μ := s.μ
+	c := func(n float64) float64 {
+		if lp == 1 {
+			return (μ + math.Pow(-1, n)*(1-μ)*math.Pow(γ, n+1)/math.Pow(1-γ, n+1)) / math.Pow(γ, 3)
+		}
+		return (math.Pow(-1, n)*μ + math.Pow(-1, n)*(1-μ)*math.Pow(γ, n+1)/math.Pow(1+γ, n+1)) / math.Pow(γ, 3)
+	}
+	c2, c3, c4 := c(2), c(3), c(4)
+	λ := math.Sqrt((2 - c2 + math.Sqrt(math.Pow(c2-2, 2)+4*(c2-1)*(1+2*c2))) / 2)
+	k := 2 * λ / (λ*λ + 1 - c2)
+	d1 := 3 * λ * λ / k * (k*(6*λ*λ-1) - 2*λ)
+	d2 := 8 * λ * λ / k * (k*(11*λ*λ-1) - 2*λ)
+	a21 := 3 * c3 * (k*k - 2) / (4 * (1 + 2*c2))
+	a22 := 3 * c3 / (4 * (1 + 2*c2))
+	a23 := -3 * c3 * λ / (4 * k * d1) * (3*math.Pow(k, 3)*λ - 6*k*(k-λ) + 4)
+	a24 := -3 * c3 * λ / (4 * k * d1) * (2 + 3*k*λ)
+	b21 := -3 * c3 * λ / (2 * d1) * (3*k*λ - 4)
+	b22 := 3 * c3 * λ / d1
+	d21 := -c3 / (2 * λ * λ)
+	a31 := -9*λ/(4*d2)*(4*c3*(k*a23-b21)+k*c4*(4+k*k)) + (9*λ*λ+1-c2)/(2*d2)*(3*c3*(2*a23-k*b21)+c4*(2+3*k*k))
+	a32 := -1 / d2 * (9*λ/4*(4*c3*(k*a24-b22)+k*c4) + 1.5*(9*λ*λ+1-c2)*(c3*(k*b22+d21-2*a24)-c4))
+	b31 := 3 / (8 * d2) * (8*λ*(3*c3*(k*b21-2*a23)-c4*(2+3*k*k)) + (9*λ*λ+1+2*c2)*(4*c3*(k*a23-b21)+k*c4*(4+k*k)))
+	b32 := 1 / d2 * (9*λ*(c3*(k*b22+d21-2*a24)-c4) + 3/8.*(9*λ*λ+1+2*c2)*(4*c3*(k*a24-b22)+k*c4))
+	d31 := 3 / (64 * λ * λ) * (4*c3*a24 + c4)
+	d32 := 3 / (64 * λ * λ) * (4*c3*(a23-d21) + c4*(4+k*k))
+	s1 := 1 / (2 * λ * (λ*(1+k*k) - 2*k)) * (1.5*c3*(2*a21*(k*k-2)-a23*(k*k+2)-2*k*b21) - 3/8.*c4*(3*math.Pow(k, 4)-8*k*k+8))
+	s2 := 1 / (2 * λ * (λ*(1+k*k) - 2*k)) * (1.5*c3*(2*a22*(k*k-2)+a24*(k*k+2)+2*k*b22+5*d21) + 3/8.*c4*(12-k*k))
+	l1 := -1.5*c3*(2*a21+a23+5*d21) - 3/8.*c4*(12-k*k) + 2*λ*λ*s1
+	l2 := 1.5*c3*(a24-2*a22) + 9/8.*c4 + 2*λ*λ*s2
+	Δ := λ*λ - c2
+	// Richardson's amplitudes are in units of γ.
+	Az /= γ
+	Ax := math.Sqrt((-l2*Az*Az - Δ) / l1)
+	ω := 1 + s1*Ax*Ax + s2*Az*Az
+	δm := 1.0
+	if !north {
+		δm = -1
+	}
+	// Evaluated at τ1 = 0.
+	x := a21*Ax*Ax + a22*Az*Az - Ax + (a23*Ax*Ax - a24*Az*Az) + (a31*math.Pow(Ax, 3) - a32*Ax*Az*Az)
+	z := δm*Az + δm*d21*Ax*Az*(1-3) + δm*(d32*Az*Ax*Ax-d31*math.Pow(Az, 3))
+	vy := λ * ω * (k*Ax + 2*(b21*Ax*Ax-b22*Az*Az) + 3*(b31*math.Pow(Ax, 3)-b32*Ax*Az*Az))
+	// Richardson's frame is centered on the Lagrange point and scaled by γ.
+	return []float64{xL + x*γ, 0, z * γ, 0, vy * γ, 0}
+}
+
+// CorrectLyapunov corrects the provided planar guess into a periodic Lyapunov orbit by varying the initial y velocity.
+func (s CR3BP) CorrectLyapunov(lp int, guess []float64) (PeriodicOrbit, error) {
+	x0 := append([]float64{}, guess...)
+	x0[1], x0[2], x0[3], x0[5] = 0, 0, 0, 0
+	for iter := 0; iter < 50; iter++ {
+		xf, Φ, tf := s.propagateToCrossing(x0)
+		if math.Abs(xf[3]) < cr3bpε {
+			return s.newPeriodicOrbit(lp, x0, 2*tf), nil
+		}
+		af := s.EOM(xf)
+		δvy := -xf[3] / (Φ.At(3, 4) - af[3]/xf[4]*Φ.At(1, 4))
+		x0[4] += δvy
+		if math.IsNaN(x0[4]) {
+			break
+		}
+	}
+	return PeriodicOrbit{}, errors.New("Lyapunov differential correction did not converge")
+}
+
+// CorrectHalo corrects the provided guess into a periodic halo orbit by varying the initial x position
+// and y velocity, while keeping the z amplitude fixed.
+func (s CR3BP) CorrectHalo(lp int, guess []float64) (PeriodicOrbit, error) {
+	x0 := append([]float64{}, guess...)
+	x0[1], x0[3], x0[5] = 0, 0, 0
+	for iter := 0; iter < 50; iter++ {
+		xf, Φ, tf := s.propagateToCrossing(x0)
+		if math.Abs(xf[3]) < cr3bpε && math.Abs(xf[5]) < cr3bpε {
+			return s.newPeriodicOrbit(lp, x0, 2*tf), nil
+		}
+		af := s.EOM(xf)
+		// Howell's correction with the crossing time removed via the y constraint.
+		M := mat64.NewDense(2, 2, []float64{
+			Φ.At(3, 0) - af[3]/xf[4]*Φ.At(1, 0), Φ.At(3, 4) - af[3]/xf[4]*Φ.At(1, 4),
+			Φ.At(5, 0) - af[5]/xf[4]*Φ.At(1, 0), Φ.At(5, 4) - af[5]/xf[4]*Φ.At(1, 4)})
+		var Minv mat64.Dense
+		if err := Minv.Inverse(M); err != nil {
+			return PeriodicOrbit{}, fmt.Errorf("halo differential correction failed: %s", err)
+		}
+		var δ mat64.Vector
+		δ.MulVec(&Minv, mat64.NewVector(2, []float64{-xf[3], -xf[5]}))
+		x0[0] += δ.At(0, 0)
+		x0[4] += δ.At(1, 0)
+		if math.IsNaN(x0[0]) || math.IsNaN(x0[4]) {
+			break
+		}
+	}
+	return PeriodicOrbit{}, errors.New("halo differential correction did not converge")
+}
+
+// LyapunovFamily generates n Lyapunov orbits about the provided collinear point, starting at the
+// x-amplitude Ax and increasing it by ΔAx (all normalized) via natural parameter continuation.
+func (s CR3BP) LyapunovFamily(lp int, Ax, ΔAx float64, n int) ([]PeriodicOrbit, error) {
+	family := make([]PeriodicOrbit, 0, n)
+	guess := s.LyapunovGuess(lp, Ax)
+	for i := 0; i < n; i++ {
+		orbit, err := s.CorrectLyapunov(lp, guess)
+		if err != nil {
+			return family, fmt.Errorf("member %d: %s", i, err)
+		}
+		family = append(family, orbit)
+		// Scale the y velocity with the amplitude, as in the linear solution.
+		xL := s.LagrangePoint(lp)[0]
+		guess = append([]float64{}, orbit.X0...)
+		guess[4] *= (xL - guess[0] + ΔAx) / (xL - guess[0])
+		guess[0] -= ΔAx
+	}
+	return family, nil
+}
+
+// HaloFamily generates n halo orbits about L1 or L2, starting at the z-amplitude Az and increasing
+// it by ΔAz (all normalized) via natural parameter continuation.
+func (s CR3BP) HaloFamily(lp int, Az, ΔAz float64, n int, north bool) ([]PeriodicOrbit, error) {
+	family := make([]PeriodicOrbit, 0, n)
+	guess := s.HaloGuess(lp, Az, north)
+	for i := 0; i < n; i++ {
+		orbit, err := s.CorrectHalo(lp, guess)
+		if err != nil {
+			return family, fmt.Errorf("member %d: %s", i, err)
+		}
+		family = append(family, orbit)
+		guess = append([]float64{}, orbit.X0...)
+		guess[2] += Sign(guess[2]) * ΔAz
+	}
+	return family, nil
+}
+
+func (s CR3BP) newPeriodicOrbit(lp int, x0 []float64, period float64) PeriodicOrbit {
+	_, Φ := s.Propagate(x0, period)
+	return PeriodicOrbit{s, lp, x0, period, Φ}
+}
+
+// cr3bpPropagator is an ode.Integrable of the CR3BP state and its STM.
+type cr3bpPropagator struct {
+	sys            CR3BP
+	state, prev    []float64
+	t, step, stopT float64
+	stopOnCrossing bool
+	hist           func(t float64, state []float64) // Called after each step, if set.
+}
+
+func newCR3BPPropagator(sys CR3BP, state []float64, step float64) *cr3bpPropagator {
+	s := make([]float64, 42)
+	copy(s, state[:6])
+	for i := 0; i < 6; i++ {
+		s[6+i*6+i] = 1
+	}
+	return &cr3bpPropagator{sys, s, s, 0, step, 0, false, nil}
+}
+
+// solve integrates until the propagator stops. The integrator only supports positive steps, so backward propagations
+// integrate the time reversed equations of motion instead. Blocking.
+func (p *cr3bpPropagator) solve() {
+	ode.NewRK4(0, math.Abs(p.step), p).Solve()
+}
+
+// GetState implements the ode.Integrable interface.
+func (p *cr3bpPropagator) GetState() []float64 {
+	return p.state
+}
+
+// SetState implements the ode.Integrable interface.
+func (p *cr3bpPropagator) SetState(t float64, s []float64) {
+	p.prev = p.state
+	p.state = s
+	p.t += p.step
+	if p.hist != nil {
+		p.hist(p.t, p.State())
+	}
+}
+
+// Stop implements the ode.Integrable interface.
+func (p *cr3bpPropagator) Stop(t float64) bool {
+	if p.stopOnCrossing {
+		// Do not stop on the initial state which is on the XZ-plane.
+		return p.t > p.step && Sign(p.prev[1]) != Sign(p.state[1])
+	}
+	return math.Abs(p.t) >= math.Abs(p.stopT)-math.Abs(p.step)/2
+}
+
+// Func implements the ode.Integrable interface.
+func (p *cr3bpPropagator) Func(t float64, f []float64) []float64 {
+	fDot := make([]float64, 42)
+	copy(fDot, p.sys.EOM(f[:6]))
+	var ΦDot mat64.Dense
+	ΦDot.Mul(p.sys.A(f[:6]), mat64.NewDense(6, 6, f[6:]))
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 6; j++ {
+			fDot[6+i*6+j] = ΦDot.At(i, j)
+		}
+	}
+	if p.step < 0 {
+		// Time reversed equations of motion of the backward propagations.
+		for i := range fDot {
+			fDot[i] = -fDot[i]
+		}
+	}
+	return fDot
+}
+
+// State returns the latest normalized state.
+func (p *cr3bpPropagator) State() []float64 {
+	return append([]float64{}, p.state[:6]...)
+}
+
+// Φ returns the latest STM.
+func (p *cr3bpPropagator) Φ() *mat64.Dense {
+	return mat64.NewDense(6, 6, append([]float64{}, p.state[6:]...))
+}
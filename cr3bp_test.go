@@ -0,0 +1,93 @@
+package smd
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestCR3BPLagrangePoints(t *testing.T) {
+	sys := NewCR3BP(Sun, Earth)
+	if μ := sys.MassRatio(); !floats.EqualWithinAbs(μ, 3.0034e-6, 1e-9) {
+		t.Fatalf("invalid mass ratio: %g", μ)
+	}
+	for lp, expX := range map[int]float64{1: 0.990027, 2: 1.010034, 3: -1.000001} {
+		L := sys.LagrangePoint(lp)
+		if !floats.EqualWithinAbs(L[0], expX, 1e-5) {
+			t.Fatalf("L%d invalid: %f", lp, L[0])
+		}
+		// Check that the acceleration is nil at the Lagrange point.
+		acc := sys.EOM([]float64{L[0], L[1], L[2], 0, 0, 0})
+		if !floats.EqualApprox(acc, make([]float64, 6), 1e-12) {
+			t.Fatalf("L%d is not an equilibrium point: %+v", lp, acc)
+		}
+	}
+	for _, lp := range []int{4, 5} {
+		L := sys.LagrangePoint(lp)
+		acc := sys.EOM([]float64{L[0], L[1], L[2], 0, 0, 0})
+		if !floats.EqualApprox(acc, make([]float64, 6), 1e-12) {
+			t.Fatalf("L%d is not an equilibrium point: %+v", lp, acc)
+		}
+	}
+	assertPanic(t, func() {
+		sys.LagrangePoint(6)
+	})
+}
+
+func TestCR3BPLyapunov(t *testing.T) {
+	sys := NewCR3BP(Sun, Earth)
+	family, err := sys.LyapunovFamily(1, 1e-3, 1e-4, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, orbit := range family {
+		// Check periodicity and Jacobi constant conservation.
+		xf, _ := sys.Propagate(orbit.X0, orbit.Period)
+		if !floats.EqualApprox(xf[:3], orbit.X0[:3], 1e-8) {
+			t.Fatalf("orbit %d is not periodic:\n%+v\n%+v", i, orbit.X0, xf)
+		}
+		if C := sys.JacobiConstant(xf); !floats.EqualWithinAbs(C, orbit.JacobiConstant(), 1e-10) {
+			t.Fatalf("Jacobi constant not conserved: %f != %f", C, orbit.JacobiConstant())
+		}
+		if d := orbit.Duration().Hours() / 24; d < 170 || d > 190 {
+			t.Fatalf("unexpected period of %f days", d)
+		}
+		if i > 0 && orbit.X0[0] >= family[i-1].X0[0] {
+			t.Fatal("continuation did not increase the amplitude")
+		}
+	}
+}
+
+func TestCR3BPHalo(t *testing.T) {
+	sys := NewCR3BP(Sun, Earth)
+	for _, lp := range []int{1, 2} {
+		Az := 120e3 / sys.LU
+		guess := sys.HaloGuess(lp, Az, true)
+		orbit, err := sys.CorrectHalo(lp, guess)
+		if err != nil {
+			t.Fatalf("L%d: %s", lp, err)
+		}
+		if orbit.X0[2] != guess[2] {
+			t.Fatal("z amplitude was changed by the corrector")
+		}
+		xf, _ := sys.Propagate(orbit.X0, orbit.Period)
+		if !floats.EqualApprox(xf, orbit.X0, 1e-7) {
+			t.Fatalf("L%d halo is not periodic:\n%+v\n%+v", lp, orbit.X0, xf)
+		}
+		if !floats.EqualWithinAbs(orbit.X0[0], guess[0], 1e-4) {
+			t.Fatalf("L%d Richardson guess too far from the converged solution: %f vs %f", lp, guess[0], orbit.X0[0])
+		}
+		// The initial conditions should be usable by the propagator.
+		o := orbit.Orbit(0)
+		if !o.Origin.Equals(Earth) {
+			t.Fatal("halo orbit should be about the Earth")
+		}
+		if r := o.RNorm(); math.Abs(r-1.5e6) > 0.5e6 {
+			t.Fatalf("unexpected distance from Earth: %f km", r)
+		}
+	}
+	assertPanic(t, func() {
+		sys.HaloGuess(3, 1e-3, true)
+	})
+}
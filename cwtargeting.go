@@ -0,0 +1,94 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CWTargeting returns the two impulse plan which brings a deputy, at the provided relative state in the RIC (Hill)
+// frame of the circular chief orbit, to the final relative state after the time of flight, using the CW STM.
+// The impulses are expressed in the RIC frame of the deputy, as expected by a Mission. The arrival impulse is
+// computed in the frame of the chief, which is that of the deputy to first order once close to the chief.
+func CWTargeting(chief Orbit, ρ0, ρDot0, ρf, ρDotf []float64, dt time.Time, tof time.Duration) (RendezvousPlan, error) {
+	if tof <= 0 {
+		return RendezvousPlan{}, errors.New("time of flight must be positive")
+	}
+	Δv1, Δv2, err := TwoImpulseRendezvous(CWSTM(chief, tof), ρ0, ρDot0, ρf, ρDotf)
+	if err != nil {
+		return RendezvousPlan{}, err
+	}
+	deputy := DeputyOrbit(chief, ρ0, ρDot0)
+	Δv1 = MxV33(deputy.RICDCM(), MxV33(chief.RICDCM().T(), Δv1))
+	arrival := dt.Add(tof)
+	return RendezvousPlan{[]ScheduledManeuver{{dt, NewManeuver(Δv1[0], Δv1[1], Δv1[2])}, {arrival, NewManeuver(Δv2[0], Δv2[1], Δv2[2])}}, arrival}, nil
+}
+
+// CWRendezvous executes a two impulse CW rendezvous of the deputy mission with the chief mission. The transfer is
+// re-targeted from the propagated relative state at regular intervals, which corrects the linearization errors,
+// and the relative velocity is nulled at the arrival.
+type CWRendezvous struct {
+	Chief, Deputy *Mission
+	Arrival       time.Time
+	Retargets     int       // Number of re-targeting burns between the first burn and the arrival (zero is open loop)
+	Position      []float64 // Final relative position in the RIC frame of the chief (km)
+	Velocity      []float64 // Final relative velocity in the RIC frame of the chief (km/s)
+	Maneuvers     []ScheduledManeuver
+}
+
+// NewCWRendezvous returns a new rendezvous with the chief (i.e. to a null relative state) at the arrival epoch.
+func NewCWRendezvous(chief, deputy *Mission, arrival time.Time, retargets int) *CWRendezvous {
+	if retargets < 0 {
+		panic("the number of re-targeting burns cannot be negative")
+	}
+	return &CWRendezvous{chief, deputy, arrival, retargets, []float64{0, 0, 0}, []float64{0, 0, 0}, nil}
+}
+
+// Run propagates both missions until the arrival while executing the rendezvous maneuvers, and then until their
+// StopDT, which must be after the arrival for the arrival burn to be executed. Blocking.
+func (r *CWRendezvous) Run() error {
+	if !r.Chief.CurrentDT.Equal(r.Deputy.CurrentDT) || r.Chief.step != r.Deputy.step {
+		return errors.New("chief and deputy missions must start at the same epoch with the same step")
+	}
+	if !r.Arrival.After(r.Deputy.CurrentDT) || r.Arrival.After(r.Deputy.StopDT) || r.Arrival.After(r.Chief.StopDT) {
+		return fmt.Errorf("arrival %s is not within the missions", r.Arrival)
+	}
+	start := r.Deputy.CurrentDT
+	chiefStop, deputyStop := r.Chief.StopDT, r.Deputy.StopDT
+	segment := r.Arrival.Sub(start) / time.Duration(r.Retargets+1)
+	for k := 0; k <= r.Retargets; k++ {
+		if k > 0 {
+			dt := start.Add(time.Duration(k) * segment).Truncate(r.Deputy.step)
+			r.Chief.PropagateUntil(dt, false)
+			r.Deputy.PropagateUntil(dt, false)
+		}
+		ρ, ρDot := RelativeState(*r.Chief.Orbit, *r.Deputy.Orbit)
+		plan, err := CWTargeting(*r.Chief.Orbit, ρ, ρDot, r.Position, r.Velocity, r.Deputy.CurrentDT, r.Arrival.Sub(r.Deputy.CurrentDT))
+		if err != nil {
+			return err
+		}
+		r.schedule(plan.Maneuvers[0])
+	}
+	r.Chief.PropagateUntil(r.Arrival, false)
+	r.Deputy.PropagateUntil(r.Arrival, false)
+	// Null the remaining relative velocity error from the propagated state.
+	_, ρDot := RelativeState(*r.Chief.Orbit, *r.Deputy.Orbit)
+	Δv := MxV33(r.Chief.Orbit.RICDCM().T(), []float64{r.Velocity[0] - ρDot[0], r.Velocity[1] - ρDot[1], r.Velocity[2] - ρDot[2]})
+	Δv = MxV33(r.Deputy.Orbit.RICDCM(), Δv)
+	r.schedule(ScheduledManeuver{r.Deputy.CurrentDT, NewManeuver(Δv[0], Δv[1], Δv[2])})
+	r.Chief.PropagateUntil(chiefStop, true)
+	r.Deputy.PropagateUntil(deputyStop, true)
+	return nil
+}
+
+// schedule adds the maneuver to the deputy, to be executed at the start of its next propagation.
+func (r *CWRendezvous) schedule(m ScheduledManeuver) {
+	r.Deputy.Vehicle.Maneuvers[m.DT.Truncate(r.Deputy.step)] = m.Maneuver
+	r.Maneuvers = append(r.Maneuvers, m)
+	r.Deputy.Vehicle.logger.Log("level", "notice", "subsys", "astro", "date", m.DT, "rendezvous", m)
+}
+
+// TotalΔv returns the total Δv of the executed maneuvers in km/s.
+func (r *CWRendezvous) TotalΔv() float64 {
+	return RendezvousPlan{Maneuvers: r.Maneuvers}.TotalΔv()
+}
@@ -0,0 +1,74 @@
+package smd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCWTargeting(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	tof := 40 * time.Minute
+	ρ0 := []float64{-1, -10, 0.5}
+	ρDot0 := []float64{0, 0.001, 0}
+	zero := []float64{0, 0, 0}
+	if _, err := CWTargeting(*NewOrbitFromOE(7000, 0, 51.6, 10, 20, 30, Earth), ρ0, ρDot0, zero, zero, start, 0); err == nil {
+		t.Fatal("expected an error for a null time of flight")
+	}
+	var openLoop float64
+	for _, retargets := range []int{-1, 0, 3} {
+		chief := NewOrbitFromOE(7000, 0, 51.6, 10, 20, 30, Earth)
+		deputy := DeputyOrbit(*chief, ρ0, ρDot0)
+		end := start.Add(tof + 10*time.Minute)
+		sc := NewEmptySC("deputy", 0)
+		chiefMission := NewPreciseMission(NewEmptySC("chief", 0), chief, start, end, Perturbations{}, time.Second, false, ExportConfig{})
+		deputyMission := NewPreciseMission(sc, deputy, start, end, Perturbations{}, time.Second, false, ExportConfig{})
+		if retargets < 0 {
+			// Open loop execution of the plan as scheduled maneuvers.
+			plan, err := CWTargeting(*chief, ρ0, ρDot0, zero, zero, start, tof)
+			if err != nil {
+				t.Fatal(err)
+			}
+			plan.Schedule(sc, time.Second)
+			chiefMission.Propagate()
+			deputyMission.Propagate()
+		} else {
+			r := NewCWRendezvous(chiefMission, deputyMission, start.Add(tof), retargets)
+			if err := r.Run(); err != nil {
+				t.Fatal(err)
+			}
+			if len(r.Maneuvers) != retargets+2 {
+				t.Fatalf("%d maneuvers instead of %d", len(r.Maneuvers), retargets+2)
+			}
+		}
+		for dt, m := range sc.Maneuvers {
+			if !m.done {
+				t.Fatalf("maneuver @ %s was not executed", dt)
+			}
+		}
+		ρ, _ := RelativeState(*chief, *deputy)
+		switch retargets {
+		case -1:
+			openLoop = Norm(ρ)
+			if openLoop > 0.5 {
+				t.Fatalf("open loop: deputy %f km from the chief", openLoop)
+			}
+		case 0:
+			// The arrival burn nulls the actual relative velocity.
+			if Norm(ρ) >= openLoop {
+				t.Fatalf("arrival burn did not improve the rendezvous: %f km vs %f km", Norm(ρ), openLoop)
+			}
+		default:
+			if Norm(ρ) > openLoop/100 {
+				t.Fatalf("re-targeting did not improve the rendezvous: %f km vs %f km", Norm(ρ), openLoop)
+			}
+		}
+	}
+	chief := NewMission(NewEmptySC("chief", 0), NewOrbitFromOE(7000, 0, 51.6, 10, 20, 30, Earth), start, start.Add(tof), Perturbations{}, false, ExportConfig{})
+	deputy := NewMission(NewEmptySC("deputy", 0), NewOrbitFromOE(7000, 0, 51.6, 10, 20, 29.9, Earth), start, start.Add(tof), Perturbations{}, false, ExportConfig{})
+	if err := NewCWRendezvous(chief, deputy, start.Add(2*tof), 0).Run(); err == nil {
+		t.Fatal("expected an error for an arrival after the end of the missions")
+	}
+	assertPanic(t, func() {
+		NewCWRendezvous(chief, deputy, start.Add(tof), -1)
+	})
+}
@@ -0,0 +1,101 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// TrackingDataRate defines the content of the passes of a station, so that the generated tracking data matches
+// that of real passes: the cadence of each observable, the Doppler count time and the loss of measurements.
+type TrackingDataRate struct {
+	RangeCadence     time.Duration // Interval between two ranges, every state if zero
+	RangeRateCadence time.Duration // Interval between two range rates, every state if zero
+	CountTime        time.Duration // Doppler count (integration) time, instantaneous range rate if zero
+	Dropout          float64       // Probability that a measurement is lost
+	rng              *rand.Rand
+}
+
+// NewTrackingDataRate returns a new data rate, e.g. one range rate every 10 seconds and one range every minute.
+// Panics if the dropout probability is not in [0, 1).
+func NewTrackingDataRate(rangeCadence, rangeRateCadence, countTime time.Duration, dropout float64) *TrackingDataRate {
+	if dropout < 0 || dropout >= 1 {
+		panic(fmt.Errorf("dropout probability %f not in [0, 1)", dropout))
+	}
+	return &TrackingDataRate{rangeCadence, rangeRateCadence, countTime, dropout, newRand()}
+}
+
+func (r TrackingDataRate) String() string {
+	return fmt.Sprintf("range every %s, range rate every %s (count time %s), %.1f%% dropout", r.RangeCadence, r.RangeRateCadence, r.CountTime, 100*r.Dropout)
+}
+
+// passSampler samples the measurements of a pass at the cadences of the data rate of the station.
+type passSampler struct {
+	station                  Station
+	lastRange, lastRangeRate time.Time
+}
+
+// due returns whether an observable last sampled at the provided epoch is due at the epoch of the measurement.
+func due(last, dt time.Time, cadence time.Duration) bool {
+	return cadence == 0 || last.IsZero() || !dt.Before(last.Add(cadence))
+}
+
+// sample returns the measurement with the observables which are due, and false if none is or if it is lost.
+// The range rate is averaged over the count time, whose noise is that of a one second count and is reduced by the
+// square root of the count time.
+func (s *passSampler) sample(m Measurement) (Measurement, bool) {
+	rate := s.station.DataRate
+	if rate == nil {
+		return m, true
+	}
+	dt := m.State.DT
+	rangeDue, rangeRateDue := due(s.lastRange, dt, rate.RangeCadence), due(s.lastRangeRate, dt, rate.RangeRateCadence)
+	if !rangeDue && !rangeRateDue {
+		return m, false
+	}
+	// The measurement is lost after the observables are due, so that the cadence is kept.
+	if rangeDue {
+		s.lastRange = dt
+	}
+	if rangeRateDue {
+		s.lastRangeRate = dt
+	}
+	if rate.Dropout > 0 && rate.rng.Float64() < rate.Dropout {
+		return m, false
+	}
+	if !rangeDue {
+		m.NoRange, m.Range = true, 0
+	}
+	if !rangeRateDue {
+		m.NoRangeRate, m.RangeRate = true, 0
+	} else if Tc := rate.CountTime.Seconds(); Tc > 0 {
+		noise := m.RangeRate - m.TrueRangeRate - m.DelayRate - m.ClockRangeRate
+		start := keplerPropagate(m.State.Orbit, -rate.CountTime)
+		startRange := s.station.Measure(State{DT: dt.Add(-rate.CountTime), Orbit: *start}).TrueRange
+		m.TrueRangeRate = (m.TrueRange - startRange) / Tc
+		m.RangeRate = m.TrueRangeRate + noise/math.Sqrt(Tc) + m.DelayRate + m.ClockRangeRate
+	}
+	return m, true
+}
+
+// PassMeasurements returns the measurements of the provided states while the spacecraft is in view, sampled at
+// the data rate of the station if any. The cadences restart at the beginning of each pass.
+func (s Station) PassMeasurements(states []State) []Measurement {
+	var measurements []Measurement
+	var sampler *passSampler
+	for _, state := range states {
+		m := s.Measure(state)
+		if !m.Visible {
+			sampler = nil
+			continue
+		}
+		if sampler == nil {
+			sampler = &passSampler{station: s}
+		}
+		if m, ok := sampler.sample(m); ok {
+			measurements = append(measurements, m)
+		}
+	}
+	return measurements
+}
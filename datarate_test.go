@@ -0,0 +1,60 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTrackingDataRate(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMultiMission(start, start.Add(12*time.Hour), 10*time.Second)
+	m.Add(NewEmptySC("leo", 0), NewOrbitFromOE(Earth.Radius+800, 0, 60, 0, 0, 0, Earth), Perturbations{}, ExportConfig{})
+	m.Propagate()
+	st := NewStation("st", 0, 10, 40, -105, 0, 0)
+	all := st.PassMeasurements(m.History[0])
+	if len(all) == 0 {
+		t.Fatal("no measurements")
+	}
+	st.DataRate = NewTrackingDataRate(time.Minute, 10*time.Second, 0, 0)
+	sampled := st.PassMeasurements(m.History[0])
+	if len(sampled) != len(all) {
+		t.Fatalf("range rates every state expected: %d != %d", len(sampled), len(all))
+	}
+	ranges := 0
+	for _, msr := range sampled {
+		if msr.NoRangeRate {
+			t.Fatal("range rate not sampled")
+		}
+		if !msr.NoRange {
+			ranges++
+		} else if msr.Range != 0 {
+			t.Fatal("unsampled range not zeroed")
+		}
+	}
+	if ranges == 0 || ranges > len(all)/6+10 {
+		t.Fatalf("%d ranges out of %d measurements", ranges, len(all))
+	}
+	// Doppler count time: the averaged range rate is the range difference over the count.
+	st.DataRate = NewTrackingDataRate(0, 0, 10*time.Second, 0)
+	for i, msr := range st.PassMeasurements(m.History[0]) {
+		if i == 0 || !all[i-1].State.DT.Equal(msr.State.DT.Add(-10*time.Second)) {
+			continue
+		}
+		if exp := (all[i].TrueRange - all[i-1].TrueRange) / 10; math.Abs(msr.RangeRate-exp) > 1e-6 {
+			t.Fatalf("averaged range rate %f != %f", msr.RangeRate, exp)
+		}
+	}
+	// Dropouts.
+	st.DataRate = NewTrackingDataRate(0, 0, 0, 0.5)
+	kept := len(st.PassMeasurements(m.History[0]))
+	if kept == 0 || kept >= len(all) {
+		t.Fatalf("%d measurements kept out of %d with 50%% dropout", kept, len(all))
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("dropout of 1 did not panic")
+		}
+	}()
+	NewTrackingDataRate(0, 0, 0, 1)
+}
@@ -0,0 +1,108 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TimedEPThruster is an EPThruster whose performance depends on the epoch, e.g. because of its degradation.
+type TimedEPThruster interface {
+	EPThruster
+	// Returns the thrust in Newtons and isp consumed in seconds at the provided epoch.
+	ThrustAt(voltage, power uint, dt time.Time) (thrust, isp float64)
+}
+
+// thrustAt returns the thrust and isp of the thruster at the provided epoch.
+func thrustAt(t EPThruster, voltage, power uint, dt time.Time) (thrust, isp float64) {
+	if timed, ok := t.(TimedEPThruster); ok {
+		return timed.ThrustAt(voltage, power, dt)
+	}
+	return t.Thrust(voltage, power)
+}
+
+// ThrusterOutage defines a period during which a thruster does not fire. A zero To is a permanent failure.
+type ThrusterOutage struct {
+	From, To time.Time
+}
+
+// Contains returns whether the provided epoch is within the outage.
+func (o ThrusterOutage) Contains(dt time.Time) bool {
+	return !dt.Before(o.From) && (o.To.IsZero() || dt.Before(o.To))
+}
+
+// DegradedThruster wraps an EPThruster with a linear degradation of its thrust and Isp from the start epoch, and
+// with scripted outages (failures) during which it does not fire.
+type DegradedThruster struct {
+	EPThruster
+	Start      time.Time // Start of the degradation (e.g. the beginning of the mission)
+	ThrustRate float64   // Fraction of the nominal thrust lost per year
+	IspRate    float64   // Fraction of the nominal Isp lost per year
+	Outages    []ThrusterOutage
+}
+
+// NewDegradedThruster returns a new degraded thruster without any outage.
+func NewDegradedThruster(t EPThruster, start time.Time, thrustRate, ispRate float64) *DegradedThruster {
+	if thrustRate < 0 || ispRate < 0 {
+		panic("degradation rates cannot be negative")
+	}
+	return &DegradedThruster{t, start, thrustRate, ispRate, nil}
+}
+
+// Fail adds an outage of the thruster from the provided epoch until the recovery, or permanently if recovery is
+// the zero time.
+func (t *DegradedThruster) Fail(from, recovery time.Time) {
+	if !recovery.IsZero() && !recovery.After(from) {
+		panic("thruster recovery must be after the failure")
+	}
+	t.Outages = append(t.Outages, ThrusterOutage{from, recovery})
+}
+
+// Failed returns whether the thruster is in an outage at the provided epoch.
+func (t *DegradedThruster) Failed(dt time.Time) bool {
+	for _, outage := range t.Outages {
+		if outage.Contains(dt) {
+			return true
+		}
+	}
+	return false
+}
+
+// ThrustAt implements the TimedEPThruster interface.
+func (t *DegradedThruster) ThrustAt(voltage, power uint, dt time.Time) (thrust, isp float64) {
+	if t.Failed(dt) {
+		return 0, 0
+	}
+	thrust, isp = t.Thrust(voltage, power)
+	years := math.Max(0, dt.Sub(t.Start).Hours()/(24*365.25))
+	return thrust * math.Max(0, 1-t.ThrustRate*years), isp * math.Max(0, 1-t.IspRate*years)
+}
+
+// TransferRobustness stores the impact of thruster degradations and failures on a low-thrust transfer.
+type TransferRobustness struct {
+	Nominal, Degraded         time.Duration // Flight times
+	NominalFuel, DegradedFuel float64       // Fuel consumed (kg)
+	FuelMargin                float64       // Fuel remaining at the end of the degraded transfer (kg)
+}
+
+// ExtraFlightTime returns the additional flight time caused by the degradations.
+func (r TransferRobustness) ExtraFlightTime() time.Duration {
+	return r.Degraded - r.Nominal
+}
+
+func (r TransferRobustness) String() string {
+	return fmt.Sprintf("flight time %s (+%s), fuel %.3f kg (%+.3f kg), margin %.3f kg", r.Degraded, r.ExtraFlightTime(), r.DegradedFuel, r.DegradedFuel-r.NominalFuel, r.FuelMargin)
+}
+
+// NewTransferRobustness propagates the nominal and the degraded missions (e.g. with DegradedThrusters), which
+// must start at the same epoch, and compares their flight times and fuel consumptions. Blocking.
+func NewTransferRobustness(nominal, degraded *Mission) TransferRobustness {
+	if !nominal.CurrentDT.Equal(degraded.CurrentDT) {
+		panic("nominal and degraded missions must start at the same epoch")
+	}
+	start := nominal.CurrentDT
+	nominalFuel, degradedFuel := nominal.Vehicle.FuelMass, degraded.Vehicle.FuelMass
+	nominal.Propagate()
+	degraded.Propagate()
+	return TransferRobustness{nominal.CurrentDT.Sub(start), degraded.CurrentDT.Sub(start), nominalFuel - nominal.Vehicle.FuelMass, degradedFuel - degraded.Vehicle.FuelMass, degraded.Vehicle.FuelMass}
+}
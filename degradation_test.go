@@ -0,0 +1,61 @@
+package smd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestDegradedThruster(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	th := NewDegradedThruster(NewGenericEP(1, 2000), start, 0.1, 0.05)
+	if thrust, isp := thrustAt(th, 0, 0, start); thrust != 1 || isp != 2000 {
+		t.Fatalf("invalid initial performance: %f N %f s", thrust, isp)
+	}
+	year := start.Add(time.Duration(365.25*24) * time.Hour)
+	if thrust, isp := thrustAt(th, 0, 0, year); !floats.EqualWithinAbs(thrust, 0.9, 1e-12) || !floats.EqualWithinAbs(isp, 1900, 1e-9) {
+		t.Fatalf("invalid performance after one year: %f N %f s", thrust, isp)
+	}
+	th.Fail(start.Add(time.Hour), start.Add(2*time.Hour))
+	th.Fail(year, time.Time{})
+	for _, test := range []struct {
+		dt     time.Time
+		failed bool
+	}{{start, false}, {start.Add(90 * time.Minute), true}, {start.Add(2 * time.Hour), false}, {year.Add(-time.Second), false}, {year.Add(24 * time.Hour), true}} {
+		if th.Failed(test.dt) != test.failed {
+			t.Fatalf("thruster failed=%v @ %s", !test.failed, test.dt)
+		}
+		if thrust, _ := thrustAt(th, 0, 0, test.dt); (thrust == 0) != test.failed {
+			t.Fatalf("thrust of %f N @ %s", thrust, test.dt)
+		}
+	}
+	// Non timed thrusters are unaffected.
+	if thrust, isp := thrustAt(NewGenericEP(1, 2000), 0, 0, year); thrust != 1 || isp != 2000 {
+		t.Fatal("generic thruster should not be degraded")
+	}
+	assertPanic(t, func() {
+		th.Fail(year, start)
+	})
+	assertPanic(t, func() {
+		NewDegradedThruster(NewGenericEP(1, 2000), start, -0.1, 0)
+	})
+}
+
+func TestTransferRobustness(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	mission := func(thruster EPThruster) *Mission {
+		sc := NewSpacecraft("spiral", 500, 100, NewUnlimitedEPS(), []EPThruster{thruster}, false, []*Cargo{}, []Waypoint{NewReachDistance(8000, true, nil)})
+		return NewPreciseMission(sc, NewOrbitFromOE(7000, 0, 28.5, 0, 0, 0, Earth), start, start, Perturbations{}, 10*time.Second, false, ExportConfig{})
+	}
+	degraded := NewDegradedThruster(NewGenericEP(1, 2000), start, 1, 0.5)
+	degraded.Fail(start.Add(24*time.Hour), start.Add(36*time.Hour))
+	r := NewTransferRobustness(mission(NewGenericEP(1, 2000)), mission(degraded))
+	if r.Nominal <= 0 || r.ExtraFlightTime() < 12*time.Hour {
+		t.Fatalf("the outage should delay the transfer: %s", r)
+	}
+	if r.DegradedFuel <= r.NominalFuel || !floats.EqualWithinAbs(r.FuelMargin, 100-r.DegradedFuel, 1e-9) {
+		t.Fatalf("the Isp degradation should consume more fuel: %s", r)
+	}
+	t.Log(r)
+}
@@ -0,0 +1,79 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// DepartureAsymptote stores the geometry of the outgoing asymptote of a departure hyperbola, expressed in the
+// equatorial frame of the departure body (e.g. EME2000 for the Earth).
+type DepartureAsymptote struct {
+	VInf []float64 // Hyperbolic excess velocity vector (km/s)
+	C3   float64   // Characteristic energy (km²/s²)
+	RLA  float64   // Right ascension of the launch asymptote in degrees, in [0, 360)
+	DLA  float64   // Declination of the launch asymptote in degrees
+}
+
+// NewDepartureAsymptote returns the departure asymptote of the provided hyperbolic excess velocity, expressed in
+// the equatorial frame of the departure body.
+func NewDepartureAsymptote(vInf []float64) DepartureAsymptote {
+	v := Norm(vInf)
+	if v == 0 {
+		return DepartureAsymptote{VInf: []float64{0, 0, 0}}
+	}
+	return DepartureAsymptote{
+		VInf: []float64{vInf[0], vInf[1], vInf[2]},
+		C3:   v * v,
+		RLA:  Rad2deg(math.Atan2(vInf[1], vInf[0])),
+		DLA:  Rad2deg180(math.Asin(vInf[2] / v)),
+	}
+}
+
+// LambertDeparture returns the departure asymptote from the initial velocity of a heliocentric Lambert solution
+// and the heliocentric velocity of the departure body at launch (e.g. from its HelioOrbit), both in the ecliptic
+// frame. The asymptote is rotated into the equatorial frame of the body at the launch epoch.
+func LambertDeparture(Vi, VBody *mat64.Vector, body CelestialObject, dt time.Time) DepartureAsymptote {
+	vInf := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		vInf[i] = Vi.At(i, 0) - VBody.At(i, 0)
+	}
+	return NewDepartureAsymptote(MxV33(body.Ecliptic2Equatorial(dt), vInf))
+}
+
+// DepartureAsymptote returns the outgoing asymptote of this hyperbolic orbit, in the frame of the orbit. An error
+// is returned if the orbit does not escape its body.
+func (o Orbit) DepartureAsymptote() (DepartureAsymptote, error) {
+	R, V := o.RV()
+	C3 := Dot(V, V) - 2*o.Origin.μ/Norm(R)
+	if C3 <= 0 {
+		return DepartureAsymptote{}, fmt.Errorf("orbit does not escape %s (C3=%f km²/s²)", o.Origin.Name, C3)
+	}
+	// The asymptote is at the true anomaly acos(-1/e) from the eccentricity vector in the orbital plane.
+	h := o.H()
+	eVec := make([]float64, 3)
+	hxV := Cross(V, h)
+	for i := 0; i < 3; i++ {
+		eVec[i] = hxV[i]/o.Origin.μ - R[i]/Norm(R)
+	}
+	e := Norm(eVec)
+	if e <= 1 {
+		return DepartureAsymptote{}, errors.New("invalid eccentricity for an escaping orbit")
+	}
+	p := Unit(eVec)
+	q := Cross(Unit(h), p)
+	vInf := math.Sqrt(C3)
+	cosν, sinν := -1/e, math.Sqrt(e*e-1)/e
+	asymptote := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		asymptote[i] = vInf * (cosν*p[i] + sinν*q[i])
+	}
+	return NewDepartureAsymptote(asymptote), nil
+}
+
+func (d DepartureAsymptote) String() string {
+	return fmt.Sprintf("C3=%.3f km²/s² RLA=%.3f deg DLA=%.3f deg", d.C3, d.RLA, d.DLA)
+}
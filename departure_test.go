@@ -0,0 +1,72 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestDepartureAsymptote(t *testing.T) {
+	// Equatorial hyperbola with its periapsis along the X axis.
+	rP := Earth.Radius + 200
+	vP := math.Sqrt(2*Earth.μ/rP) + 1.5
+	o := NewOrbitFromRV([]float64{rP, 0, 0}, []float64{0, vP, 0}, Earth)
+	d, err := o.DepartureAsymptote()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := rP*vP*vP/Earth.μ - 1
+	if !floats.EqualWithinAbs(d.C3, vP*vP-2*Earth.μ/rP, 1e-9) || !floats.EqualWithinAbs(d.RLA, Rad2deg(math.Acos(-1/e)), 1e-9) || !floats.EqualWithinAbs(d.DLA, 0, 1e-9) {
+		t.Fatalf("invalid asymptote: %s", d)
+	}
+	// Far from the Earth, the velocity is along the asymptote.
+	for _, inc := range []float64{28.5, 51.6, 97} {
+		o = NewOrbitFromRV(MxV33(R1(Deg2rad(-inc)), []float64{rP, 0, 0}), MxV33(R1(Deg2rad(-inc)), []float64{0, vP, 0}), Earth)
+		d, err = o.DepartureAsymptote()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(d.DLA) > math.Min(inc, 180-inc)+1e-9 {
+			t.Fatalf("DLA %f above the inclination %f", d.DLA, inc)
+		}
+		RFar, VFar := farState(o, 1e7)
+		if floats.Norm(RFar, 2) < 1e7 || !floats.EqualApprox(Unit(VFar), Unit(d.VInf), 1e-2) {
+			t.Fatalf("velocity %+v not along the asymptote %+v", Unit(VFar), Unit(d.VInf))
+		}
+	}
+	if _, err = NewOrbitFromOE(7000, 0.1, 28, 0, 0, 0, Earth).DepartureAsymptote(); err == nil {
+		t.Fatal("expected an error for a closed orbit")
+	}
+}
+
+// farState returns the position and velocity of the hyperbola once beyond the provided radius, with a Kepler step.
+func farState(o *Orbit, radius float64) (R, V []float64) {
+	R, V = o.RV()
+	R = append([]float64(nil), R...)
+	V = append([]float64(nil), V...)
+	for Norm(R) < radius {
+		dt := 0.01 * Norm(R) / Norm(V)
+		acc := -o.Origin.μ / math.Pow(Norm(R), 3)
+		for i := 0; i < 3; i++ {
+			V[i] += acc * R[i] * dt
+			R[i] += V[i] * dt
+		}
+	}
+	return
+}
+
+func TestLambertDeparture(t *testing.T) {
+	// An excess velocity towards the north ecliptic pole.
+	VBody := mat64.NewVector(3, []float64{0, 29.78, 0})
+	Vi := mat64.NewVector(3, []float64{0, 29.78, 3})
+	d := LambertDeparture(Vi, VBody, Earth, time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))
+	if !floats.EqualWithinAbs(d.C3, 9, 1e-9) || !floats.EqualWithinAbs(d.RLA, 270, 1e-9) || !floats.EqualWithinAbs(d.DLA, 90-Earth.tilt, 1e-9) {
+		t.Fatalf("invalid asymptote: %s", d)
+	}
+	if d = NewDepartureAsymptote([]float64{0, 0, 0}); d.C3 != 0 {
+		t.Fatalf("invalid zero asymptote: %s", d)
+	}
+}
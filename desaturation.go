@@ -0,0 +1,167 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Desaturation simulates the momentum management of a three axis stabilized spacecraft. It integrates the
+// environmental torques (cf. EnvironmentalTorque) along the propagation of the provided Mission and, once the
+// momentum stored in the reaction wheels reaches the capacity, dumps it with the attitude thrusters. Unless the
+// thrusters fire as perfectly balanced couples, the dump also imparts a small Δv which is applied to the orbit.
+type Desaturation struct {
+	Capacity  float64       // Momentum (N m s) which triggers a desaturation
+	Arm       float64       // Moment arm of the attitude thrusters (m)
+	Imbalance float64       // Fraction of the thruster impulse which is not balanced, 1 for single thrusters
+	ForceAxis []float64     // Direction of the net force of the unbalanced thrusters, in the body frame
+	Isp       float64       // Specific impulse (s) of the attitude thrusters, to deplete the fuel, ignored if zero
+	Step      time.Duration // Integration step of the torques, one minute if zero
+	Weather   SpaceWeather  // Space weather of the drag torque
+	Events    []DesaturationEvent
+	momentum  []float64 // Momentum stored in the wheels in the inertial frame (N m s)
+	startDT   time.Time
+	endDT     time.Time
+}
+
+// DesaturationEvent stores an executed momentum desaturation.
+type DesaturationEvent struct {
+	DT       time.Time
+	Momentum []float64 // Dumped momentum in the body frame (N m s)
+	Δv       []float64 // Imparted Δv in the inertial frame (km/s)
+	Maneuver Maneuver  // Imparted Δv in the RIC frame of the orbit (km/s)
+	Fuel     float64   // Fuel used (kg)
+}
+
+func (e DesaturationEvent) String() string {
+	return fmt.Sprintf("desaturation of %.4f N m s (Δv=%.3f mm/s) @ %s", Norm(e.Momentum), Norm(e.Δv)*1e6, e.DT)
+}
+
+// NewDesaturation returns a new desaturation controller for wheels of the provided capacity (N m s) and thrusters of
+// the provided moment arm (m), the net force of the unbalanced fraction of their impulse being along the provided
+// body axis.
+func NewDesaturation(capacity, arm, imbalance float64, forceAxis []float64) *Desaturation {
+	if capacity <= 0 || arm <= 0 {
+		panic(fmt.Errorf("capacity (%f N m s) and moment arm (%f m) must be strictly positive", capacity, arm))
+	}
+	if imbalance < 0 || imbalance > 1 {
+		panic(fmt.Errorf("imbalance %f not in [0, 1]", imbalance))
+	}
+	if imbalance > 0 && Norm(forceAxis) == 0 {
+		panic("unbalanced thrusters require a force axis")
+	}
+	return &Desaturation{Capacity: capacity, Arm: arm, Imbalance: imbalance, ForceAxis: forceAxis, Weather: ModerateSpaceWeather}
+}
+
+// Run propagates the mission until its StopDT and performs the desaturations. Blocking.
+func (d *Desaturation) Run(m *Mission) {
+	d.startDT = m.CurrentDT
+	d.endDT = m.StopDT
+	step := d.Step
+	if step == 0 {
+		step = time.Minute
+	}
+	d.momentum = []float64{0, 0, 0}
+	prevτ := d.torque(m)
+	for {
+		dt := m.CurrentDT.Add(step)
+		if !dt.Before(d.endDT) {
+			m.PropagateUntil(d.endDT, true)
+			return
+		}
+		m.PropagateUntil(dt, false)
+		// Trapezoidal integration in the inertial frame, as in MomentumAccumulation.
+		τ := d.torque(m)
+		for i := 0; i < 3; i++ {
+			d.momentum[i] += (τ[i] + prevτ[i]) * step.Seconds() / 2
+		}
+		prevτ = τ
+		if Norm(d.momentum) >= d.Capacity {
+			d.dump(m)
+		}
+	}
+}
+
+// torque returns the environmental torque on the vehicle at the current state, in the inertial frame (N m).
+func (d *Desaturation) torque(m *Mission) []float64 {
+	state := State{DT: m.CurrentDT, Orbit: *m.Orbit}
+	return MxV33(m.Vehicle.BodyToInertial(state), m.Vehicle.EnvironmentalTorque(state, d.Weather).Total())
+}
+
+// dump fires the attitude thrusters to cancel the momentum of the wheels, and applies the resulting Δv to the orbit.
+func (d *Desaturation) dump(m *Mission) {
+	state := State{DT: m.CurrentDT, Orbit: *m.Orbit}
+	dcm := m.Vehicle.BodyToInertial(state)
+	mass := m.Vehicle.Mass(m.CurrentDT)
+	// The total impulse of the thrusters is that of the torque over the moment arm.
+	impulse := Norm(d.momentum) / d.Arm // N s
+	Δv := []float64{0, 0, 0}
+	if d.Imbalance > 0 {
+		Δv = MxV33(dcm, Unit(d.ForceAxis))
+		for i := 0; i < 3; i++ {
+			Δv[i] *= d.Imbalance * impulse / mass * 1e-3
+		}
+	}
+	ric := MxV33(m.Orbit.RICDCM(), Δv)
+	event := DesaturationEvent{m.CurrentDT, MxV33(dcm.T(), d.momentum), Δv, NewManeuver(ric[0], ric[1], ric[2]), 0}
+	if d.Isp > 0 {
+		event.Fuel = impulse / (d.Isp * 9.807)
+		m.Vehicle.FuelMass -= event.Fuel
+	}
+	d.Events = append(d.Events, event)
+	m.Vehicle.logger.Log("level", "notice", "subsys", "adcs", "date", m.CurrentDT, "desaturation", event)
+	R, V := m.Orbit.RV()
+	for i := 0; i < 3; i++ {
+		V[i] += Δv[i]
+	}
+	*m.Orbit = *NewOrbitFromRV(R, V, m.Orbit.Origin)
+	d.momentum = []float64{0, 0, 0}
+}
+
+// Maneuvers returns the Δv imparted by the desaturations as impulsive maneuvers, e.g. to account for them in the
+// Maneuvers of the spacecraft of an orbit determination.
+func (d *Desaturation) Maneuvers() []ScheduledManeuver {
+	maneuvers := make([]ScheduledManeuver, len(d.Events))
+	for k, e := range d.Events {
+		maneuvers[k] = ScheduledManeuver{e.DT, e.Maneuver}
+	}
+	return maneuvers
+}
+
+// TotalΔv returns the total Δv imparted by the desaturations in m/s.
+func (d *Desaturation) TotalΔv() (Δv float64) {
+	for _, e := range d.Events {
+		Δv += Norm(e.Δv) * 1e3
+	}
+	return
+}
+
+// Fuel returns the total fuel used by the desaturations (kg).
+func (d *Desaturation) Fuel() (fuel float64) {
+	for _, e := range d.Events {
+		fuel += e.Fuel
+	}
+	return
+}
+
+// Frequency returns the mean interval between two desaturations over the simulated duration, or zero if none was
+// needed.
+func (d *Desaturation) Frequency() time.Duration {
+	if len(d.Events) == 0 {
+		return 0
+	}
+	return d.endDT.Sub(d.startDT) / time.Duration(len(d.Events))
+}
+
+// WriteDesaturations writes the desaturation events as a CSV table.
+func WriteDesaturations(w io.Writer, events []DesaturationEvent) error {
+	if _, err := fmt.Fprint(w, "epoch,hX,hY,hZ,dvR,dvN,dvC,fuel\n"); err != nil {
+		return err
+	}
+	for _, e := range events {
+		if _, err := fmt.Fprintf(w, "%s,%.6e,%.6e,%.6e,%.6e,%.6e,%.6e,%.6e\n", e.DT.UTC().Format(time.RFC3339), e.Momentum[0], e.Momentum[1], e.Momentum[2], e.Maneuver.R, e.Maneuver.N, e.Maneuver.C, e.Fuel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
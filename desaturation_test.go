@@ -0,0 +1,82 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDesaturation(t *testing.T) {
+	start := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	// Dawn-dusk polar orbit, with an absorbing solar array one meter away from the center of mass (cf.
+	// TestMomentumAccumulation): the SRP torque of about 9.3e-6 N m builds up 0.8 N m s per day.
+	o := NewOrbitFromOE(Earth.Radius+700, 0, 90, 72, 0, 0, Earth)
+	run := func(imbalance float64) (*Desaturation, *Mission) {
+		sc := NewEmptySC("desat", 100)
+		sc.FuelMass = 10
+		sc.Attitude = SunPointing
+		sc.BoxWing = &BoxWing{NewWing(2, []float64{0, 0, 1}, []float64{1, 0, 0}, 0, 0), 0}
+		d := NewDesaturation(0.15, 0.5, imbalance, []float64{1, 0, 0})
+		d.Isp = 70
+		orbit := *o
+		m := NewPreciseMission(sc, &orbit, start, end, Perturbations{}, 10*time.Second, false, ExportConfig{})
+		d.Run(m)
+		return d, m
+	}
+	d, m := run(1)
+	if len(d.Events) < 4 || len(d.Events) > 6 {
+		t.Fatalf("expected about five desaturations: %+v", d.Events)
+	}
+	used := 0.
+	for _, e := range d.Events {
+		h := Norm(e.Momentum)
+		if h < d.Capacity || h > 1.01*d.Capacity {
+			t.Fatalf("momentum of %f N m s dumped", h)
+		}
+		// Single thrusters: the impulse of H/arm on the spacecraft, whose mass decreases with the fuel used.
+		if Δv := Norm(e.Δv); math.Abs(Δv-h/0.5/(110-used)*1e-3) > 1e-15 {
+			t.Fatalf("Δv of %g km/s for %s", Δv, e)
+		}
+		if math.Abs(e.Fuel-h/0.5/(70*9.807)) > 1e-12 {
+			t.Fatalf("%f kg of fuel used for %s", e.Fuel, e)
+		}
+		used += e.Fuel
+		if math.Abs(e.Maneuver.Δv()-Norm(e.Δv)) > 1e-15 {
+			t.Fatalf("RIC maneuver %s does not match the Δv %v", e.Maneuver, e.Δv)
+		}
+	}
+	if fuel := d.Fuel(); math.Abs(m.Vehicle.FuelMass-(10-fuel)) > 1e-12 || fuel <= 0 {
+		t.Fatalf("fuel of %f kg used, %f kg left", fuel, m.Vehicle.FuelMass)
+	}
+	if freq := d.Frequency(); freq < 4*time.Hour || freq > 6*time.Hour {
+		t.Fatalf("desaturation every %s", freq)
+	}
+	if len(d.Maneuvers()) != len(d.Events) || d.TotalΔv() <= 0 {
+		t.Fatalf("invalid maneuvers %v", d.Maneuvers())
+	}
+	if !m.CurrentDT.Equal(end) {
+		t.Fatalf("mission stopped at %s", m.CurrentDT)
+	}
+	// Balanced couples dump the same momentum without changing the orbit.
+	balanced, mb := run(0)
+	if len(balanced.Events) != len(d.Events) || balanced.TotalΔv() != 0 {
+		t.Fatalf("balanced couples: %d events, Δv of %f m/s", len(balanced.Events), balanced.TotalΔv())
+	}
+	R, Rb := m.Orbit.R(), mb.Orbit.R()
+	if Δr := Norm([]float64{R[0] - Rb[0], R[1] - Rb[1], R[2] - Rb[2]}); Δr < 1e-3 {
+		t.Fatalf("desaturations moved the spacecraft by only %f m", Δr*1e3)
+	}
+	var buf bytes.Buffer
+	if err := WriteDesaturations(&buf, d.Events); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != len(d.Events)+1 {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+	assertPanic(t, func() {
+		NewDesaturation(1, 1, 0.5, nil)
+	})
+}
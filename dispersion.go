@@ -0,0 +1,256 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// DispersionSample stores the outcome of one Monte Carlo run.
+type DispersionSample struct {
+	Run   int
+	Final State   // Final state of the run, e.g. on the approach hyperbola of the arrival body
+	Fuel  float64 // Fuel used during the run (kg)
+}
+
+// DispersionStats stores the statistics of a dispersed quantity.
+type DispersionStats struct {
+	N                       int
+	Mean, StdDev, Min, Max  float64
+	P5, P50, P95, P99, P997 float64 // Percentiles
+}
+
+// NewDispersionStats returns the statistics of the provided values.
+func NewDispersionStats(values []float64) DispersionStats {
+	if len(values) == 0 {
+		panic("cannot compute the statistics of no values")
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	s := DispersionStats{N: len(sorted), Min: sorted[0], Max: sorted[len(sorted)-1]}
+	for _, v := range sorted {
+		s.Mean += v
+	}
+	s.Mean /= float64(s.N)
+	if s.N > 1 {
+		for _, v := range sorted {
+			s.StdDev += (v - s.Mean) * (v - s.Mean)
+		}
+		s.StdDev = math.Sqrt(s.StdDev / float64(s.N-1))
+	}
+	s.P5 = percentile(sorted, 5)
+	s.P50 = percentile(sorted, 50)
+	s.P95 = percentile(sorted, 95)
+	s.P99 = percentile(sorted, 99)
+	s.P997 = percentile(sorted, 99.7)
+	return s
+}
+
+func (s DispersionStats) String() string {
+	return fmt.Sprintf("μ=%.6f σ=%.6f [%.6f, %.6f] p50=%.6f p95=%.6f p99=%.6f", s.Mean, s.StdDev, s.Min, s.Max, s.P50, s.P95, s.P99)
+}
+
+// Percentile returns the p-th percentile (between 0 and 100) of the values, with linear interpolation
+// between the closest ranks.
+func Percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		panic("cannot compute the percentile of no values")
+	}
+	if p < 0 || p > 100 {
+		panic(fmt.Errorf("percentile %f not in [0, 100]", p))
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	return percentile(sorted, p)
+}
+
+// percentile returns the p-th percentile of the sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	if lo >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (rank-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
+
+// DispersionReport stores the arrival dispersion statistics of a Monte Carlo analysis.
+type DispersionReport struct {
+	BR, BT, BMiss    DispersionStats // B-plane components and miss distance from the target (km)
+	VInf             DispersionStats // Arrival hyperbolic excess velocity (km/s)
+	ArrivalDT        DispersionStats // Arrival epoch offset from the nominal arrival (s)
+	Fuel             DispersionStats // Fuel used (kg)
+	BPlaneCovariance *mat64.SymDense // Sample covariance of BR and BT (km²)
+}
+
+// NewDispersionReport returns the dispersion statistics of the samples with respect to the target B-plane and
+// the nominal arrival epoch. The final state of each sample must be on a hyperbola about the arrival body.
+func NewDispersionReport(samples []DispersionSample, target BPlane, nominalArrival time.Time) DispersionReport {
+	n := len(samples)
+	if n < 2 {
+		panic("dispersion report requires at least two samples")
+	}
+	bR := make([]float64, n)
+	bT := make([]float64, n)
+	bMiss := make([]float64, n)
+	vInf := make([]float64, n)
+	arrival := make([]float64, n)
+	fuel := make([]float64, n)
+	for k, sample := range samples {
+		bPlane := NewBPlane(sample.Final.Orbit)
+		bR[k] = bPlane.BR
+		bT[k] = bPlane.BT
+		bMiss[k] = math.Sqrt(math.Pow(bPlane.BR-target.BR, 2) + math.Pow(bPlane.BT-target.BT, 2))
+		vInf[k] = hyperbolicExcessVelocity(sample.Final.Orbit)
+		arrival[k] = sample.Final.DT.Sub(nominalArrival).Seconds()
+		fuel[k] = sample.Fuel
+	}
+	r := DispersionReport{
+		BR:        NewDispersionStats(bR),
+		BT:        NewDispersionStats(bT),
+		BMiss:     NewDispersionStats(bMiss),
+		VInf:      NewDispersionStats(vInf),
+		ArrivalDT: NewDispersionStats(arrival),
+		Fuel:      NewDispersionStats(fuel),
+	}
+	var cRR, cRT, cTT float64
+	for k := 0; k < n; k++ {
+		δR := bR[k] - r.BR.Mean
+		δT := bT[k] - r.BT.Mean
+		cRR += δR * δR
+		cRT += δR * δT
+		cTT += δT * δT
+	}
+	N := float64(n - 1)
+	r.BPlaneCovariance = mat64.NewSymDense(2, []float64{cRR / N, cRT / N, cRT / N, cTT / N})
+	return r
+}
+
+// BPlaneEllipse returns the semi-major and semi-minor axes (km) of the 1σ B-plane dispersion ellipse, and the
+// angle (radians) of its semi-major axis from the T axis towards the R axis.
+func (r DispersionReport) BPlaneEllipse() (semiMajor, semiMinor, θ float64) {
+	cTT := r.BPlaneCovariance.At(1, 1)
+	cRR := r.BPlaneCovariance.At(0, 0)
+	cRT := r.BPlaneCovariance.At(0, 1)
+	mean := (cTT + cRR) / 2
+	δ := math.Sqrt(math.Pow((cTT-cRR)/2, 2) + cRT*cRT)
+	return math.Sqrt(mean + δ), math.Sqrt(math.Max(mean-δ, 0)), 0.5 * math.Atan2(2*cRT, cTT-cRR)
+}
+
+func (r DispersionReport) String() string {
+	smaj, smin, θ := r.BPlaneEllipse()
+	return fmt.Sprintf("B-plane miss: %s\nBR: %s\nBT: %s\n1σ ellipse: %.3f x %.3f km at %.3f deg\nv∞: %s\narrival: %s\nfuel: %s", r.BMiss, r.BR, r.BT, smaj, smin, Rad2deg(θ), r.VInf, r.ArrivalDT, r.Fuel)
+}
+
+// hyperbolicExcessVelocity returns the hyperbolic excess velocity of the orbit (km/s).
+func hyperbolicExcessVelocity(o Orbit) float64 {
+	v := o.VNorm()
+	vInf2 := v*v - 2*o.Origin.μ/o.RNorm()
+	if vInf2 < 0 {
+		panic("orbit is not hyperbolic")
+	}
+	return math.Sqrt(vInf2)
+}
+
+// WriteDispersionSamples writes the arrival conditions of each sample as a CSV table for plotting.
+func WriteDispersionSamples(w io.Writer, samples []DispersionSample, target BPlane, nominalArrival time.Time) error {
+	if _, err := fmt.Fprint(w, "run,BR,BT,bMiss,vInf,arrivalOffset,fuel\n"); err != nil {
+		return err
+	}
+	for _, sample := range samples {
+		bPlane := NewBPlane(sample.Final.Orbit)
+		bMiss := math.Sqrt(math.Pow(bPlane.BR-target.BR, 2) + math.Pow(bPlane.BT-target.BT, 2))
+		if _, err := fmt.Fprintf(w, "%d,%.6f,%.6f,%.6f,%.9f,%.3f,%.6f\n", sample.Run, bPlane.BR, bPlane.BT, bMiss, hyperbolicExcessVelocity(sample.Final.Orbit), sample.Final.DT.Sub(nominalArrival).Seconds(), sample.Fuel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CovarianceRealism stores the consistency of a predicted covariance with the dispersion of the samples.
+type CovarianceRealism struct {
+	MahalanobisSq []float64 // Squared Mahalanobis distance of each sample
+	Mean          float64   // Mean squared Mahalanobis distance, equal to the dimension if the covariance is realistic
+	Dimension     int
+	Within        [3]float64 // Fraction of the samples within the 1, 2 and 3σ ellipsoids
+	Expected      [3]float64 // Expected fraction within the 1, 2 and 3σ ellipsoids (χ² distribution)
+}
+
+func (c CovarianceRealism) String() string {
+	return fmt.Sprintf("mean d²=%.3f (expected %d), within 1σ: %.3f (exp. %.3f) 2σ: %.3f (exp. %.3f) 3σ: %.3f (exp. %.3f)", c.Mean, c.Dimension, c.Within[0], c.Expected[0], c.Within[1], c.Expected[1], c.Within[2], c.Expected[2])
+}
+
+// NewCovarianceRealism returns the realism of the predicted covariance of the nominal state, compared to the
+// dispersed states at the same epoch. The covariance must be of the dimension of the state vectors.
+func NewCovarianceRealism(nominal State, covariance mat64.Symmetric, states []State) (CovarianceRealism, error) {
+	n := covariance.Symmetric()
+	if nominal.Vector().Len() != n {
+		return CovarianceRealism{}, fmt.Errorf("covariance dimension %d does not match the state dimension %d", n, nominal.Vector().Len())
+	}
+	if len(states) == 0 {
+		return CovarianceRealism{}, errors.New("no dispersed states")
+	}
+	var Pinv mat64.Dense
+	if err := Pinv.Inverse(covariance); err != nil {
+		return CovarianceRealism{}, err
+	}
+	c := CovarianceRealism{MahalanobisSq: make([]float64, len(states)), Dimension: n}
+	δ := mat64.NewVector(n, nil)
+	Pδ := mat64.NewVector(n, nil)
+	for k, state := range states {
+		δ.SubVec(state.Vector(), nominal.Vector())
+		Pδ.MulVec(&Pinv, δ)
+		d2 := mat64.Dot(δ, Pδ)
+		c.MahalanobisSq[k] = d2
+		c.Mean += d2
+		for σ := 0; σ < 3; σ++ {
+			if d2 <= float64((σ+1)*(σ+1)) {
+				c.Within[σ]++
+			}
+		}
+	}
+	c.Mean /= float64(len(states))
+	for σ := 0; σ < 3; σ++ {
+		c.Within[σ] /= float64(len(states))
+		c.Expected[σ] = chiSquareCDF(float64((σ+1)*(σ+1)), n)
+	}
+	return c, nil
+}
+
+// WriteCovarianceRealism writes the squared Mahalanobis distance of each sample as a CSV table for plotting.
+func WriteCovarianceRealism(w io.Writer, c CovarianceRealism) error {
+	if _, err := fmt.Fprint(w, "sample,mahalanobisSq\n"); err != nil {
+		return err
+	}
+	for k, d2 := range c.MahalanobisSq {
+		if _, err := fmt.Fprintf(w, "%d,%.9f\n", k, d2); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chiSquareCDF returns the cumulative distribution of the χ² distribution with k degrees of freedom,
+// computed from the series of the regularized lower incomplete gamma function.
+func chiSquareCDF(x float64, k int) float64 {
+	if x <= 0 {
+		return 0
+	}
+	s := float64(k) / 2
+	z := x / 2
+	lgamma, _ := math.Lgamma(s + 1)
+	term := math.Exp(s*math.Log(z) - z - lgamma)
+	sum := term
+	for n := 1; n < 500 && term > 1e-16*sum; n++ {
+		term *= z / (s + float64(n))
+		sum += term
+	}
+	return math.Min(sum, 1)
+}
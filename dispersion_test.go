@@ -0,0 +1,142 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestPercentile(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3}
+	for _, tc := range []struct{ p, exp float64 }{{0, 1}, {25, 2}, {50, 3}, {62.5, 3.5}, {100, 5}} {
+		if got := Percentile(values, tc.p); !floats.EqualWithinAbs(got, tc.exp, 1e-12) {
+			t.Fatalf("p%f=%f expected %f", tc.p, got, tc.exp)
+		}
+	}
+	if values[0] != 5 {
+		t.Fatal("percentile sorted the input")
+	}
+	stats := NewDispersionStats(values)
+	if stats.N != 5 || stats.Mean != 3 || stats.Min != 1 || stats.Max != 5 || !floats.EqualWithinAbs(stats.StdDev, math.Sqrt(2.5), 1e-12) {
+		t.Fatalf("invalid stats: %+v", stats)
+	}
+	assertPanic(t, func() {
+		Percentile(values, 101)
+	})
+	assertPanic(t, func() {
+		NewDispersionStats(nil)
+	})
+}
+
+func TestChiSquareCDF(t *testing.T) {
+	for _, tc := range []struct {
+		x   float64
+		k   int
+		exp float64
+	}{{1, 1, 0.682689492}, {4, 2, 1 - math.Exp(-2)}, {9, 3, 0.970709113}, {1, 6, 0.014387678}} {
+		if got := chiSquareCDF(tc.x, tc.k); !floats.EqualWithinAbs(got, tc.exp, 1e-8) {
+			t.Fatalf("χ²(%f, %d)=%f expected %f", tc.x, tc.k, got, tc.exp)
+		}
+	}
+}
+
+func TestDispersionReport(t *testing.T) {
+	arrival := time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)
+	nominal := NewCaptureFromApoapsis(Mars, 2.5, Mars.Radius+300, Mars.Radius+300).ApproachOrbit(30, 10, 20)
+	target := NewBPlane(*nominal)
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]DispersionSample, 500)
+	for k := range samples {
+		R := make([]float64, 3)
+		V := make([]float64, 3)
+		copy(R, nominal.R())
+		copy(V, nominal.V())
+		for i := 0; i < 3; i++ {
+			R[i] += rng.NormFloat64() * 10
+			V[i] += rng.NormFloat64() * 1e-3
+		}
+		dt := arrival.Add(time.Duration(rng.NormFloat64()*60) * time.Second)
+		samples[k] = DispersionSample{Run: k, Final: State{DT: dt, Orbit: *NewOrbitFromRV(R, V, Mars)}, Fuel: 10 + rng.NormFloat64()}
+	}
+	report := NewDispersionReport(samples, target, arrival)
+	if report.BMiss.Min < 0 || report.BMiss.P50 > report.BMiss.P95 || report.BMiss.P95 > report.BMiss.P99 {
+		t.Fatalf("invalid B-plane miss stats: %s", report.BMiss)
+	}
+	if !floats.EqualWithinAbs(report.BR.Mean, target.BR, 3) || !floats.EqualWithinAbs(report.BT.Mean, target.BT, 3) {
+		t.Fatalf("biased B-plane: %s", report)
+	}
+	if !floats.EqualWithinAbs(report.VInf.Mean, 2.5, 1e-3) || !floats.EqualWithinAbs(report.Fuel.Mean, 10, 0.2) {
+		t.Fatalf("invalid arrival conditions: %s", report)
+	}
+	if !floats.EqualWithinAbs(report.ArrivalDT.StdDev, 60, 6) {
+		t.Fatalf("invalid arrival epoch dispersion: %s", report.ArrivalDT)
+	}
+	smaj, smin, _ := report.BPlaneEllipse()
+	trace := report.BPlaneCovariance.At(0, 0) + report.BPlaneCovariance.At(1, 1)
+	if smaj < smin || !floats.EqualWithinRel(smaj*smaj+smin*smin, trace, 1e-9) {
+		t.Fatalf("invalid B-plane ellipse %f x %f", smaj, smin)
+	}
+	var buf bytes.Buffer
+	if err := WriteDispersionSamples(&buf, samples, target, arrival); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != len(samples)+1 {
+		t.Fatalf("wrote %d lines", lines)
+	}
+	if len(report.String()) == 0 {
+		t.Fatal("empty report")
+	}
+}
+
+func TestCovarianceRealism(t *testing.T) {
+	dt := time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)
+	nominal := State{DT: dt, Orbit: *NewOrbitFromOE(7000, 0.01, 30, 10, 20, 40, Earth)}
+	σ := []float64{1, 2, 0.5, 1e-3, 2e-3, 5e-4}
+	P := mat64.NewSymDense(6, nil)
+	for i, s := range σ {
+		P.SetSym(i, i, s*s)
+	}
+	rng := rand.New(rand.NewSource(2))
+	states := make([]State, 2000)
+	for k := range states {
+		R := make([]float64, 3)
+		V := make([]float64, 3)
+		copy(R, nominal.Orbit.R())
+		copy(V, nominal.Orbit.V())
+		for i := 0; i < 3; i++ {
+			R[i] += rng.NormFloat64() * σ[i]
+			V[i] += rng.NormFloat64() * σ[i+3]
+		}
+		states[k] = State{DT: dt, Orbit: *NewOrbitFromRV(R, V, Earth)}
+	}
+	realism, err := NewCovarianceRealism(nominal, P, states)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floats.EqualWithinAbs(realism.Mean, 6, 0.3) {
+		t.Fatalf("realistic covariance not consistent: %s", realism)
+	}
+	for σ := 0; σ < 3; σ++ {
+		if !floats.EqualWithinAbs(realism.Within[σ], realism.Expected[σ], 0.03) {
+			t.Fatalf("realistic covariance not consistent: %s", realism)
+		}
+	}
+	// An optimistic covariance is not realistic.
+	P.ScaleSym(0.25, P)
+	if optimistic, _ := NewCovarianceRealism(nominal, P, states); optimistic.Mean < 20 {
+		t.Fatalf("optimistic covariance is consistent: %s", optimistic)
+	}
+	var buf bytes.Buffer
+	if err := WriteCovarianceRealism(&buf, realism); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewCovarianceRealism(nominal, mat64.NewSymDense(3, nil), states); err == nil {
+		t.Fatal("dimension mismatch not detected")
+	}
+}
@@ -0,0 +1,146 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// DisposalMaxLifetime is the maximum post-mission orbital lifetime of the IADC 25 year rule.
+const DisposalMaxLifetime = time.Duration(25*365.25*24) * time.Hour
+
+// DisposalBurn stores an impulsive disposal burn and its location on the orbit.
+type DisposalBurn struct {
+	TrueAnomaly float64  // True anomaly of the burn on the orbit before the burn, in degrees
+	Maneuver    Maneuver // Δv in km/s in the RIC frame
+}
+
+func (b DisposalBurn) String() string {
+	return fmt.Sprintf("%.3f m/s @ ν=%.1f deg", b.Maneuver.Δv()*1e3, b.TrueAnomaly)
+}
+
+// DisposalPlan stores the burns of a disposal and the orbit once they are executed.
+type DisposalPlan struct {
+	Burns []DisposalBurn
+	Orbit *Orbit // Final orbit, at the location of the last burn
+}
+
+// TotalΔv returns the total Δv of the disposal in km/s.
+func (p DisposalPlan) TotalΔv() (Δv float64) {
+	for _, b := range p.Burns {
+		Δv += b.Maneuver.Δv()
+	}
+	return
+}
+
+func (p DisposalPlan) String() string {
+	s := fmt.Sprintf("disposal with %.3f m/s to hp=%.3f km ha=%.3f km", p.TotalΔv()*1e3, p.Orbit.Periapsis()-p.Orbit.Origin.Radius, p.Orbit.Apoapsis()-p.Orbit.Origin.Radius)
+	for _, b := range p.Burns {
+		s += fmt.Sprintf("\n\t%s", b)
+	}
+	return s
+}
+
+// apsides returns the periapsis and apoapsis radii, which are both the semi-major axis of circular orbits.
+func apsides(o Orbit) (rP, rA float64) {
+	a, e, _, _, _, _, _, _, _ := o.Elements()
+	if e <= eccentricityε {
+		// Circular orbits have no apsis, so the burn may be anywhere.
+		return a, a
+	}
+	return a * (1 - e), a * (1 + e)
+}
+
+// tangentialBurn returns the in-track burn at the apsis of the orbit (true anomaly of 0 or 180 degrees) which sets
+// the opposite apsis to the provided radius, and the orbit after the burn.
+func tangentialBurn(o Orbit, ν, radius float64) (DisposalBurn, *Orbit) {
+	a, _, i, Ω, ω, _, _, _, _ := o.Elements()
+	μ := o.Origin.μ
+	r, rA := apsides(o)
+	if ν != 0 {
+		r = rA
+	}
+	aF := (r + radius) / 2
+	Δv := math.Sqrt(μ*(2/r-1/aF)) - math.Sqrt(μ*(2/r-1/a))
+	// The burn location is the periapsis of the final orbit if it is raised, and its apoapsis otherwise.
+	eF, ωF := math.Abs(radius-r)/(radius+r), Rad2deg(ω)+ν
+	νF := 0.
+	if radius < r {
+		νF = 180
+		ωF -= 180
+	}
+	return DisposalBurn{ν, NewManeuver(0, Δv, 0)}, NewOrbitFromOE(aF, eF, Rad2deg(i), Rad2deg(Ω), ωF, νF, o.Origin)
+}
+
+// DeorbitDisposal returns the retrograde burn at apoapsis which lowers the periapsis to the provided altitude (km),
+// e.g. ReentryAltitude for a direct reentry.
+func DeorbitDisposal(o Orbit, perigeeAltitude float64) (DisposalPlan, error) {
+	rP := o.Origin.Radius + perigeeAltitude
+	if current, _ := apsides(o); rP >= current {
+		return DisposalPlan{}, fmt.Errorf("periapsis is already at %.3f km", current-o.Origin.Radius)
+	}
+	if _, e, _, _, _, _, _, _, _ := o.Elements(); e >= 1 {
+		return DisposalPlan{}, errors.New("cannot dispose of an open orbit")
+	}
+	burn, orbit := tangentialBurn(o, 180, rP)
+	return DisposalPlan{[]DisposalBurn{burn}, orbit}, nil
+}
+
+// GraveyardDisposal returns the Hohmann transfer which raises the orbit by the provided altitude (km) into a
+// circular graveyard orbit, e.g. with IADCGraveyardIncrease for a GEO spacecraft.
+func GraveyardDisposal(o Orbit, Δh float64) (DisposalPlan, error) {
+	if Δh <= 0 {
+		return DisposalPlan{}, errors.New("graveyard orbit must be above the current orbit")
+	}
+	if _, e, _, _, _, _, _, _, _ := o.Elements(); e >= 1 {
+		return DisposalPlan{}, errors.New("cannot dispose of an open orbit")
+	}
+	_, rA := apsides(o)
+	rF := rA + Δh
+	burn1, transfer := tangentialBurn(o, 0, rF)
+	burn2, orbit := tangentialBurn(*transfer, 180, rF)
+	return DisposalPlan{[]DisposalBurn{burn1, burn2}, orbit}, nil
+}
+
+// IADCGraveyardIncrease returns the minimum altitude increase (km) above the GEO altitude of the IADC graveyard
+// orbit from the reflectivity coefficient and the area to mass ratio (m²/kg) of the spacecraft.
+func IADCGraveyardIncrease(Cr, areaToMass float64) float64 {
+	return 235 + 1000*Cr*areaToMass
+}
+
+// DisposalCompliance stores the lifetime of the orbit after disposal, and whether it complies with the 25 year rule.
+type DisposalCompliance struct {
+	Lifetime  LifetimeEstimate
+	Compliant bool
+}
+
+// CheckDisposal returns whether the orbit complies with the 25 year rule with the lifetime estimator.
+func CheckDisposal(o Orbit, sc Spacecraft, sw SpaceWeather) DisposalCompliance {
+	est := Lifetime(o, sc, sw)
+	return DisposalCompliance{est, est.Reentered && est.Duration <= DisposalMaxLifetime}
+}
+
+// CompliantDisposal returns the smallest deorbit burn which complies with the 25 year rule, or no burn if the
+// orbit already complies.
+func CompliantDisposal(o Orbit, sc Spacecraft, sw SpaceWeather) (DisposalPlan, error) {
+	if CheckDisposal(o, sc, sw).Compliant {
+		return DisposalPlan{nil, &o}, nil
+	}
+	// The lifetime decreases with the perigee altitude, so bisect it between the reentry and the current perigee.
+	rP, _ := apsides(o)
+	low, high := ReentryAltitude, rP-o.Origin.Radius
+	for high-low > 0.1 {
+		mid := (low + high) / 2
+		plan, err := DeorbitDisposal(o, mid)
+		if err != nil {
+			return DisposalPlan{}, err
+		}
+		if CheckDisposal(*plan.Orbit, sc, sw).Compliant {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return DeorbitDisposal(o, low)
+}
@@ -0,0 +1,75 @@
+package smd
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestDeorbitDisposal(t *testing.T) {
+	o := NewOrbitFromOE(Earth.Radius+800, 0, 98.6, 10, 0, 0, Earth)
+	plan, err := DeorbitDisposal(*o, ReentryAltitude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Earth.Radius + 800
+	rP := Earth.Radius + ReentryAltitude
+	exp := math.Sqrt(Earth.μ/r) - math.Sqrt(2*Earth.μ*rP/(r*(r+rP)))
+	if len(plan.Burns) != 1 || !floats.EqualWithinAbs(plan.TotalΔv(), exp, 1e-9) || plan.Burns[0].Maneuver.N >= 0 {
+		t.Fatalf("invalid plan (expected %f m/s): %s", exp*1e3, plan)
+	}
+	if !floats.EqualWithinAbs(plan.Orbit.Periapsis(), rP, 1e-6) || !floats.EqualWithinAbs(plan.Orbit.Apoapsis(), r, 1e-6) {
+		t.Fatalf("invalid final orbit: %s", plan)
+	}
+	if _, err = DeorbitDisposal(*o, 900); err == nil {
+		t.Fatal("expected an error when raising the perigee")
+	}
+}
+
+func TestGraveyardDisposal(t *testing.T) {
+	o := NewOrbitFromOE(42164, 0, 0.1, 0, 0, 0, Earth)
+	Δh := IADCGraveyardIncrease(1.5, 0.02)
+	if !floats.EqualWithinAbs(Δh, 265, 1e-12) {
+		t.Fatalf("invalid IADC altitude increase %f", Δh)
+	}
+	plan, err := GraveyardDisposal(*o, Δh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vI, vF := math.Sqrt(Earth.μ/42164), math.Sqrt(Earth.μ/(42164+Δh))
+	vDeparture, vArrival, _ := Hohmann(42164, vI, 42164+Δh, vF, Earth)
+	if len(plan.Burns) != 2 || !floats.EqualWithinAbs(plan.TotalΔv(), vDeparture-vI+vF-vArrival, 1e-9) {
+		t.Fatalf("invalid plan: %s", plan)
+	}
+	if _, e, _, _, _, _, _, _, _ := plan.Orbit.Elements(); e > eccentricityε || !floats.EqualWithinAbs(plan.Orbit.RNorm(), 42164+Δh, 1e-6) {
+		t.Fatalf("invalid graveyard orbit: %s", plan.Orbit)
+	}
+	if _, err = GraveyardDisposal(*o, -1); err == nil {
+		t.Fatal("expected an error for a negative altitude increase")
+	}
+}
+
+func TestCompliantDisposal(t *testing.T) {
+	cubesat := NewEmptySC("3U", 4)
+	cubesat.Cd = 2.2
+	cubesat.Area = 0.03
+	if c := CheckDisposal(*NewOrbitFromOE(Earth.Radius+400, 0, 51.6, 0, 0, 0, Earth), *cubesat, ModerateSpaceWeather); !c.Compliant {
+		t.Fatalf("cubesat at 400 km is compliant: %s", c.Lifetime.Duration)
+	}
+	o := NewOrbitFromOE(Earth.Radius+800, 0, 98.6, 0, 0, 0, Earth)
+	if c := CheckDisposal(*o, *cubesat, ModerateSpaceWeather); c.Compliant {
+		t.Fatalf("cubesat at 800 km is not compliant: %s", c.Lifetime.Duration)
+	}
+	plan, err := CompliantDisposal(*o, *cubesat, ModerateSpaceWeather)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := CheckDisposal(*plan.Orbit, *cubesat, ModerateSpaceWeather)
+	if !c.Compliant || c.Lifetime.Duration < DisposalMaxLifetime*9/10 {
+		t.Fatalf("disposal is not the smallest compliant one (lifetime %s): %s", c.Lifetime.Duration, plan)
+	}
+	if full, _ := DeorbitDisposal(*o, ReentryAltitude); plan.TotalΔv() >= full.TotalΔv() {
+		t.Fatal("compliant disposal should be cheaper than a direct reentry")
+	}
+}
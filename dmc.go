@@ -0,0 +1,70 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// DMC defines the dynamic model compensation: three empirical accelerations (one per inertial axis) which are
+// estimated as first-order Gauss-Markov processes in order to absorb the unmodeled accelerations, as per Tapley,
+// Schutz & Born (2004) section 4.9. Unlike state noise compensation, the estimated accelerations are propagated
+// with the dynamics and correlated in time. The accelerations are stored in the EmpiricalAcc of the spacecraft.
+type DMC struct {
+	Tau   time.Duration // Time constant of the Gauss-Markov processes
+	Sigma float64       // Steady state standard deviation of each empirical acceleration (km/s²)
+}
+
+// NewDMC returns a new dynamic model compensation with the provided time constant and steady state standard
+// deviation of the empirical accelerations (in km/s²).
+func NewDMC(τ time.Duration, σ float64) *DMC {
+	if τ <= 0 {
+		panic(fmt.Errorf("DMC time constant must be positive, got %s", τ))
+	}
+	return &DMC{τ, σ}
+}
+
+// β returns the inverse of the time constant in 1/s.
+func (d DMC) β() float64 {
+	return 1 / d.Tau.Seconds()
+}
+
+// Decay returns the empirical accelerations after Δt without any process noise, i.e. the expected value of the
+// Gauss-Markov processes.
+func (d DMC) Decay(w []float64, Δt time.Duration) []float64 {
+	e := math.Exp(-d.β() * Δt.Seconds())
+	return []float64{w[0] * e, w[1] * e, w[2] * e}
+}
+
+// ProcessNoise returns the 9×9 discrete process noise covariance of the [R, V, w] state over Δt, where w are the
+// empirical accelerations, e.g. to be added to the propagated covariance of a filter. Only the Gauss-Markov
+// driving noise is accounted for, the spectral density of which keeps the steady state deviation at Sigma.
+func (d DMC) ProcessNoise(Δt time.Duration) *mat64.SymDense {
+	β := d.β()
+	t := Δt.Seconds()
+	q := 2 * β * d.Sigma * d.Sigma
+	e := math.Exp(-β * t)
+	e2 := math.Exp(-2 * β * t)
+	β2 := β * β
+	β3 := β2 * β
+	β4 := β3 * β
+	β5 := β4 * β
+	qrr := q * (math.Pow(t, 3)/(3*β2) - t*t/β3 + t/β4 - 2*t*e/β4 + (1-e2)/(2*β5))
+	qrv := q * (t*t/(2*β2) - t/β3 + t*e/β3 + (1-e)/β4 - (1-e2)/(2*β4))
+	qrw := q * ((1-e2)/(2*β3) - t*e/β2)
+	qvv := q * (t/β2 - 2*(1-e)/β3 + (1-e2)/(2*β3))
+	qvw := q * ((1-e)/β2 - (1-e2)/(2*β2))
+	qww := q * (1 - e2) / (2 * β)
+	Q := mat64.NewSymDense(9, nil)
+	for i := 0; i < 3; i++ {
+		Q.SetSym(i, i, qrr)
+		Q.SetSym(i, i+3, qrv)
+		Q.SetSym(i, i+6, qrw)
+		Q.SetSym(i+3, i+3, qvv)
+		Q.SetSym(i+3, i+6, qvw)
+		Q.SetSym(i+6, i+6, qww)
+	}
+	return Q
+}
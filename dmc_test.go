@@ -0,0 +1,104 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestDMCProcessNoise(t *testing.T) {
+	d := NewDMC(20*time.Minute, 1e-9)
+	Δt := 5 * time.Minute
+	Q := d.ProcessNoise(Δt)
+	// Integrate the driving noise through the transition of the empirical acceleration of one axis.
+	β := 1 / d.Tau.Seconds()
+	q := 2 * β * d.Sigma * d.Sigma
+	steps := 20000
+	h := Δt.Seconds() / float64(steps)
+	var num [3][3]float64
+	for k := 0; k < steps; k++ {
+		s := (float64(k) + 0.5) * h
+		e := math.Exp(-β * s)
+		g := []float64{s/β - (1-e)/(β*β), (1 - e) / β, e}
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				num[i][j] += q * g[i] * g[j] * h
+			}
+		}
+	}
+	for axis := 0; axis < 3; axis++ {
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				if got := Q.At(3*i+axis, 3*j+axis); !floats.EqualWithinRel(got, num[i][j], 1e-5) {
+					t.Fatalf("Q[%d,%d] = %e instead of %e", 3*i+axis, 3*j+axis, got, num[i][j])
+				}
+			}
+		}
+	}
+	if Q.At(0, 1) != 0 || Q.At(3, 7) != 0 {
+		t.Fatal("axes are correlated")
+	}
+	// The steady state variance of the empirical accelerations is Sigma².
+	if qww := d.ProcessNoise(1000*d.Tau).At(6, 6); !floats.EqualWithinRel(qww, d.Sigma*d.Sigma, 1e-9) {
+		t.Fatalf("steady state variance %e", qww)
+	}
+	assertPanic(t, func() {
+		NewDMC(0, 1e-9)
+	})
+}
+
+func TestMissionDMC(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	w := []float64{1e-7, -2e-7, 5e-8}
+	propagate := func(perts Perturbations, acc []float64, computeSTM bool) []State {
+		sc := NewEmptySC("dmc", 0)
+		sc.EmpiricalAcc = acc
+		m := NewPreciseMission(sc, NewOrbitFromOE(7000, 0.01, 30, 10, 20, 0, Earth), start, end, perts, 10*time.Second, computeSTM, ExportConfig{})
+		stateChan := make(chan State, 10)
+		m.RegisterStateChan(stateChan)
+		go m.Propagate()
+		var states []State
+		for state := range stateChan {
+			states = append(states, state)
+		}
+		return states
+	}
+
+	// With a very long time constant, the empirical accelerations are constant.
+	constant := propagate(Perturbations{DMC: NewDMC(1e6*time.Hour, 1e-9)}, w, false)
+	arbitrary := propagate(Perturbations{Arbitrary: func(o Orbit) []float64 {
+		return []float64{0, 0, 0, w[0], w[1], w[2], 0}
+	}}, nil, false)
+	final := constant[len(constant)-1]
+	if !floats.EqualApprox(final.Orbit.R(), arbitrary[len(arbitrary)-1].Orbit.R(), 1e-6) {
+		t.Fatalf("DMC R=%+v\narbitrary R=%+v", final.Orbit.R(), arbitrary[len(arbitrary)-1].Orbit.R())
+	}
+	if vec := final.Vector(); vec.Len() != 9 || !floats.EqualApprox([]float64{vec.At(6, 0), vec.At(7, 0), vec.At(8, 0)}, w, 1e-12) {
+		t.Fatalf("invalid state vector with DMC")
+	}
+
+	// The empirical accelerations decay with the time constant, and so does their STM.
+	d := NewDMC(time.Hour, 1e-9)
+	decaying := propagate(Perturbations{DMC: d}, w, true)
+	final = decaying[len(decaying)-1]
+	exp := d.Decay(w, final.DT.Sub(start))
+	if !floats.EqualApprox(final.SC.EmpiricalAcc, exp, 1e-15) {
+		t.Fatalf("empirical accelerations %+v instead of %+v", final.SC.EmpiricalAcc, exp)
+	}
+	if r, c := final.Φ.Dims(); r != 9 || c != 9 {
+		t.Fatalf("STM is %dx%d", r, c)
+	}
+	β := 1 / d.Tau.Seconds()
+	e := math.Exp(-β * 10)
+	for i := 0; i < 3; i++ {
+		if !floats.EqualWithinRel(final.Φ.At(6+i, 6+i), e, 1e-9) {
+			t.Fatalf("Φ[%d,%d] = %f instead of %f", 6+i, 6+i, final.Φ.At(6+i, 6+i), e)
+		}
+		if !floats.EqualWithinRel(final.Φ.At(3+i, 6+i), (1-e)/β, 1e-3) {
+			t.Fatalf("Φ[%d,%d] = %f instead of %f", 3+i, 6+i, final.Φ.At(3+i, 6+i), (1-e)/β)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// dragMakeupSamples is the number of samples of the osculating semi-major axis averaged over each check interval.
+const dragMakeupSamples = 24
+
+// DragMakeup simulates the altitude maintenance of a low orbit under drag. It monitors the mean semi-major axis
+// and executes tangential make-up burns to raise it back to the top of the deadband once it decays below it.
+// The perturbations are those of the provided Mission (e.g. J2 and AtmosphericDrag).
+type DragMakeup struct {
+	SMA            float64       // Target mean semi-major axis (km), zero to maintain the initial one
+	Deadband       float64       // Half width of the semi-major axis deadband (km)
+	CheckInterval  time.Duration // Interval over which the semi-major axis is averaged, one orbit if zero
+	Maneuvers      []DragMakeupManeuver
+	startDT, endDT time.Time
+}
+
+// DragMakeupManeuver stores an executed make-up maneuver, performed in two equal burns half an orbit apart to keep
+// the orbit circular.
+type DragMakeupManeuver struct {
+	DT      time.Time // Epoch of the first burn
+	MeanSMA float64   // Mean semi-major axis before the maneuver (km)
+	Δv      float64   // Total tangential Δv in km/s
+}
+
+func (m DragMakeupManeuver) String() string {
+	return fmt.Sprintf("make-up burn of %.3f m/s @ %s (mean a=%.3f km)", m.Δv*1e3, m.DT, m.MeanSMA)
+}
+
+// NewDragMakeup returns a new drag make-up controller which averages the semi-major axis over one orbit.
+func NewDragMakeup(SMA, deadband float64) *DragMakeup {
+	if deadband <= 0 {
+		panic("deadband must be strictly positive")
+	}
+	return &DragMakeup{SMA, deadband, 0, nil, time.Time{}, time.Time{}}
+}
+
+// Run propagates the mission until its StopDT and performs the make-up maneuvers. Blocking.
+func (dm *DragMakeup) Run(m *Mission) {
+	dm.startDT = m.CurrentDT
+	dm.endDT = m.StopDT
+	interval := dm.CheckInterval
+	if interval == 0 {
+		interval = m.Orbit.Period()
+	}
+	for {
+		// Average the osculating semi-major axis to remove the short periodic oscillations (e.g. due to J2).
+		var meanSMA float64
+		for k := 0; k < dragMakeupSamples; k++ {
+			dt := m.CurrentDT.Add(interval / dragMakeupSamples)
+			if !dt.Before(dm.endDT) {
+				m.PropagateUntil(dm.endDT, true)
+				return
+			}
+			m.PropagateUntil(dt, false)
+			a, _, _, _, _, _, _, _, _ := m.Orbit.Elements()
+			meanSMA += a / dragMakeupSamples
+		}
+		if dm.SMA == 0 {
+			dm.SMA = meanSMA
+		}
+		if meanSMA < dm.SMA-dm.Deadband {
+			dm.makeup(m, meanSMA)
+		}
+	}
+}
+
+// makeup raises the mean semi-major axis to the top of the deadband with two tangential burns half an orbit apart.
+func (dm *DragMakeup) makeup(m *Mission, meanSMA float64) {
+	// For a near circular orbit, the semi-major axis changes by 2Δv/n.
+	n := math.Sqrt(m.Orbit.Origin.μ / math.Pow(meanSMA, 3))
+	Δv := n * (dm.SMA + dm.Deadband - meanSMA) / 2
+	maneuver := DragMakeupManeuver{m.CurrentDT, meanSMA, Δv}
+	dm.Maneuvers = append(dm.Maneuvers, maneuver)
+	m.Vehicle.logger.Log("level", "notice", "subsys", "astro", "date", m.CurrentDT, "drag make-up", maneuver)
+	dm.apply(m, Δv/2)
+	if dt := m.CurrentDT.Add(m.Orbit.Period() / 2); dt.Before(dm.endDT) {
+		m.PropagateUntil(dt, false)
+		dm.apply(m, Δv/2)
+	}
+}
+
+func (dm *DragMakeup) apply(m *Mission, Δv float64) {
+	R, V := m.Orbit.RV()
+	vUnit := Unit(V)
+	for i := 0; i < 3; i++ {
+		V[i] += Δv * vUnit[i]
+	}
+	*m.Orbit = *NewOrbitFromRV(R, V, m.Orbit.Origin)
+}
+
+// TotalΔv returns the total make-up Δv in m/s.
+func (dm *DragMakeup) TotalΔv() (Δv float64) {
+	for _, m := range dm.Maneuvers {
+		Δv += m.Δv * 1e3
+	}
+	return
+}
+
+// AnnualΔv returns the make-up Δv in m/s per year over the simulated duration.
+func (dm *DragMakeup) AnnualΔv() float64 {
+	years := dm.endDT.Sub(dm.startDT).Hours() / (24 * 365.25)
+	if years <= 0 {
+		return 0
+	}
+	return dm.TotalΔv() / years
+}
+
+// Frequency returns the mean interval between two make-up maneuvers over the simulated duration, or zero if
+// no maneuver was needed.
+func (dm *DragMakeup) Frequency() time.Duration {
+	if len(dm.Maneuvers) == 0 {
+		return 0
+	}
+	return dm.endDT.Sub(dm.startDT) / time.Duration(len(dm.Maneuvers))
+}
@@ -0,0 +1,48 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDragMakeup(t *testing.T) {
+	start := time.Date(2017, 3, 20, 0, 0, 0, 0, time.UTC)
+	end := start.Add(3 * 24 * time.Hour)
+	sc := NewEmptySC("leo", 100)
+	sc.Cd = 2.2
+	sc.Area = 10
+	a := Earth.Radius + 400
+	dm := NewDragMakeup(0, 1)
+	o := NewOrbitFromOE(a, 0, 51.6, 0, 0, 0, Earth)
+	m := NewPreciseMission(sc, o, start, end, Perturbations{Jn: 2, Arbitrary: AtmosphericDrag(*sc, ModerateSpaceWeather)}, 10*time.Second, false, ExportConfig{})
+	dm.Run(m)
+	// The mean semi-major axis is below the osculating one because of J2.
+	if dm.SMA >= a || dm.SMA < a-10 {
+		t.Fatalf("invalid initial mean semi-major axis %f km", dm.SMA)
+	}
+	if len(dm.Maneuvers) < 2 {
+		t.Fatalf("expected several make-up maneuvers: %+v", dm.Maneuvers)
+	}
+	for _, maneuver := range dm.Maneuvers {
+		if maneuver.MeanSMA >= dm.SMA-dm.Deadband || maneuver.MeanSMA < dm.SMA-2*dm.Deadband {
+			t.Fatalf("maneuver outside of the deadband: %s", maneuver)
+		}
+	}
+	// Each maneuver raises the semi-major axis by about 1.5 to 2 deadbands, i.e. Δa*n/2.
+	n := math.Sqrt(Earth.μ / math.Pow(a, 3))
+	perManeuver := dm.TotalΔv() / float64(len(dm.Maneuvers))
+	if perManeuver < 1.5*dm.Deadband*n/2*1e3 || perManeuver > 3*dm.Deadband*n/2*1e3 {
+		t.Fatalf("unexpected Δv of %f m/s per maneuver", perManeuver)
+	}
+	if freq := dm.Frequency(); freq <= 0 || freq > end.Sub(start)/2 {
+		t.Fatalf("invalid frequency %s", freq)
+	}
+	if annual := dm.AnnualΔv(); math.Abs(annual-dm.TotalΔv()*365.25/3) > 1e-9 {
+		t.Fatalf("invalid annual Δv %f m/s", annual)
+	}
+	if !m.CurrentDT.Equal(end) {
+		t.Fatalf("mission stopped at %s", m.CurrentDT)
+	}
+	t.Logf("%d maneuvers (every %s) for %.3f m/s (%.3f m/s/yr)", len(dm.Maneuvers), dm.Frequency(), dm.TotalΔv(), dm.AnnualΔv())
+}
@@ -0,0 +1,60 @@
+package smd
+
+import "math"
+
+const (
+	wgs84SemiMajorAxis = 6378.137          // km
+	wgs84Flattening    = 1 / 298.257223563 // unitless
+	// dsnElevationMask is the lowest elevation at which the DSN antennas track, in degrees.
+	dsnElevationMask = 6.0
+)
+
+var (
+	// Typical one sigma noise of the DSN two-way X-band observables: about one meter in sequential ranging and
+	// 0.1 mm/s in Doppler over a sixty second count time. These are variances in km^2 and km^2/s^2.
+	dsnσρ    = math.Pow(1e-3, 2)
+	dsnσρDot = math.Pow(1e-7, 2)
+
+	// Goldstone Deep Space Communications Complex, California.
+	DSS13Goldstone = newDSNStation("DSS13Goldstone", 13, 1.071178, 35.247164, 243.205211)
+	DSS14Goldstone = newDSNStation("DSS14Goldstone", 14, 1.001390, 35.425901, 243.110458) // 70 m
+	DSS24Goldstone = newDSNStation("DSS24Goldstone", 24, 0.951499, 35.339898, 243.125186)
+	DSS25Goldstone = newDSNStation("DSS25Goldstone", 25, 0.959634, 35.337599, 243.124600)
+	DSS26Goldstone = newDSNStation("DSS26Goldstone", 26, 0.968686, 35.335679, 243.127020)
+	// Canberra Deep Space Communication Complex, Australia.
+	DSS34Canberra = newDSNStation("DSS34Canberra", 34, 0.692020, -35.398479, 148.981964)
+	DSS35Canberra = newDSNStation("DSS35Canberra", 35, 0.694899, -35.395939, 148.981485)
+	DSS36Canberra = newDSNStation("DSS36Canberra", 36, 0.685503, -35.395079, 148.978574)
+	DSS43Canberra = newDSNStation("DSS43Canberra", 43, 0.689608, -35.402424, 148.981267) // 70 m
+	// Madrid Deep Space Communications Complex, Spain.
+	DSS54Madrid = newDSNStation("DSS54Madrid", 54, 0.837540, 40.425620, 355.745904)
+	DSS55Madrid = newDSNStation("DSS55Madrid", 55, 0.819473, 40.424314, 355.747393)
+	DSS63Madrid = newDSNStation("DSS63Madrid", 63, 0.865544, 40.431210, 355.752006) // 70 m
+	DSS65Madrid = newDSNStation("DSS65Madrid", 65, 0.834539, 40.427222, 355.749444)
+
+	// DSNStations lists the 34 m and 70 m antennas of all three DSN complexes.
+	DSNStations = []Station{DSS13Goldstone, DSS14Goldstone, DSS24Goldstone, DSS25Goldstone, DSS26Goldstone,
+		DSS34Canberra, DSS35Canberra, DSS36Canberra, DSS43Canberra,
+		DSS54Madrid, DSS55Madrid, DSS63Madrid, DSS65Madrid}
+)
+
+// refreshDSNStations sets the planet of the DSN stations to the Earth, e.g. after a change of its constants.
+func refreshDSNStations() {
+	for _, st := range []*Station{&DSS13Goldstone, &DSS14Goldstone, &DSS24Goldstone, &DSS25Goldstone, &DSS26Goldstone,
+		&DSS34Canberra, &DSS35Canberra, &DSS36Canberra, &DSS43Canberra,
+		&DSS54Madrid, &DSS55Madrid, &DSS63Madrid, &DSS65Madrid} {
+		st.Planet = Earth
+	}
+	for k := range DSNStations {
+		DSNStations[k].Planet = Earth
+	}
+}
+
+// newDSNStation returns a DSN station from its DSS number, geodetic height (in km), latitude and longitude (in
+// degrees), whose noise is re-seeded by SetRandomSeed.
+func newDSNStation(name string, dss int, height, latΦ, longθ float64) Station {
+	st := NewGeodeticStation(name, height, dsnElevationMask, latΦ, longθ, 0, 0)
+	st.DSS = dss
+	st.setNoise(dsnσρ, dsnσρDot, newRegisteredRand())
+	return st
+}
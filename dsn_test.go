@@ -0,0 +1,67 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestGEODETIC2ECEF(t *testing.T) {
+	// On the equator and at the poles, the ellipsoid matches its axes.
+	R := GEODETIC2ECEF(0, 0, 0)
+	if !floats.EqualApprox(R, []float64{wgs84SemiMajorAxis, 0, 0}, 1e-9) {
+		t.Fatalf("equator: %+v", R)
+	}
+	R = GEODETIC2ECEF(1, math.Pi/2, 0)
+	if polar := wgs84SemiMajorAxis * (1 - wgs84Flattening); !floats.EqualApprox(R, []float64{0, 0, polar + 1}, 1e-9) {
+		t.Fatalf("pole: %+v", R)
+	}
+	// Published Earth fixed position of DSS-14 (DSN 810-005, module 301).
+	if !floats.EqualApprox(DSS14Goldstone.R, []float64{-2353.621, -4641.341, 3677.052}, 5e-2) {
+		t.Fatalf("DSS-14 at %+v", DSS14Goldstone.R)
+	}
+}
+
+func TestDSNStations(t *testing.T) {
+	complexes := map[string]Station{"Goldstone": DSS14Goldstone, "Canberra": DSS43Canberra, "Madrid": DSS63Madrid}
+	for _, st := range DSNStations {
+		if !st.Planet.Equals(Earth) {
+			t.Fatalf("%s is not on Earth", st.Name)
+		}
+		// All antennas of a complex are within a few tens of kilometers of its 70 m antenna.
+		ref := complexes[st.Name[5:]]
+		Δ := make([]float64, 3)
+		for i := 0; i < 3; i++ {
+			Δ[i] = st.R[i] - ref.R[i]
+		}
+		if d := Norm(Δ); d > 30 {
+			t.Fatalf("%s is %f km from %s", st.Name, d, ref.Name)
+		}
+		for _, name := range []string{fmt.Sprintf("dss%d", st.DSS), strings.ToLower(st.Name)} {
+			if got := BuiltinStationFromName(name); got.Name != st.Name {
+				t.Fatalf("BuiltinStationFromName(%s) returned %s", name, got.Name)
+			}
+		}
+		if st.RangeNoise == nil || st.RangeRateNoise == nil {
+			t.Fatalf("%s has no noise", st.Name)
+		}
+	}
+	// The zenith of a station is straight above it.
+	up := GEODETIC2ECEF(DSS43Canberra.Altitude+1000, DSS43Canberra.LatΦ, DSS43Canberra.Longθ)
+	if _, ρ, el, _ := DSS43Canberra.RangeElAz(up); math.Abs(el-90) > 1e-6 || math.Abs(ρ-1000) > 1e-6 {
+		t.Fatalf("zenith at el=%f ρ=%f", el, ρ)
+	}
+	for _, name := range []string{"dss99", "dss", "", "DSS43Madrid"} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil || !strings.HasPrefix(fmt.Sprint(r), "unknown station") {
+					t.Fatalf("unknown station `%s` did not panic as such: %v", name, r)
+				}
+			}()
+			BuiltinStationFromName(name)
+		}()
+	}
+}
@@ -0,0 +1,164 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ShadowModel defines the shadow model used to compute the illumination.
+type ShadowModel uint8
+
+const (
+	// ConicalShadow models the umbra and penumbra of the occulting body.
+	ConicalShadow ShadowModel = iota + 1
+	// CylindricalShadow models the shadow as a cylinder of the radius of the occulting body (no penumbra).
+	CylindricalShadow
+)
+
+func (m ShadowModel) String() string {
+	switch m {
+	case ConicalShadow:
+		return "conical"
+	case CylindricalShadow:
+		return "cylindrical"
+	default:
+		panic("unknown shadow model")
+	}
+}
+
+// IlluminationFraction returns the fraction of the solar disk visible from the spacecraft (1 in full Sun, 0 in umbra)
+// when occulted by the provided body. All positions must be in the same frame and in km.
+func IlluminationFraction(rSC, rBody, rSun []float64, body CelestialObject, model ShadowModel) float64 {
+	s := make([]float64, 3) // Body to spacecraft
+	d := make([]float64, 3) // Spacecraft to Sun
+	for i := 0; i < 3; i++ {
+		s[i] = rSC[i] - rBody[i]
+		d[i] = rSun[i] - rSC[i]
+	}
+	switch model {
+	case CylindricalShadow:
+		sunUnit := Unit([]float64{rSun[0] - rBody[0], rSun[1] - rBody[1], rSun[2] - rBody[2]})
+		proj := Dot(s, sunUnit)
+		if proj >= 0 {
+			// On the Sun side of the body.
+			return 1
+		}
+		if math.Sqrt(Dot(s, s)-proj*proj) < body.Radius {
+			return 0
+		}
+		return 1
+	case ConicalShadow:
+		// Apparent radii of the Sun and of the body, and their apparent separation (Montenbruck & Gill 3.4.2).
+		a := math.Asin(Sun.Radius / Norm(d))
+		b := math.Asin(body.Radius / Norm(s))
+		c := math.Acos(math.Max(-1, math.Min(1, -Dot(s, d)/(Norm(s)*Norm(d)))))
+		switch {
+		case c >= a+b:
+			return 1
+		case c <= b-a:
+			return 0
+		case c <= a-b:
+			// Annular eclipse.
+			return 1 - (b*b)/(a*a)
+		}
+		x := (c*c + a*a - b*b) / (2 * c)
+		y := math.Sqrt(a*a - x*x)
+		A := a*a*math.Acos(x/a) + b*b*math.Acos((c-x)/b) - c*y
+		return 1 - A/(math.Pi*a*a)
+	default:
+		panic(fmt.Errorf("unknown shadow model %d", model))
+	}
+}
+
+// Eclipsed returns the illumination fraction of the orbit at the provided epoch when occulted by the provided body,
+// using the conical shadow model. Note that this requires the ephemerides of the origin and of the body.
+func Eclipsed(o Orbit, dt time.Time, body CelestialObject) float64 {
+	rSun := sunPosition(o, dt)
+	rBody := []float64{0, 0, 0}
+	if !body.Equals(o.Origin) {
+		rBodyHelio := MxV33(o.Origin.Ecliptic2Equatorial(dt), body.HelioOrbit(dt).R())
+		for i := 0; i < 3; i++ {
+			rBody[i] = rBodyHelio[i] + rSun[i]
+		}
+	}
+	return IlluminationFraction(o.R(), rBody, rSun, body, ConicalShadow)
+}
+
+// sunPosition returns the position of the Sun with respect to the origin of the orbit, in the frame of the orbit.
+func sunPosition(o Orbit, dt time.Time) []float64 {
+	rSun := MxV33(o.Origin.Ecliptic2Equatorial(dt), o.Origin.HelioOrbit(dt).R())
+	for i := 0; i < 3; i++ {
+		rSun[i] *= -1
+	}
+	return rSun
+}
+
+// EclipseEvent stores an eclipse.
+type EclipseEvent struct {
+	Start, End     time.Time
+	Body           CelestialObject
+	Umbra          bool    // Set to true if the spacecraft was in umbra at any point of this eclipse.
+	MinIlluminated float64 // Minimum illumination fraction during this eclipse.
+}
+
+// Duration returns the duration of this eclipse.
+func (e EclipseEvent) Duration() time.Duration {
+	return e.End.Sub(e.Start)
+}
+
+func (e EclipseEvent) String() string {
+	kind := "penumbra"
+	if e.Umbra {
+		kind = "umbra"
+	}
+	return fmt.Sprintf("%s eclipse by %s from %s to %s (%s)", kind, e.Body.Name, e.Start, e.End, e.Duration())
+}
+
+// EclipseEvents returns the eclipses by the provided body during the provided states. Note that this requires
+// the ephemerides of the body.
+func EclipseEvents(states []State, body CelestialObject) []EclipseEvent {
+	dts := make([]time.Time, len(states))
+	fractions := make([]float64, len(states))
+	for k, state := range states {
+		dts[k] = state.DT
+		fractions[k] = Eclipsed(state.Orbit, state.DT, body)
+	}
+	return eclipseEvents(dts, fractions, body)
+}
+
+func eclipseEvents(dts []time.Time, fractions []float64, body CelestialObject) []EclipseEvent {
+	var events []EclipseEvent
+	var cur *EclipseEvent
+	for k, fraction := range fractions {
+		if fraction < 1 {
+			if cur == nil {
+				cur = &EclipseEvent{Start: dts[k], Body: body, MinIlluminated: 1}
+			}
+			cur.End = dts[k]
+			cur.MinIlluminated = math.Min(cur.MinIlluminated, fraction)
+			cur.Umbra = cur.Umbra || fraction == 0
+		} else if cur != nil {
+			events = append(events, *cur)
+			cur = nil
+		}
+	}
+	if cur != nil {
+		events = append(events, *cur)
+	}
+	return events
+}
+
+// WriteEclipseTable writes the eclipse events as a CSV table.
+func WriteEclipseTable(w io.Writer, events []EclipseEvent) error {
+	if _, err := fmt.Fprint(w, "start,end,durationInMinutes,body,umbra,minIllumination\n"); err != nil {
+		return err
+	}
+	for _, e := range events {
+		if _, err := fmt.Fprintf(w, "%s,%s,%.3f,%s,%v,%.6f\n", e.Start.UTC().Format(time.RFC3339), e.End.UTC().Format(time.RFC3339), e.Duration().Minutes(), e.Body.Name, e.Umbra, e.MinIlluminated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
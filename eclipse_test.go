@@ -0,0 +1,80 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIlluminationFraction(t *testing.T) {
+	rSun := []float64{AU, 0, 0}
+	origin := []float64{0, 0, 0}
+	for _, model := range []ShadowModel{ConicalShadow, CylindricalShadow} {
+		if f := IlluminationFraction([]float64{-7000, 0, 0}, origin, rSun, Earth, model); f != 0 {
+			t.Fatalf("%s: should be in umbra, got %f", model, f)
+		}
+		for _, rSC := range [][]float64{{7000, 0, 0}, {0, 7000, 0}, {-7000, 0, 7000}} {
+			if f := IlluminationFraction(rSC, origin, rSun, Earth, model); f != 1 {
+				t.Fatalf("%s: %+v should be in full Sun, got %f", model, rSC, f)
+			}
+		}
+	}
+	// Penumbra happens just outside of the cylinder with the conical model.
+	penumbra := []float64{-7000, Earth.Radius + 20, 0}
+	if f := IlluminationFraction(penumbra, origin, rSun, Earth, ConicalShadow); f <= 0 || f >= 1 {
+		t.Fatalf("should be in penumbra, got %f", f)
+	}
+	if f := IlluminationFraction(penumbra, origin, rSun, Earth, CylindricalShadow); f != 1 {
+		t.Fatalf("no penumbra in the cylindrical model, got %f", f)
+	}
+	// The illumination must decrease continuously when entering the shadow.
+	prev := 1.0
+	for y := Earth.Radius + 100; y > Earth.Radius-100; y-- {
+		f := IlluminationFraction([]float64{-7000, y, 0}, origin, rSun, Earth, ConicalShadow)
+		if f > prev {
+			t.Fatalf("illumination increased when entering the shadow at y=%f", y)
+		}
+		prev = f
+	}
+	if prev != 0 {
+		t.Fatal("did not reach the umbra")
+	}
+}
+
+func TestEclipseEvents(t *testing.T) {
+	o := NewOrbitFromOE(Earth.Radius+500, 0, 0, 0, 0, 0, Earth)
+	rSun := []float64{AU, 0, 0}
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	var dts []time.Time
+	var fractions []float64
+	period := o.Period()
+	for dt := time.Duration(0); dt < 2*period; dt += 10 * time.Second {
+		ν := 2 * math.Pi * dt.Seconds() / period.Seconds()
+		s, c := math.Sincos(ν)
+		r := o.RNorm()
+		dts = append(dts, start.Add(dt))
+		fractions = append(fractions, IlluminationFraction([]float64{r * c, r * s, 0}, []float64{0, 0, 0}, rSun, Earth, CylindricalShadow))
+	}
+	events := eclipseEvents(dts, fractions, Earth)
+	if len(events) != 2 {
+		t.Fatalf("expected two eclipses, got %d", len(events))
+	}
+	expDuration := period.Seconds() * math.Asin(Earth.Radius/o.RNorm()) / math.Pi
+	for _, e := range events {
+		if !e.Umbra || e.MinIlluminated != 0 {
+			t.Fatalf("invalid eclipse %s", e)
+		}
+		if math.Abs(e.Duration().Seconds()-expDuration) > 20 {
+			t.Fatalf("invalid eclipse duration %s (expected %fs)", e.Duration(), expDuration)
+		}
+	}
+	var buf bytes.Buffer
+	if err := WriteEclipseTable(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[1], "2017-01-01T00:") {
+		t.Fatalf("invalid table:\n%s", buf.String())
+	}
+}
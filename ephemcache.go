@@ -0,0 +1,115 @@
+package smd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// EphemerisCache caches the states of the bodies returned by an EphemerisFunc, and persists them on disk so that
+// subsequent runs (e.g. porkchop plots with adjusted windows) only compute the states they have not seen yet.
+type EphemerisCache struct {
+	Path      string // File of the cache, in CSV
+	ephemeris EphemerisFunc
+	states    map[ephemerisKey][2][]float64
+	added     int
+	mu        sync.Mutex
+}
+
+// NewEphemerisCache returns the cache of the provided ephemeris, loaded from the provided file if it exists.
+func NewEphemerisCache(ephemeris EphemerisFunc, path string) (*EphemerisCache, error) {
+	c := &EphemerisCache{Path: path, ephemeris: ephemeris, states: make(map[ephemerisKey][2][]float64)}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 8
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("ephemeris cache %s: %s", path, err)
+		}
+		epoch, err := strconv.ParseInt(record[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ephemeris cache %s: %s", path, err)
+		}
+		state := make([]float64, 6)
+		for i := range state {
+			if state[i], err = strconv.ParseFloat(record[i+2], 64); err != nil {
+				return nil, fmt.Errorf("ephemeris cache %s: %s", path, err)
+			}
+		}
+		c.states[ephemerisKey{record[0], epoch}] = [2][]float64{state[:3], state[3:]}
+	}
+	return c, nil
+}
+
+// ephemerisKey is the key of the state of a body at an epoch, in nanoseconds since the Unix epoch.
+type ephemerisKey struct {
+	body  string
+	epoch int64
+}
+
+// Ephemeris implements the EphemerisFunc, and only calls the cached ephemeris if the state is not in the cache.
+func (c *EphemerisCache) Ephemeris(body CelestialObject, dt time.Time) (R, V []float64) {
+	key := ephemerisKey{body.Name, dt.UnixNano()}
+	c.mu.Lock()
+	state, cached := c.states[key]
+	c.mu.Unlock()
+	if !cached {
+		R, V = c.ephemeris(body, dt)
+		state = [2][]float64{{R[0], R[1], R[2]}, {V[0], V[1], V[2]}}
+		c.mu.Lock()
+		c.states[key] = state
+		c.added++
+		c.mu.Unlock()
+	}
+	// Copies so that the cached states cannot be modified.
+	return []float64{state[0][0], state[0][1], state[0][2]}, []float64{state[1][0], state[1][1], state[1][2]}
+}
+
+// Len returns the number of states in the cache, and how many of those were added since it was loaded.
+func (c *EphemerisCache) Len() (states, added int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.states), c.added
+}
+
+// Save writes the cache to its file. The states are stored with full precision.
+func (c *EphemerisCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, err := os.Create(c.Path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	for key, state := range c.states {
+		record := []string{key.body, strconv.FormatInt(key.epoch, 10)}
+		for _, vec := range state {
+			for _, val := range vec {
+				record = append(record, strconv.FormatFloat(val, 'g', -1, 64))
+			}
+		}
+		if err := w.Write(record); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
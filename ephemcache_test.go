@@ -0,0 +1,61 @@
+package smd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEphemerisCache(t *testing.T) {
+	epoch := time.Date(2018, 5, 1, 0, 0, 0, 0, time.UTC)
+	ephemeris := hohmannEphemeris(epoch)
+	calls := 0
+	counting := func(body CelestialObject, dt time.Time) (R, V []float64) {
+		calls++
+		return ephemeris(body, dt)
+	}
+	dir, err := ioutil.TempDir("", "smd-ephem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ephem.csv")
+	p := NewPorkchop(Earth, Mars, epoch, epoch.Add(10*24*time.Hour), epoch.Add(200*24*time.Hour), epoch.Add(210*24*time.Hour), TTypeAuto)
+	cache, err := NewEphemerisCache(counting, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Ephemeris = cache.Ephemeris
+	exp := p.Grids()
+	if states, added := cache.Len(); states != 22 || added != 22 || calls != 22 {
+		t.Fatalf("%d states (%d added) after %d calls", states, added, calls)
+	}
+	if err = cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// A re-run with a longer launch window only computes the new launch states.
+	calls = 0
+	if cache, err = NewEphemerisCache(counting, path); err != nil {
+		t.Fatal(err)
+	}
+	p.Ephemeris = cache.Ephemeris
+	p.LaunchEnd = epoch.Add(12 * 24 * time.Hour)
+	g := p.Grids()
+	if calls != 2 {
+		t.Fatalf("%d ephemeris calls instead of two", calls)
+	}
+	for i := range exp.Arrivals {
+		for j := range exp.Launches {
+			if g.C3.At(i, j) != exp.C3.At(i, j) {
+				t.Fatalf("C3[%d,%d] = %f instead of %f with the cache", i, j, g.C3.At(i, j), exp.C3.At(i, j))
+			}
+		}
+	}
+	R, _ := cache.Ephemeris(Earth, epoch)
+	R[0] = 0
+	if R, _ = cache.Ephemeris(Earth, epoch); R[0] == 0 {
+		t.Fatal("cached state modified")
+	}
+}
@@ -2,6 +2,7 @@ package smd
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"time"
 )
@@ -67,3 +68,88 @@ func (t *TimedEPS) Drain(voltage, power uint, dt time.Time) error {
 	return errors.New("charging incomplete")
 
 }
+
+// PowerLoad is a non propulsive load of the EPS, e.g. the bus housekeeping or a payload with a duty cycle.
+type PowerLoad struct {
+	Name   string
+	Power  float64       // Power drawn when on (W)
+	Period time.Duration // Period of the duty cycle, zero for an always on load
+	OnTime time.Duration // Duration during which the load is on at the start of each period
+	Start  time.Time     // Start of the first duty cycle
+}
+
+// Draw returns the power drawn by the load at the provided time.
+func (l PowerLoad) Draw(dt time.Time) float64 {
+	if l.Period <= 0 {
+		return l.Power
+	}
+	if dt.Before(l.Start) {
+		return 0
+	}
+	if dt.Sub(l.Start)%l.Period < l.OnTime {
+		return l.Power
+	}
+	return 0
+}
+
+// LoadedEPS generates a constant power, which is first used by the non propulsive loads: the thrusters only get
+// what is left after housekeeping and payload operations.
+type LoadedEPS struct {
+	Generation float64 // Generated power (W)
+	Loads      []PowerLoad
+	dt         time.Time // Epoch of the current allocation
+	drained    float64   // Power already allocated to the thrusters at that epoch (W)
+}
+
+// NewLoadedEPS returns a new EPS generating the provided power (W) for the provided loads.
+func NewLoadedEPS(generation float64, loads ...PowerLoad) *LoadedEPS {
+	if generation < 0 {
+		panic(fmt.Errorf("negative power generation %f W", generation))
+	}
+	return &LoadedEPS{Generation: generation, Loads: loads}
+}
+
+// Load returns the power drawn by the non propulsive loads at the provided time (W).
+func (e *LoadedEPS) Load(dt time.Time) (load float64) {
+	for _, l := range e.Loads {
+		load += l.Draw(dt)
+	}
+	return
+}
+
+// Available returns the power available to the thrusters at the provided time (W).
+func (e *LoadedEPS) Available(dt time.Time) float64 {
+	available := e.Generation - e.Load(dt)
+	if e.dt.Equal(dt) {
+		available -= e.drained
+	}
+	if available < 0 {
+		return 0
+	}
+	return available
+}
+
+// Drain implements the EPS interface. The thrusters are powered in the order they drain the EPS.
+func (e *LoadedEPS) Drain(voltage, power uint, dt time.Time) error {
+	if available := e.Available(dt); float64(power) > available {
+		return fmt.Errorf("%d W requested but %.1f W available", power, available)
+	}
+	if !e.dt.Equal(dt) {
+		e.allocate(dt)
+	}
+	e.drained += float64(power)
+	return nil
+}
+
+// allocate starts a new allocation of the power to the thrusters.
+func (e *LoadedEPS) allocate(dt time.Time) {
+	e.dt = dt
+	e.drained = 0
+}
+
+// allocatingEPS is an EPS which shares its power between all the thrusters of each call to Accelerate, which
+// happens several times per integration step.
+type allocatingEPS interface {
+	EPS
+	allocate(dt time.Time)
+}
@@ -31,3 +31,38 @@ func TestTimedEPS(t *testing.T) {
 		t.Fatalf("draining EPS after charging fails: %s\n", err)
 	}
 }
+
+func TestLoadedEPS(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	bus := PowerLoad{Name: "bus", Power: 800}
+	payload := PowerLoad{Name: "payload", Power: 1500, Period: 90 * time.Minute, OnTime: 30 * time.Minute, Start: start}
+	eps := NewLoadedEPS(6000, bus, payload)
+	if load := eps.Load(start); load != 2300 {
+		t.Fatalf("load with the payload on: %f W", load)
+	}
+	if load := eps.Load(start.Add(45 * time.Minute)); load != 800 {
+		t.Fatalf("load with the payload off: %f W", load)
+	}
+	if load := eps.Load(start.Add(-time.Hour)); load != 800 {
+		t.Fatalf("load before the payload duty cycle: %f W", load)
+	}
+	// Two PPS1350 need 5 kW: only one fires while the payload is on.
+	wp := NewReachDistance(8000, true, nil)
+	sc := NewSpacecraft("loaded", 500, 100, eps, []EPThruster{new(PPS1350), new(PPS1350)}, false, []*Cargo{}, []Waypoint{wp})
+	o := NewOrbitFromOE(7000, 0, 28.5, 0, 0, 0, Earth)
+	for i := 0; i < 4; i++ {
+		// Accelerate is called several times per integration step.
+		if sc.Accelerate(start, o); sc.Guidance.Throttle != 0.5 {
+			t.Fatalf("call #%d with the payload on: throttle %f", i, sc.Guidance.Throttle)
+		}
+	}
+	if sc.Accelerate(start.Add(45*time.Minute), o); sc.Guidance.Throttle != 1 {
+		t.Fatalf("payload off: throttle %f", sc.Guidance.Throttle)
+	}
+	if err := eps.Drain(350, 2500, start.Add(45*time.Minute)); err == nil {
+		t.Fatal("draining more than the generation does not fail")
+	}
+	assertPanic(t, func() {
+		NewLoadedEPS(-1)
+	})
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"math"
 
 	"github.com/ChristopherRabotin/smd"
 	"github.com/gonum/matrix/mat64"
@@ -42,16 +41,9 @@ func main() {
 	if err != nil {
 		panic(fmt.Errorf("error while solving Lambert: %s", err))
 	}
-	// Compute the c3
-	VInfInit := mat64.NewVector(3, nil)
-	VInfInit.SubVec(ViLaunch, earthVVec)
-	c3 := math.Pow(mat64.Norm(VInfInit, 2), 2)
-	if math.IsNaN(c3) {
-		c3 = 0
-	}
-	// Compute the DLA and RLA at launch
-	rla := smd.Rad2deg(math.Atan2(VInfInit.At(1, 0), VInfInit.At(0, 0)))
-	dla := smd.Rad2deg(math.Atan2(VInfInit.At(2, 0), mat64.Norm(VInfInit, 2)))
+	// Compute the c3, RLA and DLA at launch
+	departure := smd.LambertDeparture(ViLaunch, earthVVec, smd.Earth, launchDT)
+	c3, rla, dla := departure.C3, departure.RLA, departure.DLA
 	// Compute the v_infinity at destination
 	VInfInJGA := mat64.NewVector(3, nil)
 	VInfInJGA.SubVec(jupiterVVec, VfJGA)
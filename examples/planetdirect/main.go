@@ -25,7 +25,7 @@ func sc2Mars(arrivalDT time.Time) *smd.Spacecraft {
 			smd.NewReachDistance(distance, true, ref2Mars),
 			smd.NewLoiter(time.Hour, nil),
 			smd.NewToElliptical(nil),
-			smd.NewOrbitTarget(*hyper, nil, smd.Naasz, smd.OptiΔaCL, smd.OptiΔiCL),
+			smd.NewOrbitTarget(*hyper, nil, smd.Naasz, smd.TimeOptimal, smd.OptiΔaCL, smd.OptiΔiCL),
 			smd.NewLoiter(7*24*time.Hour, nil),
 		})
 }
@@ -48,7 +48,7 @@ func sc2Earth(fuel float64, arrivalDT time.Time) *smd.Spacecraft {
 			smd.NewReachDistance(distance+smd.Earth.SOI, false, ref2Earth),
 			smd.NewLoiter(time.Hour, nil),
 			smd.NewToElliptical(nil),
-			smd.NewOrbitTarget(*hyper, nil, smd.Naasz, smd.OptiΔaCL, smd.OptiΔiCL),
+			smd.NewOrbitTarget(*hyper, nil, smd.Naasz, smd.TimeOptimal, smd.OptiΔaCL, smd.OptiΔiCL),
 			smd.NewLoiter(7*24*time.Hour, nil),
 		})
 }
@@ -31,10 +31,11 @@ type Station struct {
 }
 
 // PerformMeasurement returns whether the SC is visible, and if so, the measurement.
-func (s Station) PerformMeasurement(θgst float64, state smd.State) (bool, Measurement) {
+func (s Station) PerformMeasurement(state smd.State) (bool, Measurement) {
 	// The station vectors are in ECEF, so let's convert the state to ECEF.
-	rECEF := smd.ECI2ECEF(state.Orbit.R(), θgst)
-	vECEF := smd.ECI2ECEF(state.Orbit.V(), θgst)
+	inertial2ECEF := smd.Earth.Inertial2BodyFixed(state.DT)
+	rECEF := smd.MxV33(inertial2ECEF, state.Orbit.R())
+	vECEF := smd.MxV33(inertial2ECEF, state.Orbit.V())
 	// Compute visibility for each station.
 	ρECEF, ρ, el, _ := s.RangeElAz(rECEF)
 	vDiffECEF := make([]float64, 3)
@@ -47,7 +48,7 @@ func (s Station) PerformMeasurement(θgst float64, state smd.State) (bool, Measu
 	ρDotNoisy := ρDot + s.ρDotNoise.Rand(nil)[0]
 	// Add this to the list of measurements
 	// TODO: Change signature
-	return el >= 10, Measurement{el >= 10, ρNoisy, ρDotNoisy, ρ, ρDot, θgst, state, s}
+	return el >= 10, Measurement{el >= 10, ρNoisy, ρDotNoisy, ρ, ρDot, inertial2ECEF, state, s}
 }
 
 // RangeElAz returns the range (in the SEZ frame), elevation and azimuth (in degrees) of a given R vector in ECEF.
@@ -82,10 +83,10 @@ func NewStation(name string, altitude, latΦ, longθ, σρ, σρDot float64) Sta
 
 // Measurement stores a measurement of a station.
 type Measurement struct {
-	Visible         bool    // Stores whether or not the attempted measurement was visible from the station.
-	ρ, ρDot         float64 // Store the range and range rate
-	trueρ, trueρDot float64 // Store the true range and range rate
-	θgst            float64
+	Visible         bool         // Stores whether or not the attempted measurement was visible from the station.
+	ρ, ρDot         float64      // Store the range and range rate
+	trueρ, trueρDot float64      // Store the true range and range rate
+	inertial2ECEF   *mat64.Dense // Earth fixed frame at the epoch of the measurement
 	State           smd.State
 	Station         Station
 }
@@ -102,8 +103,8 @@ func (m Measurement) Observation() *mat64.Vector {
 
 // HTilde returns the H tilde matrix for this given measurement.
 func (m Measurement) HTilde() *mat64.Dense {
-	stationR := smd.ECEF2ECI(m.Station.R, m.θgst)
-	stationV := smd.ECEF2ECI(m.Station.V, m.θgst)
+	stationR := smd.MxV33(m.inertial2ECEF.T(), m.Station.R)
+	stationV := smd.MxV33(m.inertial2ECEF.T(), m.Station.V)
 	xS := stationR[0]
 	yS := stationR[1]
 	zS := stationR[2]
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"time"
 
 	"github.com/ChristopherRabotin/smd"
@@ -12,15 +11,16 @@ func main() {
 	export := smd.ExportConfig{Filename: "hw0", Cosmo: false, AsCSV: true, Timestamp: false}
 	ξ0 := osc.Energyξ()
 	prevV := osc.VNorm()
-	export.CSVAppendHdr = func() string {
-		return "energy,r,v,acc"
-	}
-	export.CSVAppend = func(st smd.State) string {
-		// Energy, |r|, |v|, |acc|
-		acc := st.Orbit.VNorm() - prevV
-		prevV = st.Orbit.VNorm()
-		return fmt.Sprintf("%.15f,%.3f,%.6f,%.6f", st.Orbit.Energyξ()-ξ0, st.Orbit.RNorm(), st.Orbit.VNorm(), acc)
-	}
+	export.Columns = []smd.CSVColumnProvider{smd.CSVColumns{
+		{Name: "energy", Unit: "km²/s²", Extract: func(st smd.State) float64 { return st.Orbit.Energyξ() - ξ0 }},
+		{Name: "r", Unit: "km", Extract: func(st smd.State) float64 { return st.Orbit.RNorm() }},
+		{Name: "v", Unit: "km/s", Extract: func(st smd.State) float64 { return st.Orbit.VNorm() }},
+		{Name: "acc", Unit: "km/s", Extract: func(st smd.State) float64 {
+			acc := st.Orbit.VNorm() - prevV
+			prevV = st.Orbit.VNorm()
+			return acc
+		}},
+	}}
 	start := time.Now().UTC()
 	smd.NewMission(smd.NewEmptySC("hw", 0), osc, start, start.Add(osc.Period()*2), smd.Perturbations{}, false, export).Propagate()
 }
@@ -28,9 +28,10 @@ func main() {
 	hyp := smd.NewOrbitFromRV(R, V, smd.Earth)
 
 	// Define the stations
-	st1 := NewStation("st1", 0, -35.398333, 148.981944)
-	st2 := NewStation("st2", 0, 40.427222, 355.749444)
-	st3 := NewStation("st3", 0, 35.247164, 243.205)
+	// DSN antennas DSS-34 (Canberra), DSS-65 (Madrid) and DSS-13 (Goldstone).
+	st1 := NewStation("st1", 0.692020, -35.398479, 148.981964)
+	st2 := NewStation("st2", 0.834539, 40.427222, 355.749444)
+	st3 := NewStation("st3", 1.071178, 35.247164, 243.205211)
 	stations := []Station{st1, st2, st3}
 
 	// Define the Doppler shift stuff.
@@ -54,20 +55,15 @@ func main() {
 
 		// Define the special export functions
 		export := smd.ExportConfig{Filename: tcase.name, Cosmo: false, AsCSV: true, Timestamp: false}
-		export.CSVAppendHdr = func() string {
-			hdr := "secondsSinceEpoch,"
-			for _, st := range stations {
-				hdr += fmt.Sprintf("%sRange,%sRangeRate,%sDplr,", st.name, st.name, st.name)
-			}
-			return hdr[:len(hdr)-1] // Remove trailing comma
-		}
-		export.CSVAppend = func(state smd.State) string {
-			str := fmt.Sprintf("%f,", state.DT.Sub(startDT).Seconds())
-			θgst := state.DT.Sub(startDT).Seconds() * smd.EarthRotationRate
-			rECEF := smd.ECI2ECEF(state.Orbit.R(), θgst)
-			vECEF := smd.ECI2ECEF(state.Orbit.V(), θgst)
+		// The range, range rate and Doppler shift of all stations are computed when exporting the first column.
+		current := make(map[string][3]float64)
+		export.Columns = []smd.CSVColumnProvider{smd.CSVColumn{Name: "secondsSinceEpoch", Unit: "s", Extract: func(state smd.State) float64 {
+			inertial2ECEF := smd.Earth.Inertial2BodyFixed(state.DT)
+			rECEF := smd.MxV33(inertial2ECEF, state.Orbit.R())
+			vECEF := smd.MxV33(inertial2ECEF, state.Orbit.V())
 			// Compute visibility for each station.
 			for _, st := range stations {
+				delete(current, st.name)
 				ρECEF, ρ, el, _ := st.RangeElAz(rECEF)
 				if el >= 10 {
 					vDiffECEF := make([]float64, 3)
@@ -77,12 +73,22 @@ func main() {
 					// SC is visible.
 					ρDot := mat64.Dot(mat64.NewVector(3, ρECEF), mat64.NewVector(3, vDiffECEF)) + noise.Rand(nil)[0]
 					shift := -2 * ρDot * fRef / celerity
-					str += fmt.Sprintf("%f,%f,%f,", ρ, ρDot, shift)
-				} else {
-					str += ",,,"
+					current[st.name] = [3]float64{ρ, ρDot, shift}
 				}
 			}
-			return str[:len(str)-1] // Remove trailing comma
+			return state.DT.Sub(startDT).Seconds()
+		}}}
+		for _, st := range stations {
+			name := st.name
+			for k, col := range []struct{ suffix, unit string }{{"Range", "km"}, {"RangeRate", "km/s"}, {"Dplr", "Hz"}} {
+				k := k
+				export.Columns = append(export.Columns, smd.CSVColumn{Name: name + col.suffix, Unit: col.unit, Extract: func(smd.State) float64 {
+					if values, visible := current[name]; visible {
+						return values[k]
+					}
+					return math.NaN()
+				}})
+			}
 		}
 
 		// Generate the orbits
@@ -41,40 +41,50 @@ func main() {
 	leo := smd.NewOrbitFromOE(7000, 0.001, 30, 80, 40, 0, smd.Earth)
 
 	// Define the stations
-	σρ := math.Pow(1e-3, 2)    // m , but all measurements in km.
-	σρDot := math.Pow(1e-3, 2) // m/s , but all measurements in km/s.
-	st1 := NewStation("st1", 0, -35.398333, 148.981944, σρ, σρDot)
-	st2 := NewStation("st2", 0, 40.427222, 355.749444, σρ, σρDot)
-	st3 := NewStation("st3", 0, 35.247164, 243.205, σρ, σρDot)
+	σρ := math.Pow(float64(smd.Meters(1).Kilometers()), 2)        // Variance of 1 m, in km² like the measurements.
+	σρDot := math.Pow(float64(smd.MetersPerSec(1).KmPerSec()), 2) // Variance of 1 m/s, in km²/s².
+	// DSN antennas DSS-34 (Canberra), DSS-65 (Madrid) and DSS-13 (Goldstone).
+	st1 := NewStation("st1", 0.692020, -35.398479, 148.981964, σρ, σρDot)
+	st2 := NewStation("st2", 0.834539, 40.427222, 355.749444, σρ, σρDot)
+	st3 := NewStation("st3", 1.071178, 35.247164, 243.205211, σρ, σρDot)
 	stations := []Station{st1, st2, st3}
 
 	// Vector of measurements
 	measurements := []Measurement{}
 
-	// Define the special export functions
 	export := smd.ExportConfig{Filename: "LEO", Cosmo: false, AsCSV: true, Timestamp: false}
-	export.CSVAppendHdr = func() string {
-		hdr := "secondsSinceEpoch,"
-		for _, st := range stations {
-			hdr += fmt.Sprintf("%sRange,%sRangeRate,%sNoisyRange,%sNoisyRangeRate,", st.name, st.name, st.name, st.name)
-		}
-		return hdr[:len(hdr)-1] // Remove trailing comma
-	}
-	export.CSVAppend = func(state smd.State) string {
+	// Define the special export columns: the measurements of all stations are performed when exporting the first one.
+	var current map[string]Measurement
+	export.Columns = []smd.CSVColumnProvider{smd.CSVColumn{Name: "secondsSinceEpoch", Unit: "s", Extract: func(state smd.State) float64 {
 		Δt := state.DT.Sub(startDT).Seconds()
-		str := fmt.Sprintf("%f,", Δt)
-		θgst := Δt * smd.EarthRotationRate
+		current = make(map[string]Measurement)
 		// Compute visibility for each station.
 		for _, st := range stations {
-			_, measurement := st.PerformMeasurement(θgst, state)
+			_, measurement := st.PerformMeasurement(state)
 			if measurement.Visible {
 				measurements = append(measurements, measurement)
-				str += measurement.CSV()
-			} else {
-				str += ",,,,"
+				current[st.name] = measurement
 			}
 		}
-		return str[:len(str)-1] // Remove trailing comma
+		return Δt
+	}}}
+	// visible returns the extractor of a measurement value, or NaN if the station does not see the spacecraft.
+	visible := func(name string, value func(m Measurement) float64) func(smd.State) float64 {
+		return func(smd.State) float64 {
+			if m, ok := current[name]; ok {
+				return value(m)
+			}
+			return math.NaN()
+		}
+	}
+	for _, st := range stations {
+		name := st.name
+		export.Columns = append(export.Columns, smd.CSVColumns{
+			{Name: name + "Range", Unit: "km", Extract: visible(name, func(m Measurement) float64 { return m.trueρ })},
+			{Name: name + "RangeRate", Unit: "km/s", Extract: visible(name, func(m Measurement) float64 { return m.trueρDot })},
+			{Name: name + "NoisyRange", Unit: "km", Extract: visible(name, func(m Measurement) float64 { return m.ρ })},
+			{Name: name + "NoisyRangeRate", Unit: "km/s", Extract: visible(name, func(m Measurement) float64 { return m.ρDot })},
+		})
 	}
 
 	// Generate the perturbed orbit
@@ -210,7 +220,7 @@ func main() {
 		}
 
 		// Compute "real" measurement
-		vis, computedObservation := measurement.Station.PerformMeasurement(measurement.θgst, orbitEstimate.State())
+		vis, computedObservation := measurement.Station.PerformMeasurement(orbitEstimate.State())
 		if !vis {
 			fmt.Printf("[WARNING] station %s should see the SC but does not\n", measurement.Station.name)
 			visibilityErrors++
@@ -44,32 +44,26 @@ func main() {
 	// Define the stations
 	σρ := math.Pow(1e-3, 2)    // m , but all measurements in km.
 	σρDot := math.Pow(1e-3, 2) // m/s , but all measurements in km/s.
-	st1 := smd.NewStation("st1", 0, 10, -35.398333, 148.981944, σρ, σρDot)
-	st2 := smd.NewStation("st2", 0, 10, 40.427222, 355.749444, σρ, σρDot)
-	st3 := smd.NewStation("st3", 0, 10, 35.247164, 243.205, σρ, σρDot)
-	stations := []smd.Station{st1, st2, st3}
+	stations := []smd.Station{smd.DSS34Canberra, smd.DSS65Madrid, smd.DSS13Goldstone}
+	for i := range stations {
+		stations[i] = stations[i].WithNoise(σρ, σρDot, int64(i))
+		stations[i].Elevation = 10
+	}
 
 	measurements := make(map[time.Time]smd.Measurement)
 	measurementTimes := []time.Time{}
 	numMeasurements := 0 // Easier to count them here than to iterate the map to count.
 
-	// Define the special export functions
 	export := smd.ExportConfig{Filename: "LEO", Cosmo: false, AsCSV: true, Timestamp: false}
-	export.CSVAppendHdr = func() string {
-		hdr := "secondsSinceEpoch,"
-		for _, st := range stations {
-			hdr += fmt.Sprintf("%sRange,%sRangeRate,%sNoisyRange,%sNoisyRangeRate,", st.Name, st.Name, st.Name, st.Name)
-		}
-		return hdr[:len(hdr)-1] // Remove trailing comma
-	}
-	export.CSVAppend = func(state smd.State) string {
+	// Define the special export columns: the measurements of all stations are performed when exporting the first one.
+	var current map[string]smd.Measurement
+	export.Columns = []smd.CSVColumnProvider{smd.CSVColumn{Name: "secondsSinceEpoch", Unit: "s", Extract: func(state smd.State) float64 {
 		Δt := state.DT.Sub(startDT).Seconds()
-		str := fmt.Sprintf("%f,", Δt)
-		θgst := Δt * smd.EarthRotationRate
+		current = make(map[string]smd.Measurement)
 		roundedDT := state.DT.Truncate(time.Second)
 		// Compute visibility for each station.
 		for _, st := range stations {
-			measurement := st.PerformMeasurement(θgst, state)
+			measurement := st.Measure(state)
 			if measurement.Visible {
 				// Sanity check
 				if _, exists := measurements[roundedDT]; exists {
@@ -78,12 +72,28 @@ func main() {
 				measurements[roundedDT] = measurement
 				measurementTimes = append(measurementTimes, roundedDT)
 				numMeasurements++
-				str += measurement.CSV()
-			} else {
-				str += ",,,,"
+				current[st.Name] = measurement
 			}
 		}
-		return str[:len(str)-1] // Remove trailing comma
+		return Δt
+	}}}
+	// visible returns the extractor of a measurement value, or NaN if the station does not see the spacecraft.
+	visible := func(name string, value func(m smd.Measurement) float64) func(smd.State) float64 {
+		return func(smd.State) float64 {
+			if m, ok := current[name]; ok {
+				return value(m)
+			}
+			return math.NaN()
+		}
+	}
+	for _, st := range stations {
+		name := st.Name
+		export.Columns = append(export.Columns, smd.CSVColumns{
+			{Name: name + "Range", Unit: "km", Extract: visible(name, func(m smd.Measurement) float64 { return m.TrueRange })},
+			{Name: name + "RangeRate", Unit: "km/s", Extract: visible(name, func(m smd.Measurement) float64 { return m.TrueRangeRate })},
+			{Name: name + "NoisyRange", Unit: "km", Extract: visible(name, func(m smd.Measurement) float64 { return m.Range })},
+			{Name: name + "NoisyRangeRate", Unit: "km/s", Extract: visible(name, func(m smd.Measurement) float64 { return m.RangeRate })},
+		})
 	}
 
 	// Generate the true orbit -- Mtrue
@@ -237,7 +247,7 @@ func main() {
 		}
 
 		// Compute "real" measurement
-		computedObservation := measurement.Station.PerformMeasurement(measurement.Timeθgst, state)
+		computedObservation := measurement.Station.Measure(state)
 		if !computedObservation.Visible {
 			fmt.Printf("[WARN] station %s should see the SC but does not\n", measurement.Station.Name)
 			visibilityErrors++
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -200,14 +201,32 @@ func createAsCSVCSVFile(filename string, conf ExportConfig, stateDT time.Time) *
 	f.WriteString(fmt.Sprintf(`# Creation date (UTC): %s
 # Records are a, e, i, Ω, ω, ν. All angles are in degrees.
 #   Simulation time start (UTC): %s
-time,a,e,i,Omega,omega,nu,fuel,timeInHours,timeInDays,`, time.Now(), stateDT.UTC()))
-	if conf.CSVAppendHdr != nil {
-		// Append the headers for the appended columns.
-		f.WriteString(conf.CSVAppendHdr())
+`, time.Now(), stateDT.UTC()))
+	for _, col := range conf.csvColumns() {
+		if col.Unit != "" {
+			f.WriteString(fmt.Sprintf("#   Unit of %s: %s\n", col.Name, col.Unit))
+		}
+	}
+	f.WriteString(fmt.Sprintf(`time,a,e,i,Omega,omega,nu,fuel,timeInHours,timeInDays`))
+	// Append the headers of the custom columns.
+	for _, col := range conf.csvColumns() {
+		f.WriteString("," + col.Name)
 	}
 	return f
 }
 
+// csvRow returns the CSV row of the state, where first is the first state of this file.
+func csvRow(state, first State, columns []CSVColumn) string {
+	a, e, i, Ω, ω, ν, _, _, _ := state.Orbit.Elements()
+	deltaT := state.DT.Sub(first.DT)
+	days := deltaT.Hours() / 24
+	row := fmt.Sprintf("%s,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f", state.DT.UTC().Format("2006-01-02 15:04:05"), a, e, Rad2deg180(i), Rad2deg180(Ω), Rad2deg180(ω), Rad2deg180(ν), first.SC.FuelMass, deltaT.Hours(), days)
+	for _, col := range columns {
+		row += "," + col.format(state)
+	}
+	return row
+}
+
 // StreamStates streams the output of the channel to the provided file.
 func StreamStates(conf ExportConfig, stateChan <-chan (State)) {
 	// Read from channel
@@ -237,6 +256,7 @@ func StreamStates(conf ExportConfig, stateChan <-chan (State)) {
 		}
 	}()
 
+	columns := conf.csvColumns()
 	color := []float64{0.1, 0.1, 1}
 	for {
 		state, more := <-stateChan
@@ -311,11 +331,7 @@ func StreamStates(conf ExportConfig, stateChan <-chan (State)) {
 					}
 
 					if conf.AsCSV {
-						a, e, i, Ω, ω, ν, _, _, _ := state.Orbit.Elements()
-						deltaT := state.DT.Sub(firstStatePtr.DT)
-						days := deltaT.Hours() / 24
-						asTxt := fmt.Sprintf("%s,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f", state.DT.UTC().Format("2006-01-02 15:04:05"), a, e, Rad2deg180(i), Rad2deg180(Ω), Rad2deg180(ω), Rad2deg180(ν), firstStatePtr.SC.FuelMass, deltaT.Hours(), days)
-						if _, err := fAsCSV.WriteString("\n" + asTxt); err != nil {
+						if _, err := fAsCSV.WriteString("\n" + csvRow(state, *firstStatePtr, columns)); err != nil {
 							panic(err)
 						}
 					}
@@ -334,14 +350,7 @@ func StreamStates(conf ExportConfig, stateChan <-chan (State)) {
 				}
 			}
 			if conf.AsCSV {
-				a, e, i, Ω, ω, ν, _, _, _ := state.Orbit.Elements()
-				deltaT := state.DT.Sub(firstStatePtr.DT)
-				days := deltaT.Hours() / 24
-				asTxt := fmt.Sprintf("%s,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f", state.DT.UTC().Format("2006-01-02 15:04:05"), a, e, Rad2deg180(i), Rad2deg180(Ω), Rad2deg180(ω), Rad2deg180(ν), firstStatePtr.SC.FuelMass, deltaT.Hours(), days)
-				if conf.CSVAppend != nil {
-					asTxt += "," + conf.CSVAppend(state)
-				}
-				if _, err := fAsCSV.WriteString("\n" + asTxt); err != nil {
+				if _, err := fAsCSV.WriteString("\n" + csvRow(state, *firstStatePtr, columns)); err != nil {
 					panic(err)
 				}
 			}
@@ -368,15 +377,65 @@ func StreamStates(conf ExportConfig, stateChan <-chan (State)) {
 
 // ExportConfig configures the exporting of the simulation.
 type ExportConfig struct {
-	Filename     string
-	Cosmo        bool
-	AsCSV        bool
-	Timestamp    bool
-	CSVAppend    func(st State) string // Custom export (do not include leading comma)
-	CSVAppendHdr func() string         // Header for the custom export
+	Filename  string
+	Cosmo     bool
+	AsCSV     bool
+	Timestamp bool
+	Columns   []CSVColumnProvider // Custom CSV columns, appended after the orbital elements
 }
 
 // IsUseless returns whether this config doesn't actually do anything.
 func (c ExportConfig) IsUseless() bool {
 	return !c.Cosmo && !c.AsCSV
 }
+
+// csvColumns returns the custom columns of all the providers, in order.
+func (c ExportConfig) csvColumns() []CSVColumn {
+	var columns []CSVColumn
+	for _, provider := range c.Columns {
+		columns = append(columns, provider.CSVColumns()...)
+	}
+	return columns
+}
+
+// CSVColumnProvider provides custom columns of the CSV export, e.g. measurements, power or events.
+type CSVColumnProvider interface {
+	CSVColumns() []CSVColumn
+}
+
+// CSVColumn is a custom column of the CSV export.
+type CSVColumn struct {
+	Name    string
+	Unit    string                 // Documented in the header of the file if set
+	Extract func(st State) float64 // NaN values are exported as empty cells, e.g. when no measurement is available
+	Text    func(st State) string  // Exports a text column instead of Extract if set, e.g. for names
+}
+
+// CSVColumns returns this column, i.e. a single column is a provider.
+func (c CSVColumn) CSVColumns() []CSVColumn {
+	return []CSVColumn{c}
+}
+
+// format returns the value of this column for the provided state.
+func (c CSVColumn) format(st State) string {
+	if c.Text != nil {
+		text := c.Text(st)
+		if strings.ContainsAny(text, ",\"\n") {
+			return `"` + strings.Replace(text, `"`, `""`, -1) + `"`
+		}
+		return text
+	}
+	v := c.Extract(st)
+	if math.IsNaN(v) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// CSVColumns is a fixed list of custom columns.
+type CSVColumns []CSVColumn
+
+// CSVColumns implements the CSVColumnProvider interface.
+func (c CSVColumns) CSVColumns() []CSVColumn {
+	return c
+}
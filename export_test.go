@@ -2,7 +2,10 @@ package smd
 
 import (
 	"encoding/json"
+	"math"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestBodyFrame(t *testing.T) {
@@ -205,3 +208,28 @@ func TestInterpolatedStatesImport(t *testing.T) {
 	}
 
 }
+
+func TestCSVColumns(t *testing.T) {
+	o := NewOrbitFromOE(7000, 0, 0, 0, 0, 0, Earth)
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := State{start, *NewEmptySC("csv", 10), *o, nil, nil}
+	radius := CSVColumn{Name: "r", Unit: "km", Extract: func(st State) float64 {
+		return st.Orbit.RNorm()
+	}}
+	visible := CSVColumns{
+		{Name: "range", Unit: "km", Extract: func(st State) float64 { return 1234.5 }},
+		{Name: "rangeRate", Unit: "km/s", Extract: func(st State) float64 { return math.NaN() }},
+	}
+	conf := ExportConfig{Columns: []CSVColumnProvider{radius, visible}}
+	columns := conf.csvColumns()
+	if len(columns) != 3 || columns[0].Name != "r" || columns[2].Unit != "km/s" {
+		t.Fatalf("invalid columns %+v", columns)
+	}
+	row := csvRow(State{start.Add(time.Hour), first.SC, *o, nil, nil}, first, columns)
+	if !strings.HasSuffix(row, ",1.000,0.042,7000,1234.5,") || strings.Count(row, ",") != 9+3 {
+		t.Fatalf("invalid row %s", row)
+	}
+	if row := csvRow(first, first, nil); strings.HasSuffix(row, ",") {
+		t.Fatalf("trailing comma without custom columns: %s", row)
+	}
+}
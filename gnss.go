@@ -0,0 +1,220 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	// SpeedOfLight in km/s.
+	SpeedOfLight = 299792.458
+	// GPSL1Wavelength is the wavelength of the GPS L1 carrier in km.
+	GPSL1Wavelength = SpeedOfLight / 1575.42e6
+	gpsSMA          = 26559.7
+)
+
+// GNSSSatellite is a navigation satellite on a circular orbit, with a linear clock model.
+type GNSSSatellite struct {
+	PRN                   int
+	Orbit                 Orbit // At the epoch
+	Epoch                 time.Time
+	ClockBias, ClockDrift float64 // s and s/s at the epoch
+}
+
+// NewGPSConstellation returns a simplified GPS constellation of 24 satellites (Walker 55:24/6/1) at the provided
+// epoch, with perfect clocks.
+func NewGPSConstellation(epoch time.Time) []GNSSSatellite {
+	members := WalkerDelta("GPS", 24, 6, 1, gpsSMA, 55, Earth)
+	sats := make([]GNSSSatellite, len(members))
+	for k, member := range members {
+		sats[k] = GNSSSatellite{PRN: k + 1, Orbit: *member.Orbit, Epoch: epoch}
+	}
+	return sats
+}
+
+// RV returns the position and velocity of the satellite at the provided epoch, propagated analytically.
+func (s GNSSSatellite) RV(dt time.Time) (R, V []float64) {
+	R0, V0 := s.Orbit.RV()
+	n := math.Sqrt(s.Orbit.Origin.μ / math.Pow(Norm(R0), 3))
+	sθ, cθ := math.Sincos(n * dt.Sub(s.Epoch).Seconds())
+	R = make([]float64, 3)
+	V = make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		R[i] = R0[i]*cθ + V0[i]/n*sθ
+		V[i] = -R0[i]*n*sθ + V0[i]*cθ
+	}
+	return
+}
+
+// ClockOffset returns the offset of the clock of the satellite (s) at the provided epoch.
+func (s GNSSSatellite) ClockOffset(dt time.Time) float64 {
+	return s.ClockBias + s.ClockDrift*dt.Sub(s.Epoch).Seconds()
+}
+
+// GNSSReceiver simulates the pseudorange and L1 carrier phase measurements of a spacecraft receiver.
+type GNSSReceiver struct {
+	MaskAngle             float64 // Minimum elevation above the local horizontal of the receiver (degrees)
+	GrazingAltitude       float64 // Minimum altitude of the line of sight, e.g. to avoid the ionosphere (km)
+	Epoch                 time.Time
+	ClockBias, ClockDrift float64 // s and s/s at the epoch
+	σPseudorange, σPhase  float64 // km
+	rng                   *rand.Rand
+	ambiguities           map[int]float64 // Carrier phase ambiguity of each tracked satellite (cycles)
+}
+
+// NewGNSSReceiver returns a new receiver with the provided pseudorange and carrier phase noise (km).
+func NewGNSSReceiver(epoch time.Time, maskAngle, σPseudorange, σPhase float64) *GNSSReceiver {
+	return &GNSSReceiver{MaskAngle: maskAngle, Epoch: epoch, σPseudorange: σPseudorange, σPhase: σPhase, rng: newRand(), ambiguities: make(map[int]float64)}
+}
+
+// ClockOffset returns the offset of the clock of the receiver (s) at the provided epoch.
+func (r *GNSSReceiver) ClockOffset(dt time.Time) float64 {
+	return r.ClockBias + r.ClockDrift*dt.Sub(r.Epoch).Seconds()
+}
+
+func (r *GNSSReceiver) normal() float64 {
+	if r.rng == nil {
+		return 0
+	}
+	return r.rng.NormFloat64()
+}
+
+// Measure returns the measurements of all the satellites in view of the receiver at the epoch of the state.
+// A new carrier phase ambiguity is drawn for each satellite which was not tracked at the previous call.
+func (r *GNSSReceiver) Measure(state State, sats []GNSSSatellite) []GNSSMeasurement {
+	rRx := state.Orbit.R()
+	zenith := Unit(rRx)
+	δtRx := r.ClockOffset(state.DT)
+	tracked := make(map[int]float64)
+	var measurements []GNSSMeasurement
+	for _, sat := range sats {
+		// Light time iteration: the signal was emitted τ seconds before its reception.
+		var rSat []float64
+		τ := 0.
+		for iter := 0; iter < 3; iter++ {
+			rSat, _ = sat.RV(state.DT.Add(-time.Duration(τ * 1e9)))
+			τ = Norm([]float64{rSat[0] - rRx[0], rSat[1] - rRx[1], rSat[2] - rRx[2]}) / SpeedOfLight
+		}
+		los := Unit([]float64{rSat[0] - rRx[0], rSat[1] - rRx[1], rSat[2] - rRx[2]})
+		el := Rad2deg180(math.Asin(Dot(los, zenith)))
+		if el < r.MaskAngle || !LineOfSight(rRx, rSat, state.Orbit.Origin.Radius+r.GrazingAltitude) {
+			continue
+		}
+		N, ok := r.ambiguities[sat.PRN]
+		if !ok {
+			N = math.Floor(1e6 * r.uniform())
+		}
+		tracked[sat.PRN] = N
+		ρ := τ * SpeedOfLight
+		clock := SpeedOfLight * (δtRx - sat.ClockOffset(state.DT.Add(-time.Duration(τ*1e9))))
+		m := GNSSMeasurement{PRN: sat.PRN, Range: ρ, Elevation: el, LOS: los, TruePseudorange: ρ + clock, Ambiguity: N, State: state}
+		m.TruePhase = m.TruePseudorange + N*GPSL1Wavelength
+		m.Pseudorange = m.TruePseudorange + r.σPseudorange*r.normal()
+		m.Phase = m.TruePhase + r.σPhase*r.normal()
+		measurements = append(measurements, m)
+	}
+	r.ambiguities = tracked
+	return measurements
+}
+
+func (r *GNSSReceiver) uniform() float64 {
+	if r.rng == nil {
+		return 0
+	}
+	return r.rng.Float64()
+}
+
+// GNSSMeasurement stores the pseudorange and carrier phase measurement of one satellite.
+type GNSSMeasurement struct {
+	PRN                        int
+	Pseudorange, Phase         float64   // km, the phase includes the ambiguity
+	TruePseudorange, TruePhase float64   // km
+	Range                      float64   // Geometric range (km)
+	Elevation                  float64   // degrees
+	LOS                        []float64 // Unit line of sight from the receiver to the satellite
+	Ambiguity                  float64   // Carrier phase ambiguity (cycles)
+	State                      State
+}
+
+// StateVector returns the pseudorange as a mat64.Vector
+func (m GNSSMeasurement) StateVector() *mat64.Vector {
+	return mat64.NewVector(1, []float64{m.Pseudorange})
+}
+
+// HTilde returns the H tilde matrix of the pseudorange for the state [R, V, cδt, cδtDot] where cδt is the clock
+// offset of the receiver in km.
+func (m GNSSMeasurement) HTilde() *mat64.Dense {
+	return NewAugmentedState(PositionBlock, VelocityBlock, ClockBlock).HTilde(m)
+}
+
+// Partials returns the partials of the pseudorange with respect to the position and the clock of the receiver.
+func (m GNSSMeasurement) Partials() map[StateBlock]*mat64.Dense {
+	return map[StateBlock]*mat64.Dense{
+		PositionBlock: mat64.NewDense(1, 3, []float64{-m.LOS[0], -m.LOS[1], -m.LOS[2]}),
+		ClockBlock:    mat64.NewDense(1, 2, []float64{1, 0}),
+	}
+}
+
+// CSV returns the data as CSV (does *not* include the new line)
+func (m GNSSMeasurement) CSV() string {
+	return fmt.Sprintf("%d,%f,%f,%f,%f,", m.PRN, m.TruePseudorange, m.Pseudorange, m.Phase, m.Elevation)
+}
+
+func (m GNSSMeasurement) String() string {
+	return fmt.Sprintf("PRN%02d@%s", m.PRN, m.State.DT)
+}
+
+// PseudorangeFix returns the position (km) and clock offset (s) of the receiver from at least four pseudoranges,
+// using iterative least squares. The clock offsets of the satellites are corrected from their clock models.
+func PseudorangeFix(measurements []GNSSMeasurement, sats []GNSSSatellite) (R []float64, clockOffset float64, err error) {
+	if len(measurements) < 4 {
+		return nil, 0, fmt.Errorf("%d pseudoranges: at least four are required", len(measurements))
+	}
+	byPRN := make(map[int]GNSSSatellite)
+	for _, sat := range sats {
+		byPRN[sat.PRN] = sat
+	}
+	x := []float64{0, 0, 0, 0} // Position and clock offset in km
+	dt := measurements[0].State.DT
+	for iter := 0; iter < 20; iter++ {
+		n := len(measurements)
+		H := mat64.NewDense(n, 4, nil)
+		y := mat64.NewVector(n, nil)
+		for k, m := range measurements {
+			sat, ok := byPRN[m.PRN]
+			if !ok {
+				return nil, 0, fmt.Errorf("unknown PRN %d", m.PRN)
+			}
+			// The emission epoch is estimated from the pseudorange corrected from the current clock offset estimate.
+			τ := (m.Pseudorange - x[3]) / SpeedOfLight
+			emission := dt.Add(-time.Duration(τ * 1e9))
+			corrected := m.Pseudorange + SpeedOfLight*sat.ClockOffset(emission)
+			rSat, _ := sat.RV(dt.Add(-time.Duration((corrected - x[3]) / SpeedOfLight * 1e9)))
+			δ := []float64{rSat[0] - x[0], rSat[1] - x[1], rSat[2] - x[2]}
+			ρ := Norm(δ)
+			y.SetVec(k, corrected-(ρ+x[3]))
+			for i := 0; i < 3; i++ {
+				H.Set(k, i, -δ[i]/ρ)
+			}
+			H.Set(k, 3, 1)
+		}
+		var Δx mat64.Dense
+		if err := Δx.Solve(H, y); err != nil {
+			return nil, 0, err
+		}
+		step := 0.
+		for i := 0; i < 4; i++ {
+			x[i] += Δx.At(i, 0)
+			step += Δx.At(i, 0) * Δx.At(i, 0)
+		}
+		if math.Sqrt(step) < 1e-9 {
+			return x[:3], x[3] / SpeedOfLight, nil
+		}
+	}
+	return nil, 0, errors.New("pseudorange fix did not converge")
+}
@@ -0,0 +1,74 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestGPSConstellation(t *testing.T) {
+	epoch := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	sats := NewGPSConstellation(epoch)
+	if len(sats) != 24 {
+		t.Fatalf("%d GPS satellites", len(sats))
+	}
+	// After one period, each satellite is back at the same place.
+	sat := sats[5]
+	R0, V0 := sat.RV(epoch)
+	R1, V1 := sat.RV(epoch.Add(sat.Orbit.Period()))
+	if !floats.EqualApprox(R0, R1, 1e-6) || !floats.EqualApprox(V0, V1, 1e-6) {
+		t.Fatalf("invalid analytical propagation:\n%+v\n%+v", R0, R1)
+	}
+	if R, _ := sat.RV(epoch.Add(3 * time.Hour)); !floats.EqualWithinAbs(Norm(R), gpsSMA, 1e-6) {
+		t.Fatalf("GPS satellite not on a circular orbit: %f", Norm(R))
+	}
+}
+
+func TestGNSSReceiver(t *testing.T) {
+	epoch := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	sats := NewGPSConstellation(epoch)
+	sats[3].ClockBias = 1e-5
+	rx := NewGNSSReceiver(epoch, 0, 0, 0)
+	rx.ClockBias = 2e-4
+	rx.ClockDrift = 1e-9
+	o := NewOrbitFromOE(Earth.Radius+500, 0.001, 51.6, 30, 10, 45, Earth)
+	state := State{DT: epoch.Add(10 * time.Minute), Orbit: *o}
+	measurements := rx.Measure(state, sats)
+	if len(measurements) < 6 {
+		t.Fatalf("only %d satellites in view", len(measurements))
+	}
+	for _, m := range measurements {
+		if m.Elevation < 0 {
+			t.Fatalf("%s below the mask: %f", m, m.Elevation)
+		}
+		if cycles := (m.Phase - m.Pseudorange) / GPSL1Wavelength; !floats.EqualWithinAbs(cycles, math.Floor(cycles+0.5), 1e-3) {
+			t.Fatalf("%s: ambiguity is not an integer number of cycles: %f", m, cycles)
+		}
+	}
+	R, clockOffset, err := PseudorangeFix(measurements, sats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floats.EqualApprox(R, o.R(), 1e-6) || !floats.EqualWithinAbs(clockOffset, rx.ClockOffset(state.DT), 1e-12) {
+		t.Fatalf("invalid fix R=%+v (exp. %+v) δt=%e (exp. %e)", R, o.R(), clockOffset, rx.ClockOffset(state.DT))
+	}
+	// The ambiguity of a satellite remains constant while it is tracked.
+	state.DT = state.DT.Add(time.Second)
+	for _, m := range rx.Measure(state, sats) {
+		for _, prev := range measurements {
+			if m.PRN == prev.PRN && m.Ambiguity != prev.Ambiguity {
+				t.Fatalf("ambiguity of PRN %d changed", m.PRN)
+			}
+		}
+	}
+	if _, _, err := PseudorangeFix(measurements[:3], sats); err == nil {
+		t.Fatal("expected an error with three pseudoranges")
+	}
+	// The pseudorange partials are along the line of sight and the clock offset.
+	H := measurements[0].HTilde()
+	if !floats.EqualApprox([]float64{H.At(0, 0), H.At(0, 1), H.At(0, 2)}, []float64{-measurements[0].LOS[0], -measurements[0].LOS[1], -measurements[0].LOS[2]}, 1e-12) || H.At(0, 6) != 1 {
+		t.Fatal("invalid H tilde")
+	}
+}
@@ -0,0 +1,51 @@
+package smd
+
+import "fmt"
+
+// Guidance stores what the guidance of a spacecraft commanded during a step of the propagation, e.g. to correlate
+// the changes of the orbital elements with the control laws when post-processing the exports.
+type Guidance struct {
+	Waypoint    string           // Active waypoint, empty once all of them are cleared
+	ControlLaw  string           // Control law of the active waypoint, e.g. "tan" or "optiΔa"
+	Reason      string           // Reason of the control law, if any
+	Thrust      []float64        // Thrust acceleration in the RIC frame (km/s²)
+	Throttle    float64          // Delivered thrust as a fraction of the maximum thrust of all the thrusters
+	Progress    WaypointProgress // Progress towards the active waypoint, if it reports it
+	ThermalHold bool             // Whether the thrust was inhibited by the thermal limit of the thrusters
+	Heat        float64          // Heat of the thrusters as a fraction of their thermal limit, zero without limit
+	Cooling     bool             // Whether thrusting is forbidden until the thrusters cool down
+}
+
+// Thrusting returns whether the spacecraft was thrusting.
+func (g Guidance) Thrusting() bool {
+	return len(g.Thrust) == 3 && Norm(g.Thrust) > 0
+}
+
+func (g Guidance) String() string {
+	if g.Waypoint == "" {
+		return "no guidance"
+	}
+	if g.ThermalHold {
+		return fmt.Sprintf("%s: %s %s (thermal hold)", g.Waypoint, g.ControlLaw, g.Reason)
+	}
+	return fmt.Sprintf("%s: %s %s (throttle %.1f%%)", g.Waypoint, g.ControlLaw, g.Reason, 100*g.Throttle)
+}
+
+// guidanceThrust returns the component of the thrust of the state, which is zero when no thrust was computed.
+func guidanceThrust(st State, i int) float64 {
+	if len(st.SC.Guidance.Thrust) != 3 {
+		return 0
+	}
+	return st.SC.Guidance.Thrust[i]
+}
+
+// GuidanceColumns are the guidance columns, for use in ExportConfig.Columns.
+var GuidanceColumns = CSVColumns{
+	{Name: "waypoint", Text: func(st State) string { return st.SC.Guidance.Waypoint }},
+	{Name: "controlLaw", Text: func(st State) string { return st.SC.Guidance.ControlLaw }},
+	{Name: "reason", Text: func(st State) string { return st.SC.Guidance.Reason }},
+	{Name: "thrustR", Unit: "km/s^2", Extract: func(st State) float64 { return guidanceThrust(st, 0) }},
+	{Name: "thrustI", Unit: "km/s^2", Extract: func(st State) float64 { return guidanceThrust(st, 1) }},
+	{Name: "thrustC", Unit: "km/s^2", Extract: func(st State) float64 { return guidanceThrust(st, 2) }},
+	{Name: "throttle", Extract: func(st State) float64 { return st.SC.Guidance.Throttle }},
+}
@@ -0,0 +1,53 @@
+package smd
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestGuidance(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	failed := NewDegradedThruster(NewGenericEP(1, 2000), start, 0, 0)
+	failed.Fail(start, time.Time{})
+	wp := NewReachDistance(8000, true, nil)
+	sc := NewSpacecraft("guided", 500, 100, NewUnlimitedEPS(), []EPThruster{NewGenericEP(1, 2000), failed}, false, []*Cargo{}, []Waypoint{wp})
+	o := NewOrbitFromOE(7000, 0, 28.5, 0, 0, 0, Earth)
+	if sc.Guidance.Thrusting() || sc.Guidance.String() != "no guidance" {
+		t.Fatal("guidance before any step")
+	}
+	Δv, _ := sc.Accelerate(start, o)
+	g := sc.Guidance
+	if g.Waypoint != wp.String() || g.ControlLaw != "tan" || !g.Thrusting() {
+		t.Fatalf("invalid guidance %+v", g)
+	}
+	// Only one of the two thrusters fires.
+	if !floats.EqualWithinAbs(g.Throttle, 0.5, 1e-12) {
+		t.Fatalf("throttle %f", g.Throttle)
+	}
+	if !floats.Equal(g.Thrust, Δv) || !floats.EqualWithinAbs(Norm(g.Thrust), 1/sc.Mass(start)/1e3, 1e-15) {
+		t.Fatalf("thrust %+v", g.Thrust)
+	}
+	// The thrust must not alias the returned acceleration.
+	Δv[0] = 1
+	if g.Thrust[0] == 1 {
+		t.Fatal("guidance thrust shares memory with Δv")
+	}
+
+	// Exported columns.
+	row := csvRow(State{DT: start, SC: *sc, Orbit: *o}, State{DT: start, SC: *sc, Orbit: *o}, GuidanceColumns)
+	cells := strings.Split(row, ",")
+	if n := len(cells); cells[n-1] != "0.5" || cells[n-6] != "tan" || cells[n-3] != strconv.FormatFloat(g.Thrust[1], 'f', -1, 64) {
+		t.Fatalf("invalid row %s", row)
+	}
+	if row := csvRow(State{DT: start, Orbit: *o}, State{DT: start, Orbit: *o}, GuidanceColumns); !strings.HasSuffix(row, ",,,,0,0,0,0") {
+		t.Fatalf("invalid row without guidance %s", row)
+	}
+	quoted := CSVColumn{Name: "quoted", Text: func(st State) string { return `a "b", c` }}
+	if row := csvRow(State{DT: start, Orbit: *o}, State{DT: start, Orbit: *o}, []CSVColumn{quoted}); !strings.HasSuffix(row, `,"a ""b"", c"`) {
+		t.Fatalf("invalid quoting %s", row)
+	}
+}
@@ -0,0 +1,94 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// mustBeHyperbolic panics if the orbit is not hyperbolic, and otherwise returns its eccentricity.
+func (o Orbit) mustBeHyperbolic() float64 {
+	_, e, _, _, _, _, _, _, _ := o.Elements()
+	if e <= 1 {
+		panic(fmt.Errorf("orbit is not hyperbolic (e = %f)", e))
+	}
+	return e
+}
+
+// HyperbolicAnomaly returns the hyperbolic anomaly F (radians) of a hyperbolic orbit, negative before periapsis.
+// Panics if the orbit is not hyperbolic.
+func (o Orbit) HyperbolicAnomaly() float64 {
+	e := o.mustBeHyperbolic()
+	_, _, _, _, _, ν, _, _, _ := o.Elements()
+	sinν, cosν := math.Sincos(ν)
+	return math.Asinh(math.Sqrt(e*e-1) * sinν / (1 + e*cosν))
+}
+
+// AsymptoteTrueAnomaly returns the true anomaly (radians) of the outgoing asymptote of a hyperbolic orbit, that of
+// the incoming asymptote being its opposite. Panics if the orbit is not hyperbolic.
+func (o Orbit) AsymptoteTrueAnomaly() float64 {
+	return math.Acos(-1 / o.mustBeHyperbolic())
+}
+
+// VInfNorm returns the norm of the hyperbolic excess velocity (km/s). Panics if the orbit is not hyperbolic.
+func (o Orbit) VInfNorm() float64 {
+	o.mustBeHyperbolic()
+	return math.Sqrt(2 * o.Energyξ())
+}
+
+// Asymptotes returns the unit vectors of the incoming and outgoing asymptotes of a hyperbolic orbit, i.e. the
+// directions of the velocity infinitely before and after the periapsis, in the frame of the orbit. Panics if the
+// orbit is not hyperbolic.
+func (o Orbit) Asymptotes() (in, out []float64) {
+	e := o.mustBeHyperbolic()
+	R, V := o.RV()
+	μ, r, v := o.Origin.μ, Norm(R), Norm(V)
+	// Perifocal frame: P towards the periapsis, Q ninety degrees ahead in the plane of the orbit.
+	P := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		P[i] = ((v*v-μ/r)*R[i] - Dot(R, V)*V[i]) / (μ * e)
+	}
+	Q := Unit(Cross(Unit(o.H()), P))
+	sinν, cosν := math.Sincos(o.AsymptoteTrueAnomaly())
+	in = make([]float64, 3)
+	out = make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		in[i] = -cosν*P[i] + sinν*Q[i]
+		out[i] = cosν*P[i] + sinν*Q[i]
+	}
+	return
+}
+
+// VInf returns the incoming and outgoing hyperbolic excess velocity vectors (km/s) of a hyperbolic orbit, in the
+// frame of the orbit. Panics if the orbit is not hyperbolic.
+func (o Orbit) VInf() (in, out []float64) {
+	vInf := o.VInfNorm()
+	in, out = o.Asymptotes()
+	for i := 0; i < 3; i++ {
+		in[i] *= vInf
+		out[i] *= vInf
+	}
+	return
+}
+
+// TimeFromPeriapsis returns the time since the periapsis passage, negative before the periapsis. It is valid for
+// all conics, using the hyperbolic anomaly for hyperbolic orbits and Barker's equation for parabolic ones.
+func (o Orbit) TimeFromPeriapsis() time.Duration {
+	a, e, _, _, _, ν, _, _, _ := o.Elements()
+	μ := o.Origin.μ
+	var seconds float64
+	switch {
+	case math.Abs(e-1) < eccentricityε:
+		p := o.HNorm() * o.HNorm() / μ
+		D := math.Tan(ν / 2)
+		seconds = math.Sqrt(p*p*p/μ) / 2 * (D + D*D*D/3)
+	case e > 1:
+		F := o.HyperbolicAnomaly()
+		seconds = (e*math.Sinh(F) - F) * math.Sqrt(-a*a*a/μ)
+	default:
+		sinE, cosE := o.SinCosE()
+		E := math.Atan2(sinE, cosE)
+		seconds = (E - e*sinE) * math.Sqrt(a*a*a/μ)
+	}
+	return time.Duration(seconds * 1e9)
+}
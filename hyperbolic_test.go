@@ -0,0 +1,91 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestHyperbolicOrbit(t *testing.T) {
+	a, e := -20000., 1.6
+	o := conicFromElements(a*(1-e*e), e, 30, 20, 40, 300)
+	if vInf := o.VInfNorm(); !floats.EqualWithinRel(vInf, math.Sqrt(-Earth.μ/a), 1e-9) {
+		t.Fatalf("v∞ = %f km/s", vInf)
+	}
+	if νInf := o.AsymptoteTrueAnomaly(); !floats.EqualWithinAbs(νInf, math.Acos(-1/e), 1e-12) {
+		t.Fatalf("asymptote true anomaly %f", νInf)
+	}
+	F := o.HyperbolicAnomaly()
+	if F >= 0 {
+		t.Fatalf("hyperbolic anomaly %f before periapsis", F)
+	}
+	// The true anomaly from the hyperbolic anomaly.
+	if ν := 2 * math.Atan(math.Sqrt((e+1)/(e-1))*math.Tanh(F/2)); !floats.EqualWithinAbs(ν, Deg2rad(300)-2*math.Pi, 1e-9) {
+		t.Fatalf("true anomaly %f from F", ν)
+	}
+	// Propagating to the periapsis.
+	tp := o.TimeFromPeriapsis()
+	if tp >= 0 {
+		t.Fatalf("time from periapsis %s", tp)
+	}
+	peri := keplerPropagate(*o, -tp)
+	if !floats.EqualWithinRel(peri.RNorm(), a*(1-e), 1e-6) || math.Abs(peri.HyperbolicAnomaly()) > 1e-6 {
+		t.Fatalf("not at periapsis: r = %f km, F = %f", peri.RNorm(), peri.HyperbolicAnomaly())
+	}
+	// Far from the body, the velocity is along the asymptotes.
+	in, out := o.VInf()
+	before, after := keplerPropagate(*o, -1000*24*time.Hour), keplerPropagate(*o, 1000*24*time.Hour)
+	for i := 0; i < 3; i++ {
+		if !floats.EqualWithinAbs(before.V()[i], in[i], 1e-3) || !floats.EqualWithinAbs(after.V()[i], out[i], 1e-3) {
+			t.Fatalf("v∞ in %v vs %v, out %v vs %v", in, before.V(), out, after.V())
+		}
+	}
+	sIn, sOut := o.Asymptotes()
+	if !floats.EqualWithinAbs(Norm(sIn), 1, 1e-12) || !floats.EqualWithinAbs(Dot(sOut, Unit(o.H())), 0, 1e-12) {
+		t.Fatal("asymptotes are not unit vectors in the plane of the orbit")
+	}
+	// The turn angle is that of a gravity assist at this periapsis.
+	if ψ := math.Acos(Dot(sIn, sOut)); !floats.EqualWithinAbs(ψ, GATurnAngle(o.VInfNorm(), a*(1-e), Earth), 1e-9) {
+		t.Fatalf("turn angle %f", ψ)
+	}
+}
+
+func TestTimeFromPeriapsis(t *testing.T) {
+	for _, e := range []float64{0.3, 1, 2} {
+		a := 10000.
+		if e > 1 {
+			a = -a
+		}
+		p := a * (1 - e*e)
+		if e == 1 {
+			p = 16000
+		}
+		o := conicFromElements(p, e, 10, 0, 0, 60)
+		tp := o.TimeFromPeriapsis()
+		if tp <= 0 {
+			t.Fatalf("e = %f: time from periapsis %s after periapsis", e, tp)
+		}
+		peri := keplerPropagate(*o, -tp)
+		if math.Abs(Dot(peri.R(), peri.V())) > 1e-4*peri.RNorm()*peri.VNorm() {
+			t.Fatalf("e = %f: not at periapsis after %s", e, tp)
+		}
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("hyperbolic anomaly of an ellipse did not panic")
+		}
+	}()
+	NewOrbitFromOE(10000, 0.3, 10, 0, 0, 60, Earth).HyperbolicAnomaly()
+}
+
+// conicFromElements returns the Earth orbit of the provided semi-parameter, eccentricity and angles (in degrees),
+// which may be parabolic or hyperbolic unlike NewOrbitFromOE.
+func conicFromElements(p, e, i, Ω, ω, ν float64) *Orbit {
+	sinν, cosν := math.Sincos(Deg2rad(ν))
+	μOp := math.Sqrt(Earth.μ / p)
+	rPQW := []float64{p * cosν / (1 + e*cosν), p * sinν / (1 + e*cosν), 0}
+	vPQW := []float64{-μOp * sinν, μOp * (e + cosν), 0}
+	return NewOrbitFromRV(Rot313Vec(-Deg2rad(ω), -Deg2rad(i), -Deg2rad(Ω), rPQW), Rot313Vec(-Deg2rad(ω), -Deg2rad(i), -Deg2rad(Ω), vPQW), Earth)
+}
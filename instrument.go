@@ -0,0 +1,144 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Instrument is a sensor with a conical field of view about its boresight, fixed in the body frame of the spacecraft.
+type Instrument struct {
+	Name      string
+	Boresight []float64 // Unit vector in the body frame
+	HalfAngle float64   // Half angle of the field of view (degrees)
+	MaxRange  float64   // Maximum range of the targets (km), unlimited if zero
+}
+
+// NewInstrument returns a new instrument with the provided boresight in the body frame (normalized) and half
+// angle in degrees.
+func NewInstrument(name string, boresight []float64, halfAngle float64) Instrument {
+	if halfAngle <= 0 || halfAngle > 180 {
+		panic(fmt.Errorf("invalid half angle %f deg for instrument %s", halfAngle, name))
+	}
+	return Instrument{Name: name, Boresight: Unit(boresight), HalfAngle: halfAngle}
+}
+
+// InFOV returns whether the line of sight (in the body frame) is within the field of view.
+func (i Instrument) InFOV(los []float64) bool {
+	ρ := Norm(los)
+	if ρ == 0 || (i.MaxRange > 0 && ρ > i.MaxRange) {
+		return false
+	}
+	return Dot(i.Boresight, los)/ρ >= math.Cos(Deg2rad(i.HalfAngle))
+}
+
+// FOVTarget is a target of an instrument.
+type FOVTarget interface {
+	// LineOfSight returns the vector from the spacecraft to the target in the inertial frame of its orbit (km, or
+	// a unit vector for targets at infinity), and whether the target is observable, e.g. not below the horizon.
+	LineOfSight(state State) (los []float64, observable bool)
+	String() string
+}
+
+// GroundTarget is a site on the surface of a body, observable when the spacecraft is above the elevation mask of
+// the site, e.g. for nadir imaging windows.
+type GroundTarget struct {
+	Site Station
+}
+
+// LineOfSight implements the FOVTarget interface.
+func (g GroundTarget) LineOfSight(state State) ([]float64, bool) {
+	if !state.Orbit.Origin.Equals(g.Site.Planet) {
+		panic(fmt.Errorf("site %s is on %s but the spacecraft orbits %s", g.Site.Name, g.Site.Planet.Name, state.Orbit.Origin.Name))
+	}
+	R := state.Orbit.R()
+	rS, _ := g.Site.InertialRV(state.DT)
+	_, _, el, _ := g.Site.RangeElAz(MxV33(g.Site.Planet.Inertial2BodyFixed(state.DT), R))
+	return []float64{rS[0] - R[0], rS[1] - R[1], rS[2] - R[2]}, el >= g.Site.Elevation
+}
+
+func (g GroundTarget) String() string {
+	return g.Site.Name
+}
+
+// StarTarget is a star at infinity, observable when not occulted by the body orbited by the spacecraft, e.g. for
+// stellar occultation science windows.
+type StarTarget struct {
+	Name    string
+	RA, Dec float64 // Right ascension and declination in the inertial frame of the orbits (degrees)
+}
+
+// LineOfSight implements the FOVTarget interface.
+func (s StarTarget) LineOfSight(state State) ([]float64, bool) {
+	u := Spherical2Cartesian([]float64{1, Deg2rad(90 - s.Dec), Deg2rad(s.RA)})
+	R := state.Orbit.R()
+	proj := -Dot(R, u)
+	if proj <= 0 {
+		return u, true
+	}
+	closest := []float64{R[0] + proj*u[0], R[1] + proj*u[1], R[2] + proj*u[2]}
+	return u, Norm(closest) > state.Orbit.Origin.Radius
+}
+
+func (s StarTarget) String() string {
+	return s.Name
+}
+
+// FOVEvent stores an interval during which a target is observable and within the field of view of an instrument.
+type FOVEvent struct {
+	Start, End                     time.Time
+	Spacecraft, Instrument, Target string
+}
+
+// Duration returns the duration of this event.
+func (e FOVEvent) Duration() time.Duration {
+	return e.End.Sub(e.Start)
+}
+
+func (e FOVEvent) String() string {
+	return fmt.Sprintf("%s in the FOV of %s/%s from %s to %s (%s)", e.Target, e.Spacecraft, e.Instrument, e.Start, e.End, e.Duration())
+}
+
+// FOVWindows returns the intervals of the states during which the target is observable and within the field of
+// view of each instrument of the spacecraft, pointed by its attitude.
+func (sc *Spacecraft) FOVWindows(states []State, target FOVTarget) []FOVEvent {
+	var events []FOVEvent
+	for _, instrument := range sc.Instruments {
+		var cur *FOVEvent
+		for _, state := range states {
+			los, observable := target.LineOfSight(state)
+			if observable {
+				observable = instrument.InFOV(MxV33(sc.BodyToInertial(state).T(), los))
+			}
+			if !observable {
+				if cur != nil {
+					events = append(events, *cur)
+					cur = nil
+				}
+				continue
+			}
+			if cur == nil {
+				cur = &FOVEvent{Start: state.DT, Spacecraft: sc.Name, Instrument: instrument.Name, Target: target.String()}
+			}
+			cur.End = state.DT
+		}
+		if cur != nil {
+			events = append(events, *cur)
+		}
+	}
+	return events
+}
+
+// WriteFOVTable writes the FOV events as a CSV table.
+func WriteFOVTable(w io.Writer, events []FOVEvent) error {
+	if _, err := fmt.Fprint(w, "start,end,durationInMinutes,spacecraft,instrument,target\n"); err != nil {
+		return err
+	}
+	for _, e := range events {
+		if _, err := fmt.Fprintf(w, "%s,%s,%.3f,%s,%s,%s\n", e.Start.UTC().Format(time.RFC3339), e.End.UTC().Format(time.RFC3339), e.Duration().Minutes(), e.Spacecraft, e.Instrument, e.Target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package smd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFOVWindows(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	o := NewOrbitFromOE(Earth.Radius+700, 0, 45, 0, 0, 0, Earth)
+	var states []State
+	for dt := time.Duration(0); dt < 24*time.Hour; dt += 10 * time.Second {
+		states = append(states, State{DT: start.Add(dt), Orbit: *keplerPropagate(*o, dt)})
+	}
+	sc := NewEmptySC("imager", 0)
+	sc.Instruments = []Instrument{NewInstrument("wide", []float64{0, 0, 2}, 45), NewInstrument("narrow", []float64{0, 0, 1}, 10)}
+	site := GroundTarget{NewStation("site", 0, 0, 30, 10, 0, 0)}
+	events := sc.FOVWindows(states, site)
+	var wide, narrow time.Duration
+	for _, e := range events {
+		switch e.Instrument {
+		case "wide":
+			wide += e.Duration()
+		case "narrow":
+			narrow += e.Duration()
+		}
+		if e.Target != "site" || e.Spacecraft != "imager" {
+			t.Fatalf("invalid event %s", e)
+		}
+	}
+	if wide == 0 || narrow == 0 || narrow >= wide {
+		t.Fatalf("imaging windows of %s (wide) and %s (narrow)", wide, narrow)
+	}
+	// The narrow camera only sees the site close to the nadir.
+	for _, e := range events {
+		if e.Instrument != "narrow" {
+			continue
+		}
+		for _, state := range states {
+			if state.DT.Before(e.Start) || state.DT.After(e.End) {
+				continue
+			}
+			los, observable := site.LineOfSight(state)
+			if !observable || !sc.Instruments[1].InFOV(MxV33(NadirPointing(state).T(), los)) {
+				t.Fatalf("site not in the FOV at %s", state.DT)
+			}
+		}
+	}
+	// A star in the orbit plane is hidden by the Earth once per orbit.
+	star := StarTarget{Name: "star", RA: 0, Dec: 0}
+	tracker := NewEmptySC("tracker", 0)
+	tracker.Attitude = InertialPointing(DenseIdentity(3))
+	tracker.Instruments = []Instrument{NewInstrument("spectrometer", []float64{1, 0, 0}, 1)}
+	equatorial := NewOrbitFromOE(Earth.Radius+700, 0, 0.01, 0, 0, 0, Earth)
+	states = states[:0]
+	for dt := time.Duration(0); dt < 6*time.Hour; dt += 10 * time.Second {
+		states = append(states, State{DT: start.Add(dt), Orbit: *keplerPropagate(*equatorial, dt)})
+	}
+	events = tracker.FOVWindows(states, star)
+	period := equatorial.Period()
+	if len(events) < 3 || len(events) > 5 {
+		t.Fatalf("%d stellar windows in four orbits", len(events))
+	}
+	for _, e := range events[1 : len(events)-1] {
+		if e.Duration() < period/2 || e.Duration() > period {
+			t.Fatalf("stellar window of %s for a period of %s", e.Duration(), period)
+		}
+	}
+	var buf bytes.Buffer
+	if err := WriteFOVTable(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != len(events)+1 {
+		t.Fatalf("%d lines", lines)
+	}
+	assertPanic(t, func() {
+		NewInstrument("invalid", []float64{1, 0, 0}, 0)
+	})
+}
@@ -0,0 +1,115 @@
+package smd
+
+import (
+	"math"
+
+	"github.com/ChristopherRabotin/ode"
+)
+
+// Integrator defines the numerical integration method of a Mission.
+type Integrator uint8
+
+const (
+	// RK4Integrator is the fixed step fourth order Runge-Kutta (default).
+	RK4Integrator Integrator = iota
+	// Yoshida4Integrator is the fixed step fourth order symplectic integrator of Yoshida (1990). Its energy error
+	// remains bounded for conservative dynamics (e.g. two body and Jn), which suits multi-year propagations where
+	// the energy drift of RK4 becomes visible. It does not support the computation of the STM.
+	Yoshida4Integrator
+	// KSIntegrator is the Kustaanheimo–Stiefel regularized RK4, whose steps are in fictitious time and therefore
+	// proportional to the radius. It keeps the accuracy through very low periapsis passes and near-collision
+	// trajectories, where the fixed step integrators lose accuracy or diverge. It does not support the computation
+	// of the STM.
+	KSIntegrator
+)
+
+func (i Integrator) String() string {
+	switch i {
+	case RK4Integrator:
+		return "RK4"
+	case Yoshida4Integrator:
+		return "Yoshida4"
+	case KSIntegrator:
+		return "KS"
+	default:
+		panic("unknown integrator")
+	}
+}
+
+// Yoshida coefficients of the drift (position) and kick (velocity) stages.
+var (
+	yoshidaW1 = 1 / (2 - math.Cbrt(2))
+	yoshidaW0 = -math.Cbrt(2) / (2 - math.Cbrt(2))
+	yoshidaC  = []float64{yoshidaW1 / 2, (yoshidaW0 + yoshidaW1) / 2, (yoshidaW0 + yoshidaW1) / 2, yoshidaW1 / 2}
+	yoshidaD  = []float64{yoshidaW1, yoshidaW0, yoshidaW1}
+)
+
+// yoshida4 is a fourth order symplectic integrator of an ode.Integrable whose state starts with the position
+// and the velocity. The other components of the state (e.g. the fuel) are integrated with the kick stages.
+type yoshida4 struct {
+	x0, stepSize float64
+	integrable   ode.Integrable
+}
+
+// Solve integrates until the integrable stops, and returns the number of steps.
+func (y yoshida4) Solve() uint64 {
+	h := y.stepSize
+	t := y.x0
+	var iter uint64
+	for !y.integrable.Stop(t) {
+		s := y.integrable.GetState()
+		state := make([]float64, len(s))
+		copy(state, s)
+		stageT := t
+		for k, c := range yoshidaC {
+			// Drift
+			for i := 0; i < 3; i++ {
+				state[i] += c * h * state[i+3]
+			}
+			stageT += c * h
+			if k == len(yoshidaD) {
+				break
+			}
+			// Kick
+			fDot := y.integrable.Func(stageT, state)
+			for i := 3; i < len(state); i++ {
+				state[i] += yoshidaD[k] * h * fDot[i]
+			}
+		}
+		t += h
+		y.integrable.SetState(t, state)
+		iter++
+	}
+	return iter
+}
+
+// integrate propagates the mission with its integrator. Blocking.
+func (a *Mission) integrate() {
+	a.setPropagating(true)
+	defer a.setPropagating(false)
+	var integrable ode.Integrable = a
+	step := a.step.Seconds()
+	if a.Canonical {
+		if a.Integrator == KSIntegrator {
+			panic("the KS regularization cannot be integrated in canonical units")
+		}
+		cm := newCanonicalMission(a)
+		integrable, step = cm, step/cm.units.TU
+	}
+	switch a.Integrator {
+	case RK4Integrator:
+		ode.NewRK4(0, step, integrable).Solve()
+	case Yoshida4Integrator:
+		if a.computeSTM {
+			panic("the STM cannot be computed with a symplectic integrator")
+		}
+		yoshida4{0, step, integrable}.Solve()
+	case KSIntegrator:
+		if a.computeSTM {
+			panic("the STM cannot be computed with the KS regularization")
+		}
+		ksRK4{0, step, a}.Solve()
+	default:
+		panic("unknown integrator")
+	}
+}
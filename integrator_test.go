@@ -0,0 +1,39 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestYoshida4Integrator(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	period := NewOrbitFromOE(8000, 0.1, 30, 40, 50, 60, Earth).Period()
+	propagate := func(integrator Integrator, duration, step time.Duration) (*Orbit, float64) {
+		o := NewOrbitFromOE(8000, 0.1, 30, 40, 50, 60, Earth)
+		ξ0 := o.Energyξ()
+		m := NewPreciseMission(NewEmptySC(integrator.String(), 0), o, start, start.Add(duration.Truncate(step)+step), Perturbations{}, step, false, ExportConfig{})
+		m.Integrator = integrator
+		m.Propagate()
+		return o, math.Abs(o.Energyξ()-ξ0) / math.Abs(ξ0)
+	}
+	// Fourth order accuracy.
+	o, _ := propagate(Yoshida4Integrator, period, 10*time.Second)
+	exp := keplerPropagate(*NewOrbitFromOE(8000, 0.1, 30, 40, 50, 60, Earth), period.Truncate(10*time.Second))
+	Δ := []float64{o.R()[0] - exp.R()[0], o.R()[1] - exp.R()[1], o.R()[2] - exp.R()[2]}
+	if Norm(Δ) > 1e-3 {
+		t.Fatalf("position error of %e km after one orbit", Norm(Δ))
+	}
+	// Long term: the energy error of RK4 grows, that of the symplectic integrator is bounded.
+	_, rk4 := propagate(RK4Integrator, 200*period, 2*time.Minute)
+	_, yoshida := propagate(Yoshida4Integrator, 200*period, 2*time.Minute)
+	if yoshida >= rk4 {
+		t.Fatalf("symplectic energy error %e is not below the RK4 one %e", yoshida, rk4)
+	}
+	t.Logf("relative energy error after 200 orbits: RK4=%e Yoshida4=%e", rk4, yoshida)
+	assertPanic(t, func() {
+		m := NewPreciseMission(NewEmptySC("stm", 0), NewOrbitFromOE(8000, 0.1, 30, 40, 50, 60, Earth), start, start.Add(time.Hour), Perturbations{}, time.Minute, true, ExportConfig{})
+		m.Integrator = Yoshida4Integrator
+		m.Propagate()
+	})
+}
@@ -0,0 +1,217 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	anglesOnlyMaxIterations = 25
+	anglesOnlyTolerance     = 1e-6 // km, on the correction of the position at epoch
+	anglesOnlyRMSTolerance  = 1e-8 // relative change of the RMS between iterations
+)
+
+// lineOfSight returns the inertial position of the station and the unit vector towards the target of the provided
+// optical measurement.
+func (m OpticalMeasurement) lineOfSight() (rS, ρHat []float64) {
+	rS, _ = m.Station.InertialRV(m.State.DT)
+	sα, cα := math.Sincos(Deg2rad(m.RA))
+	sδ, cδ := math.Sincos(Deg2rad(m.Dec))
+	return rS, []float64{cδ * cα, cδ * sα, sδ}
+}
+
+// GaussIOD returns the orbit at the time of the second of three angles only observations with the method of Gauss
+// (Curtis, Algorithm 5.5), which is meant for observations a few minutes to a few degrees of arc apart. Only the
+// epochs, the stations and the measured angles of the observations are used. The returned orbit is typically only
+// accurate to a few percent and should be refined, e.g. by AnglesOnlyOD.
+func GaussIOD(m1, m2, m3 OpticalMeasurement) (*Orbit, error) {
+	body := m2.Station.Planet
+	μ := body.μ
+	R1, ρ1Hat := m1.lineOfSight()
+	R2, ρ2Hat := m2.lineOfSight()
+	R3, ρ3Hat := m3.lineOfSight()
+	τ1 := m1.State.DT.Sub(m2.State.DT).Seconds()
+	τ3 := m3.State.DT.Sub(m2.State.DT).Seconds()
+	τ := τ3 - τ1
+	if τ1 >= 0 || τ3 <= 0 {
+		return nil, errors.New("observations must be in chronological order")
+	}
+	p1, p2, p3 := Cross(ρ2Hat, ρ3Hat), Cross(ρ1Hat, ρ3Hat), Cross(ρ1Hat, ρ2Hat)
+	D0 := Dot(ρ1Hat, p1)
+	if math.Abs(D0) < 1e-12 {
+		return nil, errors.New("lines of sight are coplanar")
+	}
+	D := [3][3]float64{}
+	for i, R := range [][]float64{R1, R2, R3} {
+		for j, p := range [][]float64{p1, p2, p3} {
+			D[i][j] = Dot(R, p)
+		}
+	}
+	A := (-D[0][1]*τ3/τ + D[1][1] + D[2][1]*τ1/τ) / D0
+	B := (D[0][1]*(τ3*τ3-τ*τ)*τ3/τ + D[2][1]*(τ*τ-τ1*τ1)*τ1/τ) / (6 * D0)
+	E := Dot(R2, ρ2Hat)
+	r2, err := gaussRoot(-(A*A + 2*A*E + Dot(R2, R2)), -2*μ*B*(A+E), -μ*μ*B*B, body.Radius)
+	if err != nil {
+		return nil, err
+	}
+	r23 := r2 * r2 * r2
+	ρ1 := ((6*(D[2][0]*τ1/τ3+D[1][0]*τ/τ3)*r23+μ*D[2][0]*(τ*τ-τ1*τ1)*τ1/τ3)/(6*r23+μ*(τ*τ-τ3*τ3)) - D[0][0]) / D0
+	ρ2 := A + μ*B/r23
+	ρ3 := ((6*(D[0][2]*τ3/τ1-D[1][2]*τ/τ1)*r23+μ*D[0][2]*(τ*τ-τ3*τ3)*τ3/τ1)/(6*r23+μ*(τ*τ-τ1*τ1)) - D[2][2]) / D0
+	if ρ1 <= 0 || ρ2 <= 0 || ρ3 <= 0 {
+		return nil, fmt.Errorf("negative slant range (%f, %f, %f)", ρ1, ρ2, ρ3)
+	}
+	r1, r2Vec, r3 := make([]float64, 3), make([]float64, 3), make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		r1[i] = R1[i] + ρ1*ρ1Hat[i]
+		r2Vec[i] = R2[i] + ρ2*ρ2Hat[i]
+		r3[i] = R3[i] + ρ3*ρ3Hat[i]
+	}
+	// Lagrange coefficients truncated to the first terms of their series.
+	f1, g1 := 1-μ*τ1*τ1/(2*r23), τ1-μ*τ1*τ1*τ1/(6*r23)
+	f3, g3 := 1-μ*τ3*τ3/(2*r23), τ3-μ*τ3*τ3*τ3/(6*r23)
+	v2 := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		v2[i] = (-f3*r1[i] + f1*r3[i]) / (f1*g3 - f3*g1)
+	}
+	return NewOrbitFromRV(r2Vec, v2, body), nil
+}
+
+// gaussRoot returns the smallest root above the radius of the body of the polynomial x^8 + a x^6 + b x^3 + c.
+func gaussRoot(a, b, c, radius float64) (float64, error) {
+	F := func(x float64) float64 {
+		x3 := x * x * x
+		return x3*x3*x*x + a*x3*x3 + b*x3 + c
+	}
+	lo := radius
+	for hi := 1.01 * radius; hi < 1e4*radius; hi *= 1.01 {
+		if math.Signbit(F(lo)) == math.Signbit(F(hi)) {
+			lo = hi
+			continue
+		}
+		for i := 0; i < 200 && hi-lo > 1e-9*hi; i++ {
+			mid := (lo + hi) / 2
+			if math.Signbit(F(mid)) == math.Signbit(F(lo)) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		return (lo + hi) / 2, nil
+	}
+	return 0, errors.New("no root of the Gauss polynomial above the surface")
+}
+
+// AnglesOnlySolution stores the orbit determined from an angles only tracklet.
+type AnglesOnlySolution struct {
+	Epoch        time.Time
+	IOD          Orbit           // Initial orbit from the method of Gauss
+	Orbit        Orbit           // Orbit after the batch least squares refinement
+	Covariance   *mat64.SymDense // Formal covariance of the Cartesian state at epoch
+	Residuals    [][2]float64    // Post fit residuals of the right ascension and declination (arcseconds)
+	RMS          float64         // Weighted root mean square of the post fit residuals
+	Iterations   int
+	Measurements []OpticalMeasurement
+}
+
+func (s AnglesOnlySolution) String() string {
+	return fmt.Sprintf("%s @ %s (%d obs, %d iterations, RMS = %.3f)", s.Orbit, s.Epoch, len(s.Measurements), s.Iterations, s.RMS)
+}
+
+// AnglesOnlyOD determines the orbit at the epoch of the middle observation of an angles only tracklet, e.g. from
+// the OpticalStation measurements of a space surveillance telescope. The initial orbit is computed with GaussIOD from
+// the first, middle and last observations, and refined with a weighted batch least squares on all the observations
+// under two body dynamics. All the provided measurements are used, so the invisible ones should be discarded
+// beforehand. The measurements must be in chronological order.
+func AnglesOnlyOD(measurements []OpticalMeasurement) (AnglesOnlySolution, error) {
+	n := len(measurements)
+	if n < 3 {
+		return AnglesOnlySolution{}, fmt.Errorf("need at least three observations, got %d", n)
+	}
+	mid := measurements[n/2]
+	iod, err := GaussIOD(measurements[0], mid, measurements[n-1])
+	if err != nil {
+		return AnglesOnlySolution{}, err
+	}
+	sol := AnglesOnlySolution{Epoch: mid.State.DT, IOD: *iod, Measurements: measurements}
+	R, V := iod.RV()
+	x := append(append([]float64{}, R...), V...)
+	prevRMS := math.Inf(1)
+	for sol.Iterations = 1; sol.Iterations <= anglesOnlyMaxIterations; sol.Iterations++ {
+		epoch := NewOrbitFromRV(x[:3], x[3:], iod.Origin)
+		Λ := mat64.NewSymDense(6, nil)
+		N := mat64.NewVector(6, nil)
+		sol.Residuals = make([][2]float64, n)
+		sol.RMS = 0
+		for k, m := range measurements {
+			Δt := m.State.DT.Sub(sol.Epoch)
+			predicted := m
+			predicted.State = State{DT: m.State.DT, Orbit: *keplerPropagate(*epoch, Δt)}
+			rS, _ := m.Station.InertialRV(m.State.DT)
+			Rk := predicted.State.Orbit.R()
+			ρ := []float64{Rk[0] - rS[0], Rk[1] - rS[1], Rk[2] - rS[2]}
+			y := []float64{
+				math.Remainder(Deg2rad(m.RA)-math.Atan2(ρ[1], ρ[0]), 2*math.Pi),
+				Deg2rad(m.Dec) - math.Asin(ρ[2]/Norm(ρ)),
+			}
+			σ := m.Station.σAngle
+			if σ <= 0 {
+				σ = 1
+			}
+			σ = Deg2rad(σ / 3600)
+			W := []float64{math.Pow(math.Cos(Deg2rad(m.Dec))/σ, 2), 1 / (σ * σ)}
+			var H mat64.Dense
+			H.Mul(predicted.HTilde(), KeplerSTM(*epoch, Δt))
+			for i := 0; i < 2; i++ {
+				row := H.RawRowView(i)
+				for a := 0; a < 6; a++ {
+					N.SetVec(a, N.At(a, 0)+row[a]*W[i]*y[i])
+					for b := a; b < 6; b++ {
+						Λ.SetSym(a, b, Λ.At(a, b)+row[a]*W[i]*row[b])
+					}
+				}
+				sol.Residuals[k][i] = Rad2deg(y[i]) * 3600
+				sol.RMS += y[i] * y[i] * W[i]
+			}
+		}
+		sol.RMS = math.Sqrt(sol.RMS / float64(2*n))
+		P, err := informationInverse(Λ)
+		if err != nil {
+			return sol, fmt.Errorf("information matrix not invertible: %s", err)
+		}
+		sol.Covariance = P
+		var Δx mat64.Vector
+		Δx.MulVec(P, N)
+		for i := 0; i < 6; i++ {
+			x[i] += Δx.At(i, 0)
+		}
+		sol.Orbit = *NewOrbitFromRV(x[:3], x[3:], iod.Origin)
+		// With noisy observations, the correction of the range along the line of sight may not vanish although the
+		// fit no longer improves.
+		if Norm([]float64{Δx.At(0, 0), Δx.At(1, 0), Δx.At(2, 0)}) < anglesOnlyTolerance || math.Abs(sol.RMS-prevRMS) < anglesOnlyRMSTolerance*sol.RMS {
+			return sol, nil
+		}
+		prevRMS = sol.RMS
+	}
+	sol.Iterations = anglesOnlyMaxIterations
+	return sol, fmt.Errorf("batch did not converge in %d iterations", anglesOnlyMaxIterations)
+}
+
+// informationInverse returns the covariance of the information matrix. The inverse is computed from its Cholesky
+// factorization because the condition number estimated by Dense.Inverse is unreliable for the poorly conditioned
+// information matrix of a short arc.
+func informationInverse(Λ *mat64.SymDense) (*mat64.SymDense, error) {
+	var chol mat64.Cholesky
+	if !chol.Factorize(Λ) {
+		return nil, errors.New("matrix is not positive definite")
+	}
+	var P mat64.SymDense
+	if err := P.InverseCholesky(&chol); err != nil {
+		return nil, err
+	}
+	return &P, nil
+}
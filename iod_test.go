@@ -0,0 +1,72 @@
+package smd
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+// tracklet returns the angles measurements of the telescope on the orbit every two minutes, centered on dt.
+func tracklet(telescope OpticalStation, o Orbit, dt time.Time, n int) []OpticalMeasurement {
+	measurements := make([]OpticalMeasurement, n)
+	for i := range measurements {
+		Δt := time.Duration(i-n/2) * 2 * time.Minute
+		measurements[i] = telescope.Measure(State{DT: dt.Add(Δt), Orbit: *keplerPropagate(o, Δt)})
+	}
+	return measurements
+}
+
+func TestAnglesOnlyOD(t *testing.T) {
+	dt := time.Date(2018, 3, 1, 4, 0, 0, 0, time.UTC)
+	telescope := NewOpticalStation("telescope", 1.5, 20, 32.7, 253.3, 16, -12, 10, 0.2, 1)
+	telescope.Ephemeris = hohmannEphemeris(dt)
+	telescope.rng = nil
+	state := overhead(telescope.Station, dt, 20000)
+	R, V := state.Orbit.RV()
+	// Make it slightly eccentric and inclined with respect to the overhead circular orbit.
+	truth := *NewOrbitFromRV(R, []float64{V[0] * 1.02, V[1] * 1.02, V[2] + 0.3}, Earth)
+	measurements := tracklet(telescope, truth, dt, 11)
+	iod, err := GaussIOD(measurements[0], measurements[5], measurements[10])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Δr := Norm([]float64{iod.R()[0] - R[0], iod.R()[1] - R[1], iod.R()[2] - R[2]}); Δr > 100 {
+		t.Fatalf("Gauss IOD is %f km off", Δr)
+	}
+	sol, err := AnglesOnlyOD(measurements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sol.Epoch.Equal(dt) {
+		t.Fatalf("epoch %s", sol.Epoch)
+	}
+	if !floats.EqualApprox(sol.Orbit.R(), truth.R(), 1e-4) || !floats.EqualApprox(sol.Orbit.V(), truth.V(), 1e-7) {
+		t.Fatalf("noise free solution\n%+v\n%+v", sol.Orbit, truth)
+	}
+	if sol.RMS > 1e-3 {
+		t.Fatalf("noise free RMS %f", sol.RMS)
+	}
+	// With one arcsecond of noise, the errors are consistent with the covariance.
+	telescope.rng = rand.New(rand.NewSource(42))
+	sol, err = AnglesOnlyOD(tracklet(telescope, truth, dt, 11))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sol.RMS < 0.5 || sol.RMS > 1.5 {
+		t.Fatalf("noisy RMS %f", sol.RMS)
+	}
+	for i := 0; i < 3; i++ {
+		if err := math.Abs(sol.Orbit.R()[i] - truth.R()[i]); err > 4*math.Sqrt(sol.Covariance.At(i, i)) {
+			t.Fatalf("position error %f km on axis %d beyond 4σ (%f km)", err, i, math.Sqrt(sol.Covariance.At(i, i)))
+		}
+	}
+	if _, err := AnglesOnlyOD(measurements[:2]); err == nil {
+		t.Fatal("two observations should fail")
+	}
+	if _, err := GaussIOD(measurements[2], measurements[1], measurements[0]); err == nil {
+		t.Fatal("observations out of order should fail")
+	}
+}
@@ -0,0 +1,225 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat/distmv"
+)
+
+// ISL defines an inter-satellite link.
+type ISL struct {
+	GrazingAltitude            float64 // Minimum altitude of the line of sight above the surface, e.g. for atmospheric grazing (km)
+	MaxRange                   float64 // Maximum range of the link (km), unlimited if zero
+	RangeNoise, RangeRateNoise *distmv.Normal
+}
+
+// NewISL returns a new inter-satellite link with the provided range and range rate noise variances.
+func NewISL(grazingAltitude, maxRange, σρ, σρDot float64) ISL {
+	seed := newRand()
+	ρNoise, ok := distmv.NewNormal([]float64{0}, mat64.NewSymDense(1, []float64{σρ}), seed)
+	if !ok {
+		panic("NOK in Gaussian")
+	}
+	ρDotNoise, ok := distmv.NewNormal([]float64{0}, mat64.NewSymDense(1, []float64{σρDot}), seed)
+	if !ok {
+		panic("NOK in Gaussian")
+	}
+	return ISL{grazingAltitude, maxRange, ρNoise, ρDotNoise}
+}
+
+// InView returns whether both spacecraft are in view of each other through this link. Both spacecraft must
+// orbit the same body.
+func (l ISL) InView(a, b Orbit) bool {
+	ρ, _ := interSpacecraftRV(a, b)
+	if l.MaxRange > 0 && Norm(ρ) > l.MaxRange {
+		return false
+	}
+	return LineOfSight(a.R(), b.R(), a.Origin.Radius+l.GrazingAltitude)
+}
+
+// PerformMeasurement returns the range and range rate measurement of the target by the observer, e.g. a relay
+// of known state tracking a user spacecraft.
+func (l ISL) PerformMeasurement(observer, target State) ISLMeasurement {
+	ρVec, ρDotVec := interSpacecraftRV(observer.Orbit, target.Orbit)
+	ρ := Norm(ρVec)
+	ρDot := Dot(ρVec, ρDotVec) / ρ
+	m := ISLMeasurement{Visible: l.InView(observer.Orbit, target.Orbit), Range: ρ, RangeRate: ρDot, TrueRange: ρ, TrueRangeRate: ρDot, Observer: observer, State: target}
+	if l.RangeNoise != nil {
+		m.Range += l.RangeNoise.Rand(nil)[0]
+	}
+	if l.RangeRateNoise != nil {
+		m.RangeRate += l.RangeRateNoise.Rand(nil)[0]
+	}
+	return m
+}
+
+// RelayMeasurements returns the measurements of the target by the relay while they are in view of each other,
+// e.g. an orbiter tracking a smallsat. The states must be at the same epochs.
+func (l ISL) RelayMeasurements(relay, target []State) []ISLMeasurement {
+	var measurements []ISLMeasurement
+	for k := 0; k < len(relay) && k < len(target); k++ {
+		if !relay[k].DT.Equal(target[k].DT) {
+			panic(fmt.Errorf("%s and %s are not synchronized at %s", relay[k].SC.Name, target[k].SC.Name, relay[k].DT))
+		}
+		if m := l.PerformMeasurement(relay[k], target[k]); m.Visible {
+			measurements = append(measurements, m)
+		}
+	}
+	return measurements
+}
+
+// LanderMeasurements returns the measurements of a lander by the relay while the relay is above the elevation mask
+// of the lander and within the maximum range of the link, e.g. an orbiter relaying a lander on Mars. The relay
+// must orbit the body of the lander, whose state is its inertial position and velocity.
+func (l ISL) LanderMeasurements(relay []State, lander Station) []ISLMeasurement {
+	var measurements []ISLMeasurement
+	for _, state := range relay {
+		if !state.Orbit.Origin.Equals(lander.Planet) {
+			panic(fmt.Errorf("lander %s is on %s but the relay orbits %s", lander.Name, lander.Planet.Name, state.Orbit.Origin.Name))
+		}
+		R, V := lander.InertialRV(state.DT)
+		target := State{DT: state.DT, SC: Spacecraft{Name: lander.Name}, Orbit: *NewOrbitFromRV(R, V, lander.Planet)}
+		m := l.PerformMeasurement(state, target)
+		_, _, el, _ := lander.RangeElAz(MxV33(lander.Planet.Inertial2BodyFixed(state.DT), state.Orbit.R()))
+		if el >= lander.Elevation && (l.MaxRange == 0 || m.TrueRange <= l.MaxRange) {
+			m.Visible = true
+			measurements = append(measurements, m)
+		}
+	}
+	return measurements
+}
+
+// Contacts returns the periods during which the spacecraft of the provided states are in view of each other.
+// The states must be at the same epochs.
+func (l ISL) Contacts(a, b []State, nameA, nameB string) []VisibilityEvent {
+	var events []VisibilityEvent
+	var cur *VisibilityEvent
+	steps := len(a)
+	if len(b) < steps {
+		steps = len(b)
+	}
+	for step := 0; step < steps; step++ {
+		sA, sB := a[step], b[step]
+		if !sA.DT.Equal(sB.DT) {
+			panic(fmt.Errorf("%s and %s are not synchronized at %s", nameA, nameB, sA.DT))
+		}
+		if l.InView(sA.Orbit, sB.Orbit) {
+			ρ, _ := interSpacecraftRV(sA.Orbit, sB.Orbit)
+			r := Norm(ρ)
+			if cur == nil {
+				cur = &VisibilityEvent{Start: sA.DT, A: nameA, B: nameB, MinRange: r, MaxRange: r}
+			}
+			cur.End = sA.DT
+			cur.MinRange = math.Min(cur.MinRange, r)
+			cur.MaxRange = math.Max(cur.MaxRange, r)
+		} else if cur != nil {
+			events = append(events, *cur)
+			cur = nil
+		}
+	}
+	if cur != nil {
+		events = append(events, *cur)
+	}
+	return events
+}
+
+// allContacts returns the contacts of all pairs of the provided histories, sorted by start time.
+func (l ISL) allContacts(histories [][]State, names []string) []VisibilityEvent {
+	var events []VisibilityEvent
+	for i := range histories {
+		for j := i + 1; j < len(histories); j++ {
+			events = append(events, l.Contacts(histories[i], histories[j], names[i], names[j])...)
+		}
+	}
+	sort.Stable(byStart(events))
+	return events
+}
+
+// ISLContacts returns the contacts of all pairs of spacecraft through the provided link, sorted by start time.
+func (m *MultiMission) ISLContacts(l ISL) []VisibilityEvent {
+	names := make([]string, len(m.Missions))
+	for k, mission := range m.Missions {
+		names[k] = mission.Vehicle.Name
+	}
+	return l.allContacts(m.History, names)
+}
+
+// ISLContacts returns the contacts of all pairs of members through the provided link, sorted by start time.
+func (c *ConstellationMission) ISLContacts(l ISL) []VisibilityEvent {
+	names := make([]string, len(c.Members))
+	for k, member := range c.Members {
+		names[k] = member.Name
+	}
+	return l.allContacts(c.History, names)
+}
+
+// WriteISLRanges writes the range, range rate and visibility between both spacecraft as a CSV table.
+func WriteISLRanges(w io.Writer, l ISL, a, b []State) error {
+	if _, err := fmt.Fprint(w, "time,range,rangeRate,inView\n"); err != nil {
+		return err
+	}
+	for k := 0; k < len(a) && k < len(b); k++ {
+		m := l.PerformMeasurement(a[k], b[k])
+		if _, err := fmt.Fprintf(w, "%s,%.6f,%.9f,%v\n", a[k].DT.UTC().Format(time.RFC3339), m.TrueRange, m.TrueRangeRate, m.Visible); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ISLMeasurement stores an inter-satellite range and range rate measurement.
+type ISLMeasurement struct {
+	Visible                  bool    // Stores whether the target was in view of the observer.
+	Range, RangeRate         float64 // Store the range and range rate
+	TrueRange, TrueRangeRate float64 // Store the true range and range rate
+	Observer                 State   // State of the observer, assumed to be known
+	State                    State   // State of the target
+}
+
+// IsNil returns whether this measurement is empty.
+func (m ISLMeasurement) IsNil() bool {
+	return m.Range == m.RangeRate && m.RangeRate == 0
+}
+
+// StateVector returns the measurement vector as a mat64.Vector
+func (m ISLMeasurement) StateVector() *mat64.Vector {
+	return mat64.NewVector(2, []float64{m.Range, m.RangeRate})
+}
+
+// HTilde returns the H tilde matrix of this measurement with respect to the state of the target.
+func (m ISLMeasurement) HTilde() *mat64.Dense {
+	return orbitState.HTilde(m)
+}
+
+// JointHTilde returns the H tilde matrix of this measurement with respect to the state of the observer followed by
+// that of the target, i.e. [R_obs, V_obs, R, V], for the joint orbit determination of both spacecraft.
+func (m ISLMeasurement) JointHTilde() *mat64.Dense {
+	return NewAugmentedState(ObserverPositionBlock, ObserverVelocityBlock, PositionBlock, VelocityBlock).HTilde(m)
+}
+
+// Partials returns the partials of the range and range rate with respect to the states of the target and of the
+// observer, which are opposite since the measurement only depends on their difference.
+func (m ISLMeasurement) Partials() map[StateBlock]*mat64.Dense {
+	rO, vO := m.Observer.Orbit.RV()
+	partials := positionVelocityPartials(rangeRangeRateHTilde(rO, vO, m.State.Orbit))
+	for block, observer := range map[StateBlock]StateBlock{PositionBlock: ObserverPositionBlock, VelocityBlock: ObserverVelocityBlock} {
+		var opposite mat64.Dense
+		opposite.Scale(-1, partials[block])
+		partials[observer] = &opposite
+	}
+	return partials
+}
+
+// CSV returns the data as CSV (does *not* include the new line)
+func (m ISLMeasurement) CSV() string {
+	return fmt.Sprintf("%f,%f,%f,%f,", m.TrueRange, m.TrueRangeRate, m.Range, m.RangeRate)
+}
+
+func (m ISLMeasurement) String() string {
+	return fmt.Sprintf("%s->%s@%s", m.Observer.SC.Name, m.State.SC.Name, m.State.DT)
+}
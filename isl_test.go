@@ -0,0 +1,172 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestISLInView(t *testing.T) {
+	a := 7000.0
+	o1 := *NewOrbitFromOE(a, 0, 0, 0, 0, 0, Earth)
+	o2 := *NewOrbitFromOE(a, 0, 0, 0, 0, 30, Earth)
+	// The line of sight grazes the Earth at a(cos 15°)-R, i.e. about 383 km.
+	grazing := a*math.Cos(Deg2rad(15)) - Earth.Radius
+	if !(ISL{GrazingAltitude: grazing - 10}).InView(o1, o2) {
+		t.Fatal("link should be in view above the grazing altitude")
+	}
+	if (ISL{GrazingAltitude: grazing + 10}).InView(o1, o2) {
+		t.Fatal("link should be blocked by the atmosphere")
+	}
+	if (ISL{MaxRange: 1000}).InView(o1, o2) {
+		t.Fatal("link should be out of range")
+	}
+	o3 := *NewOrbitFromOE(a, 0, 0, 0, 0, 90, Earth)
+	if (ISL{}).InView(o1, o3) {
+		t.Fatal("link should be blocked by the Earth")
+	}
+}
+
+func TestISLMeasurement(t *testing.T) {
+	dt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	relay := State{DT: dt, SC: *NewEmptySC("relay", 0), Orbit: *NewOrbitFromOE(Earth.Radius+1000, 0, 45, 0, 0, 0, Earth)}
+	user := State{DT: dt, SC: *NewEmptySC("user", 0), Orbit: *NewOrbitFromOE(Earth.Radius+400, 0.01, 50, 5, 0, 10, Earth)}
+	link := NewISL(100, 0, σρ, σρDot)
+	m := link.PerformMeasurement(relay, user)
+	if !m.Visible || m.IsNil() {
+		t.Fatalf("user should be visible: %s", m)
+	}
+	if !floats.EqualWithinAbs(m.Range, m.TrueRange, 1) {
+		t.Fatalf("noisy range %f too far from %f", m.Range, m.TrueRange)
+	}
+	// Check the partials with finite differencing of the true measurement.
+	m.Range, m.RangeRate = m.TrueRange, m.TrueRangeRate
+	H := m.HTilde()
+	R, V := user.Orbit.RV()
+	X := append(append([]float64{}, R...), V...)
+	perfect := ISL{GrazingAltitude: 100}
+	for j := 0; j < 6; j++ {
+		h := 1e-3
+		if j > 2 {
+			h = 1e-6
+		}
+		Xp := append([]float64{}, X...)
+		Xp[j] += h
+		user.Orbit = *NewOrbitFromRV(Xp[:3], Xp[3:], Earth)
+		mp := perfect.PerformMeasurement(relay, user)
+		if !floats.EqualWithinAbs((mp.TrueRange-m.TrueRange)/h, H.At(0, j), 1e-5) || !floats.EqualWithinAbs((mp.TrueRangeRate-m.TrueRangeRate)/h, H.At(1, j), 1e-5) {
+			t.Fatalf("invalid partials for component %d: %f %f", j, H.At(0, j), H.At(1, j))
+		}
+	}
+}
+
+func TestISLContacts(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Coplanar spacecraft at different altitudes periodically lose their link behind the Earth.
+	m := NewMultiMission(start, start.Add(12*time.Hour), time.Minute)
+	m.Add(NewEmptySC("leo", 0), NewOrbitFromOE(Earth.Radius+500, 0, 0, 0, 0, 0, Earth), Perturbations{}, ExportConfig{})
+	m.Add(NewEmptySC("meo", 0), NewOrbitFromOE(Earth.Radius+10000, 0, 0, 0, 0, 0, Earth), Perturbations{}, ExportConfig{})
+	m.Propagate()
+	contacts := m.ISLContacts(ISL{})
+	if len(contacts) < 3 {
+		t.Fatalf("expected several contacts, got %v", contacts)
+	}
+	grazing := m.ISLContacts(ISL{GrazingAltitude: 200})
+	var total, totalGrazing time.Duration
+	for k := range contacts {
+		total += contacts[k].Duration()
+	}
+	for k := range grazing {
+		totalGrazing += grazing[k].Duration()
+	}
+	if totalGrazing >= total {
+		t.Fatalf("grazing altitude should shorten the contacts: %s >= %s", totalGrazing, total)
+	}
+	var buf bytes.Buffer
+	if err := WriteISLRanges(&buf, ISL{}, m.History[0], m.History[1]); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != len(m.History[0])+1 {
+		t.Fatalf("wrote %d lines", lines)
+	}
+	// Members of the same plane of a constellation are always in view of their neighbors.
+	c := NewConstellationMission(WalkerDelta("ring", 8, 1, 0, Earth.Radius+1200, 50, Earth), start, start.Add(time.Hour), Perturbations{}, time.Minute, ExportConfig{})
+	c.Propagate()
+	span := c.History[0][len(c.History[0])-1].DT.Sub(c.History[0][0].DT)
+	neighbors := 0
+	for _, e := range c.ISLContacts(ISL{GrazingAltitude: 100}) {
+		if e.Duration() == span {
+			neighbors++
+		}
+	}
+	if neighbors != 8 {
+		t.Fatalf("%d neighbor links always in view", neighbors)
+	}
+}
+
+func TestISLJointHTilde(t *testing.T) {
+	dt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	relay := State{DT: dt, SC: *NewEmptySC("relay", 0), Orbit: *NewOrbitFromOE(Earth.Radius+1000, 0, 45, 0, 0, 0, Earth)}
+	user := State{DT: dt, SC: *NewEmptySC("user", 0), Orbit: *NewOrbitFromOE(Earth.Radius+400, 0.01, 50, 5, 0, 10, Earth)}
+	link := ISL{GrazingAltitude: 100}
+	m := link.PerformMeasurement(relay, user)
+	H := m.JointHTilde()
+	if r, c := H.Dims(); r != 2 || c != 12 {
+		t.Fatalf("invalid dimensions %dx%d", r, c)
+	}
+	// The partials with respect to the target are those of HTilde, check those of the observer by finite differencing.
+	R, V := relay.Orbit.RV()
+	X := append(append([]float64{}, R...), V...)
+	for j := 0; j < 6; j++ {
+		if H.At(0, j+6) != m.HTilde().At(0, j) {
+			t.Fatalf("invalid target partials for component %d", j)
+		}
+		h := 1e-3
+		if j > 2 {
+			h = 1e-6
+		}
+		Xp := append([]float64{}, X...)
+		Xp[j] += h
+		relay.Orbit = *NewOrbitFromRV(Xp[:3], Xp[3:], Earth)
+		mp := link.PerformMeasurement(relay, user)
+		if !floats.EqualWithinAbs((mp.TrueRange-m.TrueRange)/h, H.At(0, j), 1e-5) || !floats.EqualWithinAbs((mp.TrueRangeRate-m.TrueRangeRate)/h, H.At(1, j), 1e-5) {
+			t.Fatalf("invalid observer partials for component %d: %f %f", j, H.At(0, j), H.At(1, j))
+		}
+	}
+}
+
+func TestRelayMeasurements(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMultiMission(start, start.Add(12*time.Hour), time.Minute)
+	m.Add(NewEmptySC("leo", 0), NewOrbitFromOE(Earth.Radius+500, 0, 0, 0, 0, 0, Earth), Perturbations{}, ExportConfig{})
+	m.Add(NewEmptySC("meo", 0), NewOrbitFromOE(Earth.Radius+10000, 0, 0, 0, 0, 0, Earth), Perturbations{}, ExportConfig{})
+	m.Propagate()
+	measurements := ISL{}.RelayMeasurements(m.History[1], m.History[0])
+	if len(measurements) == 0 || len(measurements) == len(m.History[0]) {
+		t.Fatalf("%d relay measurements out of %d states", len(measurements), len(m.History[0]))
+	}
+	for _, msr := range measurements {
+		if !msr.Visible || msr.Observer.SC.Name != "meo" || msr.State.SC.Name != "leo" {
+			t.Fatalf("invalid measurement %s", msr)
+		}
+	}
+	// An orbiter relaying a lander on Mars.
+	orbiter := NewMultiMission(start, start.Add(12*time.Hour), time.Minute)
+	orbiter.Add(NewEmptySC("orbiter", 0), NewOrbitFromOE(Mars.Radius+400, 0, 80, 0, 0, 0, Mars), Perturbations{}, ExportConfig{})
+	orbiter.Propagate()
+	lander := NewBodyStation("lander", Mars, 0, 10, 10, 30, 0, 0)
+	landed := ISL{MaxRange: 2000}.LanderMeasurements(orbiter.History[0], lander)
+	if len(landed) == 0 || len(landed) > len(orbiter.History[0])/4 {
+		t.Fatalf("%d lander measurements out of %d states", len(landed), len(orbiter.History[0]))
+	}
+	for _, msr := range landed {
+		exp := lander.Measure(msr.Observer)
+		if msr.State.SC.Name != "lander" || msr.TrueRange > 2000 || !floats.EqualWithinAbs(msr.TrueRange, exp.TrueRange, 1e-6) {
+			t.Fatalf("lander range %f, expected %f", msr.TrueRange, exp.TrueRange)
+		}
+	}
+}
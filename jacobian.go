@@ -0,0 +1,127 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	forwardDifferenceStep = 1e-7  // Default relative step of the forward differences, about √ε
+	centralDifferenceStep = 1e-5  // Default relative step of the central differences, about ∛ε
+	complexStep           = 1e-20 // Default step of the complex step differentiation
+	stateJacobianRStep    = 1e-3  // Default position step (km) of the Jacobians of the propagation maps
+	stateJacobianVStep    = 1e-6  // Default velocity step (km/s) of the Jacobians of the propagation maps
+)
+
+// DifferenceScheme defines how a Jacobian is numerically differentiated.
+type DifferenceScheme uint8
+
+const (
+	// ForwardDifference uses n+1 evaluations and is first order accurate.
+	ForwardDifference DifferenceScheme = iota + 1
+	// CentralDifference uses 2n evaluations and is second order accurate.
+	CentralDifference
+	// ComplexStep is exact to the machine precision without subtractive cancellation, but requires the function
+	// to be evaluated in complex arithmetic (cf. ComplexStepJacobian).
+	ComplexStep
+)
+
+func (s DifferenceScheme) String() string {
+	switch s {
+	case ForwardDifference:
+		return "forward"
+	case CentralDifference:
+		return "central"
+	case ComplexStep:
+		return "complex-step"
+	}
+	panic(fmt.Errorf("unknown difference scheme %d", s))
+}
+
+// Jacobian returns the Jacobian of f at x with the provided real difference scheme. The steps are those of each
+// variable, or relative to max(1, |x|) if nil. This is meant for any map whose derivatives are not available, e.g.
+// a propagation, the residuals of a targeter or the constraints of an optimization problem.
+func Jacobian(f func(x []float64) []float64, x, h []float64, scheme DifferenceScheme) *mat64.Dense {
+	if h != nil && len(h) != len(x) {
+		panic(fmt.Errorf("%d steps for %d variables", len(h), len(x)))
+	}
+	step := func(j int) float64 {
+		if h != nil {
+			return h[j]
+		}
+		rel := forwardDifferenceStep
+		if scheme == CentralDifference {
+			rel = centralDifferenceStep
+		}
+		return rel * math.Max(1, math.Abs(x[j]))
+	}
+	var f0 []float64
+	if scheme == ForwardDifference {
+		f0 = f(x)
+	} else if scheme != CentralDifference {
+		panic(fmt.Errorf("%s differences require a real scheme", scheme))
+	}
+	var J *mat64.Dense
+	xp := append([]float64{}, x...)
+	for j := range x {
+		hj := step(j)
+		xp[j] = x[j] + hj
+		fp := f(xp)
+		if J == nil {
+			J = mat64.NewDense(len(fp), len(x), nil)
+		}
+		if scheme == ForwardDifference {
+			for i := range fp {
+				J.Set(i, j, (fp[i]-f0[i])/hj)
+			}
+		} else {
+			xp[j] = x[j] - hj
+			fm := f(xp)
+			for i := range fp {
+				J.Set(i, j, (fp[i]-fm[i])/(2*hj))
+			}
+		}
+		xp[j] = x[j]
+	}
+	return J
+}
+
+// ComplexStepJacobian returns the Jacobian of f at x by complex step differentiation, i.e. J = Im(f(x + ih))/h,
+// where f must be analytic (no abs, comparisons or conjugates of the variables). The step is 1e-20 if zero.
+func ComplexStepJacobian(f func(x []complex128) []complex128, x []float64, h float64) *mat64.Dense {
+	if h == 0 {
+		h = complexStep
+	}
+	xc := make([]complex128, len(x))
+	for j := range x {
+		xc[j] = complex(x[j], 0)
+	}
+	var J *mat64.Dense
+	for j := range x {
+		xc[j] = complex(x[j], h)
+		fp := f(xc)
+		xc[j] = complex(x[j], 0)
+		if J == nil {
+			J = mat64.NewDense(len(fp), len(x), nil)
+		}
+		for i := range fp {
+			J.Set(i, j, imag(fp[i])/h)
+		}
+	}
+	return J
+}
+
+// StateJacobian returns the Jacobian of the propagation map from the provided orbit, i.e. its STM, with the provided
+// real difference scheme and steps of 1 m in position and 1 mm/s in velocity. The map may use any dynamics, e.g.
+// func(o Orbit) Orbit { return *keplerPropagate(o, dt) } or the final orbit of a Mission.
+func StateJacobian(o Orbit, propagate func(Orbit) Orbit, scheme DifferenceScheme) *mat64.Dense {
+	R, V := o.RV()
+	x := append(append([]float64{}, R...), V...)
+	h := []float64{stateJacobianRStep, stateJacobianRStep, stateJacobianRStep, stateJacobianVStep, stateJacobianVStep, stateJacobianVStep}
+	return Jacobian(func(x []float64) []float64 {
+		R, V := propagate(*NewOrbitFromRV(x[:3], x[3:], o.Origin)).RV()
+		return append(R, V...)
+	}, x, h, scheme)
+}
@@ -0,0 +1,85 @@
+package smd
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestJacobian(t *testing.T) {
+	x := []float64{0.7, -1.3}
+	exact := mat64.NewDense(2, 2, []float64{2 * x[0] * x[1], x[0] * x[0], math.Cos(x[0]), 3 * x[1] * x[1]})
+	f := func(x []float64) []float64 {
+		return []float64{x[0] * x[0] * x[1], math.Sin(x[0]) + x[1]*x[1]*x[1]}
+	}
+	fc := func(x []complex128) []complex128 {
+		return []complex128{x[0] * x[0] * x[1], cmplx.Sin(x[0]) + x[1]*x[1]*x[1]}
+	}
+	for _, test := range []struct {
+		J   *mat64.Dense
+		tol float64
+	}{
+		{Jacobian(f, x, nil, ForwardDifference), 1e-6},
+		{Jacobian(f, x, nil, CentralDifference), 1e-9},
+		{Jacobian(f, x, []float64{1e-4, 1e-4}, CentralDifference), 1e-7},
+		{ComplexStepJacobian(fc, x, 0), 1e-14},
+	} {
+		if !mat64.EqualApprox(test.J, exact, test.tol) {
+			t.Fatalf("invalid Jacobian (tolerance %g)\n%v", test.tol, mat64.Formatted(test.J))
+		}
+	}
+	assertPanic(t, func() {
+		Jacobian(f, x, nil, ComplexStep)
+	})
+	assertPanic(t, func() {
+		Jacobian(f, x, []float64{1e-6}, ForwardDifference)
+	})
+}
+
+func TestComplexStepGravityGradient(t *testing.T) {
+	// The complex step derivative of the two body acceleration is the analytical gravity gradient.
+	R := []float64{-6045, -3490, 2500}
+	μ := Earth.μ
+	acc := func(x []complex128) []complex128 {
+		r := cmplx.Sqrt(x[0]*x[0] + x[1]*x[1] + x[2]*x[2])
+		k := complex(-μ, 0) / (r * r * r)
+		return []complex128{k * x[0], k * x[1], k * x[2]}
+	}
+	r := Norm(R)
+	exact := mat64.NewDense(3, 3, nil)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			val := 3 * R[i] * R[j]
+			if i == j {
+				val -= r * r
+			}
+			exact.Set(i, j, μ/math.Pow(r, 5)*val)
+		}
+	}
+	if J := ComplexStepJacobian(acc, R, 0); !mat64.EqualApprox(J, exact, 1e-18) {
+		t.Fatalf("invalid gravity gradient\n%v\n%v", mat64.Formatted(J), mat64.Formatted(exact))
+	}
+}
+
+func TestStateJacobian(t *testing.T) {
+	o := NewOrbitFromOE(Earth.Radius+700, 0.05, 45, 10, 20, 30, Earth)
+	dt := 45 * time.Minute
+	kepler := func(o Orbit) Orbit {
+		return *keplerPropagate(o, dt)
+	}
+	central := StateJacobian(*o, kepler, CentralDifference)
+	forward := StateJacobian(*o, kepler, ForwardDifference)
+	// The two body flow preserves the volume of the phase space.
+	if det := mat64.Det(central); math.Abs(det-1) > 1e-4 {
+		t.Fatalf("determinant of the STM is %f", det)
+	}
+	if !mat64.EqualApprox(central, forward, 1e-2) {
+		t.Fatalf("forward and central STMs differ\n%v\n%v", mat64.Formatted(forward), mat64.Formatted(central))
+	}
+	if !mat64.EqualApprox(central, KeplerSTM(*o, dt), 1e-6) {
+		t.Fatal("the closed form STM differs from the central difference STM")
+	}
+}
@@ -0,0 +1,170 @@
+package smd
+
+import (
+	"math"
+)
+
+// ksSubsteps is the number of integration steps per orbit (in fictitious time) of the KS integrator.
+const ksSubsteps = 256
+
+// ksMatrix returns the KS matrix L(u), whose first three rows map the four dimensional parameters to the position.
+func ksMatrix(u []float64) [4][4]float64 {
+	return [4][4]float64{
+		{u[0], -u[1], -u[2], u[3]},
+		{u[1], u[0], -u[3], -u[2]},
+		{u[2], u[3], u[0], u[1]},
+		{u[3], -u[2], u[1], -u[0]}}
+}
+
+// ksLx returns L(u)x.
+func ksLx(L [4][4]float64, x []float64) []float64 {
+	y := make([]float64, 4)
+	for i := 0; i < 4; i++ {
+		for j := 0; j < len(x); j++ {
+			y[i] += L[i][j] * x[j]
+		}
+	}
+	return y
+}
+
+// ksLTx returns L(u)ᵀx.
+func ksLTx(L [4][4]float64, x []float64) []float64 {
+	y := make([]float64, 4)
+	for i := 0; i < 4; i++ {
+		for j := 0; j < len(x); j++ {
+			y[i] += L[j][i] * x[j]
+		}
+	}
+	return y
+}
+
+// ksFromRV returns the KS parameters u and their derivative u' with respect to the fictitious time from the
+// position and velocity. The singularity of the transformation on the negative X axis is avoided by the choice
+// of the free parameter.
+func ksFromRV(R, V []float64) (u, uPrime []float64) {
+	r := Norm(R)
+	u = make([]float64, 4)
+	if R[0] >= 0 {
+		u[0] = math.Sqrt((r + R[0]) / 2)
+		u[1] = R[1] / (2 * u[0])
+		u[2] = R[2] / (2 * u[0])
+	} else {
+		u[1] = math.Sqrt((r - R[0]) / 2)
+		u[0] = R[1] / (2 * u[1])
+		u[3] = R[2] / (2 * u[1])
+	}
+	uPrime = ksLTx(ksMatrix(u), V)
+	for i := range uPrime {
+		uPrime[i] /= 2
+	}
+	return
+}
+
+// ksToRV returns the position and velocity from the KS parameters and their derivative.
+func ksToRV(u, uPrime []float64) (R, V []float64) {
+	L := ksMatrix(u)
+	r := Dot(u, u)
+	R = ksLx(L, u)[:3]
+	V = ksLx(L, uPrime)[:3]
+	for i := range V {
+		V[i] *= 2 / r
+	}
+	return
+}
+
+// ksRK4 integrates a mission with the Kustaanheimo–Stiefel (KS) regularization: the Cartesian state is mapped
+// onto a four dimensional harmonic oscillator in the fictitious time s, where dt = r ds. The steps in fictitious
+// time correspond to physical steps proportional to the radius, which keeps the accuracy through very low periapsis
+// passes and near-collision trajectories where the two body acceleration blows up. Each step of the mission is
+// split into RK4 steps in fictitious time, the last of which lands on the end of the mission step.
+// The perturbations, the thrust, the fuel and the empirical accelerations are computed from the Func of the mission.
+type ksRK4 struct {
+	x0, stepSize float64
+	mission      *Mission
+}
+
+// Solve integrates until the mission stops, and returns the number of steps.
+func (k ksRK4) Solve() uint64 {
+	t := k.x0
+	var iter uint64
+	for !k.mission.Stop(t) {
+		state := k.step(k.mission.GetState())
+		t += k.stepSize
+		k.mission.SetState(t, state)
+		iter++
+	}
+	return iter
+}
+
+// step returns the Cartesian state after one mission step.
+func (k ksRK4) step(state []float64) []float64 {
+	μ := k.mission.Orbit.Origin.μ
+	// The KS state is u, u', the Kepler energy h = μ/r - v²/2, the time, and the other components of the state.
+	u, uPrime := ksFromRV(state[0:3], state[3:6])
+	y := make([]float64, 10+len(state)-6)
+	copy(y[0:4], u)
+	copy(y[4:8], uPrime)
+	y[8] = μ/Norm(state[0:3]) - Dot(state[3:6], state[3:6])/2
+	copy(y[10:], state[6:])
+	// The nominal step in fictitious time is set from the frequency of the oscillator, which is sqrt(h/2) for
+	// closed orbits, and from the local frequency for open ones.
+	for math.Abs(k.stepSize-y[9]) > 1e-9 {
+		r := Dot(y[0:4], y[0:4])
+		ω := math.Sqrt(μ / (2 * r))
+		if y[8] > 0 {
+			ω = math.Sqrt(y[8] / 2)
+		}
+		ds := math.Min(math.Pi/(ksSubsteps*ω), (k.stepSize-y[9])/r)
+		k1 := k.derivatives(y)
+		k2 := k.derivatives(ksAdd(y, k1, ds/2))
+		k3 := k.derivatives(ksAdd(y, k2, ds/2))
+		k4 := k.derivatives(ksAdd(y, k3, ds))
+		for i := range y {
+			y[i] += ds / 6 * (k1[i] + 2*k2[i] + 2*k3[i] + k4[i])
+		}
+	}
+	R, V := ksToRV(y[0:4], y[4:8])
+	copy(state[0:3], R)
+	copy(state[3:6], V)
+	copy(state[6:], y[10:])
+	return state
+}
+
+// derivatives returns the derivative of the KS state with respect to the fictitious time.
+func (k ksRK4) derivatives(y []float64) []float64 {
+	u, uPrime, h := y[0:4], y[4:8], y[8]
+	μ := k.mission.Orbit.Origin.μ
+	r := Dot(u, u)
+	R, V := ksToRV(u, uPrime)
+	state := make([]float64, len(y)-4)
+	copy(state[0:3], R)
+	copy(state[3:6], V)
+	copy(state[6:], y[10:])
+	fDot := k.mission.Func(y[9], state)
+	// Perturbing acceleration, i.e. everything but the two body acceleration.
+	P := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		P[i] = fDot[i+3] + μ*R[i]/math.Pow(r, 3)
+	}
+	LTP := ksLTx(ksMatrix(u), P)
+	yPrime := make([]float64, len(y))
+	for i := 0; i < 4; i++ {
+		yPrime[i] = uPrime[i]
+		yPrime[i+4] = -h/2*u[i] + r/2*LTP[i]
+	}
+	yPrime[8] = -2 * Dot(uPrime, LTP)
+	yPrime[9] = r
+	for i := 10; i < len(y); i++ {
+		yPrime[i] = r * fDot[i-4]
+	}
+	return yPrime
+}
+
+// ksAdd returns y + ds*yPrime.
+func ksAdd(y, yPrime []float64, ds float64) []float64 {
+	z := make([]float64, len(y))
+	for i := range y {
+		z[i] = y[i] + ds*yPrime[i]
+	}
+	return z
+}
@@ -0,0 +1,82 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestKSTransformation(t *testing.T) {
+	for _, R := range [][]float64{{7000, 100, -200}, {-7000, 100, -200}, {0, -42000, 1}, {-1, 0, 0}} {
+		V := []float64{-1.2, 7.3, 0.4}
+		u, uPrime := ksFromRV(R, V)
+		if !floats.EqualWithinAbs(Dot(u, u), Norm(R), 1e-9) {
+			t.Fatalf("|u|²=%f != r=%f", Dot(u, u), Norm(R))
+		}
+		// Bilinear relation which makes the transformation of the velocity unique.
+		if l := u[3]*uPrime[0] - u[2]*uPrime[1] + u[1]*uPrime[2] - u[0]*uPrime[3]; math.Abs(l) > 1e-9 {
+			t.Fatalf("bilinear relation not verified: %e", l)
+		}
+		R1, V1 := ksToRV(u, uPrime)
+		if !floats.EqualApprox(R, R1, 1e-9) || !floats.EqualApprox(V, V1, 1e-9) {
+			t.Fatalf("R=%+v V=%+v -> R=%+v V=%+v", R, V, R1, V1)
+		}
+	}
+}
+
+func TestKSIntegratorCloseApproach(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Periapsis 100 km from the center of a point mass Earth.
+	initial := func() *Orbit { return NewOrbitFromOE(20000, 0.995, 30, 40, 50, 60, Earth) }
+	duration := 3 * initial().Period()
+	step := time.Minute
+	exp := keplerPropagate(*initial(), duration.Truncate(step))
+	propagate := func(integrator Integrator) float64 {
+		o := initial()
+		m := NewPreciseMission(NewEmptySC(integrator.String(), 0), o, start, start.Add(duration.Truncate(step)+step), Perturbations{}, step, false, ExportConfig{})
+		m.Integrator = integrator
+		m.Propagate()
+		Δ := append([]float64(nil), o.R()...)
+		floats.Sub(Δ, exp.R())
+		return Norm(Δ)
+	}
+	ks := propagate(KSIntegrator)
+	if ks > 1e-2 {
+		t.Fatalf("KS position error of %e km after three close approaches", ks)
+	}
+	rk4 := propagate(RK4Integrator)
+	if !(rk4 > 1e3*ks) {
+		t.Fatalf("RK4 position error of %e km is not much larger than the KS one (%e km)", rk4, ks)
+	}
+	t.Logf("position error after three close approaches: RK4=%e km KS=%e km", rk4, ks)
+}
+
+func TestKSIntegratorPerturbations(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	duration := 6 * time.Hour
+	propagate := func(integrator Integrator, step time.Duration) (*Orbit, float64) {
+		o := NewOrbitFromOE(8000, 0.1, 30, 40, 50, 60, Earth)
+		sc := NewSpacecraft("thrust", 500, 100, NewUnlimitedEPS(), []EPThruster{new(PPS1350)}, false, []*Cargo{}, []Waypoint{NewReachDistance(20000, true, nil)})
+		m := NewPreciseMission(sc, o, start, start.Add(duration+step), Perturbations{Jn: 2}, step, false, ExportConfig{})
+		m.Integrator = integrator
+		m.Propagate()
+		return o, sc.FuelMass
+	}
+	exp, expFuel := propagate(RK4Integrator, time.Second)
+	o, fuel := propagate(KSIntegrator, time.Minute)
+	Δ := append([]float64(nil), o.R()...)
+	floats.Sub(Δ, exp.R())
+	if Norm(Δ) > 1e-2 {
+		t.Fatalf("position error of %e km with J2 and thrust", Norm(Δ))
+	}
+	if fuel >= 100 || !floats.EqualWithinAbs(fuel, expFuel, 1e-6) {
+		t.Fatalf("fuel %f kg != %f kg", fuel, expFuel)
+	}
+	assertPanic(t, func() {
+		m := NewPreciseMission(NewEmptySC("stm", 0), NewOrbitFromOE(8000, 0.1, 30, 40, 50, 60, Earth), start, start.Add(time.Hour), Perturbations{}, time.Minute, true, ExportConfig{})
+		m.Integrator = KSIntegrator
+		m.Propagate()
+	})
+}
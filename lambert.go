@@ -0,0 +1,214 @@
+package smd
+
+import (
+	"errors"
+	"math"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// LambertSolution stores a solution of the Lambert problem with its transfer orbit.
+type LambertSolution struct {
+	Vi, Vf []float64     // Initial and final velocities (km/s)
+	Ψ      float64       // Square of the difference in eccentric anomaly
+	TOF    time.Duration // Time of flight
+	Orbit  *Orbit        // Transfer orbit at departure
+	Type   TransferType  // Transfer type used to solve the problem
+}
+
+// Arrival returns the transfer orbit at arrival.
+func (s LambertSolution) Arrival() *Orbit {
+	return s.At(s.TOF)
+}
+
+// At returns the transfer orbit at the provided time after the departure.
+func (s LambertSolution) At(dt time.Duration) *Orbit {
+	return keplerPropagate(*s.Orbit, dt)
+}
+
+// Sample returns the transfer orbit at n+1 equally spaced epochs from the departure to the arrival (included).
+func (s LambertSolution) Sample(n int) []*Orbit {
+	if n < 1 {
+		panic("at least one sampling interval is required")
+	}
+	orbits := make([]*Orbit, n+1)
+	for k := 0; k <= n; k++ {
+		orbits[k] = s.At(time.Duration(float64(s.TOF) * float64(k) / float64(n)))
+	}
+	return orbits
+}
+
+// LambertTransfer solves the Lambert problem between the initial and final radii (km) and returns the solution
+// with its transfer orbit.
+func LambertTransfer(Ri, Rf []float64, tof time.Duration, ttype TransferType, body CelestialObject) (LambertSolution, error) {
+	g, err := newLambertGeometry(mat64.NewVector(3, Ri), mat64.NewVector(3, Rf), ttype, body)
+	if err != nil {
+		return LambertSolution{}, err
+	}
+	return g.transfer(tof, body)
+}
+
+// transfer returns the solution of the Lambert problem for the provided time of flight.
+func (g lambertGeometry) transfer(tof time.Duration, body CelestialObject) (LambertSolution, error) {
+	if tof <= 0 {
+		return LambertSolution{}, errors.New("time of flight must be positive")
+	}
+	Vi, Vf, ψ, err := g.solve(tof)
+	if err != nil {
+		return LambertSolution{}, err
+	}
+	vi := []float64{Vi.At(0, 0), Vi.At(1, 0), Vi.At(2, 0)}
+	vf := []float64{Vf.At(0, 0), Vf.At(1, 0), Vf.At(2, 0)}
+	Ri := []float64{g.Ri.At(0, 0), g.Ri.At(1, 0), g.Ri.At(2, 0)}
+	return LambertSolution{vi, vf, ψ, tof, NewOrbitFromRV(Ri, vi, body), g.ttype}, nil
+}
+
+// LambertProblem defines the boundary conditions of a Lambert problem.
+type LambertProblem struct {
+	Ri, Rf []float64 // Initial and final radii (km)
+	TOF    time.Duration
+}
+
+// LambertBatch solves all the Lambert problems concurrently, and returns the solutions and the errors in the order
+// of the problems. The direction of motion and the bounds of the multi-revolution solutions are only computed once
+// per pair of radii, so sweeps of the time of flight between the same radii are cheap.
+func LambertBatch(problems []LambertProblem, ttype TransferType, body CelestialObject) ([]LambertSolution, []error) {
+	type radii [6]float64
+	keys := make([]radii, len(problems))
+	geometries := make(map[radii]*lambertGeometry)
+	geometryErrs := make(map[radii]error)
+	var unique []radii
+	errs := make([]error, len(problems))
+	for k, p := range problems {
+		if len(p.Ri) != 3 || len(p.Rf) != 3 {
+			errs[k] = errors.New("initial and final radii must be 3x1 vectors")
+			continue
+		}
+		copy(keys[k][:3], p.Ri)
+		copy(keys[k][3:], p.Rf)
+		if _, exists := geometries[keys[k]]; !exists {
+			geometries[keys[k]] = nil
+			unique = append(unique, keys[k])
+		}
+	}
+	var mutex sync.Mutex
+	parallelFor(len(unique), func(k int) {
+		key := unique[k]
+		g, err := newLambertGeometry(mat64.NewVector(3, key[:3]), mat64.NewVector(3, key[3:]), ttype, body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		geometries[key] = &g
+		geometryErrs[key] = err
+	})
+	solutions := make([]LambertSolution, len(problems))
+	parallelFor(len(problems), func(k int) {
+		if errs[k] != nil {
+			return
+		}
+		if err := geometryErrs[keys[k]]; err != nil {
+			errs[k] = err
+			return
+		}
+		solutions[k], errs[k] = geometries[keys[k]].transfer(problems[k].TOF, body)
+	})
+	return solutions, errs
+}
+
+// LambertSweep solves the Lambert problem between the same radii for all the times of flight.
+func LambertSweep(Ri, Rf []float64, tofs []time.Duration, ttype TransferType, body CelestialObject) ([]LambertSolution, []error) {
+	problems := make([]LambertProblem, len(tofs))
+	for k, tof := range tofs {
+		problems[k] = LambertProblem{Ri, Rf, tof}
+	}
+	return LambertBatch(problems, ttype, body)
+}
+
+// parallelFor calls f for all indexes from 0 to n (excluded) on as many goroutines as there are CPUs. Blocking.
+func parallelFor(n int, f func(k int)) {
+	indexes := make(chan int, n)
+	for k := 0; k < n; k++ {
+		indexes <- k
+	}
+	close(indexes)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range indexes {
+				f(k)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// stumpff returns the c2 and c3 Stumpff functions of ψ.
+func stumpff(ψ float64) (c2, c3 float64) {
+	if ψ > 1e-6 {
+		sψ := math.Sqrt(ψ)
+		return (1 - math.Cos(sψ)) / ψ, (sψ - math.Sin(sψ)) / (sψ * ψ)
+	} else if ψ < -1e-6 {
+		sψ := math.Sqrt(-ψ)
+		return (1 - math.Cosh(sψ)) / ψ, (math.Sinh(sψ) - sψ) / (sψ * -ψ)
+	}
+	return 1 / 2., 1 / 6.
+}
+
+// keplerPropagate returns the two body orbit after the provided duration with the universal variable formulation
+// (Vallado, Algorithm 8), which is valid for all conics.
+func keplerPropagate(o Orbit, dt time.Duration) *Orbit {
+	R0, V0 := o.RV()
+	μ := o.Origin.μ
+	sμ := math.Sqrt(μ)
+	Δt := dt.Seconds()
+	r0 := Norm(R0)
+	rv := Dot(R0, V0) / sμ
+	χ, α := universalAnomaly(R0, V0, μ, Δt)
+	ψ := χ * χ * α
+	c2, c3 := stumpff(ψ)
+	r := χ*χ*c2 + rv*χ*(1-ψ*c3) + r0*(1-ψ*c2)
+	f := 1 - χ*χ/r0*c2
+	g := Δt - χ*χ*χ/sμ*c3
+	gDot := 1 - χ*χ/r*c2
+	fDot := sμ / (r * r0) * χ * (ψ*c3 - 1)
+	R := make([]float64, 3)
+	V := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		R[i] = f*R0[i] + g*V0[i]
+		V[i] = fDot*R0[i] + gDot*V0[i]
+	}
+	return NewOrbitFromRV(R, V, o.Origin)
+}
+
+// universalAnomaly returns the universal anomaly χ (√km) after Δt seconds from the provided position and velocity,
+// and the reciprocal of the semi-major axis α (1/km), by solving the universal Kepler equation with Newton's method.
+func universalAnomaly(R0, V0 []float64, μ, Δt float64) (χ, α float64) {
+	sμ := math.Sqrt(μ)
+	r0 := Norm(R0)
+	rv := Dot(R0, V0) / sμ
+	α = 2/r0 - Dot(V0, V0)/μ
+	// Initial guess of the universal variable.
+	χ = sμ * Δt / r0
+	if α > 1e-6 {
+		χ = sμ * Δt * α
+	} else if α < -1e-6 && Δt != 0 {
+		a := 1 / α
+		sign := math.Copysign(1, Δt)
+		χ = sign * math.Sqrt(-a) * math.Log((-2*μ*α*Δt)/(Dot(R0, V0)+sign*math.Sqrt(-μ*a)*(1-r0*α)))
+	}
+	for iter := 0; iter < 100; iter++ {
+		ψ := χ * χ * α
+		c2, c3 := stumpff(ψ)
+		r := χ*χ*c2 + rv*χ*(1-ψ*c3) + r0*(1-ψ*c2)
+		Δχ := (sμ*Δt - χ*χ*χ*c3 - rv*χ*χ*c2 - r0*χ*(1-ψ*c3)) / r
+		χ += Δχ
+		if math.Abs(Δχ) < 1e-12*math.Max(1, math.Abs(χ)) {
+			break
+		}
+	}
+	return
+}
@@ -0,0 +1,90 @@
+package smd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestLambertTransfer(t *testing.T) {
+	// From Vallado 4th edition, page 497
+	Ri := []float64{15945.34, 0, 0}
+	Rf := []float64{12214.83899, 10249.46731, 0}
+	sol, err := LambertTransfer(Ri, Rf, 76*time.Minute, TType1, Earth)
+	if err != nil {
+		t.Fatalf("err %s", err)
+	}
+	if !floats.EqualApprox(sol.Vi, []float64{2.058913, 2.915965, 0}, 1e-6) || !floats.EqualApprox(sol.Vf, []float64{-3.451565, 0.910315, 0}, 1e-6) {
+		t.Fatalf("Vi=%+v Vf=%+v", sol.Vi, sol.Vf)
+	}
+	if !floats.EqualApprox(sol.Orbit.R(), Ri, 1e-9) || !floats.EqualApprox(sol.Orbit.V(), sol.Vi, 1e-9) {
+		t.Fatalf("invalid transfer orbit at departure: %s", sol.Orbit)
+	}
+	arrival := sol.Arrival()
+	if !floats.EqualApprox(arrival.R(), Rf, 1e-3) || !floats.EqualApprox(arrival.V(), sol.Vf, 1e-6) {
+		t.Fatalf("transfer orbit at arrival R=%+v V=%+v", arrival.R(), arrival.V())
+	}
+	samples := sol.Sample(10)
+	if len(samples) != 11 || !floats.EqualApprox(samples[0].R(), Ri, 1e-9) || !floats.EqualApprox(samples[10].R(), Rf, 1e-3) {
+		t.Fatal("invalid sampling of the transfer")
+	}
+	// The transfer is on a conic: the specific energy is that of the departure.
+	for _, o := range samples {
+		if !floats.EqualWithinAbs(o.Energyξ(), sol.Orbit.Energyξ(), 1e-9) {
+			t.Fatalf("energy %f != %f", o.Energyξ(), sol.Orbit.Energyξ())
+		}
+	}
+	if _, err := LambertTransfer(Ri, Rf, 0, TType1, Earth); err == nil {
+		t.Fatal("null time of flight should fail")
+	}
+	assertPanic(t, func() {
+		sol.Sample(0)
+	})
+}
+
+func TestKeplerPropagateHyperbolic(t *testing.T) {
+	o := NewOrbitFromRV([]float64{7000, 100, 500}, []float64{0.5, 12, 1}, Earth)
+	for _, dt := range []time.Duration{-time.Hour, 0, 5 * time.Hour} {
+		back := keplerPropagate(*keplerPropagate(*o, dt), -dt)
+		if !floats.EqualApprox(back.R(), o.R(), 1e-6) || !floats.EqualApprox(back.V(), o.V(), 1e-9) {
+			t.Fatalf("dt=%s: R=%+v V=%+v != R=%+v V=%+v", dt, back.R(), back.V(), o.R(), o.V())
+		}
+	}
+}
+
+func TestLambertSweep(t *testing.T) {
+	Ri := []float64{15945.34, 0, 0}
+	Rf := []float64{12214.83899, 10249.46731, 0}
+	var tofs []time.Duration
+	for tof := 30 * time.Minute; tof <= 5*time.Hour; tof += 10 * time.Minute {
+		tofs = append(tofs, tof)
+	}
+	for _, ttype := range []TransferType{TTypeAuto, TType2} {
+		sols, errs := LambertSweep(Ri, Rf, tofs, ttype, Earth)
+		if len(sols) != len(tofs) || len(errs) != len(tofs) {
+			t.Fatal("invalid number of solutions")
+		}
+		for k, tof := range tofs {
+			exp, expErr := LambertTransfer(Ri, Rf, tof, ttype, Earth)
+			if (expErr == nil) != (errs[k] == nil) {
+				t.Fatalf("[%s] tof=%s: err=%v expected %v", ttype, tof, errs[k], expErr)
+			}
+			if expErr != nil {
+				continue
+			}
+			if sols[k].TOF != tof || !floats.Equal(sols[k].Vi, exp.Vi) || !floats.Equal(sols[k].Vf, exp.Vf) {
+				t.Fatalf("[%s] tof=%s: batched solution differs", ttype, tof)
+			}
+		}
+	}
+	// Several pairs of radii in the same batch, with an invalid one.
+	problems := []LambertProblem{{Ri, Rf, 76 * time.Minute}, {Rf, Ri, 76 * time.Minute}, {Ri, []float64{1, 2}, time.Hour}}
+	sols, errs := LambertBatch(problems, TType1, Earth)
+	if errs[0] != nil || errs[1] != nil || errs[2] == nil {
+		t.Fatalf("errs=%+v", errs)
+	}
+	if !floats.EqualApprox(sols[1].Orbit.R(), Rf, 1e-9) {
+		t.Fatal("solutions are not in the order of the problems")
+	}
+}
@@ -0,0 +1,184 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// LaunchVehicle defines the performance of a launch vehicle as the injected mass versus C3, linearly interpolated,
+// reduced by a factor versus the absolute DLA to account for the dog-leg maneuvers and the range safety limits
+// of the launch site. The mass is zero beyond the last point of either curve.
+type LaunchVehicle struct {
+	Name      string
+	C3        []float64 // km²/s², increasing
+	Mass      []float64 // Injected mass at each C3 (kg)
+	DLA       []float64 // Absolute DLA in degrees, increasing
+	DLAFactor []float64 // Fraction of the injected mass available at each DLA
+}
+
+// capeDLA is the DLA performance factor of a launch from Cape Canaveral (28.5° latitude).
+var capeDLA, capeDLAFactor = []float64{0, 28.5, 35, 45, 57}, []float64{1, 1, 0.93, 0.75, 0.45}
+
+// Approximate performance of common vehicles launching from Cape Canaveral, for preliminary design only (derived
+// from the public NASA ELV performance figures).
+var (
+	AtlasV401    = LaunchVehicle{"Atlas V 401", []float64{0, 10, 20, 30, 40, 50}, []float64{3000, 2400, 1900, 1450, 1050, 700}, capeDLA, capeDLAFactor}
+	AtlasV551    = LaunchVehicle{"Atlas V 551", []float64{0, 10, 20, 30, 40, 60, 80, 100}, []float64{6150, 5100, 4200, 3400, 2700, 1550, 700, 100}, capeDLA, capeDLAFactor}
+	DeltaIVHeavy = LaunchVehicle{"Delta IV Heavy", []float64{0, 10, 20, 30, 40, 60, 80, 100}, []float64{10000, 8300, 6900, 5700, 4650, 2900, 1550, 500}, capeDLA, capeDLAFactor}
+	FalconHeavy  = LaunchVehicle{"Falcon Heavy (expendable)", []float64{0, 10, 20, 30, 40, 60, 80, 100}, []float64{15000, 12300, 10000, 8100, 6500, 3900, 2000, 600}, capeDLA, capeDLAFactor}
+)
+
+// interpolate returns the linear interpolation of the curve at x, and false if x is out of the curve.
+func interpolate(xs, ys []float64, x float64) (float64, bool) {
+	if len(xs) == 0 || x < xs[0] || x > xs[len(xs)-1] {
+		return 0, false
+	}
+	for k := 1; k < len(xs); k++ {
+		if x <= xs[k] {
+			return ys[k-1] + (ys[k]-ys[k-1])*(x-xs[k-1])/(xs[k]-xs[k-1]), true
+		}
+	}
+	return ys[len(ys)-1], true
+}
+
+// InjectedMass returns the mass (kg) which this vehicle can inject on the provided C3 (km²/s²) and DLA (degrees).
+func (lv LaunchVehicle) InjectedMass(C3, DLA float64) float64 {
+	mass, ok := interpolate(lv.C3, lv.Mass, math.Max(C3, lv.C3[0]))
+	if !ok {
+		return 0
+	}
+	if len(lv.DLA) > 0 {
+		factor, ok := interpolate(lv.DLA, lv.DLAFactor, math.Abs(DLA))
+		if !ok {
+			return 0
+		}
+		mass *= factor
+	}
+	return mass
+}
+
+// MaxC3 returns the maximum C3 (km²/s²) at which this vehicle can inject the provided mass (kg) at the DLA
+// (degrees), or a negative value if the mass cannot be launched at all.
+func (lv LaunchVehicle) MaxC3(mass, DLA float64) float64 {
+	if lv.InjectedMass(lv.C3[0], DLA) < mass {
+		return -1
+	}
+	// The injected mass decreases with the C3, so bisect between the bounds of the curve.
+	low, high := lv.C3[0], lv.C3[len(lv.C3)-1]
+	if lv.InjectedMass(high, DLA) >= mass {
+		return high
+	}
+	for high-low > 1e-6 {
+		mid := (low + high) / 2
+		if lv.InjectedMass(mid, DLA) >= mass {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return low
+}
+
+func (lv LaunchVehicle) String() string {
+	return lv.Name
+}
+
+// LaunchWindow scans the direct Lambert transfers from one body to another over a range of launch dates and times
+// of flight, and reports the mass injected by the launch vehicle.
+type LaunchWindow struct {
+	From, To               CelestialObject
+	LaunchStart, LaunchEnd time.Time
+	LaunchStep             time.Duration
+	MinTOF, MaxTOF         time.Duration
+	TOFStep                time.Duration
+	Vehicle                LaunchVehicle
+	Ephemeris              EphemerisFunc
+}
+
+// NewLaunchWindow returns a new launch window with daily steps, using the configured ephemerides.
+func NewLaunchWindow(from, to CelestialObject, launchStart, launchEnd time.Time, minTOF, maxTOF time.Duration, lv LaunchVehicle) LaunchWindow {
+	if launchEnd.Before(launchStart) {
+		panic("launch window ends before it starts")
+	}
+	if maxTOF < minTOF {
+		panic("maximum TOF is less than the minimum TOF")
+	}
+	day := 24 * time.Hour
+	return LaunchWindow{from, to, launchStart, launchEnd, day, minTOF, maxTOF, day, lv, HelioEphemeris}
+}
+
+// LaunchOpportunity stores one transfer of a launch window.
+type LaunchOpportunity struct {
+	Launch, Arrival time.Time
+	Departure       DepartureAsymptote
+	VInfArrival     float64 // km/s
+	Mass            float64 // Injected mass (kg)
+}
+
+func (o LaunchOpportunity) String() string {
+	return fmt.Sprintf("launch %s arrival %s: %s, v∞ arrival=%.3f km/s, mass=%.0f kg", o.Launch.Format("2006-01-02"), o.Arrival.Format("2006-01-02"), o.Departure, o.VInfArrival, o.Mass)
+}
+
+// Scan returns all the transfers of the launch window for which the Lambert problem could be solved.
+func (w LaunchWindow) Scan() []LaunchOpportunity {
+	var launches, arrivals []time.Time
+	var Vpis, Vpfs [][]float64
+	var problems []LambertProblem
+	for launch := w.LaunchStart; !launch.After(w.LaunchEnd); launch = launch.Add(w.LaunchStep) {
+		Ri, Vpi := w.Ephemeris(w.From, launch)
+		for tof := w.MinTOF; tof <= w.MaxTOF; tof += w.TOFStep {
+			arrival := launch.Add(tof)
+			Rf, Vpf := w.Ephemeris(w.To, arrival)
+			launches = append(launches, launch)
+			arrivals = append(arrivals, arrival)
+			Vpis = append(Vpis, Vpi)
+			Vpfs = append(Vpfs, Vpf)
+			problems = append(problems, LambertProblem{Ri, Rf, tof})
+		}
+	}
+	var opportunities []LaunchOpportunity
+	solutions, errs := LambertBatch(problems, TTypeAuto, Sun)
+	for k, sol := range solutions {
+		if errs[k] != nil {
+			continue
+		}
+		departure := LambertDeparture(mat64.NewVector(3, sol.Vi), mat64.NewVector(3, Vpis[k]), w.From, launches[k])
+		vInfArr := make([]float64, 3)
+		for i := 0; i < 3; i++ {
+			vInfArr[i] = sol.Vf[i] - Vpfs[k][i]
+		}
+		opportunities = append(opportunities, LaunchOpportunity{launches[k], arrivals[k], departure, Norm(vInfArr), w.Vehicle.InjectedMass(departure.C3, departure.DLA)})
+	}
+	return opportunities
+}
+
+// BestOpportunity returns the opportunity with the largest injected mass, and false if there is none.
+func BestOpportunity(opportunities []LaunchOpportunity) (LaunchOpportunity, bool) {
+	best := -1
+	for k, o := range opportunities {
+		if best < 0 || o.Mass > opportunities[best].Mass {
+			best = k
+		}
+	}
+	if best < 0 {
+		return LaunchOpportunity{}, false
+	}
+	return opportunities[best], true
+}
+
+// WriteLaunchWindow writes the launch opportunities as a CSV table.
+func WriteLaunchWindow(w io.Writer, opportunities []LaunchOpportunity) error {
+	if _, err := fmt.Fprint(w, "launch,arrival,tofDays,c3,rla,dla,vInfArrival,mass\n"); err != nil {
+		return err
+	}
+	for _, o := range opportunities {
+		if _, err := fmt.Fprintf(w, "%s,%s,%.3f,%.6f,%.3f,%.3f,%.6f,%.1f\n", o.Launch.UTC().Format(time.RFC3339), o.Arrival.UTC().Format(time.RFC3339), o.Arrival.Sub(o.Launch).Hours()/24, o.Departure.C3, o.Departure.RLA, o.Departure.DLA, o.VInfArrival, o.Mass); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestLaunchVehicle(t *testing.T) {
+	lv := AtlasV551
+	if m := lv.InjectedMass(10, 20); m != 5100 {
+		t.Fatalf("mass at C3=10 is %f", m)
+	}
+	if m := lv.InjectedMass(15, -20); !floats.EqualWithinAbs(m, 4650, 1e-9) {
+		t.Fatalf("mass at C3=15 is %f", m)
+	}
+	if m := lv.InjectedMass(-5, 0); m != 6150 {
+		t.Fatalf("mass at negative C3 is %f", m)
+	}
+	// Above the latitude of the launch site, the dog-leg reduces the mass.
+	if m := lv.InjectedMass(10, 40); !floats.EqualWithinAbs(m, 5100*0.84, 1e-9) {
+		t.Fatalf("mass at DLA=40 is %f", m)
+	}
+	if lv.InjectedMass(120, 0) != 0 || lv.InjectedMass(10, 60) != 0 {
+		t.Fatal("mass beyond the performance curves should be zero")
+	}
+	C3 := lv.MaxC3(3000, 10)
+	if !floats.EqualWithinAbs(lv.InjectedMass(C3, 10), 3000, 1e-3) {
+		t.Fatalf("max C3 %f injects %f kg", C3, lv.InjectedMass(C3, 10))
+	}
+	if lv.MaxC3(7000, 0) >= 0 || lv.MaxC3(50, 0) != 100 {
+		t.Fatal("invalid max C3 bounds")
+	}
+}
+
+// hohmannEphemeris returns the ephemeris of circular Earth and Mars orbits, phased for a Hohmann transfer at the
+// epoch.
+func hohmannEphemeris(epoch time.Time) EphemerisFunc {
+	rE, rM := AU, 1.524*AU
+	nE, nM := math.Sqrt(Sun.μ/math.Pow(rE, 3)), math.Sqrt(Sun.μ/math.Pow(rM, 3))
+	tH := math.Pi * math.Sqrt(math.Pow((rE+rM)/2, 3)/Sun.μ)
+	return func(body CelestialObject, dt time.Time) (R, V []float64) {
+		t := dt.Sub(epoch).Seconds()
+		r, θ, n, inc := rE, nE*t, nE, 0.
+		if body.Equals(Mars) {
+			r, θ, n, inc = rM, math.Pi-nM*tH+nM*t, nM, Deg2rad(1.85)
+		}
+		sθ, cθ := math.Sincos(θ)
+		return MxV33(R1(-inc), []float64{r * cθ, r * sθ, 0}), MxV33(R1(-inc), []float64{-r * n * sθ, r * n * cθ, 0})
+	}
+}
+
+func TestLaunchWindow(t *testing.T) {
+	epoch := time.Date(2020, 7, 20, 0, 0, 0, 0, time.UTC)
+	rE, rM := AU, 1.524*AU
+	ephem := hohmannEphemeris(epoch)
+	w := NewLaunchWindow(Earth, Mars, epoch.Add(-30*24*time.Hour), epoch.Add(30*24*time.Hour), 200*24*time.Hour, 320*24*time.Hour, AtlasV551)
+	w.LaunchStep = 5 * 24 * time.Hour
+	w.TOFStep = 10 * 24 * time.Hour
+	w.Ephemeris = ephem
+	opportunities := w.Scan()
+	if len(opportunities) == 0 {
+		t.Fatal("no opportunities")
+	}
+	best, ok := BestOpportunity(opportunities)
+	if !ok {
+		t.Fatal("no best opportunity")
+	}
+	vH := math.Sqrt(Sun.μ/rE) * (math.Sqrt(2*rM/(rE+rM)) - 1)
+	if best.Departure.C3 < vH*vH || best.Departure.C3 > 2*vH*vH || math.Abs(best.Departure.DLA) > 40 {
+		t.Fatalf("unexpected best opportunity (Hohmann C3=%f): %s", vH*vH, best)
+	}
+	for _, o := range opportunities {
+		if o.Mass > best.Mass || o.Mass != AtlasV551.InjectedMass(o.Departure.C3, o.Departure.DLA) {
+			t.Fatalf("invalid mass of %s", o)
+		}
+	}
+	var buf bytes.Buffer
+	if err := WriteLaunchWindow(&buf, opportunities); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != len(opportunities)+1 {
+		t.Fatalf("%d lines for %d opportunities", len(lines), len(opportunities))
+	}
+	if _, ok := BestOpportunity(nil); ok {
+		t.Fatal("best opportunity of an empty scan")
+	}
+	assertPanic(t, func() {
+		NewLaunchWindow(Earth, Mars, epoch, epoch.Add(-time.Hour), 0, time.Hour, AtlasV551)
+	})
+}
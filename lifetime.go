@@ -0,0 +1,95 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// ReentryAltitude is the perigee altitude (km) at which a spacecraft is considered to have reentered.
+	ReentryAltitude   = 120.
+	lifetimeMaxYears  = 100  // Maximum duration of the lifetime estimation.
+	lifetimeAnomalies = 36   // Number of mean anomalies used to average the drag over one orbit.
+	lifetimeMaxΔa     = 1e-3 // Maximum relative change of the semi-major axis per step.
+)
+
+// DecayState stores the orbit shape during the decay.
+type DecayState struct {
+	Elapsed         time.Duration
+	A, E            float64 // Semi-major axis (km) and eccentricity
+	Perigee, Apogee float64 // Altitudes in km
+	DaDt, DeDt      float64 // Orbit averaged rates per day
+}
+
+func (s DecayState) String() string {
+	return fmt.Sprintf("%s: a=%.3f km e=%.6f hp=%.3f km ha=%.3f km", s.Elapsed, s.A, s.E, s.Perigee, s.Apogee)
+}
+
+// LifetimeEstimate stores the result of a lifetime estimation.
+type LifetimeEstimate struct {
+	Duration  time.Duration // Duration until reentry, or the maximum duration if the orbit did not decay
+	Reentered bool
+	History   []DecayState
+}
+
+// Lifetime estimates the orbital lifetime of the spacecraft about the Earth, using orbit averaged drag rates
+// of the semi-major axis and eccentricity (fast semi-analytic propagation). The spacecraft must have a drag
+// coefficient and area. The reentry epoch is the epoch of the orbit plus the returned duration.
+func Lifetime(o Orbit, sc Spacecraft, sw SpaceWeather) LifetimeEstimate {
+	if !o.Origin.Equals(Earth) {
+		panic("lifetime estimation is only supported about the Earth")
+	}
+	if sc.Cd <= 0 || sc.Area <= 0 {
+		panic("spacecraft Cd and Area must be strictly positive")
+	}
+	// Ballistic factor in km²/kg, the density will be converted to kg/km³.
+	B := sc.Cd * sc.Area * 1e-6 / sc.Mass(time.Time{})
+	a, e, _, _, _, _, _, _, _ := o.Elements()
+	est := LifetimeEstimate{}
+	maxDuration := time.Duration(lifetimeMaxYears*365.25*24) * time.Hour
+	var elapsed time.Duration
+	for {
+		daDt, deDt := dragDecayRates(a, e, B, sw)
+		state := DecayState{elapsed, a, e, a*(1-e) - Earth.Radius, a*(1+e) - Earth.Radius, daDt * 86400, deDt * 86400}
+		est.History = append(est.History, state)
+		if state.Perigee <= ReentryAltitude {
+			est.Reentered = true
+			break
+		}
+		if elapsed >= maxDuration {
+			break
+		}
+		// Adapt the step to the decay rate, between one orbit and one day.
+		step := math.Min(86400, lifetimeMaxΔa*a/math.Abs(daDt))
+		step = math.Max(step, 2*math.Pi*math.Sqrt(math.Pow(a, 3)/Earth.μ))
+		a += daDt * step
+		e = math.Max(0, e+deDt*step)
+		elapsed += time.Duration(step * 1e9)
+	}
+	est.Duration = elapsed
+	return est
+}
+
+// dragDecayRates returns the orbit averaged rates of the semi-major axis (km/s) and eccentricity (1/s) due to drag.
+func dragDecayRates(a, e, B float64, sw SpaceWeather) (daDt, deDt float64) {
+	μ := Earth.μ
+	for k := 0; k < lifetimeAnomalies; k++ {
+		M := 2 * math.Pi * float64(k) / lifetimeAnomalies
+		// Solve Kepler's equation.
+		E := M
+		for iter := 0; iter < 20; iter++ {
+			E -= (E - e*math.Sin(E) - M) / (1 - e*math.Cos(E))
+		}
+		cE := math.Cos(E)
+		r := a * (1 - e*cE)
+		cosν := (cE - e) / (1 - e*cE)
+		v := math.Sqrt(μ * (2/r - 1/a))
+		ρ := EarthAtmosphereDensity(r-Earth.Radius, sw) * 1e9
+		// Tangential drag acceleration (atmosphere rotation is neglected).
+		aT := -0.5 * ρ * B * v * v
+		daDt += 2 * a * a * v / μ * aT
+		deDt += 2 * (e + cosν) / v * aT
+	}
+	return daDt / lifetimeAnomalies, deDt / lifetimeAnomalies
+}
@@ -0,0 +1,50 @@
+package smd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLifetime(t *testing.T) {
+	cubesat := NewEmptySC("3U", 4)
+	cubesat.Cd = 2.2
+	cubesat.Area = 0.03
+	years := func(d time.Duration) float64 {
+		return d.Hours() / (24 * 365.25)
+	}
+	est := Lifetime(*NewOrbitFromOE(Earth.Radius+400, 0, 51.6, 0, 0, 0, Earth), *cubesat, ModerateSpaceWeather)
+	if !est.Reentered {
+		t.Fatal("cubesat at 400 km should reenter")
+	}
+	if y := years(est.Duration); y < 0.3 || y > 5 {
+		t.Fatalf("unexpected lifetime of %f years", y)
+	}
+	for i := 1; i < len(est.History); i++ {
+		if est.History[i].A >= est.History[i-1].A || est.History[i].Elapsed <= est.History[i-1].Elapsed {
+			t.Fatalf("invalid decay history at %s", est.History[i])
+		}
+	}
+	if last := est.History[len(est.History)-1]; last.Perigee > ReentryAltitude {
+		t.Fatalf("last state is not reentry: %s", last)
+	}
+	// Higher solar activity, lower altitude or higher eccentricity with the same perigee change the lifetime.
+	if high := Lifetime(*NewOrbitFromOE(Earth.Radius+400, 0, 51.6, 0, 0, 0, Earth), *cubesat, SpaceWeather{250, 250, 50}); high.Duration >= est.Duration {
+		t.Fatal("higher solar activity should shorten the lifetime")
+	}
+	if low := Lifetime(*NewOrbitFromOE(Earth.Radius+300, 0, 51.6, 0, 0, 0, Earth), *cubesat, ModerateSpaceWeather); low.Duration >= est.Duration {
+		t.Fatal("lower orbit should shorten the lifetime")
+	}
+	a, e := Radii2ae(Earth.Radius+1000, Earth.Radius+400)
+	eccentric := Lifetime(*NewOrbitFromOE(a, e, 51.6, 0, 0, 0, Earth), *cubesat, ModerateSpaceWeather)
+	if eccentric.Duration <= est.Duration {
+		t.Fatal("higher apogee should lengthen the lifetime")
+	}
+	if eccentric.History[len(eccentric.History)-1].E >= e {
+		t.Fatal("drag should circularize the orbit")
+	}
+	t.Logf("400 km: %.2f years, 400x1000 km: %.2f years", years(est.Duration), years(eccentric.Duration))
+	noDrag := NewEmptySC("nodrag", 4)
+	assertPanic(t, func() {
+		Lifetime(*NewOrbitFromOE(Earth.Radius+400, 0, 51.6, 0, 0, 0, Earth), *noDrag, ModerateSpaceWeather)
+	})
+}
@@ -0,0 +1,49 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+)
+
+// boltzmannDB is the Boltzmann constant in dBW/K/Hz.
+var boltzmannDB = 10 * math.Log10(1.380649e-23)
+
+// LinkBudget defines the RF link between a station and the transponder of a spacecraft. When set on a station, the
+// measurement noise scales with the SNR of the link, and the contacts whose SNR is below the threshold are dropped.
+type LinkBudget struct {
+	TransmitPower          float64 // dBW
+	TransmitGain           float64 // dBi
+	ReceiveGain            float64 // dBi
+	Frequency              float64 // Hz
+	SystemNoiseTemperature float64 // K
+	Bandwidth              float64 // Noise bandwidth (Hz)
+	Losses                 float64 // Other losses, e.g. pointing, polarization and atmospheric (dB)
+	MinSNR                 float64 // Threshold below which no measurement is possible (dB)
+	ReferenceSNR           float64 // SNR at which the noise of the station applies (dB)
+}
+
+// FreeSpaceLoss returns the free space path loss (dB) at the provided range (km).
+func (l LinkBudget) FreeSpaceLoss(ρ float64) float64 {
+	return 20 * math.Log10(4*math.Pi*ρ*l.Frequency/SpeedOfLight)
+}
+
+// SNR returns the signal to noise ratio (dB) of the link at the provided range (km).
+func (l LinkBudget) SNR(ρ float64) float64 {
+	received := l.TransmitPower + l.TransmitGain + l.ReceiveGain - l.FreeSpaceLoss(ρ) - l.Losses
+	noise := boltzmannDB + 10*math.Log10(l.SystemNoiseTemperature*l.Bandwidth)
+	return received - noise
+}
+
+// MaxRange returns the range (km) at which the SNR of the link reaches the threshold.
+func (l LinkBudget) MaxRange() float64 {
+	return math.Pow(10, (l.SNR(1)-l.MinSNR)/20)
+}
+
+// noiseScale returns the scaling of the standard deviation of the reference noise at the provided SNR (dB).
+func (l LinkBudget) noiseScale(snr float64) float64 {
+	return math.Sqrt(math.Pow(10, (l.ReferenceSNR-snr)/10))
+}
+
+func (l LinkBudget) String() string {
+	return fmt.Sprintf("%.1f dBW, Gt=%.1f dBi, Gr=%.1f dBi, f=%.3f GHz, Tsys=%.1f K, B=%.1f Hz, min SNR=%.1f dB", l.TransmitPower, l.TransmitGain, l.ReceiveGain, l.Frequency/1e9, l.SystemNoiseTemperature, l.Bandwidth, l.MinSNR)
+}
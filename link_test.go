@@ -0,0 +1,64 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestLinkBudget(t *testing.T) {
+	link := LinkBudget{TransmitPower: 13, TransmitGain: 20, ReceiveGain: 60, Frequency: 8.4e9, SystemNoiseTemperature: 30, Bandwidth: 1e3, Losses: 3, MinSNR: 10, ReferenceSNR: 30}
+	// Linear computation of the link.
+	ρ := 40000.
+	λ := SpeedOfLight / link.Frequency
+	received := math.Pow(10, (13+20+60-3)/10.) * math.Pow(λ/(4*math.Pi*ρ), 2)
+	noise := 1.380649e-23 * 30 * 1e3
+	if exp := 10 * math.Log10(received/noise); !floats.EqualWithinAbs(link.SNR(ρ), exp, 1e-9) {
+		t.Fatalf("SNR=%f dB expected %f dB", link.SNR(ρ), exp)
+	}
+	// The SNR decreases by 6 dB when doubling the range.
+	if !floats.EqualWithinAbs(link.SNR(ρ)-link.SNR(2*ρ), 20*math.Log10(2), 1e-9) {
+		t.Fatal("invalid range dependence")
+	}
+	if !floats.EqualWithinAbs(link.SNR(link.MaxRange()), link.MinSNR, 1e-9) {
+		t.Fatalf("SNR at the maximum range of %f dB", link.SNR(link.MaxRange()))
+	}
+}
+
+func TestStationLinkBudget(t *testing.T) {
+	dt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := NewStation("st", 0, 0, 40, -105, 0, 0).WithNoise(1e-6, 1e-10, 3)
+	link := LinkBudget{TransmitPower: 13, TransmitGain: 20, ReceiveGain: 60, Frequency: 8.4e9, SystemNoiseTemperature: 30, Bandwidth: 1e3, Losses: 3, MinSNR: 10}
+	st.Link = &link
+	θ := Earth.RotationAngle(dt)
+	above := func(alt float64) State {
+		R := ECEF2ECI(GEO2BodyFixed(alt, Deg2rad(40), Deg2rad(-105), Earth), θ)
+		return State{DT: dt, Orbit: *NewOrbitFromRV(R, []float64{0, 1, 0}, Earth)}
+	}
+	maxAlt := link.MaxRange()
+	if m := st.PerformMeasurement(θ, above(1.1*maxAlt)); m.Visible {
+		t.Fatalf("contact beyond the maximum range with SNR=%f dB", m.SNR)
+	}
+	// The noise variance is that of the station at the reference SNR, and decreases with the SNR.
+	alt := 0.5 * maxAlt
+	link.ReferenceSNR = link.SNR(alt) + 10
+	n := 5000
+	var sum float64
+	for i := 0; i < n; i++ {
+		m := st.PerformMeasurement(θ, above(alt))
+		if !m.Visible || !floats.EqualWithinAbs(m.SNR, link.SNR(m.TrueRange), 1e-12) {
+			t.Fatalf("invalid contact with SNR=%f dB", m.SNR)
+		}
+		sum += math.Pow(m.Range-m.TrueRange, 2)
+	}
+	if variance := sum / float64(n); !floats.EqualWithinRel(variance, 1e-5, 0.1) {
+		t.Fatalf("range variance of %e km²", variance)
+	}
+	// Without link budget, the SNR is infinite.
+	st.Link = nil
+	if m := st.PerformMeasurement(θ, above(alt)); !math.IsInf(m.SNR, 1) {
+		t.Fatalf("SNR=%f dB without link", m.SNR)
+	}
+}
@@ -0,0 +1,42 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// LowThrustΔv returns an analytical approximation of the Δv (in km/s) of a continuous thrust transfer between two
+// near circular orbits. The change of semi-major axis and of orbital plane are combined with Edelbaum's
+// approximation, and the changes of eccentricity and of argument of periapsis are added in quadrature with their
+// optimal averaged rates, i.e. Δv = 2/3 v Δe and Δv = 2/3 v e Δω.
+func LowThrustΔv(oInit, oTarget Orbit) float64 {
+	if !oInit.Origin.Equals(oTarget.Origin) {
+		panic(fmt.Errorf("orbits around %s and %s", oInit.Origin, oTarget.Origin))
+	}
+	μ := oInit.Origin.μ
+	a0, e0, _, _, ω0, _, _, _, _ := oInit.Elements()
+	a1, e1, _, _, ω1, _, _, _, _ := oTarget.Elements()
+	v0, v1 := math.Sqrt(μ/a0), math.Sqrt(μ/a1)
+	// Angle between the orbital planes, which accounts for both the inclination and the RAAN changes.
+	θ := math.Atan2(Norm(Cross(oInit.H(), oTarget.H())), Dot(oInit.H(), oTarget.H()))
+	Δvai := math.Sqrt(v0*v0 + v1*v1 - 2*v0*v1*math.Cos(math.Pi/2*θ))
+	v := (v0 + v1) / 2
+	Δve := 2. / 3 * v * math.Abs(e1-e0)
+	Δvω := 2. / 3 * v * math.Min(e0, e1) * math.Abs(math.Remainder(ω1-ω0, 2*math.Pi))
+	return math.Sqrt(Δvai*Δvai + Δve*Δve + Δvω*Δvω)
+}
+
+// EstimateLowThrustTransfer returns the approximate time of flight and propellant mass (in kg) of a continuous
+// thrust transfer between two near circular orbits, without propagation. The thrust is in Newtons, the specific
+// impulse in seconds and the initial mass of the spacecraft in kg. The thrust is assumed constant and always on,
+// as in a TimeOptimal OrbitTarget, so this is a lower bound on the duration of a transfer which coasts.
+func EstimateLowThrustTransfer(oInit, oTarget Orbit, thrust, isp, mass float64) (tof time.Duration, fuel float64) {
+	if thrust <= 0 || isp <= 0 || mass <= 0 {
+		panic(fmt.Errorf("thrust (%f N), Isp (%f s) and mass (%f kg) must be strictly positive", thrust, isp, mass))
+	}
+	ve := isp * 9.807 // m/s
+	fuel = mass * (1 - math.Exp(-LowThrustΔv(oInit, oTarget)*1e3/ve))
+	tof = time.Duration(fuel * ve / thrust * float64(time.Second))
+	return
+}
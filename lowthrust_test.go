@@ -0,0 +1,52 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestEstimateLowThrustTransfer(t *testing.T) {
+	thrust, isp := new(PPS1350).Thrust(new(PPS1350).Max())
+	mass := 367.0
+	// Same cases as TestCorrectOEa and TestCorrectOEi, which use about 21 kg and 25 kg respectively.
+	oInit := NewOrbitFromOE(24396, 0.001, 0.001, 1, 1, 1, Earth)
+	oTarget := NewOrbitFromOE(42164, 0.001, 0.001, 1, 1, 1, Earth)
+	if Δv := LowThrustΔv(*oInit, *oTarget); !floats.EqualWithinAbs(Δv, math.Sqrt(Earth.μ/24396)-math.Sqrt(Earth.μ/42164), 1e-9) {
+		t.Fatalf("Δv=%f km/s is not the difference of the circular velocities", Δv)
+	}
+	tof, fuel := EstimateLowThrustTransfer(*oInit, *oTarget, thrust, isp, mass)
+	if !floats.EqualWithinAbs(fuel, 21, 1) {
+		t.Fatalf("Δa: fuel=%f kg", fuel)
+	}
+	if days := tof.Hours() / 24; days < 40 || days > 45 {
+		t.Fatalf("Δa: tof=%f days", days)
+	}
+	// The transfer is symmetric.
+	if tofBack, fuelBack := EstimateLowThrustTransfer(*oTarget, *oInit, thrust, isp, mass); tofBack != tof || fuelBack != fuel {
+		t.Fatalf("reverse transfer: %s %f kg", tofBack, fuelBack)
+	}
+
+	oInit = NewOrbitFromOE(Earth.Radius+350, 0.001, 46, 1, 1, 1, Earth)
+	oTarget = NewOrbitFromOE(Earth.Radius+350, 0.001, 51.6, 1, 1, 1, Earth)
+	tof, fuel = EstimateLowThrustTransfer(*oInit, *oTarget, thrust, isp, mass)
+	if !floats.EqualWithinAbs(fuel, 25, 1) {
+		t.Fatalf("Δi: fuel=%f kg", fuel)
+	}
+	if tof > time.Duration(55*24)*time.Hour {
+		t.Fatalf("Δi: tof=%s longer than the propagation", tof)
+	}
+
+	// No transfer needed.
+	if tof, fuel = EstimateLowThrustTransfer(*oInit, *oInit, thrust, isp, mass); tof != 0 || fuel != 0 {
+		t.Fatalf("identical orbits: %s %f kg", tof, fuel)
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("null thrust did not panic")
+		}
+	}()
+	EstimateLowThrustTransfer(*oInit, *oTarget, 0, isp, mass)
+}
@@ -0,0 +1,153 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Manifold stores a globalized invariant manifold of a periodic CR3BP orbit.
+type Manifold struct {
+	Orbit        PeriodicOrbit
+	Stable       bool // Stable manifolds are propagated backward in time.
+	Positive     bool // Branch of the manifold, i.e. sign of the eigenvector perturbation.
+	Trajectories []ManifoldTrajectory
+}
+
+// ManifoldTrajectory is a single trajectory of a manifold tube.
+// All times and states are normalized and are stored in chronological order.
+type ManifoldTrajectory struct {
+	T      []float64
+	States [][]float64
+}
+
+func (m Manifold) String() string {
+	kind := "unstable"
+	if m.Stable {
+		kind = "stable"
+	}
+	branch := "+"
+	if !m.Positive {
+		branch = "-"
+	}
+	return fmt.Sprintf("%s (%s) manifold of %d trajectories of the L%d orbit", kind, branch, len(m.Trajectories), m.Orbit.LagrangePoint)
+}
+
+// EigenDirections returns the unstable and stable eigenvalues and eigenvectors of the monodromy matrix.
+// The eigenvectors are normalized.
+func (p PeriodicOrbit) EigenDirections() (λu float64, vu []float64, λs float64, vs []float64) {
+	var Minv mat64.Dense
+	if err := Minv.Inverse(p.Monodromy); err != nil {
+		panic(fmt.Errorf("monodromy matrix is singular: %s", err))
+	}
+	λu, vu = powerIteration(p.Monodromy)
+	λs, vs = powerIteration(&Minv)
+	return λu, vu, 1 / λs, vs
+}
+
+// StabilityIndex returns the stability index of this orbit, i.e. (λu + 1/λu)/2.
+func (p PeriodicOrbit) StabilityIndex() float64 {
+	λu, _, _, _ := p.EigenDirections()
+	return (λu + 1/λu) / 2
+}
+
+// Manifold globalizes the manifold of this periodic orbit from n points equally spaced in time along the orbit.
+// Each point is perturbed by ε (normalized) along the local eigenvector and propagated for the normalized
+// time of flight tof (always positive, stable manifolds are propagated backward).
+func (p PeriodicOrbit) Manifold(stable, positive bool, n int, ε, tof float64) Manifold {
+	_, vu, _, vs := p.EigenDirections()
+	v0 := vu
+	if stable {
+		v0 = vs
+		tof = -math.Abs(tof)
+	} else {
+		tof = math.Abs(tof)
+	}
+	if !positive {
+		ε = -ε
+	}
+	m := Manifold{Orbit: p, Stable: stable, Positive: positive, Trajectories: make([]ManifoldTrajectory, n)}
+	for i := 0; i < n; i++ {
+		xτ, Φτ := p.System.Propagate(p.X0, float64(i)*p.Period/float64(n))
+		var vτ mat64.Vector
+		vτ.MulVec(Φτ, mat64.NewVector(6, v0))
+		// Normalize the eigenvector with respect to its position components.
+		norm := Norm([]float64{vτ.At(0, 0), vτ.At(1, 0), vτ.At(2, 0)})
+		x0 := make([]float64, 6)
+		for j := 0; j < 6; j++ {
+			x0[j] = xτ[j] + ε*vτ.At(j, 0)/norm
+		}
+		m.Trajectories[i] = p.System.trajectory(x0, tof)
+	}
+	return m
+}
+
+// trajectory propagates the provided normalized state and returns the trajectory in chronological order.
+func (s CR3BP) trajectory(state []float64, tof float64) ManifoldTrajectory {
+	steps := math.Ceil(math.Abs(tof) / cr3bpStep)
+	prop := newCR3BPPropagator(s, state, tof/steps)
+	prop.stopT = tof
+	traj := ManifoldTrajectory{T: []float64{0}, States: [][]float64{prop.State()}}
+	prop.hist = func(t float64, state []float64) {
+		traj.T = append(traj.T, t)
+		traj.States = append(traj.States, state)
+	}
+	prop.solve() // Blocking.
+	if tof < 0 {
+		for i, j := 0, len(traj.T)-1; i < j; i, j = i+1, j-1 {
+			traj.T[i], traj.T[j] = traj.T[j], traj.T[i]
+			traj.States[i], traj.States[j] = traj.States[j], traj.States[i]
+		}
+	}
+	return traj
+}
+
+// Export exports each trajectory of this manifold as a secondary centric trajectory with the provided export
+// configuration. The epoch corresponds to the normalized time zero, at which the synodic frame is rotated by θ0
+// (in radians) with respect to the inertial frame.
+func (m Manifold) Export(conf ExportConfig, epoch time.Time, θ0 float64) {
+	sys := m.Orbit.System
+	baseName := conf.Filename
+	for i, traj := range m.Trajectories {
+		conf.Filename = fmt.Sprintf("%s-%d", baseName, i)
+		sc := Spacecraft{Name: fmt.Sprintf("%s-%d", baseName, i)}
+		stateChan := make(chan State, 100)
+		go func(traj ManifoldTrajectory) {
+			for k, t := range traj.T {
+				dt := epoch.Add(time.Duration(t*sys.TU) * time.Second)
+				stateChan <- State{DT: dt, SC: sc, Orbit: *sys.SecondaryCentric(traj.States[k], θ0+t)}
+			}
+			close(stateChan)
+		}(traj)
+		StreamStates(conf, stateChan)
+	}
+}
+
+// powerIteration returns the dominant eigenvalue and its normalized eigenvector of the provided matrix.
+func powerIteration(M mat64.Matrix) (float64, []float64) {
+	r, _ := M.Dims()
+	v := mat64.NewVector(r, nil)
+	for i := 0; i < r; i++ {
+		v.SetVec(i, 1)
+	}
+	var λ float64
+	for iter := 0; iter < 1000; iter++ {
+		var Mv mat64.Vector
+		Mv.MulVec(M, v)
+		λNext := mat64.Dot(v, &Mv)
+		Mv.ScaleVec(1/mat64.Norm(&Mv, 2), &Mv)
+		v = &Mv
+		if math.Abs(λNext-λ) < 1e-12*math.Abs(λNext) {
+			λ = λNext
+			break
+		}
+		λ = λNext
+	}
+	vec := make([]float64, r)
+	for i := 0; i < r; i++ {
+		vec[i] = v.At(i, 0)
+	}
+	return λ, vec
+}
@@ -0,0 +1,84 @@
+package smd
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestManifoldEigenDirections(t *testing.T) {
+	sys := NewCR3BP(Sun, Earth)
+	orbit, err := sys.CorrectLyapunov(1, sys.LyapunovGuess(1, 1e-3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	λu, vu, λs, vs := orbit.EigenDirections()
+	if λu < 100 {
+		t.Fatalf("L1 Lyapunov orbit should be highly unstable: λu=%f", λu)
+	}
+	if !floats.EqualWithinAbs(λu*λs, 1, 1e-4) {
+		t.Fatalf("eigenvalues are not reciprocal: %f * %f = %f", λu, λs, λu*λs)
+	}
+	for _, eig := range []struct {
+		λ float64
+		v []float64
+	}{{λu, vu}, {λs, vs}} {
+		var Mv mat64.Vector
+		Mv.MulVec(orbit.Monodromy, mat64.NewVector(6, eig.v))
+		for i := 0; i < 6; i++ {
+			if !floats.EqualWithinAbs(Mv.At(i, 0), eig.λ*eig.v[i], 1e-4*math.Max(1, eig.λ)) {
+				t.Fatalf("invalid eigenvector for λ=%f: %+v", eig.λ, eig.v)
+			}
+		}
+	}
+	if s := orbit.StabilityIndex(); s < λu/2 {
+		t.Fatalf("invalid stability index %f", s)
+	}
+}
+
+func TestManifoldGlobalization(t *testing.T) {
+	sys := NewCR3BP(Sun, Earth)
+	orbit, err := sys.CorrectLyapunov(1, sys.LyapunovGuess(1, 1e-3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ε := 200 / sys.LU
+	for _, stable := range []bool{false, true} {
+		m := orbit.Manifold(stable, true, 4, ε, 2*orbit.Period)
+		if len(m.Trajectories) != 4 {
+			t.Fatalf("expected 4 trajectories, got %d", len(m.Trajectories))
+		}
+		for _, traj := range m.Trajectories {
+			if len(traj.T) != len(traj.States) {
+				t.Fatal("inconsistent trajectory lengths")
+			}
+			if !isChronological(traj.T) {
+				t.Fatal("trajectory is not chronological")
+			}
+			first, last := traj.States[0], traj.States[len(traj.States)-1]
+			// The manifold trajectories must depart from the orbit (forward in time for the unstable manifold).
+			onOrbit, offOrbit := first, last
+			if stable {
+				onOrbit, offOrbit = last, first
+			}
+			if C := sys.JacobiConstant(onOrbit); !floats.EqualWithinAbs(C, orbit.JacobiConstant(), 1e-8) {
+				t.Fatalf("manifold does not have the same energy as the orbit: %f != %f", C, orbit.JacobiConstant())
+			}
+			L1 := sys.LagrangePoint(1)
+			if distToL1, amplitude := Norm([]float64{offOrbit[0] - L1[0], offOrbit[1], offOrbit[2]}), Norm([]float64{onOrbit[0] - L1[0], onOrbit[1], onOrbit[2]}); distToL1 < 2*amplitude {
+				t.Fatalf("stable=%v trajectory did not depart from the orbit: %f <= %f", stable, distToL1, amplitude)
+			}
+		}
+	}
+}
+
+func isChronological(vals []float64) bool {
+	for i := 1; i < len(vals); i++ {
+		if vals[i] < vals[i-1] {
+			return false
+		}
+	}
+	return true
+}
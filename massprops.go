@@ -0,0 +1,139 @@
+package smd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// MassProperties stores the mass (kg), the center of mass (m) and the inertia tensor about the center of mass
+// (kg m²) of a rigid body, in the body frame.
+type MassProperties struct {
+	Mass    float64
+	CoM     []float64
+	Inertia *mat64.SymDense
+}
+
+// NewPointMass returns the mass properties of a point mass at the provided position (m).
+func NewPointMass(mass float64, position []float64) MassProperties {
+	return MassProperties{mass, []float64{position[0], position[1], position[2]}, mat64.NewSymDense(3, nil)}
+}
+
+// NewUniformSphere returns the mass properties of a uniform sphere of the provided radius (m) centered at the
+// provided position (m), e.g. a propellant tank.
+func NewUniformSphere(mass, radius float64, center []float64) MassProperties {
+	mp := NewPointMass(mass, center)
+	I := 0.4 * mass * radius * radius
+	for i := 0; i < 3; i++ {
+		mp.Inertia.SetSym(i, i, I)
+	}
+	return mp
+}
+
+// Add returns the mass properties of the rigid assembly of both bodies, using the parallel axis theorem.
+func (mp MassProperties) Add(other MassProperties) MassProperties {
+	m := mp.Mass + other.Mass
+	if m <= 0 {
+		return NewPointMass(0, []float64{0, 0, 0})
+	}
+	com := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		com[i] = (mp.Mass*mp.CoM[i] + other.Mass*other.CoM[i]) / m
+	}
+	I := mat64.NewSymDense(3, nil)
+	for _, body := range []MassProperties{mp, other} {
+		d := []float64{body.CoM[0] - com[0], body.CoM[1] - com[1], body.CoM[2] - com[2]}
+		d2 := Dot(d, d)
+		for i := 0; i < 3; i++ {
+			for j := i; j < 3; j++ {
+				Iij := -body.Mass * d[i] * d[j]
+				if i == j {
+					Iij += body.Mass * d2
+				}
+				if body.Inertia != nil {
+					Iij += body.Inertia.At(i, j)
+				}
+				I.SetSym(i, j, I.At(i, j)+Iij)
+			}
+		}
+	}
+	return MassProperties{m, com, I}
+}
+
+// Torque returns the torque (N m) about the center of mass of the provided force (N) applied at the provided point
+// (m), e.g. the disturbance torque of a thruster whose thrust does not go through the center of mass.
+func (mp MassProperties) Torque(point, force []float64) []float64 {
+	return Cross([]float64{point[0] - mp.CoM[0], point[1] - mp.CoM[1], point[2] - mp.CoM[2]}, force)
+}
+
+func (mp MassProperties) String() string {
+	return fmt.Sprintf("m=%.3f kg CoM=%+v m I=diag(%.3f, %.3f, %.3f) kg m²", mp.Mass, mp.CoM, mp.Inertia.At(0, 0), mp.Inertia.At(1, 1), mp.Inertia.At(2, 2))
+}
+
+// MassModel defines the layout of the mass of a spacecraft in its body frame, so that its mass properties can be
+// tracked as the fuel depletes and cargo is deployed.
+type MassModel struct {
+	Dry         MassProperties       // Dry mass properties, scaled to the DryMass of the spacecraft
+	Tank        []float64            // Center of the propellant tank (m)
+	TankRadius  float64              // Radius of the tank (m), whose propellant is modeled as a uniform sphere
+	CargoMounts map[*Cargo][]float64 // Attachment point of the center of mass of each piece of cargo (m)
+	ThrustPoint []float64            // Point of application of the thrust (m)
+	ThrustAxis  []float64            // Direction of the thrust in the body frame
+}
+
+// MassProperties returns the mass properties of the spacecraft at the provided epoch, including the remaining fuel
+// and the cargo onboard. Without a mass model, the spacecraft is a point mass at the origin of the body frame.
+func (sc *Spacecraft) MassProperties(dt time.Time) MassProperties {
+	if sc.MassModel == nil {
+		return NewPointMass(sc.Mass(dt), []float64{0, 0, 0})
+	}
+	model := sc.MassModel
+	mp := NewPointMass(sc.DryMass, []float64{0, 0, 0})
+	if len(model.Dry.CoM) == 3 {
+		copy(mp.CoM, model.Dry.CoM)
+	}
+	if model.Dry.Inertia != nil && model.Dry.Mass > 0 {
+		mp.Inertia.ScaleSym(sc.DryMass/model.Dry.Mass, model.Dry.Inertia)
+	}
+	if sc.FuelMass > 0 {
+		mp = mp.Add(NewUniformSphere(sc.FuelMass, model.TankRadius, model.Tank))
+	}
+	for _, cargo := range sc.Cargo {
+		if !dt.After(cargo.Arrival) {
+			continue
+		}
+		mount, ok := model.CargoMounts[cargo]
+		if !ok {
+			mount = []float64{0, 0, 0} // Unknown mounting point.
+		}
+		mp = mp.Add(NewPointMass(cargo.DryMass, mount))
+	}
+	return mp
+}
+
+// ThrustTorque returns the disturbance torque (N m) about the center of mass of the provided thrust (N), applied
+// along the thrust axis of the mass model, at the provided epoch. It is zero without a mass model.
+func (sc *Spacecraft) ThrustTorque(dt time.Time, thrust float64) []float64 {
+	if sc.MassModel == nil || len(sc.MassModel.ThrustAxis) != 3 {
+		return []float64{0, 0, 0}
+	}
+	axis := Unit(sc.MassModel.ThrustAxis)
+	return sc.MassProperties(dt).Torque(sc.MassModel.ThrustPoint, []float64{thrust * axis[0], thrust * axis[1], thrust * axis[2]})
+}
+
+// massPropertiesOf returns the mass properties of the spacecraft of the provided state.
+func massPropertiesOf(st State) MassProperties {
+	sc := st.SC
+	return sc.MassProperties(st.DT)
+}
+
+// MassPropertiesColumns are the center of mass and inertia tensor diagonal columns, for use in ExportConfig.Columns.
+var MassPropertiesColumns = CSVColumns{
+	{Name: "comX", Unit: "m", Extract: func(st State) float64 { return massPropertiesOf(st).CoM[0] }},
+	{Name: "comY", Unit: "m", Extract: func(st State) float64 { return massPropertiesOf(st).CoM[1] }},
+	{Name: "comZ", Unit: "m", Extract: func(st State) float64 { return massPropertiesOf(st).CoM[2] }},
+	{Name: "Ixx", Unit: "kg m^2", Extract: func(st State) float64 { return massPropertiesOf(st).Inertia.At(0, 0) }},
+	{Name: "Iyy", Unit: "kg m^2", Extract: func(st State) float64 { return massPropertiesOf(st).Inertia.At(1, 1) }},
+	{Name: "Izz", Unit: "kg m^2", Extract: func(st State) float64 { return massPropertiesOf(st).Inertia.At(2, 2) }},
+}
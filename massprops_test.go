@@ -0,0 +1,78 @@
+package smd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestMassPropertiesAdd(t *testing.T) {
+	// Two unit masses on the X axis.
+	mp := NewPointMass(1, []float64{1, 0, 0}).Add(NewPointMass(1, []float64{-1, 0, 0}))
+	if mp.Mass != 2 || !floats.Equal(mp.CoM, []float64{0, 0, 0}) {
+		t.Fatalf("invalid assembly %s", mp)
+	}
+	if !floats.Equal([]float64{mp.Inertia.At(0, 0), mp.Inertia.At(1, 1), mp.Inertia.At(2, 2)}, []float64{0, 2, 2}) {
+		t.Fatalf("invalid inertia %s", mp)
+	}
+	// Products of inertia of masses off the axes.
+	mp = NewPointMass(1, []float64{1, 1, 0}).Add(NewPointMass(1, []float64{-1, -1, 0}))
+	if mp.Inertia.At(0, 1) != -2 || mp.Inertia.At(2, 2) != 4 {
+		t.Fatalf("invalid products of inertia %s (Ixy=%f)", mp, mp.Inertia.At(0, 1))
+	}
+	// A force through the center of mass creates no torque.
+	if τ := mp.Torque([]float64{3, 3, 0}, []float64{-1, -1, 0}); Norm(τ) != 0 {
+		t.Fatalf("torque %+v", τ)
+	}
+	sphere := NewUniformSphere(10, 0.5, []float64{0, 0, 1})
+	if sphere.Inertia.At(0, 0) != 1 || sphere.Inertia.At(0, 1) != 0 {
+		t.Fatalf("invalid sphere %s", sphere)
+	}
+}
+
+func TestSpacecraftMassProperties(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	cargo := &Cargo{start.Add(time.Hour), NewEmptySC("cargo", 100)}
+	sc := NewSpacecraft("massprops", 400, 100, NewUnlimitedEPS(), []EPThruster{}, false, []*Cargo{cargo}, []Waypoint{})
+	// Without a mass model, the spacecraft is a point mass.
+	if mp := sc.MassProperties(start); mp.Mass != 500 || Norm(mp.CoM) != 0 || Norm(sc.ThrustTorque(start, 1)) != 0 {
+		t.Fatalf("invalid point mass %s", mp)
+	}
+	dry := NewPointMass(800, []float64{0, 0, 0})
+	dry.Inertia = mat64.NewSymDense(3, []float64{400, 0, 0, 0, 400, 0, 0, 0, 200})
+	sc.MassModel = &MassModel{
+		Dry:         dry,
+		Tank:        []float64{0, 0, 1},
+		TankRadius:  0.3,
+		CargoMounts: map[*Cargo][]float64{cargo: {0, 0, -2}},
+		ThrustPoint: []float64{0.01, 0, -1},
+		ThrustAxis:  []float64{0, 0, 1},
+	}
+	mp := sc.MassProperties(start)
+	// The dry inertia is scaled to the dry mass, and the fuel is 0.8 m above the center of mass.
+	if !floats.EqualWithinAbs(mp.CoM[2], 0.2, 1e-12) || !floats.EqualWithinAbs(mp.Inertia.At(2, 2), 100+0.4*100*0.09, 1e-9) {
+		t.Fatalf("invalid mass properties %s", mp)
+	}
+	if !floats.EqualWithinAbs(mp.Inertia.At(0, 0), 200+0.4*100*0.09+400*0.2*0.2+100*0.8*0.8, 1e-9) {
+		t.Fatalf("invalid Ixx %s", mp)
+	}
+	// The thruster is offset by 1 cm along X, so its thrust creates a torque about -Y.
+	if τ := sc.ThrustTorque(start, 0.1); !floats.EqualApprox(τ, []float64{0, -1e-3, 0}, 1e-12) {
+		t.Fatalf("thrust torque %+v", τ)
+	}
+	// The center of mass moves down as the fuel depletes and once the cargo is onboard.
+	sc.FuelMass = 0
+	if mp = sc.MassProperties(start); Norm(mp.CoM) != 0 || mp.Mass != 400 {
+		t.Fatalf("without fuel %s", mp)
+	}
+	if mp = sc.MassProperties(start.Add(2 * time.Hour)); !floats.EqualWithinAbs(mp.CoM[2], -0.4, 1e-12) || mp.Mass != 500 {
+		t.Fatalf("with cargo %s", mp)
+	}
+	st := State{DT: start.Add(2 * time.Hour), SC: *sc, Orbit: *NewOrbitFromOE(7000, 0, 0, 0, 0, 0, Earth)}
+	if row := csvRow(st, st, MassPropertiesColumns); !strings.Contains(row, ",0,0,-0.4,") {
+		t.Fatalf("invalid row %s", row)
+	}
+}
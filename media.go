@@ -0,0 +1,122 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ionosphereK is the ionospheric group delay constant (m³/s²), i.e. the delay in meters is K·TEC/f².
+const ionosphereK = 40.3
+
+// Troposphere defines the surface meteorological conditions of a station for the Saastamoinen tropospheric delay.
+type Troposphere struct {
+	Pressure         float64 // Total pressure (hPa)
+	Temperature      float64 // K
+	RelativeHumidity float64 // Between 0 and 1
+}
+
+// StandardTroposphere is the standard atmosphere at sea level with a relative humidity of 50%.
+var StandardTroposphere = Troposphere{1013.25, 288.15, 0.5}
+
+// saastamoinenB is the correction term B (hPa) of the Saastamoinen model as a function of the station altitude (km).
+var saastamoinenB = [][2]float64{{0, 1.156}, {0.5, 1.079}, {1, 1.006}, {1.5, 0.938}, {2, 0.874}, {2.5, 0.813}, {3, 0.757}, {4, 0.654}, {5, 0.563}}
+
+// WaterVaporPressure returns the partial pressure of the water vapor (hPa).
+func (t Troposphere) WaterVaporPressure() float64 {
+	return t.RelativeHumidity * math.Exp(-37.2465+0.213166*t.Temperature-0.000256908*t.Temperature*t.Temperature)
+}
+
+// Delay returns the tropospheric delay (km) of the range at the provided elevation (degrees) from a station at the
+// provided altitude (km), from the Saastamoinen model.
+func (t Troposphere) Delay(el, altitude float64) float64 {
+	z := (90 - el) * deg2rad
+	tanz := math.Tan(z)
+	// Linear interpolation of B, clamped to the table.
+	h := math.Max(saastamoinenB[0][0], math.Min(altitude, saastamoinenB[len(saastamoinenB)-1][0]))
+	k := sort.Search(len(saastamoinenB)-1, func(i int) bool { return saastamoinenB[i+1][0] >= h })
+	B := saastamoinenB[k][1] + (saastamoinenB[k+1][1]-saastamoinenB[k][1])*(h-saastamoinenB[k][0])/(saastamoinenB[k+1][0]-saastamoinenB[k][0])
+	return 0.002277 / math.Cos(z) * (t.Pressure + (1255/t.Temperature+0.05)*t.WaterVaporPressure() - B*tanz*tanz) / 1e3
+}
+
+// Ionosphere defines a single layer ionosphere for the group delay of the range.
+type Ionosphere struct {
+	VTEC        float64 // Vertical total electron content (TECU, i.e. 1e16 electrons/m²)
+	Frequency   float64 // Carrier frequency (Hz)
+	ShellHeight float64 // Altitude of the single layer (km), e.g. 350 km for the Earth
+}
+
+// Delay returns the ionospheric group delay (km) of the range at the provided elevation (degrees) from a station
+// on the provided body, with the mapping function of the single layer model.
+func (i Ionosphere) Delay(el float64, body CelestialObject) float64 {
+	sinz := body.Radius / (body.Radius + i.ShellHeight) * math.Cos(el*deg2rad)
+	mapping := 1 / math.Sqrt(1-sinz*sinz)
+	return ionosphereK * i.VTEC * 1e16 / (i.Frequency * i.Frequency) * mapping / 1e3
+}
+
+// mediaDelay returns the total media delay (km) of the range at the provided elevation (degrees) from the station.
+func (s Station) mediaDelay(el float64) (delay float64) {
+	if s.Troposphere != nil {
+		delay += s.Troposphere.Delay(el, s.Altitude)
+	}
+	if s.Ionosphere != nil {
+		delay += s.Ionosphere.Delay(el, s.Planet)
+	}
+	return
+}
+
+// PassBias is the constant range bias of a tracking pass of a station.
+type PassBias struct {
+	Station    string
+	Start, End time.Time
+	Bias       float64 // km
+	StdDev     float64 // Standard deviation of the residuals about the bias (km)
+	N          int     // Number of measurements
+}
+
+func (b PassBias) String() string {
+	return fmt.Sprintf("%s pass %s -> %s: bias=%.6f km (σ=%.6f km, %d meas.)", b.Station, b.Start, b.End, b.Bias, b.StdDev, b.N)
+}
+
+// EstimatePassBiases estimates the range bias of each pass as the mean of the range residuals, i.e. observed minus
+// computed ranges, where computed are the ranges predicted by the OD for each measurement. The measurements must be
+// in chronological order, and a new pass starts when a station has not measured for more than the provided gap. The
+// invisible measurements are ignored.
+// Panics if there are not as many computed ranges as measurements.
+func EstimatePassBiases(measurements []Measurement, computed []float64, gap time.Duration) []PassBias {
+	if len(measurements) != len(computed) {
+		panic(fmt.Errorf("%d measurements but %d computed ranges", len(measurements), len(computed)))
+	}
+	var biases []PassBias
+	var residuals [][]float64
+	current := make(map[string]int) // Index of the current pass of each station
+	for k, m := range measurements {
+		if !m.Visible {
+			continue
+		}
+		idx, exists := current[m.Station.Name]
+		if !exists || m.State.DT.Sub(biases[idx].End) > gap {
+			idx = len(biases)
+			current[m.Station.Name] = idx
+			biases = append(biases, PassBias{Station: m.Station.Name, Start: m.State.DT})
+			residuals = append(residuals, nil)
+		}
+		biases[idx].End = m.State.DT
+		residuals[idx] = append(residuals[idx], m.Range-computed[k])
+	}
+	for idx, res := range residuals {
+		var sum, sum2 float64
+		for _, r := range res {
+			sum += r
+		}
+		mean := sum / float64(len(res))
+		for _, r := range res {
+			sum2 += (r - mean) * (r - mean)
+		}
+		biases[idx].Bias = mean
+		biases[idx].StdDev = math.Sqrt(sum2 / float64(len(res)))
+		biases[idx].N = len(res)
+	}
+	return biases
+}
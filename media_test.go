@@ -0,0 +1,96 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestSaastamoinen(t *testing.T) {
+	zenith := StandardTroposphere.Delay(90, 0)
+	if !floats.EqualWithinAbs(zenith, 2.39e-3, 0.05e-3) {
+		t.Fatalf("zenith delay of %f m", zenith*1e3)
+	}
+	// The delay increases toward the horizon, roughly as the cosecant of the elevation.
+	prev := zenith
+	for _, el := range []float64{60, 30, 15, 10, 5} {
+		delay := StandardTroposphere.Delay(el, 0)
+		if delay <= prev {
+			t.Fatalf("delay at %f deg of %f m is less than at higher elevation", el, delay*1e3)
+		}
+		prev = delay
+	}
+	if csc := zenith / math.Sin(30*deg2rad); !floats.EqualWithinRel(StandardTroposphere.Delay(30, 0), csc, 0.01) {
+		t.Fatalf("delay at 30 deg of %f m", StandardTroposphere.Delay(30, 0)*1e3)
+	}
+	// For the same surface conditions, the B correction decreases with the altitude, and is clamped above 5 km.
+	if StandardTroposphere.Delay(30, 2) <= StandardTroposphere.Delay(30, 0) || StandardTroposphere.Delay(30, 10) != StandardTroposphere.Delay(30, 5) {
+		t.Fatal("invalid B correction")
+	}
+}
+
+func TestIonosphere(t *testing.T) {
+	iono := Ionosphere{VTEC: 10, Frequency: 1.57542e9, ShellHeight: 350}
+	if zenith := iono.Delay(90, Earth); !floats.EqualWithinAbs(zenith, 1.6237e-3, 1e-7) {
+		t.Fatalf("zenith delay of %f m", zenith*1e3)
+	}
+	if ratio := iono.Delay(5, Earth) / iono.Delay(90, Earth); ratio < 2.5 || ratio > 3.1 {
+		t.Fatalf("mapping at 5 deg is %f", ratio)
+	}
+}
+
+func TestStationMediaDelays(t *testing.T) {
+	dt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := NewStation("st", 0, 0, 40, -105, 0, 0)
+	st.Troposphere = &StandardTroposphere
+	st.Ionosphere = &Ionosphere{VTEC: 20, Frequency: 8.4e9, ShellHeight: 350}
+	θ := Earth.RotationAngle(dt)
+	o := *NewOrbitFromRV(ECEF2ECI(GEO2BodyFixed(2000, Deg2rad(35), Deg2rad(-100), Earth), θ), []float64{2, 5, 3}, Earth)
+	m := st.PerformMeasurement(θ, State{DT: dt, Orbit: o})
+	_, _, el, _ := st.RangeElAz(ECI2ECEF(o.R(), θ))
+	if !m.Visible || m.Delay != st.mediaDelay(el) || !floats.EqualWithinAbs(m.Range-m.TrueRange, m.Delay, 1e-12) {
+		t.Fatalf("range delay of %f m", m.Delay*1e3)
+	}
+	// The rate of the delay matches the delay one second later.
+	later := keplerPropagate(o, time.Second)
+	next := st.PerformMeasurement(θ+Earth.RotRate, State{DT: dt.Add(time.Second), Orbit: *later})
+	if !floats.EqualWithinAbs(m.DelayRate, next.Delay-m.Delay, 1e-9) || !floats.EqualWithinAbs(m.RangeRate-m.TrueRangeRate, m.DelayRate, 1e-12) {
+		t.Fatalf("delay rate of %e km/s, expected %e km/s", m.DelayRate, next.Delay-m.Delay)
+	}
+}
+
+func TestEstimatePassBiases(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	st1, st2 := Station{Name: "st1"}, Station{Name: "st2"}
+	var measurements []Measurement
+	var computed []float64
+	add := func(st Station, minutes int, bias float64, visible bool) {
+		ρ := 1000 + float64(minutes)
+		measurements = append(measurements, Measurement{Visible: visible, Range: ρ + bias, State: State{DT: start.Add(time.Duration(minutes) * time.Minute)}, Station: st})
+		computed = append(computed, ρ)
+	}
+	for k := 0; k < 10; k++ {
+		add(st1, k, 0.01+1e-4*float64(k%2), true)
+		add(st2, k, -0.02, true)
+	}
+	add(st2, 10, 1, false)
+	for k := 0; k < 10; k++ {
+		add(st1, 200+k, 0.03, true)
+	}
+	biases := EstimatePassBiases(measurements, computed, 30*time.Minute)
+	if len(biases) != 3 {
+		t.Fatalf("%d passes: %+v", len(biases), biases)
+	}
+	exp := []PassBias{{"st1", start, start.Add(9 * time.Minute), 0.01005, 5e-5, 10}, {"st2", start, start.Add(9 * time.Minute), -0.02, 0, 10}, {"st1", start.Add(200 * time.Minute), start.Add(209 * time.Minute), 0.03, 0, 10}}
+	for k, b := range biases {
+		e := exp[k]
+		if b.Station != e.Station || !b.Start.Equal(e.Start) || !b.End.Equal(e.End) || b.N != e.N || !floats.EqualWithinAbs(b.Bias, e.Bias, 1e-12) || !floats.EqualWithinAbs(b.StdDev, e.StdDev, 1e-12) {
+			t.Fatalf("pass %d: %s, expected %s", k, b, e)
+		}
+	}
+	assertPanic(t, func() {
+		EstimatePassBiases(measurements, computed[1:], time.Hour)
+	})
+}
@@ -0,0 +1,335 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const mgaFlybyPenalty = 10 // Δv penalty (km/s) per body radius below the minimum flyby radius.
+
+// EphemerisFunc returns the heliocentric position and velocity of the body at the provided epoch.
+type EphemerisFunc func(body CelestialObject, dt time.Time) (R, V []float64)
+
+// HelioEphemeris is the EphemerisFunc of the configured ephemerides.
+func HelioEphemeris(body CelestialObject, dt time.Time) (R, V []float64) {
+	return body.HelioOrbit(dt).RV()
+}
+
+// MGASequence defines a multiple gravity assist trajectory with Lambert legs between the bodies of the sequence.
+// Each flyby may be powered at periapsis to match the incoming and outgoing hyperbolic excess velocities.
+type MGASequence struct {
+	Bodies            []CelestialObject // Departure, flyby bodies and arrival
+	LaunchStart       time.Time
+	LaunchEnd         time.Time
+	MinTOF, MaxTOF    []float64 // Bounds of the time of flight of each leg in days
+	MinFlybyAltitude  float64   // Minimum flyby altitude (km), below which the flyby is penalized
+	IgnoreLaunchVInf  bool      // Set to true to exclude the launch v∞ from the Δv (e.g. if provided by the launcher)
+	IgnoreArrivalVInf bool      // Set to true to exclude the arrival v∞ from the Δv (e.g. for a flyby mission)
+	Ephemeris         EphemerisFunc
+}
+
+// NewMGASequence returns a new sequence with the TOF bounds of each leg in days, using the configured ephemerides.
+func NewMGASequence(bodies []CelestialObject, launchStart, launchEnd time.Time, minTOF, maxTOF []float64) MGASequence {
+	if len(bodies) < 2 {
+		panic("MGA sequence requires at least two bodies")
+	}
+	if len(minTOF) != len(bodies)-1 || len(maxTOF) != len(bodies)-1 {
+		panic("MGA sequence requires TOF bounds for each leg")
+	}
+	if launchEnd.Before(launchStart) {
+		panic("launch window ends before it starts")
+	}
+	return MGASequence{Bodies: bodies, LaunchStart: launchStart, LaunchEnd: launchEnd, MinTOF: minTOF, MaxTOF: maxTOF, MinFlybyAltitude: 200, Ephemeris: HelioEphemeris}
+}
+
+// Dimension returns the number of decision variables: the launch date offset in days and the TOF of each leg.
+func (s MGASequence) Dimension() int {
+	return len(s.Bodies)
+}
+
+// Bounds returns the bounds of the decision variables.
+func (s MGASequence) Bounds() (lower, upper []float64) {
+	lower = append([]float64{0}, s.MinTOF...)
+	upper = append([]float64{s.LaunchEnd.Sub(s.LaunchStart).Hours() / 24}, s.MaxTOF...)
+	return
+}
+
+// MGATrajectory stores an evaluated MGA trajectory.
+type MGATrajectory struct {
+	Epochs      []time.Time // Launch, flyby and arrival epochs
+	VInfLaunch  float64     // km/s
+	VInfArrival float64     // km/s
+	FlybyΔv     []float64   // Powered flyby Δv (km/s)
+	FlybyRP     []float64   // Flyby periapsis radii (km)
+	ΔV          float64     // Total Δv including the penalties (km/s)
+	TOF         float64     // Total time of flight (days)
+	Valid       bool        // Set to false if a Lambert leg failed
+	x           []float64
+}
+
+func (t MGATrajectory) String() string {
+	if !t.Valid {
+		return "invalid MGA trajectory"
+	}
+	return fmt.Sprintf("launch %s, Δv=%.3f km/s, TOF=%.1f days (v∞ launch=%.3f arrival=%.3f, flybys Δv=%v)", t.Epochs[0].Format("2006-01-02"), t.ΔV, t.TOF, t.VInfLaunch, t.VInfArrival, t.FlybyΔv)
+}
+
+// Evaluate returns the trajectory of the provided decision variables.
+func (s MGASequence) Evaluate(x []float64) MGATrajectory {
+	if len(x) != s.Dimension() {
+		panic(fmt.Errorf("%d decision variables for a dimension of %d", len(x), s.Dimension()))
+	}
+	traj := MGATrajectory{x: append([]float64{}, x...), ΔV: math.Inf(1), TOF: math.Inf(1)}
+	dt := s.LaunchStart.Add(time.Duration(x[0] * 24 * float64(time.Hour)))
+	traj.Epochs = []time.Time{dt}
+	var vInfIn []float64
+	var tof float64
+	ΔV := 0.
+	for leg := 0; leg < len(s.Bodies)-1; leg++ {
+		legTOF := time.Duration(x[leg+1] * 24 * float64(time.Hour))
+		arrivalDT := dt.Add(legTOF)
+		Ri, Vpi := s.Ephemeris(s.Bodies[leg], dt)
+		Rf, Vpf := s.Ephemeris(s.Bodies[leg+1], arrivalDT)
+		Vi, Vf, _, err := Lambert(mat64.NewVector(3, Ri), mat64.NewVector(3, Rf), legTOF, TTypeAuto, Sun)
+		if err != nil {
+			return traj
+		}
+		vInfOut := make([]float64, 3)
+		vInfArr := make([]float64, 3)
+		for i := 0; i < 3; i++ {
+			vInfOut[i] = Vi.At(i, 0) - Vpi[i]
+			vInfArr[i] = Vf.At(i, 0) - Vpf[i]
+		}
+		if leg == 0 {
+			traj.VInfLaunch = Norm(vInfOut)
+			if !s.IgnoreLaunchVInf {
+				ΔV += traj.VInfLaunch
+			}
+		} else {
+			Δv, rP := poweredFlyby(vInfIn, vInfOut, s.Bodies[leg])
+			traj.FlybyΔv = append(traj.FlybyΔv, Δv)
+			traj.FlybyRP = append(traj.FlybyRP, rP)
+			ΔV += Δv
+			if rMin := s.Bodies[leg].Radius + s.MinFlybyAltitude; rP < rMin {
+				ΔV += mgaFlybyPenalty * (rMin - rP) / s.Bodies[leg].Radius
+			}
+		}
+		vInfIn = vInfArr
+		tof += x[leg+1]
+		dt = arrivalDT
+		traj.Epochs = append(traj.Epochs, dt)
+	}
+	traj.VInfArrival = Norm(vInfIn)
+	if !s.IgnoreArrivalVInf {
+		ΔV += traj.VInfArrival
+	}
+	traj.ΔV = ΔV
+	traj.TOF = tof
+	traj.Valid = true
+	return traj
+}
+
+// poweredFlyby returns the Δv (km/s) and the periapsis radius (km) of the powered flyby which turns the incoming
+// hyperbolic excess velocity into the outgoing one, with the burn at periapsis.
+func poweredFlyby(vInfIn, vInfOut []float64, body CelestialObject) (Δv, rP float64) {
+	μ := body.μ
+	vIn := Norm(vInfIn)
+	vOut := Norm(vInfOut)
+	δ := math.Acos(math.Max(-1, math.Min(1, Dot(vInfIn, vInfOut)/(vIn*vOut))))
+	// Solve the turn angle equation on the periapsis radius with Newton iterations.
+	turn := func(rP float64) float64 {
+		return math.Asin(1/(1+rP*vIn*vIn/μ)) + math.Asin(1/(1+rP*vOut*vOut/μ))
+	}
+	rP = GARPeriapsis(vIn, δ, body)
+	for iter := 0; iter < 50 && rP > 0; iter++ {
+		h := 1e-6 * rP
+		f := turn(rP) - δ
+		df := (turn(rP+h) - turn(rP)) / h
+		step := f / df
+		rP -= step
+		if math.Abs(step) < 1e-6 {
+			break
+		}
+	}
+	if rP <= 0 || math.IsNaN(rP) {
+		// The turn angle cannot be achieved, even with a grazing flyby.
+		rP = 1e-3
+	}
+	Δv = math.Abs(math.Sqrt(vOut*vOut+2*μ/rP) - math.Sqrt(vIn*vIn+2*μ/rP))
+	return
+}
+
+// EvolutionConfig configures the multi-objective differential evolution.
+type EvolutionConfig struct {
+	Population  int
+	Generations int
+	F, CR       float64 // Differential weight and crossover probability
+	Seed        int64
+}
+
+// DefaultEvolutionConfig is a reasonable configuration of the multi-objective differential evolution.
+var DefaultEvolutionConfig = EvolutionConfig{Population: 60, Generations: 200, F: 0.7, CR: 0.9, Seed: 1}
+
+// MGAParetoFront searches the MGA sequence with a multi-objective differential evolution (DEMO) minimizing both the
+// Δv and the time of flight. Returns the Pareto front sorted by increasing TOF.
+func MGAParetoFront(s MGASequence, conf EvolutionConfig) []MGATrajectory {
+	if conf.Population < 4 {
+		panic("differential evolution requires a population of at least four")
+	}
+	rng := rand.New(rand.NewSource(conf.Seed))
+	lower, upper := s.Bounds()
+	n := s.Dimension()
+	pop := make([]MGATrajectory, conf.Population)
+	for k := range pop {
+		x := make([]float64, n)
+		for i := range x {
+			x[i] = lower[i] + rng.Float64()*(upper[i]-lower[i])
+		}
+		pop[k] = s.Evaluate(x)
+	}
+	for gen := 0; gen < conf.Generations; gen++ {
+		size := len(pop)
+		for k := 0; k < size; k++ {
+			// DE/rand/1/bin
+			var a, b, c int
+			for a == k || b == k || c == k || a == b || a == c || b == c {
+				a, b, c = rng.Intn(size), rng.Intn(size), rng.Intn(size)
+			}
+			x := make([]float64, n)
+			jRand := rng.Intn(n)
+			for i := range x {
+				x[i] = pop[k].x[i]
+				if i == jRand || rng.Float64() < conf.CR {
+					x[i] = pop[a].x[i] + conf.F*(pop[b].x[i]-pop[c].x[i])
+				}
+				x[i] = math.Max(lower[i], math.Min(upper[i], x[i]))
+			}
+			trial := s.Evaluate(x)
+			switch {
+			case trial.dominates(pop[k]):
+				pop[k] = trial
+			case pop[k].dominates(trial):
+			default:
+				pop = append(pop, trial)
+			}
+		}
+		pop = truncatePopulation(pop, conf.Population)
+	}
+	front := paretoFronts(pop)[0]
+	var sorted []MGATrajectory
+	for _, k := range front {
+		if pop[k].Valid {
+			sorted = append(sorted, pop[k])
+		}
+	}
+	sort.Sort(byTOF(sorted))
+	return sorted
+}
+
+// dominates returns whether the receiver Pareto dominates the other trajectory.
+func (t MGATrajectory) dominates(o MGATrajectory) bool {
+	return t.ΔV <= o.ΔV && t.TOF <= o.TOF && (t.ΔV < o.ΔV || t.TOF < o.TOF)
+}
+
+// paretoFronts returns the indexes of the trajectories of each non-dominated front.
+func paretoFronts(pop []MGATrajectory) [][]int {
+	dominatedBy := make([]int, len(pop))
+	dominates := make([][]int, len(pop))
+	var fronts [][]int
+	var cur []int
+	for p := range pop {
+		for q := range pop {
+			if pop[p].dominates(pop[q]) {
+				dominates[p] = append(dominates[p], q)
+			} else if pop[q].dominates(pop[p]) {
+				dominatedBy[p]++
+			}
+		}
+		if dominatedBy[p] == 0 {
+			cur = append(cur, p)
+		}
+	}
+	for len(cur) > 0 {
+		fronts = append(fronts, cur)
+		var next []int
+		for _, p := range cur {
+			for _, q := range dominates[p] {
+				dominatedBy[q]--
+				if dominatedBy[q] == 0 {
+					next = append(next, q)
+				}
+			}
+		}
+		cur = next
+	}
+	return fronts
+}
+
+// truncatePopulation keeps the best trajectories by non-dominated sorting and crowding distance.
+func truncatePopulation(pop []MGATrajectory, size int) []MGATrajectory {
+	if len(pop) <= size {
+		return pop
+	}
+	kept := make([]MGATrajectory, 0, size)
+	for _, front := range paretoFronts(pop) {
+		if len(kept)+len(front) <= size {
+			for _, k := range front {
+				kept = append(kept, pop[k])
+			}
+			continue
+		}
+		crowding := crowdingDistances(pop, front)
+		sort.Sort(indexSorter{front, func(a, b int) bool { return crowding[a] > crowding[b] }})
+		for _, k := range front[:size-len(kept)] {
+			kept = append(kept, pop[k])
+		}
+		break
+	}
+	return kept
+}
+
+// crowdingDistances returns the crowding distance of each trajectory of the front, indexed as the population.
+func crowdingDistances(pop []MGATrajectory, front []int) map[int]float64 {
+	crowding := make(map[int]float64, len(front))
+	objectives := []func(MGATrajectory) float64{
+		func(t MGATrajectory) float64 { return t.ΔV },
+		func(t MGATrajectory) float64 { return t.TOF },
+	}
+	sorted := append([]int{}, front...)
+	for _, obj := range objectives {
+		sort.Sort(indexSorter{sorted, func(a, b int) bool { return obj(pop[a]) < obj(pop[b]) }})
+		lo := obj(pop[sorted[0]])
+		hi := obj(pop[sorted[len(sorted)-1]])
+		crowding[sorted[0]] = math.Inf(1)
+		crowding[sorted[len(sorted)-1]] = math.Inf(1)
+		if hi == lo || math.IsInf(hi-lo, 0) {
+			continue
+		}
+		for i := 1; i < len(sorted)-1; i++ {
+			crowding[sorted[i]] += (obj(pop[sorted[i+1]]) - obj(pop[sorted[i-1]])) / (hi - lo)
+		}
+	}
+	return crowding
+}
+
+// byTOF sorts trajectories by increasing time of flight.
+type byTOF []MGATrajectory
+
+func (t byTOF) Len() int           { return len(t) }
+func (t byTOF) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t byTOF) Less(i, j int) bool { return t[i].TOF < t[j].TOF }
+
+// indexSorter sorts population indexes.
+type indexSorter struct {
+	idx  []int
+	less func(a, b int) bool
+}
+
+func (s indexSorter) Len() int           { return len(s.idx) }
+func (s indexSorter) Swap(i, j int)      { s.idx[i], s.idx[j] = s.idx[j], s.idx[i] }
+func (s indexSorter) Less(i, j int) bool { return s.less(s.idx[i], s.idx[j]) }
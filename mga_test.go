@@ -0,0 +1,104 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+// circularEphemeris returns circular heliocentric orbits of the inner planets, with all planets aligned at
+// the reference epoch.
+func circularEphemeris(body CelestialObject, dt time.Time) (R, V []float64) {
+	radii := map[string]float64{"Venus": 0.723 * AU, "Earth": AU, "Mars": 1.524 * AU}
+	incs := map[string]float64{"Venus": 3.39, "Earth": 0, "Mars": 1.85}
+	r := radii[body.Name]
+	n := math.Sqrt(Sun.μ / math.Pow(r, 3))
+	λ := n * dt.Sub(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)).Seconds()
+	o := NewOrbitFromOE(r, 0, incs[body.Name], 0, 0, Rad2deg(math.Mod(λ, 2*math.Pi)), Sun)
+	return o.RV()
+}
+
+func TestPoweredFlyby(t *testing.T) {
+	// An unpowered flyby turns the v∞ by the turn angle.
+	rP := Venus.Radius + 1000
+	vInf := 5.
+	ψ := GATurnAngle(vInf, rP, Venus)
+	in := []float64{vInf, 0, 0}
+	out := []float64{vInf * math.Cos(ψ), vInf * math.Sin(ψ), 0}
+	Δv, rPfb := poweredFlyby(in, out, Venus)
+	if !floats.EqualWithinAbs(Δv, 0, 1e-9) || !floats.EqualWithinRel(rPfb, rP, 1e-6) {
+		t.Fatalf("unpowered flyby: Δv=%f rP=%f", Δv, rPfb)
+	}
+	// A pure v∞ magnitude change is performed at periapsis.
+	out = []float64{6 * math.Cos(ψ), 6 * math.Sin(ψ), 0}
+	Δv, rPfb = poweredFlyby(in, out, Venus)
+	exp := math.Sqrt(36+2*Venus.μ/rPfb) - math.Sqrt(25+2*Venus.μ/rPfb)
+	if !floats.EqualWithinAbs(Δv, exp, 1e-12) || Δv >= 1 {
+		t.Fatalf("powered flyby Δv=%f", Δv)
+	}
+}
+
+func TestMGADirect(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	seq := NewMGASequence([]CelestialObject{Earth, Mars}, start, start.Add(800*24*time.Hour), []float64{100}, []float64{400})
+	seq.Ephemeris = circularEphemeris
+	front := MGAParetoFront(seq, EvolutionConfig{Population: 30, Generations: 80, F: 0.7, CR: 0.9, Seed: 1})
+	if len(front) < 3 {
+		t.Fatalf("Pareto front has %d trajectories", len(front))
+	}
+	for i, traj := range front {
+		if !traj.Valid || len(traj.Epochs) != 2 {
+			t.Fatalf("invalid trajectory %s", traj)
+		}
+		if !floats.EqualWithinAbs(traj.Epochs[1].Sub(traj.Epochs[0]).Hours()/24, traj.TOF, 1e-6) {
+			t.Fatalf("inconsistent TOF %s", traj)
+		}
+		for j, other := range front {
+			if i != j && other.dominates(traj) {
+				t.Fatalf("%s is dominated by %s", traj, other)
+			}
+		}
+		if i > 0 && (traj.TOF < front[i-1].TOF || traj.ΔV > front[i-1].ΔV) {
+			t.Fatal("Pareto front not sorted")
+		}
+	}
+	// The minimum Δv matches a grid search.
+	gridBest := math.Inf(1)
+	for launch := 0.; launch <= 800; launch += 4 {
+		for tof := 100.; tof <= 400; tof += 4 {
+			if traj := seq.Evaluate([]float64{launch, tof}); traj.Valid {
+				gridBest = math.Min(gridBest, traj.ΔV)
+			}
+		}
+	}
+	if best := front[len(front)-1]; best.ΔV > gridBest*1.01 {
+		t.Fatalf("best Δv trajectory %s is worse than the grid search %f km/s", best, gridBest)
+	}
+}
+
+func TestMGAFlyby(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	seq := NewMGASequence([]CelestialObject{Earth, Venus, Mars}, start, start.Add(600*24*time.Hour), []float64{80, 100}, []float64{300, 500})
+	seq.Ephemeris = circularEphemeris
+	front := MGAParetoFront(seq, EvolutionConfig{Population: 30, Generations: 50, F: 0.7, CR: 0.9, Seed: 2})
+	if len(front) == 0 {
+		t.Fatal("empty Pareto front")
+	}
+	for _, traj := range front {
+		if len(traj.Epochs) != 3 || len(traj.FlybyΔv) != 1 || len(traj.FlybyRP) != 1 {
+			t.Fatalf("invalid flyby trajectory %s", traj)
+		}
+		exp := traj.VInfLaunch + traj.FlybyΔv[0] + traj.VInfArrival
+		if traj.FlybyRP[0] >= Venus.Radius+seq.MinFlybyAltitude && !floats.EqualWithinAbs(traj.ΔV, exp, 1e-9) {
+			t.Fatalf("invalid Δv for %s", traj)
+		}
+		if traj.ΔV < exp {
+			t.Fatalf("Δv lower than its components for %s", traj)
+		}
+	}
+	assertPanic(t, func() {
+		NewMGASequence([]CelestialObject{Earth, Mars}, start, start, []float64{100, 100}, []float64{400})
+	})
+}
@@ -6,7 +6,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/ChristopherRabotin/ode"
 	"github.com/gonum/matrix/mat64"
 )
 
@@ -25,13 +24,21 @@ type Mission struct {
 	Orbit                      *Orbit       // As pointer because the orbit changes during propagation.
 	Φ                          *mat64.Dense // STM
 	StartDT, StopDT, CurrentDT time.Time
+	Integrator                 Integrator               // Numerical integrator, RK4 by default
+	Canonical                  bool                     // Integrates in the canonical units of the central body (cf. CanonicalUnits)
+	Gravity                    map[string]BodyConstants // Per body name overrides of the GM and zonal harmonics of the dynamics
+	SOI                        *SOITransitions          // Automatic SOI transitions, disabled if nil
 	perts                      Perturbations
 	step                       time.Duration // time step
 	stopChan                   chan (bool)
-	histChans                  []chan (State)
+	histChans                  []stateSubscriber
 	computeSTM, done, collided bool
 	autoChanClosing            bool // Set to False to not automatically close the channels upon end propgation time reached.
 	propuntilCalled            bool // Avoids too many messages if repeated calls to PropagateUntil()
+	pauseChan                  chan (chan (bool))
+	pauseMu                    sync.Mutex // Protects the two following fields
+	resumeChan                 chan (bool)
+	propagating                bool
 }
 
 // NewMission is the same as NewPreciseMission with the default step size.
@@ -49,14 +56,15 @@ func NewPreciseMission(s *Spacecraft, o *Orbit, start, end time.Time, perts Pert
 		end = end.UTC()
 	}
 	rSTM, _ := perts.STMSize()
-	a := &Mission{s, o, DenseIdentity(rSTM), start, end, start, perts, step, make(chan (bool), 1), nil, computeSTM, false, false, true, false}
+	a := &Mission{s, o, DenseIdentity(rSTM), start, end, start, RK4Integrator, false, nil, nil, perts, step, make(chan (bool), 1), nil, computeSTM, false, false, true, false, make(chan (chan (bool)), 1), sync.Mutex{}, nil, false}
 	// Create a main history channel if there is any exporting
 	if !conf.IsUseless() {
-		a.histChans = []chan (State){make(chan (State), 10)}
+		histChan := make(chan (State), 10)
+		a.histChans = []stateSubscriber{{histChan, BlockPolicy}}
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			StreamStates(conf, a.histChans[0])
+			StreamStates(conf, histChan)
 		}()
 		// Write the first data point.
 	}
@@ -68,10 +76,81 @@ func NewPreciseMission(s *Spacecraft, o *Orbit, start, end time.Time, perts Pert
 	return a
 }
 
-// RegisterStateChan appends a new channel where to publish states as they are computed
+// RegisterStateChan appends a new channel where to publish states as they are computed, which blocks the
+// propagation until the channel has room (cf. Subscribe).
 // WARNING: One *should not* write to this channel, but no check is done. Don't be dumb.
 func (a *Mission) RegisterStateChan(c chan (State)) {
-	a.histChans = append(a.histChans, c)
+	a.Subscribe(c, BlockPolicy)
+}
+
+// Subscribe appends a new channel where to publish states as they are computed, with the provided policy when the
+// subscriber is slower than the propagation. Several subscribers (e.g. an OD filter and an exporter) may listen
+// concurrently, each with its own policy. The channel is closed at the end of the propagation.
+// Panics if the policy is DropOldestPolicy on an unbuffered channel.
+func (a *Mission) Subscribe(c chan (State), policy BackpressurePolicy) {
+	if policy == DropOldestPolicy && cap(c) == 0 {
+		panic("drop oldest policy requires a buffered channel")
+	}
+	a.histChans = append(a.histChans, stateSubscriber{c, policy})
+}
+
+// BackpressurePolicy defines how states are published to a subscriber which is slower than the propagation.
+type BackpressurePolicy uint8
+
+const (
+	// BlockPolicy blocks the propagation until the subscriber has room for the state: no state is lost.
+	BlockPolicy BackpressurePolicy = iota
+	// DropOldestPolicy drops the oldest buffered state to make room for the new one: the subscriber always
+	// receives the latest states, within the capacity of its channel.
+	DropOldestPolicy
+	// SamplePolicy only publishes a state if the subscriber has room for it, i.e. the states computed while the
+	// subscriber is busy are skipped. On an unbuffered channel, the subscriber receives a state whenever it is ready.
+	SamplePolicy
+)
+
+func (p BackpressurePolicy) String() string {
+	switch p {
+	case BlockPolicy:
+		return "block"
+	case DropOldestPolicy:
+		return "drop-oldest"
+	case SamplePolicy:
+		return "sample"
+	default:
+		panic("unknown backpressure policy")
+	}
+}
+
+// stateSubscriber is a channel where to publish the states, with its backpressure policy.
+type stateSubscriber struct {
+	c      chan (State)
+	policy BackpressurePolicy
+}
+
+// publish publishes the state according to the policy of the subscriber.
+func (s stateSubscriber) publish(state State) {
+	switch s.policy {
+	case BlockPolicy:
+		s.c <- state
+	case DropOldestPolicy:
+		for {
+			select {
+			case s.c <- state:
+				return
+			default:
+			}
+			// The channel is full: drop the oldest state, unless the subscriber just received it.
+			select {
+			case <-s.c:
+			default:
+			}
+		}
+	case SamplePolicy:
+		select {
+		case s.c <- state:
+		default:
+		}
+	}
 }
 
 // LogStatus returns the status of the propagation and vehicle.
@@ -79,17 +158,38 @@ func (a *Mission) LogStatus() {
 	a.Vehicle.logger.Log("level", "info", "subsys", "astro", "date", a.CurrentDT, "fuel(kg)", a.Vehicle.FuelMass, "orbit", a.Orbit)
 }
 
-// PropagateUntil propagates until the given time is reached.
+// PropagateUntil propagates until the given time is reached. If the time is not a whole number of steps away, the
+// last step is shortened so that the last published state is exactly at the requested time.
 func (a *Mission) PropagateUntil(dt time.Time, autoClose bool) {
 	if !a.propuntilCalled {
 		a.CurrentDT = a.CurrentDT.Add(-a.step)
 		a.SetState(0, a.GetState())
 		a.LogStatus()
 	}
+	// Maneuvers scheduled at the current epoch, e.g. between two calls, are executed before the first step.
+	a.executeManeuver()
 	a.propuntilCalled = true
-	a.autoChanClosing = autoClose
-	a.StopDT = dt
 	a.StartDT = a.CurrentDT.Add(-a.step)
+	a.StopDT = dt
+	remaining := dt.Sub(a.CurrentDT)
+	if partial := remaining % a.step; remaining > 0 && partial != 0 {
+		// Propagate the whole steps first, without closing the channels.
+		a.autoChanClosing = false
+		a.StopDT = dt.Add(-partial)
+		a.integrate()
+		if a.CurrentDT.Before(a.StopDT) {
+			// The propagation was stopped.
+			a.StopDT = a.CurrentDT
+		} else {
+			a.StopDT = dt
+			step := a.step
+			a.step = partial
+			defer func() {
+				a.step = step
+			}()
+		}
+	}
+	a.autoChanClosing = autoClose
 	a.Propagate()
 }
 
@@ -98,10 +198,12 @@ func (a *Mission) Propagate() {
 	// Write the first data point
 	if !a.propuntilCalled {
 		a.StopDT = a.StopDT.Add(-a.step)
+		// SetState moves to the next step, so the first data point is published at the start.
+		a.CurrentDT = a.CurrentDT.Add(-a.step)
 		a.SetState(0, a.GetState())
-		a.CurrentDT = a.CurrentDT.Add(-a.step) // Reset after first SetState call
 		a.LogStatus()
 	}
+	a.executeManeuver()
 	// Add a ticker status report based on the duration of the simulation.
 	ticker := time.NewTicker(10 * time.Second)
 	go func() {
@@ -114,7 +216,7 @@ func (a *Mission) Propagate() {
 	}()
 	vInit := Norm(a.Orbit.V())
 	initFuel := a.Vehicle.FuelMass
-	ode.NewRK4(0, a.step.Seconds(), a).Solve() // Blocking.
+	a.integrate() // Blocking.
 	vFinal := Norm(a.Orbit.V())
 	a.done = true
 	if a.autoChanClosing {
@@ -143,8 +245,74 @@ func (a *Mission) StopPropagation() {
 	a.stopChan <- true
 }
 
+// Pause pauses the propagation at the end of the current step, and blocks until it is paused. Until Resume is
+// called, the spacecraft and its orbit may be inspected or modified, e.g. to add a maneuver. Returns false if no
+// propagation is running (or it is already paused), in which case nothing is paused.
+func (a *Mission) Pause() bool {
+	a.pauseMu.Lock()
+	if !a.propagating || a.resumeChan != nil {
+		a.pauseMu.Unlock()
+		return false
+	}
+	paused := make(chan (bool), 1)
+	a.pauseChan <- paused
+	a.pauseMu.Unlock()
+	return <-paused
+}
+
+// Resume resumes a paused propagation. Returns false if the propagation is not paused.
+func (a *Mission) Resume() bool {
+	a.pauseMu.Lock()
+	defer a.pauseMu.Unlock()
+	if a.resumeChan == nil {
+		return false
+	}
+	close(a.resumeChan)
+	a.resumeChan = nil
+	return true
+}
+
+// setPropagating sets whether the integrator is running, and rejects any pending pause when it stops.
+func (a *Mission) setPropagating(propagating bool) {
+	a.pauseMu.Lock()
+	defer a.pauseMu.Unlock()
+	a.propagating = propagating
+	if !propagating {
+		select {
+		case paused := <-a.pauseChan:
+			paused <- false
+		default:
+		}
+	}
+}
+
+// waitIfPaused blocks while the propagation is paused.
+func (a *Mission) waitIfPaused() {
+	select {
+	case paused := <-a.pauseChan:
+		resume := make(chan (bool))
+		a.pauseMu.Lock()
+		a.resumeChan = resume
+		a.pauseMu.Unlock()
+		a.Vehicle.logger.Log("level", "notice", "subsys", "astro", "status", "paused", "date", a.CurrentDT)
+		paused <- true
+		select {
+		case <-resume:
+			a.Vehicle.logger.Log("level", "notice", "subsys", "astro", "status", "resumed", "date", a.CurrentDT)
+		case <-a.stopChan:
+			// Stopped while paused: the stop is handled by the caller.
+			a.pauseMu.Lock()
+			a.resumeChan = nil
+			a.pauseMu.Unlock()
+			a.stopChan <- true
+		}
+	default:
+	}
+}
+
 // Stop implements the stop call of the integrator. To stop the propagation, call StopPropagation().
 func (a *Mission) Stop(t float64) bool {
+	a.waitIfPaused()
 	var stop bool
 	select {
 	case <-a.stopChan:
@@ -174,24 +342,37 @@ func (a *Mission) Stop(t float64) bool {
 	if stop {
 		if a.autoChanClosing {
 			for _, histChan := range a.histChans {
-				close(histChan)
+				close(histChan.c)
 			}
 		}
 	}
 	return stop
 }
 
-// GetState returns the state for the integrator for the Gaussian VOP.
-func (a *Mission) GetState() (s []float64) {
-	stateSize := 7
+// stateSize returns the size of the integration state: position, velocity and fuel, followed by Cr (only if
+// computing the STM with drag), the DMC empirical accelerations, and finally the components of the STM.
+func (a *Mission) stateSize() int {
 	if a.computeSTM {
 		rSTM, cSTM := a.perts.STMSize()
-		stateSize += rSTM * cSTM
-		if a.perts.Drag {
-			stateSize += 1
-		}
+		return rSTM + 1 + rSTM*cSTM
+	}
+	if a.perts.DMC != nil {
+		return 10
+	}
+	return 7
+}
+
+// dmcIndex returns the index of the first empirical acceleration in the integration state.
+func (a *Mission) dmcIndex() int {
+	if a.computeSTM && a.perts.Drag {
+		return 8
 	}
-	s = make([]float64, stateSize)
+	return 7
+}
+
+// GetState returns the Cartesian state for the integrator.
+func (a *Mission) GetState() (s []float64) {
+	s = make([]float64, a.stateSize())
 	R, V := a.Orbit.RV()
 	// R, V in the state
 	for i := 0; i < 3; i++ {
@@ -199,6 +380,9 @@ func (a *Mission) GetState() (s []float64) {
 		s[i+3] = V[i]
 	}
 	s[6] = a.Vehicle.FuelMass
+	if a.perts.DMC != nil && len(a.Vehicle.EmpiricalAcc) == 3 {
+		copy(s[a.dmcIndex():], a.Vehicle.EmpiricalAcc)
+	}
 	if a.computeSTM {
 		if a.Vehicle.Drag > 0 {
 			s[7] = a.Vehicle.Drag
@@ -216,12 +400,35 @@ func (a *Mission) GetState() (s []float64) {
 	return
 }
 
+// executeManeuver adds the impulsive maneuver scheduled at the current epoch, if any, to the velocity of the orbit,
+// so that the next integration step starts after the burn. The impulse is instantaneous instead of an acceleration
+// over the first stage of the step, and therefore does not depend on the step size.
+func (a *Mission) executeManeuver() {
+	dt := a.CurrentDT.Truncate(a.step)
+	maneuver, exists := a.Vehicle.Maneuvers[dt]
+	if !exists || maneuver.done {
+		return
+	}
+	a.Vehicle.logger.Log("level", "info", "subsys", "astro", "date", a.CurrentDT, "thrust", "impulse", "v(km/s)", maneuver.Δv())
+	R, V := a.Orbit.RV()
+	Δv := MxV33(a.Orbit.RICDCM().T(), []float64{maneuver.R, maneuver.N, maneuver.C})
+	*a.Orbit = *NewOrbitFromRV(R, []float64{V[0] + Δv[0], V[1] + Δv[1], V[2] + Δv[2]}, a.Orbit.Origin)
+	maneuver.done = true
+	a.Vehicle.Maneuvers[dt] = maneuver
+}
+
 // SetState sets the updated state.
 func (a *Mission) SetState(t float64, s []float64) {
 	a.CurrentDT = a.CurrentDT.Add(a.step)
 	R := []float64{s[0], s[1], s[2]}
 	V := []float64{s[3], s[4], s[5]}
 	*a.Orbit = *NewOrbitFromRV(R, V, a.Orbit.Origin) // Deref is important (cf. TestMissionSpiral)
+	if t > 0 {
+		// Not on the initial state.
+		a.soiTransition()
+		copy(s[0:3], a.Orbit.R())
+		copy(s[3:6], a.Orbit.V())
+	}
 
 	// Orbit sanity checks and warnings.
 	if !a.collided && a.Orbit.RNorm() < a.Orbit.Origin.Radius {
@@ -240,16 +447,24 @@ func (a *Mission) SetState(t float64, s []float64) {
 	}
 	a.Vehicle.FuelMass = s[6]
 
-	var latestVector *mat64.Vector
+	st := make([]float64, 6, 10)
+	copy(st, s[0:6])
 	if a.Vehicle.Drag > 0 && a.computeSTM {
-		st := s[0:6]
 		st = append(st, a.Vehicle.Drag)
 		// Update Cr
 		a.Vehicle.Drag = s[7]
-		latestVector = mat64.NewVector(7, st)
-	} else {
-		latestVector = mat64.NewVector(6, s[0:6])
 	}
+	if a.perts.DMC != nil {
+		// New slice because the State stores a copy of the spacecraft.
+		dmcIdx := a.dmcIndex()
+		a.Vehicle.EmpiricalAcc = []float64{s[dmcIdx], s[dmcIdx+1], s[dmcIdx+2]}
+		st = append(st, a.Vehicle.EmpiricalAcc...)
+	}
+	if a.Vehicle.Thermal != nil {
+		// Copy because the State shares the thermal limit with the propagated spacecraft.
+		a.Vehicle.Guidance.Heat, a.Vehicle.Guidance.Cooling = a.Vehicle.Thermal.Heat(), a.Vehicle.Thermal.Cooling()
+	}
+	latestVector := mat64.NewVector(len(st), st)
 	latestState := State{a.CurrentDT, *a.Vehicle, *a.Orbit, nil, latestVector}
 
 	if a.computeSTM {
@@ -273,7 +488,7 @@ func (a *Mission) SetState(t float64, s []float64) {
 	}
 
 	for _, histChan := range a.histChans {
-		histChan <- latestState
+		histChan.publish(latestState)
 	}
 
 	// Let's execute any function which is in the queue of this time step.
@@ -285,18 +500,16 @@ func (a *Mission) SetState(t float64, s []float64) {
 	}
 	a.Vehicle.FuncQ = make([]func(), 5) // Clear the queue.
 
+	if t > 0 {
+		// The maneuvers of the initial epoch are executed by Propagate and PropagateUntil.
+		a.executeManeuver()
+	}
 }
 
-// Func is the integration function using Gaussian VOP as per Ruggiero et al. 2011.
+// Func is the integration function of the Cartesian state, where the thrust is computed with the control laws of
+// Ruggiero et al. 2011.
 func (a *Mission) Func(t float64, f []float64) (fDot []float64) {
-	stateSize := 7
-	if a.computeSTM {
-		rSTM, cSTM := a.perts.STMSize()
-		stateSize += rSTM * cSTM
-		if a.perts.Drag {
-			stateSize += 1
-		}
-	}
+	stateSize := a.stateSize()
 	fDot = make([]float64, stateSize) // init return vector
 	// Let's add the thrust to increase the magnitude of the velocity.
 	// XXX: Should this Accelerate call be with tmpOrbit?!
@@ -306,20 +519,16 @@ func (a *Mission) Func(t float64, f []float64) (fDot []float64) {
 	R := []float64{f[0], f[1], f[2]}
 	V := []float64{f[3], f[4], f[5]}
 	tmpOrbit = NewOrbitFromRV(R, V, a.Orbit.Origin)
-	bodyAcc := -tmpOrbit.Origin.μ / math.Pow(Norm(R), 3)
-	_, _, i, Ω, _, _, _, _, u := tmpOrbit.Elements()
-	// Check if any impulse burn, and execute them if needed.
-	if maneuver, exists := a.Vehicle.Maneuvers[a.CurrentDT.Truncate(a.step)]; exists {
-		if !maneuver.done {
-			a.Vehicle.logger.Log("level", "info", "subsys", "astro", "date", a.CurrentDT, "thrust", "impulse", "v(km/s)", maneuver.Δv())
-			Δv[0] += maneuver.R
-			Δv[1] += maneuver.N
-			Δv[2] += maneuver.C
-			maneuver.done = true
-			a.Vehicle.Maneuvers[a.CurrentDT.Truncate(a.step)] = maneuver
-		}
+	perts := a.perts
+	perts.gravity = a.Gravity
+	gravity := perts.gravityOf(a.Orbit.Origin)
+	bodyAcc := -gravity.μ / math.Pow(Norm(R), 3)
+	// The thrust is rotated from the RIC frame without the orbital angles, which are ill-defined (and wrapped)
+	// for circular and equatorial orbits, and made the thrust direction depend on the step.
+	// The frame is undefined for rectilinear trajectories, which is only an issue when thrusting.
+	if Norm(Δv) > 0 {
+		Δv = MxV33(tmpOrbit.RICDCM().T(), Δv)
 	}
-	Δv = Rot313Vec(-u, -i, -Ω, Δv)
 	// d\vec{R}/dt
 	fDot[0] = f[3]
 	fDot[1] = f[4]
@@ -332,7 +541,17 @@ func (a *Mission) Func(t float64, f []float64) (fDot []float64) {
 	fDot[6] = -usedFuel
 
 	// Compute and add the perturbations (which are method dependent).
-	pert := a.perts.Perturb(*tmpOrbit, a.CurrentDT, *a.Vehicle)
+	pert := perts.Perturb(*tmpOrbit, a.CurrentDT, *a.Vehicle)
+
+	// Empirical accelerations as first order Gauss-Markov processes.
+	if a.perts.DMC != nil {
+		dmcIdx := a.dmcIndex()
+		β := a.perts.DMC.β()
+		for i := 0; i < 3; i++ {
+			fDot[i+3] += f[dmcIdx+i]
+			fDot[dmcIdx+i] = -β * f[dmcIdx+i]
+		}
+	}
 
 	// Compute STM if needed.
 	if a.computeSTM {
@@ -369,15 +588,15 @@ func (a *Mission) Func(t float64, f []float64) (fDot []float64) {
 		r252 := math.Pow(r2, 5/2.)
 
 		// Add the body perturbations
-		dAxDx := 3*a.Orbit.Origin.μ*x2/r252 - a.Orbit.Origin.μ/r232
-		dAxDy := 3 * a.Orbit.Origin.μ * x * y / r252
-		dAxDz := 3 * a.Orbit.Origin.μ * x * z / r252
-		dAyDx := 3 * a.Orbit.Origin.μ * x * y / r252
-		dAyDy := 3*a.Orbit.Origin.μ*y2/r252 - a.Orbit.Origin.μ/r232
-		dAyDz := 3 * a.Orbit.Origin.μ * y * z / r252
-		dAzDx := 3 * a.Orbit.Origin.μ * x * z / r252
-		dAzDy := 3 * a.Orbit.Origin.μ * y * z / r252
-		dAzDz := 3*a.Orbit.Origin.μ*z2/r252 - a.Orbit.Origin.μ/r232
+		dAxDx := 3*gravity.μ*x2/r252 - gravity.μ/r232
+		dAxDy := 3 * gravity.μ * x * y / r252
+		dAxDz := 3 * gravity.μ * x * z / r252
+		dAyDx := 3 * gravity.μ * x * y / r252
+		dAyDy := 3*gravity.μ*y2/r252 - gravity.μ/r232
+		dAyDz := 3 * gravity.μ * y * z / r252
+		dAzDx := 3 * gravity.μ * x * z / r252
+		dAzDy := 3 * gravity.μ * y * z / r252
+		dAzDz := 3*gravity.μ*z2/r252 - gravity.μ/r232
 
 		A.Set(3, 0, dAxDx)
 		A.Set(4, 0, dAyDx)
@@ -413,7 +632,7 @@ func (a *Mission) Func(t float64, f []float64) (fDot []float64) {
 			r272 := math.Pow(r2, 7/2.)
 			r292 := math.Pow(r2, 9/2.)
 			// J2
-			j2fact := a.Orbit.Origin.J(2) * math.Pow(a.Orbit.Origin.Radius, 2) * a.Orbit.Origin.μ
+			j2fact := gravity.J(2) * math.Pow(gravity.Radius, 2) * gravity.μ
 			A30 += -f32 * j2fact * (35*x2*z2/r292 - 5*x2/r272 - 5*z2/r272 + 1/r252) //dAxDx
 			A40 += -f152 * j2fact * (7*x*y*z2/r292 - x*y/r272)                      //dAyDx
 			A50 += -f152 * j2fact * (7*x*z3/r292 - 3*x*z/r272)                      //dAzDx
@@ -432,7 +651,7 @@ func (a *Mission) Func(t float64, f []float64) (fDot []float64) {
 				r2112 := math.Pow(r2, 11/2.)
 				f52 := 5 / 2.
 				f1052 := 105 / 2.
-				j3fact := a.Orbit.Origin.J(3) * math.Pow(a.Orbit.Origin.Radius, 3) * a.Orbit.Origin.μ
+				j3fact := gravity.J(3) * math.Pow(gravity.Radius, 3) * gravity.μ
 				A30 += -f52 * j3fact * (63*x2*z3/r2112 - 21*x2*z/r292 - 7*z3/r292 + 3*z/r272) //dAxDx
 				A40 += -f1052 * j3fact * (3*x*y*z3/r2112 - x*y*z/r292)                        //dAyDx
 				A50 += -f152 * j3fact * (21*x*z4/r2112 - 14*x*z2/r292 + x/r272)               //dAzDx
@@ -459,74 +678,25 @@ func (a *Mission) Func(t float64, f []float64) (fDot []float64) {
 			A.Set(5, 2, A52)
 		}
 
-		var RSunToEarth, RSunToSC, REarthToSC []float64
-
-		if a.perts.Drag || a.perts.PerturbingBody != nil {
-			REarthToSC = a.Orbit.R()
-			RSunToEarth = MxV33(R1(Deg2rad(-Earth.tilt)), a.Orbit.Origin.HelioOrbit(a.CurrentDT).R())
-			RSunToSC = make([]float64, 3)
-			for i := 0; i < 3; i++ {
-				RSunToSC[i] = RSunToEarth[i] + REarthToSC[i]
+		// SRP, third body and arbitrary perturbations (e.g. drag).
+		dAdX, dAdCr := perts.Partials(*tmpOrbit, a.CurrentDT, *a.Vehicle)
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 6; j++ {
+				A.Set(i+3, j, A.At(i+3, j)+dAdX.At(i, j))
 			}
-		}
-
-		if a.perts.Drag || a.perts.PerturbingBody != nil {
-			Cr := a.Vehicle.Drag
-			S := 0.01e-6 // TODO: Idem for the Area to mass ratio
-			Phi := 1357.
-			// Build the vectors.
-			celerity := 2.997925e+05
-			thisPert := -Sun.μ
+			// \partial a/\partial Cr
 			if a.perts.Drag {
-				thisPert += (Phi * AU * AU * S / celerity) * Cr
+				A.Set(i+3, 6, dAdCr[i])
 			}
-			RSunToSC3 := math.Pow(Norm(RSunToSC), 3)
-			RSunToSC5 := math.Pow(Norm(RSunToSC), 5)
-
-			// Getting values
-			// Ai0 = \frac{\partial a}{\partial x}
-			// Ai1 = \frac{\partial a}{\partial y}
-			// Ai2 = \frac{\partial a}{\partial z}
-			A30 := A.At(3, 0)
-			A40 := A.At(4, 0)
-			A50 := A.At(5, 0)
-			A31 := A.At(3, 1)
-			A41 := A.At(4, 1)
-			A51 := A.At(5, 1)
-			A32 := A.At(3, 2)
-			A42 := A.At(4, 2)
-			A52 := A.At(5, 2)
+		}
 
-			dAxDx := thisPert/RSunToSC3 + thisPert*(-1.5/RSunToSC5)*(-RSunToSC[0])*2*(-RSunToSC[0])
-			dAxDy := thisPert * (-1.5 / RSunToSC5) * (-RSunToSC[0]) * 2 * (-RSunToSC[1])
-			dAxDz := thisPert * (-1.5 / RSunToSC5) * (-RSunToSC[0]) * 2 * (-RSunToSC[2])
-			dAxDCr := (Phi * AU * AU * S / celerity) / (RSunToSC3 * (-RSunToSC[0]))
-			dAyDx := thisPert * (-1.5 / RSunToSC5) * (-RSunToSC[1]) * 2 * (-RSunToSC[0])
-			dAyDy := thisPert/RSunToSC3 + thisPert*(-1.5/RSunToSC5)*(-RSunToSC[1])*2*(-RSunToSC[1])
-			dAyDz := thisPert * (-1.5 / RSunToSC5) * (-RSunToSC[1]) * 2 * (-RSunToSC[2])
-			dAyDCr := (Phi * AU * AU * S / celerity) / (RSunToSC3 * (-RSunToSC[1]))
-			dAzDx := thisPert * (-1.5 / RSunToSC5) * (-RSunToSC[2]) * 2 * (-RSunToSC[0])
-			dAzDy := thisPert * (-1.5 / RSunToSC5) * (-RSunToSC[2]) * 2 * (-RSunToSC[1])
-			dAzDz := thisPert/RSunToSC3 + thisPert*(-1.5/RSunToSC5)*(-RSunToSC[2])*2*(-RSunToSC[2])
-			dAzDCr := (Phi * AU * AU * S / celerity) / (RSunToSC3 * (-RSunToSC[2]))
-			// Setting values
-			// \frac{\partial a}{\partial x}
-			A.Set(3, 0, A30+dAxDx)
-			A.Set(4, 0, A40+dAyDx)
-			A.Set(5, 0, A50+dAzDx)
-			// \partial a/\partial y
-			A.Set(3, 1, A31+dAxDy)
-			A.Set(4, 1, A41+dAyDy)
-			A.Set(5, 1, A51+dAzDy)
-			// \partial a/\partial z
-			A.Set(3, 2, A32+dAxDz)
-			A.Set(4, 2, A42+dAyDz)
-			A.Set(5, 2, A52+dAzDz)
-			// \partial a/\partial Cr
-			if a.perts.Drag {
-				A.Set(3, 6, dAxDCr)
-				A.Set(4, 6, dAyDCr)
-				A.Set(5, 6, dAzDCr)
+		if a.perts.DMC != nil {
+			// \partial a/\partial w is identity and \partial \dot{w}/\partial w is -β identity.
+			wIdx := a.perts.dmcSTMIndex()
+			β := a.perts.DMC.β()
+			for i := 0; i < 3; i++ {
+				A.Set(i+3, wIdx+i, 1)
+				A.Set(wIdx+i, wIdx+i, -β)
 			}
 		}
 
@@ -535,7 +705,7 @@ func (a *Mission) Func(t float64, f []float64) (fDot []float64) {
 		// Store ΦDot in fDot
 		fIdx = rΦ + 1
 		if a.perts.Drag {
-			fDot[fIdx-1] = a.Vehicle.Drag
+			fDot[7] = a.Vehicle.Drag
 		}
 		for i := 0; i < rΦ; i++ {
 			for j := 0; j < cΦ; j++ {
@@ -567,7 +737,7 @@ type State struct {
 	cVector *mat64.Vector
 }
 
-// Vector returns the orbit vector with position and velocity.
+// Vector returns the orbit vector with position and velocity, followed by Cr and the DMC empirical accelerations if any.
 func (s State) Vector() *mat64.Vector {
 	if s.cVector == nil {
 		var vec *mat64.Vector
@@ -582,6 +752,13 @@ func (s State) Vector() *mat64.Vector {
 			vec.SetVec(i, R[i])
 			vec.SetVec(i+3, V[i])
 		}
+		if len(s.SC.EmpiricalAcc) == 3 {
+			vals := make([]float64, vec.Len(), vec.Len()+3)
+			for i := range vals {
+				vals[i] = vec.At(i, 0)
+			}
+			vec = mat64.NewVector(len(vals)+3, append(vals, s.SC.EmpiricalAcc...))
+		}
 		s.cVector = vec
 	}
 	return s.cVector
@@ -201,7 +201,7 @@ func TestCorrectOEa(t *testing.T) {
 		EPThrusters := []EPThruster{new(PPS1350)}
 		dryMass := 300.0
 		fuelMass := 67.0
-		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔaCL)})
+		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔaCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		end := start.Add(time.Duration(45*24) * time.Hour)
 		astro := NewMission(sc, oInit, start, end, Perturbations{}, false, ExportConfig{Filename: fmt.Sprintf("ruggOEa-%s", meth), Cosmo: smdConfig().testExport, AsCSV: smdConfig().testExport})
@@ -229,7 +229,7 @@ func TestCorrectOEaNeg(t *testing.T) {
 		EPThrusters := []EPThruster{new(PPS1350)}
 		dryMass := 300.0
 		fuelMass := 67.0
-		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔaCL)})
+		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔaCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		end := start.Add(time.Duration(45*24) * time.Hour)
 		astro := NewMission(sc, oInit, start, end, Perturbations{}, false, ExportConfig{})
@@ -256,7 +256,7 @@ func TestCorrectOEi(t *testing.T) {
 		EPThrusters := []EPThruster{new(PPS1350)}
 		dryMass := 300.0
 		fuelMass := 67.0
-		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔiCL)})
+		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔiCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		end := start.Add(time.Duration(55*24) * time.Hour)
 		astro := NewMission(sc, oInit, start, end, Perturbations{}, false, ExportConfig{})
@@ -283,7 +283,7 @@ func TestCorrectOEiNeg(t *testing.T) {
 		EPThrusters := []EPThruster{new(PPS1350)}
 		dryMass := 300.0
 		fuelMass := 67.0
-		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔiCL)})
+		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔiCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		end := start.Add(time.Duration(55*24) * time.Hour)
 		astro := NewMission(sc, oInit, start, end, Perturbations{}, false, ExportConfig{})
@@ -301,6 +301,52 @@ func TestCorrectOEiNeg(t *testing.T) {
 	}
 }
 
+// TestCorrectOEiFuelOptimal checks that coasting away from the nodes saves propellant on an inclination change.
+func TestCorrectOEiFuelOptimal(t *testing.T) {
+	oTarget := NewOrbitFromOE(Earth.Radius+350, 0.001, 47, 1, 1, 1, Earth)
+	fuel := make(map[TargetingMode]float64)
+	for _, mode := range []TargetingMode{TimeOptimal, FuelOptimal} {
+		oInit := NewOrbitFromOE(Earth.Radius+350, 0.001, 46, 1, 1, 1, Earth)
+		fuelMass := 67.0
+		wp := NewOrbitTarget(*oTarget, nil, Ruggiero, mode, OptiΔiCL)
+		wp.SetEffectivity(0.8)
+		sc := NewSpacecraft("COE", 300, fuelMass, NewUnlimitedEPS(), []EPThruster{new(PPS1350)}, false, []*Cargo{}, []Waypoint{wp})
+		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		astro := NewMission(sc, oInit, start, start.Add(time.Duration(20*24)*time.Hour), Perturbations{}, false, ExportConfig{})
+		astro.Propagate()
+		_, _, i, _, _, _, _, _, _ := astro.Orbit.Elements()
+		if !floats.EqualWithinAbs(i, Deg2rad(47), angleε) {
+			t.Fatalf("%s: inclination of %f instead of 47", mode, Rad2deg(i))
+		}
+		fuel[mode] = fuelMass - astro.Vehicle.FuelMass
+	}
+	if fuel[FuelOptimal] >= 0.9*fuel[TimeOptimal] {
+		t.Fatalf("fuel optimal used %f kg but time optimal %f kg", fuel[FuelOptimal], fuel[TimeOptimal])
+	}
+}
+
+func TestOrbitTargetCoasting(t *testing.T) {
+	oTarget := NewOrbitFromOE(Earth.Radius+350, 0.001, 47, 0, 0, 0, Earth)
+	for _, tc := range []struct {
+		ν     float64
+		coast bool
+	}{{0, false}, {180, false}, {90, true}, {270, true}} {
+		wp := NewOrbitTarget(*oTarget, nil, Ruggiero, FuelOptimal, OptiΔiCL)
+		o := *NewOrbitFromOE(Earth.Radius+350, 0.001, 46, 0, 0, tc.ν, Earth)
+		ctrl, _ := wp.ThrustDirection(o, time.Now())
+		ctrl.Control(o) // Initializes the control law.
+		if thrust := ctrl.Control(o); (Norm(thrust) == 0) != tc.coast {
+			t.Fatalf("ν=%f: thrust=%+v", tc.ν, thrust)
+		}
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("invalid effectivity did not panic")
+		}
+	}()
+	NewOrbitTarget(*oTarget, nil, Ruggiero, FuelOptimal).SetEffectivity(1.5)
+}
+
 // TestCorrectOEΩ runs the test case from the Ruggiero 2012 conference paper.
 func TestCorrectOEΩ(t *testing.T) {
 	for _, meth := range []ControlLawType{Ruggiero, Naasz} {
@@ -310,7 +356,7 @@ func TestCorrectOEΩ(t *testing.T) {
 		EPThrusters := []EPThruster{new(PPS1350)}
 		dryMass := 300.0
 		fuelMass := 67.0
-		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔΩCL)})
+		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔΩCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		end := start.Add(time.Duration(49*24) * time.Hour)
 		astro := NewMission(sc, oInit, start, end, Perturbations{}, false, ExportConfig{})
@@ -337,7 +383,7 @@ func TestCorrectOEΩNeg(t *testing.T) {
 		EPThrusters := []EPThruster{new(PPS1350)}
 		dryMass := 300.0
 		fuelMass := 67.0
-		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔΩCL)})
+		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔΩCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		end := start.Add(time.Duration(49*24) * time.Hour) // just after the expected time
 		astro := NewMission(sc, oInit, start, end, Perturbations{}, false, ExportConfig{})
@@ -366,7 +412,7 @@ func TestCorrectOEΩShortWay(t *testing.T) {
 	EPThrusters := []EPThruster{new(PPS1350)}
 	dryMass := 300.0
 	fuelMass := 67.0
-	sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔΩCL)})
+	sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔΩCL)})
 	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 	end := start.Add(-1)
 	//end := start.Add(time.Duration(26) * time.Hour)
@@ -389,7 +435,7 @@ func TestCorrectOEe(t *testing.T) {
 		EPThrusters := []EPThruster{new(PPS1350)}
 		dryMass := 300.0
 		fuelMass := 67.0
-		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔeCL)})
+		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔeCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		end := start.Add(time.Duration(30*24) * time.Hour) // just after the expected time
 		astro := NewMission(sc, oInit, start, end, Perturbations{}, false, ExportConfig{Filename: fmt.Sprintf("ruggOEe-%s", meth), Cosmo: smdConfig().testExport, AsCSV: smdConfig().testExport})
@@ -416,7 +462,7 @@ func TestCorrectOEeNeg(t *testing.T) {
 		EPThrusters := []EPThruster{new(PPS1350)}
 		dryMass := 300.0
 		fuelMass := 67.0
-		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔeCL)})
+		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔeCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		end := start.Add(time.Duration(30*24) * time.Hour) // just after the expected time
 		astro := NewMission(sc, oInit, start, end, Perturbations{}, false, ExportConfig{})
@@ -443,7 +489,7 @@ func TestCorrectOEω(t *testing.T) {
 		EPThrusters := []EPThruster{new(PPS1350)}
 		dryMass := 300.0
 		fuelMass := 67.0
-		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔωCL)})
+		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔωCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		end := start.Add(time.Duration(2.5*24) * time.Hour) // just after the expected time
 		astro := NewMission(sc, oInit, start, end, Perturbations{}, false, ExportConfig{})
@@ -466,7 +512,7 @@ func TestCorrectOEωNeg(t *testing.T) {
 		EPThrusters := []EPThruster{new(PPS1350)}
 		dryMass := 300.0
 		fuelMass := 67.0
-		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔωCL)})
+		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔωCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		end := start.Add(time.Duration(1*24)*time.Hour + 2*time.Hour)
 		astro := NewMission(sc, oInit, start, end, Perturbations{}, false, ExportConfig{})
@@ -499,7 +545,7 @@ func TestCorrectOEωShortWay(t *testing.T) {
 	EPThrusters := []EPThruster{new(PPS1350)}
 	dryMass := 300.0
 	fuelMass := 67.0
-	sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔωCL)})
+	sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔωCL)})
 	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 	end := start.Add(time.Duration(27) * time.Hour)
 	astro := NewMission(sc, oInit, start, end, Perturbations{}, false, ExportConfig{})
@@ -522,7 +568,7 @@ func TestMultiCorrectOE(t *testing.T) {
 		EPThrusters := []EPThruster{new(PPS1350)}
 		dryMass := 300.0
 		fuelMass := 67.0
-		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔaCL, OptiΔeCL, OptiΔiCL)})
+		sc := NewSpacecraft("COE", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔaCL, OptiΔeCL, OptiΔiCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		var days int
 		var fuel float64
@@ -560,7 +606,7 @@ func TestPetropoulosCaseA(t *testing.T) {
 		EPThrusters := []EPThruster{NewGenericEP(1, 3100)}
 		dryMass := 1.0
 		fuelMass := 299.0
-		sc := NewSpacecraft("Petro", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔaCL, OptiΔeCL)})
+		sc := NewSpacecraft("Petro", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔaCL, OptiΔeCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		// With eta=0, the duration is 14.600 days.
 		//end := start.Add(time.Duration(15*24) * time.Hour)
@@ -584,7 +630,7 @@ func TestPetropoulosCaseB(t *testing.T) {
 		EPThrusters := []EPThruster{NewGenericEP(0.350, 2000)}
 		dryMass := 1.0
 		fuelMass := 1999.0
-		sc := NewSpacecraft("Petro", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔaCL, OptiΔiCL)})
+		sc := NewSpacecraft("Petro", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔaCL, OptiΔiCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		// About three months is what is needed without the eccentricity change.
 		end := start.Add(time.Duration(90*24) * time.Hour)
@@ -606,7 +652,7 @@ func TestPetropoulosCaseC(t *testing.T) {
 		EPThrusters := []EPThruster{NewGenericEP(9.3, 3100)}
 		dryMass := 1.0
 		fuelMass := 299.0
-		sc := NewSpacecraft("Petro", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, OptiΔaCL, OptiΔeCL)})
+		sc := NewSpacecraft("Petro", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal, OptiΔaCL, OptiΔeCL)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		end := start.Add(time.Duration(80*24) * time.Hour)
 		astro := NewMission(sc, oInit, start, end, Perturbations{}, false, ExportConfig{Filename: fmt.Sprintf("petroC-%s", meth), Cosmo: smdConfig().testExport, AsCSV: smdConfig().testExport})
@@ -630,7 +676,7 @@ func TestPetropoulosCaseE(t *testing.T) {
 		EPThrusters := []EPThruster{NewGenericEP(2, 2000)}
 		dryMass := 1.0
 		fuelMass := 1999.0
-		sc := NewSpacecraft("Petro", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth)})
+		sc := NewSpacecraft("Petro", dryMass, fuelMass, eps, EPThrusters, false, []*Cargo{}, []Waypoint{NewOrbitTarget(*oTarget, nil, meth, TimeOptimal)})
 		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
 		// There is no provided time, but the graph goes all the way to 240 days.
 		//end := start.Add(time.Duration(190*24) * time.Hour)
@@ -791,3 +837,164 @@ func TestMissionSTM(t *testing.T) {
 		}
 	}
 }
+
+func TestMissionTwoBodyAnalytic(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	duration := 10 * NewOrbitFromOE(8000, 0.1, 30, 40, 50, 60, Earth).Period()
+	for _, step := range []time.Duration{time.Second, 10 * time.Second, time.Minute} {
+		o := NewOrbitFromOE(8000, 0.1, 30, 40, 50, 60, Earth)
+		exp := keplerPropagate(*o, duration.Truncate(step))
+		NewPreciseMission(NewEmptySC("kepler", 0), o, start, start.Add(duration.Truncate(step)+step), Perturbations{}, step, false, ExportConfig{}).Propagate()
+		Δ := append([]float64(nil), o.R()...)
+		floats.Sub(Δ, exp.R())
+		// RK4 errors scale with the fourth power of the step.
+		if tol := 1e-3 * math.Pow(step.Seconds()/10, 4); Norm(Δ) > math.Max(tol, 1e-6) {
+			t.Fatalf("step=%s: position error of %e km", step, Norm(Δ))
+		}
+	}
+}
+
+func TestMissionThrustDirection(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	// The thrust must be tangential for circular orbits, including equatorial ones, at any point of the orbit.
+	for _, i := range []float64{0, 51.6} {
+		for ν := 0.; ν < 360; ν += 45 {
+			sc := NewSpacecraft("thrust", 500, 100, NewUnlimitedEPS(), []EPThruster{NewGenericEP(1, 2000)}, false, []*Cargo{}, []Waypoint{NewReachDistance(8000, true, nil)})
+			o := NewOrbitFromOE(7000, 0, i, 0, 0, ν, Earth)
+			m := NewPreciseMission(sc, o, start, start.Add(time.Hour), Perturbations{}, time.Second, false, ExportConfig{})
+			s := m.GetState()
+			fDot := m.Func(0, s)
+			acc := make([]float64, 3)
+			bodyAcc := -Earth.μ / math.Pow(o.RNorm(), 3)
+			for j := 0; j < 3; j++ {
+				acc[j] = fDot[j+3] - bodyAcc*s[j]
+			}
+			if !floats.EqualWithinRel(Norm(acc), 1/600e3, 1e-9) || !floats.EqualApprox(Unit(acc), Unit(o.V()), 1e-9) {
+				t.Fatalf("i=%f ν=%f: thrust %+v not along the velocity %+v", i, ν, Unit(acc), Unit(o.V()))
+			}
+		}
+	}
+}
+
+func TestMissionSubscribers(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewPreciseMission(NewEmptySC("subs", 0), NewOrbitFromOE(7000, 0.01, 30, 0, 0, 0, Earth), start, start.Add(time.Hour), Perturbations{}, time.Minute, false, ExportConfig{})
+	blocking := make(chan State)
+	dropOldest := make(chan State, 3)
+	sampleIdle := make(chan State)
+	sampleBuffered := make(chan State, 2)
+	m.RegisterStateChan(blocking)
+	m.Subscribe(dropOldest, DropOldestPolicy)
+	m.Subscribe(sampleIdle, SamplePolicy)
+	m.Subscribe(sampleBuffered, SamplePolicy)
+	var all []State
+	done := make(chan bool)
+	go func() {
+		for state := range blocking {
+			all = append(all, state)
+		}
+		done <- true
+	}()
+	m.Propagate()
+	<-done
+	drain := func(c chan State) (states []State) {
+		for state := range c {
+			states = append(states, state)
+		}
+		return
+	}
+	if len(all) < 60 || !all[len(all)-1].DT.Equal(m.CurrentDT) {
+		t.Fatalf("blocking subscriber received %d states", len(all))
+	}
+	// Only the latest states are kept when dropping the oldest ones.
+	if states := drain(dropOldest); len(states) != 3 || !states[0].DT.Equal(all[len(all)-3].DT) || !states[2].DT.Equal(m.CurrentDT) {
+		t.Fatalf("drop oldest subscriber received %d states", len(states))
+	}
+	// The idle samplers only received what they had room for.
+	if states := drain(sampleIdle); len(states) != 0 {
+		t.Fatalf("idle sampler received %d states", len(states))
+	}
+	if states := drain(sampleBuffered); len(states) != 2 || !states[0].DT.Equal(all[0].DT) || !states[1].DT.Equal(all[1].DT) {
+		t.Fatalf("buffered sampler received %d states", len(states))
+	}
+	assertPanic(t, func() {
+		m.Subscribe(make(chan State), DropOldestPolicy)
+	})
+}
+
+func TestMissionPropagateUntilExact(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	o := NewOrbitFromOE(7000, 0.01, 30, 40, 50, 60, Earth)
+	initial := *o
+	m := NewPreciseMission(NewEmptySC("exact", 0), o, start, start.Add(time.Hour), Perturbations{}, 10*time.Second, false, ExportConfig{})
+	stateChan := make(chan State, 1000)
+	m.RegisterStateChan(stateChan)
+	for _, dt := range []time.Duration{95 * time.Second, 95 * time.Second, 10*time.Minute + 1234*time.Millisecond} {
+		m.PropagateUntil(start.Add(dt), false)
+		if !m.CurrentDT.Equal(start.Add(dt)) {
+			t.Fatalf("propagated until %s instead of %s", m.CurrentDT, start.Add(dt))
+		}
+		exp := keplerPropagate(initial, dt)
+		if !floats.EqualApprox(o.R(), exp.R(), 1e-3) || !floats.EqualApprox(o.V(), exp.V(), 1e-6) {
+			t.Fatalf("%s: R=%+v V=%+v expected R=%+v V=%+v", dt, o.R(), o.V(), exp.R(), exp.V())
+		}
+		if len(stateChan) == 0 {
+			continue
+		}
+		var last State
+		for len(stateChan) > 0 {
+			last = <-stateChan
+		}
+		if !last.DT.Equal(start.Add(dt)) {
+			t.Fatalf("last state at %s instead of %s", last.DT, start.Add(dt))
+		}
+	}
+	// The step is restored after the partial step.
+	if m.step != 10*time.Second {
+		t.Fatalf("step is now %s", m.step)
+	}
+}
+
+func TestMissionPauseResume(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	o := NewOrbitFromOE(7000, 0.01, 30, 40, 50, 60, Earth)
+	m := NewPreciseMission(NewEmptySC("pause", 0), o, start, start.Add(24*time.Hour), Perturbations{}, time.Second, false, ExportConfig{})
+	if m.Pause() || m.Resume() {
+		t.Fatal("cannot pause or resume before the propagation")
+	}
+	done := make(chan bool)
+	go func() {
+		m.Propagate()
+		done <- true
+	}()
+	for !m.Pause() {
+		time.Sleep(time.Millisecond)
+	}
+	if m.Pause() {
+		t.Fatal("already paused")
+	}
+	// The propagation does not advance while paused, and the orbit may be modified.
+	pausedDT := m.CurrentDT
+	time.Sleep(10 * time.Millisecond)
+	if !m.CurrentDT.Equal(pausedDT) {
+		t.Fatal("propagation advanced while paused")
+	}
+	V := m.Orbit.V()
+	m.Orbit = NewOrbitFromRV(m.Orbit.R(), []float64{V[0] * 1.01, V[1] * 1.01, V[2] * 1.01}, Earth)
+	ξ := m.Orbit.Energyξ()
+	if !m.Resume() || m.Resume() {
+		t.Fatal("could not resume")
+	}
+	// Stopping while paused ends the propagation.
+	for !m.Pause() {
+		time.Sleep(time.Millisecond)
+	}
+	m.StopPropagation()
+	<-done
+	if m.CurrentDT.After(start.Add(12*time.Hour)) || !floats.EqualWithinAbs(m.Orbit.Energyξ(), ξ, 1e-6) {
+		t.Fatalf("propagation not stopped (%s) or orbit not modified", m.CurrentDT)
+	}
+	if m.Pause() || m.Resume() {
+		t.Fatal("cannot pause or resume after the propagation")
+	}
+}
@@ -0,0 +1,238 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// MultiMission propagates several spacecraft over the same epochs, e.g. for formation flying or relay studies.
+type MultiMission struct {
+	Missions        []*Mission
+	History         [][]State // States of each spacecraft, filled during the propagation
+	StartDT, StopDT time.Time
+	step            time.Duration
+}
+
+// NewMultiMission returns a new multi-spacecraft mission where all spacecraft share the same epochs.
+func NewMultiMission(start, end time.Time, step time.Duration) *MultiMission {
+	return &MultiMission{StartDT: start, StopDT: end, step: step}
+}
+
+// Add adds a spacecraft to the mission and returns its index. As with Mission, the orbit is updated during the
+// propagation.
+func (m *MultiMission) Add(sc *Spacecraft, o *Orbit, perts Perturbations, conf ExportConfig) int {
+	m.Missions = append(m.Missions, NewPreciseMission(sc, o, m.StartDT, m.StopDT, perts, m.step, false, conf))
+	m.History = append(m.History, nil)
+	return len(m.Missions) - 1
+}
+
+// Propagate propagates all spacecraft concurrently and records their states.
+func (m *MultiMission) Propagate() {
+	propagateAll(m.Missions, m.History)
+}
+
+// propagateAll propagates all the missions concurrently and stores their states in the history of the same index.
+func propagateAll(missions []*Mission, history [][]State) {
+	runAll(missions, history, func(_ int, m *Mission) {
+		m.Propagate()
+	})
+}
+
+// runAll runs the provided function (which must propagate the mission until its end) on each mission concurrently
+// and stores their states in the history of the same index.
+func runAll(missions []*Mission, history [][]State, run func(k int, m *Mission)) {
+	var propWG sync.WaitGroup
+	for k, mission := range missions {
+		stateChan := make(chan State, 10)
+		mission.RegisterStateChan(stateChan)
+		propWG.Add(2)
+		go func(k int) {
+			defer propWG.Done()
+			for state := range stateChan {
+				history[k] = append(history[k], state)
+			}
+		}(k)
+		go func(k int, mission *Mission) {
+			defer propWG.Done()
+			run(k, mission)
+		}(k, mission)
+	}
+	propWG.Wait()
+}
+
+// MultiState stores the states of all spacecraft at a given epoch.
+type MultiState struct {
+	DT     time.Time
+	States []State // In the order of the missions
+}
+
+// Timeline returns the merged states of all spacecraft at each shared epoch.
+func (m *MultiMission) Timeline() []MultiState {
+	steps := m.steps()
+	timeline := make([]MultiState, steps)
+	for step := 0; step < steps; step++ {
+		timeline[step] = MultiState{DT: m.History[0][step].DT, States: make([]State, len(m.Missions))}
+		for k := range m.Missions {
+			if !m.History[k][step].DT.Equal(timeline[step].DT) {
+				panic(fmt.Errorf("%s is not synchronized at %s", m.Missions[k].Vehicle.Name, timeline[step].DT))
+			}
+			timeline[step].States[k] = m.History[k][step]
+		}
+	}
+	return timeline
+}
+
+// steps returns the number of epochs shared by all spacecraft.
+func (m *MultiMission) steps() int {
+	if len(m.Missions) == 0 {
+		panic("multi mission has no spacecraft")
+	}
+	steps := -1
+	for _, history := range m.History {
+		if steps < 0 || len(history) < steps {
+			steps = len(history)
+		}
+	}
+	if steps == 0 {
+		panic("multi mission must be propagated first")
+	}
+	return steps
+}
+
+// RangeSample stores the relative range between two spacecraft.
+type RangeSample struct {
+	DT        time.Time
+	Range     float64 // km
+	RangeRate float64 // km/s, positive when the spacecraft move apart
+}
+
+// RelativeRange returns the range and range rate between the spacecraft of indexes i and j at each shared epoch.
+// Both spacecraft must orbit the same body.
+func (m *MultiMission) RelativeRange(i, j int) []RangeSample {
+	steps := m.steps()
+	samples := make([]RangeSample, steps)
+	for step := 0; step < steps; step++ {
+		a, b := m.History[i][step], m.History[j][step]
+		ρ, ρDot := interSpacecraftRV(a.Orbit, b.Orbit)
+		r := Norm(ρ)
+		samples[step] = RangeSample{DT: a.DT, Range: r, RangeRate: Dot(ρ, ρDot) / r}
+	}
+	return samples
+}
+
+// interSpacecraftRV returns the inertial position and velocity of b relative to a.
+func interSpacecraftRV(a, b Orbit) (ρ, ρDot []float64) {
+	if !a.Origin.Equals(b.Origin) {
+		panic(fmt.Errorf("spacecraft orbit different bodies (%s and %s)", a.Origin.Name, b.Origin.Name))
+	}
+	rA, vA := a.RV()
+	rB, vB := b.RV()
+	ρ = make([]float64, 3)
+	ρDot = make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		ρ[i] = rB[i] - rA[i]
+		ρDot[i] = vB[i] - vA[i]
+	}
+	return
+}
+
+// LineOfSight returns whether the segment between the positions rA and rB does not intersect the sphere of the
+// provided radius centered at the origin.
+func LineOfSight(rA, rB []float64, radius float64) bool {
+	d := []float64{rB[0] - rA[0], rB[1] - rA[1], rB[2] - rA[2]}
+	d2 := Dot(d, d)
+	if d2 == 0 {
+		return Norm(rA) > radius
+	}
+	// Closest point of the segment to the center of the body.
+	τ := math.Max(0, math.Min(1, -Dot(rA, d)/d2))
+	closest := []float64{rA[0] + τ*d[0], rA[1] + τ*d[1], rA[2] + τ*d[2]}
+	return Norm(closest) > radius
+}
+
+// VisibilityEvent stores a period during which two spacecraft are in view of each other.
+type VisibilityEvent struct {
+	Start, End time.Time
+	A, B       string  // Names of the spacecraft
+	MinRange   float64 // km
+	MaxRange   float64 // km
+}
+
+// Duration returns the duration of this visibility.
+func (e VisibilityEvent) Duration() time.Duration {
+	return e.End.Sub(e.Start)
+}
+
+func (e VisibilityEvent) String() string {
+	return fmt.Sprintf("%s and %s in view from %s to %s (%s, range %.3f-%.3f km)", e.A, e.B, e.Start, e.End, e.Duration(), e.MinRange, e.MaxRange)
+}
+
+// Visibility returns the periods during which the spacecraft of indexes i and j are in view of each other, i.e.
+// when the body they orbit does not block the line of sight and their range is below maxRange (km). Use a
+// maxRange of zero for an unlimited range.
+func (m *MultiMission) Visibility(i, j int, maxRange float64) []VisibilityEvent {
+	return ISL{MaxRange: maxRange}.Contacts(m.History[i], m.History[j], m.Missions[i].Vehicle.Name, m.Missions[j].Vehicle.Name)
+}
+
+// Events returns the visibility events of all pairs of spacecraft, sorted by start time.
+func (m *MultiMission) Events(maxRange float64) []VisibilityEvent {
+	return m.ISLContacts(ISL{MaxRange: maxRange})
+}
+
+// byStart sorts visibility events by start time.
+type byStart []VisibilityEvent
+
+func (s byStart) Len() int           { return len(s) }
+func (s byStart) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byStart) Less(i, j int) bool { return s[i].Start.Before(s[j].Start) }
+
+// WriteTimeline writes the merged timeline as a CSV table with the position of each spacecraft (km) and the
+// range between each pair of spacecraft (km).
+func (m *MultiMission) WriteTimeline(w io.Writer) error {
+	hdr := "time"
+	for _, mission := range m.Missions {
+		name := mission.Vehicle.Name
+		hdr += fmt.Sprintf(",%s_x,%s_y,%s_z", name, name, name)
+	}
+	for i := range m.Missions {
+		for j := i + 1; j < len(m.Missions); j++ {
+			hdr += fmt.Sprintf(",range_%s_%s", m.Missions[i].Vehicle.Name, m.Missions[j].Vehicle.Name)
+		}
+	}
+	if _, err := fmt.Fprintln(w, hdr); err != nil {
+		return err
+	}
+	for _, ms := range m.Timeline() {
+		line := ms.DT.UTC().Format(time.RFC3339)
+		for _, state := range ms.States {
+			R := state.Orbit.R()
+			line += fmt.Sprintf(",%.6f,%.6f,%.6f", R[0], R[1], R[2])
+		}
+		for i := range ms.States {
+			for j := i + 1; j < len(ms.States); j++ {
+				ρ, _ := interSpacecraftRV(ms.States[i].Orbit, ms.States[j].Orbit)
+				line += fmt.Sprintf(",%.6f", Norm(ρ))
+			}
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteVisibilityTable writes the visibility events as a CSV table.
+func WriteVisibilityTable(w io.Writer, events []VisibilityEvent) error {
+	if _, err := fmt.Fprint(w, "start,end,durationInMinutes,a,b,minRange,maxRange\n"); err != nil {
+		return err
+	}
+	for _, e := range events {
+		if _, err := fmt.Fprintf(w, "%s,%s,%.3f,%s,%s,%.6f,%.6f\n", e.Start.UTC().Format(time.RFC3339), e.End.UTC().Format(time.RFC3339), e.Duration().Minutes(), e.A, e.B, e.MinRange, e.MaxRange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestLineOfSight(t *testing.T) {
+	r := Earth.Radius
+	if !LineOfSight([]float64{r + 500, 0, 0}, []float64{r + 500, 1000, 0}, r) {
+		t.Fatal("spacecraft side by side should be in view")
+	}
+	if LineOfSight([]float64{r + 500, 0, 0}, []float64{-r - 500, 0, 0}, r) {
+		t.Fatal("Earth should block the line of sight")
+	}
+	// Both spacecraft on the same side of the Earth, with the closest point of the line outside the segment.
+	if !LineOfSight([]float64{2 * r, 0, 0}, []float64{3 * r, 0, 0}, r) {
+		t.Fatal("aligned spacecraft should be in view")
+	}
+}
+
+func TestMultiMission(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := 7000.0
+	m := NewMultiMission(start, start.Add(3*time.Hour), time.Minute)
+	lead := m.Add(NewEmptySC("lead", 0), NewOrbitFromOE(a, 0, 28.5, 10, 0, 30, Earth), Perturbations{}, ExportConfig{})
+	trail := m.Add(NewEmptySC("trail", 0), NewOrbitFromOE(a, 0, 28.5, 10, 0, 0, Earth), Perturbations{}, ExportConfig{})
+	opposite := m.Add(NewEmptySC("opposite", 0), NewOrbitFromOE(a, 0, 28.5, 10, 0, 180, Earth), Perturbations{}, ExportConfig{})
+	m.Propagate()
+	timeline := m.Timeline()
+	if len(timeline) < 180 {
+		t.Fatalf("timeline has %d epochs", len(timeline))
+	}
+	for _, ms := range timeline {
+		if len(ms.States) != 3 {
+			t.Fatalf("%d states at %s", len(ms.States), ms.DT)
+		}
+	}
+	// Spacecraft on the same circular orbit remain at the same distance.
+	expRange := 2 * a * math.Sin(Deg2rad(15))
+	for _, sample := range m.RelativeRange(trail, lead) {
+		if !floats.EqualWithinAbs(sample.Range, expRange, 1e-2) || !floats.EqualWithinAbs(sample.RangeRate, 0, 1e-5) {
+			t.Fatalf("invalid range %+v (expected %f km)", sample, expRange)
+		}
+	}
+	events := m.Visibility(lead, trail, 0)
+	if len(events) != 1 || !events[0].Start.Equal(timeline[0].DT) || !events[0].End.Equal(timeline[len(timeline)-1].DT) {
+		t.Fatalf("lead and trail should always be in view: %v", events)
+	}
+	if events := m.Visibility(lead, trail, expRange/2); len(events) != 0 {
+		t.Fatalf("lead and trail should be out of range: %v", events)
+	}
+	if events := m.Visibility(lead, opposite, 0); len(events) != 0 {
+		t.Fatalf("Earth should always block the line of sight: %v", events)
+	}
+	all := m.Events(0)
+	if len(all) != 1 || all[0].A != "lead" || all[0].B != "trail" {
+		t.Fatalf("invalid events %v", all)
+	}
+	var buf bytes.Buffer
+	if err := m.WriteTimeline(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(timeline)+1 || len(strings.Split(lines[0], ",")) != 1+9+3 {
+		t.Fatalf("invalid timeline export:\n%s", lines[0])
+	}
+	buf.Reset()
+	if err := WriteVisibilityTable(&buf, all); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "\n") != 2 {
+		t.Fatalf("invalid visibility table:\n%s", buf.String())
+	}
+}
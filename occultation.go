@@ -0,0 +1,176 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// OccultationKind defines whether the target is hidden by or in front of the body.
+type OccultationKind uint8
+
+const (
+	// Occulted means the body blocks the line of sight from the observer to the target (contact loss).
+	Occulted OccultationKind = iota + 1
+	// Transiting means the target is seen in front of the disk of the body, e.g. the Sun for comms noise.
+	Transiting
+)
+
+func (k OccultationKind) String() string {
+	switch k {
+	case Occulted:
+		return "occultation"
+	case Transiting:
+		return "transit"
+	default:
+		panic("unknown occultation kind")
+	}
+}
+
+// Occultation predicts the occultations and transits of targets by a body as seen from an observer.
+type Occultation struct {
+	Body            CelestialObject
+	GrazingAltitude float64       // Altitude above the limb of the body below which the line of sight is blocked (km)
+	Ephemeris       EphemerisFunc // Used when the body is not the origin of the target, defaults to HelioEphemeris
+}
+
+// NewOccultation returns a new occultation predictor by the provided body, using the configured ephemerides.
+func NewOccultation(body CelestialObject) Occultation {
+	return Occultation{Body: body, Ephemeris: HelioEphemeris}
+}
+
+// bodyPosition returns the position of the body with respect to the origin, in the frame of the origin.
+func (o Occultation) bodyPosition(origin CelestialObject, dt time.Time) []float64 {
+	if o.Body.Equals(origin) {
+		return []float64{0, 0, 0}
+	}
+	ephem := o.Ephemeris
+	if ephem == nil {
+		ephem = HelioEphemeris
+	}
+	rBody, _ := ephem(o.Body, dt)
+	rOrigin, _ := ephem(origin, dt)
+	return MxV33(origin.Ecliptic2Equatorial(dt), []float64{rBody[0] - rOrigin[0], rBody[1] - rOrigin[1], rBody[2] - rOrigin[2]})
+}
+
+// Kind returns the kind of occultation of the target as seen from the observer, or zero if the target is in view
+// and not transiting. All positions must be in the same frame and in km.
+func (o Occultation) Kind(rObserver, rTarget, rBody []float64) OccultationKind {
+	radius := o.Body.Radius + o.GrazingAltitude
+	obs := []float64{rObserver[0] - rBody[0], rObserver[1] - rBody[1], rObserver[2] - rBody[2]}
+	tgt := []float64{rTarget[0] - rBody[0], rTarget[1] - rBody[1], rTarget[2] - rBody[2]}
+	d := []float64{tgt[0] - obs[0], tgt[1] - obs[1], tgt[2] - obs[2]}
+	ρ := Norm(d)
+	if ρ == 0 {
+		return 0
+	}
+	// Only the closest point strictly between both ends is checked against the grazing altitude, so that an
+	// observer on the surface (e.g. a station) is not occulted by its own body above its horizon.
+	proj := -Dot(obs, d) / ρ
+	if proj > 0 && proj < ρ && math.Sqrt(math.Max(Dot(obs, obs)-proj*proj, 0)) <= radius {
+		return Occulted
+	}
+	// The target transits if the body is behind it and within the radius of the ray from the observer.
+	if proj > ρ && math.Sqrt(math.Max(Dot(obs, obs)-proj*proj, 0)) < radius {
+		return Transiting
+	}
+	return 0
+}
+
+// OccultationEvent stores an occultation or a transit of the target as seen from the observer.
+type OccultationEvent struct {
+	Start, End       time.Time
+	Kind             OccultationKind
+	Observer, Target string
+	Body             CelestialObject
+}
+
+// Duration returns the duration of this event.
+func (e OccultationEvent) Duration() time.Duration {
+	return e.End.Sub(e.Start)
+}
+
+func (e OccultationEvent) String() string {
+	return fmt.Sprintf("%s of %s by %s as seen from %s from %s to %s (%s)", e.Kind, e.Target, e.Body.Name, e.Observer, e.Start, e.End, e.Duration())
+}
+
+// FromStation returns the occultations and transits of the target states as seen from the station. The
+// target must orbit the body of the station.
+func (o Occultation) FromStation(s Station, target []State, targetName string) []OccultationEvent {
+	rObserver := make([][]float64, len(target))
+	for k, state := range target {
+		if !state.Orbit.Origin.Equals(s.Planet) {
+			panic(fmt.Errorf("station %s is on %s but %s orbits %s", s.Name, s.Planet.Name, targetName, state.Orbit.Origin.Name))
+		}
+		rObserver[k], _ = s.InertialRV(state.DT)
+	}
+	return o.events(rObserver, target, s.Name, targetName)
+}
+
+// FromSpacecraft returns the occultations and transits of the target states as seen from the observer states.
+// Both must orbit the same body and be at the same epochs.
+func (o Occultation) FromSpacecraft(observer, target []State, observerName, targetName string) []OccultationEvent {
+	steps := len(target)
+	if len(observer) < steps {
+		steps = len(observer)
+	}
+	rObserver := make([][]float64, steps)
+	for k := 0; k < steps; k++ {
+		if !observer[k].DT.Equal(target[k].DT) {
+			panic(fmt.Errorf("%s and %s are not synchronized at %s", observerName, targetName, target[k].DT))
+		}
+		if !observer[k].Orbit.Origin.Equals(target[k].Orbit.Origin) {
+			panic(fmt.Errorf("%s and %s orbit different bodies", observerName, targetName))
+		}
+		rObserver[k] = observer[k].Orbit.R()
+	}
+	return o.events(rObserver, target[:steps], observerName, targetName)
+}
+
+func (o Occultation) events(rObserver [][]float64, target []State, observerName, targetName string) []OccultationEvent {
+	var events []OccultationEvent
+	var cur *OccultationEvent
+	for k, state := range target {
+		kind := o.Kind(rObserver[k], state.Orbit.R(), o.bodyPosition(state.Orbit.Origin, state.DT))
+		if cur != nil && cur.Kind != kind {
+			events = append(events, *cur)
+			cur = nil
+		}
+		if kind == 0 {
+			continue
+		}
+		if cur == nil {
+			cur = &OccultationEvent{Start: state.DT, Kind: kind, Observer: observerName, Target: targetName, Body: o.Body}
+		}
+		cur.End = state.DT
+	}
+	if cur != nil {
+		events = append(events, *cur)
+	}
+	return events
+}
+
+// ContactLoss returns only the occultations of the events, i.e. the intervals without any line of sight.
+func ContactLoss(events []OccultationEvent) []OccultationEvent {
+	var losses []OccultationEvent
+	for _, e := range events {
+		if e.Kind == Occulted {
+			losses = append(losses, e)
+		}
+	}
+	return losses
+}
+
+// WriteOccultationTable writes the occultation events as a CSV table.
+func WriteOccultationTable(w io.Writer, events []OccultationEvent) error {
+	if _, err := fmt.Fprint(w, "start,end,durationInMinutes,kind,body,observer,target\n"); err != nil {
+		return err
+	}
+	for _, e := range events {
+		if _, err := fmt.Fprintf(w, "%s,%s,%.3f,%s,%s,%s,%s\n", e.Start.UTC().Format(time.RFC3339), e.End.UTC().Format(time.RFC3339), e.Duration().Minutes(), e.Kind, e.Body.Name, e.Observer, e.Target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
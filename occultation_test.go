@@ -0,0 +1,101 @@
+package smd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOccultationKind(t *testing.T) {
+	occ := Occultation{Body: Earth}
+	r := Earth.Radius
+	origin := []float64{0, 0, 0}
+	if k := occ.Kind([]float64{r + 500, 0, 0}, []float64{-r - 500, 0, 0}, origin); k != Occulted {
+		t.Fatalf("expected an occultation, got %d", k)
+	}
+	if k := occ.Kind([]float64{r + 500, 0, 0}, []float64{r + 500, 1000, 0}, origin); k != 0 {
+		t.Fatalf("expected no event, got %s", k)
+	}
+	// The target is between the observer and the body.
+	if k := occ.Kind([]float64{3 * r, 0, 0}, []float64{2 * r, 100, 0}, origin); k != Transiting {
+		t.Fatalf("expected a transit, got %d", k)
+	}
+	// An observer on the surface is not occulted by its own body above its horizon.
+	if k := occ.Kind([]float64{r, 0, 0}, []float64{r + 500, 500, 0}, origin); k != 0 {
+		t.Fatalf("expected no event from the surface, got %s", k)
+	}
+	// The line of sight grazes the Earth at about 383 km.
+	a := 7000.0
+	o1 := NewOrbitFromOE(a, 0, 0, 0, 0, 0, Earth)
+	o2 := NewOrbitFromOE(a, 0, 0, 0, 0, 30, Earth)
+	if k := occ.Kind(o1.R(), o2.R(), origin); k != 0 {
+		t.Fatalf("expected no event, got %s", k)
+	}
+	occ.GrazingAltitude = 400
+	if k := occ.Kind(o1.R(), o2.R(), origin); k != Occulted {
+		t.Fatalf("expected an atmospheric occultation, got %d", k)
+	}
+}
+
+func TestOccultationEvents(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMultiMission(start, start.Add(6*time.Hour), time.Minute)
+	m.Add(NewEmptySC("leo", 0), NewOrbitFromOE(Earth.Radius+500, 0, 51.6, 0, 0, 0, Earth), Perturbations{}, ExportConfig{})
+	m.Add(NewEmptySC("relay", 0), NewOrbitFromOE(Earth.Radius+20000, 0, 0, 0, 0, 0, Earth), Perturbations{}, ExportConfig{})
+	m.Propagate()
+	occ := Occultation{Body: Earth}
+	events := occ.FromSpacecraft(m.History[1], m.History[0], "relay", "leo")
+	losses := ContactLoss(events)
+	if len(losses) < 2 {
+		t.Fatalf("expected several contact losses, got %v", events)
+	}
+	// The occultations must match the lack of inter-satellite visibility.
+	for k := range m.History[0] {
+		leo, relay := m.History[0][k].Orbit, m.History[1][k].Orbit
+		if (occ.Kind(relay.R(), leo.R(), []float64{0, 0, 0}) == Occulted) == (ISL{}).InView(relay, leo) {
+			t.Fatalf("occultation and link visibility disagree at %s", m.History[0][k].DT)
+		}
+	}
+	// A station sees the LEO spacecraft only briefly.
+	station := NewStation("station", 0, 0, 0, 0, σρ, σρDot)
+	fromStation := occ.FromStation(station, m.History[0], "leo")
+	var hidden time.Duration
+	for _, e := range ContactLoss(fromStation) {
+		hidden += e.Duration()
+	}
+	if hidden < 5*time.Hour {
+		t.Fatalf("LEO spacecraft hidden for only %s", hidden)
+	}
+	var buf bytes.Buffer
+	if err := WriteOccultationTable(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != len(events)+1 {
+		t.Fatalf("wrote %d lines", lines)
+	}
+	assertPanic(t, func() {
+		occ.FromStation(NewBodyStation("lander", Mars, 0, 0, 0, 0, σρ, σρDot), m.History[0], "leo")
+	})
+}
+
+func TestThirdBodyOccultation(t *testing.T) {
+	dt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	// The Moon is placed along +X of the Earth for this test.
+	ephem := func(body CelestialObject, dt time.Time) (R, V []float64) {
+		if body.Equals(Moon) {
+			return []float64{384400, 0, 0}, []float64{0, 1, 0}
+		}
+		return []float64{0, 0, 0}, []float64{0, 0, 0}
+	}
+	occ := Occultation{Body: Moon, Ephemeris: ephem}
+	behind := State{DT: dt, Orbit: *NewOrbitFromRV([]float64{400000, 0, 0}, []float64{0, 1, 0}, Earth)}
+	beside := State{DT: dt, Orbit: *NewOrbitFromRV([]float64{400000, 10000, 0}, []float64{0, 1, 0}, Earth)}
+	observer := State{DT: dt, Orbit: *NewOrbitFromRV([]float64{7000, 0, 0}, []float64{0, 7.5, 0}, Earth)}
+	if events := occ.FromSpacecraft([]State{observer}, []State{behind}, "obs", "behind"); len(events) != 1 || events[0].Kind != Occulted {
+		t.Fatalf("spacecraft behind the Moon should be occulted: %v", events)
+	}
+	if events := occ.FromSpacecraft([]State{observer}, []State{beside}, "obs", "beside"); len(events) != 0 {
+		t.Fatalf("spacecraft beside the Moon should be in view: %v", events)
+	}
+}
@@ -0,0 +1,107 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	odDefaultMaxIterations = 10
+	odDefaultRMSTolerance  = 1e-3 // relative change of the residual RMS between iterations
+)
+
+// ODPassResult is the outcome of one orbit determination pass over the tracking data.
+type ODPassResult struct {
+	Deviation *mat64.Vector   // Estimated (e.g. smoothed) deviation of the initial state from the reference
+	Residuals []*mat64.Vector // Post fit residuals, nil where there was no measurement
+}
+
+// ODPass runs one orbit determination pass (batch or sequential filter) with a reference trajectory propagated from
+// the provided initial orbit, typically by creating a new Mission from it.
+type ODPass func(initial Orbit) (ODPassResult, error)
+
+// ODIteration is the outer loop of an orbit determination: the reference initial state is updated with the estimated
+// deviation and the tracking data is filtered again, until the RMS of the residuals converges. This is required when
+// the initial reference is too far from the truth for the linearization of the filter to hold.
+type ODIteration struct {
+	MaxIterations int     // Defaults to 10
+	RMSTolerance  float64 // Relative change of the residual RMS between iterations, defaults to 1e-3
+	Initial       Orbit   // Converged (or last) reference initial orbit
+	RMS           []float64
+	Iterations    int
+	Converged     bool
+}
+
+func (it ODIteration) String() string {
+	rms := math.NaN()
+	if len(it.RMS) > 0 {
+		rms = it.RMS[len(it.RMS)-1]
+	}
+	return fmt.Sprintf("%d iterations (converged: %t), RMS=%g: %s", it.Iterations, it.Converged, rms, it.Initial)
+}
+
+// Iterate runs the orbit determination pass from the initial orbit and updates it until the residual RMS converges.
+// Returns an error if a pass fails or if it did not converge within the maximum number of iterations, in which case
+// the last initial orbit is still available.
+func (it *ODIteration) Iterate(initial Orbit, pass ODPass) error {
+	if it.MaxIterations <= 0 {
+		it.MaxIterations = odDefaultMaxIterations
+	}
+	if it.RMSTolerance <= 0 {
+		it.RMSTolerance = odDefaultRMSTolerance
+	}
+	R, V := initial.RV()
+	it.Initial = *NewOrbitFromRV(R, V, initial.Origin)
+	it.RMS, it.Converged = nil, false
+	for it.Iterations = 1; it.Iterations <= it.MaxIterations; it.Iterations++ {
+		result, err := pass(it.Initial)
+		if err != nil {
+			return fmt.Errorf("iteration %d: %s", it.Iterations, err)
+		}
+		rms, err := ResidualRMS(result.Residuals)
+		if err != nil {
+			return fmt.Errorf("iteration %d: %s", it.Iterations, err)
+		}
+		if n := len(it.RMS); n > 0 && math.Abs(rms-it.RMS[n-1]) < it.RMSTolerance*it.RMS[n-1] {
+			// The reference used for this pass is the converged one.
+			it.RMS = append(it.RMS, rms)
+			it.Converged = true
+			return nil
+		}
+		it.RMS = append(it.RMS, rms)
+		if result.Deviation == nil || result.Deviation.Len() < 6 {
+			return fmt.Errorf("iteration %d: invalid deviation of the initial state", it.Iterations)
+		}
+		R, V := it.Initial.RV()
+		R = []float64{R[0], R[1], R[2]}
+		V = []float64{V[0], V[1], V[2]}
+		for i := 0; i < 3; i++ {
+			R[i] += result.Deviation.At(i, 0)
+			V[i] += result.Deviation.At(i+3, 0)
+		}
+		it.Initial = *NewOrbitFromRV(R, V, it.Initial.Origin)
+	}
+	it.Iterations = it.MaxIterations
+	return fmt.Errorf("orbit determination did not converge in %d iterations", it.MaxIterations)
+}
+
+// ResidualRMS returns the root mean square of all the components of the residuals, skipping the nil ones.
+func ResidualRMS(residuals []*mat64.Vector) (float64, error) {
+	sum, n := 0., 0
+	for _, residual := range residuals {
+		if residual == nil {
+			continue
+		}
+		for i := 0; i < residual.Len(); i++ {
+			sum += residual.At(i, 0) * residual.At(i, 0)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, errors.New("no residuals")
+	}
+	return math.Sqrt(sum / float64(n)), nil
+}
@@ -0,0 +1,55 @@
+package smd
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestODIteration(t *testing.T) {
+	truth := NewOrbitFromOE(7000, 0.01, 28.5, 10, 20, 30, Earth)
+	Rt, Vt := truth.RV()
+	initial := NewOrbitFromRV([]float64{Rt[0] + 2, Rt[1] - 1, Rt[2] + 0.5}, []float64{Vt[0] + 1e-3, Vt[1], Vt[2] - 2e-3}, Earth)
+	passes := 0
+	// A filter which only recovers 90% of the initial error per pass, above a 10 m noise floor.
+	pass := func(o Orbit) (ODPassResult, error) {
+		passes++
+		R, V := o.RV()
+		δ := mat64.NewVector(6, nil)
+		for i := 0; i < 3; i++ {
+			δ.SetVec(i, 0.9*(Rt[i]-R[i]))
+			δ.SetVec(i+3, 0.9*(Vt[i]-V[i]))
+		}
+		errR := Norm([]float64{Rt[0] - R[0], Rt[1] - R[1], Rt[2] - R[2]})
+		residuals := []*mat64.Vector{mat64.NewVector(2, []float64{errR, 0.01}), nil, mat64.NewVector(2, []float64{-errR, -0.01})}
+		return ODPassResult{Deviation: δ, Residuals: residuals}, nil
+	}
+	var it ODIteration
+	if err := it.Iterate(*initial, pass); err != nil {
+		t.Fatal(err)
+	}
+	if !it.Converged || it.Iterations != passes || len(it.RMS) != passes || passes < 3 {
+		t.Fatalf("%s after %d passes", it, passes)
+	}
+	if it.RMS[0] <= it.RMS[len(it.RMS)-1] || math.Abs(it.RMS[len(it.RMS)-1]-0.01/math.Sqrt2) > 1e-3 {
+		t.Fatalf("RMS did not converge to the noise floor: %v", it.RMS)
+	}
+	R := it.Initial.R()
+	if Norm([]float64{Rt[0] - R[0], Rt[1] - R[1], Rt[2] - R[2]}) > 0.01 {
+		t.Fatalf("initial orbit not updated: %s", it.Initial)
+	}
+	// Not enough iterations.
+	it = ODIteration{MaxIterations: 2}
+	if err := it.Iterate(*initial, pass); err == nil || it.Converged {
+		t.Fatal("converged in two iterations")
+	}
+	// Failed passes.
+	if err := it.Iterate(*initial, func(Orbit) (ODPassResult, error) { return ODPassResult{}, errors.New("diverged") }); err == nil {
+		t.Fatal("failed pass not reported")
+	}
+	if err := it.Iterate(*initial, func(Orbit) (ODPassResult, error) { return ODPassResult{}, nil }); err == nil {
+		t.Fatal("pass without residuals not reported")
+	}
+}
@@ -0,0 +1,273 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const optimFDStep = 1e-7 // Relative finite differencing step of the optimization adapters.
+
+// OptimizationProblem defines a nonlinear program: minimize the objective such that the equality constraints
+// are zero and the inequality constraints are negative or zero, within the bounds of the variables.
+type OptimizationProblem interface {
+	Dimension() int
+	Bounds() (lower, upper []float64) // Use ±Inf for unbounded variables
+	Objective(x []float64) float64
+	Constraints(x []float64) (eq, ineq []float64)
+}
+
+// DifferentiableProblem is an OptimizationProblem which provides its own derivatives, e.g. from the STM of the
+// propagations. Problems which do not implement it are finite differenced by the adapters.
+type DifferentiableProblem interface {
+	OptimizationProblem
+	Gradient(x, grad []float64)
+	// ConstraintsJacobian returns the Jacobian of the equality constraints stacked on top of the inequality ones.
+	ConstraintsJacobian(x []float64) *mat64.Dense
+}
+
+// NLPAdapter exposes an OptimizationProblem with the flat callbacks used by most gradient based NLP solvers
+// (e.g. IPOPT or SNOPT wrappers).
+type NLPAdapter struct {
+	Problem OptimizationProblem
+}
+
+// NewNLPAdapter returns a new adapter of the provided problem.
+func NewNLPAdapter(p OptimizationProblem) *NLPAdapter {
+	return &NLPAdapter{p}
+}
+
+// F returns the objective.
+func (a *NLPAdapter) F(x []float64) float64 {
+	return a.Problem.Objective(x)
+}
+
+// Grad stores the gradient of the objective in grad.
+func (a *NLPAdapter) Grad(grad, x []float64) {
+	if dp, ok := a.Problem.(DifferentiableProblem); ok {
+		dp.Gradient(x, grad)
+		return
+	}
+	fdGradient(a.Problem.Objective, x, grad)
+}
+
+// G returns the equality constraints followed by the inequality constraints.
+func (a *NLPAdapter) G(x []float64) []float64 {
+	eq, ineq := a.Problem.Constraints(x)
+	return append(append([]float64{}, eq...), ineq...)
+}
+
+// NumConstraints returns the number of equality and inequality constraints.
+func (a *NLPAdapter) NumConstraints() (nEq, nIneq int) {
+	x := make([]float64, a.Problem.Dimension())
+	lower, upper := a.Problem.Bounds()
+	for i := range x {
+		x[i] = boundedGuess(lower[i], upper[i])
+	}
+	eq, ineq := a.Problem.Constraints(x)
+	return len(eq), len(ineq)
+}
+
+// JacG returns the Jacobian of the constraints, in the order of G.
+func (a *NLPAdapter) JacG(x []float64) *mat64.Dense {
+	if dp, ok := a.Problem.(DifferentiableProblem); ok {
+		return dp.ConstraintsJacobian(x)
+	}
+	return Jacobian(a.G, x, nil, ForwardDifference)
+}
+
+// NLoptObjective returns the objective with the signature of the NLopt callbacks, where the gradient is only
+// computed if grad is not empty.
+func (a *NLPAdapter) NLoptObjective() func(x, grad []float64) float64 {
+	return func(x, grad []float64) float64 {
+		if len(grad) > 0 {
+			a.Grad(grad, x)
+		}
+		return a.F(x)
+	}
+}
+
+// NLoptConstraints returns each equality and inequality constraint with the signature of the NLopt callbacks.
+func (a *NLPAdapter) NLoptConstraints() (eq, ineq []func(x, grad []float64) float64) {
+	nEq, nIneq := a.NumConstraints()
+	constraint := func(k int) func(x, grad []float64) float64 {
+		return func(x, grad []float64) float64 {
+			if len(grad) > 0 {
+				J := a.JacG(x)
+				for j := range grad {
+					grad[j] = J.At(k, j)
+				}
+			}
+			return a.G(x)[k]
+		}
+	}
+	for k := 0; k < nEq; k++ {
+		eq = append(eq, constraint(k))
+	}
+	for k := nEq; k < nEq+nIneq; k++ {
+		ineq = append(ineq, constraint(k))
+	}
+	return
+}
+
+// Penalty returns the objective and its gradient augmented with a quadratic penalty of the weight μ on the
+// constraints and bounds violations, with the signatures of the unconstrained gonum optimize.Problem.
+func (a *NLPAdapter) Penalty(μ float64) (f func(x []float64) float64, grad func(grad, x []float64)) {
+	f = func(x []float64) float64 {
+		val := a.F(x)
+		for _, v := range a.violations(x) {
+			val += μ * v * v
+		}
+		return val
+	}
+	grad = func(grad, x []float64) {
+		fdGradient(f, x, grad)
+	}
+	return
+}
+
+// violations returns the constraints and bounds violations of x.
+func (a *NLPAdapter) violations(x []float64) []float64 {
+	eq, ineq := a.Problem.Constraints(x)
+	v := append([]float64{}, eq...)
+	for _, c := range ineq {
+		v = append(v, math.Max(c, 0))
+	}
+	lower, upper := a.Problem.Bounds()
+	for i, xi := range x {
+		v = append(v, math.Max(lower[i]-xi, 0), math.Max(xi-upper[i], 0))
+	}
+	return v
+}
+
+// MaxViolation returns the maximum constraint or bound violation of x.
+func (a *NLPAdapter) MaxViolation(x []float64) float64 {
+	var max float64
+	for _, v := range a.violations(x) {
+		max = math.Max(max, math.Abs(v))
+	}
+	return max
+}
+
+// fdGradient stores the forward finite difference gradient of f in grad.
+func fdGradient(f func([]float64) float64, x, grad []float64) {
+	if len(grad) != len(x) {
+		panic(fmt.Errorf("gradient of length %d for %d variables", len(grad), len(x)))
+	}
+	f0 := f(x)
+	xp := append([]float64{}, x...)
+	for j := range x {
+		h := optimFDStep * math.Max(1, math.Abs(x[j]))
+		xp[j] = x[j] + h
+		grad[j] = (f(xp) - f0) / h
+		xp[j] = x[j]
+	}
+}
+
+// boundedGuess returns a value within the bounds.
+func boundedGuess(lower, upper float64) float64 {
+	switch {
+	case !math.IsInf(lower, -1) && !math.IsInf(upper, 1):
+		return (lower + upper) / 2
+	case !math.IsInf(lower, -1):
+		return lower
+	case !math.IsInf(upper, 1):
+		return upper
+	default:
+		return 0
+	}
+}
+
+// ImpulsiveProblem is the impulsive trajectory optimization problem of a targeter: minimize the burn magnitude
+// such that the constraints of the targeter are met. The variables are those of the targeter, and the
+// derivatives of the constraints are computed from the propagations.
+type ImpulsiveProblem struct {
+	Targeter     *Targeter
+	Lower, Upper []float64 // Bounds of the variables, unbounded if nil
+}
+
+// NewImpulsiveProblem returns the impulsive optimization problem of the targeter.
+func NewImpulsiveProblem(t *Targeter) *ImpulsiveProblem {
+	if len(t.variables) == 0 || len(t.constraints) == 0 {
+		panic("targeter requires at least one variable and one constraint")
+	}
+	return &ImpulsiveProblem{Targeter: t}
+}
+
+// Dimension implements the OptimizationProblem interface.
+func (p *ImpulsiveProblem) Dimension() int {
+	return len(p.Targeter.variables)
+}
+
+// Bounds implements the OptimizationProblem interface.
+func (p *ImpulsiveProblem) Bounds() (lower, upper []float64) {
+	lower, upper = p.Lower, p.Upper
+	if lower == nil {
+		lower = make([]float64, p.Dimension())
+		for i := range lower {
+			lower[i] = math.Inf(-1)
+		}
+	}
+	if upper == nil {
+		upper = make([]float64, p.Dimension())
+		for i := range upper {
+			upper[i] = math.Inf(1)
+		}
+	}
+	return
+}
+
+// X returns the current values of the variables of the targeter, i.e. an initial guess.
+func (p *ImpulsiveProblem) X() []float64 {
+	x := make([]float64, p.Dimension())
+	for i, v := range p.Targeter.variables {
+		x[i] = p.Targeter.get(v)
+	}
+	return x
+}
+
+// setX sets the variables of the targeter.
+func (p *ImpulsiveProblem) setX(x []float64) {
+	if len(x) != p.Dimension() {
+		panic(fmt.Errorf("%d values for %d variables", len(x), p.Dimension()))
+	}
+	for i, v := range p.Targeter.variables {
+		p.Targeter.set(v, x[i])
+	}
+}
+
+// Objective implements the OptimizationProblem interface.
+func (p *ImpulsiveProblem) Objective(x []float64) float64 {
+	p.setX(x)
+	return Norm(p.Targeter.ΔV)
+}
+
+// Constraints implements the OptimizationProblem interface: the constraints of the targeter are equalities.
+func (p *ImpulsiveProblem) Constraints(x []float64) (eq, ineq []float64) {
+	p.setX(x)
+	final := p.Targeter.propagate(false).final
+	eq = make([]float64, len(p.Targeter.constraints))
+	for j, c := range p.Targeter.constraints {
+		eq[j] = c.residual(final)
+	}
+	return eq, nil
+}
+
+// Gradient implements the DifferentiableProblem interface.
+func (p *ImpulsiveProblem) Gradient(x, grad []float64) {
+	p.setX(x)
+	ΔV := Norm(p.Targeter.ΔV)
+	for i, v := range p.Targeter.variables {
+		grad[i] = 0
+		if ΔV > 0 && v >= ΔVx && v <= ΔVz {
+			grad[i] = p.Targeter.ΔV[v-ΔVx] / ΔV
+		}
+	}
+}
+
+// ConstraintsJacobian implements the DifferentiableProblem interface, using the STM of the propagations.
+func (p *ImpulsiveProblem) ConstraintsJacobian(x []float64) *mat64.Dense {
+	p.setX(x)
+	return p.Targeter.stmJacobian(p.Targeter.propagate(true))
+}
@@ -0,0 +1,130 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// toyProblem minimizes (x0-1)²+(x1-2)² such that x0+x1=1 and x0 ≥ 0.
+type toyProblem struct{}
+
+func (p toyProblem) Dimension() int { return 2 }
+func (p toyProblem) Bounds() (lower, upper []float64) {
+	return []float64{math.Inf(-1), -10}, []float64{math.Inf(1), 10}
+}
+func (p toyProblem) Objective(x []float64) float64 {
+	return math.Pow(x[0]-1, 2) + math.Pow(x[1]-2, 2)
+}
+func (p toyProblem) Constraints(x []float64) (eq, ineq []float64) {
+	return []float64{x[0] + x[1] - 1}, []float64{-x[0]}
+}
+
+// opaqueProblem hides the derivatives of a problem.
+type opaqueProblem struct {
+	OptimizationProblem
+}
+
+func TestNLPAdapter(t *testing.T) {
+	a := NewNLPAdapter(toyProblem{})
+	x := []float64{0.5, 0.25}
+	grad := make([]float64, 2)
+	a.Grad(grad, x)
+	if !floats.EqualApprox(grad, []float64{-1, -3.5}, 1e-5) {
+		t.Fatalf("invalid gradient %+v", grad)
+	}
+	if nEq, nIneq := a.NumConstraints(); nEq != 1 || nIneq != 1 {
+		t.Fatalf("invalid number of constraints %d %d", nEq, nIneq)
+	}
+	if !floats.EqualApprox(a.G(x), []float64{-0.25, -0.5}, 1e-12) {
+		t.Fatalf("invalid constraints %+v", a.G(x))
+	}
+	J := a.JacG(x)
+	if !mat64.EqualApprox(J, mat64.NewDense(2, 2, []float64{1, 1, -1, 0}), 1e-6) {
+		t.Fatalf("invalid Jacobian %v", mat64.Formatted(J))
+	}
+	obj := a.NLoptObjective()
+	nlGrad := make([]float64, 2)
+	if obj(x, nlGrad) != a.F(x) || !floats.EqualApprox(nlGrad, grad, 1e-12) {
+		t.Fatal("NLopt objective differs")
+	}
+	eq, ineq := a.NLoptConstraints()
+	if len(eq) != 1 || len(ineq) != 1 {
+		t.Fatal("invalid number of NLopt constraints")
+	}
+	if ineq[0](x, nlGrad) != -0.5 || !floats.EqualApprox(nlGrad, []float64{-1, 0}, 1e-6) {
+		t.Fatalf("invalid NLopt inequality constraint gradient %+v", nlGrad)
+	}
+	// The optimum is (0, 1) where the inequality constraint is active.
+	if v := a.MaxViolation([]float64{0, 1}); v != 0 {
+		t.Fatalf("optimum is not feasible: %f", v)
+	}
+	if v := a.MaxViolation([]float64{-1, 2}); v != 1 {
+		t.Fatalf("violation=%f", v)
+	}
+	if v := a.MaxViolation([]float64{0, 11}); v != 10 {
+		t.Fatalf("bound violation=%f", v)
+	}
+	f, _ := a.Penalty(100)
+	if !floats.EqualWithinAbs(f([]float64{-1, 2}), 4+100, 1e-12) {
+		t.Fatalf("invalid penalty %f", f([]float64{-1, 2}))
+	}
+	// Penalty gradient descent converges near the optimum.
+	f, pGrad := a.Penalty(10)
+	xk := []float64{0.5, 0.5}
+	for k := 0; k < 2000; k++ {
+		pGrad(grad, xk)
+		floats.AddScaled(xk, -0.02, grad)
+	}
+	if !floats.EqualApprox(xk, []float64{0, 1}, 0.1) {
+		t.Fatalf("penalty minimum %+v", xk)
+	}
+}
+
+func TestImpulsiveProblem(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	initial := *NewOrbitFromOE(7000, 0, 28.5, 10, 20, 30, Earth)
+	tgt := NewTargeter(initial, start, 10*time.Minute, Perturbations{})
+	tgt.Step = 10 * time.Second
+	tgt.Vary(ΔVx, ΔVy, ΔVz)
+	tgt.Achieve(SMAConstraint(7100, 1e-6))
+	p := NewImpulsiveProblem(tgt)
+	a := NewNLPAdapter(p)
+	// The optimal burn is tangential with the magnitude of the first burn of a Hohmann transfer.
+	vDir := Unit(initial.V())
+	r := initial.RNorm()
+	Δv := math.Sqrt(Earth.μ*(2/r-1/7100.)) - math.Sqrt(Earth.μ/r)
+	x := []float64{Δv * vDir[0], Δv * vDir[1], Δv * vDir[2]}
+	if eq := a.G(x); math.Abs(eq[0]) > 1e-3 {
+		t.Fatalf("Hohmann burn not feasible: %e", eq[0])
+	}
+	if !floats.EqualWithinAbs(a.F(x), Δv, 1e-12) {
+		t.Fatal("invalid objective")
+	}
+	// Derivatives from the STM match the finite differences.
+	Jstm := a.JacG(x)
+	Jfd := NewNLPAdapter(opaqueProblem{p}).JacG(x)
+	if !mat64.EqualApprox(Jstm, Jfd, 1e-2*mat64.Norm(Jfd, 2)) {
+		t.Fatalf("STM Jacobian differs:\n%v\n%v", mat64.Formatted(Jstm), mat64.Formatted(Jfd))
+	}
+	grad := make([]float64, 3)
+	a.Grad(grad, x)
+	if !floats.EqualApprox(grad, vDir, 1e-12) {
+		t.Fatal("invalid objective gradient")
+	}
+	// KKT: the objective gradient is parallel to the constraint gradient at the optimum.
+	jac := []float64{Jstm.At(0, 0), Jstm.At(0, 1), Jstm.At(0, 2)}
+	if cross := Norm(Cross(grad, Unit(jac))); cross > 1e-4 {
+		t.Fatalf("tangential burn is not stationary: %e", cross)
+	}
+	// The targeter leaves the problem in a feasible state.
+	if _, err := tgt.Solve(); err != nil {
+		t.Fatal(err)
+	}
+	if v := a.MaxViolation(p.X()); v > 1e-6 {
+		t.Fatalf("targeter solution not feasible: %e", v)
+	}
+}
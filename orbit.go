@@ -323,32 +323,55 @@ func (o Orbit) StrictlyEquals(o1 Orbit) (bool, error) {
 	return o.Equals(o1)
 }
 
-// ToXCentric converts this orbit the provided celestial object centric equivalent.
-// Panics if the vehicle is not within the SOI of the object.
+// HelioRV returns the heliocentric position and velocity of this orbit at the provided epoch, in the ecliptic frame.
+func (o Orbit) HelioRV(dt time.Time) (R, V []float64) {
+	return o.helioRV(dt, HelioEphemeris)
+}
+
+// helioRV returns the heliocentric position and velocity of this orbit with the provided ephemeris.
+func (o Orbit) helioRV(dt time.Time, ephem EphemerisFunc) (R, V []float64) {
+	if o.Origin.Equals(Sun) {
+		return append([]float64(nil), o.rVec...), append([]float64(nil), o.vVec...)
+	}
+	// The orbits about other bodies are in their inertial equatorial frame.
+	dcm := o.Origin.Equatorial2Ecliptic(dt)
+	R = MxV33(dcm, o.rVec)
+	V = MxV33(dcm, o.vVec)
+	rOrigin, vOrigin := ephem(o.Origin, dt)
+	for i := 0; i < 3; i++ {
+		R[i] += rOrigin[i]
+		V[i] += vOrigin[i]
+	}
+	return
+}
+
+// ToXCentric converts this orbit to the provided celestial object centric equivalent at the provided epoch, using
+// the configured ephemerides. Heliocentric orbits are in the ecliptic frame, and the other ones in the inertial
+// equatorial frame of their origin (cf. Equatorial2Ecliptic). Any origin can be converted to any other, e.g. Earth
+// to Mars, through the heliocentric state.
 // Panics if already in this frame.
 func (o *Orbit) ToXCentric(b CelestialObject, dt time.Time) {
+	o.toXCentric(b, dt, HelioEphemeris)
+}
+
+// toXCentric converts this orbit to the provided celestial object centric equivalent with the provided ephemeris.
+func (o *Orbit) toXCentric(b CelestialObject, dt time.Time, ephem EphemerisFunc) {
 	if o.Origin.Name == b.Name {
 		panic(fmt.Errorf("already in orbit around %s", b.Name))
 	}
-
-	// Using SPICE for the conversion.
-	state := make([]float64, 6)
-	for i := 0; i < 3; i++ {
-		state[i] = o.rVec[i]
-		state[i+3] = o.vVec[i]
-	}
-	toFrame := "IAU_" + b.Name
-	if b.Equals(Sun) {
-		toFrame = "ECLIPJ2000"
-	}
-	fromFrame := "IAU_" + o.Origin.Name
-	if o.Origin.Equals(Sun) {
-		fromFrame = "ECLIPJ2000"
+	R, V := o.helioRV(dt, ephem)
+	if !b.Equals(Sun) {
+		rBody, vBody := ephem(b, dt)
+		for i := 0; i < 3; i++ {
+			R[i] -= rBody[i]
+			V[i] -= vBody[i]
+		}
+		dcm := b.Ecliptic2Equatorial(dt)
+		R = MxV33(dcm, R)
+		V = MxV33(dcm, V)
 	}
-	pstate := smdConfig().ChgFrame(toFrame, fromFrame, dt, state)
-	o.rVec = pstate.R
-	o.vVec = pstate.V
-
+	o.rVec = R
+	o.vVec = V
 	o.Origin = b // Don't forget to switch origin
 }
 
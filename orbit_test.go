@@ -326,3 +326,47 @@ func TestOrbitSpeCircularEquatorial(t *testing.T) {
 		}
 	}
 }
+
+func TestOrbitHelioRV(t *testing.T) {
+	// Use the Meeus ephemerides of the Earth.
+	defer func(loaded bool, conf _smdconfig) {
+		cfgLoaded, config = loaded, conf
+	}(cfgLoaded, config)
+	cfgLoaded = true
+	config = _smdconfig{meeus: true}
+	dt := time.Date(2016, 3, 24, 20, 41, 48, 0, time.UTC)
+	earthR, earthV := Earth.HelioOrbit(dt).RV()
+	// The center of the Earth is at the Earth ephemeris.
+	center := NewOrbitFromRV([]float64{0, 0, 0}, []float64{0, 0, 0}, Earth)
+	if R, V := center.HelioRV(dt); !floats.EqualApprox(R, earthR, 1e-9) || !floats.EqualApprox(V, earthV, 1e-12) {
+		t.Fatalf("R=%+v V=%+v", R, V)
+	}
+	// The pole of the Earth is tilted in the ecliptic frame.
+	pole := NewOrbitFromRV([]float64{0, 0, 1e4}, []float64{0, 0, 0}, Earth)
+	R, _ := pole.HelioRV(dt)
+	floats.Sub(R, earthR)
+	if !floats.EqualWithinAbs(Rad2deg(math.Acos(R[2]/Norm(R))), Earth.tilt, 1e-9) {
+		t.Fatalf("pole at %+v", R)
+	}
+	// Heliocentric orbits are unchanged, and are copied.
+	helio := NewOrbitFromRV([]float64{1e8, 0, 0}, []float64{0, 30, 0}, Sun)
+	R, _ = helio.HelioRV(dt)
+	R[0] = 0
+	if helio.R()[0] != 1e8 {
+		t.Fatal("HelioRV modified the orbit")
+	}
+	// Round trip through the Sun.
+	o := NewOrbitFromOE(7000, 0.01, 30, 40, 50, 60, Earth)
+	R0, V0 := append([]float64(nil), o.R()...), append([]float64(nil), o.V()...)
+	o.ToXCentric(Sun, dt)
+	if !o.Origin.Equals(Sun) || o.RNorm() < 0.9*AU {
+		t.Fatalf("not heliocentric: %s", o)
+	}
+	o.ToXCentric(Earth, dt)
+	if !floats.EqualApprox(o.R(), R0, 1e-6) || !floats.EqualApprox(o.V(), V0, 1e-9) {
+		t.Fatalf("R=%+v V=%+v != R=%+v V=%+v", o.R(), o.V(), R0, V0)
+	}
+	assertPanic(t, func() {
+		o.ToXCentric(Earth, dt)
+	})
+}
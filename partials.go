@@ -0,0 +1,141 @@
+package smd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// StateBlock is a named block of the augmented state estimated by the orbit determination, e.g. the position of
+// the spacecraft or the clock offset of a one-way link.
+type StateBlock string
+
+const (
+	// PositionBlock is the inertial position of the spacecraft (km).
+	PositionBlock StateBlock = "position"
+	// VelocityBlock is the inertial velocity of the spacecraft (km/s).
+	VelocityBlock StateBlock = "velocity"
+	// CrBlock is the coefficient of reflectivity of the spacecraft.
+	CrBlock StateBlock = "Cr"
+	// ClockBlock is the clock offset and drift [cδt, cδtDot] (km and km/s).
+	ClockBlock StateBlock = "clock"
+	// ObserverPositionBlock is the inertial position of the observing spacecraft of an inter-satellite link (km).
+	ObserverPositionBlock StateBlock = "observerPosition"
+	// ObserverVelocityBlock is the inertial velocity of the observing spacecraft of an inter-satellite link (km/s).
+	ObserverVelocityBlock StateBlock = "observerVelocity"
+)
+
+var (
+	stateBlockSizes = map[StateBlock]int{PositionBlock: 3, VelocityBlock: 3, CrBlock: 1, ClockBlock: 2, ObserverPositionBlock: 3, ObserverVelocityBlock: 3}
+	stateBlocksMu   sync.RWMutex
+)
+
+// RegisterStateBlock registers a new block of the augmented state of the provided size, e.g. a range bias, such
+// that new observables may provide their partials with respect to it.
+// Panics if the size is not positive or if the block is already registered with another size.
+func RegisterStateBlock(block StateBlock, size int) {
+	if size < 1 {
+		panic(fmt.Errorf("invalid size %d for state block %s", size, block))
+	}
+	stateBlocksMu.Lock()
+	defer stateBlocksMu.Unlock()
+	if prev, exists := stateBlockSizes[block]; exists && prev != size {
+		panic(fmt.Errorf("state block %s already registered with size %d", block, prev))
+	}
+	stateBlockSizes[block] = size
+}
+
+// Observable is a measurement which provides its partial derivatives with respect to the blocks of the augmented
+// state it depends on. Each partial has as many rows as the measurement vector and as many columns as its block.
+type Observable interface {
+	StateVector() *mat64.Vector
+	Partials() map[StateBlock]*mat64.Dense
+}
+
+// AugmentedState defines the layout of the state estimated by the orbit determination.
+type AugmentedState struct {
+	Blocks  []StateBlock
+	offsets map[StateBlock]int
+	size    int
+}
+
+// NewAugmentedState returns the augmented state made of the provided blocks, in that order.
+// Panics if a block is not registered or is repeated.
+func NewAugmentedState(blocks ...StateBlock) AugmentedState {
+	stateBlocksMu.RLock()
+	defer stateBlocksMu.RUnlock()
+	s := AugmentedState{Blocks: blocks, offsets: make(map[StateBlock]int)}
+	for _, block := range blocks {
+		size, registered := stateBlockSizes[block]
+		if !registered {
+			panic(fmt.Errorf("unregistered state block %s", block))
+		}
+		if _, dup := s.offsets[block]; dup {
+			panic(fmt.Errorf("state block %s is repeated", block))
+		}
+		s.offsets[block] = s.size
+		s.size += size
+	}
+	return s
+}
+
+// Len returns the size of the augmented state.
+func (s AugmentedState) Len() int {
+	return s.size
+}
+
+// Offset returns the index of the first component of the provided block, and whether it is part of this state.
+func (s AugmentedState) Offset(block StateBlock) (int, bool) {
+	offset, ok := s.offsets[block]
+	return offset, ok
+}
+
+// HTilde returns the H tilde matrix of the observable with respect to this augmented state. The blocks which the
+// observable does not depend on are zero, and its partials with respect to blocks which are not estimated are
+// ignored (e.g. consider parameters).
+func (s AugmentedState) HTilde(m Observable) *mat64.Dense {
+	rows := m.StateVector().Len()
+	H := mat64.NewDense(rows, s.size, nil)
+	for block, partials := range m.Partials() {
+		offset, estimated := s.offsets[block]
+		if !estimated {
+			continue
+		}
+		r, c := partials.Dims()
+		if r != rows || c != stateBlockSizes[block] {
+			panic(fmt.Errorf("partials of %s are %dx%d instead of %dx%d", block, r, c, rows, stateBlockSizes[block]))
+		}
+		for i := 0; i < r; i++ {
+			for j := 0; j < c; j++ {
+				H.Set(i, offset+j, partials.At(i, j))
+			}
+		}
+	}
+	return H
+}
+
+func (s AugmentedState) String() string {
+	names := make([]string, len(s.Blocks))
+	for k, block := range s.Blocks {
+		names[k] = string(block)
+	}
+	return fmt.Sprintf("[%s] (%d)", strings.Join(names, ", "), s.size)
+}
+
+// positionVelocityPartials splits partials with respect to the position and velocity into their blocks.
+func positionVelocityPartials(H *mat64.Dense) map[StateBlock]*mat64.Dense {
+	rows, _ := H.Dims()
+	pos, vel := mat64.NewDense(rows, 3, nil), mat64.NewDense(rows, 3, nil)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < 3; j++ {
+			pos.Set(i, j, H.At(i, j))
+			vel.Set(i, j, H.At(i, j+3))
+		}
+	}
+	return map[StateBlock]*mat64.Dense{PositionBlock: pos, VelocityBlock: vel}
+}
+
+// orbitState is the augmented state of the position and velocity of the spacecraft.
+var orbitState = NewAugmentedState(PositionBlock, VelocityBlock)
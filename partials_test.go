@@ -0,0 +1,82 @@
+package smd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// biasedRange is a range measurement with a bias, as a new observable would be added.
+type biasedRange struct {
+	Measurement
+}
+
+func (m biasedRange) StateVector() *mat64.Vector {
+	return mat64.NewVector(1, []float64{m.Range})
+}
+
+func (m biasedRange) Partials() map[StateBlock]*mat64.Dense {
+	H := m.Measurement.HTilde()
+	return map[StateBlock]*mat64.Dense{
+		PositionBlock:          mat64.NewDense(1, 3, []float64{H.At(0, 0), H.At(0, 1), H.At(0, 2)}),
+		"rangeBias":            mat64.NewDense(1, 1, []float64{1}),
+		"unestimatedParameter": mat64.NewDense(1, 1, []float64{42}),
+	}
+}
+
+func TestAugmentedState(t *testing.T) {
+	RegisterStateBlock("rangeBias", 1)
+	RegisterStateBlock("unestimatedParameter", 1)
+	s := NewAugmentedState(PositionBlock, VelocityBlock, CrBlock, "rangeBias")
+	if s.Len() != 8 {
+		t.Fatalf("augmented state %s", s)
+	}
+	if offset, ok := s.Offset("rangeBias"); !ok || offset != 7 {
+		t.Fatalf("range bias at %d", offset)
+	}
+	if _, ok := s.Offset(ClockBlock); ok {
+		t.Fatal("clock is not estimated")
+	}
+	dt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := NewStation("st", 0, 0, 0, 0, 0, 0)
+	state := State{DT: dt, Orbit: *NewOrbitFromOE(Earth.Radius+1000, 0, 10, 0, 0, 5, Earth)}
+	m := st.PerformMeasurement(0, state)
+	H := s.HTilde(biasedRange{m})
+	if r, c := H.Dims(); r != 1 || c != 8 {
+		t.Fatalf("H tilde is %dx%d", r, c)
+	}
+	full := m.HTilde()
+	for j := 0; j < 8; j++ {
+		exp := 0.
+		switch {
+		case j < 3:
+			exp = full.At(0, j)
+		case j == 7:
+			exp = 1
+		}
+		if H.At(0, j) != exp {
+			t.Fatalf("H[%d] = %f instead of %f", j, H.At(0, j), exp)
+		}
+	}
+	// The station measurements with the coefficient of reflectivity.
+	m.Station = NewSpecialStation("cr", 0, 0, 0, 0, 0, 0, 7)
+	if _, c := m.HTilde().Dims(); c != 7 {
+		t.Fatalf("%d columns with Cr", c)
+	}
+	for _, f := range []func(){
+		func() { NewAugmentedState(PositionBlock, PositionBlock) },
+		func() { NewAugmentedState("unknown") },
+		func() { RegisterStateBlock("rangeBias", 2) },
+		func() { RegisterStateBlock("empty", 0) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatal("invalid layout did not panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
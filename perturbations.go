@@ -17,18 +17,43 @@ type Perturbations struct {
 	Drag           bool             // Set to true to use the Spacecraft's Drag for everything including STM computation
 	Noise          OrbitNoise
 	Arbitrary      func(o Orbit) []float64 // Additional arbitrary pertubation.
+	// ArbitraryPartials returns the partials (3x6) of the Arbitrary acceleration with respect to the position and
+	// velocity for the STM (e.g. AtmosphericDragPartials). If nil, they are computed by central differences.
+	ArbitraryPartials func(o Orbit) *mat64.Dense
+	DMC               *DMC                     // Estimate empirical accelerations (dynamic model compensation), nil to disable
+	gravity           map[string]BodyConstants // Gravity overrides of the mission (cf. Mission.Gravity)
 }
 
 func (p Perturbations) isEmpty() bool {
 	return p.Jn <= 1 && p.PerturbingBody == nil && p.AutoThirdBody && p.Arbitrary == nil
 }
 
+// gravityOf returns the provided body with the gravity overrides of the mission, if any.
+func (p Perturbations) gravityOf(body CelestialObject) CelestialObject {
+	if override, ok := p.gravity[body.Name]; ok {
+		override.apply(&body)
+	}
+	return body
+}
+
 // STMSize returns the size of the STM
 func (p Perturbations) STMSize() (r, c int) {
+	r = 6
 	if p.Drag {
-		return 7, 7
+		r++
 	}
-	return 6, 6
+	if p.DMC != nil {
+		r += 3
+	}
+	return r, r
+}
+
+// dmcSTMIndex returns the index of the first empirical acceleration in the STM.
+func (p Perturbations) dmcSTMIndex() int {
+	if p.Drag {
+		return 7
+	}
+	return 6
 }
 
 // Perturb returns the perturbing state vector based on the kind of propagation being used.
@@ -41,6 +66,7 @@ func (p Perturbations) Perturb(o Orbit, dt time.Time, sc Spacecraft) []float64 {
 	}
 	if p.Jn > 1 && !o.Origin.Equals(Sun) {
 		// Ignore any Jn about the Sun
+		body := p.gravityOf(o.Origin)
 		R := o.R()
 		x := R[0]
 		y := R[1]
@@ -51,7 +77,7 @@ func (p Perturbations) Perturb(o Orbit, dt time.Time, sc Spacecraft) []float64 {
 		r252 := math.Pow(r2, 5/2.)
 		r272 := math.Pow(r2, 7/2.)
 		// J2 (computed via SageMath: https://cloud.sagemath.com/projects/1fb6b227-1832-4f82-a05c-7e45614c00a2/files/j2perts.sagews)
-		accJ2 := (3 / 2.) * o.Origin.J(2) * math.Pow(o.Origin.Radius, 2) * o.Origin.μ
+		accJ2 := (3 / 2.) * body.J(2) * math.Pow(body.Radius, 2) * body.μ
 		pert[3] += accJ2 * (5*x*z2/r272 - x/r252)
 		pert[4] += accJ2 * (5*y*z2/r272 - y/r252)
 		pert[5] += accJ2 * (5*z3/r272 - 3*z/r252)
@@ -59,33 +85,22 @@ func (p Perturbations) Perturb(o Orbit, dt time.Time, sc Spacecraft) []float64 {
 			// J3 (computed via SageMath: https://cloud.sagemath.com/#projects/1fb6b227-1832-4f82-a05c-7e45614c00a2/files/j3perts.sagews)
 			r292 := math.Pow(r2, 9/2.)
 			z4 := math.Pow(R[2], 4)
-			accJ3 := o.Origin.J(3) * math.Pow(o.Origin.Radius, 3) * o.Origin.μ
+			accJ3 := body.J(3) * math.Pow(body.Radius, 3) * body.μ
 			pert[3] += (5 / 2.) * accJ3 * (7*x*z3/r292 - 3*x*z/r272)
 			pert[4] += (5 / 2.) * accJ3 * (7*y*z3/r292 - 3*y*z/r272)
 			pert[5] += 0.5 * accJ3 * (35*z4/r292 - 30*z2/r272 + 3/r252)
 		}
 	}
 
-	var RSunToEarth, RSunToSC, REarthToSC []float64
-
+	var RSunToEarth, RSunToSC []float64
 	if p.Drag || p.PerturbingBody != nil {
-		REarthToSC = o.R()
-		RSunToEarth = MxV33(R1(Deg2rad(-Earth.tilt)), o.Origin.HelioOrbit(dt).R())
-		RSunToSC = make([]float64, 3)
-		for i := 0; i < 3; i++ {
-			RSunToSC[i] = RSunToEarth[i] + REarthToSC[i]
-		}
+		RSunToEarth, RSunToSC = sunToOriginAndSC(o, dt)
 	}
 
 	if p.Drag {
 		// If Drag, SRP is *also* turned on.
 		// TODO: Drag, there is only SRP here.
-		Cr := sc.Drag
-		S := 0.01e-6 // TODO: Idem for the Area to mass ratio
-		Phi := 1357.
-		// Build the vectors.
-		celerity := 2.997925e+05
-		srpCst := (Phi * AU * AU * S / celerity) * Cr / math.Pow(Norm(RSunToSC), 3)
+		srpCst := srpFactor(o, RSunToEarth, RSunToSC) * sc.Drag
 		for i := 0; i < 3; i++ {
 			pert[i+3] += -srpCst * RSunToSC[i]
 		}
@@ -95,10 +110,11 @@ func (p Perturbations) Perturb(o Orbit, dt time.Time, sc Spacecraft) []float64 {
 		if !p.PerturbingBody.Equals(Sun) {
 			panic("only the Sun as a perturbing body is currently supported")
 		}
+		μSun := p.gravityOf(Sun).μ
 		RSunToEarthNorm3 := math.Pow(Norm(RSunToEarth), 3)
 		RSunToSCNorm3 := math.Pow(Norm(RSunToSC), 3)
 		for i := 0; i < 3; i++ {
-			pert[i+3] += Sun.μ * (RSunToEarth[i]/RSunToEarthNorm3 - RSunToSC[i]/RSunToSCNorm3)
+			pert[i+3] += μSun * (RSunToEarth[i]/RSunToEarthNorm3 - RSunToSC[i]/RSunToSCNorm3)
 		}
 	}
 	if p.Arbitrary != nil {
@@ -117,17 +133,98 @@ func (p Perturbations) Perturb(o Orbit, dt time.Time, sc Spacecraft) []float64 {
 	return pert
 }
 
+// Partials returns the partials of the SRP, third body and arbitrary accelerations of Perturb with respect to the
+// position and velocity (3x6), and with respect to the coefficient of reflectivity of the spacecraft (cf. Drag), for
+// the variational equations of the STM. The partials of the Jn accelerations are computed by the Mission, and the
+// variation of the shadow of the central body is neglected.
+func (p Perturbations) Partials(o Orbit, dt time.Time, sc Spacecraft) (dAdX *mat64.Dense, dAdCr []float64) {
+	dAdX = mat64.NewDense(3, 6, nil)
+	dAdCr = make([]float64, 3)
+	if p.Drag || p.PerturbingBody != nil {
+		RSunToEarth, RSunToSC := sunToOriginAndSC(o, dt)
+		// Both accelerations are of the form k*RSunToSC, whose gradient is k*(I - 3 u uᵀ).
+		u := Unit(RSunToSC)
+		var k float64
+		if p.Drag {
+			srpCst := srpFactor(o, RSunToEarth, RSunToSC)
+			k -= srpCst * sc.Drag
+			for i := 0; i < 3; i++ {
+				dAdCr[i] = -srpCst * RSunToSC[i]
+			}
+		}
+		if p.PerturbingBody != nil && !p.PerturbingBody.Equals(o.Origin) {
+			// The acceleration of the origin by the Sun does not depend on the state of the spacecraft.
+			k -= p.gravityOf(Sun).μ / math.Pow(Norm(RSunToSC), 3)
+		}
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				val := -3 * u[i] * u[j]
+				if i == j {
+					val++
+				}
+				dAdX.Set(i, j, k*val)
+			}
+		}
+	}
+	if p.Arbitrary != nil {
+		partials := p.ArbitraryPartials
+		if partials == nil {
+			partials = p.arbitraryFDPartials
+		}
+		var sum mat64.Dense
+		sum.Add(dAdX, partials(o))
+		dAdX = &sum
+	}
+	return
+}
+
+// arbitraryFDPartials returns the partials of the Arbitrary acceleration by central differences.
+func (p Perturbations) arbitraryFDPartials(o Orbit) *mat64.Dense {
+	R, V := o.RV()
+	x := append(append([]float64{}, R...), V...)
+	h := []float64{stateJacobianRStep, stateJacobianRStep, stateJacobianRStep, stateJacobianVStep, stateJacobianVStep, stateJacobianVStep}
+	return Jacobian(func(x []float64) []float64 {
+		return p.Arbitrary(*NewOrbitFromRV(x[:3], x[3:], o.Origin))[3:6]
+	}, x, h, CentralDifference)
+}
+
+// sunToOriginAndSC returns the positions of the origin of the orbit and of the spacecraft with respect to the Sun.
+func sunToOriginAndSC(o Orbit, dt time.Time) (RSunToEarth, RSunToSC []float64) {
+	REarthToSC := o.R()
+	RSunToEarth = MxV33(o.Origin.Ecliptic2Equatorial(dt), o.Origin.HelioOrbit(dt).R())
+	RSunToSC = make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		RSunToSC[i] = RSunToEarth[i] + REarthToSC[i]
+	}
+	return
+}
+
+// srpFactor returns the factor of the SRP acceleration -srpFactor*Cr*RSunToSC (km/s²), including the shadow of the
+// central body.
+func srpFactor(o Orbit, RSunToEarth, RSunToSC []float64) float64 {
+	S := 0.01e-6 // TODO: Idem for the Area to mass ratio
+	Phi := 1357.
+	celerity := 2.997925e+05
+	srpCst := (Phi * AU * AU * S / celerity) / math.Pow(Norm(RSunToSC), 3)
+	if !o.Origin.Equals(Sun) {
+		// Account for the shadow of the central body.
+		srpCst *= IlluminationFraction(o.R(), []float64{0, 0, 0}, []float64{-RSunToEarth[0], -RSunToEarth[1], -RSunToEarth[2]}, o.Origin, ConicalShadow)
+	}
+	return srpCst
+}
+
 // OrbitNoise defines a new orbit noise applied as a perturbations.
 // Use case is for generating datasets for filtering.
 type OrbitNoise struct {
 	probability float64
 	position    *distmv.Normal
 	velocity    *distmv.Normal
+	rng         *rand.Rand
 }
 
 func (n OrbitNoise) Generate() (rtn []float64) {
 	rtn = make([]float64, 6)
-	if randFloat := rand.Float64(); n.probability < randFloat {
+	if randFloat := n.rng.Float64(); n.probability < randFloat {
 		return
 	}
 	position := n.position.Rand(nil)
@@ -142,7 +239,7 @@ func (n OrbitNoise) Generate() (rtn []float64) {
 func NewOrbitNoise(probability, sigmaPosition, sigmaVelocity float64) OrbitNoise {
 	posMatrix := mat64.NewSymDense(3, []float64{sigmaPosition, 0, 0, 0, sigmaPosition, 0, 0, 0, sigmaPosition})
 	velMatrix := mat64.NewSymDense(3, []float64{sigmaVelocity, 0, 0, 0, sigmaVelocity, 0, 0, 0, sigmaVelocity})
-	seed := rand.New(rand.NewSource(time.Now().UnixNano()))
+	seed := newRand()
 	position, ok := distmv.NewNormal(make([]float64, 3), posMatrix, seed)
 	if !ok {
 		panic("process noise invalid")
@@ -151,5 +248,5 @@ func NewOrbitNoise(probability, sigmaPosition, sigmaVelocity float64) OrbitNoise
 	if !ok {
 		panic("measurement noise invalid")
 	}
-	return OrbitNoise{probability, position, velocity}
+	return OrbitNoise{probability, position, velocity, seed}
 }
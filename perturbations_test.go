@@ -1,10 +1,12 @@
 package smd
 
 import (
+	"math"
 	"testing"
 	"time"
 
 	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
 )
 
 func TestPertArbitrary(t *testing.T) {
@@ -50,3 +52,57 @@ func TestPert3rdBody(t *testing.T) {
 	}
 
 }
+
+func TestPertPartials(t *testing.T) {
+	dt := time.Date(2017, 3, 20, 12, 0, 0, 0, time.UTC)
+	o := *NewOrbitFromOE(Earth.Radius+400, 0.001, 51.6, 10, 20, 30, Earth)
+	sc := NewEmptySC("partials", 100)
+	sc.Drag = 1.2 // Cr
+	sc.Cd = 2.2
+	sc.Area = 2
+	R, V := o.RV()
+	x := append(append([]float64{}, R...), V...)
+	h := []float64{1e-3, 1e-3, 1e-3, 1e-6, 1e-6, 1e-6}
+	// blockDiff returns the relative difference of the position and velocity columns of both partials.
+	blockDiff := func(exp, got *mat64.Dense) (rel [2]float64) {
+		for b := 0; b < 2; b++ {
+			var num, den float64
+			for i := 0; i < 3; i++ {
+				for j := 3 * b; j < 3*b+3; j++ {
+					num += math.Pow(exp.At(i, j)-got.At(i, j), 2)
+					den += math.Pow(exp.At(i, j), 2)
+				}
+			}
+			if den > 0 {
+				rel[b] = math.Sqrt(num / den)
+			} else {
+				rel[b] = math.Sqrt(num)
+			}
+		}
+		return
+	}
+	for _, perts := range []Perturbations{
+		{PerturbingBody: &Sun},
+		{Drag: true},
+		{Arbitrary: AtmosphericDrag(*sc, ModerateSpaceWeather)},
+		{Arbitrary: AtmosphericDrag(*sc, ModerateSpaceWeather), ArbitraryPartials: AtmosphericDragPartials(*sc, ModerateSpaceWeather)},
+		{Drag: true, PerturbingBody: &Sun, Arbitrary: AtmosphericDrag(*sc, ModerateSpaceWeather)},
+	} {
+		exp := Jacobian(func(x []float64) []float64 {
+			return perts.Perturb(*NewOrbitFromRV(x[:3], x[3:], Earth), dt, *sc)[3:6]
+		}, x, h, CentralDifference)
+		dAdX, dAdCr := perts.Partials(o, dt, *sc)
+		if rel := blockDiff(exp, dAdX); rel[0] > 1e-4 || rel[1] > 1e-4 {
+			t.Fatalf("%+v: relative errors of %v\n%v\n%v", perts, rel, mat64.Formatted(exp), mat64.Formatted(dAdX))
+		}
+		// The SRP is linear in Cr.
+		cr := *sc
+		cr.Drag++
+		pert, pertCr := perts.Perturb(o, dt, *sc), perts.Perturb(o, dt, cr)
+		for i := 0; i < 3; i++ {
+			if math.Abs(dAdCr[i]-(pertCr[i+3]-pert[i+3])) > 1e-9*math.Abs(dAdCr[i])+1e-30 {
+				t.Fatalf("%+v: invalid Cr partials %v", perts, dAdCr)
+			}
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ScheduledManeuver stores an impulsive maneuver (km/s, in the RIC frame of the orbit at the burn) and its epoch.
+type ScheduledManeuver struct {
+	DT       time.Time
+	Maneuver Maneuver
+}
+
+func (m ScheduledManeuver) String() string {
+	return fmt.Sprintf("%s @ %s", m.Maneuver, m.DT)
+}
+
+// RendezvousPlan stores the impulsive maneuvers of a rendezvous and the epoch at which the chaser reaches the target.
+type RendezvousPlan struct {
+	Maneuvers []ScheduledManeuver
+	Arrival   time.Time
+}
+
+// TotalΔv returns the total Δv of the plan in km/s.
+func (p RendezvousPlan) TotalΔv() (Δv float64) {
+	for _, m := range p.Maneuvers {
+		Δv += m.Maneuver.Δv()
+	}
+	return
+}
+
+// Schedule adds the maneuvers of the plan to the spacecraft, such that they are executed by a Mission propagated
+// with the provided step.
+func (p RendezvousPlan) Schedule(sc *Spacecraft, step time.Duration) {
+	for _, m := range p.Maneuvers {
+		sc.Maneuvers[m.DT.Truncate(step)] = NewManeuver(m.Maneuver.R, m.Maneuver.N, m.Maneuver.C)
+	}
+}
+
+func (p RendezvousPlan) String() string {
+	s := fmt.Sprintf("rendezvous @ %s with %.3f m/s", p.Arrival, p.TotalΔv()*1e3)
+	for _, m := range p.Maneuvers {
+		s += fmt.Sprintf("\n\t%s", m)
+	}
+	return s
+}
+
+// PhaseAngle returns the angle (in radians, in [0, 2π)) by which the target leads the chaser on coplanar orbits.
+func PhaseAngle(chaser, target Orbit) float64 {
+	// The elements are ill-defined for circular orbits, so use the angle between the radius vectors.
+	Rc, Rt := chaser.R(), target.R()
+	θ := math.Atan2(Dot(Unit(chaser.H()), Cross(Rc, Rt)), Dot(Rc, Rt))
+	if θ < 0 {
+		θ += 2 * math.Pi
+	}
+	return θ
+}
+
+// checkCoplanarCircular returns an error if the chaser and the target are not on the same circular orbit.
+func checkCoplanarCircular(chaser, target Orbit) error {
+	if !chaser.Origin.Equals(target.Origin) {
+		return errors.New("chaser and target do not orbit the same body")
+	}
+	ac, ec, _, _, _, _, _, _, _ := chaser.Elements()
+	at, et, _, _, _, _, _, _, _ := target.Elements()
+	if ec > 1e-3 || et > 1e-3 {
+		return errors.New("phasing requires circular orbits")
+	}
+	if math.Abs(ac-at) > 1e-3*at {
+		return fmt.Errorf("chaser and target are not on the same orbit (a=%f km vs %f km)", ac, at)
+	}
+	if Dot(Unit(chaser.H()), Unit(target.H())) < math.Cos(Deg2rad(0.1)) {
+		return errors.New("chaser and target are not coplanar")
+	}
+	return nil
+}
+
+// PeriodPhasing returns the two burn plan which catches up with a target leading the chaser on the same circular
+// orbit: the chaser lowers its period such that, after the provided number of revolutions on the phasing orbit,
+// the target has closed the phase angle, and then circularizes back on the target.
+// An error is returned if the periapsis of the phasing orbit is below the minimum radius (km).
+func PeriodPhasing(chaser, target Orbit, dt time.Time, revs int, minRadius float64) (RendezvousPlan, error) {
+	if revs < 1 {
+		panic("phasing requires at least one revolution")
+	}
+	if err := checkCoplanarCircular(chaser, target); err != nil {
+		return RendezvousPlan{}, err
+	}
+	μ := chaser.Origin.μ
+	r := chaser.RNorm()
+	nt := 2 * math.Pi / target.Period().Seconds()
+	θ := PhaseAngle(chaser, target)
+	k := float64(revs)
+	Tph := (2*math.Pi*k - θ) / (k * nt)
+	aPh := math.Cbrt(μ * math.Pow(Tph/(2*math.Pi), 2))
+	if rP := 2*aPh - r; rP < minRadius {
+		return RendezvousPlan{}, fmt.Errorf("phasing orbit periapsis of %.3f km is below %.3f km: increase the number of revolutions", rP, minRadius)
+	}
+	Δv := math.Sqrt(μ*(2/r-1/aPh)) - chaser.VNorm()
+	arrival := dt.Add(time.Duration(k * Tph * 1e9))
+	return RendezvousPlan{[]ScheduledManeuver{{dt, NewManeuver(0, Δv, 0)}, {arrival, NewManeuver(0, -Δv, 0)}}, arrival}, nil
+}
+
+// CoellipticPhasing returns the four burn co-elliptic plan which catches up with a target leading the chaser on
+// the same circular orbit: the chaser performs a Hohmann transfer down by Δh (km), drifts on the lower co-elliptic
+// orbit until the phase angle allows a Hohmann transfer back up to end on the target.
+func CoellipticPhasing(chaser, target Orbit, dt time.Time, Δh float64) (RendezvousPlan, error) {
+	if Δh <= 0 {
+		panic("co-elliptic phasing requires a positive altitude difference")
+	}
+	if err := checkCoplanarCircular(chaser, target); err != nil {
+		return RendezvousPlan{}, err
+	}
+	μ := chaser.Origin.μ
+	r := chaser.RNorm()
+	r2 := r - Δh
+	if r2 <= chaser.Origin.Radius {
+		return RendezvousPlan{}, fmt.Errorf("co-elliptic orbit of %.3f km is below the surface of %s", r2, chaser.Origin.Name)
+	}
+	aTr := (r + r2) / 2
+	tH := math.Pi * math.Sqrt(math.Pow(aTr, 3)/μ)
+	vApo := math.Sqrt(μ * (2/r - 1/aTr))
+	vPeri := math.Sqrt(μ * (2/r2 - 1/aTr))
+	vCirc2 := math.Sqrt(μ / r2)
+	nt := 2 * math.Pi / target.Period().Seconds()
+	n2 := math.Sqrt(μ / math.Pow(r2, 3))
+	// Phase angle once on the co-elliptic orbit, and the one required to start the transfer back up.
+	θ2 := PhaseAngle(chaser, target) + nt*tH - math.Pi
+	θf := math.Pi - nt*tH
+	tDrift := math.Mod(math.Mod(θ2-θf, 2*math.Pi)+2*math.Pi, 2*math.Pi) / (n2 - nt)
+	toDuration := func(t float64) time.Duration { return time.Duration(t * 1e9) }
+	dt2 := dt.Add(toDuration(tH))
+	dt3 := dt2.Add(toDuration(tDrift))
+	arrival := dt3.Add(toDuration(tH))
+	return RendezvousPlan{[]ScheduledManeuver{
+		{dt, NewManeuver(0, vApo-chaser.VNorm(), 0)},
+		{dt2, NewManeuver(0, vCirc2-vPeri, 0)},
+		{dt3, NewManeuver(0, vPeri-vCirc2, 0)},
+		{arrival, NewManeuver(0, math.Sqrt(μ/r)-vApo, 0)},
+	}, arrival}, nil
+}
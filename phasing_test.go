@@ -0,0 +1,112 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+// executePlan propagates the chaser with the maneuvers of the plan and the target until the arrival, and returns
+// the distance between both (km).
+func executePlan(t *testing.T, plan RendezvousPlan, chaser, target *Orbit, start time.Time) float64 {
+	step := time.Second
+	sc := NewEmptySC("chaser", 0)
+	plan.Schedule(sc, step)
+	end := plan.Arrival.Add(2 * step)
+	NewPreciseMission(sc, chaser, start, end, Perturbations{}, step, false, ExportConfig{}).Propagate()
+	NewPreciseMission(NewEmptySC("target", 0), target, start, end, Perturbations{}, step, false, ExportConfig{}).Propagate()
+	for dt, m := range sc.Maneuvers {
+		if !m.done {
+			t.Fatalf("maneuver @ %s was not executed", dt)
+		}
+	}
+	Δ := append([]float64(nil), chaser.R()...)
+	floats.Sub(Δ, target.R())
+	return Norm(Δ)
+}
+
+func TestPhaseAngle(t *testing.T) {
+	chaser := NewOrbitFromOE(7000, 0, 30, 40, 50, 350, Earth)
+	target := NewOrbitFromOE(7000, 0, 30, 40, 50, 20, Earth)
+	if θ := PhaseAngle(*chaser, *target); !floats.EqualWithinAbs(θ, Deg2rad(30), 1e-9) {
+		t.Fatalf("θ=%f deg", Rad2deg(θ))
+	}
+	if θ := PhaseAngle(*target, *chaser); !floats.EqualWithinAbs(θ, Deg2rad(330), 1e-9) {
+		t.Fatalf("θ=%f deg", Rad2deg(θ))
+	}
+}
+
+func TestManeuverImpulse(t *testing.T) {
+	// The impulse must be independent of the propagation step.
+	for _, step := range []time.Duration{time.Second, 10 * time.Second} {
+		start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		o := NewOrbitFromOE(7000, 0, 30, 40, 50, 60, Earth)
+		v0 := o.VNorm()
+		sc := NewEmptySC("impulse", 0)
+		sc.Maneuvers[start] = NewManeuver(0, 0.1, 0)
+		NewPreciseMission(sc, o, start, start.Add(2*step), Perturbations{}, step, false, ExportConfig{}).Propagate()
+		// The specific energy is conserved after the burn.
+		ξ := math.Pow(v0+0.1, 2)/2 - Earth.μ/7000
+		if !floats.EqualWithinRel(o.Energyξ(), ξ, 1e-9) {
+			t.Fatalf("step=%s: ξ=%f instead of %f", step, o.Energyξ(), ξ)
+		}
+	}
+	// Reading the state does not execute the maneuver.
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	sc := NewEmptySC("pending", 0)
+	sc.Maneuvers[start] = NewManeuver(0, 0.1, 0)
+	m := NewPreciseMission(sc, NewOrbitFromOE(7000, 0, 30, 40, 50, 60, Earth), start, start.Add(time.Minute), Perturbations{}, time.Second, false, ExportConfig{})
+	if s0, s1 := m.GetState(), m.GetState(); !floats.Equal(s0, s1) || !floats.Equal(s0[3:6], m.Orbit.V()) || sc.Maneuvers[start].done {
+		t.Fatal("GetState executed the maneuver")
+	}
+}
+
+func TestPeriodPhasing(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	chaser := NewOrbitFromOE(7000, 0, 51.6, 40, 0, 0, Earth)
+	target := NewOrbitFromOE(7000, 0, 51.6, 40, 0, 20, Earth)
+	if _, err := PeriodPhasing(*chaser, *target, start, 1, 6900); err == nil {
+		t.Fatal("expected an error for a phasing periapsis below the minimum radius")
+	}
+	plan, err := PeriodPhasing(*chaser, *target, start, 3, 6600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Maneuvers) != 2 || plan.Maneuvers[0].Maneuver.N >= 0 || plan.Maneuvers[0].Maneuver.N != -plan.Maneuvers[1].Maneuver.N {
+		t.Fatalf("invalid plan: %s", plan)
+	}
+	if d := executePlan(t, plan, chaser, target, start); d > 1 {
+		t.Fatalf("chaser is %f km from the target at arrival", d)
+	}
+	if _, e, _, _, _, _, _, _, _ := chaser.Elements(); e > 1e-4 {
+		t.Fatalf("chaser not circularized (e=%f)", e)
+	}
+	if _, err = PeriodPhasing(*NewOrbitFromOE(7000, 0, 28, 40, 0, 0, Earth), *target, start, 3, 6600); err == nil {
+		t.Fatal("expected an error for non coplanar orbits")
+	}
+}
+
+func TestCoellipticPhasing(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	chaser := NewOrbitFromOE(6778, 0, 51.6, 40, 0, 0, Earth)
+	target := NewOrbitFromOE(6778, 0, 51.6, 40, 0, 45, Earth)
+	plan, err := CoellipticPhasing(*chaser, *target, start, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Maneuvers) != 4 {
+		t.Fatalf("invalid plan: %s", plan)
+	}
+	// Both Hohmann transfers are symmetric.
+	if !floats.EqualWithinAbs(plan.Maneuvers[0].Maneuver.N, -plan.Maneuvers[3].Maneuver.N, 1e-12) || !floats.EqualWithinAbs(plan.Maneuvers[1].Maneuver.N, -plan.Maneuvers[2].Maneuver.N, 1e-12) {
+		t.Fatalf("invalid plan: %s", plan)
+	}
+	if d := executePlan(t, plan, chaser, target, start); d > 1 {
+		t.Fatalf("chaser is %f km from the target at arrival", d)
+	}
+	if _, err = CoellipticPhasing(*chaser, *target, start, 500); err == nil {
+		t.Fatal("expected an error for a co-elliptic orbit below the surface")
+	}
+}
@@ -0,0 +1,261 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// porkchopScale is the number of pixels between two grid points of a rendered porkchop plot.
+const porkchopScale = 8
+
+// Porkchop generates the grids of a porkchop plot of the direct transfers from one body to another.
+type Porkchop struct {
+	From, To                 CelestialObject
+	LaunchStart, LaunchEnd   time.Time
+	ArrivalStart, ArrivalEnd time.Time
+	LaunchStep, ArrivalStep  time.Duration
+	Type                     TransferType
+	Ephemeris                EphemerisFunc
+}
+
+// NewPorkchop returns a new porkchop plot with daily steps, using the configured ephemerides.
+func NewPorkchop(from, to CelestialObject, launchStart, launchEnd, arrivalStart, arrivalEnd time.Time, ttype TransferType) Porkchop {
+	if launchEnd.Before(launchStart) || arrivalEnd.Before(arrivalStart) {
+		panic("launch or arrival window ends before it starts")
+	}
+	day := 24 * time.Hour
+	return Porkchop{from, to, launchStart, launchEnd, arrivalStart, arrivalEnd, day, day, ttype, HelioEphemeris}
+}
+
+// PorkchopGrids stores the grids of a porkchop plot, with one row per arrival date and one column per launch date,
+// i.e. the arrival is on the Y axis of a contour plot. Transfers which arrive before their launch, or whose Lambert
+// problem could not be solved, are NaN.
+type PorkchopGrids struct {
+	Launches, Arrivals []time.Time
+	C3                 *mat64.Dense // Launch C3 (km²/s²)
+	VInfArrival        *mat64.Dense // Hyperbolic excess velocity at arrival (km/s)
+	TOF                *mat64.Dense // Time of flight (days)
+}
+
+// Grids solves all the transfers of the porkchop plot.
+func (p Porkchop) Grids() PorkchopGrids {
+	var g PorkchopGrids
+	for dt := p.LaunchStart; !dt.After(p.LaunchEnd); dt = dt.Add(p.LaunchStep) {
+		g.Launches = append(g.Launches, dt)
+	}
+	for dt := p.ArrivalStart; !dt.After(p.ArrivalEnd); dt = dt.Add(p.ArrivalStep) {
+		g.Arrivals = append(g.Arrivals, dt)
+	}
+	rows, cols := len(g.Arrivals), len(g.Launches)
+	g.C3 = mat64.NewDense(rows, cols, nil)
+	g.VInfArrival = mat64.NewDense(rows, cols, nil)
+	g.TOF = mat64.NewDense(rows, cols, nil)
+	arrivals := make([][2][]float64, rows)
+	for i, dt := range g.Arrivals {
+		arrivals[i][0], arrivals[i][1] = p.Ephemeris(p.To, dt)
+	}
+	var problems []LambertProblem
+	var cells [][2]int
+	var Vps [][]float64
+	for j, launch := range g.Launches {
+		Ri, Vpi := p.Ephemeris(p.From, launch)
+		for i, arrival := range g.Arrivals {
+			g.C3.Set(i, j, math.NaN())
+			g.VInfArrival.Set(i, j, math.NaN())
+			g.TOF.Set(i, j, math.NaN())
+			if !arrival.After(launch) {
+				continue
+			}
+			problems = append(problems, LambertProblem{Ri, arrivals[i][0], arrival.Sub(launch)})
+			cells = append(cells, [2]int{i, j})
+			Vps = append(Vps, Vpi)
+		}
+	}
+	solutions, errs := LambertBatch(problems, p.Type, Sun)
+	for k, sol := range solutions {
+		if errs[k] != nil {
+			continue
+		}
+		i, j := cells[k][0], cells[k][1]
+		vInfDep := make([]float64, 3)
+		vInfArr := make([]float64, 3)
+		for c := 0; c < 3; c++ {
+			vInfDep[c] = sol.Vi[c] - Vps[k][c]
+			vInfArr[c] = sol.Vf[c] - arrivals[i][1][c]
+		}
+		g.C3.Set(i, j, math.Pow(Norm(vInfDep), 2))
+		g.VInfArrival.Set(i, j, Norm(vInfArr))
+		g.TOF.Set(i, j, sol.TOF.Hours()/24)
+	}
+	return g
+}
+
+// PorkchopTransfer is a transfer of a porkchop plot propagated with perturbations, compared to its conic solution.
+type PorkchopTransfer struct {
+	Launch, Arrival   time.Time
+	C3, VInfArrival   float64 // Of the conic solution (km²/s² and km/s)
+	Conic, Propagated Orbit   // Heliocentric states at arrival
+	ΔR, ΔV            float64 // Position (km) and velocity (km/s) differences of the propagated state from the conic one
+}
+
+// Propagate numerically propagates the n transfers of the grids with the lowest C3 with the provided perturbations,
+// and compares their arrival states with the conic ones, e.g. to check that the best candidates survive the SRP or
+// the arbitrary planetary perturbations. The transfers are propagated concurrently on all CPUs, each with a copy of
+// the provided spacecraft, and are returned by increasing C3.
+func (p Porkchop) Propagate(g PorkchopGrids, n int, sc Spacecraft, perts Perturbations, step time.Duration) []PorkchopTransfer {
+	rows, cols := g.C3.Dims()
+	var cells []int
+	for k := 0; k < rows*cols; k++ {
+		if !math.IsNaN(g.C3.At(k/cols, k%cols)) {
+			cells = append(cells, k)
+		}
+	}
+	sort.Sort(indexSorter{cells, func(a, b int) bool { return g.C3.At(a/cols, a%cols) < g.C3.At(b/cols, b%cols) }})
+	if n < len(cells) {
+		cells = cells[:n]
+	}
+	problems := make([]LambertProblem, len(cells))
+	for k, cell := range cells {
+		launch, arrival := g.Launches[cell%cols], g.Arrivals[cell/cols]
+		Ri, _ := p.Ephemeris(p.From, launch)
+		Rf, _ := p.Ephemeris(p.To, arrival)
+		problems[k] = LambertProblem{Ri, Rf, arrival.Sub(launch)}
+	}
+	solutions, errs := LambertBatch(problems, p.Type, Sun)
+	transfers := make([]PorkchopTransfer, len(cells))
+	parallelFor(len(cells), func(k int) {
+		if errs[k] != nil {
+			return
+		}
+		i, j := cells[k]/cols, cells[k]%cols
+		// The mission marks the maneuvers as done, so they may not be shared between the spacecraft.
+		vehicle := sc
+		vehicle.Maneuvers = make(map[time.Time]Maneuver)
+		for dt, maneuver := range sc.Maneuvers {
+			vehicle.Maneuvers[dt] = maneuver
+		}
+		orbit := NewOrbitFromRV(problems[k].Ri, solutions[k].Vi, Sun)
+		NewPreciseMission(&vehicle, orbit, g.Launches[j], g.Arrivals[i], perts, step, false, ExportConfig{}).PropagateUntil(g.Arrivals[i], true)
+		conic := NewOrbitFromRV(problems[k].Rf, solutions[k].Vf, Sun)
+		Rc, Vc := conic.RV()
+		Rp, Vp := orbit.RV()
+		ΔR := make([]float64, 3)
+		ΔV := make([]float64, 3)
+		for c := 0; c < 3; c++ {
+			ΔR[c], ΔV[c] = Rp[c]-Rc[c], Vp[c]-Vc[c]
+		}
+		transfers[k] = PorkchopTransfer{g.Launches[j], g.Arrivals[i], g.C3.At(i, j), g.VInfArrival.At(i, j), *conic, *orbit, Norm(ΔR), Norm(ΔV)}
+	})
+	var solved []PorkchopTransfer
+	for k, transfer := range transfers {
+		if errs[k] == nil {
+			solved = append(solved, transfer)
+		}
+	}
+	return solved
+}
+
+// WritePorkchopTransfers writes the propagated transfers as a CSV table.
+func WritePorkchopTransfers(w io.Writer, transfers []PorkchopTransfer) error {
+	if _, err := fmt.Fprint(w, "launch,arrival,tof,c3,vInfArrival,dR,dV\n"); err != nil {
+		return err
+	}
+	for _, t := range transfers {
+		if _, err := fmt.Fprintf(w, "%s,%s,%.3f,%.6f,%.6f,%.3f,%.9f\n", t.Launch.Format(time.RFC3339), t.Arrival.Format(time.RFC3339), t.Arrival.Sub(t.Launch).Hours()/24, t.C3, t.VInfArrival, t.ΔR, t.ΔV); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PorkchopLevels returns n contour levels equally spaced between the extrema of the grid (excluded), which
+// ignores the NaN values.
+func PorkchopLevels(grid mat64.Matrix, n int) []float64 {
+	min, max := math.Inf(1), math.Inf(-1)
+	rows, cols := grid.Dims()
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			if v := grid.At(i, j); !math.IsNaN(v) && !math.IsInf(v, 0) {
+				min, max = math.Min(min, v), math.Max(max, v)
+			}
+		}
+	}
+	if min > max {
+		return nil
+	}
+	levels := make([]float64, n)
+	for k := range levels {
+		levels[k] = min + (max-min)*float64(k+1)/float64(n+1)
+	}
+	return levels
+}
+
+// WritePorkchopPNG renders the grid (e.g. the C3 of PorkchopGrids) as a filled contour plot with black contour
+// lines at the provided levels, with the columns on the X axis and the rows on the Y axis (first row at the bottom).
+// NaN values are left blank.
+func WritePorkchopPNG(w io.Writer, grid mat64.Matrix, levels []float64) error {
+	rows, cols := grid.Dims()
+	if rows < 2 || cols < 2 {
+		return errors.New("porkchop grid must have at least two rows and two columns")
+	}
+	if len(levels) == 0 {
+		return errors.New("no contour levels")
+	}
+	sorted := append([]float64(nil), levels...)
+	sort.Float64s(sorted)
+	width, height := (cols-1)*porkchopScale+1, (rows-1)*porkchopScale+1
+	// Contour band of each pixel, or -1 for NaN values.
+	bands := make([][]int, height)
+	for y := 0; y < height; y++ {
+		bands[y] = make([]int, width)
+		for x := 0; x < width; x++ {
+			v := bilinear(grid, float64(y)/porkchopScale, float64(x)/porkchopScale)
+			if math.IsNaN(v) {
+				bands[y][x] = -1
+			} else {
+				bands[y][x] = sort.SearchFloat64s(sorted, v)
+			}
+		}
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			band := bands[y][x]
+			var c color.Color
+			switch {
+			case band < 0:
+				c = color.White
+			case (x > 0 && bands[y][x-1] >= 0 && bands[y][x-1] != band) || (y > 0 && bands[y-1][x] >= 0 && bands[y-1][x] != band):
+				c = color.Black
+			default:
+				c = porkchopColor(float64(band) / float64(len(sorted)))
+			}
+			// The first row is at the bottom of the image.
+			img.Set(x, height-1-y, c)
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// bilinear returns the bilinear interpolation of the grid at the fractional row and column, or NaN if any of the
+// surrounding values is NaN.
+func bilinear(grid mat64.Matrix, row, col float64) float64 {
+	rows, cols := grid.Dims()
+	i, j := int(math.Min(math.Floor(row), float64(rows-2))), int(math.Min(math.Floor(col), float64(cols-2)))
+	di, dj := row-float64(i), col-float64(j)
+	return (1-di)*((1-dj)*grid.At(i, j)+dj*grid.At(i, j+1)) + di*((1-dj)*grid.At(i+1, j)+dj*grid.At(i+1, j+1))
+}
+
+// porkchopColor returns the color of the colormap from blue (0) to red (1).
+func porkchopColor(t float64) color.RGBA {
+	return color.RGBA{uint8(255 * t), uint8(255 * (1 - math.Abs(2*t-1))), uint8(255 * (1 - t)), 255}
+}
@@ -0,0 +1,169 @@
+package smd
+
+import (
+	"bytes"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestPorkchop(t *testing.T) {
+	epoch := time.Date(2020, 7, 20, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	p := NewPorkchop(Earth, Mars, epoch.Add(-40*day), epoch.Add(40*day), epoch.Add(150*day), epoch.Add(350*day), TTypeAuto)
+	p.LaunchStep = 10 * day
+	p.ArrivalStep = 10 * day
+	p.Ephemeris = hohmannEphemeris(epoch)
+	g := p.Grids()
+	if len(g.Launches) != 9 || len(g.Arrivals) != 21 {
+		t.Fatalf("%d launches and %d arrivals", len(g.Launches), len(g.Arrivals))
+	}
+	if r, c := g.C3.Dims(); r != 21 || c != 9 {
+		t.Fatalf("C3 grid is %dx%d", r, c)
+	}
+	// Compare one cell with the direct solution of the Lambert problem.
+	i, j := 12, 4
+	Ri, Vpi := p.Ephemeris(Earth, g.Launches[j])
+	Rf, Vpf := p.Ephemeris(Mars, g.Arrivals[i])
+	sol, err := LambertTransfer(Ri, Rf, g.Arrivals[i].Sub(g.Launches[j]), TTypeAuto, Sun)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vInfDep := []float64{sol.Vi[0] - Vpi[0], sol.Vi[1] - Vpi[1], sol.Vi[2] - Vpi[2]}
+	vInfArr := []float64{sol.Vf[0] - Vpf[0], sol.Vf[1] - Vpf[1], sol.Vf[2] - Vpf[2]}
+	if !floats.EqualWithinAbs(g.C3.At(i, j), math.Pow(Norm(vInfDep), 2), 1e-9) || !floats.EqualWithinAbs(g.VInfArrival.At(i, j), Norm(vInfArr), 1e-9) {
+		t.Fatalf("C3=%f v∞=%f", g.C3.At(i, j), g.VInfArrival.At(i, j))
+	}
+	if g.TOF.At(i, j) != g.Arrivals[i].Sub(g.Launches[j]).Hours()/24 {
+		t.Fatalf("TOF=%f days", g.TOF.At(i, j))
+	}
+	// The minimum C3 is close to that of the Hohmann transfer.
+	rE, rM := AU, 1.524*AU
+	vH := math.Sqrt(Sun.μ/rE) * (math.Sqrt(2*rM/(rE+rM)) - 1)
+	minC3 := math.Inf(1)
+	for i := range g.Arrivals {
+		for j := range g.Launches {
+			if c3 := g.C3.At(i, j); !math.IsNaN(c3) {
+				minC3 = math.Min(minC3, c3)
+			}
+		}
+	}
+	if minC3 < vH*vH || minC3 > 2*vH*vH {
+		t.Fatalf("minimum C3 of %f (Hohmann %f)", minC3, vH*vH)
+	}
+
+	levels := PorkchopLevels(g.C3, 5)
+	if len(levels) != 5 || levels[0] <= minC3 {
+		t.Fatalf("levels=%+v", levels)
+	}
+	var buf bytes.Buffer
+	if err := WritePorkchopPNG(&buf, g.C3, levels); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 8*porkchopScale+1 || b.Dy() != 20*porkchopScale+1 {
+		t.Fatalf("image is %dx%d", b.Dx(), b.Dy())
+	}
+	contour := false
+	for y := 0; y < img.Bounds().Dy() && !contour; y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			if r, g, b, _ := img.At(x, y).RGBA(); r == 0 && g == 0 && b == 0 {
+				contour = true
+				break
+			}
+		}
+	}
+	if !contour {
+		t.Fatal("no contour line rendered")
+	}
+}
+
+func TestPorkchopPropagate(t *testing.T) {
+	epoch := time.Date(2020, 7, 20, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	p := NewPorkchop(Earth, Mars, epoch.Add(-20*day), epoch.Add(20*day), epoch.Add(200*day), epoch.Add(300*day), TTypeAuto)
+	p.LaunchStep = 10 * day
+	p.ArrivalStep = 20 * day
+	p.Ephemeris = hohmannEphemeris(epoch)
+	g := p.Grids()
+	sc := NewEmptySC("transfer", 1000)
+	sc.Drag = 1.2 // Cr
+	conic := p.Propagate(g, 3, *sc, Perturbations{}, time.Hour)
+	if len(conic) != 3 {
+		t.Fatalf("%d transfers propagated", len(conic))
+	}
+	minC3, solved := math.Inf(1), 0
+	for i := range g.Arrivals {
+		for j := range g.Launches {
+			if c3 := g.C3.At(i, j); !math.IsNaN(c3) {
+				minC3 = math.Min(minC3, c3)
+				solved++
+			}
+		}
+	}
+	if conic[0].C3 != minC3 {
+		t.Fatalf("best transfer has a C3 of %f instead of %f", conic[0].C3, minC3)
+	}
+	for k, transfer := range conic {
+		if k > 0 && transfer.C3 < conic[k-1].C3 {
+			t.Fatal("transfers are not sorted by C3")
+		}
+		// Without perturbations, the propagation matches the Lambert solution.
+		if transfer.ΔR > 1 || transfer.ΔV > 1e-6 {
+			t.Fatalf("two body propagation differs from the conic: %+v", transfer)
+		}
+		if _, Vf := p.Ephemeris(Mars, transfer.Arrival); math.Abs(Norm([]float64{transfer.Conic.V()[0] - Vf[0], transfer.Conic.V()[1] - Vf[1], transfer.Conic.V()[2] - Vf[2]})-transfer.VInfArrival) > 1e-9 {
+			t.Fatal("conic state does not match the arrival v∞")
+		}
+	}
+	// The SRP pushes the spacecraft away from the conic arrival.
+	srp := p.Propagate(g, 2, *sc, Perturbations{Drag: true}, time.Hour)
+	for k, transfer := range srp {
+		if !transfer.Launch.Equal(conic[k].Launch) || !transfer.Arrival.Equal(conic[k].Arrival) {
+			t.Fatal("the same transfers should be propagated")
+		}
+		if transfer.ΔR < 100 {
+			t.Fatalf("SRP only moved the arrival by %f km", transfer.ΔR)
+		}
+	}
+	if all := p.Propagate(g, 1000, *sc, Perturbations{}, 12*time.Hour); len(all) != solved {
+		t.Fatalf("%d transfers propagated", len(all))
+	}
+	var buf bytes.Buffer
+	if err := WritePorkchopTransfers(&buf, srp); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Fatalf("%d lines in CSV", lines)
+	}
+}
+
+func TestPorkchopPNGErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePorkchopPNG(&buf, mat64.NewDense(1, 3, nil), []float64{1}); err == nil {
+		t.Fatal("a single row should fail")
+	}
+	if err := WritePorkchopPNG(&buf, mat64.NewDense(2, 2, nil), nil); err == nil {
+		t.Fatal("no levels should fail")
+	}
+	nan := mat64.NewDense(2, 2, []float64{math.NaN(), math.NaN(), math.NaN(), math.NaN()})
+	if PorkchopLevels(nan, 3) != nil {
+		t.Fatal("levels of a NaN grid should be nil")
+	}
+	// NaN values are blank.
+	if err := WritePorkchopPNG(&buf, nan, []float64{1}); err != nil {
+		t.Fatal(err)
+	}
+	img, _ := png.Decode(&buf)
+	if r, g, b, _ := img.At(0, 0).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff {
+		t.Fatal("NaN values should be blank")
+	}
+}
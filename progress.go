@@ -0,0 +1,128 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// WaypointProgress stores the progress of the spacecraft towards its active waypoint.
+type WaypointProgress struct {
+	Fraction float64            // Fraction of the way to the waypoint, in [0, 1]
+	Elements map[string]float64 // Fraction of the gap closed per targeted orbital element, e.g. "Δa"
+	Start    time.Time          // Epoch at which the waypoint became active
+	ETA      time.Time          // Estimated epoch at which the waypoint is cleared, zero if unknown
+}
+
+func (p WaypointProgress) String() string {
+	if p.ETA.IsZero() {
+		return fmt.Sprintf("%.1f%% (ETA unknown)", 100*p.Fraction)
+	}
+	return fmt.Sprintf("%.1f%% (ETA %s)", 100*p.Fraction, p.ETA.Format(time.RFC3339))
+}
+
+// ProgressWaypoint is a Waypoint which reports its progress, so that long transfers can be monitored from the
+// guidance of the states (cf. Mission.Subscribe and ProgressColumns).
+type ProgressWaypoint interface {
+	Waypoint
+	Progress(o Orbit, dt time.Time) WaypointProgress
+}
+
+// newWaypointProgress returns the progress with the provided fraction, clamped to [0, 1], and the ETA extrapolated
+// linearly from the progress made since the start.
+func newWaypointProgress(start, dt time.Time, fraction float64) WaypointProgress {
+	p := WaypointProgress{Fraction: math.Max(0, math.Min(1, fraction)), Start: start}
+	if elapsed := dt.Sub(start); p.Fraction > 0 && elapsed > 0 {
+		p.ETA = start.Add(time.Duration(float64(elapsed) / p.Fraction))
+	}
+	return p
+}
+
+// Progress implements the ProgressWaypoint interface.
+func (wp *Loiter) Progress(o Orbit, dt time.Time) WaypointProgress {
+	if !wp.startedLoitering {
+		return WaypointProgress{}
+	}
+	p := newWaypointProgress(wp.startDT, dt, dt.Sub(wp.startDT).Seconds()/wp.duration.Seconds())
+	p.ETA = wp.endDT
+	return p
+}
+
+// Progress implements the ProgressWaypoint interface.
+func (wp *FiniteBurn) Progress(o Orbit, dt time.Time) WaypointProgress {
+	p := newWaypointProgress(wp.startDT, dt, dt.Sub(wp.startDT).Seconds()/wp.endDT.Sub(wp.startDT).Seconds())
+	p.ETA = wp.endDT
+	return p
+}
+
+// Progress implements the ProgressWaypoint interface. The progress is that of the distance from the central body.
+func (wp *ReachDistance) Progress(o Orbit, dt time.Time) WaypointProgress {
+	if wp.startDT.IsZero() {
+		wp.startDT, wp.initial = dt, o.RNorm()
+	}
+	if wp.initial == wp.distance {
+		return newWaypointProgress(wp.startDT, dt, 1)
+	}
+	return newWaypointProgress(wp.startDT, dt, (o.RNorm()-wp.initial)/(wp.distance-wp.initial))
+}
+
+// Progress implements the ProgressWaypoint interface. The overall fraction is that of the targeted element which
+// is the least advanced.
+func (wp *OrbitTarget) Progress(o Orbit, dt time.Time) WaypointProgress {
+	if wp.startDT.IsZero() {
+		wp.startDT = dt
+	}
+	if !wp.ctrl.Initd {
+		return WaypointProgress{Start: wp.startDT}
+	}
+	elements := wp.ctrl.progress(o)
+	fraction := 1.0
+	for _, f := range elements {
+		fraction = math.Min(fraction, f)
+	}
+	p := newWaypointProgress(wp.startDT, dt, fraction)
+	p.Elements = elements
+	return p
+}
+
+// progress returns the fraction of the gap closed for each of the targeted orbital elements.
+func (cl *OptimalΔOrbit) progress(o Orbit) map[string]float64 {
+	a, e, i, Ω, ω, _, _, _, _ := o.Elements()
+	closed := func(oscul, init, target float64, angle bool) float64 {
+		gap, initGap := target-oscul, target-init
+		if angle {
+			gap, initGap = math.Remainder(gap, 2*math.Pi), math.Remainder(initGap, 2*math.Pi)
+		}
+		if initGap == 0 {
+			return 1
+		}
+		return math.Max(0, math.Min(1, 1-math.Abs(gap/initGap)))
+	}
+	elements := make(map[string]float64)
+	for _, ctrl := range cl.controls {
+		switch ctrl.Type() {
+		case OptiΔaCL:
+			elements["Δa"] = closed(a, cl.oInita, cl.oTgta, false)
+		case OptiΔeCL:
+			elements["Δe"] = closed(e, cl.oInite, cl.oTgte, false)
+		case OptiΔiCL:
+			elements["Δi"] = closed(i, cl.oIniti, cl.oTgti, true)
+		case OptiΔΩCL:
+			elements["ΔΩ"] = closed(Ω, cl.oInitΩ, cl.oTgtΩ, true)
+		case OptiΔωCL:
+			elements["Δω"] = closed(ω, cl.oInitω, cl.oTgtω, true)
+		}
+	}
+	return elements
+}
+
+// ProgressColumns are the columns of the progress towards the active waypoint, for use in ExportConfig.Columns.
+var ProgressColumns = CSVColumns{
+	{Name: "progress", Extract: func(st State) float64 { return st.SC.Guidance.Progress.Fraction }},
+	{Name: "eta", Text: func(st State) string {
+		if st.SC.Guidance.Progress.ETA.IsZero() {
+			return ""
+		}
+		return st.SC.Guidance.Progress.ETA.Format(time.RFC3339)
+	}},
+}
@@ -0,0 +1,56 @@
+package smd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestWaypointProgress(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Reaching a distance, reported in the guidance.
+	wp := NewReachDistance(8000, true, nil)
+	sc := NewSpacecraft("progress", 500, 100, NewUnlimitedEPS(), []EPThruster{NewGenericEP(1, 2000)}, false, []*Cargo{}, []Waypoint{wp})
+	sc.Accelerate(start, NewOrbitFromOE(7000, 0, 28.5, 0, 0, 0, Earth))
+	if p := sc.Guidance.Progress; p.Fraction != 0 || !p.Start.Equal(start) || !p.ETA.IsZero() {
+		t.Fatalf("initial progress %+v", p)
+	}
+	sc.Accelerate(start.Add(time.Hour), NewOrbitFromOE(7500, 0, 28.5, 0, 0, 0, Earth))
+	if p := sc.Guidance.Progress; !floats.EqualWithinAbs(p.Fraction, 0.5, 1e-9) || p.ETA.Sub(start.Add(2*time.Hour)) > time.Millisecond {
+		t.Fatalf("half way progress %s", p)
+	}
+	o := NewOrbitFromOE(7000, 0, 28.5, 0, 0, 0, Earth)
+	row := csvRow(State{DT: start, SC: *sc, Orbit: *o}, State{DT: start, SC: *sc, Orbit: *o}, ProgressColumns)
+	if !strings.HasSuffix(row, ",0.5,"+sc.Guidance.Progress.ETA.Format(time.RFC3339)) {
+		t.Fatalf("invalid row %s", row)
+	}
+
+	// Loitering ends at a known epoch.
+	loiter := NewLoiter(4*time.Hour, nil)
+	if p := loiter.Progress(*o, start); p.Fraction != 0 {
+		t.Fatalf("loiter progress before starting %s", p)
+	}
+	loiter.ThrustDirection(*o, start)
+	if p := loiter.Progress(*o, start.Add(time.Hour)); p.Fraction != 0.25 || !p.ETA.Equal(start.Add(4*time.Hour)) {
+		t.Fatalf("loiter progress %s", p)
+	}
+
+	// Orbit target progress per element.
+	target := NewOrbitFromOE(8000, 0.01, 30, 0, 0, 0, Earth)
+	otgt := NewOrbitTarget(*target, nil, Ruggiero, TimeOptimal, OptiΔaCL, OptiΔiCL)
+	oInit := NewOrbitFromOE(7000, 0.01, 28, 0, 0, 0, Earth)
+	ctrl, _ := otgt.ThrustDirection(*oInit, start)
+	if p := otgt.Progress(*oInit, start); p.Fraction != 0 || p.Elements != nil {
+		t.Fatalf("orbit target progress before initialization %+v", p)
+	}
+	ctrl.Control(*oInit)
+	p := otgt.Progress(*NewOrbitFromOE(7750, 0.01, 28.5, 0, 0, 0, Earth), start.Add(time.Hour))
+	if !floats.EqualWithinAbs(p.Elements["Δa"], 0.75, 1e-6) || !floats.EqualWithinAbs(p.Elements["Δi"], 0.25, 1e-6) || p.Fraction != p.Elements["Δi"] {
+		t.Fatalf("orbit target progress %+v", p)
+	}
+	if p.ETA.Sub(start.Add(4*time.Hour)) > time.Millisecond {
+		t.Fatalf("orbit target ETA %s", p.ETA)
+	}
+}
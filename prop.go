@@ -14,6 +14,9 @@ type ControlLaw uint8
 // ControlLawType defines the way to sum different Lyuapunov optimal CL
 type ControlLawType uint8
 
+// TargetingMode defines whether an orbit target favors the transfer duration or the propellant.
+type TargetingMode uint8
+
 type hohmannStatus uint8
 
 const (
@@ -79,6 +82,30 @@ func (meth ControlLawType) String() string {
 	panic("cannot stringify unknown control law summation method")
 }
 
+const (
+	// TimeOptimal thrusts continuously until the target orbit is reached.
+	TimeOptimal TargetingMode = iota
+	// FuelOptimal coasts when thrusting is not effective enough, e.g. changing the inclination far from the nodes.
+	FuelOptimal
+)
+
+const (
+	// defaultEffectivity is the default minimum effectivity to thrust in FuelOptimal mode.
+	defaultEffectivity = 0.5
+	// effectivitySamples is the number of true anomalies at which to search for the best thrusting location.
+	effectivitySamples = 36
+)
+
+func (mode TargetingMode) String() string {
+	switch mode {
+	case TimeOptimal:
+		return "time optimal"
+	case FuelOptimal:
+		return "fuel optimal"
+	}
+	panic("cannot stringify unknown targeting mode")
+}
+
 // ThrustControl defines a thrust control interface.
 type ThrustControl interface {
 	Control(o Orbit) []float64
@@ -253,6 +280,8 @@ type OptimalΔOrbit struct {
 	// local copy of the OEs of the inital and target orbits
 	oInita, oInite, oIniti, oInitΩ, oInitω, oInitν float64
 	oTgta, oTgte, oTgti, oTgtΩ, oTgtω, oTgtν       float64
+	Mode                                           TargetingMode
+	Effectivity                                    float64 // Minimum effectivity to thrust in FuelOptimal mode
 	GenericCL
 }
 
@@ -261,6 +290,7 @@ func NewOptimalΔOrbit(target Orbit, method ControlLawType, laws []ControlLaw) *
 	cl := OptimalΔOrbit{}
 	cl.cleared = false
 	cl.method = method
+	cl.Effectivity = defaultEffectivity
 	cl.oTgta, cl.oTgte, cl.oTgti, cl.oTgtΩ, cl.oTgtω, cl.oTgtν, _, _, _ = target.Elements()
 	if len(laws) == 0 {
 		laws = []ControlLaw{OptiΔaCL, OptiΔeCL, OptiΔiCL, OptiΔΩCL, OptiΔωCL}
@@ -277,6 +307,12 @@ func NewOptimalΔOrbit(target Orbit, method ControlLawType, laws []ControlLaw) *
 	return &cl
 }
 
+// SetTarget updates the target orbit, e.g. to track a moving target. The control laws selected on the first control
+// are kept.
+func (cl *OptimalΔOrbit) SetTarget(target Orbit) {
+	cl.oTgta, cl.oTgte, cl.oTgti, cl.oTgtΩ, cl.oTgtω, cl.oTgtν, _, _, _ = target.Elements()
+}
+
 func (cl *OptimalΔOrbit) String() string {
 	return "OptimalΔOrbit"
 }
@@ -311,6 +347,7 @@ func (cl *OptimalΔOrbit) Control(o Orbit) []float64 {
 
 	cl.cleared = true // Will be set to false if not yet converged.
 	a, e, i, Ω, ω, _, _, _, _ := o.Elements()
+	facts := make([]float64, len(cl.controls))
 	switch cl.method {
 	case Ruggiero:
 		factor := func(oscul, init, target, tol float64) float64 {
@@ -320,7 +357,7 @@ func (cl *OptimalΔOrbit) Control(o Orbit) []float64 {
 			return (target - oscul) / math.Abs(target-init)
 		}
 
-		for _, ctrl := range cl.controls {
+		for k, ctrl := range cl.controls {
 			var oscul, init, target, tol float64
 			switch ctrl.Type() {
 			case OptiΔaCL:
@@ -349,13 +386,9 @@ func (cl *OptimalΔOrbit) Control(o Orbit) []float64 {
 				target = cl.oTgtω
 				tol = angleε
 			}
-			// XXX: This summation may be wrong: |\sum x_i| != \sum |x_i|.
 			if fact := factor(oscul, init, target, tol); fact != 0 {
 				cl.cleared = false // We're not actually done.
-				tmpThrust := ctrl.Control(o)
-				for i := 0; i < 3; i++ {
-					thrust[i] += fact * tmpThrust[i]
-				}
+				facts[k] = fact
 			}
 		}
 	case Naasz:
@@ -363,7 +396,7 @@ func (cl *OptimalΔOrbit) Control(o Orbit) []float64 {
 		// works one way (because of the δO^2) per OE. So I added the sign function
 		// to fix it.
 		dε, eε, aε := o.epsilons()
-		for _, ctrl := range cl.controls {
+		for k, ctrl := range cl.controls {
 			var weight, δO float64
 			p := o.SemiParameter()
 			h := o.HNorm()
@@ -410,20 +443,99 @@ func (cl *OptimalΔOrbit) Control(o Orbit) []float64 {
 			}
 			if δO != 0 {
 				cl.cleared = false // We're not actually done.
-				tmpThrust := ctrl.Control(o)
-				fact := 0.5 * weight * math.Pow(δO, 2)
-				for i := 0; i < 3; i++ {
-					thrust[i] += fact * tmpThrust[i]
-				}
+				facts[k] = 0.5 * weight * math.Pow(δO, 2)
 			}
 		}
 	default:
 		panic(fmt.Errorf("control law sumation %+v not yet supported", cl.method))
 	}
 
+	if cl.Mode == FuelOptimal && !cl.cleared && cl.effectivity(o, facts) < cl.Effectivity {
+		return thrust // Coast until the control is efficient enough.
+	}
+	return cl.combine(o, facts)
+}
+
+// combine returns the unit thrust direction from the control laws weighted by the provided factors.
+func (cl *OptimalΔOrbit) combine(o Orbit, facts []float64) []float64 {
+	thrust := []float64{0, 0, 0}
+	for k, ctrl := range cl.controls {
+		if facts[k] == 0 {
+			continue
+		}
+		// XXX: This summation may be wrong: |\sum x_i| != \sum |x_i|.
+		tmpThrust := ctrl.Control(o)
+		for i := 0; i < 3; i++ {
+			thrust[i] += facts[k] * tmpThrust[i]
+		}
+	}
 	return Unit(thrust)
 }
 
+// rate returns the weighted rate of change of the targeted orbital elements when thrusting along the combined
+// control direction, per unit of acceleration.
+func (cl *OptimalΔOrbit) rate(o Orbit, facts []float64) (J float64) {
+	rates := gaussRates(o, cl.combine(o, facts))
+	for k, ctrl := range cl.controls {
+		if facts[k] == 0 {
+			continue
+		}
+		switch ctrl.Type() {
+		case OptiΔaCL:
+			J += facts[k] * rates[0]
+		case OptiΔeCL:
+			J += facts[k] * rates[1]
+		case OptiΔiCL:
+			J += facts[k] * rates[2]
+		case OptiΔΩCL:
+			J += facts[k] * rates[3]
+		case OptiΔωCL:
+			J += facts[k] * rates[4]
+		}
+	}
+	return
+}
+
+// effectivity returns the ratio between the current rate of change of the targeted orbital elements and the best
+// rate over the osculating orbit (Petropoulos' absolute effectivity).
+func (cl *OptimalΔOrbit) effectivity(o Orbit, facts []float64) float64 {
+	a, e, i, Ω, ω, _, _, _, _ := o.Elements()
+	best := 0.0
+	for k := 0; k < effectivitySamples; k++ {
+		ν := 360 * float64(k) / effectivitySamples
+		if J := cl.rate(*NewOrbitFromOE(a, e, Rad2deg(i), Rad2deg(Ω), Rad2deg(ω), ν, o.Origin), facts); J > best {
+			best = J
+		}
+	}
+	if best <= 0 {
+		return 1 // No better place to thrust.
+	}
+	return cl.rate(o, facts) / best
+}
+
+// gaussRates returns the rates of change of a, e, i, Ω and ω from the Gauss variational equations for the
+// provided acceleration in the RCN frame.
+func gaussRates(o Orbit, f []float64) []float64 {
+	a, e, i, _, ω, ν, _, _, _ := o.Elements()
+	p := o.SemiParameter()
+	h := o.HNorm()
+	r := Norm(o.R())
+	sinν, cosν := math.Sincos(ν)
+	sinu, cosu := math.Sincos(ω + ν)
+	rates := make([]float64, 5)
+	rates[0] = 2 * a * a / h * (e*sinν*f[0] + p/r*f[1])
+	rates[1] = (p*sinν*f[0] + ((p+r)*cosν+r*e)*f[1]) / h
+	rates[2] = r * cosu * f[2] / h
+	if sini := math.Sin(i); math.Abs(sini) > 1e-12 {
+		rates[3] = r * sinu * f[2] / (h * sini)
+		rates[4] = -r * sinu * math.Cos(i) * f[2] / (h * sini)
+	}
+	if e > eccentricityε {
+		rates[4] += (-p*cosν*f[0] + (p+r)*sinν*f[1]) / (h * e)
+	}
+	return rates
+}
+
 // HohmannΔv computes the Δv needed to go from one orbit to another, and performs an instantaneous Δv.
 type HohmannΔv struct {
 	target                      Orbit
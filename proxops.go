@@ -0,0 +1,294 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// proxOps is the common part of the proximity operations waypoints. The chief is propagated as a two body orbit from
+// its epoch and the guidance is computed in its Hill (RIC) frame. The impulses are IMPULSE actions, which are executed
+// at the end of the current step: the relative state is therefore predicted one step ahead (cf. stepTracker).
+type proxOps struct {
+	chief    Orbit
+	chiefDT  time.Time
+	Impulses []ScheduledManeuver // Executed impulses, in the RIC frame of the deputy
+	action   *WaypointAction
+	burn     *WaypointAction
+	cleared  bool
+	stepTracker
+}
+
+// Cleared implements the Waypoint interface.
+func (p *proxOps) Cleared() bool {
+	return p.cleared
+}
+
+// Action implements the Waypoint interface. It returns the pending impulse, or the action of the waypoint once
+// cleared.
+func (p *proxOps) Action() *WaypointAction {
+	if p.burn != nil {
+		burn := p.burn
+		p.burn = nil
+		return burn
+	}
+	if p.cleared {
+		return p.action
+	}
+	return nil
+}
+
+// TotalΔv returns the total Δv of the executed impulses in km/s.
+func (p *proxOps) TotalΔv() float64 {
+	return RendezvousPlan{Maneuvers: p.Impulses}.TotalΔv()
+}
+
+// relative returns the chief, the deputy and the relative state of the deputy in the Hill frame of the chief at the
+// end of the current step.
+func (p *proxOps) relative(o Orbit, dt time.Time) (chief, deputy Orbit, ρ, ρDot []float64) {
+	chief = *keplerPropagate(p.chief, dt.Add(p.step).Sub(p.chiefDT))
+	deputy = *keplerPropagate(o, p.step)
+	ρ, ρDot = RelativeState(chief, deputy)
+	return
+}
+
+// impulse sets the pending impulse from the provided Δv in the Hill frame of the chief.
+func (p *proxOps) impulse(chief, deputy Orbit, dt time.Time, Δv []float64) {
+	Δv = MxV33(deputy.RICDCM(), MxV33(chief.RICDCM().T(), Δv))
+	p.burn = &WaypointAction{Type: IMPULSE, Impulse: Δv}
+	p.Impulses = append(p.Impulses, ScheduledManeuver{dt.Add(p.step), NewManeuver(Δv[0], Δv[1], Δv[2])})
+}
+
+// target sets the impulse which brings the deputy to the provided relative position after the time of flight, as the
+// first burn of a two impulse rendezvous.
+func (p *proxOps) target(chief, deputy Orbit, dt time.Time, ρ, ρDot, ρf []float64, tof time.Duration) {
+	Δv1, _, err := TwoImpulseRendezvous(RelativeSTM(chief, tof), ρ, ρDot, ρf, []float64{0, 0, 0})
+	if err != nil {
+		panic(fmt.Errorf("proximity operations targeting failed @ %s: %s", dt, err))
+	}
+	p.impulse(chief, deputy, dt, Δv1)
+}
+
+// newProxOps checks that the chief orbit is elliptical.
+func newProxOps(chief Orbit, chiefDT time.Time, action *WaypointAction) proxOps {
+	if _, e, _, _, _, _, _, _, _ := chief.Elements(); e >= 1 {
+		panic("proximity operations require an elliptical chief orbit")
+	}
+	if action != nil && action.Type == IMPULSE {
+		panic("the IMPULSE action is reserved to the proximity operations guidance")
+	}
+	return proxOps{chief: chief, chiefDT: chiefDT, action: action}
+}
+
+// HillHold holds the deputy at a fixed position in the Hill frame of the chief with periodic correction pulses, each
+// of which targets the hold point one interval later. On the V-bar (in-track axis) the hold point is an equilibrium
+// of the relative motion and the pulses only correct the drift, whereas elsewhere (e.g. on the R-bar) the deputy
+// hops about the hold point.
+type HillHold struct {
+	proxOps
+	Position []float64     // Hold point in the Hill frame of the chief (km)
+	Interval time.Duration // Interval between two correction pulses
+	name     string
+	duration time.Duration
+	endDT    time.Time
+	nextDT   time.Time
+}
+
+// String implements the Waypoint interface.
+func (wp *HillHold) String() string {
+	return fmt.Sprintf("%s at %v km for %s", wp.name, wp.Position, wp.duration)
+}
+
+// ThrustDirection implements the Waypoint interface.
+func (wp *HillHold) ThrustDirection(o Orbit, dt time.Time) (ThrustControl, bool) {
+	coast := Coast{wp.name}
+	if !wp.tick(dt) {
+		return coast, false
+	}
+	burnDT := dt.Add(wp.step)
+	if wp.endDT.IsZero() {
+		wp.endDT = dt.Add(wp.duration)
+		wp.nextDT = burnDT
+	}
+	if !burnDT.Before(wp.endDT) {
+		wp.cleared = true
+		return coast, true
+	}
+	if burnDT.Before(wp.nextDT) {
+		return coast, false
+	}
+	wp.nextDT = burnDT.Add(wp.Interval)
+	chief, deputy, ρ, ρDot := wp.relative(o, dt)
+	wp.target(chief, deputy, dt, ρ, ρDot, wp.Position, wp.Interval)
+	return coast, true
+}
+
+// NewHillHold defines a new hold at the provided position (km) in the Hill frame of the chief, which is at the
+// provided orbit at chiefDT. The interval between the pulses must be shorter than half an orbit of the chief.
+func NewHillHold(chief Orbit, chiefDT time.Time, position []float64, duration, interval time.Duration, action *WaypointAction) *HillHold {
+	if len(position) != 3 {
+		panic("the hold point must be a 3D position")
+	}
+	if interval <= 0 || interval >= chief.Period()/2 {
+		panic(fmt.Errorf("the pulse interval must be in ]0;%s[", chief.Period()/2))
+	}
+	return &HillHold{proxOps: newProxOps(chief, chiefDT, action), Position: position, Interval: interval, name: "Hill frame hold", duration: duration}
+}
+
+// NewVBarHold defines a new hold on the V-bar, at the provided in-track distance (km) from the chief, positive ahead
+// of it.
+func NewVBarHold(chief Orbit, chiefDT time.Time, inTrack float64, duration, interval time.Duration, action *WaypointAction) *HillHold {
+	wp := NewHillHold(chief, chiefDT, []float64{0, inTrack, 0}, duration, interval, action)
+	wp.name = "V-bar hold"
+	return wp
+}
+
+// NewRBarHold defines a new hold on the R-bar, at the provided radial distance (km) from the chief, positive above
+// it.
+func NewRBarHold(chief Orbit, chiefDT time.Time, radial float64, duration, interval time.Duration, action *WaypointAction) *HillHold {
+	wp := NewHillHold(chief, chiefDT, []float64{radial, 0, 0}, duration, interval, action)
+	wp.name = "R-bar hold"
+	return wp
+}
+
+// FootballOrbit injects the deputy on a closed relative orbit (a 2:1 ellipse, or "football") centered on the V-bar,
+// for example to inspect the chief. The football goes through the position of the deputy at the injection: its
+// radial semi-axis is half of its in-track semi-axis, which is set by the offset of the deputy from the center. The
+// deputy is re-injected once per revolution to correct the drift due to the non linear or perturbed motion. Valid
+// for near circular chief orbits.
+type FootballOrbit struct {
+	proxOps
+	Center      float64 // In-track position of the center of the football (km)
+	revolutions int
+	injections  int
+	nextDT      time.Time
+}
+
+// String implements the Waypoint interface.
+func (wp *FootballOrbit) String() string {
+	return fmt.Sprintf("football orbit centered %.3f km in-track for %d revolutions", wp.Center, wp.revolutions)
+}
+
+// ThrustDirection implements the Waypoint interface.
+func (wp *FootballOrbit) ThrustDirection(o Orbit, dt time.Time) (ThrustControl, bool) {
+	coast := Coast{"football orbit"}
+	if !wp.tick(dt) {
+		return coast, false
+	}
+	burnDT := dt.Add(wp.step)
+	if wp.nextDT.IsZero() {
+		wp.nextDT = burnDT
+	}
+	if burnDT.Before(wp.nextDT) {
+		return coast, false
+	}
+	if wp.injections == wp.revolutions {
+		wp.cleared = true
+		return coast, true
+	}
+	chief, deputy, ρ, ρDot := wp.relative(o, dt)
+	a, _, _, _, _, _, _, _, _ := chief.Elements()
+	n := math.Sqrt(chief.Origin.μ / math.Pow(a, 3))
+	// The CW motion is closed if ẏ = -2nx, and centered at y - 2ẋ/n.
+	wp.impulse(chief, deputy, dt, []float64{n*(ρ[1]-wp.Center)/2 - ρDot[0], -2*n*ρ[0] - ρDot[1], 0})
+	wp.injections++
+	wp.nextDT = burnDT.Add(chief.Period())
+	return coast, true
+}
+
+// NewFootballOrbit defines a new football orbit about the chief, which is at the provided orbit at chiefDT, centered
+// at the provided in-track position (km), e.g. zero to circle the chief.
+func NewFootballOrbit(chief Orbit, chiefDT time.Time, center float64, revolutions int, action *WaypointAction) *FootballOrbit {
+	if revolutions <= 0 {
+		panic("the number of revolutions must be strictly positive")
+	}
+	return &FootballOrbit{proxOps: newProxOps(chief, chiefDT, action), Center: center, revolutions: revolutions}
+}
+
+// Glideslope approaches a position in the Hill frame of the chief along the straight line from the initial position
+// of the deputy, with the range rate decreasing linearly with the range (Hablani et al., 2002): ρ̇ = aρ + ρ̇T. The
+// approach is performed with equally spaced pulses, each of which targets the next point of the glideslope, and the
+// relative velocity is nulled at the arrival.
+type Glideslope struct {
+	proxOps
+	Target       []float64 // Final position in the Hill frame of the chief (km)
+	initialSpeed float64   // Initial closing speed (km/s)
+	finalSpeed   float64   // Final closing speed (km/s)
+	pulses       int
+	k            int
+	u            []float64 // Unit vector from the target to the initial position
+	ρ0, a        float64
+	startDT      time.Time
+	segment      time.Duration
+}
+
+// String implements the Waypoint interface.
+func (wp *Glideslope) String() string {
+	return fmt.Sprintf("glideslope to %v km in %d pulses", wp.Target, wp.pulses)
+}
+
+// Duration returns the duration of the approach, known once the approach started.
+func (wp *Glideslope) Duration() time.Duration {
+	return time.Duration(wp.pulses) * wp.segment
+}
+
+// rangeAt returns the range to the target after the provided time along the glideslope.
+func (wp *Glideslope) rangeAt(t time.Duration) float64 {
+	ρDotT := -wp.finalSpeed
+	return (wp.ρ0+ρDotT/wp.a)*math.Exp(wp.a*t.Seconds()) - ρDotT/wp.a
+}
+
+// ThrustDirection implements the Waypoint interface.
+func (wp *Glideslope) ThrustDirection(o Orbit, dt time.Time) (ThrustControl, bool) {
+	coast := Coast{"glideslope"}
+	if !wp.tick(dt) {
+		return coast, false
+	}
+	if wp.k > wp.pulses {
+		wp.cleared = true
+		return coast, true
+	}
+	burnDT := dt.Add(wp.step)
+	chief, deputy, ρ, ρDot := wp.relative(o, dt)
+	if wp.startDT.IsZero() {
+		δ := []float64{ρ[0] - wp.Target[0], ρ[1] - wp.Target[1], ρ[2] - wp.Target[2]}
+		wp.ρ0 = Norm(δ)
+		if wp.ρ0 == 0 {
+			panic("the deputy is already at the glideslope target")
+		}
+		wp.u = Unit(δ)
+		wp.a = (wp.finalSpeed - wp.initialSpeed) / wp.ρ0
+		wp.startDT = burnDT
+		wp.segment = time.Duration(math.Log(wp.finalSpeed/wp.initialSpeed) / wp.a / float64(wp.pulses) * float64(time.Second))
+	}
+	if burnDT.Before(wp.startDT.Add(time.Duration(wp.k) * wp.segment)) {
+		return coast, false
+	}
+	wp.k++
+	if wp.k > wp.pulses {
+		// Arrival: null the relative velocity, and clear on the next step so that the action is not overridden.
+		wp.impulse(chief, deputy, dt, []float64{-ρDot[0], -ρDot[1], -ρDot[2]})
+		return coast, true
+	}
+	next := wp.startDT.Add(time.Duration(wp.k) * wp.segment)
+	r := wp.rangeAt(next.Sub(wp.startDT))
+	ρf := []float64{wp.Target[0] + r*wp.u[0], wp.Target[1] + r*wp.u[1], wp.Target[2] + r*wp.u[2]}
+	wp.target(chief, deputy, dt, ρ, ρDot, ρf, next.Sub(burnDT))
+	return coast, true
+}
+
+// NewGlideslope defines a new glideslope approach of the provided position (km) in the Hill frame of the chief, which
+// is at the provided orbit at chiefDT. The closing speed decreases from the initial to the final speed (km/s). Each
+// segment between two pulses must be shorter than half an orbit of the chief.
+func NewGlideslope(chief Orbit, chiefDT time.Time, target []float64, initialSpeed, finalSpeed float64, pulses int, action *WaypointAction) *Glideslope {
+	if len(target) != 3 {
+		panic("the glideslope target must be a 3D position")
+	}
+	if finalSpeed <= 0 || initialSpeed <= finalSpeed {
+		panic("the closing speed must decrease and remain strictly positive")
+	}
+	if pulses <= 0 {
+		panic("the number of pulses must be strictly positive")
+	}
+	return &Glideslope{proxOps: newProxOps(chief, chiefDT, action), Target: target, initialSpeed: initialSpeed, finalSpeed: finalSpeed, pulses: pulses}
+}
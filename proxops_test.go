@@ -0,0 +1,97 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestProximityOperations(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	chief := NewOrbitFromOE(7000, 0, 51.6, 10, 20, 30, Earth)
+	deputy := DeputyOrbit(*chief, []float64{0, -1, 0}, []float64{0, 0, 0})
+	glideslope := NewGlideslope(*chief, start, []float64{0, -0.1, 0}, 1e-3, 1e-4, 5, nil)
+	hold := NewVBarHold(*chief, start, -0.1, 30*time.Minute, 10*time.Minute, nil)
+	football := NewFootballOrbit(*chief, start, 0, 1, nil)
+	sc := NewEmptySC("deputy", 100)
+	sc.WayPoints = []Waypoint{glideslope, hold, football}
+	end := start.Add(3 * time.Hour)
+	history := make([][]State, 1)
+	propagateAll([]*Mission{NewPreciseMission(sc, deputy, start, end, Perturbations{}, time.Second, false, ExportConfig{})}, history)
+	for _, wp := range sc.WayPoints {
+		if !wp.Cleared() {
+			t.Fatalf("%s not cleared", wp)
+		}
+	}
+	relative := func(state State) ([]float64, []float64) {
+		return RelativeState(*keplerPropagate(*chief, state.DT.Sub(start)), state.Orbit)
+	}
+	// Glideslope: ln(0.1)/a with a = -1e-3 s^-1 for the 0.9 km approach.
+	if len(glideslope.Impulses) != 6 || math.Abs(glideslope.Duration().Seconds()-math.Log(10)*1000) > 1 {
+		t.Fatalf("invalid glideslope: %d impulses over %s", len(glideslope.Impulses), glideslope.Duration())
+	}
+	arrival := glideslope.Impulses[len(glideslope.Impulses)-1].DT
+	holdStart, footballStart := hold.Impulses[0].DT, football.Impulses[0].DT
+	var maxHold, maxRadial, maxInTrack float64
+	for _, state := range history[0] {
+		ρ, ρDot := relative(state)
+		switch {
+		case state.DT.Equal(arrival.Add(time.Second)):
+			// The impulses are executed after the state of their epoch is published.
+			if dist := Norm([]float64{ρ[0], ρ[1] + 0.1, ρ[2]}); dist > 1e-3 || Norm(ρDot) > 1e-6 {
+				t.Fatalf("glideslope arrival %f km from the target with %f m/s", dist, Norm(ρDot)*1e3)
+			}
+		case !state.DT.Before(holdStart) && state.DT.Before(footballStart):
+			maxHold = math.Max(maxHold, Norm([]float64{ρ[0], ρ[1] + 0.1, ρ[2]}))
+		case !state.DT.Before(footballStart):
+			maxRadial = math.Max(maxRadial, math.Abs(ρ[0]))
+			maxInTrack = math.Max(maxInTrack, math.Abs(ρ[1]))
+		}
+	}
+	if maxHold > 1e-3 || hold.TotalΔv() > 1e-5 {
+		t.Fatalf("V-bar hold drifted by %f km with %f m/s", maxHold, hold.TotalΔv()*1e3)
+	}
+	// The football through the hold point circles the chief with semi-axes of 50 m and 100 m.
+	if math.Abs(maxRadial-0.05) > 2e-3 || math.Abs(maxInTrack-0.1) > 2e-3 {
+		t.Fatalf("invalid football: %f km radial, %f km in-track", maxRadial, maxInTrack)
+	}
+	if football.TotalΔv() <= 0 || len(football.Impulses) != 1 {
+		t.Fatalf("invalid football injection: %+v", football.Impulses)
+	}
+	assertPanic(t, func() {
+		NewRBarHold(*chief, start, 1, time.Hour, chief.Period(), nil)
+	})
+	assertPanic(t, func() {
+		NewGlideslope(*chief, start, []float64{0, 0, 0}, 1e-4, 1e-3, 5, nil)
+	})
+	assertPanic(t, func() {
+		NewFootballOrbit(*chief, start, 0, 1, &WaypointAction{Type: IMPULSE})
+	})
+}
+
+func TestRBarHold(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	chief := NewOrbitFromOE(7000, 0, 51.6, 10, 20, 30, Earth)
+	hold := NewRBarHold(*chief, start, 0.2, time.Hour, 2*time.Minute, nil)
+	sc := NewEmptySC("deputy", 100)
+	sc.WayPoints = []Waypoint{hold}
+	deputy := DeputyOrbit(*chief, []float64{0.2, 0, 0}, []float64{0, 0, 0})
+	history := make([][]State, 1)
+	propagateAll([]*Mission{NewPreciseMission(sc, deputy, start, start.Add(90*time.Minute), Perturbations{}, time.Second, false, ExportConfig{})}, history)
+	if !hold.Cleared() || len(hold.Impulses) != 30 {
+		t.Fatalf("R-bar hold: %d impulses", len(hold.Impulses))
+	}
+	// The R-bar is not an equilibrium: the deputy hops about the hold point.
+	if hold.TotalΔv() <= 0 {
+		t.Fatal("R-bar hold did not require any Δv")
+	}
+	for _, state := range history[0] {
+		if state.DT.After(start.Add(time.Hour)) {
+			break
+		}
+		ρ, _ := RelativeState(*keplerPropagate(*chief, state.DT.Sub(start)), state.Orbit)
+		if dist := Norm([]float64{ρ[0] - 0.2, ρ[1], ρ[2]}); dist > 0.01 {
+			t.Fatalf("deputy %f km from the R-bar hold point @ %s", dist, state.DT)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Quaternion is a unit quaternion with the scalar part first. As an attitude, it rotates the vectors from the body
+// frame to the inertial frame: v_inertial = q ⊗ v_body ⊗ q*.
+type Quaternion struct {
+	W, X, Y, Z float64
+}
+
+// NewQuaternion returns the quaternion of the rotation of the provided angle (radians) about the provided axis.
+func NewQuaternion(axis []float64, angle float64) Quaternion {
+	u := Unit(axis)
+	s, c := math.Sincos(angle / 2)
+	return Quaternion{c, s * u[0], s * u[1], s * u[2]}
+}
+
+// DCM2Quaternion returns the quaternion of the provided body to inertial DCM, with a non negative scalar part
+// (Shepperd's method).
+func DCM2Quaternion(dcm mat64.Matrix) Quaternion {
+	m00, m11, m22 := dcm.At(0, 0), dcm.At(1, 1), dcm.At(2, 2)
+	trace := m00 + m11 + m22
+	var q Quaternion
+	switch {
+	case trace >= m00 && trace >= m11 && trace >= m22:
+		s := 2 * math.Sqrt(1+trace)
+		q = Quaternion{s / 4, (dcm.At(2, 1) - dcm.At(1, 2)) / s, (dcm.At(0, 2) - dcm.At(2, 0)) / s, (dcm.At(1, 0) - dcm.At(0, 1)) / s}
+	case m00 >= m11 && m00 >= m22:
+		s := 2 * math.Sqrt(1+m00-m11-m22)
+		q = Quaternion{(dcm.At(2, 1) - dcm.At(1, 2)) / s, s / 4, (dcm.At(0, 1) + dcm.At(1, 0)) / s, (dcm.At(0, 2) + dcm.At(2, 0)) / s}
+	case m11 >= m22:
+		s := 2 * math.Sqrt(1+m11-m00-m22)
+		q = Quaternion{(dcm.At(0, 2) - dcm.At(2, 0)) / s, (dcm.At(0, 1) + dcm.At(1, 0)) / s, s / 4, (dcm.At(1, 2) + dcm.At(2, 1)) / s}
+	default:
+		s := 2 * math.Sqrt(1+m22-m00-m11)
+		q = Quaternion{(dcm.At(1, 0) - dcm.At(0, 1)) / s, (dcm.At(0, 2) + dcm.At(2, 0)) / s, (dcm.At(1, 2) + dcm.At(2, 1)) / s, s / 4}
+	}
+	if q.W < 0 {
+		q = q.Scale(-1)
+	}
+	return q.Unit()
+}
+
+// DCM returns the body to inertial DCM of this quaternion.
+func (q Quaternion) DCM() *mat64.Dense {
+	w, x, y, z := q.W, q.X, q.Y, q.Z
+	return mat64.NewDense(3, 3, []float64{
+		1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y),
+		2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x),
+		2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)})
+}
+
+// Mul returns the Hamilton product q ⊗ p, i.e. the rotation p followed by q.
+func (q Quaternion) Mul(p Quaternion) Quaternion {
+	return Quaternion{
+		q.W*p.W - q.X*p.X - q.Y*p.Y - q.Z*p.Z,
+		q.W*p.X + q.X*p.W + q.Y*p.Z - q.Z*p.Y,
+		q.W*p.Y - q.X*p.Z + q.Y*p.W + q.Z*p.X,
+		q.W*p.Z + q.X*p.Y - q.Y*p.X + q.Z*p.W}
+}
+
+// Conjugate returns the conjugate of this quaternion, i.e. the inverse rotation.
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{q.W, -q.X, -q.Y, -q.Z}
+}
+
+// Dot returns the inner product of both quaternions.
+func (q Quaternion) Dot(p Quaternion) float64 {
+	return q.W*p.W + q.X*p.X + q.Y*p.Y + q.Z*p.Z
+}
+
+// Scale returns this quaternion multiplied by the provided scalar.
+func (q Quaternion) Scale(s float64) Quaternion {
+	return Quaternion{s * q.W, s * q.X, s * q.Y, s * q.Z}
+}
+
+// Unit returns the normalized quaternion.
+func (q Quaternion) Unit() Quaternion {
+	return q.Scale(1 / math.Sqrt(q.Dot(q)))
+}
+
+// Rotate rotates the provided vector, i.e. from the body frame to the inertial frame for an attitude.
+func (q Quaternion) Rotate(v []float64) []float64 {
+	r := q.Mul(Quaternion{0, v[0], v[1], v[2]}).Mul(q.Conjugate())
+	return []float64{r.X, r.Y, r.Z}
+}
+
+// Angle returns the angle (radians, in [0, π]) of the rotation from this quaternion to the provided one.
+func (q Quaternion) Angle(p Quaternion) float64 {
+	return 2 * math.Acos(math.Min(1, math.Abs(q.Dot(p))))
+}
+
+func (q Quaternion) String() string {
+	return fmt.Sprintf("[%.9f %.9f %.9f %.9f]", q.W, q.X, q.Y, q.Z)
+}
@@ -0,0 +1,113 @@
+package smd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// TrackingMode defines the geometry of the radiometric measurements of a station.
+type TrackingMode uint8
+
+const (
+	// InstantaneousTracking measures the geometric range and range rate at the epoch of the measurement.
+	InstantaneousTracking TrackingMode = iota
+	// OneWayTracking measures the downlink of the spacecraft, whose range and Doppler include the offset between the
+	// clock of the station and that of the spacecraft.
+	OneWayTracking
+	// TwoWayTracking measures the round trip of an uplink transponded by the spacecraft: the range and range rate
+	// are the average of both legs and the clocks cancel out.
+	TwoWayTracking
+)
+
+func (m TrackingMode) String() string {
+	switch m {
+	case OneWayTracking:
+		return "one-way"
+	case TwoWayTracking:
+		return "two-way"
+	default:
+		return "instantaneous"
+	}
+}
+
+// Clock is a linear clock model.
+type Clock struct {
+	Epoch       time.Time
+	Bias, Drift float64 // s and s/s at the epoch
+}
+
+// Offset returns the offset of the clock (s) at the provided epoch.
+func (c *Clock) Offset(dt time.Time) float64 {
+	if c == nil {
+		return 0
+	}
+	return c.Bias + c.Drift*dt.Sub(c.Epoch).Seconds()
+}
+
+func (c *Clock) drift() float64 {
+	if c == nil {
+		return 0
+	}
+	return c.Drift
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("bias = %g s; drift = %g s/s @ %s", c.Bias, c.Drift, c.Epoch)
+}
+
+// lightTime returns the range and range rate (in km and km/s) of the state from the station whose body rotation
+// angle at the reception is θgst, accounting for the light time of each leg of the tracking mode, and the duration
+// of the downlink (s). The spacecraft is propagated on a two body orbit over the light time.
+func (s Station) lightTime(θgst float64, o Orbit) (ρ, ρDot, τ float64) {
+	leg := func(θ float64, R, V []float64) (float64, float64) {
+		rS, vS := ECEF2ECI(s.R, θ), ECEF2ECI(s.V, θ)
+		ρVec, vRel := make([]float64, 3), make([]float64, 3)
+		for i := 0; i < 3; i++ {
+			ρVec[i] = R[i] - rS[i]
+			vRel[i] = V[i] - vS[i]
+		}
+		ρ := Norm(ρVec)
+		return ρ, Dot(ρVec, vRel) / ρ
+	}
+	// Downlink: the signal received at θgst was transmitted τ seconds earlier.
+	var R, V []float64
+	for iter := 0; iter < 3; iter++ {
+		R, V = keplerPropagate(o, -time.Duration(τ*1e9)).RV()
+		ρ, ρDot = leg(θgst, R, V)
+		τ = ρ / SpeedOfLight
+	}
+	if s.Mode != TwoWayTracking {
+		return
+	}
+	// Uplink: the signal transponded at the transmission of the downlink left the station τu seconds before that.
+	τu := τ
+	var ρu, ρDotu float64
+	for iter := 0; iter < 3; iter++ {
+		ρu, ρDotu = leg(θgst-s.Planet.RotRate*(τ+τu), R, V)
+		τu = ρu / SpeedOfLight
+	}
+	return (ρ + ρu) / 2, (ρDot + ρDotu) / 2, τ
+}
+
+// clockOffsets returns the range and range rate (in km and km/s) due to the offset between the clock of the
+// station at the reception and that of the spacecraft at the transmission τ seconds earlier, for one-way tracking.
+func (s Station) clockOffsets(dt time.Time, τ float64) (clock, clockRate float64) {
+	if s.Mode != OneWayTracking {
+		return
+	}
+	clock = SpeedOfLight * (s.Clock.Offset(dt) - s.SpacecraftClock.Offset(dt.Add(-time.Duration(τ*1e9))))
+	clockRate = SpeedOfLight * (s.Clock.drift() - s.SpacecraftClock.drift())
+	return
+}
+
+// ClockHTilde returns the H tilde matrix of this measurement for the state augmented with [cδt, cδtDot], where
+// cδt is the offset (in km) between the clock of the station and that of the spacecraft, so that the filters may
+// estimate it. Only one-way measurements depend on the clocks.
+func (m Measurement) ClockHTilde() *mat64.Dense {
+	if m.Station.rowsH > 6 {
+		return NewAugmentedState(PositionBlock, VelocityBlock, CrBlock, ClockBlock).HTilde(m)
+	}
+	return NewAugmentedState(PositionBlock, VelocityBlock, ClockBlock).HTilde(m)
+}
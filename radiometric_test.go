@@ -0,0 +1,62 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestTrackingModes(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMultiMission(start, start.Add(12*time.Hour), time.Minute)
+	m.Add(NewEmptySC("meo", 0), NewOrbitFromOE(Earth.Radius+8000, 0.1, 50, 0, 0, 0, Earth), Perturbations{}, ExportConfig{})
+	m.Propagate()
+	st := NewStation("st", 0, 10, 40, -105, 0, 0)
+	instant := st.PassMeasurements(m.History[0])
+	if len(instant) == 0 {
+		t.Fatal("no measurements")
+	}
+	clock := &Clock{Epoch: start, Bias: 1e-6, Drift: 1e-9}
+	st.Clock, st.SpacecraftClock = clock, &Clock{Epoch: start}
+	st.Mode = OneWayTracking
+	oneWay := st.PassMeasurements(m.History[0])
+	st.Mode = TwoWayTracking
+	twoWay := st.PassMeasurements(m.History[0])
+	if len(oneWay) != len(instant) || len(twoWay) != len(instant) {
+		t.Fatal("the tracking mode changed the visibility")
+	}
+	for i, inst := range instant {
+		one, two := oneWay[i], twoWay[i]
+		if inst.LightTime != 0 || math.Abs(one.LightTime-one.TrueRange/SpeedOfLight) > 1e-9 {
+			t.Fatalf("light time %f s for a range of %f km", one.LightTime, one.TrueRange)
+		}
+		// The spacecraft and the station move by at most a few tens of meters during the light time.
+		vSC, vSt := Norm(inst.State.Orbit.V()), Norm(st.V)
+		if math.Abs(one.TrueRange-inst.TrueRange) > vSC*one.LightTime || math.Abs(two.TrueRange-inst.TrueRange) > (vSC+2*vSt)*one.LightTime {
+			t.Fatalf("ranges: instantaneous %f, one-way %f, two-way %f", inst.TrueRange, one.TrueRange, two.TrueRange)
+		}
+		if one.TrueRange == inst.TrueRange || two.TrueRange == one.TrueRange {
+			t.Fatal("the light time is not accounted for")
+		}
+		offset := clock.Offset(one.State.DT)
+		if !floats.EqualWithinAbs(one.ClockRange, SpeedOfLight*offset, 1e-9) || !floats.EqualWithinAbs(one.Range-one.TrueRange, SpeedOfLight*offset, 1e-9) {
+			t.Fatalf("one-way clock range %f km for an offset of %g s", one.ClockRange, offset)
+		}
+		if !floats.EqualWithinAbs(one.ClockRangeRate, SpeedOfLight*clock.Drift, 1e-12) {
+			t.Fatalf("one-way clock range rate %f km/s", one.ClockRangeRate)
+		}
+		if two.ClockRange != 0 || two.ClockRangeRate != 0 || two.Range != two.TrueRange || two.RangeRate != two.TrueRangeRate {
+			t.Fatal("the clocks affect two-way measurements")
+		}
+	}
+	H := oneWay[0].ClockHTilde()
+	if r, c := H.Dims(); r != 2 || c != 8 || H.At(0, 6) != 1 || H.At(1, 7) != 1 || H.At(0, 7) != 0 || H.At(1, 6) != 0 {
+		t.Fatal("invalid one-way clock H tilde")
+	}
+	H = twoWay[0].ClockHTilde()
+	if H.At(0, 6) != 0 || H.At(1, 7) != 0 || H.At(0, 0) != twoWay[0].HTilde().At(0, 0) {
+		t.Fatal("invalid two-way clock H tilde")
+	}
+}
@@ -0,0 +1,60 @@
+package smd
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	seedMu    sync.Mutex
+	seedsRand *rand.Rand // Generates the seeds of the new generators, or nil to seed them from the time
+	// Generators created at package initialization (e.g. the noise of the DSN stations), re-seeded by SetRandomSeed.
+	registeredRands []*rand.Rand
+)
+
+// SetRandomSeed makes all the random number generators created from now on derive from the provided seed, so that
+// the noise of the simulations is reproducible bit-for-bit: measurement noise of the stations, sensors, GNSS
+// receivers and inter-satellite links, and the orbit noise. Each new generator is seeded with the next seed of a
+// generator of the provided seed, so the objects must be created in the same order for the simulations to be
+// identical. The generators of the builtin objects, such as the DSN stations, are re-seeded first, so the seed must
+// be set before their use and not while they are measuring.
+func SetRandomSeed(seed int64) {
+	seedMu.Lock()
+	defer seedMu.Unlock()
+	seedsRand = rand.New(rand.NewSource(seed))
+	for _, rng := range registeredRands {
+		rng.Seed(seedsRand.Int63())
+	}
+}
+
+// UnsetRandomSeed seeds the random number generators created from now on, and re-seeds those of the builtin objects,
+// with the time (default).
+func UnsetRandomSeed() {
+	seedMu.Lock()
+	defer seedMu.Unlock()
+	seedsRand = nil
+	for _, rng := range registeredRands {
+		rng.Seed(time.Now().UnixNano())
+	}
+}
+
+// newRand returns a new random number generator, seeded from the random seed if set, or from the time otherwise.
+func newRand() *rand.Rand {
+	seedMu.Lock()
+	defer seedMu.Unlock()
+	if seedsRand == nil {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return rand.New(rand.NewSource(seedsRand.Int63()))
+}
+
+// newRegisteredRand returns a new random number generator which is re-seeded by SetRandomSeed, for the builtin
+// objects created before the seed can be set.
+func newRegisteredRand() *rand.Rand {
+	rng := newRand()
+	seedMu.Lock()
+	defer seedMu.Unlock()
+	registeredRands = append(registeredRands, rng)
+	return rng
+}
@@ -0,0 +1,62 @@
+package smd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestRandomSeed(t *testing.T) {
+	defer UnsetRandomSeed()
+	draw := func() []float64 {
+		st := NewStation("st", 0, 10, 40, -105, 1e-3, 1e-6)
+		isl := NewISL(100, 0, 1e-3, 1e-6)
+		noise := NewOrbitNoise(1, 1e-2, 1e-4)
+		var values []float64
+		for i := 0; i < 5; i++ {
+			values = append(values, st.RangeNoise.Rand(nil)[0], st.RangeRateNoise.Rand(nil)[0], isl.RangeNoise.Rand(nil)[0])
+			values = append(values, noise.Generate()...)
+		}
+		return values
+	}
+	SetRandomSeed(42)
+	first := draw()
+	SetRandomSeed(42)
+	if second := draw(); !floats.Equal(first, second) {
+		t.Fatal("seeded simulations differ")
+	}
+	SetRandomSeed(43)
+	if other := draw(); floats.Equal(first, other) {
+		t.Fatal("different seeds lead to the same noise")
+	}
+	// Generators created successively differ.
+	SetRandomSeed(42)
+	if a, b := newRand().Int63(), newRand().Int63(); a == b {
+		t.Fatal("successive generators are identical")
+	}
+}
+
+func TestRandomSeedDSN(t *testing.T) {
+	defer UnsetRandomSeed()
+	dt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	rSC := ECEF2ECI([]float64{-9000, -18000, 14000}, Earth.RotationAngle(dt))
+	state := State{DT: dt, Orbit: *NewOrbitFromRV(rSC, []float64{0, 1, 3}, Earth)}
+	measure := func() []float64 {
+		var values []float64
+		for i := 0; i < 3; i++ {
+			m := DSS14Goldstone.Measure(state)
+			values = append(values, m.Range, m.RangeRate)
+		}
+		return values
+	}
+	SetRandomSeed(42)
+	first := measure()
+	SetRandomSeed(42)
+	if second := measure(); !floats.Equal(first, second) {
+		t.Fatalf("seeded DSN measurements differ:\n%+v\n%+v", first, second)
+	}
+	if first[0] == first[2] {
+		t.Fatal("DSN measurements without noise")
+	}
+}
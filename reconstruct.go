@@ -0,0 +1,126 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// EstimatedTrajectory is the best estimate trajectory of an orbit determination, i.e. the reference trajectory
+// plus the (smoothed) state deviations, along with the covariance of each state if known.
+type EstimatedTrajectory struct {
+	States      []State
+	Covariances []*mat64.SymDense // Covariance of each state, nil if unknown
+}
+
+// ReconstructTrajectory returns the best estimate trajectory at the epoch of each snapshot (e.g. the smoothed
+// estimates of a filter run) by adding its deviation to the reference trajectory. The reference is interpolated
+// with cubic Hermite polynomials, so it may be at a different step than the snapshots, but must span them.
+// Both must be sorted by epoch.
+func ReconstructTrajectory(reference []State, snapshots []FilterSnapshot) (EstimatedTrajectory, error) {
+	if len(reference) == 0 || len(snapshots) == 0 {
+		return EstimatedTrajectory{}, errors.New("empty trajectory")
+	}
+	t := EstimatedTrajectory{States: make([]State, len(snapshots)), Covariances: make([]*mat64.SymDense, len(snapshots))}
+	for k, snapshot := range snapshots {
+		if k > 0 && !snapshot.DT.After(snapshots[k-1].DT) {
+			return EstimatedTrajectory{}, fmt.Errorf("snapshot %d at %s is not after the previous one", k, snapshot.DT)
+		}
+		R, V, ok := hermiteState(reference, snapshot.DT)
+		if !ok {
+			return EstimatedTrajectory{}, fmt.Errorf("snapshot %d at %s is outside of the reference trajectory", k, snapshot.DT)
+		}
+		if snapshot.Estimate != nil {
+			if snapshot.Estimate.Len() < 6 {
+				return EstimatedTrajectory{}, fmt.Errorf("snapshot %d deviation is of dimension %d", k, snapshot.Estimate.Len())
+			}
+			for i := 0; i < 3; i++ {
+				R[i] += snapshot.Estimate.At(i, 0)
+				V[i] += snapshot.Estimate.At(i+3, 0)
+			}
+		}
+		t.States[k] = State{DT: snapshot.DT, SC: reference[0].SC, Orbit: *NewOrbitFromRV(R, V, reference[0].Orbit.Origin)}
+		if snapshot.Covariance != nil {
+			t.Covariances[k] = copySym(snapshot.Covariance)
+		}
+	}
+	return t, nil
+}
+
+// At returns the best estimate state at the provided epoch, interpolated between the estimates, and false if
+// outside of the trajectory.
+func (t EstimatedTrajectory) At(dt time.Time) (State, bool) {
+	R, V, ok := hermiteState(t.States, dt)
+	if !ok {
+		return State{}, false
+	}
+	return State{DT: dt, SC: t.States[0].SC, Orbit: *NewOrbitFromRV(R, V, t.States[0].Orbit.Origin)}, true
+}
+
+// Resample returns the continuous best estimate trajectory at the provided step from its first to its last epoch,
+// e.g. to export it at a regular step although the measurements are not.
+func (t EstimatedTrajectory) Resample(step time.Duration) []State {
+	if len(t.States) == 0 || step <= 0 {
+		return nil
+	}
+	first, last := t.States[0].DT, t.States[len(t.States)-1].DT
+	var states []State
+	for dt := first; !dt.After(last); dt = dt.Add(step) {
+		state, _ := t.At(dt)
+		states = append(states, state)
+	}
+	return states
+}
+
+// InitialState returns the first best estimate state, to be used as the new reference initial state of the next
+// orbit determination iteration.
+func (t EstimatedTrajectory) InitialState() State {
+	return t.States[0]
+}
+
+// Export exports the best estimate trajectory resampled at the provided step in the formats of the configuration,
+// as a propagated trajectory would be.
+func (t EstimatedTrajectory) Export(conf ExportConfig, step time.Duration) {
+	if conf.IsUseless() {
+		return
+	}
+	stateChan := make(chan (State), 1)
+	done := make(chan struct{})
+	go func() {
+		StreamStates(conf, stateChan)
+		close(done)
+	}()
+	for _, state := range t.Resample(step) {
+		stateChan <- state
+	}
+	close(stateChan)
+	<-done
+}
+
+// WriteOEM writes the states as a CCSDS Orbit Ephemeris Message in KVN format, which ReadOEM can read back. The
+// states must be sorted by epoch and about the same body.
+func WriteOEM(w io.Writer, objectName string, states []State) error {
+	if len(states) == 0 {
+		return errors.New("empty trajectory")
+	}
+	const epochFmt = "2006-01-02T15:04:05.000"
+	first, last := states[0], states[len(states)-1]
+	header := fmt.Sprintf("CCSDS_OEM_VERS = 2.0\nCREATION_DATE = %s\nORIGINATOR = smd\n\nMETA_START\nOBJECT_NAME = %s\nOBJECT_ID = %s\nCENTER_NAME = %s\nREF_FRAME = EME2000\nTIME_SYSTEM = UTC\nSTART_TIME = %s\nSTOP_TIME = %s\nMETA_STOP\n\n", time.Now().UTC().Format(epochFmt), objectName, objectName, strings.ToUpper(first.Orbit.Origin.Name), first.DT.UTC().Format(epochFmt), last.DT.UTC().Format(epochFmt))
+	if _, err := fmt.Fprint(w, header); err != nil {
+		return err
+	}
+	for _, state := range states {
+		if !state.Orbit.Origin.Equals(first.Orbit.Origin) {
+			return fmt.Errorf("state at %s about %s but trajectory about %s", state.DT, state.Orbit.Origin.Name, first.Orbit.Origin.Name)
+		}
+		R, V := state.Orbit.RV()
+		if _, err := fmt.Fprintf(w, "%s %.6f %.6f %.6f %.9f %.9f %.9f\n", state.DT.UTC().Format(epochFmt), R[0], R[1], R[2], V[0], V[1], V[2]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
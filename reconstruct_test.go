@@ -0,0 +1,74 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestReconstructTrajectory(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	o := NewOrbitFromOE(7000, 0.01, 28.5, 10, 20, 30, Earth)
+	sc := NewEmptySC("od", 0)
+	var reference []State
+	for dt := time.Duration(0); dt <= time.Hour; dt += 30 * time.Second {
+		reference = append(reference, State{DT: start.Add(dt), SC: *sc, Orbit: *keplerPropagate(*o, dt)})
+	}
+	// Smoothed deviations at irregular epochs, between the reference states.
+	var snapshots []FilterSnapshot
+	for dt := 95 * time.Second; dt < time.Hour; dt += 7 * time.Minute {
+		snapshots = append(snapshots, FilterSnapshot{DT: start.Add(dt), Estimate: mat64.NewVector(6, []float64{0.1, -0.2, 0.05, 1e-4, 0, -2e-4}), Covariance: mat64.NewSymDense(6, nil)})
+	}
+	traj, err := ReconstructTrajectory(reference, snapshots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traj.States) != len(snapshots) || len(traj.Covariances) != len(snapshots) || traj.Covariances[0] == nil {
+		t.Fatalf("%d states and %d covariances for %d snapshots", len(traj.States), len(traj.Covariances), len(snapshots))
+	}
+	for k, state := range traj.States {
+		R, V := state.Orbit.RV()
+		exp := keplerPropagate(*o, state.DT.Sub(start))
+		Rexp, Vexp := exp.RV()
+		for i := 0; i < 3; i++ {
+			if math.Abs(R[i]-Rexp[i]-snapshots[k].Estimate.At(i, 0)) > 1e-4 || math.Abs(V[i]-Vexp[i]-snapshots[k].Estimate.At(i+3, 0)) > 1e-5 {
+				t.Fatalf("state %d: R=%+v V=%+v instead of %+v %+v plus the deviation", k, R, V, Rexp, Vexp)
+			}
+		}
+	}
+	if traj.InitialState().DT != start.Add(95*time.Second) {
+		t.Fatalf("initial state at %s", traj.InitialState().DT)
+	}
+	resampled := traj.Resample(time.Minute)
+	if len(resampled) != 57 || resampled[0].SC.Name != "od" {
+		t.Fatalf("%d resampled states", len(resampled))
+	}
+	// The OEM export may be read back as a reference.
+	var oem bytes.Buffer
+	if err = WriteOEM(&oem, "od", resampled); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadOEM(&oem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != len(resampled) || !read[0].DT.Equal(resampled[0].DT) || !read[0].Orbit.Origin.Equals(Earth) {
+		t.Fatalf("read %d states from the OEM", len(read))
+	}
+	if c, err := CompareEphemerides(resampled, read); err != nil || c.MaxPosition > 1e-5 || c.MaxVelocity > 1e-8 {
+		t.Fatalf("OEM round trip: %s (%v)", c, err)
+	}
+	// Errors
+	if _, err = ReconstructTrajectory(reference, []FilterSnapshot{{DT: start.Add(-time.Minute)}}); err == nil {
+		t.Fatal("snapshot outside of the reference accepted")
+	}
+	if _, err = ReconstructTrajectory(reference, []FilterSnapshot{snapshots[1], snapshots[0]}); err == nil {
+		t.Fatal("unsorted snapshots accepted")
+	}
+	if err = WriteOEM(&oem, "od", nil); err == nil {
+		t.Fatal("empty OEM accepted")
+	}
+}
@@ -0,0 +1,238 @@
+package smd
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/ChristopherRabotin/ode"
+	"github.com/gonum/matrix/mat64"
+)
+
+// RICDCM returns the DCM from the inertial frame to the RIC (radial, in-track, cross-track) frame of this orbit.
+func (o Orbit) RICDCM() *mat64.Dense {
+	rUnit := Unit(o.R())
+	cUnit := Unit(o.H())
+	iUnit := Cross(cUnit, rUnit)
+	return mat64.NewDense(3, 3, []float64{
+		rUnit[0], rUnit[1], rUnit[2],
+		iUnit[0], iUnit[1], iUnit[2],
+		cUnit[0], cUnit[1], cUnit[2]})
+}
+
+// ricAngularVelocity returns the angular velocity of the RIC frame of this orbit, expressed in that frame.
+func (o Orbit) ricAngularVelocity() []float64 {
+	return []float64{0, 0, o.HNorm() / math.Pow(o.RNorm(), 2)}
+}
+
+// RelativeState returns the position (km) and velocity (km/s) of the deputy in the RIC frame of the chief.
+// The velocity is the one seen by an observer in the rotating RIC frame.
+func RelativeState(chief, deputy Orbit) (ρ, ρDot []float64) {
+	dcm := chief.RICDCM()
+	rD, vD := deputy.RV()
+	rC, vC := chief.RV()
+	ρ = MxV33(dcm, []float64{rD[0] - rC[0], rD[1] - rC[1], rD[2] - rC[2]})
+	vRel := MxV33(dcm, []float64{vD[0] - vC[0], vD[1] - vC[1], vD[2] - vC[2]})
+	ωxρ := Cross(chief.ricAngularVelocity(), ρ)
+	ρDot = []float64{vRel[0] - ωxρ[0], vRel[1] - ωxρ[1], vRel[2] - ωxρ[2]}
+	return
+}
+
+// DeputyOrbit returns the deputy orbit from the chief and the relative RIC state of the deputy.
+func DeputyOrbit(chief Orbit, ρ, ρDot []float64) *Orbit {
+	dcmT := chief.RICDCM().T()
+	ωxρ := Cross(chief.ricAngularVelocity(), ρ)
+	rC, vC := chief.RV()
+	R := MxV33(dcmT, ρ)
+	V := MxV33(dcmT, []float64{ρDot[0] + ωxρ[0], ρDot[1] + ωxρ[1], ρDot[2] + ωxρ[2]})
+	for i := 0; i < 3; i++ {
+		R[i] += rC[i]
+		V[i] += vC[i]
+	}
+	return NewOrbitFromRV(R, V, chief.Origin)
+}
+
+// CWSTM returns the Clohessy-Wiltshire state transition matrix of the RIC relative state for the provided
+// circular chief orbit.
+func CWSTM(chief Orbit, dt time.Duration) *mat64.Dense {
+	a, _, _, _, _, _, _, _, _ := chief.Elements()
+	n := math.Sqrt(chief.Origin.μ / math.Pow(a, 3))
+	t := dt.Seconds()
+	s, c := math.Sincos(n * t)
+	return mat64.NewDense(6, 6, []float64{
+		4 - 3*c, 0, 0, s / n, 2 * (1 - c) / n, 0,
+		6 * (s - n*t), 1, 0, -2 * (1 - c) / n, (4*s - 3*n*t) / n, 0,
+		0, 0, c, 0, 0, s / n,
+		3 * n * s, 0, 0, c, 2 * s, 0,
+		-6 * n * (1 - c), 0, 0, -2 * s, 4*c - 3, 0,
+		0, 0, -n * s, 0, 0, c})
+}
+
+// THSTM returns the Tschauner-Hempel state transition matrix of the RIC relative state for the provided
+// (possibly eccentric) chief orbit. The linearized equations of relative motion are integrated alongside the chief.
+func THSTM(chief Orbit, dt time.Duration) *mat64.Dense {
+	if dt == 0 {
+		return DenseIdentity(6)
+	}
+	steps := math.Ceil(math.Abs(dt.Seconds()) / math.Min(StepSize.Seconds(), chief.Period().Seconds()/1000))
+	prop := newTHPropagator(chief, dt.Seconds()/steps, int(steps))
+	prop.solve() // Blocking.
+	return mat64.NewDense(6, 6, append([]float64{}, prop.state[6:]...))
+}
+
+// RelativeSTM returns the CW STM for circular chief orbits, and the TH STM otherwise.
+func RelativeSTM(chief Orbit, dt time.Duration) *mat64.Dense {
+	_, e, _, _, _, _, _, _, _ := chief.Elements()
+	if e < eccentricityε {
+		return CWSTM(chief, dt)
+	}
+	return THSTM(chief, dt)
+}
+
+// KeplerRelativeSTM returns the state transition matrix of the RIC relative state for the provided chief orbit of any
+// eccentricity, from the closed form two body STM of the chief (cf. KeplerSTM), i.e. without integration.
+func KeplerRelativeSTM(chief Orbit, dt time.Duration) *mat64.Dense {
+	var tmp, Φ mat64.Dense
+	tmp.Mul(KeplerSTM(chief, dt), chief.relativeTransform(true))
+	Φ.Mul(keplerPropagate(chief, dt).relativeTransform(false), &tmp)
+	return &Φ
+}
+
+// relativeTransform returns the transformation from the inertial state error to the RIC relative state of
+// RelativeState, with the velocity seen in the rotating frame, or its inverse.
+func (o Orbit) relativeTransform(inverse bool) *mat64.Dense {
+	var rot, coupling mat64.Dense
+	ω := o.ricAngularVelocity()[2]
+	if inverse {
+		// The inertial velocity error is dcmᵀ(ρDot + ω×ρ).
+		rot.Clone(o.RICDCM().T())
+		coupling.Mul(&rot, mat64.NewDense(3, 3, []float64{0, -ω, 0, ω, 0, 0, 0, 0, 0}))
+	} else {
+		// The relative velocity is dcm δv - ω×ρ.
+		rot.Clone(o.RICDCM())
+		coupling.Mul(mat64.NewDense(3, 3, []float64{0, ω, 0, -ω, 0, 0, 0, 0, 0}), &rot)
+	}
+	T := mat64.NewDense(6, 6, nil)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			T.Set(i, j, rot.At(i, j))
+			T.Set(i+3, j+3, rot.At(i, j))
+			T.Set(i+3, j, coupling.At(i, j))
+		}
+	}
+	return T
+}
+
+// PropagateRelative propagates the RIC relative state with the provided state transition matrix.
+func PropagateRelative(Φ *mat64.Dense, ρ, ρDot []float64) (ρf, ρDotf []float64) {
+	var xf mat64.Vector
+	xf.MulVec(Φ, mat64.NewVector(6, []float64{ρ[0], ρ[1], ρ[2], ρDot[0], ρDot[1], ρDot[2]}))
+	return []float64{xf.At(0, 0), xf.At(1, 0), xf.At(2, 0)}, []float64{xf.At(3, 0), xf.At(4, 0), xf.At(5, 0)}
+}
+
+// TwoImpulseRendezvous returns the two RIC impulses (km/s) needed to go from the initial relative state to the
+// final relative state, where Φ is the relative STM over the transfer time (e.g. from CWSTM or THSTM).
+func TwoImpulseRendezvous(Φ *mat64.Dense, ρ0, ρDot0, ρf, ρDotf []float64) (Δv1, Δv2 []float64, err error) {
+	Φrr := Φ.View(0, 0, 3, 3)
+	Φrv := Φ.View(0, 3, 3, 3)
+	Φvr := Φ.View(3, 0, 3, 3)
+	Φvv := Φ.View(3, 3, 3, 3)
+	var ΦrvInv mat64.Dense
+	if ierr := ΦrvInv.Inverse(Φrv); ierr != nil {
+		return nil, nil, errors.New("rendezvous transfer time is singular (e.g. multiple of the orbital period)")
+	}
+	var rr mat64.Vector
+	rr.MulVec(Φrr, mat64.NewVector(3, ρ0))
+	var v0 mat64.Vector
+	v0.MulVec(&ΦrvInv, mat64.NewVector(3, []float64{ρf[0] - rr.At(0, 0), ρf[1] - rr.At(1, 0), ρf[2] - rr.At(2, 0)}))
+	var vrArr, vvArr mat64.Vector
+	vrArr.MulVec(Φvr, mat64.NewVector(3, ρ0))
+	vvArr.MulVec(Φvv, &v0)
+	Δv1 = make([]float64, 3)
+	Δv2 = make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		Δv1[i] = v0.At(i, 0) - ρDot0[i]
+		Δv2[i] = ρDotf[i] - vrArr.At(i, 0) - vvArr.At(i, 0)
+	}
+	return Δv1, Δv2, nil
+}
+
+// thPropagator is an ode.Integrable of the chief inertial state and of the relative STM.
+type thPropagator struct {
+	μ         float64
+	state     []float64
+	step      float64
+	remaining int
+}
+
+func newTHPropagator(chief Orbit, step float64, steps int) *thPropagator {
+	s := make([]float64, 42)
+	R, V := chief.RV()
+	copy(s, R)
+	copy(s[3:], V)
+	for i := 0; i < 6; i++ {
+		s[6+i*6+i] = 1
+	}
+	return &thPropagator{chief.Origin.μ, s, step, steps}
+}
+
+// solve integrates the propagator with a positive step, since the backward propagations integrate the time reversed
+// equations of motion (cf. Func).
+func (p *thPropagator) solve() {
+	ode.NewRK4(0, math.Abs(p.step), p).Solve()
+}
+
+// GetState implements the ode.Integrable interface.
+func (p *thPropagator) GetState() []float64 {
+	return p.state
+}
+
+// SetState implements the ode.Integrable interface.
+func (p *thPropagator) SetState(t float64, s []float64) {
+	p.state = s
+	p.remaining--
+}
+
+// Stop implements the ode.Integrable interface.
+func (p *thPropagator) Stop(t float64) bool {
+	return p.remaining <= 0
+}
+
+// Func implements the ode.Integrable interface.
+func (p *thPropagator) Func(t float64, f []float64) []float64 {
+	fDot := make([]float64, 42)
+	R := f[0:3]
+	V := f[3:6]
+	r := Norm(R)
+	r3 := math.Pow(r, 3)
+	for i := 0; i < 3; i++ {
+		fDot[i] = V[i]
+		fDot[3+i] = -p.μ * R[i] / r3
+	}
+	// Linearized equations of relative motion about an eccentric chief.
+	νDot := Norm(Cross(R, V)) / (r * r)
+	rDot := Dot(R, V) / r
+	νDDot := -2 * rDot * νDot / r
+	k := p.μ / r3
+	A := mat64.NewDense(6, 6, []float64{
+		0, 0, 0, 1, 0, 0,
+		0, 0, 0, 0, 1, 0,
+		0, 0, 0, 0, 0, 1,
+		νDot*νDot + 2*k, νDDot, 0, 0, 2 * νDot, 0,
+		-νDDot, νDot*νDot - k, 0, -2 * νDot, 0, 0,
+		0, 0, -k, 0, 0, 0})
+	var ΦDot mat64.Dense
+	ΦDot.Mul(A, mat64.NewDense(6, 6, f[6:]))
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 6; j++ {
+			fDot[6+i*6+j] = ΦDot.At(i, j)
+		}
+	}
+	if p.step < 0 {
+		// Time reversed equations of motion of the backward propagations.
+		for i := range fDot {
+			fDot[i] = -fDot[i]
+		}
+	}
+	return fDot
+}
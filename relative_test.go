@@ -0,0 +1,77 @@
+package smd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestRelativeStateRoundTrip(t *testing.T) {
+	chief := NewOrbitFromOE(7000, 0.1, 30, 40, 50, 60, Earth)
+	ρ := []float64{1.2, -3.4, 0.5}
+	ρDot := []float64{0.001, -0.002, 0.0005}
+	deputy := DeputyOrbit(*chief, ρ, ρDot)
+	ρ1, ρDot1 := RelativeState(*chief, *deputy)
+	if !floats.EqualApprox(ρ, ρ1, 1e-9) || !floats.EqualApprox(ρDot, ρDot1, 1e-12) {
+		t.Fatalf("round trip failed:\n%+v %+v\n%+v %+v", ρ, ρDot, ρ1, ρDot1)
+	}
+	// A deputy ahead of the chief on the same orbit must be in-track.
+	ahead := NewOrbitFromOE(7000, 0, 30, 40, 50, 60.01, Earth)
+	circ := NewOrbitFromOE(7000, 0, 30, 40, 50, 60, Earth)
+	ρ2, _ := RelativeState(*circ, *ahead)
+	if ρ2[1] <= 0 || ρ2[1] < 1e3*ρ2[0]*-1 || floats.EqualWithinAbs(ρ2[1], 0, 1) {
+		t.Fatalf("deputy should be ahead: %+v", ρ2)
+	}
+}
+
+func TestRelativeCWAndTH(t *testing.T) {
+	dt := 30 * time.Minute
+	for _, e := range []float64{0, 0.2} {
+		chief := NewOrbitFromOE(8000, e, 45, 10, 20, 30, Earth)
+		ρ := []float64{0.05, 0.1, -0.02}
+		ρDot := []float64{0.00002, -0.00004, 0.00001}
+		deputy := DeputyOrbit(*chief, ρ, ρDot)
+		chiefF := keplerPropagate(*chief, dt)
+		deputyF := keplerPropagate(*deputy, dt)
+		ρExp, ρDotExp := RelativeState(*chiefF, *deputyF)
+		ρF, ρDotF := PropagateRelative(THSTM(*chief, dt), ρ, ρDot)
+		if !floats.EqualApprox(ρF, ρExp, 1e-4) || !floats.EqualApprox(ρDotF, ρDotExp, 1e-7) {
+			t.Fatalf("e=%f TH propagation failed:\n%+v %+v\n%+v %+v", e, ρF, ρDotF, ρExp, ρDotExp)
+		}
+		ρK, ρDotK := PropagateRelative(KeplerRelativeSTM(*chief, dt), ρ, ρDot)
+		if !floats.EqualApprox(ρK, ρExp, 1e-4) || !floats.EqualApprox(ρDotK, ρDotExp, 1e-7) {
+			t.Fatalf("e=%f closed form propagation failed:\n%+v %+v\n%+v %+v", e, ρK, ρDotK, ρExp, ρDotExp)
+		}
+		if e == 0 {
+			ρCW, ρDotCW := PropagateRelative(RelativeSTM(*chief, dt), ρ, ρDot)
+			if !floats.EqualApprox(ρCW, ρExp, 1e-4) || !floats.EqualApprox(ρDotCW, ρDotExp, 1e-7) {
+				t.Fatalf("CW propagation failed:\n%+v %+v\n%+v %+v", ρCW, ρDotCW, ρExp, ρDotExp)
+			}
+		}
+		// Back to the initial relative state.
+		ρB, ρDotB := PropagateRelative(RelativeSTM(*chiefF, -dt), ρExp, ρDotExp)
+		if !floats.EqualApprox(ρB, ρ, 1e-4) || !floats.EqualApprox(ρDotB, ρDot, 1e-7) {
+			t.Fatalf("e=%f backward propagation failed:\n%+v %+v\n%+v %+v", e, ρB, ρDotB, ρ, ρDot)
+		}
+	}
+}
+
+func TestRelativeRendezvous(t *testing.T) {
+	chief := NewOrbitFromOE(7000, 0, 51.6, 10, 20, 30, Earth)
+	dt := 40 * time.Minute
+	ρ0 := []float64{-1, -10, 0.5}
+	ρDot0 := []float64{0, 0.001, 0}
+	Φ := CWSTM(*chief, dt)
+	Δv1, Δv2, err := TwoImpulseRendezvous(Φ, ρ0, ρDot0, []float64{0, 0, 0}, []float64{0, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ρf, ρDotf := PropagateRelative(Φ, ρ0, []float64{ρDot0[0] + Δv1[0], ρDot0[1] + Δv1[1], ρDot0[2] + Δv1[2]})
+	if !floats.EqualApprox(ρf, []float64{0, 0, 0}, 1e-9) {
+		t.Fatalf("rendezvous did not reach the chief: %+v", ρf)
+	}
+	if !floats.EqualApprox([]float64{ρDotf[0] + Δv2[0], ρDotf[1] + Δv2[1], ρDotf[2] + Δv2[2]}, []float64{0, 0, 0}, 1e-12) {
+		t.Fatal("second impulse does not null the relative velocity")
+	}
+}
@@ -0,0 +1,142 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// ricTransform returns the n×n transformation from the inertial frame to the RIC frame of this orbit. It rotates
+// the position and the velocity (i.e. the first three or six components) and leaves any other component, such as
+// Cr or the DMC empirical accelerations, unchanged. The velocity components are the inertial velocity expressed
+// in the RIC axes, which is how state errors and covariances are usually reported.
+func (o Orbit) ricTransform(n int) *mat64.Dense {
+	if n != 3 && n < 6 {
+		panic(fmt.Errorf("cannot rotate a %d dimensional state to the RIC frame", n))
+	}
+	dcm := o.RICDCM()
+	T := DenseIdentity(n)
+	blocks := 2
+	if n == 3 {
+		blocks = 1
+	}
+	for b := 0; b < blocks; b++ {
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				T.Set(3*b+i, 3*b+j, dcm.At(i, j))
+			}
+		}
+	}
+	return T
+}
+
+// ECI2RIC returns the provided inertial state error (of dimension 3, or at least 6 with position first) rotated
+// into the RIC frame of the orbit.
+func ECI2RIC(o Orbit, δ *mat64.Vector) *mat64.Vector {
+	T := o.ricTransform(δ.Len())
+	δRIC := mat64.NewVector(δ.Len(), nil)
+	δRIC.MulVec(T, δ)
+	return δRIC
+}
+
+// RIC2ECI returns the provided state error in the RIC frame of the orbit rotated into the inertial frame.
+func RIC2ECI(o Orbit, δ *mat64.Vector) *mat64.Vector {
+	T := o.ricTransform(δ.Len())
+	δECI := mat64.NewVector(δ.Len(), nil)
+	δECI.MulVec(T.T(), δ)
+	return δECI
+}
+
+// ECI2RICCovariance returns the provided inertial covariance rotated into the RIC frame of the orbit.
+func ECI2RICCovariance(o Orbit, P mat64.Symmetric) *mat64.SymDense {
+	T := o.ricTransform(P.Symmetric())
+	return rotateCovariance(T, P)
+}
+
+// RIC2ECICovariance returns the provided covariance in the RIC frame of the orbit rotated into the inertial frame,
+// e.g. to use a process noise defined in the RIC frame.
+func RIC2ECICovariance(o Orbit, P mat64.Symmetric) *mat64.SymDense {
+	T := o.ricTransform(P.Symmetric())
+	var Tt mat64.Dense
+	Tt.Clone(T.T())
+	return rotateCovariance(&Tt, P)
+}
+
+// rotateCovariance returns T*P*T' as a symmetric matrix, enforcing the symmetry lost to rounding errors.
+func rotateCovariance(T mat64.Matrix, P mat64.Symmetric) *mat64.SymDense {
+	n, _ := T.Dims()
+	var TP, TPTt mat64.Dense
+	TP.Mul(T, P)
+	TPTt.Mul(&TP, T.T())
+	rotated := mat64.NewSymDense(n, nil)
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			rotated.SetSym(i, j, (TPTt.At(i, j)+TPTt.At(j, i))/2)
+		}
+	}
+	return rotated
+}
+
+// RICError stores the error of an estimate and its covariance in the RIC frame of the reference orbit.
+type RICError struct {
+	DT                 time.Time
+	Position, Velocity []float64       // km and km/s along the radial, in-track and cross-track directions
+	Covariance         *mat64.SymDense // nil if no covariance was provided
+}
+
+// NewRICError returns the RIC error from the deviation of the estimate with respect to the reference state, and
+// its covariance, both in the inertial frame (e.g. the state and covariance of a Kalman filter estimate). The
+// covariance may be nil.
+func NewRICError(reference State, δ *mat64.Vector, P mat64.Symmetric) RICError {
+	δRIC := ECI2RIC(reference.Orbit, δ)
+	e := RICError{DT: reference.DT, Position: make([]float64, 3), Velocity: make([]float64, 3)}
+	for i := 0; i < 3; i++ {
+		e.Position[i] = δRIC.At(i, 0)
+		if δ.Len() >= 6 {
+			e.Velocity[i] = δRIC.At(i+3, 0)
+		}
+	}
+	if P != nil {
+		e.Covariance = ECI2RICCovariance(reference.Orbit, P)
+	}
+	return e
+}
+
+// Sigmas returns the standard deviations of the RIC position (km) and velocity (km/s), or zeros if there is no
+// covariance.
+func (e RICError) Sigmas() (position, velocity []float64) {
+	position = make([]float64, 3)
+	velocity = make([]float64, 3)
+	if e.Covariance == nil {
+		return
+	}
+	for i := 0; i < 3; i++ {
+		position[i] = math.Sqrt(e.Covariance.At(i, i))
+		if e.Covariance.Symmetric() >= 6 {
+			velocity[i] = math.Sqrt(e.Covariance.At(i+3, i+3))
+		}
+	}
+	return
+}
+
+func (e RICError) String() string {
+	σR, σV := e.Sigmas()
+	return fmt.Sprintf("%s RIC error R=%+v km (σ=%+v) V=%+v km/s (σ=%+v)", e.DT, e.Position, σR, e.Velocity, σV)
+}
+
+// WriteRICErrors writes the RIC errors and their standard deviations as a CSV table.
+func WriteRICErrors(w io.Writer, errs []RICError) error {
+	if _, err := fmt.Fprint(w, "time,radial,inTrack,crossTrack,vRadial,vInTrack,vCrossTrack,sigmaRadial,sigmaInTrack,sigmaCrossTrack,sigmaVRadial,sigmaVInTrack,sigmaVCrossTrack\n"); err != nil {
+		return err
+	}
+	for _, e := range errs {
+		σR, σV := e.Sigmas()
+		if _, err := fmt.Fprintf(w, "%s,%.9f,%.9f,%.9f,%.12f,%.12f,%.12f,%.9f,%.9f,%.9f,%.12f,%.12f,%.12f\n", e.DT.UTC().Format(time.RFC3339), e.Position[0], e.Position[1], e.Position[2], e.Velocity[0], e.Velocity[1], e.Velocity[2], σR[0], σR[1], σR[2], σV[0], σV[1], σV[2]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package smd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestRICError(t *testing.T) {
+	o := NewOrbitFromOE(7000, 0, 51.6, 30, 0, 45, Earth)
+	R, V := o.RV()
+	rUnit, vUnit, hUnit := Unit(R), Unit(V), Unit(o.H())
+	// A radial position error and an along-track velocity error (circular orbit).
+	δ := mat64.NewVector(6, []float64{
+		0.1*rUnit[0] + 0.02*hUnit[0], 0.1*rUnit[1] + 0.02*hUnit[1], 0.1*rUnit[2] + 0.02*hUnit[2],
+		1e-4 * vUnit[0], 1e-4 * vUnit[1], 1e-4 * vUnit[2]})
+	δRIC := ECI2RIC(*o, δ)
+	if !mat64.EqualApprox(δRIC, mat64.NewVector(6, []float64{0.1, 0, 0.02, 0, 1e-4, 0}), 1e-12) {
+		t.Fatalf("invalid RIC error %+v", mat64.Formatted(δRIC.T()))
+	}
+	if !mat64.EqualApprox(RIC2ECI(*o, δRIC), δ, 1e-12) {
+		t.Fatal("RIC2ECI is not the inverse of ECI2RIC")
+	}
+	// A covariance elongated along the in-track direction.
+	PRIC := mat64.NewSymDense(7, nil)
+	for i, σ := range []float64{0.01, 1, 0.1, 1e-6, 1e-5, 1e-6, 0.2} {
+		PRIC.SetSym(i, i, σ*σ)
+	}
+	PECI := RIC2ECICovariance(*o, PRIC)
+	var PvRIC mat64.Vector
+	PvRIC.MulVec(PECI, mat64.NewVector(7, []float64{vUnit[0], vUnit[1], vUnit[2], 0, 0, 0, 0}))
+	if !floats.EqualWithinAbs(mat64.Dot(&PvRIC, mat64.NewVector(7, []float64{vUnit[0], vUnit[1], vUnit[2], 0, 0, 0, 0})), 1, 1e-12) {
+		t.Fatal("the in-track variance is not along the velocity")
+	}
+	if !mat64.EqualApprox(ECI2RICCovariance(*o, PECI), PRIC, 1e-15) {
+		t.Fatal("ECI2RICCovariance is not the inverse of RIC2ECICovariance")
+	}
+	if PECI.At(6, 6) != PRIC.At(6, 6) {
+		t.Fatal("Cr variance was rotated")
+	}
+
+	state := State{DT: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), Orbit: *o}
+	e := NewRICError(state, δ, RIC2ECICovariance(*o, mat64.NewSymDense(6, []float64{
+		4, 0, 0, 0, 0, 0,
+		0, 9, 0, 0, 0, 0,
+		0, 0, 1, 0, 0, 0,
+		0, 0, 0, 1e-6, 0, 0,
+		0, 0, 0, 0, 4e-6, 0,
+		0, 0, 0, 0, 0, 1e-6})))
+	σR, σV := e.Sigmas()
+	if !floats.EqualApprox(σR, []float64{2, 3, 1}, 1e-9) || !floats.EqualApprox(σV, []float64{1e-3, 2e-3, 1e-3}, 1e-9) {
+		t.Fatalf("invalid sigmas %+v %+v", σR, σV)
+	}
+	var buf bytes.Buffer
+	if err := WriteRICErrors(&buf, []RICError{e, NewRICError(state, δ, nil)}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[1], "2017-01-01T00:00:00Z,0.1") {
+		t.Fatalf("invalid table:\n%s", buf.String())
+	}
+	assertPanic(t, func() {
+		ECI2RIC(*o, mat64.NewVector(4, nil))
+	})
+}
@@ -0,0 +1,252 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+// defaultDriftRevolutions is the default number of revolutions over which the ROE control corrects the mean
+// longitude error with a drift.
+const defaultDriftRevolutions = 5
+
+// RelativeOE stores the quasi-nonsingular relative orbital elements (ROE) of a deputy with respect to a chief
+// (D'Amico, 2010), which are dimensionless and stay well defined for near circular orbits, unlike the differences
+// of the classical elements. Multiplied by the semi-major axis of the chief, they are the amplitudes (km) of the
+// relative motion in the Hill frame.
+type RelativeOE struct {
+	Δa       float64 // Relative semi-major axis
+	Δλ       float64 // Relative mean longitude (rad)
+	Δex, Δey float64 // Relative eccentricity vector
+	Δix, Δiy float64 // Relative inclination vector (rad)
+}
+
+// NewRelativeOE returns the ROE of the deputy with respect to the chief, from their osculating states.
+// Panics if either orbit is not elliptical.
+func NewRelativeOE(chief, deputy Orbit) RelativeOE {
+	aC, exC, eyC, iC, ΩC, uC := roeElements(chief)
+	aD, exD, eyD, iD, ΩD, uD := roeElements(deputy)
+	ΔΩ := math.Remainder(ΩD-ΩC, 2*math.Pi)
+	return RelativeOE{
+		Δa:  (aD - aC) / aC,
+		Δλ:  math.Remainder(uD-uC+ΔΩ*math.Cos(iC), 2*math.Pi),
+		Δex: exD - exC,
+		Δey: eyD - eyC,
+		Δix: iD - iC,
+		Δiy: ΔΩ * math.Sin(iC),
+	}
+}
+
+// Vector returns the ROE as a slice, in the order of the fields.
+func (r RelativeOE) Vector() []float64 {
+	return []float64{r.Δa, r.Δλ, r.Δex, r.Δey, r.Δix, r.Δiy}
+}
+
+// Norm returns the Euclidean norm of all the ROE.
+func (r RelativeOE) Norm() float64 {
+	return floats.Norm(r.Vector(), 2)
+}
+
+// Sub returns the difference between these ROE and the provided ones.
+func (r RelativeOE) Sub(r1 RelativeOE) RelativeOE {
+	return RelativeOE{r.Δa - r1.Δa, math.Remainder(r.Δλ-r1.Δλ, 2*math.Pi), r.Δex - r1.Δex, r.Δey - r1.Δey, r.Δix - r1.Δix, r.Δiy - r1.Δiy}
+}
+
+func (r RelativeOE) String() string {
+	return fmt.Sprintf("δa=%.3e δλ=%.3e δe=(%.3e, %.3e) δi=(%.3e, %.3e)", r.Δa, r.Δλ, r.Δex, r.Δey, r.Δix, r.Δiy)
+}
+
+// DeputyFromROE returns the orbit of the deputy with the provided ROE with respect to the chief.
+// Panics if the chief is equatorial, where the relative inclination vector does not define the node.
+func DeputyFromROE(chief Orbit, r RelativeOE) *Orbit {
+	a, ex, ey, i, Ω, u := roeElements(chief)
+	if math.Sin(i) < angleε {
+		panic("the ROE of an equatorial chief do not define the node of the deputy")
+	}
+	ΔΩ := r.Δiy / math.Sin(i)
+	a, ex, ey, i, Ω, u = a*(1+r.Δa), ex+r.Δex, ey+r.Δey, i+r.Δix, Ω+ΔΩ, u+r.Δλ-ΔΩ*math.Cos(i)
+	// The state is computed in the frame of the node since NewOrbitFromOE is singular for circular orbits.
+	e, ω := math.Sqrt(ex*ex+ey*ey), math.Atan2(ey, ex)
+	p := a * (1 - e*e)
+	ν := ellipticTrueAnomaly(e, u-ω)
+	r0, v0 := p/(1+e*math.Cos(ν)), math.Sqrt(chief.Origin.μ/p)
+	sinΩ, cosΩ := math.Sincos(Ω)
+	sini, cosi := math.Sincos(i)
+	node, normal := []float64{cosΩ, sinΩ, 0}, []float64{-sinΩ * cosi, cosΩ * cosi, sini}
+	sinu, cosu := math.Sincos(ν + ω)
+	R, V := make([]float64, 3), make([]float64, 3)
+	for k := 0; k < 3; k++ {
+		R[k] = r0 * (cosu*node[k] + sinu*normal[k])
+		V[k] = v0 * (-(sinu+ey)*node[k] + (cosu+ex)*normal[k])
+	}
+	return NewOrbitFromRV(R, V, chief.Origin)
+}
+
+// roeElements returns the quasi-nonsingular elements of the orbit needed for the ROE, where (ex, ey) is the
+// eccentricity vector in the frame of the node and u is the mean argument of latitude. They are computed from the
+// state since Elements clamps the eccentricity and the inclination of near circular and equatorial orbits.
+// Panics if the orbit is not elliptical.
+func roeElements(o Orbit) (a, ex, ey, i, Ω, u float64) {
+	R, V := o.RV()
+	r, v := Norm(R), Norm(V)
+	a = -o.Origin.μ / (v*v - 2*o.Origin.μ/r)
+	if a <= 0 {
+		panic("relative orbital elements require elliptical orbits")
+	}
+	h := Cross(R, V)
+	i = math.Acos(h[2] / Norm(h))
+	// The node is arbitrarily along the X axis for equatorial orbits.
+	node := []float64{1, 0, 0}
+	if n := math.Sqrt(h[0]*h[0] + h[1]*h[1]); n > 0 {
+		node = []float64{-h[1] / n, h[0] / n, 0}
+	}
+	Ω = math.Atan2(node[1], node[0])
+	normal := Cross(Unit(h), node)
+	eVec := make([]float64, 3)
+	for k := 0; k < 3; k++ {
+		eVec[k] = ((v*v-o.Origin.μ/r)*R[k] - Dot(R, V)*V[k]) / o.Origin.μ
+	}
+	ex, ey = Dot(eVec, node), Dot(eVec, normal)
+	e, ω := math.Sqrt(ex*ex+ey*ey), math.Atan2(ey, ex)
+	ν := math.Atan2(Dot(R, normal), Dot(R, node)) - ω
+	u = math.Remainder(ω+ellipticMeanAnomaly(e, ν), 2*math.Pi)
+	return
+}
+
+// ellipticTrueAnomaly returns the true anomaly of the provided mean anomaly on an elliptical orbit, by solving
+// Kepler's equation with Newton's method.
+func ellipticTrueAnomaly(e, M float64) float64 {
+	M = math.Remainder(M, 2*math.Pi)
+	E := M
+	for iter := 0; iter < 50; iter++ {
+		ΔE := (E - e*math.Sin(E) - M) / (1 - e*math.Cos(E))
+		E -= ΔE
+		if math.Abs(ΔE) < 1e-14 {
+			break
+		}
+	}
+	return 2 * math.Atan2(math.Sqrt(1+e)*math.Sin(E/2), math.Sqrt(1-e)*math.Cos(E/2))
+}
+
+// ROEControl is a low thrust feedback control law which drives the ROE of the spacecraft (the deputy) with respect to
+// a chief to the target ones, e.g. for formation acquisition and maintenance, which is better conditioned than
+// targeting the absolute elements of the nearby chief orbit. The thrust direction decreases a quadratic Lyapunov
+// function of the ROE error with the Gauss variational equations of near circular orbits. The relative mean
+// longitude is corrected through the drift of a reference relative semi-major axis over DriftRevolutions of the
+// chief, since a radial thrust is inefficient. The chief is propagated as a two body orbit from its epoch.
+type ROEControl struct {
+	Chief            Orbit
+	ChiefDT          time.Time
+	Target           RelativeOE
+	Tolerance        float64 // Error (km, i.e. scaled by the semi-major axis of the chief) under which the law coasts
+	DriftRevolutions float64
+	reason           string
+}
+
+// NewROEControl returns a new ROE control law to the provided target ROE, with the chief at the provided orbit at
+// chiefDT. Panics if the tolerance is not positive or if the chief is not elliptical.
+func NewROEControl(chief Orbit, chiefDT time.Time, target RelativeOE, tolerance float64) *ROEControl {
+	if tolerance <= 0 {
+		panic("ROE control tolerance must be positive")
+	}
+	if a, _, _, _, _, _, _, _, _ := chief.Elements(); a <= 0 {
+		panic("ROE control requires an elliptical chief orbit")
+	}
+	return &ROEControl{chief, chiefDT, target, tolerance, defaultDriftRevolutions, "ROE"}
+}
+
+// Reason implements the ThrustControl interface.
+func (cl *ROEControl) Reason() string {
+	return cl.reason
+}
+
+// Type implements the ThrustControl interface.
+func (cl *ROEControl) Type() ControlLaw {
+	return multiOpti
+}
+
+// Control implements the ThrustControl interface, with the chief at its epoch.
+func (cl *ROEControl) Control(o Orbit) []float64 {
+	return cl.ControlAt(o, cl.ChiefDT)
+}
+
+// ControlAt implements the TimedThrustControl interface.
+func (cl *ROEControl) ControlAt(o Orbit, dt time.Time) []float64 {
+	chief, Δ := cl.Error(o, dt)
+	a, _, _, _, _, _, _, _, _ := chief.Elements()
+	if a*Δ.Norm() < cl.Tolerance {
+		return []float64{0, 0, 0}
+	}
+	// Tracking a reference relative semi-major axis drifts the mean longitude error away with a time constant of
+	// DriftRevolutions.
+	Δa := Δ.Δa - Δ.Δλ/(3*math.Pi*cl.DriftRevolutions)
+	_, _, _, _, _, u := roeElements(o)
+	sinu, cosu := math.Sincos(u)
+	// Opposite of the transpose of the control input matrix times the error.
+	thrust := []float64{
+		-(sinu*Δ.Δex - cosu*Δ.Δey),
+		-2 * (Δa + cosu*Δ.Δex + sinu*Δ.Δey),
+		-(cosu*Δ.Δix + sinu*Δ.Δiy),
+	}
+	if Norm(thrust) < 1e-15 {
+		return []float64{0, 0, 0}
+	}
+	return Unit(thrust)
+}
+
+// Error returns the orbit of the chief at the provided epoch and the ROE error of the deputy from the target.
+func (cl *ROEControl) Error(o Orbit, dt time.Time) (chief Orbit, Δ RelativeOE) {
+	chief = *keplerPropagate(cl.Chief, dt.Sub(cl.ChiefDT))
+	return chief, NewRelativeOE(chief, o).Sub(cl.Target)
+}
+
+// ROETarget is a waypoint which acquires the target ROE with respect to a chief with the ROE control law. It is
+// cleared once the error is within the tolerance of the law, unless it keeps the formation, in which case the law
+// only thrusts when the error exceeds the tolerance, e.g. because of the differential perturbations.
+type ROETarget struct {
+	Law     *ROEControl
+	Keep    bool
+	action  *WaypointAction
+	cleared bool
+}
+
+// String implements the Waypoint interface.
+func (wp *ROETarget) String() string {
+	if wp.Keep {
+		return fmt.Sprintf("keeping ROE %s", wp.Law.Target)
+	}
+	return fmt.Sprintf("acquiring ROE %s", wp.Law.Target)
+}
+
+// Cleared implements the Waypoint interface.
+func (wp *ROETarget) Cleared() bool {
+	return wp.cleared
+}
+
+// Action implements the Waypoint interface.
+func (wp *ROETarget) Action() *WaypointAction {
+	if wp.cleared {
+		return wp.action
+	}
+	return nil
+}
+
+// ThrustDirection implements the Waypoint interface.
+func (wp *ROETarget) ThrustDirection(o Orbit, dt time.Time) (ThrustControl, bool) {
+	if !wp.Keep {
+		chief, Δ := wp.Law.Error(o, dt)
+		a, _, _, _, _, _, _, _, _ := chief.Elements()
+		if a*Δ.Norm() < wp.Law.Tolerance {
+			wp.cleared = true
+		}
+	}
+	return wp.Law, wp.cleared
+}
+
+// NewROETarget defines a new ROE target with respect to the chief, which is at the provided orbit at chiefDT. The
+// tolerance is in km, i.e. scaled by the semi-major axis of the chief.
+func NewROETarget(chief Orbit, chiefDT time.Time, target RelativeOE, tolerance float64, keep bool, action *WaypointAction) *ROETarget {
+	return &ROETarget{NewROEControl(chief, chiefDT, target, tolerance), keep, action, false}
+}
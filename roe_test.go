@@ -0,0 +1,88 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestRelativeOE(t *testing.T) {
+	for _, chief := range []*Orbit{
+		NewOrbitFromOE(7000, 0.001, 51.6, 10, 20, 30, Earth),
+		NewOrbitFromOE(7000, 0, 98, 10, 0, 30, Earth),
+		NewOrbitFromOE(26600, 0.7, 63.4, 10, 270, 200, Earth),
+	} {
+		roe := RelativeOE{1e-4, -2e-4, 3e-5, -1e-5, 2e-5, 4e-5}
+		deputy := DeputyFromROE(*chief, roe)
+		if got := NewRelativeOE(*chief, *deputy); !floats.EqualApprox(got.Vector(), roe.Vector(), 1e-9) {
+			t.Fatalf("round trip failed for %s:\n%s\n%s", chief, roe, got)
+		}
+		if got := NewRelativeOE(*chief, *chief); !floats.EqualApprox(got.Vector(), make([]float64, 6), 1e-12) {
+			t.Fatalf("ROE of the chief with itself: %s", got)
+		}
+	}
+	// The ROE scaled by the semi-major axis are the amplitudes of the relative motion.
+	chief := NewOrbitFromOE(7000, 0, 51.6, 10, 20, 30, Earth)
+	ahead := NewRelativeOE(*chief, *DeputyOrbit(*chief, []float64{0, 1, 0}, []float64{0, 0, 0}))
+	if !floats.EqualWithinAbs(7000*ahead.Δλ, 1, 1e-3) || math.Abs(7000*ahead.Δa) > 1e-3 {
+		t.Fatalf("in-track deputy %s", ahead)
+	}
+	above := NewRelativeOE(*chief, *DeputyOrbit(*chief, []float64{0, 0, 1}, []float64{0, 0, 0}))
+	if !floats.EqualWithinAbs(7000*math.Sqrt(above.Δix*above.Δix+above.Δiy*above.Δiy), 1, 1e-3) {
+		t.Fatalf("cross-track deputy %s", above)
+	}
+	assertPanic(t, func() {
+		DeputyFromROE(*NewOrbitFromOE(7000, 0.001, 0, 0, 20, 30, Earth), RelativeOE{})
+	})
+}
+
+func TestROEControlDirection(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	chief := NewOrbitFromOE(7000, 0.001, 51.6, 10, 20, 30, Earth)
+	law := NewROEControl(*chief, start, RelativeOE{}, 1e-3)
+	// A higher deputy must lower its orbit.
+	if thrust := law.ControlAt(*DeputyFromROE(*chief, RelativeOE{Δa: 1e-4}), start); !floats.EqualApprox(thrust, []float64{0, -1, 0}, 1e-6) {
+		t.Fatalf("thrust %+v to lower the orbit", thrust)
+	}
+	// A deputy ahead of the chief must raise its orbit to drift back.
+	if thrust := law.ControlAt(*DeputyFromROE(*chief, RelativeOE{Δλ: 1e-4}), start); !floats.EqualApprox(thrust, []float64{0, 1, 0}, 1e-6) {
+		t.Fatalf("thrust %+v to drift back", thrust)
+	}
+	// An inclination error is corrected out of plane.
+	deputy := DeputyFromROE(*chief, RelativeOE{Δix: 1e-4})
+	_, _, _, _, _, u := roeElements(*deputy)
+	if thrust := law.ControlAt(*deputy, start); math.Abs(thrust[0]) > 1e-6 || math.Abs(thrust[1]) > 1e-6 || Sign(thrust[2]) != -Sign(math.Cos(u)) {
+		t.Fatalf("thrust %+v to correct the inclination at u=%f", thrust, Rad2deg(u))
+	}
+	// Coast within the tolerance.
+	if thrust := law.ControlAt(*DeputyFromROE(*chief, RelativeOE{Δa: 1e-8}), start); Norm(thrust) != 0 {
+		t.Fatalf("thrust %+v within the tolerance", thrust)
+	}
+	assertPanic(t, func() {
+		NewROEControl(*chief, start, RelativeOE{}, 0)
+	})
+}
+
+func TestROETarget(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	chief := NewOrbitFromOE(7000, 0.001, 51.6, 10, 20, 30, Earth)
+	a := 7000.
+	// Acquire a passive safety ellipse 1 km ahead of the chief from 3 km behind it.
+	initial := RelativeOE{Δλ: -3 / a, Δex: 0.2 / a, Δiy: 0.1 / a}
+	target := RelativeOE{Δλ: 1 / a, Δey: 0.3 / a, Δiy: 0.3 / a}
+	wp := NewROETarget(*chief, start, target, 0.02, false, nil)
+	sc := NewSpacecraft("deputy", 500, 100, NewUnlimitedEPS(), []EPThruster{NewGenericEP(0.02, 2000)}, false, []*Cargo{}, []Waypoint{wp})
+	deputy := DeputyFromROE(*chief, initial)
+	m := NewPreciseMission(sc, deputy, start, start.Add(5*24*time.Hour), Perturbations{}, 10*time.Second, false, ExportConfig{})
+	m.Propagate()
+	if !wp.Cleared() {
+		_, Δ := wp.Law.Error(*deputy, m.CurrentDT)
+		t.Fatalf("ROE not acquired after %s: error of %s", m.CurrentDT.Sub(start), Δ)
+	}
+	fuel := 100 - sc.FuelMass
+	if fuel <= 0 || fuel > 1 {
+		t.Fatalf("acquisition used %f kg", fuel)
+	}
+}
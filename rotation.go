@@ -2,8 +2,10 @@ package smd
 
 import (
 	"math"
+	"time"
 
 	"github.com/gonum/matrix/mat64"
+	"github.com/soniakeys/meeus/julian"
 )
 
 const (
@@ -58,12 +60,27 @@ func MxV33(m mat64.Matrix, v []float64) (o []float64) {
 // GEO2ECEF converts the provided parameters (in km and radians) to the ECEF vector.
 // Note that the first parameter is the altitude, not the radius from the center of the body!
 func GEO2ECEF(altitude, latitude, longitude float64) []float64 {
+	return GEO2BodyFixed(altitude, latitude, longitude, Earth)
+}
+
+// GEO2BodyFixed converts the provided parameters (in km and radians) to the body fixed vector of a spherical body.
+func GEO2BodyFixed(altitude, latitude, longitude float64, body CelestialObject) []float64 {
 	sLong, cLong := math.Sincos(longitude)
 	sLat, cLat := math.Sincos(latitude)
-	r := altitude + Earth.Radius
+	r := altitude + body.Radius
 	return []float64{r * cLat * cLong, r * cLat * sLong, r * sLat}
 }
 
+// GEODETIC2ECEF returns the Earth fixed position of the provided geodetic height (in km), latitude and longitude
+// (in radians) on the WGS-84 ellipsoid.
+func GEODETIC2ECEF(height, latitude, longitude float64) []float64 {
+	e2 := wgs84Flattening * (2 - wgs84Flattening)
+	sLong, cLong := math.Sincos(longitude)
+	sLat, cLat := math.Sincos(latitude)
+	N := wgs84SemiMajorAxis / math.Sqrt(1-e2*sLat*sLat) // Radius of curvature in the prime vertical
+	return []float64{(N + height) * cLat * cLong, (N + height) * cLat * sLong, (N*(1-e2) + height) * sLat}
+}
+
 // ECI2ECEF converts the provided ECI vector to ECEF for the θgst given in degrees.
 func ECI2ECEF(R []float64, θgst float64) []float64 {
 	return MxV33(R3(θgst), R)
@@ -73,3 +90,67 @@ func ECI2ECEF(R []float64, θgst float64) []float64 {
 func ECEF2ECI(R []float64, θgst float64) []float64 {
 	return ECI2ECEF(R, -θgst)
 }
+
+// j2000 is the J2000 epoch.
+var j2000 = time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// gmst returns the Greenwich mean sidereal time (IAU 1982) in radians, in [0, 2π).
+// The days since J2000 are computed from the elapsed duration and the whole turns are removed before scaling, since
+// the precision of the Julian date (about 50 µs) is not enough for the rates of the look angles.
+func gmst(dt time.Time) float64 {
+	d := dt.Sub(j2000).Hours() / 24
+	t := d / 36525
+	θ := math.Mod(280.46061837+360*math.Mod(d, 1)+0.98564736629*d+0.000387933*t*t-t*t*t/38710000, 360)
+	if θ < 0 {
+		// Before J2000.
+		θ += 360
+	}
+	return θ * deg2rad
+}
+
+// GAST returns the Greenwich apparent sidereal time in radians, in [0, 2π), i.e. the GMST corrected by the
+// equation of the equinoxes. It is the rotation angle of the Earth from the true equinox of date.
+func GAST(dt time.Time) float64 {
+	t := julianCenturies(dt)
+	Δψ, _, ε := nutation(t)
+	Ω := Deg2rad(125.04452 - 1934.136261*t)
+	eqEquinoxes := Δψ*math.Cos(ε) + (0.00264*math.Sin(Ω)+0.000063*math.Sin(2*Ω))/3600*deg2rad
+	return math.Mod(gmst(dt)+eqEquinoxes+2*math.Pi, 2*math.Pi)
+}
+
+// J20002TOD returns the DCM from the mean equator and equinox of J2000 (i.e. the inertial frame of the Earth) to
+// the true equator and equinox of date, from the IAU 1976 precession and the main terms of the IAU 1980 nutation.
+// Rotating the result by GAST gives the Earth fixed frame, polar motion aside.
+func J20002TOD(dt time.Time) *mat64.Dense {
+	t := julianCenturies(dt)
+	t2, t3 := t*t, t*t*t
+	ζ := (2306.2181*t + 0.30188*t2 + 0.017998*t3) / 3600 * deg2rad
+	θ := (2004.3109*t - 0.42665*t2 - 0.041833*t3) / 3600 * deg2rad
+	z := (2306.2181*t + 1.09468*t2 + 0.018203*t3) / 3600 * deg2rad
+	var P, N, NP, tmp mat64.Dense
+	tmp.Mul(R2(θ), R3(-ζ))
+	P.Mul(R3(-z), &tmp)
+	Δψ, ε0, ε := nutation(t)
+	tmp.Mul(R3(-Δψ), R1(ε0))
+	N.Mul(R1(-ε), &tmp)
+	NP.Mul(&N, &P)
+	return &NP
+}
+
+// nutation returns the nutation in longitude, the mean and the true obliquity of the ecliptic (in radians) from the
+// largest terms of the IAU 1980 nutation theory (accurate to about half an arcsecond), where t is in Julian
+// centuries since J2000.
+func nutation(t float64) (Δψ, ε0, ε float64) {
+	Ω := Deg2rad(125.04452 - 1934.136261*t)
+	L := Deg2rad(280.4665 + 36000.7698*t)
+	Lm := Deg2rad(218.3165 + 481267.8813*t)
+	Δψ = (-17.20*math.Sin(Ω) - 1.32*math.Sin(2*L) - 0.23*math.Sin(2*Lm) + 0.21*math.Sin(2*Ω)) / 3600 * deg2rad
+	Δε := (9.20*math.Cos(Ω) + 0.57*math.Cos(2*L) + 0.10*math.Cos(2*Lm) - 0.09*math.Cos(2*Ω)) / 3600 * deg2rad
+	ε0 = (84381.448 - 46.8150*t - 0.00059*t*t + 0.001813*t*t*t) / 3600 * deg2rad
+	return Δψ, ε0, ε0 + Δε
+}
+
+// julianCenturies returns the number of Julian centuries since J2000 of the provided epoch.
+func julianCenturies(dt time.Time) float64 {
+	return (julian.TimeToJD(dt.UTC()) - 2451545.0) / 36525
+}
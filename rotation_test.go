@@ -111,3 +111,42 @@ func TestECInECEF(t *testing.T) {
 		}
 	}
 }
+
+func TestGMST(t *testing.T) {
+	// Vallado, Examples 3-5 (before J2000) and 3-15, at the UT1 epochs since GMST is a function of UT1.
+	for _, tc := range []struct {
+		dt   time.Time
+		gmst float64
+	}{
+		{time.Date(1992, 8, 20, 12, 14, 0, 0, time.UTC), 152.578787810},
+		{time.Date(2004, 4, 6, 7, 51, 27, 946047000, time.UTC), 312.8098943},
+	} {
+		θ := gmst(tc.dt)
+		if θ < 0 || θ >= 2*math.Pi || math.Abs(θ/deg2rad-tc.gmst) > 1e-4 {
+			t.Fatalf("%s: GMST %f deg instead of %f", tc.dt, θ/deg2rad, tc.gmst)
+		}
+		if θ := GAST(tc.dt); θ < 0 || θ >= 2*math.Pi || math.Abs(math.Remainder(θ/deg2rad-tc.gmst, 360)) > 0.01 {
+			t.Fatalf("%s: GAST %f deg", tc.dt, θ/deg2rad)
+		}
+	}
+}
+
+func TestGASTTrueOfDate(t *testing.T) {
+	// Vallado, Example 3-15.
+	dt := time.Date(2004, 4, 6, 7, 51, 28, 386009000, time.UTC)
+	if eqEquinoxes := math.Remainder(GAST(dt)-gmst(dt), 2*math.Pi) / deg2rad; math.Abs(eqEquinoxes+0.0031291) > 3e-4 {
+		t.Fatalf("equation of the equinoxes %f deg", eqEquinoxes)
+	}
+	NP := J20002TOD(dt)
+	var I mat64.Dense
+	I.Mul(NP, NP.T())
+	if !mat64.EqualApprox(&I, DenseIdentity(3), 1e-12) {
+		t.Fatal("J2000 to TOD is not orthonormal")
+	}
+	rTOD := MxV33(NP, []float64{5102.5096, 6123.01152, 6378.1363})
+	for i, exp := range []float64{5094.5147804, 6127.3664612, 6380.3445328} {
+		if math.Abs(rTOD[i]-exp) > 0.1 {
+			t.Fatalf("r_TOD = %+v", rTOD)
+		}
+	}
+}
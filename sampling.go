@@ -0,0 +1,138 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat/distmv"
+)
+
+// The orbital element space used for sampling and covariances is [a, e, i, Ω, ω, ν], in km and degrees as in
+// NewOrbitFromOE. It is singular for circular and equatorial orbits, in which case the Cartesian space should be
+// used instead.
+
+// elementsRV returns the position and velocity from the orbital elements (in km and degrees), without any of the
+// special cases of NewOrbitFromOE, so that the elements remain continuous about the nominal orbit.
+func elementsRV(a, e, i, Ω, ω, ν, μ float64) (R, V []float64) {
+	p := a * (1 - e*e)
+	μOp := math.Sqrt(μ / p)
+	sinν, cosν := math.Sincos(ν * deg2rad)
+	rPQW := []float64{p * cosν / (1 + e*cosν), p * sinν / (1 + e*cosν), 0}
+	vPQW := []float64{-μOp * sinν, μOp * (e + cosν), 0}
+	return Rot313Vec(-ω*deg2rad, -i*deg2rad, -Ω*deg2rad, rPQW), Rot313Vec(-ω*deg2rad, -i*deg2rad, -Ω*deg2rad, vPQW)
+}
+
+// elementsVector returns the orbital elements of the orbit, in km and degrees.
+func elementsVector(o Orbit) []float64 {
+	a, e, i, Ω, ω, ν, _, _, _ := o.Elements()
+	return []float64{a, e, Rad2deg(i), Rad2deg(Ω), Rad2deg(ω), Rad2deg(ν)}
+}
+
+// ElementsJacobian returns the 6×6 Jacobian of the Cartesian state [R, V] with respect to the orbital elements
+// [a, e, i, Ω, ω, ν] (in km and degrees) of the orbit, computed by central differences.
+func ElementsJacobian(o Orbit) *mat64.Dense {
+	oe := elementsVector(o)
+	steps := []float64{1e-6 * oe[0], 1e-7, 1e-6, 1e-6, 1e-6, 1e-6}
+	J := mat64.NewDense(6, 6, nil)
+	for j := 0; j < 6; j++ {
+		plus := append([]float64(nil), oe...)
+		minus := append([]float64(nil), oe...)
+		plus[j] += steps[j]
+		minus[j] -= steps[j]
+		Rp, Vp := elementsRV(plus[0], plus[1], plus[2], plus[3], plus[4], plus[5], o.Origin.μ)
+		Rm, Vm := elementsRV(minus[0], minus[1], minus[2], minus[3], minus[4], minus[5], o.Origin.μ)
+		for i := 0; i < 3; i++ {
+			J.Set(i, j, (Rp[i]-Rm[i])/(2*steps[j]))
+			J.Set(i+3, j, (Vp[i]-Vm[i])/(2*steps[j]))
+		}
+	}
+	return J
+}
+
+// Elements2CartesianCovariance returns the linearized Cartesian covariance of the orbit from its covariance in
+// orbital element space.
+func Elements2CartesianCovariance(o Orbit, P mat64.Symmetric) *mat64.SymDense {
+	return rotateCovariance(ElementsJacobian(o), P)
+}
+
+// Cartesian2ElementsCovariance returns the linearized orbital element covariance of the orbit from its Cartesian
+// covariance. An error is returned if the elements are singular for this orbit.
+func Cartesian2ElementsCovariance(o Orbit, P mat64.Symmetric) (*mat64.SymDense, error) {
+	var Jinv mat64.Dense
+	if err := Jinv.Inverse(ElementsJacobian(o)); err != nil {
+		return nil, fmt.Errorf("orbital elements are singular: %s", err)
+	}
+	return rotateCovariance(&Jinv, P), nil
+}
+
+// OrbitSampler draws orbits from a Gaussian distribution about a nominal orbit, in Cartesian or orbital element space.
+type OrbitSampler struct {
+	Nominal    Orbit
+	Elements   bool // Set if the Gaussian is in orbital element space
+	covariance mat64.Symmetric
+	normal     *distmv.Normal
+}
+
+// NewCartesianSampler returns a sampler of the Gaussian of covariance P (6×6, in km and km/s) about the position
+// and velocity of the nominal orbit. The seed allows to reproduce a Monte Carlo analysis.
+func NewCartesianSampler(nominal Orbit, P mat64.Symmetric, seed int64) *OrbitSampler {
+	R, V := nominal.RV()
+	return newOrbitSampler(nominal, false, append(append([]float64(nil), R...), V...), P, seed)
+}
+
+// NewElementsSampler returns a sampler of the Gaussian of covariance P (6×6) about the orbital elements
+// [a, e, i, Ω, ω, ν] (in km and degrees) of the nominal orbit. Samples with a negative eccentricity are reflected
+// about the line of apsides and hyperbolic samples are drawn again.
+func NewElementsSampler(nominal Orbit, P mat64.Symmetric, seed int64) *OrbitSampler {
+	return newOrbitSampler(nominal, true, elementsVector(nominal), P, seed)
+}
+
+func newOrbitSampler(nominal Orbit, elements bool, μ []float64, P mat64.Symmetric, seed int64) *OrbitSampler {
+	if P.Symmetric() != 6 {
+		panic(fmt.Errorf("orbit covariance must be 6×6, got %d×%d", P.Symmetric(), P.Symmetric()))
+	}
+	normal, ok := distmv.NewNormal(μ, P, rand.New(rand.NewSource(seed)))
+	if !ok {
+		panic("NOK in Gaussian")
+	}
+	return &OrbitSampler{nominal, elements, P, normal}
+}
+
+// Sample returns a new orbit drawn from the distribution.
+func (s *OrbitSampler) Sample() *Orbit {
+	x := s.normal.Rand(nil)
+	if !s.Elements {
+		return NewOrbitFromRV(x[0:3], x[3:6], s.Nominal.Origin)
+	}
+	for x[1] >= 1 || x[0] <= 0 {
+		x = s.normal.Rand(nil)
+	}
+	if x[1] < 0 {
+		// Same position and velocity with a positive eccentricity.
+		x[1] = -x[1]
+		x[4] += 180
+		x[5] -= 180
+	}
+	R, V := elementsRV(x[0], x[1], x[2], x[3], x[4], x[5], s.Nominal.Origin.μ)
+	return NewOrbitFromRV(R, V, s.Nominal.Origin)
+}
+
+// Samples returns n orbits drawn from the distribution.
+func (s *OrbitSampler) Samples(n int) []*Orbit {
+	orbits := make([]*Orbit, n)
+	for k := range orbits {
+		orbits[k] = s.Sample()
+	}
+	return orbits
+}
+
+// CartesianCovariance returns the Cartesian covariance of the distribution, linearized about the nominal orbit if
+// sampling in orbital element space.
+func (s *OrbitSampler) CartesianCovariance() *mat64.SymDense {
+	if s.Elements {
+		return Elements2CartesianCovariance(s.Nominal, s.covariance)
+	}
+	return rotateCovariance(DenseIdentity(6), s.covariance)
+}
@@ -0,0 +1,95 @@
+package smd
+
+import (
+	"testing"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// sampleCovariance returns the covariance of the Cartesian states of the orbits.
+func sampleCovariance(orbits []*Orbit) *mat64.SymDense {
+	n := float64(len(orbits))
+	mean := make([]float64, 6)
+	states := make([][]float64, len(orbits))
+	for k, o := range orbits {
+		R, V := o.RV()
+		states[k] = append(append([]float64(nil), R...), V...)
+		floats.AddScaled(mean, 1/n, states[k])
+	}
+	P := mat64.NewSymDense(6, nil)
+	for _, x := range states {
+		for i := 0; i < 6; i++ {
+			for j := i; j < 6; j++ {
+				P.SetSym(i, j, P.At(i, j)+(x[i]-mean[i])*(x[j]-mean[j])/(n-1))
+			}
+		}
+	}
+	return P
+}
+
+func checkCovariance(t *testing.T, name string, got, exp mat64.Symmetric, tol float64) {
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 6; j++ {
+			// Compare relative to the standard deviations.
+			if diff := got.At(i, j) - exp.At(i, j); diff*diff > tol*tol*exp.At(i, i)*exp.At(j, j) {
+				t.Fatalf("%s: P[%d,%d]=%e instead of %e", name, i, j, got.At(i, j), exp.At(i, j))
+			}
+		}
+	}
+}
+
+func TestCartesianSampler(t *testing.T) {
+	nominal := NewOrbitFromOE(7000, 0.01, 51.6, 20, 30, 40, Earth)
+	P := mat64.NewSymDense(6, nil)
+	for i := 0; i < 3; i++ {
+		P.SetSym(i, i, 1)
+		P.SetSym(i+3, i+3, 1e-6)
+		P.SetSym(i, i+3, 5e-4)
+	}
+	s := NewCartesianSampler(*nominal, P, 1)
+	checkCovariance(t, "Cartesian", sampleCovariance(s.Samples(20000)), P, 0.05)
+	if !mat64.Equal(s.CartesianCovariance(), P) {
+		t.Fatal("invalid Cartesian covariance")
+	}
+	// The seed reproduces the samples.
+	if !floats.Equal(NewCartesianSampler(*nominal, P, 42).Sample().R(), NewCartesianSampler(*nominal, P, 42).Sample().R()) {
+		t.Fatal("samples are not reproducible")
+	}
+	assertPanic(t, func() {
+		NewCartesianSampler(*nominal, mat64.NewSymDense(3, nil), 1)
+	})
+}
+
+func TestElementsSampler(t *testing.T) {
+	nominal := NewOrbitFromOE(7000, 0.01, 51.6, 20, 30, 40, Earth)
+	R, V := elementsRV(7000, 0.01, 51.6, 20, 30, 40, Earth.μ)
+	if !floats.EqualApprox(R, nominal.R(), 1e-6) || !floats.EqualApprox(V, nominal.V(), 1e-9) {
+		t.Fatal("elementsRV does not match NewOrbitFromOE")
+	}
+	Poe := mat64.NewSymDense(6, []float64{
+		0.25, 0, 0, 0, 0, 0,
+		0, 1e-8, 0, 0, 0, 0,
+		0, 0, 1e-4, 0, 0, 0,
+		0, 0, 0, 1e-4, 0, 0,
+		0, 0, 0, 0, 1e-2, -9e-3,
+		0, 0, 0, 0, -9e-3, 1e-2})
+	// Conversions back and forth.
+	P := Elements2CartesianCovariance(*nominal, Poe)
+	Poe2, err := Cartesian2ElementsCovariance(*nominal, P)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCovariance(t, "round trip", Poe2, Poe, 1e-5)
+	// For small uncertainties, the samples follow the linearized Cartesian covariance.
+	s := NewElementsSampler(*nominal, Poe, 2)
+	checkCovariance(t, "elements", sampleCovariance(s.Samples(20000)), s.CartesianCovariance(), 0.05)
+
+	// Negative eccentricities are reflected.
+	Poe.SetSym(1, 1, 1e-4)
+	for _, o := range NewElementsSampler(*nominal, Poe, 3).Samples(1000) {
+		if a, e, _, _, _, _, _, _, _ := o.Elements(); e < 0 || e >= 1 || !floats.EqualWithinAbs(a, 7000, 5) {
+			t.Fatalf("invalid sample a=%f e=%f", a, e)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// TrackingPass is a period during which a station is allocated to the tracking of a spacecraft.
+type TrackingPass struct {
+	Station, Spacecraft string
+	Start, End          time.Time
+	MaxElevation        float64 // degrees
+}
+
+// Duration returns the duration of this pass.
+func (p TrackingPass) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+func (p TrackingPass) String() string {
+	return fmt.Sprintf("%s tracks %s from %s to %s (%s, max el. %.1f deg)", p.Station, p.Spacecraft, p.Start, p.End, p.Duration(), p.MaxElevation)
+}
+
+// ContactScheduler allocates the tracking passes of a ground network to several spacecraft. Each station has a
+// number of antennas, each of which tracks at most one spacecraft at a time, and each spacecraft is tracked by at
+// most one station at a time. A pass is never interrupted while the spacecraft remains in view, and the free
+// antennas are allocated to the visible spacecraft which have been tracked the least so far.
+type ContactScheduler struct {
+	Stations    []Station
+	Antennas    map[string]int // Number of antennas of each station by name, one if not set
+	MinDuration time.Duration  // Passes shorter than this are discarded, e.g. to account for the acquisition
+}
+
+// NewContactScheduler returns a new scheduler of the provided stations, each with a single antenna.
+func NewContactScheduler(stations ...Station) ContactScheduler {
+	return ContactScheduler{stations, make(map[string]int), 0}
+}
+
+// antennas returns the number of antennas of the station.
+func (s ContactScheduler) antennas(station Station) int {
+	if n, defined := s.Antennas[station.Name]; defined {
+		return n
+	}
+	return 1
+}
+
+// inView returns whether the spacecraft is in view of the station, i.e. above the elevation mask and with enough
+// SNR if the station has a link budget, and its elevation (degrees).
+func (s Station) inView(state State) (bool, float64) {
+	if !state.Orbit.Origin.Equals(s.Planet) {
+		panic(fmt.Errorf("station %s is on %s but the spacecraft orbits %s", s.Name, s.Planet.Name, state.Orbit.Origin.Name))
+	}
+	// Same geometry as the measurements, cf. Measure.
+	θ, rotated, _ := s.rotationOf(state)
+	_, ρ, el, _ := s.RangeElAz(ECI2ECEF(rotated.Orbit.R(), θ))
+	visible := el >= s.Elevation
+	if s.Link != nil {
+		visible = visible && s.Link.SNR(ρ) >= s.Link.MinSNR
+	}
+	return visible, el
+}
+
+// Schedule returns the allocated passes, sorted by start time, of the spacecraft of the provided names whose
+// states are synchronized, e.g. the history of a MultiMission.
+func (s ContactScheduler) Schedule(histories [][]State, names []string) []TrackingPass {
+	if len(histories) != len(names) {
+		panic(fmt.Errorf("%d histories but %d names", len(histories), len(names)))
+	}
+	steps := -1
+	for _, history := range histories {
+		if steps < 0 || len(history) < steps {
+			steps = len(history)
+		}
+	}
+	var passes []TrackingPass
+	current := make([]*TrackingPass, len(histories)) // Current pass of each spacecraft
+	tracked := make([]time.Duration, len(histories)) // Total tracking time of each spacecraft
+	busy := make(map[string]int)                     // Antennas in use of each station
+	end := func(k int) {
+		if current[k].Duration() >= s.MinDuration {
+			passes = append(passes, *current[k])
+		}
+		busy[current[k].Station]--
+		current[k] = nil
+	}
+	for step := 0; step < steps; step++ {
+		dt := histories[0][step].DT
+		visible := make([]map[string]float64, len(histories)) // Elevation of the spacecraft from each station in view
+		for k, history := range histories {
+			if !history[step].DT.Equal(dt) {
+				panic(fmt.Errorf("%s is not synchronized at %s", names[k], dt))
+			}
+			visible[k] = make(map[string]float64)
+			for _, station := range s.Stations {
+				if inView, el := station.inView(history[step]); inView {
+					visible[k][station.Name] = el
+				}
+			}
+		}
+		// Continue the current passes while in view.
+		for k, pass := range current {
+			if pass == nil {
+				continue
+			}
+			el, inView := visible[k][pass.Station]
+			if !inView {
+				end(k)
+				continue
+			}
+			tracked[k] += dt.Sub(pass.End)
+			pass.End = dt
+			pass.MaxElevation = math.Max(pass.MaxElevation, el)
+		}
+		// Allocate the free antennas, first to the spacecraft tracked the least.
+		order := make([]int, 0, len(histories))
+		for k := range histories {
+			if current[k] == nil && len(visible[k]) > 0 {
+				order = append(order, k)
+			}
+		}
+		sort.Stable(byTracking{order, tracked})
+		for _, k := range order {
+			for _, station := range s.Stations {
+				el, inView := visible[k][station.Name]
+				if !inView || busy[station.Name] >= s.antennas(station) {
+					continue
+				}
+				busy[station.Name]++
+				current[k] = &TrackingPass{Station: station.Name, Spacecraft: names[k], Start: dt, End: dt, MaxElevation: el}
+				break
+			}
+		}
+	}
+	for k := range current {
+		if current[k] != nil {
+			end(k)
+		}
+	}
+	sort.Stable(passesByStart(passes))
+	return passes
+}
+
+// Measurements returns the measurements of the allocated passes, in chronological order, where the states are
+// those used for the schedule. The measurements are sampled at the data rate of the stations, if any.
+func (s ContactScheduler) Measurements(passes []TrackingPass, histories [][]State, names []string) []Measurement {
+	stations := make(map[string]Station)
+	for _, station := range s.Stations {
+		stations[station.Name] = station
+	}
+	spacecraft := make(map[string]int)
+	for k, name := range names {
+		spacecraft[name] = k
+	}
+	var measurements []Measurement
+	for _, pass := range passes {
+		station := stations[pass.Station]
+		sampler := passSampler{station: station}
+		for _, state := range histories[spacecraft[pass.Spacecraft]] {
+			if state.DT.Before(pass.Start) || state.DT.After(pass.End) {
+				continue
+			}
+			if m, ok := sampler.sample(station.Measure(state)); ok {
+				measurements = append(measurements, m)
+			}
+		}
+	}
+	sort.Stable(measurementsByDT(measurements))
+	return measurements
+}
+
+// TrackingSchedule returns the tracking passes of the spacecraft of this multi-mission.
+func (m *MultiMission) TrackingSchedule(s ContactScheduler) []TrackingPass {
+	names := make([]string, len(m.Missions))
+	for k, mission := range m.Missions {
+		names[k] = mission.Vehicle.Name
+	}
+	return s.Schedule(m.History, names)
+}
+
+// WriteTrackingSchedule writes the tracking passes as a CSV table.
+func WriteTrackingSchedule(w io.Writer, passes []TrackingPass) error {
+	if _, err := fmt.Fprint(w, "start,end,durationInMinutes,station,spacecraft,maxElevation\n"); err != nil {
+		return err
+	}
+	for _, p := range passes {
+		if _, err := fmt.Fprintf(w, "%s,%s,%.3f,%s,%s,%.3f\n", p.Start.UTC().Format(time.RFC3339), p.End.UTC().Format(time.RFC3339), p.Duration().Minutes(), p.Station, p.Spacecraft, p.MaxElevation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// byTracking sorts spacecraft indexes by increasing tracking time.
+type byTracking struct {
+	indexes []int
+	tracked []time.Duration
+}
+
+func (s byTracking) Len() int           { return len(s.indexes) }
+func (s byTracking) Swap(i, j int)      { s.indexes[i], s.indexes[j] = s.indexes[j], s.indexes[i] }
+func (s byTracking) Less(i, j int) bool { return s.tracked[s.indexes[i]] < s.tracked[s.indexes[j]] }
+
+// passesByStart sorts tracking passes by start time.
+type passesByStart []TrackingPass
+
+func (s passesByStart) Len() int           { return len(s) }
+func (s passesByStart) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s passesByStart) Less(i, j int) bool { return s[i].Start.Before(s[j].Start) }
+
+// measurementsByDT sorts measurements chronologically.
+type measurementsByDT []Measurement
+
+func (s measurementsByDT) Len() int           { return len(s) }
+func (s measurementsByDT) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s measurementsByDT) Less(i, j int) bool { return s[i].State.DT.Before(s[j].State.DT) }
@@ -0,0 +1,87 @@
+package smd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContactScheduler(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMultiMission(start, start.Add(24*time.Hour), time.Minute)
+	// Three spacecraft close to each other on the same orbit, which are simultaneously in view of the stations.
+	for k, ν := range []float64{0, 5, 10} {
+		m.Add(NewEmptySC(string('a'+rune(k)), 0), NewOrbitFromOE(Earth.Radius+800, 0, 60, 0, 0, ν, Earth), Perturbations{}, ExportConfig{})
+	}
+	m.Propagate()
+	st1 := NewStation("st1", 0, 10, 40, -105, 0, 0)
+	st2 := NewStation("st2", 0, 10, 45, 10, 0, 0)
+	s := NewContactScheduler(st1, st2)
+	passes := m.TrackingSchedule(s)
+	if len(passes) == 0 {
+		t.Fatal("no pass scheduled")
+	}
+	stations := map[string]Station{"st1": st1, "st2": st2}
+	var total time.Duration
+	for i, p := range passes {
+		total += p.Duration()
+		if i > 0 && p.Start.Before(passes[i-1].Start) {
+			t.Fatal("passes are not sorted")
+		}
+		// The spacecraft is in view during the whole pass.
+		k := int(p.Spacecraft[0] - 'a')
+		for _, state := range m.History[k] {
+			if state.DT.Before(p.Start) || state.DT.After(p.End) {
+				continue
+			}
+			if inView, _ := stations[p.Station].inView(state); !inView {
+				t.Fatalf("%s not in view at %s", p, state.DT)
+			}
+		}
+		// No station tracks two spacecraft, and no spacecraft is tracked twice, at once.
+		for _, q := range passes[i+1:] {
+			overlap := !q.Start.After(p.End) && !p.Start.After(q.End)
+			if overlap && (q.Station == p.Station || q.Spacecraft == p.Spacecraft) {
+				t.Fatalf("overlapping passes %s and %s", p, q)
+			}
+		}
+	}
+	// More antennas allow more tracking.
+	s.Antennas["st1"] = 3
+	var totalAntennas time.Duration
+	for _, p := range m.TrackingSchedule(s) {
+		totalAntennas += p.Duration()
+	}
+	if totalAntennas <= total {
+		t.Fatalf("tracking with three antennas %s <= %s", totalAntennas, total)
+	}
+	// Measurements of the schedule.
+	meas := s.Measurements(passes, m.History, []string{"a", "b", "c"})
+	expected := 0
+	for _, p := range passes {
+		expected += int(p.Duration()/time.Minute) + 1
+	}
+	if len(meas) != expected {
+		t.Fatalf("%d measurements, expected %d", len(meas), expected)
+	}
+	for i, me := range meas {
+		if !me.Visible || (i > 0 && me.State.DT.Before(meas[i-1].State.DT)) {
+			t.Fatalf("invalid measurement %s", me)
+		}
+	}
+	// Short passes are discarded.
+	s.MinDuration = 5 * time.Minute
+	for _, p := range m.TrackingSchedule(s) {
+		if p.Duration() < s.MinDuration {
+			t.Fatalf("short pass %s", p)
+		}
+	}
+	var buf bytes.Buffer
+	if err := WriteTrackingSchedule(&buf, passes); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != len(passes)+1 || lines[0] != "start,end,durationInMinutes,station,spacecraft,maxElevation" {
+		t.Fatalf("invalid CSV:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,42 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+)
+
+// j2SecularFactor returns the mean motion n (rad/s), J2·(R/p)² and the eccentricity and inclination of this orbit,
+// for the first order secular rates due to the J2 of its origin. Panics if the orbit is not elliptical.
+func (o Orbit) j2SecularFactor() (n, k, e, i float64) {
+	a, e, i, _, _, _, _, _, _ := o.Elements()
+	if e >= 1 {
+		panic(fmt.Errorf("secular rates are only defined for elliptical orbits (e = %f)", e))
+	}
+	n = math.Sqrt(o.Origin.μ / (a * a * a))
+	p := a * (1 - e*e)
+	k = o.Origin.J(2) * math.Pow(o.Origin.Radius/p, 2)
+	return
+}
+
+// J2NodalRate returns the secular rate of the right ascension of the ascending node (rad/s) due to the J2 of the
+// origin, e.g. 0.9856 degrees per day for a Sun synchronous orbit.
+func (o Orbit) J2NodalRate() float64 {
+	n, k, _, i := o.j2SecularFactor()
+	return -1.5 * n * k * math.Cos(i)
+}
+
+// J2ApsidalRate returns the secular rate of the argument of periapsis (rad/s) due to the J2 of the origin, which
+// vanishes at the critical inclination.
+func (o Orbit) J2ApsidalRate() float64 {
+	n, k, _, i := o.j2SecularFactor()
+	sini := math.Sin(i)
+	return 0.75 * n * k * (4 - 5*sini*sini)
+}
+
+// J2MeanAnomalyDrift returns the secular rate of the mean anomaly (rad/s) due to the J2 of the origin, in addition
+// to the mean motion.
+func (o Orbit) J2MeanAnomalyDrift() float64 {
+	n, k, e, i := o.j2SecularFactor()
+	sini := math.Sin(i)
+	return 0.75 * n * k * math.Sqrt(1-e*e) * (2 - 3*sini*sini)
+}
@@ -0,0 +1,36 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestJ2SecularRates(t *testing.T) {
+	// Sun synchronous orbit at 700 km (Vallado, example 9-7).
+	sso := NewOrbitFromOE(Earth.Radius+700, 0.001, 98.19, 0, 90, 0, Earth)
+	if rate := Rad2deg(sso.J2NodalRate() * 86400); !floats.EqualWithinAbs(rate, 0.9856, 2e-3) {
+		t.Fatalf("SSO nodal rate of %f deg/day", rate)
+	}
+	// No apsidal rotation at the critical inclination.
+	molniya := NewOrbitFromOE(26600, 0.74, math.Acos(math.Sqrt(1/5.))*r2d, 0, 270, 0, Earth)
+	if rate := molniya.J2ApsidalRate(); math.Abs(rate) > 1e-15 {
+		t.Fatalf("apsidal rate of %g rad/s at the critical inclination", rate)
+	}
+	// Nor drift of the mean anomaly at 54.7 degrees.
+	if rate := NewOrbitFromOE(8000, 0.01, math.Acos(math.Sqrt(1/3.))*r2d, 0, 0, 0, Earth).J2MeanAnomalyDrift(); math.Abs(rate) > 1e-15 {
+		t.Fatalf("mean anomaly drift of %g rad/s", rate)
+	}
+	// Compare the nodal rate to a numerical propagation with J2 over a day.
+	o := NewOrbitFromOE(Earth.Radius+800, 0.001, 50, 10, 0, 0, Earth)
+	expected := o.J2NodalRate() * 86400
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMission(NewEmptySC("j2", 0), o, start, start.Add(24*time.Hour), Perturbations{Jn: 2}, false, ExportConfig{})
+	m.Propagate()
+	_, _, _, Ω, _, _, _, _, _ := m.Orbit.Elements()
+	if ΔΩ := Ω - Deg2rad(10); !floats.EqualWithinRel(ΔΩ, expected, 0.05) {
+		t.Fatalf("propagated nodal drift %f deg/day, expected %f", Rad2deg180(ΔΩ), Rad2deg180(expected))
+	}
+}
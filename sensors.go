@@ -0,0 +1,269 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+const sunApparentMagnitude = -26.74
+
+// RadarStation is a monostatic ground radar measuring the range, range rate (Doppler) and angles of a target.
+// The noise of each measurement scales with the inverse of the square root of the signal to noise ratio.
+type RadarStation struct {
+	Station
+	Wavelength        float64 // m
+	ReferenceSNR      float64 // SNR (dB) of a 1 m² target at the reference range
+	ReferenceRange    float64 // km
+	MinSNR            float64 // Detection threshold (dB)
+	RCS               float64 // Radar cross section of the target (m²)
+	σρ, σρDot, σAngle float64 // Noise standard deviations at the reference SNR (km, km/s and degrees)
+	rng               *rand.Rand
+}
+
+// NewRadarStation returns a new radar station on Earth. Angles in degrees; the noise standard deviations are
+// those at the reference SNR.
+func NewRadarStation(name string, altitude, elevation, latΦ, longθ, wavelength, referenceSNR, referenceRange, minSNR, rcs, σρ, σρDot, σAngle float64) RadarStation {
+	return RadarStation{
+		Station:        NewStation(name, altitude, elevation, latΦ, longθ, σρ*σρ, σρDot*σρDot),
+		Wavelength:     wavelength,
+		ReferenceSNR:   referenceSNR,
+		ReferenceRange: referenceRange,
+		MinSNR:         minSNR,
+		RCS:            rcs,
+		σρ:             σρ,
+		σρDot:          σρDot,
+		σAngle:         σAngle,
+		rng:            newRand(),
+	}
+}
+
+// SNR returns the signal to noise ratio (dB) of the target at the provided range (km), from the radar equation.
+func (r RadarStation) SNR(ρ float64) float64 {
+	return r.ReferenceSNR + 10*math.Log10(r.RCS) - 40*math.Log10(ρ/r.ReferenceRange)
+}
+
+// noiseScale returns the scaling of the reference noise at the provided SNR (dB).
+func (r RadarStation) noiseScale(snr float64) float64 {
+	return math.Sqrt(math.Pow(10, (r.ReferenceSNR-snr)/10))
+}
+
+// Measure returns the radar measurement of the state, which must orbit the body of the station.
+func (r RadarStation) Measure(state State) RadarMeasurement {
+	if !state.Orbit.Origin.Equals(r.Planet) {
+		panic(fmt.Errorf("radar %s is on %s but the spacecraft orbits %s", r.Name, r.Planet.Name, state.Orbit.Origin.Name))
+	}
+	_, ρ, el, az := r.RangeElAz(MxV33(r.Planet.Inertial2BodyFixed(state.DT), state.Orbit.R()))
+	rS, vS := r.InertialRV(state.DT)
+	ρDot := topocentricRangeRate(rS, vS, state.Orbit)
+	m := RadarMeasurement{TrueRange: ρ, TrueRangeRate: ρDot, TrueAzimuth: az, TrueElevation: el, SNR: r.SNR(ρ), State: state, Station: r}
+	m.Visible = el >= r.Elevation && m.SNR >= r.MinSNR
+	scale := r.noiseScale(m.SNR)
+	m.Range = ρ + scale*r.σρ*r.normal()
+	m.RangeRate = ρDot + scale*r.σρDot*r.normal()
+	m.Azimuth = az + scale*r.σAngle*r.normal()
+	m.Elevation = el + scale*r.σAngle*r.normal()
+	return m
+}
+
+func (r RadarStation) normal() float64 {
+	if r.rng == nil {
+		return 0
+	}
+	return r.rng.NormFloat64()
+}
+
+// RadarMeasurement stores a radar measurement.
+type RadarMeasurement struct {
+	Visible                    bool
+	SNR                        float64 // dB
+	Range, RangeRate           float64 // km and km/s
+	Azimuth, Elevation         float64 // degrees
+	TrueRange, TrueRangeRate   float64
+	TrueAzimuth, TrueElevation float64
+	State                      State
+	Station                    RadarStation
+}
+
+// Doppler returns the measured two-way Doppler shift (Hz).
+func (m RadarMeasurement) Doppler() float64 {
+	return -2 * m.RangeRate * 1e3 / m.Station.Wavelength
+}
+
+// StateVector returns the range and range rate measurement as a mat64.Vector
+func (m RadarMeasurement) StateVector() *mat64.Vector {
+	return mat64.NewVector(2, []float64{m.Range, m.RangeRate})
+}
+
+// HTilde returns the H tilde matrix of the range and range rate of this measurement.
+func (m RadarMeasurement) HTilde() *mat64.Dense {
+	return orbitState.HTilde(m)
+}
+
+// Partials returns the partials of the range and range rate with respect to the position and velocity.
+func (m RadarMeasurement) Partials() map[StateBlock]*mat64.Dense {
+	rS, vS := m.Station.InertialRV(m.State.DT)
+	return positionVelocityPartials(rangeRangeRateHTilde(rS, vS, m.State.Orbit))
+}
+
+// CSV returns the data as CSV (does *not* include the new line)
+func (m RadarMeasurement) CSV() string {
+	return fmt.Sprintf("%f,%f,%f,%f,%f,%f,%f,", m.SNR, m.TrueRange, m.TrueRangeRate, m.Range, m.RangeRate, m.Azimuth, m.Elevation)
+}
+
+func (m RadarMeasurement) String() string {
+	return fmt.Sprintf("%s@%s (SNR=%.1f dB)", m.Station.Name, m.State.DT, m.SNR)
+}
+
+// OpticalStation is a ground telescope measuring the topocentric right ascension and declination of a target. The
+// target is only visible when it is illuminated by the Sun, the station is in darkness and the target is brighter
+// than the limiting magnitude.
+type OpticalStation struct {
+	Station
+	LimitingMagnitude float64
+	MaxSunElevation   float64 // Maximum elevation of the Sun at the station (degrees), e.g. -12 for nautical twilight
+	Area, Albedo      float64 // Cross section (m²) and albedo of the target, modeled as a diffuse sphere
+	σAngle            float64 // Standard deviation of the angles (arcseconds)
+	Ephemeris         EphemerisFunc
+	rng               *rand.Rand
+}
+
+// NewOpticalStation returns a new optical station on Earth, using the configured ephemerides. Angles in degrees.
+func NewOpticalStation(name string, altitude, elevation, latΦ, longθ, limitingMagnitude, maxSunElevation, area, albedo, σAngle float64) OpticalStation {
+	return OpticalStation{
+		Station:           NewStation(name, altitude, elevation, latΦ, longθ, 0, 0),
+		LimitingMagnitude: limitingMagnitude,
+		MaxSunElevation:   maxSunElevation,
+		Area:              area,
+		Albedo:            albedo,
+		σAngle:            σAngle,
+		Ephemeris:         HelioEphemeris,
+		rng:               newRand(),
+	}
+}
+
+// sunPosition returns the position of the Sun with respect to the body of the station.
+func (s OpticalStation) sunPosition(dt time.Time) []float64 {
+	ephem := s.Ephemeris
+	if ephem == nil {
+		ephem = HelioEphemeris
+	}
+	rBody, _ := ephem(s.Planet, dt)
+	return MxV33(s.Planet.Ecliptic2Equatorial(dt), []float64{-rBody[0], -rBody[1], -rBody[2]})
+}
+
+// ApparentMagnitude returns the apparent magnitude of a diffuse sphere of the provided cross section (m²) and
+// albedo, at the provided range (km) and solar phase angle (radians).
+func ApparentMagnitude(area, albedo, ρ, phase float64) float64 {
+	F := 2 / (3 * math.Pi * math.Pi) * ((math.Pi-phase)*math.Cos(phase) + math.Sin(phase))
+	return sunApparentMagnitude - 2.5*math.Log10(area*albedo*F/math.Pow(ρ*1e3, 2))
+}
+
+// Measure returns the optical measurement of the state, which must orbit the body of the station.
+func (s OpticalStation) Measure(state State) OpticalMeasurement {
+	if !state.Orbit.Origin.Equals(s.Planet) {
+		panic(fmt.Errorf("telescope %s is on %s but the spacecraft orbits %s", s.Name, s.Planet.Name, state.Orbit.Origin.Name))
+	}
+	rS, _ := s.InertialRV(state.DT)
+	R := state.Orbit.R()
+	ρVec := []float64{R[0] - rS[0], R[1] - rS[1], R[2] - rS[2]}
+	ρ := Norm(ρVec)
+	rSun := s.sunPosition(state.DT)
+	toSun := []float64{rSun[0] - R[0], rSun[1] - R[1], rSun[2] - R[2]}
+	phase := math.Acos(math.Max(-1, math.Min(1, -Dot(ρVec, toSun)/(ρ*Norm(toSun)))))
+	m := OpticalMeasurement{
+		TrueRA:      Rad2deg(math.Atan2(ρVec[1], ρVec[0])),
+		TrueDec:     Rad2deg180(math.Asin(ρVec[2] / ρ)),
+		Magnitude:   ApparentMagnitude(s.Area, s.Albedo, ρ, phase),
+		Illuminated: IlluminationFraction(R, []float64{0, 0, 0}, rSun, s.Planet, CylindricalShadow) > 0,
+		State:       state,
+		Station:     s,
+	}
+	dcm := s.Planet.Inertial2BodyFixed(state.DT)
+	_, _, el, _ := s.RangeElAz(MxV33(dcm, R))
+	_, _, sunEl, _ := s.RangeElAz(MxV33(dcm, rSun))
+	m.Dark = sunEl <= s.MaxSunElevation
+	m.Visible = el >= s.Elevation && m.Dark && m.Illuminated && m.Magnitude <= s.LimitingMagnitude
+	m.RA, m.Dec = m.TrueRA, m.TrueDec
+	if s.rng != nil {
+		σ := s.σAngle / 3600
+		m.RA += σ * s.rng.NormFloat64() / math.Cos(Deg2rad(m.TrueDec))
+		m.Dec += σ * s.rng.NormFloat64()
+	}
+	return m
+}
+
+// OpticalMeasurement stores an optical angles measurement.
+type OpticalMeasurement struct {
+	Visible           bool
+	Dark, Illuminated bool    // Whether the station is in darkness and whether the target is in sunlight
+	Magnitude         float64 // Apparent magnitude of the target
+	RA, Dec           float64 // Topocentric right ascension and declination (degrees)
+	TrueRA, TrueDec   float64
+	State             State
+	Station           OpticalStation
+}
+
+// StateVector returns the angles measurement (in radians) as a mat64.Vector
+func (m OpticalMeasurement) StateVector() *mat64.Vector {
+	return mat64.NewVector(2, []float64{Deg2rad(m.RA), Deg2rad(m.Dec)})
+}
+
+// HTilde returns the H tilde matrix of the right ascension and declination (in radians) of this measurement.
+func (m OpticalMeasurement) HTilde() *mat64.Dense {
+	return orbitState.HTilde(m)
+}
+
+// Partials returns the partials of the right ascension and declination (in radians) with respect to the position.
+func (m OpticalMeasurement) Partials() map[StateBlock]*mat64.Dense {
+	rS, _ := m.Station.InertialRV(m.State.DT)
+	R := m.State.Orbit.R()
+	x, y, z := R[0]-rS[0], R[1]-rS[1], R[2]-rS[2]
+	ρxy2 := x*x + y*y
+	ρ2 := ρxy2 + z*z
+	ρxy := math.Sqrt(ρxy2)
+	H := mat64.NewDense(2, 3, nil)
+	H.Set(0, 0, -y/ρxy2)
+	H.Set(0, 1, x/ρxy2)
+	H.Set(1, 0, -x*z/(ρ2*ρxy))
+	H.Set(1, 1, -y*z/(ρ2*ρxy))
+	H.Set(1, 2, ρxy/ρ2)
+	return map[StateBlock]*mat64.Dense{PositionBlock: H}
+}
+
+// CSV returns the data as CSV (does *not* include the new line)
+func (m OpticalMeasurement) CSV() string {
+	return fmt.Sprintf("%f,%f,%f,%f,%f,", m.Magnitude, m.TrueRA, m.TrueDec, m.RA, m.Dec)
+}
+
+func (m OpticalMeasurement) String() string {
+	return fmt.Sprintf("%s@%s (mag=%.1f)", m.Station.Name, m.State.DT, m.Magnitude)
+}
+
+// topocentricRangeRate returns the range rate of the orbit from the observer of the provided inertial state.
+func topocentricRangeRate(rS, vS []float64, o Orbit) float64 {
+	R, V := o.RV()
+	ρ := []float64{R[0] - rS[0], R[1] - rS[1], R[2] - rS[2]}
+	ρDot := []float64{V[0] - vS[0], V[1] - vS[1], V[2] - vS[2]}
+	return Dot(ρ, ρDot) / Norm(ρ)
+}
+
+// rangeRangeRateHTilde returns the partials of the range and range rate from the observer of the provided
+// inertial state with respect to the state of the orbit.
+func rangeRangeRateHTilde(rS, vS []float64, o Orbit) *mat64.Dense {
+	R, V := o.RV()
+	ρVec := []float64{R[0] - rS[0], R[1] - rS[1], R[2] - rS[2]}
+	ρDotVec := []float64{V[0] - vS[0], V[1] - vS[1], V[2] - vS[2]}
+	ρ := Norm(ρVec)
+	ρDot := Dot(ρVec, ρDotVec) / ρ
+	H := mat64.NewDense(2, 6, nil)
+	for i := 0; i < 3; i++ {
+		H.Set(0, i, ρVec[i]/ρ)
+		H.Set(1, i, ρDotVec[i]/ρ-ρDot*ρVec[i]/(ρ*ρ))
+		H.Set(1, i+3, ρVec[i]/ρ)
+	}
+	return H
+}
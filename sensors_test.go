@@ -0,0 +1,122 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// overhead returns the state of a spacecraft right above the station, at the provided altitude.
+func overhead(s Station, dt time.Time, altitude float64) State {
+	R := MxV33(s.Planet.BodyFixed2Inertial(dt), GEO2BodyFixed(altitude, s.LatΦ, s.Longθ, s.Planet))
+	V := Cross(Unit(R), []float64{0, 0, math.Sqrt(s.Planet.μ / Norm(R))})
+	return State{DT: dt, Orbit: *NewOrbitFromRV(R, V, s.Planet)}
+}
+
+// checkHTilde checks the partials of the measurements with central finite differences.
+func checkHTilde(t *testing.T, H *mat64.Dense, state State, measure func(State) []float64) {
+	R, V := state.Orbit.RV()
+	X := append(append([]float64{}, R...), V...)
+	for j := 0; j < 6; j++ {
+		h := 1e-3
+		if j > 2 {
+			h = 1e-6
+		}
+		Xp := append([]float64{}, X...)
+		Xm := append([]float64{}, X...)
+		Xp[j] += h
+		Xm[j] -= h
+		sp, sm := state, state
+		sp.Orbit = *NewOrbitFromRV(Xp[:3], Xp[3:], state.Orbit.Origin)
+		sm.Orbit = *NewOrbitFromRV(Xm[:3], Xm[3:], state.Orbit.Origin)
+		yp, ym := measure(sp), measure(sm)
+		for i := range yp {
+			if !floats.EqualWithinAbs((yp[i]-ym[i])/(2*h), H.At(i, j), 1e-7) {
+				t.Fatalf("invalid partial (%d, %d): %e != %e", i, j, H.At(i, j), (yp[i]-ym[i])/(2*h))
+			}
+		}
+	}
+}
+
+func TestRadarStation(t *testing.T) {
+	dt := time.Date(2018, 3, 1, 4, 0, 0, 0, time.UTC)
+	radar := NewRadarStation("radar", 0, 10, 10, -70, 0.23, 20, 1000, 5, 1, 1e-3, 1e-5, 1e-2)
+	state := overhead(radar.Station, dt, 2000)
+	m := radar.Measure(state)
+	if !m.Visible || !floats.EqualWithinAbs(m.TrueRange, 2000, 1e-6) || !floats.EqualWithinAbs(m.TrueElevation, 90, 1e-6) {
+		t.Fatalf("invalid measurement %s: ρ=%f el=%f", m, m.TrueRange, m.TrueElevation)
+	}
+	if expSNR := 20 - 40*math.Log10(2.); !floats.EqualWithinAbs(m.SNR, expSNR, 1e-9) {
+		t.Fatalf("SNR=%f expected %f", m.SNR, expSNR)
+	}
+	if scale := radar.noiseScale(radar.ReferenceSNR - 20); !floats.EqualWithinAbs(scale, 10, 1e-12) {
+		t.Fatalf("noise scale %f at -20 dB", scale)
+	}
+	if !floats.EqualWithinAbs(m.Doppler(), -2*m.RangeRate*1e3/0.23, 1e-9) {
+		t.Fatal("invalid Doppler")
+	}
+	// Too far to be detected.
+	if m := radar.Measure(overhead(radar.Station, dt, 5000)); m.Visible {
+		t.Fatalf("target should be below the SNR threshold: %s", m)
+	}
+	perfect := radar
+	perfect.rng = nil
+	state.Orbit = *NewOrbitFromRV(state.Orbit.R(), []float64{1, 6, 2}, Earth)
+	checkHTilde(t, perfect.Measure(state).HTilde(), state, func(s State) []float64 {
+		m := perfect.Measure(s)
+		return []float64{m.TrueRange, m.TrueRangeRate}
+	})
+}
+
+func TestOpticalStation(t *testing.T) {
+	dt := time.Date(2018, 3, 1, 4, 0, 0, 0, time.UTC)
+	telescope := NewOpticalStation("telescope", 0, 20, 0, 0, 15, -12, 10, 0.2, 1)
+	// The station is on the equator, so its zenith is close to the equatorial plane at the right ascension θ.
+	rS, _ := telescope.InertialRV(dt)
+	θ := math.Atan2(rS[1], rS[0])
+	sunAt := func(angle float64) EphemerisFunc {
+		// The Sun is placed in the equatorial plane at the provided angle (degrees) from the zenith of the station.
+		return func(body CelestialObject, dt time.Time) (R, V []float64) {
+			rSun := []float64{AU * math.Cos(θ+Deg2rad(angle)), AU * math.Sin(θ+Deg2rad(angle)), 0}
+			return MxV33(R1(Deg2rad(Earth.tilt)), []float64{-rSun[0], -rSun[1], -rSun[2]}), []float64{0, 0, 0}
+		}
+	}
+	state := overhead(telescope.Station, dt, 20000)
+	telescope.Ephemeris = sunAt(120)
+	m := telescope.Measure(state)
+	if !m.Visible || !m.Dark || !m.Illuminated {
+		t.Fatalf("target should be visible: %+v", m)
+	}
+	if !floats.EqualWithinAbs(Rad2deg180(Deg2rad(m.TrueRA)-θ), 0, 1e-6) || !floats.EqualWithinAbs(m.TrueDec, Rad2deg180(math.Asin(rS[2]/Norm(rS))), 1e-6) {
+		t.Fatalf("invalid RA/Dec %f %f", m.TrueRA, m.TrueDec)
+	}
+	if expMag := ApparentMagnitude(10, 0.2, 20000, Deg2rad(60)); !floats.EqualWithinAbs(m.Magnitude, expMag, 1e-3) {
+		t.Fatalf("magnitude %f expected %f", m.Magnitude, expMag)
+	}
+	// In daylight.
+	telescope.Ephemeris = sunAt(30)
+	if m := telescope.Measure(state); m.Visible || m.Dark {
+		t.Fatal("target should not be visible in daylight")
+	}
+	// In the shadow of the Earth.
+	telescope.Ephemeris = sunAt(180)
+	if m := telescope.Measure(state); m.Visible || m.Illuminated {
+		t.Fatal("target should be in the shadow of the Earth")
+	}
+	// Too faint.
+	telescope.Ephemeris = sunAt(120)
+	telescope.LimitingMagnitude = m.Magnitude - 1
+	if m := telescope.Measure(state); m.Visible {
+		t.Fatal("target should be too faint")
+	}
+	perfect := telescope
+	perfect.rng = nil
+	state.Orbit = *NewOrbitFromRV([]float64{state.Orbit.R()[0] + 1000, state.Orbit.R()[1] - 500, 3000}, state.Orbit.V(), Earth)
+	checkHTilde(t, perfect.Measure(state).HTilde(), state, func(s State) []float64 {
+		m := perfect.Measure(s)
+		return []float64{Deg2rad(m.TrueRA), Deg2rad(m.TrueDec)}
+	})
+}
@@ -0,0 +1,121 @@
+package smd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MissionPhase is one phase of a MissionSequence, e.g. a launch coast, a spiral, a cruise or a capture.
+type MissionPhase struct {
+	Name          string
+	Origin        CelestialObject // Center of the phase: the orbit is converted to it at the start of the phase
+	Duration      time.Duration   // Duration of the phase, or zero to end it once all its waypoints are reached
+	Perturbations Perturbations
+	Step          time.Duration
+	WayPoints     []Waypoint // Waypoints of the spacecraft during the phase, nil to coast
+}
+
+// PhaseSummary stores the conditions at the boundaries of a propagated phase.
+type PhaseSummary struct {
+	Name           string
+	Start, End     time.Time
+	Initial, Final Orbit
+	Fuel           float64 // Fuel used during the phase (kg)
+}
+
+func (s PhaseSummary) String() string {
+	return fmt.Sprintf("%s: %s -> %s (%s) fuel=%.3f kg\n\t%s\n\t%s", s.Name, s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339), s.End.Sub(s.Start), s.Fuel, s.Initial, s.Final)
+}
+
+// MissionSequence chains mission phases with different centers, perturbations and step sizes. The spacecraft (with
+// its fuel and cargo) and the orbit carry over from one phase to the next, and all the states are published to a
+// single export stream, where the state at each phase boundary is only published once, unless the center changes.
+type MissionSequence struct {
+	Vehicle   *Spacecraft
+	Orbit     *Orbit // As pointer because the orbit changes during propagation.
+	StartDT   time.Time
+	Phases    []MissionPhase
+	Ephemeris EphemerisFunc  // Ephemerides used to change the center of the orbit between phases
+	History   []State        // States of all the phases, filled during the propagation
+	Summaries []PhaseSummary // Summary of each propagated phase
+	conf      ExportConfig
+}
+
+// NewMissionSequence returns a new empty sequence, using the configured ephemerides.
+func NewMissionSequence(sc *Spacecraft, o *Orbit, start time.Time, conf ExportConfig) *MissionSequence {
+	return &MissionSequence{Vehicle: sc, Orbit: o, StartDT: start.UTC(), Ephemeris: HelioEphemeris, conf: conf}
+}
+
+// Add appends a phase to the sequence.
+// Panics if the step is not positive, or if the phase would never end.
+func (s *MissionSequence) Add(phase MissionPhase) {
+	if phase.Step <= 0 {
+		panic(fmt.Errorf("step of phase %s must be positive", phase.Name))
+	}
+	if phase.Duration < 0 || (phase.Duration == 0 && len(phase.WayPoints) == 0) {
+		panic(fmt.Errorf("phase %s needs a positive duration or waypoints", phase.Name))
+	}
+	s.Phases = append(s.Phases, phase)
+}
+
+// Propagate propagates all the phases in order. The waypoints of the spacecraft are replaced by those of each phase.
+func (s *MissionSequence) Propagate() {
+	var streamChan chan (State)
+	var streamWG sync.WaitGroup
+	if !s.conf.IsUseless() {
+		// The missions wait for the global exports at the end of each phase, so the stream may not be one of them.
+		streamChan = make(chan (State), 10)
+		streamWG.Add(1)
+		go func() {
+			defer streamWG.Done()
+			StreamStates(s.conf, streamChan)
+		}()
+	}
+	dt := s.StartDT
+	for _, phase := range s.Phases {
+		if !s.Orbit.Origin.Equals(phase.Origin) {
+			s.Orbit.toXCentric(phase.Origin, dt, s.Ephemeris)
+		}
+		summary := PhaseSummary{Name: phase.Name, Start: dt, Initial: *s.Orbit}
+		initFuel := s.Vehicle.FuelMass
+		s.Vehicle.WayPoints = phase.WayPoints
+		end := dt.Add(phase.Duration)
+		if phase.Duration == 0 {
+			// Propagate until the waypoints are reached.
+			end = dt.Add(-1)
+		}
+		m := NewPreciseMission(s.Vehicle, s.Orbit, dt, end, phase.Perturbations, phase.Step, false, ExportConfig{})
+		phaseChan := make(chan (State), 10)
+		m.RegisterStateChan(phaseChan)
+		var phaseWG sync.WaitGroup
+		phaseWG.Add(1)
+		go func() {
+			defer phaseWG.Done()
+			for state := range phaseChan {
+				if n := len(s.History); n > 0 && s.History[n-1].DT.Equal(state.DT) && s.History[n-1].Orbit.Origin.Equals(state.Orbit.Origin) {
+					// Already published at the end of the previous phase.
+					continue
+				}
+				s.History = append(s.History, state)
+				if streamChan != nil {
+					streamChan <- state
+				}
+			}
+		}()
+		s.Vehicle.logger.Log("level", "notice", "subsys", "astro", "phase", phase.Name, "date", dt, "orbit", s.Orbit)
+		if phase.Duration == 0 {
+			m.Propagate()
+		} else {
+			m.PropagateUntil(end, true)
+		}
+		phaseWG.Wait()
+		dt = m.CurrentDT
+		summary.End, summary.Final, summary.Fuel = dt, *s.Orbit, initFuel-s.Vehicle.FuelMass
+		s.Summaries = append(s.Summaries, summary)
+	}
+	if streamChan != nil {
+		close(streamChan)
+		streamWG.Wait()
+	}
+}
@@ -0,0 +1,70 @@
+package smd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMissionSequence(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	sc := NewSpacecraft("sequence", 500, 100, NewUnlimitedEPS(), []EPThruster{NewGenericEP(1, 2000)}, false, []*Cargo{}, nil)
+	o := NewOrbitFromOE(7000, 0, 28.5, 0, 0, 0, Earth)
+	seq := NewMissionSequence(sc, o, start, ExportConfig{})
+	seq.Ephemeris = hohmannEphemeris(start)
+	seq.Add(MissionPhase{Name: "spiral", Origin: Earth, Step: 10 * time.Second, WayPoints: []Waypoint{NewReachDistance(7100, true, nil)}})
+	seq.Add(MissionPhase{Name: "coast", Origin: Earth, Duration: 90 * time.Minute, Perturbations: Perturbations{Jn: 2}, Step: time.Minute})
+	seq.Add(MissionPhase{Name: "cruise", Origin: Sun, Duration: 24 * time.Hour, Step: time.Hour})
+	seq.Propagate()
+	if len(seq.Summaries) != 3 {
+		t.Fatalf("%d phases propagated", len(seq.Summaries))
+	}
+	spiral, coast, cruise := seq.Summaries[0], seq.Summaries[1], seq.Summaries[2]
+	if spiral.Fuel <= 0 || coast.Fuel != 0 || cruise.Fuel != 0 {
+		t.Fatalf("invalid fuel usage: %f %f %f", spiral.Fuel, coast.Fuel, cruise.Fuel)
+	}
+	if sc.FuelMass != 100-spiral.Fuel {
+		t.Fatalf("fuel of %f kg after the sequence", sc.FuelMass)
+	}
+	if spiral.Final.RNorm() < 7100 || spiral.End.Equal(start) {
+		t.Fatalf("spiral did not reach its waypoint: %s", spiral)
+	}
+	// The phases are contiguous.
+	if !coast.Start.Equal(spiral.End) || !cruise.Start.Equal(coast.End) || !coast.End.Equal(coast.Start.Add(90*time.Minute)) {
+		t.Fatalf("phases are not contiguous:\n%s\n%s\n%s", spiral, coast, cruise)
+	}
+	if ok, err := coast.Initial.Equals(spiral.Final); !ok {
+		t.Fatalf("the orbit did not carry over between the phases: %s", err)
+	}
+	if !cruise.Initial.Origin.Equals(Sun) || !o.Origin.Equals(Sun) || !cruise.End.Equal(cruise.Start.Add(24*time.Hour)) {
+		t.Fatalf("invalid cruise %s", cruise)
+	}
+	// The boundary is published once about the same center, and once per center when it changes.
+	centers := 0
+	for k, state := range seq.History {
+		if k == 0 {
+			continue
+		}
+		prev := seq.History[k-1]
+		if state.DT.Before(prev.DT) {
+			t.Fatalf("state at %s published after %s", state.DT, prev.DT)
+		}
+		if state.DT.Equal(prev.DT) {
+			if prev.Orbit.Origin.Equals(state.Orbit.Origin) {
+				t.Fatalf("state at %s published twice", state.DT)
+			}
+			centers++
+		}
+	}
+	if centers != 1 {
+		t.Fatalf("%d duplicated boundaries", centers)
+	}
+	if first, last := seq.History[0], seq.History[len(seq.History)-1]; !first.DT.Equal(start) || !last.DT.Equal(cruise.End) {
+		t.Fatalf("history from %s to %s", first.DT, last.DT)
+	}
+	assertPanic(t, func() {
+		seq.Add(MissionPhase{Name: "endless", Origin: Sun, Step: time.Hour})
+	})
+	assertPanic(t, func() {
+		seq.Add(MissionPhase{Name: "stepless", Origin: Sun, Duration: time.Hour})
+	})
+}
@@ -0,0 +1,218 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// slotKeepingSamples is the number of samples of the osculating elements averaged over each check interval.
+const slotKeepingSamples = 24
+
+// SlotKeeping keeps a constellation member within an along-track box centered on its nominal slot, which moves
+// with the mean argument of latitude of the member at the beginning of the simulation. Differential drag (or J2
+// with a different semi-major axis) makes the member drift away from its slot: the controller then executes
+// tangential phasing maneuvers which set a drift rate back towards the slot.
+// The perturbations are those of the provided Mission (e.g. J2 and AtmosphericDrag).
+type SlotKeeping struct {
+	Box           float64       // Half width of the along-track box (km)
+	Rate          float64       // Rate of the argument of latitude of the slot (rad/s), measured over the first check intervals if zero
+	CheckInterval time.Duration // Interval over which the argument of latitude is averaged, one orbit if zero
+	DriftCycle    time.Duration // Maximum time allotted to drift back to the slot after a maneuver
+	Maneuvers     []SlotKeepingManeuver
+	startDT       time.Time
+	endDT         time.Time
+	slotDT        time.Time // Epoch of the initial slot
+	slotU         float64   // Initial mean argument of latitude (unwrapped, rad)
+	prevDT        time.Time // Epoch of the previous offset check, zero after a maneuver
+	prevΔs        float64   // Along-track offset at the previous check (km)
+	prevSMA       float64   // Mean semi-major axis at the previous check (km)
+	maxΔs         float64
+}
+
+// SlotKeepingManeuver stores an executed phasing maneuver, performed in two equal tangential burns half an orbit
+// apart to keep the orbit circular.
+type SlotKeepingManeuver struct {
+	DT     time.Time // Epoch of the first burn
+	Offset float64   // Along-track offset from the slot before the maneuver (km), positive ahead of the slot
+	Δv     float64   // Total tangential Δv in km/s, negative for a retrograde burn
+}
+
+func (m SlotKeepingManeuver) String() string {
+	return fmt.Sprintf("phasing burn of %.3f m/s @ %s (offset=%.3f km)", m.Δv*1e3, m.DT, m.Offset)
+}
+
+// NewSlotKeeping returns a new slot keeping controller which averages the argument of latitude over one orbit and
+// drifts back to the slot in at most a day.
+func NewSlotKeeping(box float64) *SlotKeeping {
+	if box <= 0 {
+		panic("box half width must be strictly positive")
+	}
+	return &SlotKeeping{box, 0, 0, 24 * time.Hour, nil, time.Time{}, time.Time{}, time.Time{}, 0, time.Time{}, 0, 0, 0}
+}
+
+// Run propagates the mission until its StopDT and performs the phasing maneuvers. Blocking.
+func (sk *SlotKeeping) Run(m *Mission) {
+	sk.startDT = m.CurrentDT
+	sk.endDT = m.StopDT
+	interval := sk.CheckInterval
+	if interval == 0 {
+		interval = m.Orbit.Period()
+	}
+	var u, prevU float64
+	var prevDT time.Time
+	for {
+		// Average the osculating elements to remove the short periodic oscillations (e.g. due to J2).
+		var meanU, meanSMA float64
+		meanDT := m.CurrentDT.Add(interval * (slotKeepingSamples + 1) / (2 * slotKeepingSamples))
+		for k := 0; k < slotKeepingSamples; k++ {
+			dt := m.CurrentDT.Add(interval / slotKeepingSamples)
+			if !dt.Before(sk.endDT) {
+				m.PropagateUntil(sk.endDT, true)
+				return
+			}
+			m.PropagateUntil(dt, false)
+			// Elements is singular for the near circular orbits of constellations.
+			a, _, _, _, _, uk := roeElements(*m.Orbit)
+			if prevDT.IsZero() {
+				u = uk
+			} else {
+				// Unwrap the argument of latitude around its advance at the mean motion, since the samples may be
+				// half an orbit apart around a phasing maneuver.
+				Δu := math.Sqrt(m.Orbit.Origin.μ/math.Pow(a, 3)) * dt.Sub(prevDT).Seconds()
+				u += Δu + math.Remainder(uk-prevU-Δu, 2*math.Pi)
+			}
+			prevU, prevDT = uk, dt
+			meanU += u / slotKeepingSamples
+			meanSMA += a / slotKeepingSamples
+		}
+		sk.check(m, meanDT, meanU, meanSMA)
+	}
+}
+
+// check compares the mean argument of latitude with that of the slot and maneuvers if the member is out of the box
+// and will not drift back to its slot.
+func (sk *SlotKeeping) check(m *Mission, dt time.Time, u, meanSMA float64) {
+	if sk.slotDT.IsZero() {
+		sk.slotDT = dt
+		sk.slotU = u
+		return
+	}
+	if sk.Rate == 0 {
+		sk.Rate = (u - sk.slotU) / dt.Sub(sk.slotDT).Seconds()
+		return
+	}
+	Δs := meanSMA * (u - sk.slotU - sk.Rate*dt.Sub(sk.slotDT).Seconds())
+	sk.maxΔs = math.Max(sk.maxΔs, math.Abs(Δs))
+	if sk.prevDT.IsZero() {
+		sk.prevDT, sk.prevΔs, sk.prevSMA = dt, Δs, meanSMA
+		return
+	}
+	Δt := dt.Sub(sk.prevDT).Seconds()
+	sDot := (Δs - sk.prevΔs) / Δt
+	// The decay of the semi-major axis (e.g. due to drag) accelerates the drift: s̈ = -3/2 n ȧ.
+	sDDot := -1.5 * sk.Rate * (meanSMA - sk.prevSMA) / Δt
+	sk.prevDT, sk.prevΔs, sk.prevSMA = dt, Δs, meanSMA
+	if math.Abs(Δs) <= sk.Box {
+		return
+	}
+	// The drift acceleration (e.g. due to differential drag) opposes the return if it has the sign of the offset.
+	opposed := sDDot*Δs > 0
+	if sDot*Δs < 0 && (!opposed || sDot*sDot/(2*math.Abs(sDDot)) >= math.Abs(Δs)) {
+		// Already drifting back, and will reach the slot.
+		return
+	}
+	sDotTarget := math.Abs(Δs) / sk.DriftCycle.Seconds()
+	if opposed {
+		// Aim for the far edge of the box, where the drift reverses.
+		sDotTarget = math.Max(sDotTarget, math.Sqrt(2*math.Abs(sDDot)*(math.Abs(Δs)+sk.Box)))
+	}
+	sDotTarget *= -Sign(Δs)
+	// For a near circular orbit, the along-track drift rate changes by -3Δv.
+	sk.phase(m, Δs, -(sDotTarget-sDot)/3)
+}
+
+// phase executes the phasing maneuver with two tangential burns half an orbit apart.
+func (sk *SlotKeeping) phase(m *Mission, Δs, Δv float64) {
+	maneuver := SlotKeepingManeuver{m.CurrentDT, Δs, Δv}
+	sk.Maneuvers = append(sk.Maneuvers, maneuver)
+	m.Vehicle.logger.Log("level", "notice", "subsys", "astro", "date", m.CurrentDT, "slot keeping", maneuver)
+	sk.apply(m, Δv/2)
+	if dt := m.CurrentDT.Add(m.Orbit.Period() / 2); dt.Before(sk.endDT) {
+		m.PropagateUntil(dt, false)
+		sk.apply(m, Δv/2)
+	}
+	// The drift rate will be measured again from the next checks.
+	sk.prevDT = time.Time{}
+}
+
+func (sk *SlotKeeping) apply(m *Mission, Δv float64) {
+	R, V := m.Orbit.RV()
+	vUnit := Unit(V)
+	for i := 0; i < 3; i++ {
+		V[i] += Δv * vUnit[i]
+	}
+	*m.Orbit = *NewOrbitFromRV(R, V, m.Orbit.Origin)
+}
+
+// MaxOffset returns the maximum along-track offset from the slot (km) over all the checks.
+func (sk *SlotKeeping) MaxOffset() float64 {
+	return sk.maxΔs
+}
+
+// TotalΔv returns the total phasing Δv in m/s.
+func (sk *SlotKeeping) TotalΔv() (Δv float64) {
+	for _, m := range sk.Maneuvers {
+		Δv += math.Abs(m.Δv) * 1e3
+	}
+	return
+}
+
+// AnnualΔv returns the phasing Δv in m/s per year over the simulated duration.
+func (sk *SlotKeeping) AnnualΔv() float64 {
+	years := sk.endDT.Sub(sk.startDT).Hours() / (24 * 365.25)
+	if years <= 0 {
+		return 0
+	}
+	return sk.TotalΔv() / years
+}
+
+// Frequency returns the mean interval between two phasing maneuvers over the simulated duration, or zero if no
+// maneuver was needed.
+func (sk *SlotKeeping) Frequency() time.Duration {
+	if len(sk.Maneuvers) == 0 {
+		return 0
+	}
+	return sk.endDT.Sub(sk.startDT) / time.Duration(len(sk.Maneuvers))
+}
+
+// SlotKeeping propagates all members concurrently, each keeping its along-track slot within the box (km), and
+// records their states. Returns the controller of each member, in the order of the members.
+func (c *ConstellationMission) SlotKeeping(box float64) []*SlotKeeping {
+	controllers := make([]*SlotKeeping, len(c.Missions))
+	for k := range controllers {
+		controllers[k] = NewSlotKeeping(box)
+	}
+	runAll(c.Missions, c.History, func(k int, m *Mission) {
+		controllers[k].Run(m)
+	})
+	return controllers
+}
+
+// WriteSlotKeeping writes the maintenance statistics of each member as a CSV table.
+func (c *ConstellationMission) WriteSlotKeeping(w io.Writer, controllers []*SlotKeeping) error {
+	if len(controllers) != len(c.Members) {
+		return fmt.Errorf("%d controllers for %d members", len(controllers), len(c.Members))
+	}
+	if _, err := fmt.Fprint(w, "name,plane,slot,maneuvers,maxOffsetKm,totalDvMps,annualDvMps,frequencyHours\n"); err != nil {
+		return err
+	}
+	for k, member := range c.Members {
+		sk := controllers[k]
+		if _, err := fmt.Fprintf(w, "%s,%d,%d,%d,%.3f,%.6f,%.6f,%.3f\n", member.Name, member.Plane, member.Slot, len(sk.Maneuvers), sk.MaxOffset(), sk.TotalΔv(), sk.AnnualΔv(), sk.Frequency().Hours()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
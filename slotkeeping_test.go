@@ -0,0 +1,81 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlotKeeping(t *testing.T) {
+	start := time.Date(2017, 3, 20, 0, 0, 0, 0, time.UTC)
+	end := start.Add(3 * 24 * time.Hour)
+	sc := NewEmptySC("leo", 100)
+	sc.Cd = 2.2
+	sc.Area = 10
+	sk := NewSlotKeeping(5)
+	o := NewOrbitFromOE(Earth.Radius+400, 0, 51.6, 0, 0, 0, Earth)
+	m := NewPreciseMission(sc, o, start, end, Perturbations{Jn: 2, Arbitrary: AtmosphericDrag(*sc, ModerateSpaceWeather)}, 10*time.Second, false, ExportConfig{})
+	sk.Run(m)
+	// The slot moves at about the mean motion.
+	if n := math.Sqrt(Earth.μ / math.Pow(Earth.Radius+400, 3)); math.Abs(sk.Rate-n)/n > 1e-2 {
+		t.Fatalf("invalid slot rate %g rad/s (n=%g)", sk.Rate, n)
+	}
+	if len(sk.Maneuvers) < 2 {
+		t.Fatalf("expected several phasing maneuvers: %+v", sk.Maneuvers)
+	}
+	for _, maneuver := range sk.Maneuvers {
+		if math.Abs(maneuver.Offset) <= sk.Box {
+			t.Fatalf("maneuver within the box: %s", maneuver)
+		}
+		// Drag moves the spacecraft ahead of its slot, which is corrected by raising the orbit, and vice versa.
+		if Sign(maneuver.Δv) != Sign(maneuver.Offset) {
+			t.Fatalf("maneuver in the wrong direction: %s", maneuver)
+		}
+	}
+	if sk.MaxOffset() <= sk.Box || sk.MaxOffset() > 5*sk.Box {
+		t.Fatalf("slot not maintained: max offset of %f km", sk.MaxOffset())
+	}
+	if freq := sk.Frequency(); freq <= 0 || freq > end.Sub(start)/2 {
+		t.Fatalf("invalid frequency %s", freq)
+	}
+	if annual := sk.AnnualΔv(); math.Abs(annual-sk.TotalΔv()*365.25/3) > 1e-9 {
+		t.Fatalf("invalid annual Δv %f m/s", annual)
+	}
+	if !m.CurrentDT.Equal(end) {
+		t.Fatalf("mission stopped at %s", m.CurrentDT)
+	}
+	t.Logf("%d maneuvers (every %s) for %.3f m/s (%.3f m/s/yr), max offset %.3f km", len(sk.Maneuvers), sk.Frequency(), sk.TotalΔv(), sk.AnnualΔv(), sk.MaxOffset())
+}
+
+func TestConstellationSlotKeeping(t *testing.T) {
+	start := time.Date(2017, 3, 20, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	members := WalkerDelta("test", 2, 2, 0, Earth.Radius+700, 53, Earth)
+	c := NewConstellationMission(members, start, end, Perturbations{Jn: 2}, 10*time.Second, ExportConfig{})
+	controllers := c.SlotKeeping(5)
+	if len(controllers) != len(members) {
+		t.Fatalf("%d controllers for %d members", len(controllers), len(members))
+	}
+	for k, sk := range controllers {
+		// Without differential drag, J2 moves the members and their slots together.
+		if len(sk.Maneuvers) != 0 || sk.MaxOffset() > 1 {
+			t.Fatalf("%s: unexpected maneuvers %+v (max offset %f km)", members[k].Name, sk.Maneuvers, sk.MaxOffset())
+		}
+		if len(c.History[k]) == 0 || !c.History[k][len(c.History[k])-1].DT.Equal(end) {
+			t.Fatalf("%s was not propagated until the end", members[k].Name)
+		}
+	}
+	var buf bytes.Buffer
+	if err := c.WriteSlotKeeping(&buf, controllers); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "test-P0S0,0,0,0,") {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+	if err := c.WriteSlotKeeping(&buf, controllers[:1]); err == nil {
+		t.Fatal("missing controller not reported")
+	}
+}
@@ -0,0 +1,144 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// SOITransitions enables the automatic transitions between the heliocentric orbit and the spheres of influence
+// (SOI) of the bodies during a mission. A planetocentric mission switches to the Sun when leaving the SOI of its
+// origin, and a heliocentric mission switches to any of the bodies whose SOI it enters. The crossing epoch is
+// found by bisection of the two body motion over the step, and the remainder of the step is propagated about the
+// new center, instead of switching at the step boundary.
+type SOITransitions struct {
+	Bodies     []CelestialObject // Bodies whose SOI may be entered from a heliocentric orbit
+	Encounters []SOIEncounter    // Transitions so far
+	Ephemeris  EphemerisFunc
+}
+
+// NewSOITransitions returns new SOI transitions into the provided bodies, using the configured ephemerides.
+func NewSOITransitions(bodies ...CelestialObject) *SOITransitions {
+	for _, body := range bodies {
+		if body.Equals(Sun) || body.SOI <= 0 {
+			panic(fmt.Errorf("the SOI of %s is not defined", body.Name))
+		}
+	}
+	return &SOITransitions{bodies, nil, HelioEphemeris}
+}
+
+// SOIEncounter stores the conditions of a transition between spheres of influence.
+type SOIEncounter struct {
+	DT       time.Time
+	From, To CelestialObject
+	Orbit    Orbit   // Orbit about the new center at the crossing
+	VInf     float64 // Hyperbolic excess velocity with respect to the planet (km/s), or NaN if bound to it
+	BPlane   *BPlane // B-plane of the arrival when entering an SOI on a hyperbola, nil otherwise
+}
+
+// Entry returns whether this is the entry into the SOI of a planet.
+func (e SOIEncounter) Entry() bool {
+	return e.From.Equals(Sun)
+}
+
+func (e SOIEncounter) String() string {
+	s := fmt.Sprintf("%s %s -> %s: v∞=%.6f km/s", e.DT.Format(time.RFC3339Nano), e.From.Name, e.To.Name, e.VInf)
+	if e.BPlane != nil {
+		s += fmt.Sprintf(" %s", e.BPlane)
+	}
+	return s
+}
+
+// soiTransition switches the orbit of the mission to a new center if it crossed an SOI during the last step.
+func (a *Mission) soiTransition() {
+	if a.SOI == nil {
+		return
+	}
+	o := *a.Orbit
+	prev := keplerPropagate(o, -a.step)
+	prevDT := a.CurrentDT.Add(-a.step)
+	if !o.Origin.Equals(Sun) {
+		if o.Origin.SOI <= 0 || o.RNorm() <= o.Origin.SOI || prev.RNorm() > o.Origin.SOI {
+			return
+		}
+		a.switchCenter(Sun, func(o Orbit, dt time.Time) float64 {
+			return o.RNorm() - o.Origin.SOI
+		})
+		return
+	}
+	for _, body := range a.SOI.Bodies {
+		if a.SOI.distanceTo(o, body, a.CurrentDT) > body.SOI || a.SOI.distanceTo(*prev, body, prevDT) <= body.SOI {
+			continue
+		}
+		body := body
+		a.switchCenter(body, func(o Orbit, dt time.Time) float64 {
+			return body.SOI - a.SOI.distanceTo(o, body, dt)
+		})
+		return
+	}
+}
+
+// distanceTo returns the distance (km) between the heliocentric orbit and the body.
+func (s *SOITransitions) distanceTo(o Orbit, body CelestialObject, dt time.Time) float64 {
+	rBody, _ := s.Ephemeris(body, dt)
+	R := o.R()
+	return Norm([]float64{R[0] - rBody[0], R[1] - rBody[1], R[2] - rBody[2]})
+}
+
+// switchCenter finds the crossing of the SOI during the last step, where the provided function becomes positive,
+// switches the orbit to the new center at that epoch, and propagates it about the new center until the end of the
+// step. The orbit is propagated with the two body dynamics during the step.
+func (a *Mission) switchCenter(to CelestialObject, crossing func(o Orbit, dt time.Time) float64) {
+	from := a.Orbit.Origin
+	// Bisection of the crossing, as a duration before the current epoch.
+	before, after := a.step, time.Duration(0)
+	for before-after > time.Microsecond {
+		mid := (before + after) / 2
+		if crossing(*keplerPropagate(*a.Orbit, -mid), a.CurrentDT.Add(-mid)) > 0 {
+			after = mid
+		} else {
+			before = mid
+		}
+	}
+	dt := a.CurrentDT.Add(-after)
+	leaving := keplerPropagate(*a.Orbit, -after)
+	entering := *leaving
+	entering.toXCentric(to, dt, a.SOI.Ephemeris)
+	encounter := SOIEncounter{DT: dt, From: from, To: to, Orbit: entering, VInf: math.NaN()}
+	// The excess velocity is with respect to the planet, i.e. before leaving or after entering its SOI.
+	planetocentric := *leaving
+	if encounter.Entry() {
+		planetocentric = entering
+	}
+	if planetocentric.Energyξ() > 0 {
+		encounter.VInf = hyperbolicExcessVelocity(planetocentric)
+		if encounter.Entry() {
+			bPlane := NewBPlane(planetocentric)
+			encounter.BPlane = &bPlane
+		}
+	}
+	*a.Orbit = *keplerPropagate(entering, after)
+	a.SOI.Encounters = append(a.SOI.Encounters, encounter)
+	a.Vehicle.logger.Log("level", "notice", "subsys", "astro", "date", dt, "soi", to.Name, "from", from.Name, "v∞(km/s)", encounter.VInf, "orbit", a.Orbit)
+	if encounter.BPlane != nil {
+		a.Vehicle.logger.Log("level", "notice", "subsys", "astro", "date", dt, "soi", to.Name, "BR", encounter.BPlane.BR, "BT", encounter.BPlane.BT)
+	}
+}
+
+// WriteSOIEncounters writes the SOI transitions as a CSV table.
+func WriteSOIEncounters(w io.Writer, encounters []SOIEncounter) error {
+	if _, err := fmt.Fprint(w, "epoch,from,to,vInf,BR,BT\n"); err != nil {
+		return err
+	}
+	for _, e := range encounters {
+		bR, bT := math.NaN(), math.NaN()
+		if e.BPlane != nil {
+			bR, bT = e.BPlane.BR, e.BPlane.BT
+		}
+		if _, err := fmt.Fprintf(w, "%s,%s,%s,%.6f,%.3f,%.3f\n", e.DT.UTC().Format(time.RFC3339Nano), e.From.Name, e.To.Name, e.VInf, bR, bT); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestSOIExit(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	escape := NewOrbitFromRV([]float64{7000, 0, 0}, []float64{0, 11.5, 0}, Earth)
+	o := NewOrbitFromRV([]float64{7000, 0, 0}, []float64{0, 11.5, 0}, Earth)
+	m := NewPreciseMission(NewEmptySC("escape", 0), o, start, start.Add(4*24*time.Hour), Perturbations{}, time.Minute, false, ExportConfig{})
+	m.SOI = NewSOITransitions()
+	m.SOI.Ephemeris = hohmannEphemeris(start)
+	m.Propagate()
+	if !o.Origin.Equals(Sun) || len(m.SOI.Encounters) != 1 {
+		t.Fatalf("origin %s after %d encounters", o.Origin.Name, len(m.SOI.Encounters))
+	}
+	enc := m.SOI.Encounters[0]
+	if enc.Entry() || !enc.From.Equals(Earth) || !enc.To.Equals(Sun) || enc.BPlane != nil {
+		t.Fatalf("invalid encounter %s", enc)
+	}
+	// Crossing time of the two body escape.
+	before, after := 4*24*time.Hour, time.Duration(0)
+	for before-after > time.Millisecond {
+		mid := (before + after) / 2
+		if keplerPropagate(*escape, mid).RNorm() > Earth.SOI {
+			before = mid
+		} else {
+			after = mid
+		}
+	}
+	if Δ := enc.DT.Sub(start.Add(after)); math.Abs(Δ.Seconds()) > 1 {
+		t.Fatalf("crossing at %s, expected %s", enc.DT, start.Add(after))
+	}
+	if d := m.SOI.distanceTo(enc.Orbit, Earth, enc.DT); !floats.EqualWithinAbs(d, Earth.SOI, 1) {
+		t.Fatalf("crossing at %f km from the Earth", d)
+	}
+	if !floats.EqualWithinAbs(enc.VInf, hyperbolicExcessVelocity(*escape), 1e-5) {
+		t.Fatalf("v∞=%f expected %f", enc.VInf, hyperbolicExcessVelocity(*escape))
+	}
+	// The mission continues about the Sun, outside of the SOI.
+	if d := m.SOI.distanceTo(*o, Earth, m.CurrentDT); d < Earth.SOI {
+		t.Fatalf("final distance to the Earth of %f km", d)
+	}
+	var buf bytes.Buffer
+	if err := WriteSOIEncounters(&buf, m.SOI.Encounters); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 || lines[0] != "epoch,from,to,vInf,BR,BT" || !strings.Contains(lines[1], ",Earth,Sun,") {
+		t.Fatalf("invalid CSV:\n%s", buf.String())
+	}
+}
+
+func TestSOIEntry(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Incoming hyperbola, started outside of the SOI of the Earth.
+	flyby := NewOrbitFromRV([]float64{7000, 0, 1000}, []float64{0, 11.5, 0}, Earth)
+	o := keplerPropagate(*flyby, -3*24*time.Hour)
+	if o.RNorm() < Earth.SOI {
+		t.Fatal("test orbit starts within the SOI")
+	}
+	o.toXCentric(Sun, start, hohmannEphemeris(start))
+	m := NewPreciseMission(NewEmptySC("flyby", 0), o, start, start.Add(3*24*time.Hour), Perturbations{}, time.Minute, false, ExportConfig{})
+	m.SOI = NewSOITransitions(Earth)
+	m.SOI.Ephemeris = hohmannEphemeris(start)
+	m.Propagate()
+	if !o.Origin.Equals(Earth) || len(m.SOI.Encounters) != 1 {
+		t.Fatalf("origin %s after %d encounters", o.Origin.Name, len(m.SOI.Encounters))
+	}
+	enc := m.SOI.Encounters[0]
+	if !enc.Entry() || enc.BPlane == nil || !enc.Orbit.Origin.Equals(Earth) {
+		t.Fatalf("invalid encounter %s", enc)
+	}
+	if !floats.EqualWithinAbs(enc.Orbit.RNorm(), Earth.SOI, 1) {
+		t.Fatalf("crossing at %f km from the Earth", enc.Orbit.RNorm())
+	}
+	// The gravity of the Earth is ignored outside of its SOI, so the arrival is only close to the flyby.
+	exp := NewBPlane(*flyby)
+	B := math.Sqrt(enc.BPlane.BR*enc.BPlane.BR + enc.BPlane.BT*enc.BPlane.BT)
+	expB := math.Sqrt(exp.BR*exp.BR + exp.BT*exp.BT)
+	if !floats.EqualWithinRel(B, expB, 0.05) || !floats.EqualWithinAbs(enc.VInf, hyperbolicExcessVelocity(*flyby), 0.05) {
+		t.Fatalf("B=%f km v∞=%f km/s, expected B=%f km v∞=%f km/s", B, enc.VInf, expB, hyperbolicExcessVelocity(*flyby))
+	}
+	assertPanic(t, func() {
+		NewSOITransitions(Sun)
+	})
+}
@@ -11,20 +11,29 @@ import (
 
 // Spacecraft defines a new spacecraft.
 type Spacecraft struct {
-	Name        string                 // Name of spacecraft
-	DryMass     float64                // DryMass of spacecraft (in kg)
-	FuelMass    float64                // FuelMass of spacecraft (in kg) (will panic if runs out of fuel)
-	EPS         EPS                    // EPS definition, needed for the EPThrusters.
-	EPThrusters []EPThruster           // All available EP EPThrusters
-	ChemProp    bool                   // Set to true to allow Hohmann Transfers.
-	Cargo       []*Cargo               // All onboard cargo
-	WayPoints   []Waypoint             // All waypoints of the tug
-	Maneuvers   map[time.Time]Maneuver // List of maneuvers.
-	FuncQ       []func()
-	logger      kitlog.Logger
-	prevCL      *ControlLaw // Stores the previous control law to follow what is going on.
-	Drag        float64
-	handleFuel  bool
+	Name         string                 // Name of spacecraft
+	DryMass      float64                // DryMass of spacecraft (in kg)
+	FuelMass     float64                // FuelMass of spacecraft (in kg) (will panic if runs out of fuel)
+	EPS          EPS                    // EPS definition, needed for the EPThrusters.
+	EPThrusters  []EPThruster           // All available EP EPThrusters
+	ChemProp     bool                   // Set to true to allow Hohmann Transfers.
+	Cargo        []*Cargo               // All onboard cargo
+	WayPoints    []Waypoint             // All waypoints of the tug
+	Maneuvers    map[time.Time]Maneuver // List of maneuvers.
+	FuncQ        []func()
+	logger       kitlog.Logger
+	prevCL       *ControlLaw // Stores the previous control law to follow what is going on.
+	Drag         float64
+	Cd           float64       // Drag coefficient
+	Area         float64       // Drag cross-sectional area in m²
+	EmpiricalAcc []float64     // Empirical accelerations (km/s²) estimated when using DMC
+	Guidance     Guidance      // What the guidance commanded at the latest step
+	MassModel    *MassModel    // Layout of the mass, to track the center of mass and inertia (optional)
+	Thermal      *ThermalLimit // Thermal constraint of the thrusters (optional)
+	Attitude     AttitudeFunc  // Attitude of the body frame, defaults to NadirPointing
+	Instruments  []Instrument  // Instruments mounted on the body, e.g. cameras or occultation spectrometers
+	BoxWing      *BoxWing      // Surfaces of the body, for the environmental torques (optional)
+	handleFuel   bool
 }
 
 // SCLogInit initializes the logger.
@@ -72,6 +81,10 @@ func (sc *Spacecraft) Accelerate(dt time.Time, o *Orbit) (Δv []float64, fuel fl
 	thrust := 0.0
 	fuel = 0.0
 	Δv = make([]float64, 3)
+	sc.Guidance = Guidance{Thrust: []float64{0, 0, 0}}
+	if sc.Thermal != nil {
+		sc.Thermal.advance(dt)
+	}
 	for _, wp := range sc.WayPoints {
 		if sc.EPS == nil {
 			panic("cannot attempt to reach any waypoint without an EPS")
@@ -81,6 +94,10 @@ func (sc *Spacecraft) Accelerate(dt time.Time, o *Orbit) (Δv []float64, fuel fl
 		}
 		// We've found a waypoint which isn't reached.
 		ctrl, reached := wp.ThrustDirection(*o, dt)
+		sc.Guidance.Waypoint, sc.Guidance.ControlLaw, sc.Guidance.Reason = wp.String(), ctrl.Type().String(), ctrl.Reason()
+		if pwp, ok := wp.(ProgressWaypoint); ok {
+			sc.Guidance.Progress = pwp.Progress(*o, dt)
+		}
 		if clType := ctrl.Type(); sc.prevCL == nil || *sc.prevCL != clType {
 			sc.logger.Log("level", "info", "subsys", "astro", "date", dt, "thrust", clType, "reason", ctrl.Reason(), "v(km/s)", Norm(o.V()), "orbit", o, "period", o.Period())
 			sc.prevCL = &clType
@@ -131,13 +148,21 @@ func (sc *Spacecraft) Accelerate(dt time.Time, o *Orbit) (Δv []float64, fuel fl
 				case REFSUN:
 					sc.FuncQ = append(sc.FuncQ, sc.ToXCentric(Sun, dt, o))
 					break
+				case IMPULSE:
+					sc.FuncQ = append(sc.FuncQ, sc.impulse(action.Impulse, dt, o))
+					break
 				default:
 					panic("unknown action")
 				}
 			}
+			if !wp.Cleared() {
+				// The waypoint only requested its action (e.g. an impulse) and stays active: the next waypoints
+				// must not start yet.
+				return []float64{0, 0, 0}, 0
+			}
 			continue
 		}
-		Δv := ctrl.Control(*o)
+		Δv := controlAt(ctrl, *o, dt)
 		// Let's normalize the allocation.
 		if ΔvNorm := Norm(Δv); ΔvNorm == 0 {
 			// Nothing to do, we're probably just loitering.
@@ -145,25 +170,45 @@ func (sc *Spacecraft) Accelerate(dt time.Time, o *Orbit) (Δv []float64, fuel fl
 		} else if math.Abs(ΔvNorm-1) > 1e-12 {
 			panic(fmt.Errorf(" Δv = %+v! Normalization not implemented yet ", Δv))
 		}
+		if sc.Thermal != nil && !sc.Thermal.fire() {
+			// Cool-down coast.
+			sc.Guidance.ThermalHold = true
+			return []float64{0, 0, 0}, 0
+		}
+		maxThrust := 0.0
+		if eps, ok := sc.EPS.(allocatingEPS); ok {
+			eps.allocate(dt)
+		}
 		for _, EPThruster := range sc.EPThrusters {
 			voltage, power := EPThruster.Max()
+			nominal, _ := EPThruster.Thrust(voltage, power)
+			maxThrust += nominal
+			tThrust, isp := thrustAt(EPThruster, voltage, power, dt)
+			if tThrust <= 0 {
+				// Failed thruster.
+				continue
+			}
 			if err := sc.EPS.Drain(voltage, power, dt); err == nil {
 				// Okay to thrust.
-				tThrust, isp := EPThruster.Thrust(voltage, power)
 				thrust += tThrust
 				fuel += tThrust / (isp * 9.807)
 			} // Error handling of EPS happens in EPS subsystem.
 		}
+		if maxThrust > 0 {
+			sc.Guidance.Throttle = thrust / maxThrust
+		}
 		thrust /= sc.Mass(dt) // Convert kg*m/(s^-2) to m/(s^-2)
 		thrust /= 1e3         // Convert m/s^-2 to km/s^-2
 		// For Chem prop, let's make sure the thrust is not nil.
 		if thrust == 0 && sc.ChemProp {
 			thrust = 1
+			sc.Guidance.Throttle = 1
 		}
 		// Apply norm of the thrust to each component of the normalized Δv vector
 		Δv[0] *= thrust
 		Δv[1] *= thrust
 		Δv[2] *= thrust
+		sc.Guidance.Thrust = []float64{Δv[0], Δv[1], Δv[2]}
 		return Δv, fuel
 	}
 	return
@@ -181,14 +226,24 @@ func (sc *Spacecraft) ToXCentric(body CelestialObject, dt time.Time, o *Orbit) f
 	}
 }
 
+// impulse adds the provided Δv (in the RIC frame, in km/s) to the velocity of the orbit and logs it.
+func (sc *Spacecraft) impulse(Δv []float64, dt time.Time, o *Orbit) func() {
+	return func() {
+		R, V := o.RV()
+		ΔV := MxV33(o.RICDCM().T(), Δv)
+		*o = *NewOrbitFromRV([]float64{R[0], R[1], R[2]}, []float64{V[0] + ΔV[0], V[1] + ΔV[1], V[2] + ΔV[2]}, o.Origin)
+		sc.logger.Log("level", "info", "subsys", "astro", "date", dt, "thrust", "impulse", "v(km/s)", Norm(Δv), "orbit", o)
+	}
+}
+
 // NewEmptySC returns a spacecraft with no cargo and no EPThrusters.
 func NewEmptySC(name string, mass uint) *Spacecraft {
-	return &Spacecraft{name, float64(mass), 0, NewUnlimitedEPS(), []EPThruster{}, false, []*Cargo{}, []Waypoint{}, make(map[time.Time]Maneuver), []func(){}, SCLogInit(name), nil, 0, false}
+	return &Spacecraft{name, float64(mass), 0, NewUnlimitedEPS(), []EPThruster{}, false, []*Cargo{}, []Waypoint{}, make(map[time.Time]Maneuver), []func(){}, SCLogInit(name), nil, 0, 0, 0, nil, Guidance{}, nil, nil, nil, nil, nil, false}
 }
 
 // NewSpacecraft returns a spacecraft with initialized function queue and logger.
 func NewSpacecraft(name string, dryMass, fuelMass float64, eps EPS, prop []EPThruster, impulse bool, payload []*Cargo, wp []Waypoint) *Spacecraft {
-	return &Spacecraft{name, dryMass, fuelMass, eps, prop, impulse, payload, wp, make(map[time.Time]Maneuver), make([]func(), 5), SCLogInit(name), nil, 0, fuelMass > 0}
+	return &Spacecraft{name, dryMass, fuelMass, eps, prop, impulse, payload, wp, make(map[time.Time]Maneuver), make([]func(), 5), SCLogInit(name), nil, 0, 0, 0, nil, Guidance{}, nil, nil, nil, nil, nil, fuelMass > 0}
 }
 
 // Cargo defines a piece of cargo with arrival date and destination orbit
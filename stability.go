@@ -0,0 +1,149 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// principalAxisε is the maximum angle (radians) between a spin axis and the closest principal axis.
+const principalAxisε = 1e-6
+
+// PrincipalAxes returns the principal moments of inertia (kg m²) in increasing order, i.e. of the minor, intermediate
+// and major axes, and the corresponding principal axes as the columns of a right handed DCM from the principal frame
+// to the body frame.
+func (mp MassProperties) PrincipalAxes() (moments []float64, axes *mat64.Dense) {
+	var eig mat64.EigenSym
+	if !eig.Factorize(mp.Inertia, true) {
+		panic(fmt.Errorf("could not diagonalize the inertia tensor of %s", mp))
+	}
+	moments = eig.Values(nil)
+	axes = mat64.NewDense(3, 3, nil)
+	axes.EigenvectorsSym(&eig)
+	if mat64.Det(axes) < 0 {
+		for i := 0; i < 3; i++ {
+			axes.Set(i, 2, -axes.At(i, 2))
+		}
+	}
+	return
+}
+
+// SpinStability is the stability of the torque free spin of a rigid body about one of its principal axes.
+type SpinStability uint8
+
+const (
+	// SpinUnstable is the spin about the intermediate axis.
+	SpinUnstable SpinStability = iota
+	// SpinMinorAxis is the spin about the minor axis, which is stable for a rigid body but unstable as soon as
+	// energy is dissipated (e.g. by flexible appendages or propellant slosh).
+	SpinMinorAxis
+	// SpinMajorAxis is the spin about the major axis (any axis of a spherical inertia), which remains stable with
+	// energy dissipation.
+	SpinMajorAxis
+)
+
+func (s SpinStability) String() string {
+	switch s {
+	case SpinMinorAxis:
+		return "minor axis (stable without energy dissipation)"
+	case SpinMajorAxis:
+		return "major axis (stable)"
+	}
+	return "unstable"
+}
+
+// SpinStability returns the stability of the torque free spin about the provided body axis, which must be a principal
+// axis. An axis of symmetry is stable if its moment is the largest or the smallest one.
+func (mp MassProperties) SpinStability(axis []float64) (SpinStability, error) {
+	u := Unit(axis)
+	Iu := MxV33(mp.Inertia, u)
+	if Norm(Cross(u, Iu)) > principalAxisε*Norm(Iu) {
+		return SpinUnstable, fmt.Errorf("%v is not a principal axis of %s", axis, mp)
+	}
+	moments, _ := mp.PrincipalAxes()
+	I := Dot(u, Iu)
+	tol := principalAxisε * moments[2]
+	switch {
+	case I >= moments[2]-tol:
+		return SpinMajorAxis, nil
+	case I <= moments[0]+tol:
+		return SpinMinorAxis, nil
+	}
+	return SpinUnstable, nil
+}
+
+// GravityGradientStability stores the stability of the gravity gradient equilibrium of a rigid body on a circular
+// orbit, whose roll (in-track), pitch (orbit normal) and yaw (nadir) axes are principal axes (Hughes, 1986).
+type GravityGradientStability struct {
+	K1, K3         float64 // Inertia ratios (Ipitch-Iyaw)/Iroll and (Ipitch-Iroll)/Iyaw
+	Pitch          bool    // Whether the pitch libration is stable, i.e. Iroll > Iyaw
+	RollYaw        bool    // Whether the coupled roll and yaw librations are stable
+	PitchFrequency float64 // Pitch libration frequency (rad/s), zero if unstable
+}
+
+// Stable returns whether the equilibrium is stable in pitch, roll and yaw.
+func (g GravityGradientStability) Stable() bool {
+	return g.Pitch && g.RollYaw
+}
+
+func (g GravityGradientStability) String() string {
+	return fmt.Sprintf("k1=%.6f k3=%.6f pitch stable: %t roll/yaw stable: %t", g.K1, g.K3, g.Pitch, g.RollYaw)
+}
+
+// GravityGradientStability returns the stability of the gravity gradient equilibrium of these mass properties on the
+// provided orbit, in the NadirPointing attitude: the body X, Y and Z axes are respectively the roll, pitch and yaw
+// axes. The products of inertia are ignored.
+func (mp MassProperties) GravityGradientStability(o Orbit) GravityGradientStability {
+	Ir, Ip, Iy := mp.Inertia.At(0, 0), mp.Inertia.At(1, 1), mp.Inertia.At(2, 2)
+	g := GravityGradientStability{K1: (Ip - Iy) / Ir, K3: (Ip - Ir) / Iy}
+	g.Pitch = Ir > Iy
+	g.RollYaw = g.K1*g.K3 > 0 && 1+3*g.K1+g.K1*g.K3 > 4*math.Sqrt(g.K1*g.K3)
+	if g.Pitch {
+		a, _, _, _, _, _, _, _, _ := o.Elements()
+		n := math.Sqrt(o.Origin.μ / math.Pow(a, 3))
+		g.PitchFrequency = n * math.Sqrt(3*(Ir-Iy)/Ip)
+	}
+	return g
+}
+
+// GravityGradientAttitude returns the stable gravity gradient equilibrium of these mass properties (in the Lagrange
+// region): the minor axis points to the nadir, the major axis is along the orbit normal and the intermediate axis is
+// in-track.
+func (mp MassProperties) GravityGradientAttitude() AttitudeFunc {
+	_, axes := mp.PrincipalAxes()
+	// Rows of the DCM from the body frame to the NadirPointing frame: intermediate, -major and minor axes.
+	body2nadir := mat64.NewDense(3, 3, nil)
+	for j := 0; j < 3; j++ {
+		body2nadir.Set(0, j, axes.At(j, 1))
+		body2nadir.Set(1, j, -axes.At(j, 2))
+		body2nadir.Set(2, j, axes.At(j, 0))
+	}
+	if mat64.Det(body2nadir) < 0 {
+		for j := 0; j < 3; j++ {
+			body2nadir.Set(0, j, -body2nadir.At(0, j))
+		}
+	}
+	return func(state State) *mat64.Dense {
+		var dcm mat64.Dense
+		dcm.Mul(NadirPointing(state), body2nadir)
+		return &dcm
+	}
+}
+
+// GravityGradientTorque returns the gravity gradient torque (N m) in the body frame of the provided mass properties,
+// where R is the position of the spacecraft relative to the attracting body (km) in the body frame.
+func (mp MassProperties) GravityGradientTorque(R []float64, μ float64) []float64 {
+	r := Norm(R)
+	u := Unit(R)
+	τ := Cross(u, MxV33(mp.Inertia, u))
+	k := 3 * μ / (r * r * r)
+	return []float64{k * τ[0], k * τ[1], k * τ[2]}
+}
+
+// GravityGradientTorque returns the gravity gradient torque (N m) on the spacecraft at the provided state, in its body
+// frame, from its mass properties and attitude.
+func (sc *Spacecraft) GravityGradientTorque(state State) []float64 {
+	R := MxV33(sc.BodyToInertial(state).T(), state.Orbit.R())
+	return sc.MassProperties(state.DT).GravityGradientTorque(R, state.Orbit.Origin.μ)
+}
@@ -0,0 +1,118 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+// rotatedInertia returns the diagonal inertia rotated by the DCM, i.e. with the principal axes as its columns.
+func rotatedInertia(diag []float64, dcm *mat64.Dense) *mat64.SymDense {
+	var tmp, I mat64.Dense
+	tmp.Mul(dcm, mat64.NewDense(3, 3, []float64{diag[0], 0, 0, 0, diag[1], 0, 0, 0, diag[2]}))
+	I.Mul(&tmp, dcm.T())
+	sym := mat64.NewSymDense(3, nil)
+	for i := 0; i < 3; i++ {
+		for j := i; j < 3; j++ {
+			sym.SetSym(i, j, (I.At(i, j)+I.At(j, i))/2)
+		}
+	}
+	return sym
+}
+
+func TestPrincipalAxesSpinStability(t *testing.T) {
+	dcm := NewQuaternion([]float64{1, 2, 3}, Deg2rad(50)).DCM()
+	mp := NewPointMass(100, []float64{0, 0, 0})
+	mp.Inertia = rotatedInertia([]float64{300, 100, 200}, dcm)
+	moments, axes := mp.PrincipalAxes()
+	if !floats.EqualApprox(moments, []float64{100, 200, 300}, 1e-9) || math.Abs(mat64.Det(axes)-1) > 1e-12 {
+		t.Fatalf("invalid principal moments %v", moments)
+	}
+	column := func(m *mat64.Dense, j int) []float64 {
+		return []float64{m.At(0, j), m.At(1, j), m.At(2, j)}
+	}
+	// The minor axis is the second column of the DCM, and the major axis the first one.
+	for k, j := range []int{1, 2, 0} {
+		if math.Abs(math.Abs(Dot(column(axes, k), column(dcm, j)))-1) > 1e-9 {
+			t.Fatalf("principal axis %d is not aligned", k)
+		}
+	}
+	for j, exp := range []SpinStability{SpinMajorAxis, SpinMinorAxis, SpinUnstable} {
+		if s, err := mp.SpinStability(column(dcm, j)); err != nil || s != exp {
+			t.Fatalf("spin about axis %d: %s (%v) instead of %s", j, s, err, exp)
+		}
+	}
+	if _, err := mp.SpinStability([]float64{1, 1, 0}); err == nil {
+		t.Fatal("expected an error for a non principal axis")
+	}
+	// Any axis of a sphere is a major axis.
+	sphere := NewUniformSphere(10, 1, []float64{0, 0, 0})
+	if s, err := sphere.SpinStability([]float64{1, 2, 3}); err != nil || s != SpinMajorAxis {
+		t.Fatalf("spin of a sphere: %s (%v)", s, err)
+	}
+}
+
+func TestGravityGradientStability(t *testing.T) {
+	o := NewOrbitFromOE(Earth.Radius+700, 0, 45, 0, 0, 0, Earth)
+	n := 2 * math.Pi / o.Period().Seconds()
+	mp := NewPointMass(100, []float64{0, 0, 0})
+	for _, test := range []struct {
+		roll, pitch, yaw float64
+		pitchStable      bool
+		rollYawStable    bool
+	}{
+		{200, 300, 100, true, true},   // Lagrange region
+		{300, 200, 100, true, false},  // Pitch stable only
+		{100, 300, 200, false, true},  // Pitch unstable
+		{100, 200, 300, false, false}, // Intermediate pitch
+	} {
+		mp.Inertia = mat64.NewSymDense(3, []float64{test.roll, 0, 0, 0, test.pitch, 0, 0, 0, test.yaw})
+		g := mp.GravityGradientStability(*o)
+		if g.Pitch != test.pitchStable || g.RollYaw != test.rollYawStable || g.Stable() != (test.pitchStable && test.rollYawStable) {
+			t.Fatalf("%+v: %s", test, g)
+		}
+		if !g.Pitch && g.PitchFrequency != 0 {
+			t.Fatalf("unstable pitch with a libration frequency: %s", g)
+		}
+	}
+	mp.Inertia = mat64.NewSymDense(3, []float64{200, 0, 0, 0, 300, 0, 0, 0, 100})
+	if g := mp.GravityGradientStability(*o); math.Abs(g.PitchFrequency-n) > 1e-3*n {
+		t.Fatalf("pitch libration at %g rad/s instead of %g", g.PitchFrequency, n)
+	}
+}
+
+func TestGravityGradientTorque(t *testing.T) {
+	start := time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC)
+	o := NewOrbitFromOE(Earth.Radius+700, 0, 45, 10, 20, 30, Earth)
+	state := State{DT: start, Orbit: *o}
+	k := 3 * Earth.μ / math.Pow(o.RNorm(), 3)
+	sc := NewEmptySC("gg", 100)
+	dry := NewPointMass(100, []float64{0, 0, 0})
+	dry.Inertia = mat64.NewSymDense(3, []float64{200, 0, 0, 0, 300, 0, 0, 0, 100})
+	sc.MassModel = &MassModel{Dry: dry}
+	if τ := sc.GravityGradientTorque(state); Norm(τ) > 1e-12*k*200 {
+		t.Fatalf("torque of %v N m at the equilibrium", τ)
+	}
+	// Pitching by θ creates a restoring torque of -3n²(Iroll-Iyaw)sinθcosθ about the pitch axis.
+	θ := Deg2rad(10)
+	s, c := math.Sincos(θ)
+	pitch := mat64.NewDense(3, 3, []float64{c, 0, s, 0, 1, 0, -s, 0, c})
+	sc.Attitude = func(st State) *mat64.Dense {
+		var dcm mat64.Dense
+		dcm.Mul(NadirPointing(st), pitch)
+		return &dcm
+	}
+	if τ := sc.GravityGradientTorque(state); !floats.EqualApprox(τ, []float64{0, -k * 100 * s * c, 0}, 1e-9*k) {
+		t.Fatalf("invalid pitch torque %v N m", τ)
+	}
+	// The equilibrium attitude of a rotated inertia aligns its principal axes with the orbit frame.
+	dry.Inertia = rotatedInertia([]float64{300, 100, 200}, NewQuaternion([]float64{-1, 0.5, 2}, 2).DCM())
+	sc.MassModel = &MassModel{Dry: dry}
+	sc.Attitude = dry.GravityGradientAttitude()
+	if τ := sc.GravityGradientTorque(state); Norm(τ) > 1e-9*k {
+		t.Fatalf("torque of %v N m at the equilibrium", τ)
+	}
+}
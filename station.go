@@ -17,11 +17,8 @@ const (
 )
 
 var (
-	σρ             = math.Pow(5e-3, 2) // m , but all measurements in km.
-	σρDot          = math.Pow(5e-6, 2) // m/s , but all measurements in km/s.
-	DSS34Canberra  = NewSpecialStation("DSS34Canberra", 0.691750, 0, -35.398333, 148.981944, σρ, σρDot, 6)
-	DSS65Madrid    = NewSpecialStation("DSS65Madrid", 0.834939, 0, 40.427222, 4.250556, σρ, σρDot, 6)
-	DSS13Goldstone = NewSpecialStation("DSS13Goldstone", 1.07114904, 0, 35.247164, 243.205, σρ, σρDot, 6)
+	σρ    = math.Pow(5e-3, 2) // m , but all measurements in km.
+	σρDot = math.Pow(5e-6, 2) // m/s , but all measurements in km/s.
 )
 
 // Station defines a ground station.
@@ -30,9 +27,16 @@ type Station struct {
 	R, V                       []float64 // position and velocity in ECEF
 	LatΦ, Longθ                float64   // these are stored in radians!
 	Altitude, Elevation        float64
-	RangeNoise, RangeRateNoise *distmv.Normal // Station noise
+	RangeNoise, RangeRateNoise *distmv.Normal // Station noise, none if nil
 	Planet                     CelestialObject
-	rowsH                      int // If estimating Cr in addition to position and velocity, this needs to be 7
+	rowsH                      int               // If estimating Cr in addition to position and velocity, this needs to be 7
+	Troposphere                *Troposphere      // Tropospheric delay of the measurements, none if nil
+	Ionosphere                 *Ionosphere       // Ionospheric delay of the measurements, none if nil
+	Link                       *LinkBudget       // RF link which scales the noise with the SNR, none if nil
+	DataRate                   *TrackingDataRate // Content of the passes, every observable of every state if nil
+	Mode                       TrackingMode      // Light time geometry of the measurements
+	Clock, SpacecraftClock     *Clock            // Clocks of one-way measurements, perfect if nil
+	DSS                        int               // Deep Space Station number of the DSN antennas, zero otherwise
 }
 
 // PerformMeasurement returns whether the SC is visible, and if so, the measurement.
@@ -47,9 +51,76 @@ func (s Station) PerformMeasurement(θgst float64, state State) Measurement {
 		vDiffECEF[i] = (vECEF[i] - s.V[i]) / ρ
 	}
 	ρDot := mat64.Dot(mat64.NewVector(3, ρECEF), mat64.NewVector(3, vDiffECEF))
-	ρNoisy := ρ + s.RangeNoise.Rand(nil)[0]
-	ρDotNoisy := ρDot + s.RangeRateNoise.Rand(nil)[0]
-	return Measurement{el >= s.Elevation, ρNoisy, ρDotNoisy, ρ, ρDot, θgst, state, s}
+	var τ float64
+	if s.Mode != InstantaneousTracking {
+		ρ, ρDot, τ = s.lightTime(θgst, state.Orbit)
+	}
+	clock, clockRate := s.clockOffsets(state.DT, τ)
+	visible := el >= s.Elevation
+	snr, scale := math.Inf(1), 1.
+	if s.Link != nil {
+		snr = s.Link.SNR(ρ)
+		scale = s.Link.noiseScale(snr)
+		visible = visible && snr >= s.Link.MinSNR
+	}
+	ρNoisy, ρDotNoisy := ρ, ρDot
+	if s.RangeNoise != nil {
+		ρNoisy += scale * s.RangeNoise.Rand(nil)[0]
+	}
+	if s.RangeRateNoise != nil {
+		ρDotNoisy += scale * s.RangeRateNoise.Rand(nil)[0]
+	}
+	var delay, delayRate float64
+	if el > 0 && (s.Troposphere != nil || s.Ionosphere != nil) {
+		// The rate of the delay is computed from the elevation one second later.
+		R, V := state.Orbit.RV()
+		rLater := make([]float64, 3)
+		for i := 0; i < 3; i++ {
+			rLater[i] = R[i] + V[i]
+		}
+		_, _, elLater, _ := s.RangeElAz(ECI2ECEF(rLater, θgst+s.Planet.RotRate))
+		delay = s.mediaDelay(el)
+		delayRate = s.mediaDelay(elLater) - delay
+	}
+	return Measurement{visible, ρNoisy + delay + clock, ρDotNoisy + delayRate + clockRate, ρ, ρDot, θgst, state, s, delay, delayRate, snr, false, false, τ, clock, clockRate, nil}
+}
+
+// Measure returns the measurement of the state, using the rotation angle of the body of the station at the
+// epoch of the state. The state must orbit the body of the station. For Earth stations, the state is rotated to
+// the true equator and equinox of date and the station by GAST, so that the geometry does not drift with
+// precession and nutation as the epoch gets further from J2000.
+func (s Station) Measure(state State) Measurement {
+	if !state.Orbit.Origin.Equals(s.Planet) {
+		panic(fmt.Errorf("station %s is on %s but the spacecraft orbits %s", s.Name, s.Planet.Name, state.Orbit.Origin.Name))
+	}
+	θ, rotated, NP := s.rotationOf(state)
+	m := s.PerformMeasurement(θ, rotated)
+	m.State = state
+	m.trueOfDate = NP
+	return m
+}
+
+// rotationOf returns the rotation angle of the body of the station at the epoch of the state, and the state in the
+// inertial frame from which that angle applies: the true equator and equinox of date for the Earth, along with the
+// J2000 to true of date DCM, and the unchanged state (and a nil DCM) for the other bodies.
+func (s Station) rotationOf(state State) (θ float64, rotated State, NP *mat64.Dense) {
+	θ, NP = s.Planet.rotation(state.DT)
+	if NP == nil {
+		return θ, state, nil
+	}
+	R, V := state.Orbit.RV()
+	rotated = state
+	rotated.Orbit = *NewOrbitFromRV(MxV33(NP, R), MxV33(NP, V), state.Orbit.Origin)
+	return θ, rotated, NP
+}
+
+// InertialRV returns the position and velocity of the station in the inertial frame of its body at the provided
+// epoch, e.g. for surface to relay geometry.
+func (s Station) InertialRV(dt time.Time) (R, V []float64) {
+	dcm := s.Planet.BodyFixed2Inertial(dt)
+	R = MxV33(dcm, s.R)
+	V = MxV33(dcm, s.V)
+	return
 }
 
 // RangeElAz returns the range (in the SEZ frame), elevation and azimuth (in degrees) of a given R vector in ECEF.
@@ -59,9 +130,8 @@ func (s Station) RangeElAz(rECEF []float64) (ρECEF []float64, ρ, el, az float6
 		ρECEF[i] = rECEF[i] - s.R[i]
 	}
 	ρ = Norm(ρECEF)
-	rSEZ := MxV33(R3(s.Longθ), ρECEF)
-	rSEZ = MxV33(R2(math.Pi/2-s.LatΦ), rSEZ)
-	el = math.Asin(rSEZ[2]/ρ) * r2d
+	rSEZ := s.ECEF2SEZ(ρECEF)
+	el = math.Asin(math.Max(-1, math.Min(1, rSEZ[2]/ρ))) * r2d
 	az = (2*math.Pi + math.Atan2(rSEZ[1], -rSEZ[0])) * r2d
 	return
 }
@@ -77,28 +147,73 @@ func NewStation(name string, altitude, elevation, latΦ, longθ, σρ, σρDot f
 
 // NewSpecialStation same as NewStation but can specify the rows of H.
 func NewSpecialStation(name string, altitude, elevation, latΦ, longθ, σρ, σρDot float64, rowsH int) Station {
-	R := GEO2ECEF(altitude, latΦ*d2r, longθ*d2r)
-	V := Cross([]float64{0, 0, EarthRotationRate}, R)
-	seed := rand.New(rand.NewSource(time.Now().UnixNano()))
-	ρNoise, ok := distmv.NewNormal([]float64{0}, mat64.NewSymDense(1, []float64{σρ}), seed)
-	if !ok {
-		panic("NOK in Gaussian")
+	return newStation(name, Earth, altitude, elevation, latΦ, longθ, σρ, σρDot, rowsH)
+}
+
+// NewBodyStation returns a new station on the surface of the provided body, e.g. a lander on Mars or the Moon.
+// Angles in degrees.
+func NewBodyStation(name string, body CelestialObject, altitude, elevation, latΦ, longθ, σρ, σρDot float64) Station {
+	return newStation(name, body, altitude, elevation, latΦ, longθ, σρ, σρDot, 6)
+}
+
+// NewGeodeticStation returns a new Earth station from its geodetic height (in km), latitude and longitude on the
+// WGS-84 ellipsoid, as published for real ground stations. Angles in degrees.
+func NewGeodeticStation(name string, height, elevation, latΦ, longθ, σρ, σρDot float64) Station {
+	st := newStation(name, Earth, height, elevation, latΦ, longθ, σρ, σρDot, 6)
+	st.R = GEODETIC2ECEF(height, latΦ*d2r, longθ*d2r)
+	st.V = Cross([]float64{0, 0, Earth.RotRate}, st.R)
+	return st
+}
+
+func newStation(name string, body CelestialObject, altitude, elevation, latΦ, longθ, σρ, σρDot float64, rowsH int) Station {
+	R := GEO2BodyFixed(altitude, latΦ*d2r, longθ*d2r, body)
+	V := Cross([]float64{0, 0, body.RotRate}, R)
+	st := Station{name, R, V, latΦ * d2r, longθ * d2r, altitude, elevation, nil, nil, body, rowsH, nil, nil, nil, nil, InstantaneousTracking, nil, nil, 0}
+	st.setNoise(σρ, σρDot, newRand())
+	return st
+}
+
+// WithNoise returns a copy of this station with the provided range and range rate noise variances, whose
+// realizations derive from the provided seed, e.g. to reproduce the measurements of a simulation. A variance of
+// zero disables the noise of that observable.
+func (s Station) WithNoise(σρ, σρDot float64, seed int64) Station {
+	s.setNoise(σρ, σρDot, rand.New(rand.NewSource(seed)))
+	return s
+}
+
+// setNoise sets the Gaussian noise of the range and range rate with the provided variances.
+func (s *Station) setNoise(σρ, σρDot float64, rng *rand.Rand) {
+	s.RangeNoise = newGaussianNoise(σρ, rng)
+	s.RangeRateNoise = newGaussianNoise(σρDot, rng)
+}
+
+// newGaussianNoise returns a zero mean Gaussian of the provided variance, or nil if the variance is zero.
+func newGaussianNoise(variance float64, rng *rand.Rand) *distmv.Normal {
+	if variance == 0 {
+		return nil
 	}
-	ρDotNoise, ok := distmv.NewNormal([]float64{0}, mat64.NewSymDense(1, []float64{σρDot}), seed)
+	noise, ok := distmv.NewNormal([]float64{0}, mat64.NewSymDense(1, []float64{variance}), rng)
 	if !ok {
 		panic("NOK in Gaussian")
 	}
-	return Station{name, R, V, latΦ * d2r, longθ * d2r, altitude, elevation, ρNoise, ρDotNoise, Earth, rowsH}
+	return noise
 }
 
 // Measurement stores a measurement of a station.
 type Measurement struct {
-	Visible                  bool    // Stores whether or not the attempted measurement was visible from the station.
-	Range, RangeRate         float64 // Store the range and range rate
-	TrueRange, TrueRangeRate float64 // Store the true range and range rate
-	Timeθgst                 float64
-	State                    State
-	Station                  Station
+	Visible                    bool    // Stores whether or not the attempted measurement was visible from the station.
+	Range, RangeRate           float64 // Store the range and range rate
+	TrueRange, TrueRangeRate   float64 // Store the true range and range rate
+	Timeθgst                   float64
+	State                      State
+	Station                    Station
+	Delay, DelayRate           float64 // Media delays included in the range and range rate
+	SNR                        float64 // dB, infinite without link budget
+	NoRange, NoRangeRate       bool    // Set if the observable is not sampled at this epoch (cf. TrackingDataRate)
+	LightTime                  float64 // Duration of the downlink (s), zero for instantaneous tracking
+	ClockRange, ClockRangeRate float64 // Clock offsets included in the one-way range and range rate
+
+	trueOfDate *mat64.Dense // J2000 to true of date DCM if Timeθgst is the GAST, cf. Measure
 }
 
 // IsNil returns the state vector as a mat64.Vector
@@ -111,10 +226,19 @@ func (m Measurement) StateVector() *mat64.Vector {
 	return mat64.NewVector(2, []float64{m.Range, m.RangeRate})
 }
 
-// HTilde returns the H tilde matrix for this given measurement.
+// HTilde returns the H tilde matrix for this given measurement, with respect to the position and velocity, and
+// the coefficient of reflectivity if the station was created with seven rows.
 func (m Measurement) HTilde() *mat64.Dense {
-	stationR := ECEF2ECI(m.Station.R, m.Timeθgst)
-	stationV := ECEF2ECI(m.Station.V, m.Timeθgst)
+	if m.Station.rowsH > 6 {
+		return NewAugmentedState(PositionBlock, VelocityBlock, CrBlock).HTilde(m)
+	}
+	return orbitState.HTilde(m)
+}
+
+// Partials returns the partials of the range and range rate with respect to the position and velocity, and to
+// the clock offsets for one-way measurements.
+func (m Measurement) Partials() map[StateBlock]*mat64.Dense {
+	stationR, stationV := m.stationRV()
 	xS := stationR[0]
 	yS := stationR[1]
 	zS := stationR[2]
@@ -129,7 +253,7 @@ func (m Measurement) HTilde() *mat64.Dense {
 	xDot := V[0]
 	yDot := V[1]
 	zDot := V[2]
-	H := mat64.NewDense(2, m.Station.rowsH, nil)
+	H := mat64.NewDense(2, 6, nil)
 	// \partial \rho / \partial {x,y,z}
 	H.Set(0, 0, (x-xS)/m.Range)
 	H.Set(0, 1, (y-yS)/m.Range)
@@ -141,7 +265,20 @@ func (m Measurement) HTilde() *mat64.Dense {
 	H.Set(1, 3, (x-xS)/m.Range)
 	H.Set(1, 4, (y-yS)/m.Range)
 	H.Set(1, 5, (z-zS)/m.Range)
-	return H
+	partials := positionVelocityPartials(H)
+	if m.Station.Mode == OneWayTracking {
+		partials[ClockBlock] = mat64.NewDense(2, 2, []float64{1, 0, 0, 1})
+	}
+	return partials
+}
+
+// stationRV returns the position and velocity of the station in the inertial frame of the measured state.
+func (m Measurement) stationRV() (R, V []float64) {
+	R, V = ECEF2ECI(m.Station.R, m.Timeθgst), ECEF2ECI(m.Station.V, m.Timeθgst)
+	if m.trueOfDate != nil {
+		R, V = MxV33(m.trueOfDate.T(), R), MxV33(m.trueOfDate.T(), V)
+	}
+	return
 }
 
 // CSV returns the data as CSV (does *not* include the new line)
@@ -158,15 +295,13 @@ func (m Measurement) String() string {
 	return fmt.Sprintf("%s@%s", m.Station.Name, m.State.DT)
 }
 
+// BuiltinStationFromName returns the DSN station of the provided name, either its DSS identifier (e.g. "dss43") or
+// its full name (e.g. "DSS43Canberra"), case insensitive.
 func BuiltinStationFromName(name string) Station {
-	switch strings.ToLower(name) {
-	case "dss13":
-		return DSS13Goldstone
-	case "dss34":
-		return DSS34Canberra
-	case "dss65":
-		return DSS65Madrid
-	default:
-		panic(fmt.Errorf("unknown station `%s`", name))
+	for _, st := range DSNStations {
+		if strings.EqualFold(st.Name, name) || strings.EqualFold(fmt.Sprintf("DSS%d", st.DSS), name) {
+			return st
+		}
 	}
+	panic(fmt.Errorf("unknown station `%s`", name))
 }
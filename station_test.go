@@ -0,0 +1,119 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestBodyStation(t *testing.T) {
+	dt := time.Date(2020, 7, 4, 12, 0, 0, 0, time.UTC)
+	lander := NewBodyStation("lander", Mars, 0, 10, 10, 30, σρ, σρDot)
+	if !lander.Planet.Equals(Mars) {
+		t.Fatalf("station on %s", lander.Planet.Name)
+	}
+	R, V := lander.InertialRV(dt)
+	if !floats.EqualWithinAbs(Norm(R), Mars.Radius, 1e-9) || !floats.EqualWithinAbs(Norm(V), Mars.RotRate*Mars.Radius*math.Cos(Deg2rad(10)), 1e-12) {
+		t.Fatalf("invalid inertial state R=%+v V=%+v", R, V)
+	}
+	// A spacecraft at the areostationary altitude right above the lander and rotating with Mars.
+	alt := 17031.
+	θ := Mars.RotationAngle(dt)
+	rSC := ECEF2ECI(GEO2BodyFixed(alt, Deg2rad(10), Deg2rad(30), Mars), θ)
+	vSC := Cross([]float64{0, 0, Mars.RotRate}, rSC)
+	m := lander.Measure(State{DT: dt, Orbit: *NewOrbitFromRV(rSC, vSC, Mars)})
+	if !m.Visible || !floats.EqualWithinAbs(m.TrueRange, alt, 1e-6) || !floats.EqualWithinAbs(m.TrueRangeRate, 0, 1e-9) {
+		t.Fatalf("invalid measurement: visible=%v ρ=%f ρDot=%f", m.Visible, m.TrueRange, m.TrueRangeRate)
+	}
+	_, _, el, _ := lander.RangeElAz(ECI2ECEF(rSC, θ))
+	if !floats.EqualWithinAbs(el, 90, 1e-6) {
+		t.Fatalf("elevation %f", el)
+	}
+	// Half a sol later, the spacecraft is below the horizon if it stayed inertially fixed.
+	later := dt.Add(time.Duration(math.Pi / Mars.RotRate * 1e9))
+	if m := lander.Measure(State{DT: later, Orbit: *NewOrbitFromRV(rSC, vSC, Mars)}); m.Visible {
+		t.Fatal("spacecraft should not be visible half a sol later")
+	}
+	assertPanic(t, func() {
+		lander.Measure(State{DT: dt, Orbit: *NewOrbitFromRV(rSC, vSC, Earth)})
+	})
+}
+
+func TestEarthStationRotation(t *testing.T) {
+	dt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	if Earth.RotationAngle(dt) != GAST(dt) {
+		t.Fatal("Earth rotation should use GAST")
+	}
+	// The body fixed frame of the Earth is the same as that of the measurements.
+	var dcm mat64.Dense
+	dcm.Mul(R3(GAST(dt)), J20002TOD(dt))
+	if !mat64.EqualApprox(Earth.Inertial2BodyFixed(dt), &dcm, 1e-15) {
+		t.Fatal("Earth body fixed frame should be true of date")
+	}
+	if !DSS65Madrid.Planet.Equals(Earth) {
+		t.Fatal("DSN stations should be on Earth")
+	}
+	if moon, err := CelestialObjectFromString("moon"); err != nil || !moon.Equals(Moon) {
+		t.Fatal("Moon not found")
+	}
+}
+
+func TestStationNoise(t *testing.T) {
+	dt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := NewStation("st", 0, 0, 40, -105, 0, 0)
+	// Spacecraft above the station.
+	rSC := ECEF2ECI(GEO2BodyFixed(20000, Deg2rad(40), Deg2rad(-105), Earth), Earth.RotationAngle(dt))
+	state := State{DT: dt, Orbit: *NewOrbitFromRV(rSC, []float64{0, 1, 3}, Earth)}
+	if m := st.Measure(state); !m.Visible || m.Range != m.TrueRange || m.RangeRate != m.TrueRangeRate {
+		t.Fatalf("noise without variance: ρ=%f (%f) ρDot=%f (%f)", m.Range, m.TrueRange, m.RangeRate, m.TrueRangeRate)
+	}
+	σ2ρ, σ2ρDot := 1e-4, 1e-8
+	noisy, replica := st.WithNoise(σ2ρ, σ2ρDot, 7), st.WithNoise(σ2ρ, σ2ρDot, 7)
+	n := 5000
+	var sumρ, sumρDot float64
+	for i := 0; i < n; i++ {
+		m := noisy.Measure(state)
+		if r := replica.Measure(state); r.Range != m.Range || r.RangeRate != m.RangeRate {
+			t.Fatal("stations with the same seed differ")
+		}
+		sumρ += math.Pow(m.Range-m.TrueRange, 2)
+		sumρDot += math.Pow(m.RangeRate-m.TrueRangeRate, 2)
+	}
+	if !floats.EqualWithinRel(sumρ/float64(n), σ2ρ, 0.1) || !floats.EqualWithinRel(sumρDot/float64(n), σ2ρDot, 0.1) {
+		t.Fatalf("variances %e and %e", sumρ/float64(n), sumρDot/float64(n))
+	}
+	// The original station is unchanged.
+	if st.RangeNoise != nil {
+		t.Fatal("WithNoise modified the station")
+	}
+}
+
+func TestStationTrueOfDate(t *testing.T) {
+	dt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	st := NewStation("st", 0, 0, 40, -105, 0, 0)
+	state := State{DT: dt, Orbit: *NewOrbitFromOE(Earth.Radius+20000, 0.01, 45, 180, 10, 5, Earth)}
+	m := st.Measure(state)
+	// The station in J2000 after undoing the rotation by GAST, nutation and precession.
+	var ECEF2J2000 mat64.Dense
+	ECEF2J2000.Mul(R3(GAST(dt)), J20002TOD(dt))
+	rS := MxV33(ECEF2J2000.T(), st.R)
+	R := state.Orbit.R()
+	ρ := []float64{R[0] - rS[0], R[1] - rS[1], R[2] - rS[2]}
+	if math.Abs(m.TrueRange-Norm(ρ)) > 1e-6 {
+		t.Fatalf("range %f km instead of %f km", m.TrueRange, Norm(ρ))
+	}
+	H := m.HTilde()
+	for i := 0; i < 3; i++ {
+		if math.Abs(H.At(0, i)-ρ[i]/m.Range) > 1e-9 {
+			t.Fatalf("H tilde not in J2000: %v", mat64.Formatted(H))
+		}
+	}
+	// Ignoring the precession since J2000 misplaces the station by tens of kilometers.
+	old := ECEF2ECI(st.R, gmst(dt))
+	if Δ := Norm([]float64{old[0] - rS[0], old[1] - rS[1], old[2] - rS[2]}); Δ < 10 {
+		t.Fatalf("GMST only station off by %f km", Δ)
+	}
+}
@@ -0,0 +1,142 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// GEOStationKeeping simulates the station-keeping of a geostationary spacecraft. It monitors the longitude drift
+// and the inclination, and executes impulsive East/West and North/South maneuvers to stay within the deadbands.
+// The perturbations are those of the provided Mission (e.g. J2, third body, SRP).
+type GEOStationKeeping struct {
+	Longitude           float64       // Target longitude (degrees East)
+	LongitudeDeadband   float64       // Half width of the longitude deadband (degrees)
+	InclinationDeadband float64       // Maximum inclination (degrees)
+	CheckInterval       time.Duration // Interval between two deadband checks
+	DriftCycle          time.Duration // Time allotted to drift back to the target longitude after an E/W maneuver
+	Maneuvers           []StationKeepingManeuver
+	startDT, endDT      time.Time
+	prevDT              time.Time // Epoch of the previous longitude check
+	prevΔλ              float64   // Longitude offset at the previous check
+}
+
+// StationKeepingManeuver stores an executed station-keeping maneuver.
+type StationKeepingManeuver struct {
+	DT       time.Time
+	EastWest bool      // Set to false for a North/South maneuver
+	Δv       []float64 // Inertial Δv in km/s
+}
+
+func (m StationKeepingManeuver) String() string {
+	kind := "N/S"
+	if m.EastWest {
+		kind = "E/W"
+	}
+	return fmt.Sprintf("%s burn of %.3f m/s @ %s", kind, Norm(m.Δv)*1e3, m.DT)
+}
+
+// NewGEOStationKeeping returns a new GEO station-keeping with a daily check and a fourteen day drift cycle.
+func NewGEOStationKeeping(longitude, longitudeDeadband, inclinationDeadband float64) *GEOStationKeeping {
+	return &GEOStationKeeping{longitude, longitudeDeadband, inclinationDeadband, 24 * time.Hour, 14 * 24 * time.Hour, nil, time.Time{}, time.Time{}, time.Time{}, 0}
+}
+
+// Run propagates the mission until its StopDT and performs the station-keeping maneuvers. Blocking.
+func (sk *GEOStationKeeping) Run(m *Mission) {
+	if !m.Orbit.Origin.Equals(Earth) {
+		panic("GEO station-keeping requires an Earth orbit")
+	}
+	sk.startDT = m.CurrentDT
+	sk.endDT = m.StopDT
+	for dt := sk.startDT.Add(sk.CheckInterval); ; dt = dt.Add(sk.CheckInterval) {
+		last := !dt.Before(sk.endDT)
+		if last {
+			dt = sk.endDT
+		}
+		m.PropagateUntil(dt, last)
+		if last {
+			break
+		}
+		if _, _, i, _, _, _, _, _, _ := m.Orbit.Elements(); Rad2deg180(i) > sk.InclinationDeadband {
+			// Propagate to the next node, where the inclination can be removed with a single burn.
+			_, _, _, _, _, _, _, _, u := m.Orbit.Elements()
+			n := 2 * math.Pi / m.Orbit.Period().Seconds()
+			nodeDT := m.CurrentDT.Add(time.Duration((math.Pi-math.Mod(u, math.Pi))/n) * time.Second)
+			if nodeDT.Before(sk.endDT) {
+				m.PropagateUntil(nodeDT, false)
+				sk.northSouth(m)
+			}
+		}
+		Δλ := sk.longitudeOffset(*m.Orbit, m.CurrentDT)
+		if !sk.prevDT.IsZero() && math.Abs(Δλ) > sk.LongitudeDeadband {
+			sk.eastWest(m, Δλ)
+		}
+		sk.prevDT = m.CurrentDT
+		sk.prevΔλ = Δλ
+	}
+}
+
+// longitudeOffset returns the offset in degrees between the longitude of the spacecraft and the target longitude.
+func (sk *GEOStationKeeping) longitudeOffset(o Orbit, dt time.Time) float64 {
+	R := MxV33(Earth.Inertial2BodyFixed(dt), o.R())
+	return Rad2deg180(math.Atan2(R[1], R[0]) - Deg2rad(sk.Longitude))
+}
+
+// eastWest sets a drift rate which brings the spacecraft back to the target longitude in one drift cycle, unless
+// it is already drifting back.
+func (sk *GEOStationKeeping) eastWest(m *Mission, Δλ float64) {
+	a, _, _, _, _, _, _, _, _ := m.Orbit.Elements()
+	// The drift rate is measured because the osculating semi-major axis is biased by J2.
+	λDot := (Δλ - sk.prevΔλ) * deg2rad / m.CurrentDT.Sub(sk.prevDT).Seconds()
+	if Sign(λDot) != Sign(Δλ) {
+		// Already drifting back towards the target longitude.
+		return
+	}
+	λDotTarget := -Deg2rad(math.Abs(Δλ)) * Sign(Δλ) / sk.DriftCycle.Seconds()
+	// For a near circular orbit, the drift rate changes by -3Δv/a.
+	Δv := -a / 3 * (λDotTarget - λDot)
+	vUnit := Unit(m.Orbit.V())
+	sk.apply(m, []float64{Δv * vUnit[0], Δv * vUnit[1], Δv * vUnit[2]}, true)
+}
+
+// northSouth removes the inclination by zeroing the out of plane velocity, which is valid at the nodes.
+func (sk *GEOStationKeeping) northSouth(m *Mission) {
+	V := m.Orbit.V()
+	vNorm := Norm(V)
+	Vf := []float64{V[0], V[1], 0}
+	scale := vNorm / Norm(Vf)
+	sk.apply(m, []float64{Vf[0]*scale - V[0], Vf[1]*scale - V[1], -V[2]}, false)
+}
+
+func (sk *GEOStationKeeping) apply(m *Mission, Δv []float64, eastWest bool) {
+	R, V := m.Orbit.RV()
+	for i := 0; i < 3; i++ {
+		V[i] += Δv[i]
+	}
+	*m.Orbit = *NewOrbitFromRV(R, V, m.Orbit.Origin)
+	maneuver := StationKeepingManeuver{m.CurrentDT, eastWest, Δv}
+	sk.Maneuvers = append(sk.Maneuvers, maneuver)
+	m.Vehicle.logger.Log("level", "notice", "subsys", "astro", "date", m.CurrentDT, "station-keeping", maneuver)
+}
+
+// TotalΔv returns the total East/West and North/South Δv in m/s.
+func (sk *GEOStationKeeping) TotalΔv() (eastWest, northSouth float64) {
+	for _, m := range sk.Maneuvers {
+		if m.EastWest {
+			eastWest += Norm(m.Δv) * 1e3
+		} else {
+			northSouth += Norm(m.Δv) * 1e3
+		}
+	}
+	return
+}
+
+// AnnualΔv returns the East/West and North/South Δv in m/s per year over the simulated duration.
+func (sk *GEOStationKeeping) AnnualΔv() (eastWest, northSouth float64) {
+	years := sk.endDT.Sub(sk.startDT).Hours() / (24 * 365.25)
+	if years <= 0 {
+		return 0, 0
+	}
+	eastWest, northSouth = sk.TotalΔv()
+	return eastWest / years, northSouth / years
+}
@@ -0,0 +1,49 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGEOStationKeeping(t *testing.T) {
+	start := time.Date(2017, 3, 20, 0, 0, 0, 0, time.UTC)
+	end := start.Add(60 * 24 * time.Hour)
+	// Start at the target longitude, slightly too low (drifting East) and inclined.
+	sk := NewGEOStationKeeping(-75, 0.05, 0.05)
+	rλ := MxV33(Earth.BodyFixed2Inertial(start), GEO2ECEF(0, 0, Deg2rad(sk.Longitude)))
+	θ := Rad2deg(math.Atan2(rλ[1], rλ[0]))
+	o := NewOrbitFromOE(42164-2, 0, 0.1, θ, 0, 0, Earth)
+	if Δλ := sk.longitudeOffset(*o, start); Δλ > 1e-3 || Δλ < -1e-3 {
+		t.Fatalf("initial longitude offset should be nil, got %f", Δλ)
+	}
+	m := NewPreciseMission(NewEmptySC("geo", 1000), o, start, end, Perturbations{Jn: 2}, time.Minute, false, ExportConfig{})
+	sk.Run(m)
+	var ew, ns int
+	for _, maneuver := range sk.Maneuvers {
+		if maneuver.EastWest {
+			ew++
+		} else {
+			ns++
+		}
+	}
+	if ew == 0 || ns != 1 {
+		t.Fatalf("expected several E/W and one N/S maneuvers: %+v", sk.Maneuvers)
+	}
+	if _, _, i, _, _, _, _, _, _ := m.Orbit.Elements(); Rad2deg180(i) > sk.InclinationDeadband {
+		t.Fatalf("inclination not controlled: %f", Rad2deg180(i))
+	}
+	if Δλ := sk.longitudeOffset(*m.Orbit, m.CurrentDT); Δλ > 2*sk.LongitudeDeadband || Δλ < -2*sk.LongitudeDeadband {
+		t.Fatalf("longitude not controlled: %f", Δλ)
+	}
+	ewΔv, nsΔv := sk.TotalΔv()
+	// N/S Δv to remove 0.1 degree at GEO: 2*3.075 km/s*sin(0.05°) ≈ 5.4 m/s
+	if nsΔv < 5 || nsΔv > 6 {
+		t.Fatalf("unexpected N/S Δv of %f m/s", nsΔv)
+	}
+	annualEW, annualNS := sk.AnnualΔv()
+	if annualNS < nsΔv*6 || annualEW < ewΔv*6 {
+		t.Fatalf("invalid annual Δv: %f %f", annualEW, annualNS)
+	}
+	t.Logf("E/W: %.3f m/s (%.3f m/s/yr), N/S: %.3f m/s", ewΔv, annualEW, nsΔv)
+}
@@ -0,0 +1,86 @@
+package smd
+
+import (
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// KeplerSTM returns the closed form two body state transition matrix of the position and velocity from the provided
+// orbit over the provided duration (Battin, 1999, section 9.7), which is valid for all conics and much faster than
+// integrating the variational equations, e.g. for a quick covariance mapping.
+func KeplerSTM(o Orbit, dt time.Duration) *mat64.Dense {
+	R0, V0 := o.RV()
+	R, V := keplerPropagate(o, dt).RV()
+	μ := o.Origin.μ
+	sμ := math.Sqrt(μ)
+	Δt := dt.Seconds()
+	r0, r := Norm(R0), Norm(R)
+	χ, α := universalAnomaly(R0, V0, μ, Δt)
+	ψ := χ * χ * α
+	c2, c3 := stumpff(ψ)
+	c4, c5 := 1/24., 1/120.
+	if math.Abs(ψ) > 1e-6 {
+		c4, c5 = (0.5-c2)/ψ, (1/6.-c3)/ψ
+	}
+	// Universal functions and Lagrange coefficients.
+	U1 := χ * (1 - ψ*c3)
+	U2 := χ * χ * c2
+	U4 := math.Pow(χ, 4) * c4
+	U5 := math.Pow(χ, 5) * c5
+	F := 1 - U2/r0
+	G := Δt - χ*χ*χ*c3/sμ
+	Ft := -sμ * U1 / (r * r0)
+	Gt := 1 - U2/r
+	C := (3*U5-χ*U4)/sμ - Δt*U2
+	ΔR := []float64{R[0] - R0[0], R[1] - R0[1], R[2] - R0[2]}
+	ΔV := []float64{V[0] - V0[0], V[1] - V0[1], V[2] - V0[2]}
+	r03, r3 := r0*r0*r0, r*r*r
+	rDotv := Dot(R, V)
+	Φ := mat64.NewDense(6, 6, nil)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			δ := 0.
+			if i == j {
+				δ = 1
+			}
+			Φ.Set(i, j, r/μ*ΔV[i]*ΔV[j]+(r0*(1-F)*R[i]*R0[j]+C*V[i]*R0[j])/r03+F*δ)
+			Φ.Set(i, j+3, r0/μ*(1-F)*(ΔR[i]*V0[j]-ΔV[i]*R0[j])+C/μ*V[i]*V0[j]+G*δ)
+			Φ.Set(i+3, j, -ΔV[i]*R0[j]/(r0*r0)-R[i]*ΔV[j]/(r*r)-μ*C/(r3*r03)*R[i]*R0[j]+
+				Ft*(δ-R[i]*R[j]/(r*r)+(R[i]*rDotv-V[i]*r*r)*ΔV[j]/(μ*r)))
+			Φ.Set(i+3, j+3, r0/μ*ΔV[i]*ΔV[j]+(r0*(1-F)*R[i]*R0[j]-C*R[i]*V0[j])/r3+Gt*δ)
+		}
+	}
+	return Φ
+}
+
+// J2MeanSTM returns the state transition matrix of the position and velocity from the provided orbit over the
+// provided duration, for two body motion with the first order secular drifts of the node, periapsis and mean anomaly
+// due to J2 (cf. J2NodalRate). The elements of the orbit are used as mean elements, so this only captures the
+// secular effects of J2, without any integration. Panics if the orbit is not elliptical.
+func J2MeanSTM(o Orbit, dt time.Duration) *mat64.Dense {
+	return StateJacobian(o, func(o Orbit) Orbit {
+		return *j2MeanPropagate(o, dt)
+	}, CentralDifference)
+}
+
+// j2MeanPropagate returns the two body orbit after the provided duration, with the J2 secular drifts of the node,
+// periapsis and mean anomaly.
+func j2MeanPropagate(o Orbit, dt time.Duration) *Orbit {
+	Δt := dt.Seconds()
+	n, _, _, _ := o.j2SecularFactor()
+	// The drift of the mean anomaly is a shift of the time along the orbit.
+	drifted := keplerPropagate(o, dt+time.Duration(o.J2MeanAnomalyDrift()*Δt/n*float64(time.Second)))
+	R, V := drifted.RV()
+	// Rotate the periapsis about the orbit normal, and then the node about the pole.
+	ω := NewQuaternion(o.H(), o.J2ApsidalRate()*Δt)
+	Ω := NewQuaternion([]float64{0, 0, 1}, o.J2NodalRate()*Δt)
+	q := Ω.Mul(ω)
+	return NewOrbitFromRV(q.Rotate(R), q.Rotate(V), o.Origin)
+}
+
+// MapCovariance returns the covariance mapped with the provided state transition matrix, i.e. Φ P Φᵀ.
+func MapCovariance(Φ mat64.Matrix, P mat64.Symmetric) *mat64.SymDense {
+	return rotateCovariance(Φ, P)
+}
@@ -0,0 +1,89 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestKeplerSTM(t *testing.T) {
+	for _, test := range []struct {
+		o  *Orbit
+		dt time.Duration
+	}{
+		{NewOrbitFromOE(Earth.Radius+700, 0.05, 45, 10, 20, 30, Earth), 45 * time.Minute},
+		{NewOrbitFromOE(Earth.Radius+700, 0.05, 45, 10, 20, 30, Earth), -30 * time.Minute},
+		{NewOrbitFromOE(26600, 0.7, 63.4, 10, 270, 0, Earth), 10 * time.Hour},
+		{NewOrbitFromRV([]float64{7000, 100, -300}, []float64{0.5, 12.4, 3}, Earth), 3 * time.Hour},
+	} {
+		Φ := KeplerSTM(*test.o, test.dt)
+		numerical := StateJacobian(*test.o, func(o Orbit) Orbit {
+			return *keplerPropagate(o, test.dt)
+		}, CentralDifference)
+		if !mat64.EqualApprox(Φ, numerical, 1e-6) {
+			t.Fatalf("closed form STM over %s differs from the numerical one\n%v\n%v", test.dt, mat64.Formatted(Φ), mat64.Formatted(numerical))
+		}
+		if det := mat64.Det(Φ); math.Abs(det-1) > 1e-6 {
+			t.Fatalf("determinant of the STM is %f", det)
+		}
+	}
+	if Φ := KeplerSTM(*NewOrbitFromOE(Earth.Radius+700, 0, 45, 10, 20, 30, Earth), 0); !mat64.EqualApprox(Φ, DenseIdentity(6), 1e-12) {
+		t.Fatalf("STM over zero seconds is not the identity\n%v", mat64.Formatted(Φ))
+	}
+}
+
+func TestJ2MeanSTM(t *testing.T) {
+	o := NewOrbitFromOE(Earth.Radius+700, 0.01, 98, 10, 20, 30, Earth)
+	dt := 12 * time.Hour
+	// The secular drift preserves the shape and the inclination of the orbit.
+	a0, e0, i0, Ω0, _, _, _, _, _ := o.Elements()
+	a, e, i, Ω, _, _, _, _, _ := j2MeanPropagate(*o, dt).Elements()
+	if math.Abs(a-a0) > 1e-6 || math.Abs(e-e0) > 1e-9 || math.Abs(i-i0) > 1e-9 {
+		t.Fatalf("secular drift changed the orbit: a=%f e=%f i=%f", a, e, Rad2deg(i))
+	}
+	if ΔΩ := math.Remainder(Ω-Ω0, 2*math.Pi); math.Abs(ΔΩ-o.J2NodalRate()*dt.Seconds()) > 1e-9 {
+		t.Fatalf("node drifted by %f degrees instead of %f", Rad2deg(ΔΩ), Rad2deg(o.J2NodalRate()*dt.Seconds()))
+	}
+	Φ := J2MeanSTM(*o, dt)
+	if mat64.EqualApprox(Φ, KeplerSTM(*o, dt), 1e-3) {
+		t.Fatal("J2 mean STM is the two body one")
+	}
+	// The STM maps a small dispersion.
+	δ := []float64{0.01, -0.02, 0.005, 1e-5, 2e-5, -1e-5}
+	R, V := o.RV()
+	dispersed := NewOrbitFromRV([]float64{R[0] + δ[0], R[1] + δ[1], R[2] + δ[2]}, []float64{V[0] + δ[3], V[1] + δ[4], V[2] + δ[5]}, Earth)
+	Rf, Vf := j2MeanPropagate(*o, dt).RV()
+	Rd, Vd := j2MeanPropagate(*dispersed, dt).RV()
+	var δf mat64.Vector
+	δf.MulVec(Φ, mat64.NewVector(6, δ))
+	for k := 0; k < 3; k++ {
+		if math.Abs(δf.At(k, 0)-(Rd[k]-Rf[k])) > 1e-3 || math.Abs(δf.At(k+3, 0)-(Vd[k]-Vf[k])) > 1e-6 {
+			t.Fatalf("mapped dispersion %v differs from the propagated one", mat64.Formatted(δf.T()))
+		}
+	}
+}
+
+func TestMapCovariance(t *testing.T) {
+	o := NewOrbitFromOE(Earth.Radius+700, 0.01, 45, 10, 20, 30, Earth)
+	P := mat64.NewSymDense(6, nil)
+	for i := 0; i < 6; i++ {
+		P.SetSym(i, i, 1e-2)
+		if i > 2 {
+			P.SetSym(i, i, 1e-8)
+		}
+	}
+	Φ := KeplerSTM(*o, 30*time.Minute)
+	mapped := MapCovariance(Φ, P)
+	var ΦP, exp mat64.Dense
+	ΦP.Mul(Φ, P)
+	exp.Mul(&ΦP, Φ.T())
+	if !mat64.EqualApprox(mapped, &exp, 1e-12) {
+		t.Fatal("invalid mapped covariance")
+	}
+	// The in-track uncertainty grows along the orbit.
+	if ric := ECI2RICCovariance(*keplerPropagate(*o, 30*time.Minute), mapped); ric.At(1, 1) <= 1e-2 {
+		t.Fatalf("in-track variance of %f km²", ric.At(1, 1))
+	}
+}
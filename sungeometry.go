@@ -0,0 +1,70 @@
+package smd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// SunAngles stores the Sun geometry of a spacecraft. All angles are in degrees.
+type SunAngles struct {
+	Beta         float64 // Solar beta angle, i.e. elevation of the Sun above the orbital plane
+	SubSatSunEl  float64 // Elevation of the Sun at the sub-satellite point
+	SCSunBody    float64 // Angle at the Sun between the spacecraft and the central body
+	Illumination float64 // Illumination fraction from the central body shadow (conical)
+}
+
+func (a SunAngles) String() string {
+	return fmt.Sprintf("β=%.3f el=%.3f SC-Sun-body=%.6f illum=%.3f", a.Beta, a.SubSatSunEl, a.SCSunBody, a.Illumination)
+}
+
+// NewSunAngles returns the Sun geometry of the orbit where rSun is the position of the Sun with respect to the
+// center of the orbit, in the same frame.
+func NewSunAngles(o Orbit, rSun []float64) SunAngles {
+	sunUnit := Unit(rSun)
+	R := o.R()
+	sunToSC := make([]float64, 3)
+	sunToBody := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		sunToSC[i] = R[i] - rSun[i]
+		sunToBody[i] = -rSun[i]
+	}
+	return SunAngles{
+		Beta:         math.Asin(Dot(Unit(o.H()), sunUnit)) / deg2rad,
+		SubSatSunEl:  math.Asin(Dot(Unit(R), sunUnit)) / deg2rad,
+		SCSunBody:    math.Atan2(Norm(Cross(sunToSC, sunToBody)), Dot(sunToSC, sunToBody)) / deg2rad,
+		Illumination: IlluminationFraction(R, []float64{0, 0, 0}, rSun, o.Origin, ConicalShadow),
+	}
+}
+
+// SunGeometry returns the Sun geometry of the orbit at the provided epoch. Note that this requires the ephemerides
+// of the origin of the orbit.
+func SunGeometry(o Orbit, dt time.Time) SunAngles {
+	return NewSunAngles(o, sunPosition(o, dt))
+}
+
+// SunGeometryColumns are the Sun geometry columns, for use in ExportConfig.Columns.
+var SunGeometryColumns = CSVColumns{
+	{Name: "beta", Unit: "deg", Extract: func(st State) float64 { return SunGeometry(st.Orbit, st.DT).Beta }},
+	{Name: "sunElevation", Unit: "deg", Extract: func(st State) float64 { return SunGeometry(st.Orbit, st.DT).SubSatSunEl }},
+	{Name: "scSunBody", Unit: "deg", Extract: func(st State) float64 { return SunGeometry(st.Orbit, st.DT).SCSunBody }},
+	{Name: "illumination", Unit: "", Extract: func(st State) float64 { return SunGeometry(st.Orbit, st.DT).Illumination }},
+}
+
+func (a SunAngles) csv() string {
+	return fmt.Sprintf("%.6f,%.6f,%.9f,%.6f", a.Beta, a.SubSatSunEl, a.SCSunBody, a.Illumination)
+}
+
+// WriteSunGeometry writes the Sun geometry of each state of a mission timeline as a CSV table.
+func WriteSunGeometry(w io.Writer, states []State) error {
+	if _, err := fmt.Fprint(w, "time,beta,sunElevation,scSunBody,illumination\n"); err != nil {
+		return err
+	}
+	for _, st := range states {
+		if _, err := fmt.Fprintf(w, "%s,%s\n", st.DT.UTC().Format(time.RFC3339), SunGeometry(st.Orbit, st.DT).csv()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
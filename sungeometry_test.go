@@ -0,0 +1,36 @@
+package smd
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestSunAngles(t *testing.T) {
+	rSun := []float64{AU, 0, 0}
+	equatorial := NewOrbitFromOE(7000, 0, 0, 0, 0, 0, Earth)
+	angles := NewSunAngles(*equatorial, rSun)
+	if !floats.EqualWithinAbs(angles.Beta, 0, 1e-9) || !floats.EqualWithinAbs(angles.SubSatSunEl, 90, 1e-6) || angles.Illumination != 1 {
+		t.Fatalf("invalid angles at noon: %s", angles)
+	}
+	if !floats.EqualWithinAbs(angles.SCSunBody, 0, 1e-6) {
+		t.Fatalf("spacecraft is between the Sun and the Earth: %f", angles.SCSunBody)
+	}
+	midnight := NewSunAngles(*NewOrbitFromOE(7000, 0, 0, 0, 0, 180, Earth), rSun)
+	if !floats.EqualWithinAbs(midnight.SubSatSunEl, -90, 1e-6) || midnight.Illumination != 0 {
+		t.Fatalf("invalid angles at midnight: %s", midnight)
+	}
+	// Dawn-dusk orbit: the orbit normal points to the Sun.
+	dawnDusk := NewSunAngles(*NewOrbitFromOE(7000, 0, 90, 90, 0, 0, Earth), rSun)
+	if !floats.EqualWithinAbs(math.Abs(dawnDusk.Beta), 90, 1e-6) || !floats.EqualWithinAbs(dawnDusk.SubSatSunEl, 0, 1e-6) {
+		t.Fatalf("invalid angles for dawn-dusk orbit: %s", dawnDusk)
+	}
+	if exp := math.Atan(7000/AU) / deg2rad; !floats.EqualWithinRel(dawnDusk.SCSunBody, exp, 1e-6) {
+		t.Fatalf("invalid SC-Sun-body angle %f != %f", dawnDusk.SCSunBody, exp)
+	}
+	if csv := angles.csv(); len(SunGeometryColumns) != strings.Count(csv, ",")+1 {
+		t.Fatalf("columns and values mismatch: %d vs %s", len(SunGeometryColumns), csv)
+	}
+}
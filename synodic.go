@@ -0,0 +1,36 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// BodyPhaseAngle returns the heliocentric angle (in radians, in [0, 2π)) by which the arrival body leads the
+// departure body at the provided epoch, measured in the orbital plane of the departure body.
+func BodyPhaseAngle(departure, arrival CelestialObject, dt time.Time) float64 {
+	return PhaseAngle(departure.HelioOrbit(dt), arrival.HelioOrbit(dt))
+}
+
+// HohmannPhaseAngle returns the angle (in radians, in (-π, π]) by which the target on a circular orbit of radius
+// rF must lead the spacecraft on a circular orbit of radius rI at the departure of a Hohmann transfer, such that
+// both meet at the arrival. It is negative for transfers to a lower orbit, e.g. from the Earth to Venus.
+func HohmannPhaseAngle(rI, rF float64, body CelestialObject) float64 {
+	tof := math.Pi * math.Sqrt(math.Pow((rI+rF)/2, 3)/body.μ)
+	nF := math.Sqrt(body.μ / math.Pow(rF, 3))
+	θ := math.Mod(math.Pi-nF*tof, 2*math.Pi)
+	if θ <= -math.Pi {
+		θ += 2 * math.Pi
+	}
+	return θ
+}
+
+// SynodicPeriod returns the period after which both orbits return to the same relative geometry.
+// Panics if both orbits have the same period.
+func SynodicPeriod(a, b Orbit) time.Duration {
+	Ta, Tb := a.Period().Seconds(), b.Period().Seconds()
+	if Ta == Tb {
+		panic(fmt.Errorf("orbits have the same period of %s", a.Period()))
+	}
+	return time.Duration(math.Abs(Ta*Tb/(Ta-Tb)) * 1e9)
+}
@@ -0,0 +1,54 @@
+package smd
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestHohmannPhaseAngle(t *testing.T) {
+	// Vallado, example 6-8 / Curtis: the required phase angles of Earth to Mars and Earth to Venus transfers.
+	if θ := Rad2deg180(HohmannPhaseAngle(AU, 1.523679*AU, Sun)); !floats.EqualWithinAbs(θ, 44.3, 0.1) {
+		t.Fatalf("Earth to Mars phase angle of %f degrees", θ)
+	}
+	if θ := Rad2deg180(HohmannPhaseAngle(AU, 0.723332*AU, Sun)); !floats.EqualWithinAbs(θ, -54.0, 0.1) {
+		t.Fatalf("Earth to Venus phase angle of %f degrees", θ)
+	}
+	// Propagating both bodies over the transfer with the required phase angle leads to a rendezvous.
+	rI, rF := Earth.Radius+400, Earth.Radius+35786
+	θ := HohmannPhaseAngle(rI, rF, Earth)
+	chaser := NewOrbitFromOE(rI, 0, 0, 0, 0, 0, Earth)
+	target := NewOrbitFromOE(rF, 0, 0, 0, 0, Rad2deg(θ), Earth)
+	_, _, tof := Hohmann(rI, chaser.VNorm(), rF, target.VNorm(), Earth)
+	arrival := keplerPropagate(*target, tof)
+	// The chaser arrives at the apoapsis of the transfer, opposite of its departure.
+	if θf := PhaseAngle(*chaser, *arrival); !floats.EqualWithinAbs(θf, math.Pi, 1e-3) {
+		t.Fatalf("target at %f degrees from the departure at the arrival", Rad2deg(θf))
+	}
+}
+
+func TestSynodicPeriod(t *testing.T) {
+	earth := NewOrbitFromOE(AU, 0, 0, 0, 0, 0, Sun)
+	mars := NewOrbitFromOE(1.523679*AU, 0, 0, 0, 0, 0, Sun)
+	if days := SynodicPeriod(*earth, *mars).Hours() / 24; !floats.EqualWithinAbs(days, 780, 2) {
+		t.Fatalf("Earth Mars synodic period of %f days", days)
+	}
+	if SynodicPeriod(*earth, *mars) != SynodicPeriod(*mars, *earth) {
+		t.Fatal("synodic period is not symmetric")
+	}
+	// The phase angle is the same after a synodic period.
+	a := NewOrbitFromOE(7000, 0, 30, 10, 0, 0, Earth)
+	b := NewOrbitFromOE(9000, 0, 30, 10, 0, 70, Earth)
+	T := SynodicPeriod(*a, *b)
+	θ0 := PhaseAngle(*a, *b)
+	if θ := PhaseAngle(*keplerPropagate(*a, T), *keplerPropagate(*b, T)); !floats.EqualWithinAbs(θ, θ0, 1e-4) {
+		t.Fatalf("phase angle of %f after a synodic period of %s, expected %f", θ, T, θ0)
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("same periods did not panic")
+		}
+	}()
+	SynodicPeriod(*a, *a)
+}
@@ -0,0 +1,53 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TargetEphemeris returns the orbit of a moving target at the provided epoch, e.g. for an orbit target which tracks
+// it (cf. NewEphemerisTarget).
+type TargetEphemeris func(dt time.Time) Orbit
+
+// BodyTargetEphemeris returns the heliocentric orbit of the body from the provided ephemerides, e.g. HelioEphemeris.
+func BodyTargetEphemeris(body CelestialObject, ephem EphemerisFunc) TargetEphemeris {
+	return func(dt time.Time) Orbit {
+		R, V := ephem(body, dt)
+		return *NewOrbitFromRV(R, V, Sun)
+	}
+}
+
+// KeplerTargetEphemeris returns the two body motion of the orbit from the provided epoch, e.g. for a comet defined by
+// its osculating elements.
+func KeplerTargetEphemeris(o Orbit, epoch time.Time) TargetEphemeris {
+	return func(dt time.Time) Orbit {
+		return *keplerPropagate(o, dt.Sub(epoch))
+	}
+}
+
+// StatesTargetEphemeris returns the ephemeris of the provided states, e.g. read from an OEM file (cf. ReadOEM): the
+// orbit at any epoch is the two body propagation of the closest state, so the states should be close enough for the
+// perturbations to be negligible in between. The states must be sorted by epoch and have the same origin.
+func StatesTargetEphemeris(states []State) (TargetEphemeris, error) {
+	if len(states) == 0 {
+		return nil, errors.New("no states")
+	}
+	for k := 1; k < len(states); k++ {
+		if !states[k].DT.After(states[k-1].DT) {
+			return nil, fmt.Errorf("state %d at %s is not after the previous one", k, states[k].DT)
+		}
+		if !states[k].Orbit.Origin.Equals(states[0].Orbit.Origin) {
+			return nil, fmt.Errorf("state %d is about %s instead of %s", k, states[k].Orbit.Origin.Name, states[0].Orbit.Origin.Name)
+		}
+	}
+	return func(dt time.Time) Orbit {
+		// Index of the first state after the epoch, and then of the closest state.
+		k := sort.Search(len(states), func(k int) bool { return states[k].DT.After(dt) })
+		if k == len(states) || (k > 0 && dt.Sub(states[k-1].DT) < states[k].DT.Sub(dt)) {
+			k--
+		}
+		return *keplerPropagate(states[k].Orbit, dt.Sub(states[k].DT))
+	}, nil
+}
@@ -0,0 +1,79 @@
+package smd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestStatesTargetEphemeris(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	o := NewOrbitFromOE(Earth.Radius+700, 0.05, 45, 10, 20, 30, Earth)
+	var states []State
+	for k := 0; k < 10; k++ {
+		dt := time.Duration(k) * 10 * time.Minute
+		states = append(states, State{DT: start.Add(dt), Orbit: *keplerPropagate(*o, dt)})
+	}
+	ephem, err := StatesTargetEphemeris(states)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Within and outside of the span of the states.
+	for _, dt := range []time.Duration{-5 * time.Minute, 0, 14 * time.Minute, 16 * time.Minute, 77 * time.Minute, 2 * time.Hour} {
+		R, V := ephem(start.Add(dt)).RV()
+		Rexp, Vexp := keplerPropagate(*o, dt).RV()
+		if !floats.EqualApprox(R, Rexp, 1e-6) || !floats.EqualApprox(V, Vexp, 1e-9) {
+			t.Fatalf("%s: %+v %+v instead of %+v %+v", dt, R, V, Rexp, Vexp)
+		}
+	}
+	if _, err := StatesTargetEphemeris(nil); err == nil {
+		t.Fatal("no states should fail")
+	}
+	if _, err := StatesTargetEphemeris([]State{states[1], states[0]}); err == nil {
+		t.Fatal("unsorted states should fail")
+	}
+	helio := states[1]
+	helio.Orbit = *NewOrbitFromOE(AU, 0.1, 1, 2, 3, 4, Sun)
+	if _, err := StatesTargetEphemeris([]State{states[0], helio}); err == nil {
+		t.Fatal("different origins should fail")
+	}
+}
+
+func TestBodyTargetEphemeris(t *testing.T) {
+	epoch := time.Date(2020, 7, 20, 0, 0, 0, 0, time.UTC)
+	ephem := hohmannEphemeris(epoch)
+	dt := epoch.Add(100 * 24 * time.Hour)
+	o := BodyTargetEphemeris(Mars, ephem)(dt)
+	R, V := ephem(Mars, dt)
+	if !o.Origin.Equals(Sun) || !floats.Equal(o.R(), R) || !floats.Equal(o.V(), V) {
+		t.Fatalf("invalid orbit of Mars %s", o)
+	}
+	comet := NewOrbitFromOE(3*AU, 0.6, 10, 20, 30, 40, Sun)
+	if c := KeplerTargetEphemeris(*comet, epoch)(dt); !floats.EqualApprox(c.R(), keplerPropagate(*comet, 100*24*time.Hour).R(), 1e-6) {
+		t.Fatalf("invalid orbit of the comet %s", c)
+	}
+}
+
+func TestEphemerisTarget(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	// The semi-major axis of the target decreases by 100 km per hour.
+	ephem := func(dt time.Time) Orbit {
+		return *NewOrbitFromOE(7200-100*dt.Sub(start).Hours(), 0.01, 45, 10, 20, 30, Earth)
+	}
+	o := *NewOrbitFromOE(7100, 0.01, 45, 10, 20, 90, Earth)
+	fixed := NewOrbitTarget(ephem(start), nil, Ruggiero, TimeOptimal, OptiΔaCL)
+	moving := NewEphemerisTarget(ephem, start, nil, Ruggiero, TimeOptimal, OptiΔaCL)
+	if moving.String() == fixed.String() {
+		t.Fatal("moving target should be distinguishable")
+	}
+	if _, reached := moving.ThrustDirection(o, start); reached {
+		t.Fatal("moving target reached at the start")
+	}
+	if _, reached := fixed.ThrustDirection(o, start.Add(time.Hour)); reached {
+		t.Fatal("fixed target reached without a change of orbit")
+	}
+	if _, reached := moving.ThrustDirection(o, start.Add(time.Hour)); !reached || !moving.Cleared() {
+		t.Fatal("moving target not reached when it matches the orbit")
+	}
+}
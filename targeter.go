@@ -0,0 +1,493 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ChristopherRabotin/ode"
+	"github.com/gonum/matrix/mat64"
+)
+
+const (
+	targeterMaxIterations = 50
+	targeterStateFDStep   = 1e-6 // Relative step of the constraint partials with respect to the final state.
+)
+
+// TargeterVariable is a control variable of the targeter.
+type TargeterVariable uint8
+
+const (
+	// ΔVx is the X component of the impulsive burn (km/s).
+	ΔVx TargeterVariable = iota + 1
+	// ΔVy is the Y component of the impulsive burn (km/s).
+	ΔVy
+	// ΔVz is the Z component of the impulsive burn (km/s).
+	ΔVz
+	// BurnEpoch is the epoch of the burn, as an offset from the start (s).
+	BurnEpoch
+	// TimeOfFlight is the propagation duration from the start (s).
+	TimeOfFlight
+	// InitialX is the initial X position (km).
+	InitialX
+	// InitialY is the initial Y position (km).
+	InitialY
+	// InitialZ is the initial Z position (km).
+	InitialZ
+	// InitialVx is the initial X velocity (km/s).
+	InitialVx
+	// InitialVy is the initial Y velocity (km/s).
+	InitialVy
+	// InitialVz is the initial Z velocity (km/s).
+	InitialVz
+)
+
+func (v TargeterVariable) String() string {
+	switch v {
+	case ΔVx:
+		return "ΔVx"
+	case ΔVy:
+		return "ΔVy"
+	case ΔVz:
+		return "ΔVz"
+	case BurnEpoch:
+		return "burn epoch"
+	case TimeOfFlight:
+		return "TOF"
+	case InitialX:
+		return "initial X"
+	case InitialY:
+		return "initial Y"
+	case InitialZ:
+		return "initial Z"
+	case InitialVx:
+		return "initial Vx"
+	case InitialVy:
+		return "initial Vy"
+	case InitialVz:
+		return "initial Vz"
+	default:
+		panic(fmt.Errorf("unknown targeter variable %d", v))
+	}
+}
+
+// perturbation returns the finite differencing step of this variable.
+func (v TargeterVariable) perturbation() float64 {
+	switch v {
+	case BurnEpoch, TimeOfFlight:
+		return 1
+	case InitialX, InitialY, InitialZ:
+		return 1e-3
+	default:
+		return 1e-6
+	}
+}
+
+// TargetConstraint is a constraint on the final state of the targeter.
+type TargetConstraint struct {
+	Name      string
+	Target    float64
+	Tolerance float64
+	Eval      func(State) float64
+}
+
+func (c TargetConstraint) String() string {
+	return fmt.Sprintf("%s=%f (±%g)", c.Name, c.Target, c.Tolerance)
+}
+
+// residual returns the error of the constraint for the provided state.
+func (c TargetConstraint) residual(s State) float64 {
+	return c.Eval(s) - c.Target
+}
+
+// BRConstraint returns a constraint on the B-plane BR component (km).
+func BRConstraint(target, tolerance float64) TargetConstraint {
+	return TargetConstraint{"BR", target, tolerance, func(s State) float64 { return NewBPlane(s.Orbit).BR }}
+}
+
+// BTConstraint returns a constraint on the B-plane BT component (km).
+func BTConstraint(target, tolerance float64) TargetConstraint {
+	return TargetConstraint{"BT", target, tolerance, func(s State) float64 { return NewBPlane(s.Orbit).BT }}
+}
+
+// SMAConstraint returns a constraint on the final semi-major axis (km).
+func SMAConstraint(target, tolerance float64) TargetConstraint {
+	return TargetConstraint{"SMA", target, tolerance, func(s State) float64 {
+		a, _, _, _, _, _, _, _, _ := s.Orbit.Elements()
+		return a
+	}}
+}
+
+// EccentricityConstraint returns a constraint on the final eccentricity.
+func EccentricityConstraint(target, tolerance float64) TargetConstraint {
+	return TargetConstraint{"ecc", target, tolerance, func(s State) float64 {
+		_, e, _, _, _, _, _, _, _ := s.Orbit.Elements()
+		return e
+	}}
+}
+
+// InclinationConstraint returns a constraint on the final inclination (degrees).
+func InclinationConstraint(target, tolerance float64) TargetConstraint {
+	return TargetConstraint{"inc", target, tolerance, func(s State) float64 {
+		_, _, i, _, _, _, _, _, _ := s.Orbit.Elements()
+		return Rad2deg(i)
+	}}
+}
+
+// RMagConstraint returns a constraint on the final radius (km).
+func RMagConstraint(target, tolerance float64) TargetConstraint {
+	return TargetConstraint{"rmag", target, tolerance, func(s State) float64 { return s.Orbit.RNorm() }}
+}
+
+// PositionConstraint returns a constraint on the provided component (0 for X, 1 for Y and 2 for Z) of the final
+// position (km).
+func PositionConstraint(axis int, target, tolerance float64) TargetConstraint {
+	if axis < 0 || axis > 2 {
+		panic("position axis must be 0, 1 or 2")
+	}
+	return TargetConstraint{fmt.Sprintf("R[%d]", axis), target, tolerance, func(s State) float64 { return s.Orbit.R()[axis] }}
+}
+
+// Targeter is a shooting differential corrector: the initial orbit is propagated until the burn epoch where an
+// impulsive burn is applied, and then until the time of flight. The selected control variables are varied until
+// the constraints on the final state are met.
+type Targeter struct {
+	Initial       Orbit
+	Start         time.Time
+	BurnOffset    time.Duration // Epoch of the burn from the start
+	TOF           time.Duration // Time of flight from the start
+	ΔV            []float64     // Impulsive burn in the inertial frame (km/s)
+	Perturbations Perturbations
+	Step          time.Duration
+	UseSTM        bool // Set to true to compute the Jacobian from the STM instead of finite differencing
+	MaxIterations int
+	variables     []TargeterVariable
+	constraints   []TargetConstraint
+}
+
+// NewTargeter returns a new targeter from the initial orbit and the time of flight, with a zero burn at the start.
+func NewTargeter(initial Orbit, start time.Time, tof time.Duration, perts Perturbations) *Targeter {
+	return &Targeter{initial, start, 0, tof, []float64{0, 0, 0}, perts, StepSize, false, targeterMaxIterations, nil, nil}
+}
+
+// Vary adds control variables to the targeter.
+func (t *Targeter) Vary(variables ...TargeterVariable) {
+	for _, v := range variables {
+		for _, prev := range t.variables {
+			if prev == v {
+				panic(fmt.Errorf("variable %s is already varied", v))
+			}
+		}
+		t.variables = append(t.variables, v)
+	}
+}
+
+// Achieve adds constraints to the targeter.
+func (t *Targeter) Achieve(constraints ...TargetConstraint) {
+	t.constraints = append(t.constraints, constraints...)
+}
+
+// TargeterResult stores the outcome of the targeter.
+type TargeterResult struct {
+	Iterations int
+	Final      State
+	Residuals  []float64
+}
+
+// Solve runs the differential corrector. The receiver is updated with the solution, even if it did not converge.
+func (t *Targeter) Solve() (TargeterResult, error) {
+	if len(t.variables) == 0 || len(t.constraints) == 0 {
+		panic("targeter requires at least one variable and one constraint")
+	}
+	res := TargeterResult{Residuals: make([]float64, len(t.constraints))}
+	for res.Iterations = 0; res.Iterations <= t.MaxIterations; res.Iterations++ {
+		leg := t.propagate(t.UseSTM)
+		res.Final = leg.final
+		converged := true
+		for j, c := range t.constraints {
+			res.Residuals[j] = c.residual(leg.final)
+			if math.Abs(res.Residuals[j]) > c.Tolerance {
+				converged = false
+			}
+		}
+		if converged {
+			return res, nil
+		}
+		if res.Iterations == t.MaxIterations {
+			break
+		}
+		var J *mat64.Dense
+		if t.UseSTM {
+			J = t.stmJacobian(leg)
+		} else {
+			J = t.fdJacobian(res.Residuals)
+		}
+		δu, err := newtonStep(J, res.Residuals)
+		if err != nil {
+			return res, err
+		}
+		for i, v := range t.variables {
+			t.set(v, t.get(v)-δu.At(i, 0))
+		}
+	}
+	return res, fmt.Errorf("targeter did not converge after %d iterations (residuals: %v)", t.MaxIterations, res.Residuals)
+}
+
+// newtonStep returns the minimum norm (or least squares) correction of the variables.
+func newtonStep(J *mat64.Dense, residuals []float64) (*mat64.Dense, error) {
+	m, n := J.Dims()
+	g := mat64.NewDense(m, 1, residuals)
+	var δu mat64.Dense
+	if m <= n {
+		// δu = Jᵀ (J Jᵀ)⁻¹ g
+		var JJt, JJtInv, tmp mat64.Dense
+		JJt.Mul(J, J.T())
+		if err := JJtInv.Inverse(&JJt); err != nil {
+			return nil, errors.New("targeter Jacobian is singular")
+		}
+		tmp.Mul(&JJtInv, g)
+		δu.Mul(J.T(), &tmp)
+	} else {
+		// δu = (Jᵀ J)⁻¹ Jᵀ g
+		var JtJ, JtJInv, tmp mat64.Dense
+		JtJ.Mul(J.T(), J)
+		if err := JtJInv.Inverse(&JtJ); err != nil {
+			return nil, errors.New("targeter Jacobian is singular")
+		}
+		tmp.Mul(J.T(), g)
+		δu.Mul(&JtJInv, &tmp)
+	}
+	return &δu, nil
+}
+
+// fdJacobian returns the Jacobian of the constraints by forward finite differencing of the variables.
+func (t *Targeter) fdJacobian(residuals []float64) *mat64.Dense {
+	J := mat64.NewDense(len(t.constraints), len(t.variables), nil)
+	for i, v := range t.variables {
+		nominal := t.get(v)
+		h := v.perturbation()
+		t.set(v, nominal+h)
+		final := t.propagate(false).final
+		t.set(v, nominal)
+		for j, c := range t.constraints {
+			J.Set(j, i, (c.residual(final)-residuals[j])/h)
+		}
+	}
+	return J
+}
+
+// stmJacobian returns the Jacobian of the constraints from the STM of the legs and the finite differenced
+// partials of the constraints with respect to the final state.
+func (t *Targeter) stmJacobian(leg targeterLegs) *mat64.Dense {
+	// Partials of the final state with respect to each variable.
+	dXdU := mat64.NewDense(6, len(t.variables), nil)
+	var Φ mat64.Dense
+	Φ.Mul(leg.ΦCoast, leg.ΦBurn)
+	fFinal := twoBodyDerivative(leg.final.Orbit)
+	for i, v := range t.variables {
+		for r := 0; r < 6; r++ {
+			var val float64
+			switch v {
+			case ΔVx, ΔVy, ΔVz:
+				val = leg.ΦCoast.At(r, 3+int(v-ΔVx))
+			case BurnEpoch:
+				for k := 0; k < 3; k++ {
+					val -= leg.ΦCoast.At(r, k) * t.ΔV[k]
+				}
+			case TimeOfFlight:
+				val = fFinal[r]
+			default:
+				val = Φ.At(r, int(v-InitialX))
+			}
+			dXdU.Set(r, i, val)
+		}
+	}
+	// Partials of the constraints with respect to the final state.
+	dGdX := mat64.NewDense(len(t.constraints), 6, nil)
+	R, V := leg.final.Orbit.RV()
+	for k := 0; k < 6; k++ {
+		Rp := []float64{R[0], R[1], R[2]}
+		Vp := []float64{V[0], V[1], V[2]}
+		var h float64
+		if k < 3 {
+			h = targeterStateFDStep * math.Max(Norm(R), 1)
+			Rp[k] += h
+		} else {
+			h = targeterStateFDStep * math.Max(Norm(V), 1e-3)
+			Vp[k-3] += h
+		}
+		perturbed := State{DT: leg.final.DT, Orbit: *NewOrbitFromRV(Rp, Vp, leg.final.Orbit.Origin)}
+		for j, c := range t.constraints {
+			dGdX.Set(j, k, (c.Eval(perturbed)-c.Eval(leg.final))/h)
+		}
+	}
+	var J mat64.Dense
+	J.Mul(dGdX, dXdU)
+	return &J
+}
+
+func (t *Targeter) get(v TargeterVariable) float64 {
+	switch v {
+	case ΔVx, ΔVy, ΔVz:
+		return t.ΔV[v-ΔVx]
+	case BurnEpoch:
+		return t.BurnOffset.Seconds()
+	case TimeOfFlight:
+		return t.TOF.Seconds()
+	case InitialX, InitialY, InitialZ:
+		return t.Initial.R()[v-InitialX]
+	default:
+		return t.Initial.V()[v-InitialVx]
+	}
+}
+
+func (t *Targeter) set(v TargeterVariable, val float64) {
+	switch v {
+	case ΔVx, ΔVy, ΔVz:
+		t.ΔV[v-ΔVx] = val
+	case BurnEpoch:
+		t.BurnOffset = time.Duration(val * 1e9)
+	case TimeOfFlight:
+		t.TOF = time.Duration(val * 1e9)
+	default:
+		R := make([]float64, 3)
+		V := make([]float64, 3)
+		copy(R, t.Initial.R())
+		copy(V, t.Initial.V())
+		if v <= InitialZ {
+			R[v-InitialX] = val
+		} else {
+			V[v-InitialVx] = val
+		}
+		t.Initial = *NewOrbitFromRV(R, V, t.Initial.Origin)
+	}
+}
+
+// targeterLegs stores the outcome of the propagation of the targeter.
+type targeterLegs struct {
+	final         State
+	ΦBurn, ΦCoast *mat64.Dense // STMs from the start to the burn, and from the burn to the final epoch
+}
+
+// propagate propagates the initial orbit with the burn.
+func (t *Targeter) propagate(withSTM bool) targeterLegs {
+	if t.BurnOffset < 0 || t.BurnOffset > t.TOF {
+		panic(fmt.Errorf("burn offset %s is not within the time of flight %s", t.BurnOffset, t.TOF))
+	}
+	R, V := t.Initial.RV()
+	state := append(append([]float64{}, R...), V...)
+	state, ΦBurn := t.propagateLeg(state, t.Start, t.BurnOffset, withSTM)
+	for i := 0; i < 3; i++ {
+		state[i+3] += t.ΔV[i]
+	}
+	burnDT := t.Start.Add(t.BurnOffset)
+	state, ΦCoast := t.propagateLeg(state, burnDT, t.TOF-t.BurnOffset, withSTM)
+	finalDT := t.Start.Add(t.TOF)
+	return targeterLegs{State{DT: finalDT, Orbit: *NewOrbitFromRV(state[:3], state[3:6], t.Initial.Origin)}, ΦBurn, ΦCoast}
+}
+
+// propagateLeg propagates the state for the provided duration, in an integer number of steps.
+func (t *Targeter) propagateLeg(state []float64, start time.Time, duration time.Duration, withSTM bool) ([]float64, *mat64.Dense) {
+	if duration <= 0 {
+		return state, DenseIdentity(6)
+	}
+	steps := math.Ceil(duration.Seconds() / t.Step.Seconds())
+	prop := newShootingPropagator(t.Initial.Origin, t.Perturbations, state, start, duration.Seconds()/steps, withSTM)
+	prop.stopT = duration.Seconds()
+	ode.NewRK4(0, prop.step, prop).Solve() // Blocking.
+	return append([]float64{}, prop.state[:6]...), prop.Φ()
+}
+
+// twoBodyDerivative returns the two body time derivative of the orbit state.
+func twoBodyDerivative(o Orbit) []float64 {
+	R, V := o.RV()
+	acc := -o.Origin.μ / math.Pow(o.RNorm(), 3)
+	return []float64{V[0], V[1], V[2], acc * R[0], acc * R[1], acc * R[2]}
+}
+
+// shootingPropagator is an ode.Integrable of a perturbed Cartesian state, with its two body STM.
+type shootingPropagator struct {
+	origin         CelestialObject
+	perts          Perturbations
+	state          []float64
+	start          time.Time
+	t, step, stopT float64
+	withSTM        bool
+}
+
+func newShootingPropagator(origin CelestialObject, perts Perturbations, state []float64, start time.Time, step float64, withSTM bool) *shootingPropagator {
+	n := 6
+	if withSTM {
+		n = 42
+	}
+	s := make([]float64, n)
+	copy(s, state[:6])
+	if withSTM {
+		for i := 0; i < 6; i++ {
+			s[6+i*6+i] = 1
+		}
+	}
+	return &shootingPropagator{origin, perts, s, start, 0, step, 0, withSTM}
+}
+
+// GetState implements the ode.Integrable interface.
+func (p *shootingPropagator) GetState() []float64 {
+	return p.state
+}
+
+// SetState implements the ode.Integrable interface.
+func (p *shootingPropagator) SetState(t float64, s []float64) {
+	p.state = s
+	p.t += p.step
+}
+
+// Stop implements the ode.Integrable interface.
+func (p *shootingPropagator) Stop(t float64) bool {
+	return p.t >= p.stopT-p.step/2
+}
+
+// Func implements the ode.Integrable interface.
+func (p *shootingPropagator) Func(t float64, f []float64) []float64 {
+	fDot := make([]float64, len(f))
+	o := NewOrbitFromRV([]float64{f[0], f[1], f[2]}, []float64{f[3], f[4], f[5]}, p.origin)
+	deriv := twoBodyDerivative(*o)
+	pert := p.perts.Perturb(*o, p.start.Add(time.Duration(p.t*1e9)), Spacecraft{})
+	for i := 0; i < 6; i++ {
+		fDot[i] = deriv[i] + pert[i]
+	}
+	if p.withSTM {
+		// Two body gravity gradient only: this is enough for the convergence of the corrector.
+		A := mat64.NewDense(6, 6, nil)
+		A.Set(0, 3, 1)
+		A.Set(1, 4, 1)
+		A.Set(2, 5, 1)
+		r := o.RNorm()
+		μ := p.origin.μ
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				val := 3 * μ * f[i] * f[j] / math.Pow(r, 5)
+				if i == j {
+					val -= μ / math.Pow(r, 3)
+				}
+				A.Set(3+i, j, val)
+			}
+		}
+		var ΦDot mat64.Dense
+		ΦDot.Mul(A, mat64.NewDense(6, 6, f[6:42]))
+		for i := 0; i < 6; i++ {
+			for j := 0; j < 6; j++ {
+				fDot[6+i*6+j] = ΦDot.At(i, j)
+			}
+		}
+	}
+	return fDot
+}
+
+// Φ returns the STM of the propagation, or nil if it was not computed.
+func (p *shootingPropagator) Φ() *mat64.Dense {
+	if !p.withSTM {
+		return nil
+	}
+	return mat64.NewDense(6, 6, append([]float64{}, p.state[6:42]...))
+}
@@ -0,0 +1,109 @@
+package smd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+)
+
+func TestTargeterPosition(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	initial := *NewOrbitFromOE(7000, 0.001, 28.5, 10, 20, 30, Earth)
+	ΔV := []float64{0.01, -0.02, 0.015}
+	// Generate the reference final position.
+	ref := NewTargeter(initial, start, 45*time.Minute, Perturbations{})
+	ref.Step = 10 * time.Second
+	copy(ref.ΔV, ΔV)
+	Rf := ref.propagate(false).final.Orbit.R()
+	for _, useSTM := range []bool{false, true} {
+		tgt := NewTargeter(initial, start, 45*time.Minute, Perturbations{})
+		tgt.Step = 10 * time.Second
+		tgt.UseSTM = useSTM
+		tgt.Vary(ΔVx, ΔVy, ΔVz)
+		tgt.Achieve(PositionConstraint(0, Rf[0], 1e-4), PositionConstraint(1, Rf[1], 1e-4), PositionConstraint(2, Rf[2], 1e-4))
+		res, err := tgt.Solve()
+		if err != nil {
+			t.Fatalf("STM=%v: %s", useSTM, err)
+		}
+		if !floats.EqualApprox(tgt.ΔV, ΔV, 1e-6) {
+			t.Fatalf("STM=%v: ΔV=%+v expected %+v", useSTM, tgt.ΔV, ΔV)
+		}
+		if res.Iterations > 10 {
+			t.Fatalf("STM=%v: too many iterations: %d", useSTM, res.Iterations)
+		}
+	}
+}
+
+func TestTargeterSMA(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	tgt := NewTargeter(*NewOrbitFromOE(7000, 0.001, 28.5, 10, 20, 30, Earth), start, 30*time.Minute, Perturbations{Jn: 2})
+	tgt.Step = 10 * time.Second
+	tgt.BurnOffset = 10 * time.Minute
+	tgt.Vary(ΔVx, ΔVy, ΔVz)
+	tgt.Achieve(SMAConstraint(7200, 1e-3), InclinationConstraint(28.5, 1e-3))
+	res, err := tgt.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _, i, _, _, _, _, _, _ := res.Final.Orbit.Elements()
+	if !floats.EqualWithinAbs(a, 7200, 1e-3) || !floats.EqualWithinAbs(Rad2deg(i), 28.5, 1e-3) {
+		t.Fatalf("final orbit not achieved: %s", res.Final.Orbit)
+	}
+	if !res.Final.DT.Equal(start.Add(30 * time.Minute)) {
+		t.Fatalf("invalid final epoch %s", res.Final.DT)
+	}
+}
+
+func TestTargeterBPlane(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	approach := NewCaptureFromApoapsis(Mars, 2.5, Mars.Radius+500, Mars.Radius+500).ApproachOrbit(30, 10, 20)
+	// Start one day before periapsis.
+	R, V := approach.RV()
+	initial := *NewOrbitFromRV(append([]float64{}, R...), []float64{-V[0], -V[1], -V[2]}, Mars)
+	back := NewTargeter(initial, start, 24*time.Hour, Perturbations{})
+	back.Step = time.Minute
+	Rb, Vb := back.propagate(false).final.Orbit.RV()
+	initial = *NewOrbitFromRV(Rb, []float64{-Vb[0], -Vb[1], -Vb[2]}, Mars)
+	nominal := NewBPlane(initial)
+	tgt := NewTargeter(initial, start, 0, Perturbations{})
+	tgt.UseSTM = true
+	tgt.Vary(ΔVy, ΔVz)
+	tgt.Achieve(BRConstraint(nominal.BR+100, 1e-3), BTConstraint(nominal.BT-200, 1e-3))
+	res, err := tgt.Solve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	final := NewBPlane(res.Final.Orbit)
+	if !floats.EqualWithinAbs(final.BR, nominal.BR+100, 1e-3) || !floats.EqualWithinAbs(final.BT, nominal.BT-200, 1e-3) {
+		t.Fatalf("B-plane not achieved: %s", final)
+	}
+}
+
+func TestTargeterJacobian(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	tgt := NewTargeter(*NewOrbitFromOE(8000, 0.1, 45, 10, 20, 30, Earth), start, time.Hour, Perturbations{})
+	tgt.Step = 5 * time.Second
+	tgt.BurnOffset = 20 * time.Minute
+	tgt.ΔV = []float64{0.05, 0.02, -0.03}
+	tgt.Vary(ΔVx, BurnEpoch, TimeOfFlight, InitialX, InitialVz)
+	tgt.Achieve(PositionConstraint(0, 0, 1), RMagConstraint(0, 1), EccentricityConstraint(0, 1))
+	leg := tgt.propagate(true)
+	residuals := make([]float64, 3)
+	for j, c := range tgt.constraints {
+		residuals[j] = c.residual(leg.final)
+	}
+	fd := tgt.fdJacobian(residuals)
+	stm := tgt.stmJacobian(leg)
+	r, c := fd.Dims()
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			if !floats.EqualWithinAbsOrRel(stm.At(i, j), fd.At(i, j), 1e-4, 1e-3) {
+				t.Fatalf("Jacobian (%d, %d) for %s: STM=%e FD=%e", i, j, tgt.variables[j], stm.At(i, j), fd.At(i, j))
+			}
+		}
+	}
+	assertPanic(t, func() {
+		tgt.Vary(ΔVx)
+	})
+}
@@ -0,0 +1,148 @@
+package smd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// TCM defines a statistical trajectory correction maneuver, which nulls the position deviation of the trajectory
+// at the target epoch (e.g. the encounter or the next TCM) from the state deviation at the TCM epoch.
+type TCM struct {
+	Name       string
+	DT         time.Time
+	Covariance mat64.Symmetric // 6×6 delivery covariance of the state at the TCM epoch (km and km/s)
+	Φ          *mat64.Dense    // 6×6 STM from the TCM epoch to the target epoch
+}
+
+// gain returns the 3×6 matrix which maps the state deviation at the TCM epoch to the correction Δv, i.e.
+// -[Φrv⁻¹Φrr, I].
+func (t TCM) gain() (*mat64.Dense, error) {
+	Φrr := t.Φ.View(0, 0, 3, 3)
+	Φrv := t.Φ.View(0, 3, 3, 3)
+	var ΦrvInv mat64.Dense
+	if err := ΦrvInv.Inverse(Φrv); err != nil {
+		return nil, fmt.Errorf("%s cannot target the position (singular Φrv): %s", t.Name, err)
+	}
+	var K mat64.Dense
+	K.Mul(&ΦrvInv, Φrr)
+	G := mat64.NewDense(3, 6, nil)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			G.Set(i, j, -K.At(i, j))
+		}
+		G.Set(i, i+3, -1)
+	}
+	return G, nil
+}
+
+// ΔvCovariance returns the 3×3 covariance of the correction (km/s).
+func (t TCM) ΔvCovariance() (*mat64.SymDense, error) {
+	G, err := t.gain()
+	if err != nil {
+		return nil, err
+	}
+	return rotateCovariance(G, t.Covariance), nil
+}
+
+// TCMAllocation stores the statistical Δv of a TCM.
+type TCMAllocation struct {
+	Name       string
+	DT         time.Time
+	Stats      DispersionStats // Statistics of the Δv magnitude (km/s)
+	Percentile float64         // Δv at the percentile of the budget (km/s)
+}
+
+// TCMBudget stores the statistical Δv allocation of a sequence of TCMs.
+type TCMBudget struct {
+	Allocations      []TCMAllocation
+	Percentile       float64 // e.g. 99
+	Total            float64 // Sum of the percentile Δv of each TCM (km/s)
+	StatisticalTotal float64 // Percentile of the total Δv of all the TCMs, assumed independent (km/s)
+}
+
+// NewTCMBudget returns the Δv budget of the TCMs at the provided percentile (between 0 and 100) from a seeded
+// Monte Carlo of the provided number of samples of each delivery covariance.
+func NewTCMBudget(tcms []TCM, percentile float64, samples int, seed int64) (TCMBudget, error) {
+	if len(tcms) == 0 || samples < 2 {
+		return TCMBudget{}, errors.New("at least one TCM and two samples are required")
+	}
+	rng := rand.New(rand.NewSource(seed))
+	budget := TCMBudget{Percentile: percentile}
+	totals := make([]float64, samples)
+	for _, tcm := range tcms {
+		if tcm.Covariance.Symmetric() != 6 {
+			return TCMBudget{}, fmt.Errorf("%s covariance is not 6×6", tcm.Name)
+		}
+		G, err := tcm.gain()
+		if err != nil {
+			return TCMBudget{}, err
+		}
+		L, err := choleskyLower(tcm.Covariance)
+		if err != nil {
+			return TCMBudget{}, fmt.Errorf("%s: %s", tcm.Name, err)
+		}
+		magnitudes := make([]float64, samples)
+		z := mat64.NewVector(6, nil)
+		var δx, Δv mat64.Vector
+		for k := range magnitudes {
+			for i := 0; i < 6; i++ {
+				z.SetVec(i, rng.NormFloat64())
+			}
+			δx.MulVec(L, z)
+			Δv.MulVec(G, &δx)
+			magnitudes[k] = mat64.Norm(&Δv, 2)
+			totals[k] += magnitudes[k]
+		}
+		allocation := TCMAllocation{Name: tcm.Name, DT: tcm.DT, Stats: NewDispersionStats(magnitudes), Percentile: Percentile(magnitudes, percentile)}
+		budget.Allocations = append(budget.Allocations, allocation)
+		budget.Total += allocation.Percentile
+	}
+	budget.StatisticalTotal = Percentile(totals, percentile)
+	return budget, nil
+}
+
+func (b TCMBudget) String() string {
+	s := fmt.Sprintf("TCM budget (p%.1f): total=%.3f m/s (statistical %.3f m/s)", b.Percentile, b.Total*1e3, b.StatisticalTotal*1e3)
+	for _, a := range b.Allocations {
+		s += fmt.Sprintf("\n\t%s @ %s: mean=%.3f m/s p%.1f=%.3f m/s", a.Name, a.DT.Format(time.RFC3339), a.Stats.Mean*1e3, b.Percentile, a.Percentile*1e3)
+	}
+	return s
+}
+
+// WriteTCMBudget writes the Δv (in m/s) of each TCM of the budget as a CSV table.
+func WriteTCMBudget(w io.Writer, b TCMBudget) error {
+	if _, err := fmt.Fprint(w, "tcm,time,meanDv,stdDevDv,percentileDv\n"); err != nil {
+		return err
+	}
+	for _, a := range b.Allocations {
+		if _, err := fmt.Fprintf(w, "%s,%s,%.6f,%.6f,%.6f\n", a.Name, a.DT.UTC().Format(time.RFC3339), a.Stats.Mean*1e3, a.Stats.StdDev*1e3, a.Percentile*1e3); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PropagateSTM returns the STM of the orbit from the start to the end epoch, with the provided perturbations
+// (ignoring drag and DMC so that the STM is 6×6), e.g. to map the dispersions at a TCM to its target.
+func PropagateSTM(o Orbit, start, end time.Time, perts Perturbations, step time.Duration) *mat64.Dense {
+	orbit := NewOrbitFromRV(append([]float64(nil), o.R()...), append([]float64(nil), o.V()...), o.Origin)
+	perts.Drag = false
+	perts.DMC = nil
+	m := NewPreciseMission(NewEmptySC("stm", 0), orbit, start, end, perts, step, true, ExportConfig{})
+	stateChan := make(chan State, 10)
+	m.RegisterStateChan(stateChan)
+	go m.Propagate()
+	Φ := DenseIdentity(6)
+	for state := range stateChan {
+		// Each state stores the STM of its step only.
+		var Φk mat64.Dense
+		Φk.Mul(state.Φ, Φ)
+		Φ = &Φk
+	}
+	return Φ
+}
@@ -0,0 +1,83 @@
+package smd
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestTCMBudget(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	o := NewOrbitFromOE(42164, 0.3, 10, 20, 30, 0, Earth)
+	P := mat64.NewSymDense(6, nil)
+	for i := 0; i < 3; i++ {
+		P.SetSym(i, i, 1)        // 1 km
+		P.SetSym(i+3, i+3, 1e-8) // 0.1 m/s
+	}
+	tcm1 := TCM{"TCM-1", start, P, PropagateSTM(*o, start, start.Add(6*time.Hour), Perturbations{}, time.Minute)}
+	tcm2 := TCM{"TCM-2", start, P, PropagateSTM(*o, start, start.Add(2*time.Hour), Perturbations{}, time.Minute)}
+
+	// The correction nulls the position deviation at the target.
+	G, err := tcm1.gain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	δx := mat64.NewVector(6, []float64{0.5, -0.2, 0.1, 1e-4, 2e-5, -3e-5})
+	var Δv mat64.Vector
+	Δv.MulVec(G, δx)
+	corrected := mat64.NewVector(6, nil)
+	corrected.CopyVec(δx)
+	for i := 0; i < 3; i++ {
+		corrected.SetVec(i+3, δx.At(i+3, 0)+Δv.At(i, 0))
+	}
+	var δxf mat64.Vector
+	δxf.MulVec(tcm1.Φ, corrected)
+	for i := 0; i < 3; i++ {
+		if math.Abs(δxf.At(i, 0)) > 1e-9 {
+			t.Fatalf("position deviation at the target: %+v", mat64.Formatted(δxf.T()))
+		}
+	}
+
+	budget, err := NewTCMBudget([]TCM{tcm1, tcm2}, 99, 20000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, tcm := range []TCM{tcm1, tcm2} {
+		PΔv, err := tcm.ΔvCovariance()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var eig mat64.EigenSym
+		eig.Factorize(PΔv, false)
+		σMax := math.Sqrt(floats.Max(eig.Values(nil)))
+		if p := budget.Allocations[k].Percentile; p < 2.5*σMax || p > 3.4*σMax {
+			t.Fatalf("%s p99=%f for σmax=%f", tcm.Name, p, σMax)
+		}
+	}
+	if !floats.EqualWithinAbs(budget.Total, budget.Allocations[0].Percentile+budget.Allocations[1].Percentile, 1e-12) || budget.StatisticalTotal > budget.Total {
+		t.Fatalf("invalid totals: %s", budget)
+	}
+	// A shorter arc requires a larger correction.
+	if budget.Allocations[1].Stats.Mean < budget.Allocations[0].Stats.Mean {
+		t.Fatal("TCM-2 should require more Δv than TCM-1")
+	}
+	again, _ := NewTCMBudget([]TCM{tcm1, tcm2}, 99, 20000, 1)
+	if again.Total != budget.Total {
+		t.Fatal("budget is not reproducible")
+	}
+	var buf bytes.Buffer
+	if err := WriteTCMBudget(&buf, budget); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 {
+		t.Fatalf("invalid table:\n%s", buf.String())
+	}
+	if _, err := NewTCMBudget(nil, 99, 100, 1); err == nil {
+		t.Fatal("expected an error without TCMs")
+	}
+}
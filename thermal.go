@@ -0,0 +1,93 @@
+package smd
+
+import (
+	"fmt"
+	"time"
+)
+
+const thermalε = 1e-12
+
+// ThermalLimit is a duty cycle based thermal accumulator of the propulsion system: it heats up while thrusting
+// and cools down while coasting. Once the heat reaches the limit, thrusting is forbidden until it cools down to
+// the resume level, which forces a cool-down coast as commonly required in EP operations.
+type ThermalLimit struct {
+	HeatRate float64 // Heat gained per second of thrust
+	CoolRate float64 // Heat lost per second of coast
+	Limit    float64 // Heat at which thrusting is forbidden
+	Resume   float64 // Heat below which thrusting is allowed again after reaching the limit
+	heat     float64
+	cooling  bool
+	firing   bool
+	prevDT   time.Time
+}
+
+// NewThermalLimit returns a thermal limit which allows thrusting for maxOnTime from a cold start, and then forces a
+// coast of coolDown before thrusting again.
+func NewThermalLimit(maxOnTime, coolDown time.Duration) *ThermalLimit {
+	if maxOnTime <= 0 || coolDown <= 0 {
+		panic(fmt.Errorf("invalid thermal limit: %s on, %s cool-down", maxOnTime, coolDown))
+	}
+	return &ThermalLimit{HeatRate: 1 / maxOnTime.Seconds(), CoolRate: 1 / coolDown.Seconds(), Limit: 1}
+}
+
+// Heat returns the current heat, as a fraction of the limit.
+func (t *ThermalLimit) Heat() float64 {
+	return t.heat / t.Limit
+}
+
+// Cooling returns whether thrusting is forbidden until the propulsion system cools down.
+func (t *ThermalLimit) Cooling() bool {
+	return t.cooling
+}
+
+// advance accumulates the heat since the previous epoch, depending on whether the spacecraft was thrusting, and
+// assumes a coast until fire is called. It is called several times per integration step with the same epoch.
+func (t *ThermalLimit) advance(dt time.Time) {
+	if !t.prevDT.IsZero() && dt.After(t.prevDT) {
+		Δt := dt.Sub(t.prevDT).Seconds()
+		if t.firing {
+			t.heat += t.HeatRate * Δt
+		} else if t.heat -= t.CoolRate * Δt; t.heat < 0 {
+			t.heat = 0
+		}
+		// Tolerates rounding of the accumulated heat.
+		if ε := thermalε * t.Limit; t.heat >= t.Limit-ε {
+			t.cooling = true
+		} else if t.cooling && t.heat <= t.Resume+ε {
+			t.cooling = false
+		}
+	}
+	t.prevDT = dt
+	t.firing = false
+}
+
+// fire records that the spacecraft is thrusting, and returns false if it is not allowed to.
+func (t *ThermalLimit) fire() bool {
+	if t.cooling {
+		return false
+	}
+	t.firing = true
+	return true
+}
+
+func (t *ThermalLimit) String() string {
+	return fmt.Sprintf("heat %.1f%% of limit (cooling: %v)", 100*t.Heat(), t.cooling)
+}
+
+// ThermalColumns are the heat of the thrusters, the cooling and the thermal hold flag columns, for use in
+// ExportConfig.Columns.
+var ThermalColumns = CSVColumns{
+	{Name: "heat", Extract: func(st State) float64 { return st.SC.Guidance.Heat }},
+	{Name: "cooling", Extract: func(st State) float64 {
+		if st.SC.Guidance.Cooling {
+			return 1
+		}
+		return 0
+	}},
+	{Name: "thermalHold", Extract: func(st State) float64 {
+		if st.SC.Guidance.ThermalHold {
+			return 1
+		}
+		return 0
+	}},
+}
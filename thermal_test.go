@@ -0,0 +1,80 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestThermalLimit(t *testing.T) {
+	assertPanic(t, func() {
+		NewThermalLimit(0, time.Minute)
+	})
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	wp := NewReachDistance(1e6, true, nil)
+	sc := NewSpacecraft("thermal", 500, 100, NewUnlimitedEPS(), []EPThruster{new(PPS1350)}, false, []*Cargo{}, []Waypoint{wp})
+	sc.Thermal = NewThermalLimit(30*time.Minute, 15*time.Minute)
+	o := NewOrbitFromOE(7000, 0, 28.5, 0, 0, 0, Earth)
+	thrusting := 0
+	for minute := 0; minute < 120; minute++ {
+		dt := start.Add(time.Duration(minute) * time.Minute)
+		for i := 0; i < 4; i++ {
+			// Accelerate is called several times per integration step.
+			sc.Accelerate(dt, o)
+		}
+		if sc.Guidance.ThermalHold != sc.Thermal.Cooling() {
+			t.Fatalf("minute %d: hold flag %v but cooling %v", minute, sc.Guidance.ThermalHold, sc.Thermal.Cooling())
+		}
+		if !sc.Guidance.ThermalHold {
+			thrusting++
+		}
+		switch minute {
+		case 29:
+			if sc.Guidance.ThermalHold {
+				t.Fatalf("thermal hold before the maximum on time: %s", sc.Thermal)
+			}
+		case 30, 44:
+			if !sc.Guidance.ThermalHold {
+				t.Fatalf("minute %d: no cool-down coast: %s", minute, sc.Thermal)
+			}
+		case 45:
+			if sc.Guidance.ThermalHold {
+				t.Fatalf("thermal hold after the cool-down: %s", sc.Thermal)
+			}
+		}
+	}
+	// On 30 minutes, off 15 minutes: two thirds duty cycle.
+	if thrusting != 90 {
+		t.Fatalf("thrusted %d minutes instead of 90", thrusting)
+	}
+}
+
+func TestThermalColumns(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	wp := NewReachDistance(1e6, true, nil)
+	sc := NewSpacecraft("thermal", 500, 100, NewUnlimitedEPS(), []EPThruster{new(PPS1350)}, false, []*Cargo{}, []Waypoint{wp})
+	sc.Thermal = NewThermalLimit(30*time.Minute, 15*time.Minute)
+	o := NewOrbitFromOE(7000, 0, 28.5, 0, 0, 0, Earth)
+	m := NewPreciseMission(sc, o, start, start.Add(2*time.Hour), Perturbations{}, time.Minute, false, ExportConfig{})
+	stateChan := make(chan State, 10)
+	m.RegisterStateChan(stateChan)
+	go m.Propagate()
+	var states []State
+	for state := range stateChan {
+		states = append(states, state)
+	}
+	// The states keep the heat of their epoch, and not the one of the propagated spacecraft.
+	var maxHeat float64
+	var cooled bool
+	for _, st := range states {
+		heat, cooling := ThermalColumns[0].Extract(st), ThermalColumns[1].Extract(st)
+		if heat != st.SC.Guidance.Heat || (cooling == 1) != st.SC.Guidance.Cooling {
+			t.Fatalf("%s: columns %f %f do not match %+v", st.DT, heat, cooling, st.SC.Guidance)
+		}
+		maxHeat = math.Max(maxHeat, heat)
+		cooled = cooled || st.SC.Guidance.Cooling
+	}
+	if len(states) < 100 || states[0].SC.Guidance.Heat > 0.1 || maxHeat < 0.99 || !cooled {
+		t.Fatalf("%d states, initial heat %f, maximum heat %f, cooled %v", len(states), states[0].SC.Guidance.Heat, maxHeat, cooled)
+	}
+}
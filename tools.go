@@ -110,9 +110,25 @@ func Hohmann(rI, vI, rF, vF float64, body CelestialObject) (vDeparture, vArrival
 // along with φ which is the square of the difference in eccentric anomaly. Note that the direction of motion
 // is computed directly in this function to simplify the generation of Pork chop plots.
 func Lambert(Ri, Rf *mat64.Vector, Δt0 time.Duration, ttype TransferType, body CelestialObject) (Vi, Vf *mat64.Vector, φ float64, err error) {
-	// Initialize return variables
-	Vi = mat64.NewVector(3, nil)
-	Vf = mat64.NewVector(3, nil)
+	g, err := newLambertGeometry(Ri, Rf, ttype, body)
+	if err != nil {
+		return mat64.NewVector(3, nil), mat64.NewVector(3, nil), 0, err
+	}
+	return g.solve(Δt0)
+}
+
+// lambertGeometry stores the parts of the Lambert problem which only depend on the radii, the transfer type and
+// the central body, and are therefore shared by all the times of flight between the same radii.
+type lambertGeometry struct {
+	Ri, Rf    *mat64.Vector
+	rI, rF, A float64
+	φlow, φup float64
+	ttype     TransferType
+	μ         float64
+}
+
+// newLambertGeometry computes the direction of motion and the bounds of φ of the Lambert problem.
+func newLambertGeometry(Ri, Rf *mat64.Vector, ttype TransferType, body CelestialObject) (g lambertGeometry, err error) {
 	// Sanity checks
 	Rir, _ := Ri.Dims()
 	Rfr, _ := Rf.Dims()
@@ -120,7 +136,6 @@ func Lambert(Ri, Rf *mat64.Vector, Δt0 time.Duration, ttype TransferType, body
 		err = errors.New("initial and final radii must be 3x1 vectors")
 		return
 	}
-	Δt0Sec := Δt0.Seconds()
 	rI := mat64.Norm(Ri, 2)
 	rF := mat64.Norm(Rf, 2)
 	cosΔν := mat64.Dot(Ri, Rf) / (rI * rF)
@@ -176,6 +191,17 @@ func Lambert(Ri, Rf *mat64.Vector, Δt0 time.Duration, ttype TransferType, body
 			φlow = φBound
 		}
 	}
+	return lambertGeometry{Ri, Rf, rI, rF, A, φlow, φup, ttype, body.μ}, nil
+}
+
+// solve returns the initial and final velocities, and φ, for the provided time of flight.
+func (g lambertGeometry) solve(Δt0 time.Duration) (Vi, Vf *mat64.Vector, φ float64, err error) {
+	// Initialize return variables
+	Vi = mat64.NewVector(3, nil)
+	Vf = mat64.NewVector(3, nil)
+	Δt0Sec := Δt0.Seconds()
+	rI, rF, A := g.rI, g.rF, g.A
+	φlow, φup := g.φlow, g.φup
 	// Initial guesses for c2 and c3
 	c2 := 1 / 2.
 	c3 := 1 / 6.
@@ -201,8 +227,8 @@ func Lambert(Ri, Rf *mat64.Vector, Δt0 time.Duration, ttype TransferType, body
 			}
 		}
 		χ := math.Sqrt(y / c2)
-		Δt = (math.Pow(χ, 3)*c3 + A*math.Sqrt(y)) / math.Sqrt(body.μ)
-		if ttype != TType3 {
+		Δt = (math.Pow(χ, 3)*c3 + A*math.Sqrt(y)) / math.Sqrt(g.μ)
+		if g.ttype != TType3 {
 			if Δt <= Δt0Sec {
 				φlow = φ
 			} else {
@@ -232,18 +258,19 @@ func Lambert(Ri, Rf *mat64.Vector, Δt0 time.Duration, ttype TransferType, body
 	}
 	f := 1 - y/rI
 	gDot := 1 - y/rF
-	g := (A * math.Sqrt(y/body.μ))
+	gLagrange := (A * math.Sqrt(y/g.μ))
 	// Compute velocities
 	Rf2 := mat64.NewVector(3, nil)
-	Vi.AddScaledVec(Rf, -f, Ri)
-	Vi.ScaleVec(1/g, Vi)
-	Rf2.ScaleVec(gDot, Rf)
-	Vf.AddScaledVec(Rf2, -1, Ri)
-	Vf.ScaleVec(1/g, Vf)
+	Vi.AddScaledVec(g.Rf, -f, g.Ri)
+	Vi.ScaleVec(1/gLagrange, Vi)
+	Rf2.ScaleVec(gDot, g.Rf)
+	Vf.AddScaledVec(Rf2, -1, g.Ri)
+	Vf.ScaleVec(1/gLagrange, Vf)
 	return
 }
 
 // PCPGenerator generates the PCP files to perform contour plots in Matlab (and eventually prints the command).
+// Use Porkchop for the grids as matrices and for the direct rendering of the contour plots.
 func PCPGenerator(initPlanet, arrivalPlanet CelestialObject, initLaunch, maxLaunch, initArrival, maxArrival time.Time, ptsPerLaunchDay, ptsPerArrivalDay float64, transferType TransferType, plotC3, verbose, output bool) (c3Map, tofMap, vinfMap map[time.Time][]float64, vInfInitVecs, vInfArriVecs map[time.Time][]mat64.Vector) {
 	launchWindow := int(maxLaunch.Sub(initLaunch).Hours() / 24)    //days
 	arrivalWindow := int(maxArrival.Sub(initArrival).Hours() / 24) //days
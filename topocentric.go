@@ -0,0 +1,71 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// SEZDCM returns the DCM from the body fixed frame to the topocentric South-East-Zenith frame of the station.
+func (s Station) SEZDCM() *mat64.Dense {
+	var dcm mat64.Dense
+	dcm.Mul(R2(math.Pi/2-s.LatΦ), R3(s.Longθ))
+	return &dcm
+}
+
+// ECEF2SEZ rotates the provided body fixed vector (e.g. the position of a target relative to the station) into
+// the SEZ frame of the station.
+func (s Station) ECEF2SEZ(v []float64) []float64 {
+	return MxV33(s.SEZDCM(), v)
+}
+
+// SEZ2ECEF rotates the provided vector in the SEZ frame of the station into the body fixed frame.
+func (s Station) SEZ2ECEF(v []float64) []float64 {
+	return MxV33(s.SEZDCM().T(), v)
+}
+
+// LookAngles stores the topocentric geometry of a target seen from a station.
+type LookAngles struct {
+	DT                         time.Time
+	SEZ, SEZRate               []float64 // Position (km) and velocity (km/s) relative to the station in its SEZ frame
+	Range, RangeRate           float64   // km and km/s
+	Azimuth, Elevation         float64   // degrees, the azimuth is clockwise from the North in [0, 360)
+	AzimuthRate, ElevationRate float64   // degrees per second
+}
+
+func (l LookAngles) String() string {
+	return fmt.Sprintf("%s ρ=%.3f km ρDot=%.6f km/s az=%.3f deg el=%.3f deg", l.DT.Format(time.RFC3339), l.Range, l.RangeRate, l.Azimuth, l.Elevation)
+}
+
+// LookAngles returns the range, azimuth and elevation of the state seen from the station, and their rates, with the
+// same body fixed frame as Measure. The rates are those seen in the rotating frame of the body, as a tracking antenna
+// would follow the target. The state must orbit the body of the station.
+func (s Station) LookAngles(state State) LookAngles {
+	if !state.Orbit.Origin.Equals(s.Planet) {
+		panic(fmt.Errorf("station %s is on %s but the spacecraft orbits %s", s.Name, s.Planet.Name, state.Orbit.Origin.Name))
+	}
+	θ, rotated, _ := s.rotationOf(state)
+	R, V := rotated.Orbit.RV()
+	rECEF := ECI2ECEF(R, θ)
+	vECEF := ECI2ECEF(V, θ)
+	ω := Cross([]float64{0, 0, s.Planet.RotRate}, rECEF)
+	ρECEF, ρDotECEF := make([]float64, 3), make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		ρECEF[i] = rECEF[i] - s.R[i]
+		ρDotECEF[i] = vECEF[i] - ω[i]
+	}
+	l := LookAngles{DT: state.DT, SEZ: s.ECEF2SEZ(ρECEF), SEZRate: s.ECEF2SEZ(ρDotECEF)}
+	S, E, Z := l.SEZ[0], l.SEZ[1], l.SEZ[2]
+	Sd, Ed, Zd := l.SEZRate[0], l.SEZRate[1], l.SEZRate[2]
+	l.Range = Norm(l.SEZ)
+	l.RangeRate = Dot(l.SEZ, l.SEZRate) / l.Range
+	l.Elevation = math.Asin(math.Max(-1, math.Min(1, Z/l.Range))) * r2d
+	l.Azimuth = math.Mod(math.Atan2(E, -S)*r2d+360, 360)
+	if horizontal2 := S*S + E*E; horizontal2 > 0 {
+		l.AzimuthRate = (E*Sd - S*Ed) / horizontal2 * r2d
+		l.ElevationRate = (Zd - l.RangeRate*Z/l.Range) / math.Sqrt(horizontal2) * r2d
+	}
+	return l
+}
@@ -0,0 +1,54 @@
+package smd
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestStationLookAngles(t *testing.T) {
+	st := NewStation("st", 0, 10, 40, -105, 0, 0)
+	// The zenith of the station is along the Z axis of the SEZ frame, and the North along -S.
+	if zenith := st.ECEF2SEZ(Unit(st.R)); math.Abs(zenith[2]-1) > 1e-12 {
+		t.Fatalf("zenith in SEZ: %+v", zenith)
+	}
+	v := []float64{1, -2, 3}
+	if back := st.SEZ2ECEF(st.ECEF2SEZ(v)); !vectorsEqual(back, v) {
+		t.Fatalf("SEZ round trip: %+v", back)
+	}
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	o := NewOrbitFromOE(Earth.Radius+20000, 0.1, 50, 240, 10, 0, Earth)
+	at := func(dt time.Duration) State {
+		return State{DT: start.Add(dt), Orbit: *keplerPropagate(*o, dt)}
+	}
+	var visible int
+	for dt := time.Duration(0); dt < 12*time.Hour; dt += 20 * time.Minute {
+		state := at(dt)
+		l := st.LookAngles(state)
+		m := st.Measure(state)
+		if math.Abs(l.Range-m.TrueRange) > 1e-6 || math.Abs(l.RangeRate-m.TrueRangeRate) > 1e-9 {
+			t.Fatalf("%s: look angles differ from the measurement %s", l, m)
+		}
+		if l.Azimuth < 0 || l.Azimuth >= 360 {
+			t.Fatalf("azimuth %f", l.Azimuth)
+		}
+		if l.Elevation < st.Elevation {
+			continue
+		}
+		visible++
+		// Rates by central finite differences.
+		h := time.Second
+		before, after := st.LookAngles(at(dt-h)), st.LookAngles(at(dt+h))
+		azRate := math.Remainder(after.Azimuth-before.Azimuth, 360) / 2
+		elRate := (after.Elevation - before.Elevation) / 2
+		if math.Abs(azRate-l.AzimuthRate) > 1e-6 || math.Abs(elRate-l.ElevationRate) > 1e-6 {
+			t.Fatalf("%s: rates az %g (exp. %g) el %g (exp. %g)", l, l.AzimuthRate, azRate, l.ElevationRate, elRate)
+		}
+		if ρRate := (after.Range - before.Range) / 2; math.Abs(ρRate-l.RangeRate) > 1e-6 {
+			t.Fatalf("range rate %f instead of %f", l.RangeRate, ρRate)
+		}
+	}
+	if visible == 0 {
+		t.Fatal("the spacecraft is never visible")
+	}
+}
@@ -0,0 +1,110 @@
+package smd
+
+import "fmt"
+
+// Kilometers is a distance in km, the distance unit of smd.
+type Kilometers float64
+
+// Meters returns the distance in meters.
+func (d Kilometers) Meters() Meters {
+	return Meters(d * 1e3)
+}
+
+func (d Kilometers) String() string {
+	return fmt.Sprintf("%f km", float64(d))
+}
+
+// Meters is a distance in m, e.g. the noise of a range measurement.
+type Meters float64
+
+// Kilometers returns the distance in km.
+func (d Meters) Kilometers() Kilometers {
+	return Kilometers(d / 1e3)
+}
+
+func (d Meters) String() string {
+	return fmt.Sprintf("%f m", float64(d))
+}
+
+// KmPerSec is a velocity in km/s, the velocity unit of smd.
+type KmPerSec float64
+
+// MetersPerSec returns the velocity in m/s.
+func (v KmPerSec) MetersPerSec() MetersPerSec {
+	return MetersPerSec(v * 1e3)
+}
+
+func (v KmPerSec) String() string {
+	return fmt.Sprintf("%f km/s", float64(v))
+}
+
+// MetersPerSec is a velocity in m/s, e.g. the noise of a range rate measurement.
+type MetersPerSec float64
+
+// KmPerSec returns the velocity in km/s.
+func (v MetersPerSec) KmPerSec() KmPerSec {
+	return KmPerSec(v / 1e3)
+}
+
+func (v MetersPerSec) String() string {
+	return fmt.Sprintf("%f m/s", float64(v))
+}
+
+// Angle is an angle in either Degrees or Radians, which avoids confusing both units at the API boundary.
+type Angle interface {
+	Radians() Radians
+	Degrees() Degrees
+}
+
+// Degrees is an angle in degrees.
+type Degrees float64
+
+// Radians implements the Angle interface.
+func (θ Degrees) Radians() Radians {
+	return Radians(θ * deg2rad)
+}
+
+// Degrees implements the Angle interface.
+func (θ Degrees) Degrees() Degrees {
+	return θ
+}
+
+func (θ Degrees) String() string {
+	return fmt.Sprintf("%f deg", float64(θ))
+}
+
+// Radians is an angle in radians.
+type Radians float64
+
+// Radians implements the Angle interface.
+func (θ Radians) Radians() Radians {
+	return θ
+}
+
+// Degrees implements the Angle interface.
+func (θ Radians) Degrees() Degrees {
+	return Degrees(θ * rad2deg)
+}
+
+func (θ Radians) String() string {
+	return fmt.Sprintf("%f rad", float64(θ))
+}
+
+// NewOrbitFromKeplerian returns an orbit from its orbital elements, with explicit units, e.g.
+// NewOrbitFromKeplerian(7000, 0.01, Degrees(28.5), Radians(0), Radians(0), Degrees(90), Earth).
+func NewOrbitFromKeplerian(a Kilometers, e float64, i, Ω, ω, ν Angle, c CelestialObject) *Orbit {
+	return NewOrbitFromOE(float64(a), e, float64(i.Degrees()), float64(Ω.Degrees()), float64(ω.Degrees()), float64(ν.Degrees()), c)
+}
+
+// KeplerianElements returns the semi-major axis, the eccentricity and the angles of the orbital elements, with
+// explicit units.
+func (o Orbit) KeplerianElements() (a Kilometers, e float64, i, Ω, ω, ν Radians) {
+	aF, e, iF, ΩF, ωF, νF, _, _, _ := o.Elements()
+	return Kilometers(aF), e, Radians(iF), Radians(ΩF), Radians(ωF), Radians(νF)
+}
+
+// NewStationWithUnits returns a new station on the provided body, with explicit units. Unlike the variances of
+// NewStation, the noise of the range and range rate are their standard deviations, e.g. Meters(1).Kilometers().
+func NewStationWithUnits(name string, body CelestialObject, altitude Kilometers, elevation, latΦ, longθ Angle, σρ Kilometers, σρDot KmPerSec) Station {
+	return newStation(name, body, float64(altitude), float64(elevation.Degrees()), float64(latΦ.Degrees()), float64(longθ.Degrees()), float64(σρ*σρ), float64(σρDot*σρDot), 6)
+}
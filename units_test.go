@@ -0,0 +1,62 @@
+package smd
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/floats"
+)
+
+func TestUnits(t *testing.T) {
+	if Meters(1).Kilometers() != 1e-3 || Kilometers(1).Meters() != 1e3 {
+		t.Fatal("incorrect distance conversions")
+	}
+	if MetersPerSec(1).KmPerSec() != 1e-3 || KmPerSec(1).MetersPerSec() != 1e3 {
+		t.Fatal("incorrect velocity conversions")
+	}
+	if !floats.EqualWithinAbs(float64(Degrees(180).Radians()), math.Pi, 1e-15) || !floats.EqualWithinAbs(float64(Radians(math.Pi/2).Degrees()), 90, 1e-12) {
+		t.Fatal("incorrect angle conversions")
+	}
+	for _, angle := range []Angle{Degrees(45), Radians(math.Pi / 4)} {
+		if !floats.EqualWithinAbs(float64(angle.Degrees()), 45, 1e-12) {
+			t.Fatalf("%s is not 45 deg", angle)
+		}
+	}
+}
+
+func TestNewOrbitFromKeplerian(t *testing.T) {
+	exp := NewOrbitFromOE(7000, 0.01, 28.5, 10, 45, 90, Earth)
+	o := NewOrbitFromKeplerian(7000, 0.01, Degrees(28.5), Degrees(10), Radians(math.Pi/4), Degrees(90), Earth)
+	if !vectorsEqual(o.R(), exp.R()) || !vectorsEqual(o.V(), exp.V()) {
+		t.Fatalf("orbits differ:\n%s\n%s", o, exp)
+	}
+	a, e, i, _, ω, _ := o.KeplerianElements()
+	if !floats.EqualWithinAbs(float64(a), 7000, 1e-6) || !floats.EqualWithinAbs(e, 0.01, 1e-9) {
+		t.Fatalf("a=%s e=%f", a, e)
+	}
+	if !floats.EqualWithinAbs(float64(i.Degrees()), 28.5, 1e-9) || !floats.EqualWithinAbs(float64(ω), math.Pi/4, 1e-9) {
+		t.Fatalf("i=%s ω=%s", i, ω)
+	}
+}
+
+func TestNewStationWithUnits(t *testing.T) {
+	exp := NewBodyStation("dss", Earth, 0.5, 10, 35.4, 243.2, 1e-6, 1e-12)
+	st := NewStationWithUnits("dss", Earth, Meters(500).Kilometers(), Degrees(10), Degrees(35.4), Degrees(243.2), Meters(1).Kilometers(), MetersPerSec(1e-3).KmPerSec())
+	if !vectorsEqual(st.R, exp.R) || st.Elevation != exp.Elevation || st.LatΦ != exp.LatΦ {
+		t.Fatalf("stations differ:\n%s\n%s", st, exp)
+	}
+	// Standard deviations of 1 m and 1 mm/s.
+	var ρ2, ρDot2 float64
+	const samples = 5000
+	for i := 0; i < samples; i++ {
+		ρ, ρDot := st.RangeNoise.Rand(nil)[0], st.RangeRateNoise.Rand(nil)[0]
+		ρ2 += ρ * ρ / samples
+		ρDot2 += ρDot * ρDot / samples
+	}
+	if σρ := math.Sqrt(ρ2); !floats.EqualWithinRel(σρ, 1e-3, 0.1) {
+		t.Fatalf("range noise σ=%e km", σρ)
+	}
+	if σρDot := math.Sqrt(ρDot2); !floats.EqualWithinRel(σρDot, 1e-6, 0.1) {
+		t.Fatalf("range rate noise σ=%e km/s", σρDot)
+	}
+}
@@ -0,0 +1,151 @@
+package smd
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// UnscentedTransform maps a mean and covariance through a nonlinear function with sigma points, as per Julier &
+// Uhlmann (2004) and Wan & van der Merwe (2000). Unlike the linear mapping with the STM, it captures the
+// nonlinearity of the dynamics, e.g. over long arcs or hyperbolic flybys, and does not require any filter.
+type UnscentedTransform struct {
+	Alpha float64 // Spread of the sigma points about the mean
+	Beta  float64 // Prior knowledge of the distribution, 2 is optimal for a Gaussian
+	Kappa float64 // Secondary scaling parameter
+}
+
+// NewUnscentedTransform returns an unscented transform with the sigma points spread by the standard deviations
+// (α=1, β=2, κ=0), which keeps all the weights of the covariance positive.
+func NewUnscentedTransform() UnscentedTransform {
+	return UnscentedTransform{1, 2, 0}
+}
+
+// weights returns the scaling λ and the weights of the mean and of the covariance of the 2n+1 sigma points.
+func (u UnscentedTransform) weights(n int) (λ float64, wm, wc []float64) {
+	λ = u.Alpha*u.Alpha*(float64(n)+u.Kappa) - float64(n)
+	wm = make([]float64, 2*n+1)
+	wc = make([]float64, 2*n+1)
+	wm[0] = λ / (float64(n) + λ)
+	wc[0] = wm[0] + 1 - u.Alpha*u.Alpha + u.Beta
+	for k := 1; k <= 2*n; k++ {
+		wm[k] = 1 / (2 * (float64(n) + λ))
+		wc[k] = wm[k]
+	}
+	return
+}
+
+// SigmaPoints returns the 2n+1 sigma points of the provided mean and covariance, the first one being the mean.
+func (u UnscentedTransform) SigmaPoints(mean []float64, P mat64.Symmetric) ([][]float64, error) {
+	n := len(mean)
+	if P.Symmetric() != n {
+		panic(fmt.Errorf("covariance is %d×%d but the mean has %d components", P.Symmetric(), P.Symmetric(), n))
+	}
+	λ, _, _ := u.weights(n)
+	var scaled mat64.SymDense
+	scaled.ScaleSym(float64(n)+λ, P)
+	L, err := choleskyLower(&scaled)
+	if err != nil {
+		return nil, err
+	}
+	points := make([][]float64, 2*n+1)
+	points[0] = append([]float64(nil), mean...)
+	for j := 0; j < n; j++ {
+		plus := make([]float64, n)
+		minus := make([]float64, n)
+		for i := 0; i < n; i++ {
+			plus[i] = mean[i] + L.At(i, j)
+			minus[i] = mean[i] - L.At(i, j)
+		}
+		points[j+1] = plus
+		points[n+j+1] = minus
+	}
+	return points, nil
+}
+
+// Transform returns the mean and covariance of the provided distribution mapped through f.
+func (u UnscentedTransform) Transform(mean []float64, P mat64.Symmetric, f func(x []float64) []float64) ([]float64, *mat64.SymDense, error) {
+	points, err := u.SigmaPoints(mean, P)
+	if err != nil {
+		return nil, nil, err
+	}
+	mapped := make([][]float64, len(points))
+	for k, x := range points {
+		mapped[k] = f(x)
+	}
+	μ, cov := u.combine(mapped)
+	return μ, cov, nil
+}
+
+// combine returns the weighted mean and covariance of the mapped sigma points.
+func (u UnscentedTransform) combine(mapped [][]float64) ([]float64, *mat64.SymDense) {
+	_, wm, wc := u.weights((len(mapped) - 1) / 2)
+	m := len(mapped[0])
+	μ := make([]float64, m)
+	for k, y := range mapped {
+		for i := 0; i < m; i++ {
+			μ[i] += wm[k] * y[i]
+		}
+	}
+	cov := mat64.NewSymDense(m, nil)
+	for k, y := range mapped {
+		for i := 0; i < m; i++ {
+			for j := i; j < m; j++ {
+				cov.SetSym(i, j, cov.At(i, j)+wc[k]*(y[i]-μ[i])*(y[j]-μ[j]))
+			}
+		}
+	}
+	return μ, cov
+}
+
+// PropagateCovariance maps the Cartesian covariance P (6×6, in km and km/s) of the orbit at the start epoch over
+// the provided span through the full dynamics, by propagating each sigma point with the perturbations. It returns
+// the mean orbit and its covariance at the end of the span.
+func (u UnscentedTransform) PropagateCovariance(o Orbit, P mat64.Symmetric, start time.Time, span time.Duration, perts Perturbations, step time.Duration) (*Orbit, *mat64.SymDense, error) {
+	R, V := o.RV()
+	points, err := u.SigmaPoints(append(append([]float64(nil), R...), V...), P)
+	if err != nil {
+		return nil, nil, err
+	}
+	mapped := make([][]float64, len(points))
+	var propWG sync.WaitGroup
+	for k, x := range points {
+		propWG.Add(1)
+		go func(k int, x []float64) {
+			defer propWG.Done()
+			orbit := NewOrbitFromRV(x[0:3], x[3:6], o.Origin)
+			NewPreciseMission(NewEmptySC(fmt.Sprintf("σ%d", k), 0), orbit, start, start.Add(span), perts, step, false, ExportConfig{}).Propagate()
+			Rf, Vf := orbit.RV()
+			mapped[k] = append(append([]float64(nil), Rf...), Vf...)
+		}(k, x)
+	}
+	propWG.Wait()
+	μ, cov := u.combine(mapped)
+	return NewOrbitFromRV(μ[0:3], μ[3:6], o.Origin), cov, nil
+}
+
+// choleskyLower returns the lower triangular L such that L*L' is the provided positive definite matrix.
+func choleskyLower(P mat64.Symmetric) (*mat64.Dense, error) {
+	n := P.Symmetric()
+	L := mat64.NewDense(n, n, nil)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			s := P.At(i, j)
+			for k := 0; k < j; k++ {
+				s -= L.At(i, k) * L.At(j, k)
+			}
+			if i == j {
+				if s <= 0 {
+					return nil, fmt.Errorf("matrix is not positive definite (pivot %d is %e)", i, s)
+				}
+				L.Set(i, i, math.Sqrt(s))
+			} else {
+				L.Set(i, j, s/L.At(j, j))
+			}
+		}
+	}
+	return L, nil
+}
@@ -0,0 +1,84 @@
+package smd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gonum/floats"
+	"github.com/gonum/matrix/mat64"
+)
+
+func TestUnscentedTransformLinear(t *testing.T) {
+	u := NewUnscentedTransform()
+	P := mat64.NewSymDense(3, []float64{4, 1, 0, 1, 2, 0.5, 0, 0.5, 1})
+	A := mat64.NewDense(2, 3, []float64{1, 2, 3, -1, 0, 2})
+	mean, cov, err := u.Transform([]float64{1, 2, 3}, P, func(x []float64) []float64 {
+		y := mat64.NewVector(2, nil)
+		y.MulVec(A, mat64.NewVector(3, x))
+		return []float64{y.At(0, 0) + 1, y.At(1, 0)}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A linear mapping is exact.
+	if !floats.EqualApprox(mean, []float64{15, 5}, 1e-12) {
+		t.Fatalf("invalid mean %+v", mean)
+	}
+	if !mat64.EqualApprox(cov, rotateCovariance(A, P), 1e-12) {
+		t.Fatalf("invalid covariance %+v", mat64.Formatted(cov))
+	}
+	// Non linear mapping: the variance of x² with x~N(1, 4) is 4μ²σ²+2σ⁴ = 48.
+	_, cov, err = NewUnscentedTransform().Transform([]float64{1}, mat64.NewSymDense(1, []float64{4}), func(x []float64) []float64 {
+		return []float64{x[0] * x[0]}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !floats.EqualWithinAbs(cov.At(0, 0), 48, 1e-9) {
+		t.Fatalf("variance of x² is %f", cov.At(0, 0))
+	}
+	if _, err := u.SigmaPoints([]float64{0, 0}, mat64.NewSymDense(2, []float64{1, 2, 2, 1})); err == nil {
+		t.Fatal("expected an error for a covariance which is not positive definite")
+	}
+	assertPanic(t, func() {
+		u.SigmaPoints([]float64{0, 0}, P)
+	})
+}
+
+func TestUnscentedPropagateCovariance(t *testing.T) {
+	start := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	span := 20 * time.Minute
+	o := NewOrbitFromOE(7000, 0.01, 30, 10, 20, 0, Earth)
+	P := mat64.NewSymDense(6, nil)
+	for i := 0; i < 3; i++ {
+		P.SetSym(i, i, 1e-6)
+		P.SetSym(i+3, i+3, 1e-12)
+	}
+	mean, Pf, err := NewUnscentedTransform().PropagateCovariance(*o, P, start, span, Perturbations{}, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Compare with the STM mapping, which is accurate for such a small covariance.
+	sc := NewEmptySC("stm", 0)
+	m := NewPreciseMission(sc, NewOrbitFromOE(7000, 0.01, 30, 10, 20, 0, Earth), start, start.Add(span), Perturbations{}, 10*time.Second, true, ExportConfig{})
+	stateChan := make(chan State, 10)
+	m.RegisterStateChan(stateChan)
+	go m.Propagate()
+	Φ := DenseIdentity(6)
+	var final State
+	for state := range stateChan {
+		var Φk mat64.Dense
+		Φk.Mul(state.Φ, Φ)
+		Φ = &Φk
+		final = state
+	}
+	if !floats.EqualApprox(mean.R(), final.Orbit.R(), 1e-6) {
+		t.Fatalf("mean R=%+v instead of %+v", mean.R(), final.Orbit.R())
+	}
+	Plin := rotateCovariance(Φ, P)
+	for i := 0; i < 6; i++ {
+		if !floats.EqualWithinRel(Pf.At(i, i), Plin.At(i, i), 1e-3) {
+			t.Fatalf("P[%d,%d]=%e instead of %e", i, i, Pf.At(i, i), Plin.At(i, i))
+		}
+	}
+}
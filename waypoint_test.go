@@ -8,7 +8,7 @@ import (
 )
 
 func TestLoiter(t *testing.T) {
-	action := &WaypointAction{ADDCARGO, nil}
+	action := &WaypointAction{Type: ADDCARGO, Cargo: nil}
 	wp := NewLoiter(time.Duration(1)*time.Minute, action)
 	if wp.Cleared() {
 		t.Fatal("Waypoint was cleared at creation.")
@@ -52,6 +52,40 @@ func TestLoiter(t *testing.T) {
 	}
 }
 
+func TestFiniteBurn(t *testing.T) {
+	action := &WaypointAction{Type: ADDCARGO, Cargo: nil}
+	start := time.Unix(0, 0).Add(time.Minute)
+	wp := NewFiniteBurn(start, 2*time.Minute, AntiTangential{}, action)
+	if wp.Cleared() {
+		t.Fatal("Waypoint was cleared at creation.")
+	}
+	o := *NewOrbitFromRV([]float64{7000, 0, 0}, []float64{0, 7.5, 0}, Earth)
+	ctrl, reached := wp.ThrustDirection(o, time.Unix(0, 0))
+	if reached || Norm(ctrl.Control(o)) != 0 {
+		t.Fatal("finite burn thrusted before its start")
+	}
+	ctrl, reached = wp.ThrustDirection(o, start.Add(time.Minute))
+	if reached {
+		t.Fatal("finite burn was reached too early")
+	}
+	if !floats.EqualApprox(ctrl.Control(o), []float64{0, -1, 0}, 1e-12) {
+		t.Fatalf("finite burn did not thrust anti-tangentially: %+v", ctrl.Control(o))
+	}
+	if wp.Action() != nil {
+		t.Fatal("finite burn returned an action before being reached")
+	}
+	ctrl, reached = wp.ThrustDirection(o, start.Add(2*time.Minute))
+	if !reached || !wp.Cleared() || Norm(ctrl.Control(o)) != 0 {
+		t.Fatal("finite burn was not cleared at its end")
+	}
+	if wp.Action() == nil {
+		t.Fatal("finite burn did not return any action after being reached")
+	}
+	if len(wp.String()) == 0 {
+		t.Fatal("finite burn string is empty")
+	}
+}
+
 func TestHohmannΔv(t *testing.T) {
 	target := *NewOrbitFromOE(Earth.Radius+35781.34857, 0, 0, 0, 0, 90, Earth)
 	oscul := *NewOrbitFromOE(Earth.Radius+191.34411, 0, 0, 0, 0, 90, Earth)
@@ -154,6 +188,80 @@ func TestHohmannΔv(t *testing.T) {
 	}
 }
 
+func TestTwoBurnTransfer(t *testing.T) {
+	start := time.Date(2017, 1, 20, 12, 13, 14, 0, time.UTC)
+	for _, tc := range []struct {
+		oInit, oTarget *Orbit
+		Δv1, Δv2       float64
+		step           time.Duration
+	}{
+		// Same as TestHohmannΔv but starting a quarter of an orbit before the departure.
+		{NewOrbitFromOE(Earth.Radius+191.34411, 0, 0, 0, 0, 90, Earth), NewOrbitFromOE(Earth.Radius+35781.34857, 0, 0, 0, 0, 90, Earth), 2.457038, 1.478187, StepSize},
+		// Idem with a step of the mission other than the default one.
+		{NewOrbitFromOE(Earth.Radius+191.34411, 0, 0, 0, 0, 90, Earth), NewOrbitFromOE(Earth.Radius+35781.34857, 0, 0, 0, 0, 90, Earth), 2.457038, 1.478187, 45 * time.Second},
+		// Lowering from an elliptical orbit waits for its apoapsis.
+		{NewOrbitFromOE(12000, 0.2, 0, 0, 0, 10, Earth), NewOrbitFromOE(8000, 0, 0, 0, 0, 0, Earth), 0, 0, StepSize},
+	} {
+		wp := NewTwoBurnTransfer(*tc.oTarget, nil)
+		sc := NewSpacecraft("2B", 500, 0, NewUnlimitedEPS(), []EPThruster{}, true, []*Cargo{}, []Waypoint{wp})
+		astro := NewPreciseMission(sc, tc.oInit, start, start.Add(36*time.Hour), Perturbations{}, tc.step, false, ExportConfig{})
+		astro.Propagate()
+		if !wp.Cleared() {
+			t.Fatalf("transfer to %s not completed", tc.oTarget)
+		}
+		if tc.step != StepSize && wp.arrivalDT.Sub(start)%tc.step == 0 {
+			t.Fatalf("arrival at %s on the grid of the mission: the test is not representative", wp.arrivalDT)
+		}
+		aTgt, _, _, _, _, _, _, _, _ := tc.oTarget.Elements()
+		a, e, _, _, _, _, _, _, _ := astro.Orbit.Elements()
+		if !floats.EqualWithinAbs(a, aTgt, 1e-3*aTgt) || e > 1e-3 {
+			t.Fatalf("step %s: final orbit %s instead of %s", tc.step, astro.Orbit, tc.oTarget)
+		}
+		if tc.Δv1 > 0 && (!floats.EqualWithinAbs(Norm(wp.Δv1), tc.Δv1, 1e-3) || !floats.EqualWithinAbs(Norm(wp.Δv2), tc.Δv2, 1e-3)) {
+			t.Fatalf("step %s: Δv1=%+v Δv2=%+v", tc.step, wp.Δv1, wp.Δv2)
+		}
+		if wp.Δv1[1]*wp.Δv2[1] <= 0 {
+			t.Fatalf("burns in opposite directions: Δv1=%+v Δv2=%+v", wp.Δv1, wp.Δv2)
+		}
+	}
+}
+
+func TestSpiralToBody(t *testing.T) {
+	dt := time.Date(2020, 7, 20, 0, 0, 0, 0, time.UTC)
+	wp := NewSpiralToBody(Mars, 10000, &WaypointAction{Type: REFMARS})
+	wp.Ephemeris = hohmannEphemeris(dt)
+	rMars, _ := wp.Ephemeris(Mars, dt)
+	for _, tc := range []struct {
+		o       *Orbit
+		law     ControlLaw
+		cleared bool
+	}{
+		{NewOrbitFromOE(Earth.Radius+400, 0, 0, 0, 0, 0, Earth), tangential, false},
+		{NewOrbitFromOE(AU, 0, 0, 0, 0, 0, Sun), tangential, false},
+		{NewOrbitFromOE(2*AU, 0, 0, 0, 0, 0, Sun), antiTangential, false},
+		{NewOrbitFromOE(Norm(rMars), 0, 0, 0, 0, 0, Sun), coast, false},
+		{NewOrbitFromOE(50000, 0.5, 0, 0, 0, 0, Mars), antiTangential, false},
+		{NewOrbitFromOE(9000, 0, 0, 0, 0, 0, Mars), coast, true},
+	} {
+		ctrl, cleared := wp.ThrustDirection(*tc.o, dt)
+		if ctrl.Type() != tc.law || cleared != tc.cleared {
+			t.Fatalf("%s: got %s (cleared=%v)", tc.o, ctrl.Type(), cleared)
+		}
+	}
+	if wp.Action() != nil {
+		t.Fatal("the reference change action should be dropped")
+	}
+	if len(wp.String()) == 0 {
+		t.Fatal("spiral string is empty")
+	}
+	assertPanic(t, func() {
+		NewSpiralToBody(Sun, 0, nil)
+	})
+	assertPanic(t, func() {
+		NewSpiralToBody(Mars, 2*Mars.SOI, nil)
+	})
+}
+
 func TestToElliptical(t *testing.T) {
 	// Example action
 	ref2Mars := WaypointAction{Type: REFMARS, Cargo: nil}
@@ -2,6 +2,7 @@ package smd
 
 import (
 	"fmt"
+	"math"
 	"time"
 )
 
@@ -19,12 +20,15 @@ const (
 	REFMARS
 	//REFSUN switches the orbit reference to the Sun
 	REFSUN
+	// IMPULSE adds an instantaneous Δv to the velocity of the spacecraft
+	IMPULSE
 )
 
 // WaypointAction defines what happens when a given waypoint is reached.
 type WaypointAction struct {
-	Type  WaypointActionEnum
-	Cargo *Cargo
+	Type    WaypointActionEnum
+	Cargo   *Cargo
+	Impulse []float64 // Δv of an IMPULSE in the RIC frame, in km/s
 }
 
 // Waypoint defines the Waypoint interface.
@@ -42,7 +46,7 @@ func NewOutwardSpiral(body CelestialObject, action *WaypointAction) *ReachDistan
 		// will crash if there are multiple attempts to switch to another
 		action = nil
 	}
-	return &ReachDistance{body.SOI, action, true, false}
+	return &ReachDistance{body.SOI, action, true, false, 0, time.Time{}}
 }
 
 // Loiter is a type of waypoint which allows the vehicle to stay at a given position for a given duration.
@@ -95,11 +99,57 @@ func NewLoiter(duration time.Duration, action *WaypointAction) *Loiter {
 	return &Loiter{duration, time.Unix(0, 0), time.Unix(0, 0), false, action, false}
 }
 
+// FiniteBurn is a type of waypoint which thrusts with the provided control law between two epochs, and coasts
+// before the start of the burn.
+type FiniteBurn struct {
+	startDT, endDT time.Time
+	ctrl           ThrustControl
+	action         *WaypointAction
+	cleared        bool
+}
+
+// String implements the Waypoint interface.
+func (wp *FiniteBurn) String() string {
+	return fmt.Sprintf("%s finite burn from %s for %s.", wp.ctrl.Type(), wp.startDT, wp.endDT.Sub(wp.startDT))
+}
+
+// Cleared implements the Waypoint interface.
+func (wp *FiniteBurn) Cleared() bool {
+	return wp.cleared
+}
+
+// ThrustDirection implements the Waypoint interface.
+func (wp *FiniteBurn) ThrustDirection(o Orbit, dt time.Time) (ThrustControl, bool) {
+	if dt.Before(wp.startDT) {
+		return Coast{}, false
+	}
+	if dt.Before(wp.endDT) {
+		return wp.ctrl, false
+	}
+	wp.cleared = true
+	return Coast{}, true
+}
+
+// Action implements the Waypoint interface.
+func (wp *FiniteBurn) Action() *WaypointAction {
+	if wp.cleared {
+		return wp.action
+	}
+	return nil
+}
+
+// NewFiniteBurn defines a new finite burn waypoint, i.e. "thrust from the start epoch for the given duration".
+func NewFiniteBurn(start time.Time, duration time.Duration, ctrl ThrustControl, action *WaypointAction) *FiniteBurn {
+	return &FiniteBurn{start, start.Add(duration), ctrl, action, false}
+}
+
 // ReachDistance is a type of waypoint which thrusts until a given distance is reached from the central body.
 type ReachDistance struct {
 	distance         float64
 	action           *WaypointAction
 	further, cleared bool
+	initial          float64 // distance when the waypoint became active
+	startDT          time.Time
 }
 
 // String implements the Waypoint interface.
@@ -135,19 +185,96 @@ func (wp *ReachDistance) Action() *WaypointAction {
 
 // NewReachDistance defines a new spiral until a given distance is reached.
 func NewReachDistance(distance float64, further bool, action *WaypointAction) *ReachDistance {
-	return &ReachDistance{distance, action, further, false}
+	return &ReachDistance{distance, action, further, false, 0, time.Time{}}
+}
+
+// SpiralToBody spirals from the current body to a target body. It thrusts tangentially until leaving the SOI of
+// the departure body, then heliocentrically (anti-tangentially towards an inner body) until reaching the distance
+// of the target body from the Sun, and coasts until the mission hands the orbit off to the target body upon entering
+// its SOI. The hand off requires the SOITransitions of the mission to include the target body. With a capture
+// radius, the vehicle then spirals down to that radius about the target body.
+type SpiralToBody struct {
+	Target        CelestialObject
+	CaptureRadius float64 // km, zero to clear the waypoint upon entering the SOI
+	Ephemeris     EphemerisFunc
+	action        *WaypointAction
+	cleared       bool
+}
+
+// String implements the Waypoint interface.
+func (wp *SpiralToBody) String() string {
+	if wp.CaptureRadius > 0 {
+		return fmt.Sprintf("Spiral to %s and capture at %.1f km.", wp.Target.Name, wp.CaptureRadius)
+	}
+	return fmt.Sprintf("Spiral to %s.", wp.Target.Name)
+}
+
+// Cleared implements the Waypoint interface.
+func (wp *SpiralToBody) Cleared() bool {
+	return wp.cleared
+}
+
+// ThrustDirection implements the Waypoint interface.
+func (wp *SpiralToBody) ThrustDirection(o Orbit, dt time.Time) (ThrustControl, bool) {
+	switch {
+	case o.Origin.Equals(wp.Target):
+		if o.RNorm() > wp.CaptureRadius {
+			return AntiTangential{"capture spiral"}, false
+		}
+		wp.cleared = true
+		return Coast{}, true
+	case !o.Origin.Equals(Sun):
+		return Tangential{"escape spiral"}, false
+	}
+	rBody, _ := wp.Ephemeris(wp.Target, dt)
+	r, rTarget := o.RNorm(), Norm(rBody)
+	if r < rTarget-wp.Target.SOI {
+		return Tangential{"outward spiral"}, false
+	} else if r > rTarget+wp.Target.SOI {
+		return AntiTangential{"inward spiral"}, false
+	}
+	return Coast{"awaiting SOI entry"}, false
+}
+
+// Action implements the Waypoint interface.
+func (wp *SpiralToBody) Action() *WaypointAction {
+	if wp.cleared {
+		return wp.action
+	}
+	return nil
+}
+
+// NewSpiralToBody defines a new spiral to the provided body, with the configured ephemerides. A null capture radius
+// clears the waypoint upon entering the SOI of the body.
+func NewSpiralToBody(target CelestialObject, captureRadius float64, action *WaypointAction) *SpiralToBody {
+	if target.Equals(Sun) || target.SOI <= 0 {
+		panic(fmt.Errorf("the SOI of %s is not defined", target.Name))
+	}
+	if captureRadius < 0 || captureRadius > target.SOI {
+		panic(fmt.Errorf("capture radius of %f km outside the SOI of %s", captureRadius, target.Name))
+	}
+	if action != nil && action.Type >= REFEARTH && action.Type <= REFSUN {
+		// The SOI transitions of the mission already switch the orbit reference.
+		action = nil
+	}
+	return &SpiralToBody{target, captureRadius, HelioEphemeris, action, false}
 }
 
 // OrbitTarget allows to target an orbit.
 type OrbitTarget struct {
-	target  Orbit
-	ctrl    *OptimalΔOrbit
-	action  *WaypointAction
-	cleared bool
+	target    Orbit
+	ctrl      *OptimalΔOrbit
+	action    *WaypointAction
+	cleared   bool
+	startDT   time.Time
+	ephemeris TargetEphemeris // Moving target, nil for a fixed target
 }
 
 // String implements the Waypoint interface.
 func (wp *OrbitTarget) String() string {
+	if wp.ephemeris != nil {
+		return fmt.Sprintf("targeting moving orbit")
+	}
 	return fmt.Sprintf("targeting orbit")
 }
 
@@ -166,6 +293,14 @@ func (wp *OrbitTarget) Action() *WaypointAction {
 
 // ThrustDirection implements the optimal orbit target.
 func (wp *OrbitTarget) ThrustDirection(o Orbit, dt time.Time) (ThrustControl, bool) {
+	if wp.ephemeris != nil {
+		// Re-evaluate the moving target at each step.
+		wp.target = wp.ephemeris(dt)
+		if !wp.target.Origin.Equals(o.Origin) {
+			wp.target.ToXCentric(o.Origin, dt)
+		}
+		wp.ctrl.SetTarget(wp.target)
+	}
 	if ok, err := wp.target.Equals(o); ok {
 		wp.cleared = true
 	} else if wp.ctrl.cleared {
@@ -175,12 +310,35 @@ func (wp *OrbitTarget) ThrustDirection(o Orbit, dt time.Time) (ThrustControl, bo
 	return wp.ctrl, wp.cleared
 }
 
-// NewOrbitTarget defines a new orbit target.
-func NewOrbitTarget(target Orbit, action *WaypointAction, meth ControlLawType, laws ...ControlLaw) *OrbitTarget {
+// SetEffectivity sets the minimum effectivity, between zero and one, at which a FuelOptimal target thrusts.
+func (wp *OrbitTarget) SetEffectivity(η float64) {
+	if η <= 0 || η > 1 {
+		panic(fmt.Errorf("effectivity must be in ]0;1], got %f", η))
+	}
+	wp.ctrl.Effectivity = η
+}
+
+// NewOrbitTarget defines a new orbit target. In TimeOptimal mode, the spacecraft always thrusts, whereas in
+// FuelOptimal mode it coasts when the effectivity of the control is below the threshold (cf. SetEffectivity).
+func NewOrbitTarget(target Orbit, action *WaypointAction, meth ControlLawType, mode TargetingMode, laws ...ControlLaw) *OrbitTarget {
 	if target.Periapsis() < target.Origin.Radius || target.Apoapsis() < target.Origin.Radius {
 		fmt.Printf("[WARNING] Target orbit on collision course with %s\n", target.Origin)
 	}
-	return &OrbitTarget{target, NewOptimalΔOrbit(target, meth, laws), action, false}
+	ctrl := NewOptimalΔOrbit(target, meth, laws)
+	ctrl.Mode = mode
+	return &OrbitTarget{target, ctrl, action, false, time.Time{}, nil}
+}
+
+// NewEphemerisTarget defines a new orbit target which tracks a moving target, e.g. a planet, a comet or another
+// spacecraft: the target orbit is re-evaluated from the ephemeris at each step, and converted to the center of the
+// spacecraft with the configured ephemerides if needed. Unless provided, the control laws are selected on the first
+// step from the elements which differ from the target, so they should be provided if other elements of the target
+// drift later on.
+func NewEphemerisTarget(ephemeris TargetEphemeris, dt time.Time, action *WaypointAction, meth ControlLawType, mode TargetingMode, laws ...ControlLaw) *OrbitTarget {
+	target := ephemeris(dt)
+	ctrl := NewOptimalΔOrbit(target, meth, laws)
+	ctrl.Mode = mode
+	return &OrbitTarget{target, ctrl, action, false, time.Time{}, ephemeris}
 }
 
 // HohmannTransfer allows to perform an Hohmann transfer.
@@ -249,6 +407,181 @@ func NewHohmannTransfer(target Orbit, action *WaypointAction) *HohmannTransfer {
 	return &HohmannTransfer{action, NewHohmannΔv(target), epoch, false}
 }
 
+// circularε is the eccentricity below which a two burn transfer departs immediately.
+const circularε = 1e-3
+
+// stepTracker measures the step of the mission from the epochs of the calls to ThrustDirection, for the waypoints
+// whose IMPULSE actions are executed at the end of the current step. Since ThrustDirection is called at each stage of
+// the integrator, the guidance only runs once per step, and starts on the second step of the waypoint, once the step
+// size is known.
+type stepTracker struct {
+	prevDT time.Time
+	step   time.Duration
+}
+
+// tick returns whether the guidance should run at this epoch, i.e. once per step after the first one.
+func (s *stepTracker) tick(dt time.Time) bool {
+	if !s.prevDT.IsZero() {
+		if !dt.After(s.prevDT) {
+			return false
+		}
+		s.step = dt.Sub(s.prevDT)
+	}
+	s.prevDT = dt
+	return s.step > 0
+}
+
+// TwoBurnTransfer performs an impulsive two burn transfer to a coplanar orbit, which may be elliptical. It coasts
+// until the departure apsis (periapsis when raising the orbit, apoapsis when lowering it, or immediately from a
+// circular orbit), burns onto the transfer orbit, coasts for half of it, and matches the velocity of the target orbit
+// on arrival. Unlike HohmannTransfer, the burns are instantaneous IMPULSE actions rather than thrusting.
+type TwoBurnTransfer struct {
+	target    Orbit
+	action    *WaypointAction
+	status    hohmannStatus
+	burn      *WaypointAction
+	rArrival  float64
+	Δv1, Δv2  []float64 // Burns in the RIC frame (km/s)
+	arrivalDT time.Time
+	cleared   bool
+	stepTracker
+}
+
+// String implements the Waypoint interface.
+func (wp *TwoBurnTransfer) String() string {
+	return "two burn transfer"
+}
+
+// Cleared implements the Waypoint interface.
+func (wp *TwoBurnTransfer) Cleared() bool {
+	return wp.cleared
+}
+
+// Action implements the Waypoint interface. It returns the pending burn, or the action of the waypoint once the
+// second burn is performed.
+func (wp *TwoBurnTransfer) Action() *WaypointAction {
+	if wp.burn != nil {
+		burn := wp.burn
+		wp.burn = nil
+		return burn
+	}
+	if wp.cleared {
+		return wp.action
+	}
+	return nil
+}
+
+// ThrustDirection implements the Waypoint interface. The burns are executed at the end of the current step, so
+// they are triggered and computed one step of the mission ahead.
+func (wp *TwoBurnTransfer) ThrustDirection(o Orbit, dt time.Time) (ThrustControl, bool) {
+	coast := Coast{"two burn transfer"}
+	if !wp.tick(dt) {
+		return coast, wp.cleared
+	}
+	switch wp.status {
+	case hohmannCompute:
+		if toDeparture := wp.timeToDeparture(o); toDeparture > wp.step {
+			return coast, false
+		}
+		o = *keplerPropagate(o, wp.step)
+		μ := o.Origin.μ
+		r1 := o.RNorm()
+		aTransfer := (r1 + wp.rArrival) / 2
+		vr, vθ := o.ricVelocity()
+		wp.Δv1 = []float64{-vr, math.Sqrt(μ*(2/r1-1/aTransfer)) - vθ, 0}
+		wp.arrivalDT = dt.Add(wp.step).Add(time.Duration(math.Pi * math.Sqrt(math.Pow(aTransfer, 3)/μ) * float64(time.Second)))
+		wp.burn = &WaypointAction{Type: IMPULSE, Impulse: wp.Δv1}
+		wp.status = hohmmanCoast
+		return coast, true
+	case hohmmanCoast:
+		if dt.Add(wp.step).Before(wp.arrivalDT) {
+			return coast, false
+		}
+		// Match the velocity of the target orbit at the arrival direction.
+		o = *keplerPropagate(o, wp.step)
+		eTgt := Norm(wp.target.eccentricityVector())
+		νArrival := wp.target.trueAnomalyOf(o.R())
+		μh := wp.target.Origin.μ / wp.target.HNorm()
+		vr, vθ := o.ricVelocity()
+		wp.Δv2 = []float64{μh*eTgt*math.Sin(νArrival) - vr, μh*(1+eTgt*math.Cos(νArrival)) - vθ, 0}
+		wp.burn = &WaypointAction{Type: IMPULSE, Impulse: wp.Δv2}
+		wp.status = hohmmanCompleted
+		wp.cleared = true
+		return coast, true
+	}
+	return coast, wp.cleared
+}
+
+// timeToDeparture returns the time until the departure apsis of the provided orbit, and sets the arrival radius.
+// The anomalies are computed from the eccentricity vector since the elements are ill-defined on circular orbits.
+func (wp *TwoBurnTransfer) timeToDeparture(o Orbit) time.Duration {
+	a, _, _, _, _, _, _, _, _ := o.Elements()
+	aTgt, _, _, _, _, _, _, _, _ := wp.target.Elements()
+	e := Norm(o.eccentricityVector())
+	ν := o.trueAnomalyOf(o.R())
+	νDeparture := 0.0
+	if e < circularε {
+		νDeparture = ν
+	} else if aTgt < a {
+		νDeparture = math.Pi
+	}
+	// The arrival is opposite to the departure.
+	sν, cν := math.Sincos(νDeparture - ν)
+	Rdep := MxV33(o.RICDCM().T(), []float64{cν, sν, 0})
+	νArrival := wp.target.trueAnomalyOf([]float64{-Rdep[0], -Rdep[1], -Rdep[2]})
+	wp.rArrival = wp.target.SemiParameter() / (1 + Norm(wp.target.eccentricityVector())*math.Cos(νArrival))
+	ΔM := math.Mod(ellipticMeanAnomaly(e, νDeparture)-ellipticMeanAnomaly(e, ν), 2*math.Pi)
+	if ΔM < 0 {
+		ΔM += 2 * math.Pi
+	}
+	return time.Duration(ΔM / (2 * math.Pi) * float64(o.Period()))
+}
+
+// ricVelocity returns the radial and in-track components of the velocity.
+func (o Orbit) ricVelocity() (vr, vθ float64) {
+	V := MxV33(o.RICDCM(), o.V())
+	return V[0], V[1]
+}
+
+// eccentricityVector returns the eccentricity vector, which points to the periapsis.
+func (o Orbit) eccentricityVector() []float64 {
+	μ := o.Origin.μ
+	R, V := o.RV()
+	r, v2, rv := Norm(R), Dot(V, V), Dot(R, V)
+	eVec := make([]float64, 3)
+	for i := 0; i < 3; i++ {
+		eVec[i] = ((v2-μ/r)*R[i] - rv*V[i]) / μ
+	}
+	return eVec
+}
+
+// trueAnomalyOf returns the true anomaly of the provided direction in the plane of the orbit.
+func (o Orbit) trueAnomalyOf(R []float64) float64 {
+	eHat := Unit(o.eccentricityVector())
+	if Norm(eHat) == 0 {
+		// Circular orbit: measure from the current position instead.
+		eHat = Unit(o.R())
+	}
+	return math.Atan2(Dot(Unit(o.H()), Cross(eHat, R)), Dot(eHat, R))
+}
+
+// ellipticMeanAnomaly returns the mean anomaly of the provided true anomaly on an elliptical orbit.
+func ellipticMeanAnomaly(e, ν float64) float64 {
+	E := 2 * math.Atan(math.Sqrt((1-e)/(1+e))*math.Tan(ν/2))
+	return E - e*math.Sin(E)
+}
+
+// NewTwoBurnTransfer defines a new impulsive two burn transfer to the provided coplanar orbit.
+func NewTwoBurnTransfer(target Orbit, action *WaypointAction) *TwoBurnTransfer {
+	if target.Periapsis() < target.Origin.Radius {
+		fmt.Printf("[WARNING] Target orbit on collision course with %s\n", target.Origin)
+	}
+	if _, e, _, _, _, _, _, _, _ := target.Elements(); e >= 1 {
+		panic(fmt.Errorf("cannot perform a two burn transfer to a hyperbolic orbit"))
+	}
+	return &TwoBurnTransfer{target: target, action: action, status: hohmannCompute}
+}
+
 // ToElliptical decelerates the vehicle until its orbit is elliptical.
 type ToElliptical struct {
 	action  *WaypointAction